@@ -5,6 +5,9 @@ go 1.23.1
 require (
 	github.com/mmcdole/gofeed v1.3.0
 	github.com/tidwall/gjson v1.18.0
+	github.com/yuin/gopher-lua v1.1.1
+	golang.org/x/crypto v0.27.0
+	golang.org/x/net v0.29.0
 	golang.org/x/text v0.18.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -18,5 +21,4 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
-	golang.org/x/net v0.29.0 // indirect
 )
@@ -0,0 +1,16 @@
+// The snapshot goes through the widget since cameras usually can't be reached from the
+// browser, the time it was captured at is part of the URL so that it's cached until then
+function setupCamera(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+
+    element.addEventListener("error", () => element.remove(), { once: true });
+    element.src = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/snapshot?t=${element.dataset.cameraSnapshot}`;
+}
+
+export function setupCameras(root = document) {
+    const elements = root.querySelectorAll("[data-camera-snapshot]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupCamera(elements[i]);
+    }
+}
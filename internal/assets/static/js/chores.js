@@ -0,0 +1,46 @@
+const errorIndicatorMs = 3000;
+
+// The checkbox changes right away and goes back if saving fails
+function setupChores(element) {
+    const widgetID = element.closest("[data-widget-id]").dataset.widgetId;
+    const doneURL = `${pageData.baseURL}/api/widgets/${widgetID}/done`;
+
+    let errorTimeout = null;
+
+    element.addEventListener("change", async (event) => {
+        const checkbox = event.target.closest(".chores-checkbox");
+
+        if (checkbox === null) {
+            return;
+        }
+
+        const done = checkbox.checked;
+        checkbox.nextElementSibling.classList.toggle("chores-done", done);
+
+        try {
+            const response = await fetch(doneURL, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ chore: checkbox.dataset.chore, done }),
+            });
+
+            if (response.ok) {
+                return;
+            }
+        } catch {}
+
+        checkbox.checked = !done;
+        checkbox.nextElementSibling.classList.toggle("chores-done", !done);
+        element.classList.add("chores-error");
+        clearTimeout(errorTimeout);
+        errorTimeout = setTimeout(() => element.classList.remove("chores-error"), errorIndicatorMs);
+    });
+}
+
+export function setupChoreLists(root = document) {
+    const elements = root.querySelectorAll(".chores");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupChores(elements[i]);
+    }
+}
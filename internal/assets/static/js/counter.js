@@ -0,0 +1,51 @@
+const errorIndicatorMs = 3000;
+
+// The count shown is always the one the server responds with, which
+// is also what other widgets with the same storage key will show
+function setupCounter(element) {
+    const widgetID = element.closest("[data-widget-id]").dataset.widgetId;
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetID}`;
+    const valueElement = element.querySelector(".counter-value");
+    const barElement = element.querySelector(".counter-bar");
+    const goal = Number(element.dataset.goal);
+
+    let errorTimeout = null;
+
+    const show = (value) => {
+        valueElement.textContent = value;
+
+        if (barElement !== null) {
+            barElement.firstElementChild.style.width = `${Math.min(value / goal * 100, 100)}%`;
+            barElement.classList.toggle("counter-goal-reached", value >= goal);
+        }
+    };
+
+    element.addEventListener("click", async (event) => {
+        const button = event.target.closest("[data-counter-action]");
+
+        if (button === null) {
+            return;
+        }
+
+        try {
+            const response = await fetch(`${baseURL}/${button.dataset.counterAction}`, { method: "POST" });
+
+            if (response.ok) {
+                show((await response.json()).value);
+                return;
+            }
+        } catch {}
+
+        element.classList.add("counter-error");
+        clearTimeout(errorTimeout);
+        errorTimeout = setTimeout(() => element.classList.remove("counter-error"), errorIndicatorMs);
+    });
+}
+
+export function setupCounters(root = document) {
+    const elements = root.querySelectorAll(".counter");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupCounter(elements[i]);
+    }
+}
@@ -0,0 +1,15 @@
+// Thumbnails go through the widget since Frigate usually can't be reached from the browser
+function setupFrigateThumbnail(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+
+    element.addEventListener("error", () => element.remove(), { once: true });
+    element.src = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/thumbnail/${element.dataset.frigateThumbnail}`;
+}
+
+export function setupFrigateThumbnails(root = document) {
+    const elements = root.querySelectorAll("[data-frigate-thumbnail]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupFrigateThumbnail(elements[i]);
+    }
+}
@@ -0,0 +1,100 @@
+const recentLocationsKey = "recent-locations";
+const maxRecentLocations = 5;
+
+function recentLocations() {
+    try {
+        return JSON.parse(localStorage.getItem(recentLocationsKey)) || [];
+    } catch {
+        return [];
+    }
+}
+
+function rememberLocation(name) {
+    const recent = recentLocations().filter((location) => location !== name);
+    recent.unshift(name);
+    localStorage.setItem(recentLocationsKey, JSON.stringify(recent.slice(0, maxRecentLocations)));
+}
+
+// The picked location is kept in a cookie by the server, the recently picked ones are
+// kept in the browser so that switching between a few places only takes a click
+function setupLocationPicker(element) {
+    const toggleElement = element.querySelector(".location-picker-toggle");
+    const formElement = element.querySelector(".location-picker-form");
+    const inputElement = element.querySelector(".location-picker-input");
+    const recentElement = element.querySelector(".location-picker-recent");
+    const resetElement = element.querySelector(".location-picker-reset");
+    const errorElement = element.querySelector(".location-picker-error");
+    const locationURL = `${pageData.baseURL}/api/location`;
+
+    const send = async (request) => {
+        errorElement.textContent = "";
+        formElement.classList.add("location-picker-busy");
+
+        try {
+            const response = await fetch(locationURL, request);
+
+            if (!response.ok) {
+                errorElement.textContent = (await response.text()).trim();
+                return;
+            }
+
+            if (request.method === "POST") {
+                const location = await response.json();
+                rememberLocation(location.country ? `${location.name}, ${location.country}` : location.name);
+            }
+
+            window.location.reload();
+        } catch {
+            errorElement.textContent = "Could not change the location";
+        } finally {
+            formElement.classList.remove("location-picker-busy");
+        }
+    };
+
+    const pick = (name) => send({ method: "POST", body: new URLSearchParams({ location: name }) });
+
+    const open = () => {
+        recentElement.replaceChildren(...recentLocations().map((name) => {
+            const button = document.createElement("button");
+            button.type = "button";
+            button.classList.add("location-picker-recent-item");
+            button.textContent = name;
+            button.addEventListener("click", () => pick(name));
+
+            return button;
+        }));
+
+        toggleElement.hidden = true;
+        formElement.hidden = false;
+        inputElement.focus();
+    };
+
+    const close = () => {
+        formElement.hidden = true;
+        toggleElement.hidden = false;
+        errorElement.textContent = "";
+    };
+
+    toggleElement.addEventListener("click", open);
+    resetElement.addEventListener("click", () => send({ method: "DELETE" }));
+
+    formElement.addEventListener("submit", (event) => {
+        event.preventDefault();
+        pick(inputElement.value.trim());
+    });
+
+    formElement.addEventListener("keydown", (event) => {
+        if (event.key === "Escape") {
+            close();
+            toggleElement.focus();
+        }
+    });
+}
+
+export function setupLocationPickers(root = document) {
+    const elements = root.querySelectorAll(".location-picker");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupLocationPicker(elements[i]);
+    }
+}
@@ -1,18 +1,68 @@
 import { setupPopovers } from './popover.js';
 import { setupMasonries } from './masonry.js';
+import { setupToDos } from './to-do.js';
+import { setupLocationPickers } from './location-picker.js';
+import { setupWatchlists } from './watchlist.js';
+import { setupTimeTrackings } from './time-tracking.js';
+import { setupPomodoros } from './pomodoro.js';
+import { setupCounters } from './counter.js';
+import { setupQuickLogs } from './quick-log.js';
+import { setupChoreLists } from './chores.js';
+import { setupPrinters } from './printer.js';
+import { setupCameras } from './camera.js';
+import { setupFrigateThumbnails } from './frigate.js';
+import { setupThermostats } from './thermostat.js';
+import { setupReminderLists } from './reminders.js';
+import { setupMediaPosters } from './media-releases.js';
+import { setupWikipediaArticles } from './wikipedia.js';
+import { setupMemoryPhotos } from './on-this-day.js';
 import { throttledDebounce, isElementVisible } from './utils.js';
 
+// Lets the server skip widgets that are hidden on the current layout,
+// uses the same breakpoint as the one in the styles
+function currentLayout() {
+    return window.matchMedia("(max-width: 1190px)").matches ? "mobile" : "desktop";
+}
+
+// Preview styles and the profile in the URL of the page get forwarded so
+// that they apply to both the initial content and any live updates
+function pageContentQuery() {
+    const query = new URLSearchParams({ layout: currentLayout() });
+    const pageQuery = new URLSearchParams(window.location.search);
+
+    for (const style of pageQuery.getAll("preview-style")) {
+        query.append("preview-style", style);
+    }
+
+    if (pageQuery.has("profile")) {
+        query.set("profile", pageQuery.get("profile"));
+    }
+
+    return query.toString();
+}
+
+// The time of the server when the content of the page was last rendered,
+// used to only get the widgets that changed since then when the page wakes up
+let pageRenderedAt = 0;
+
+// The widgets which were rendered as placeholders or with outdated data
+// when the page loads its widgets async, filled in through the updates stream
+let pendingWidgetIDs = [];
+
 async function fetchPageContent(pageData) {
     // TODO: handle non 200 status codes/time outs
     // TODO: add retries
-    const response = await fetch(`${pageData.baseURL}/api/pages/${pageData.slug}/content/`);
+    const response = await fetch(`${pageData.baseURL}/api/pages/${pageData.slug}/content/?${pageContentQuery()}`);
     const content = await response.text();
 
+    pageRenderedAt = Number(response.headers.get("X-Rendered-At")) || Date.now();
+    pendingWidgetIDs = (response.headers.get("X-Pending-Widgets") || "").split(",").filter((id) => id !== "");
+
     return content;
 }
 
-function setupCarousels() {
-    const carouselElements = document.getElementsByClassName("carousel-container");
+function setupCarousels(root = document) {
+    const carouselElements = root.getElementsByClassName("carousel-container");
 
     if (carouselElements.length == 0) {
         return;
@@ -175,8 +225,13 @@ function setupSearchBoxes() {
             document.removeEventListener("input", handleInput);
         });
 
+        // the search itself happens when pressing enter, the form is only there for semantics
+        widget.addEventListener("submit", (event) => event.preventDefault());
+
         document.addEventListener("keydown", (event) => {
-            if (['INPUT', 'TEXTAREA'].includes(document.activeElement.tagName)) return;
+            if (['INPUT', 'TEXTAREA', 'SELECT'].includes(document.activeElement.tagName)) return;
+            if (document.activeElement.isContentEditable) return;
+            if (event.ctrlKey || event.metaKey || event.altKey) return;
             if (event.key != "s") return;
 
             inputElement.focus();
@@ -190,14 +245,15 @@ function setupSearchBoxes() {
 }
 
 function setupDynamicRelativeTime() {
-    const elements = document.querySelectorAll("[data-dynamic-relative-time]");
+    // queried every time since widgets can get replaced by live updates
+    const updateElements = () => updateRelativeTimeForElements(document.querySelectorAll("[data-dynamic-relative-time]"));
     const updateInterval = 60 * 1000;
     let lastUpdateTime = Date.now();
 
-    updateRelativeTimeForElements(elements);
+    updateElements();
 
     const updateElementsAndTimestamp = () => {
-        updateRelativeTimeForElements(elements);
+        updateElements();
         lastUpdateTime = Date.now();
     };
 
@@ -231,8 +287,48 @@ function setupDynamicRelativeTime() {
     });
 }
 
-function setupGroups() {
-    const groups = document.getElementsByClassName("widget-type-group");
+// The pills of the mobile navigation follow the column that was swiped
+// to, and picking a pill scrolls to the column that it belongs to
+function setupSwipeableColumns() {
+    const columnsElement = document.querySelector(".page-columns");
+    const inputs = document.getElementsByClassName("mobile-navigation-input");
+
+    if (columnsElement === null || inputs.length == 0) {
+        return;
+    }
+
+    const columns = columnsElement.children;
+    const columnOffset = (index) => columns[index].offsetLeft - columns[0].offsetLeft;
+
+    for (let i = 0; i < inputs.length && i < columns.length; i++) {
+        inputs[i].addEventListener("change", () => {
+            columnsElement.scrollTo({ left: columnOffset(i), behavior: "smooth" });
+        });
+
+        if (inputs[i].checked) {
+            columnsElement.scrollTo({ left: columnOffset(i), behavior: "instant" });
+        }
+    }
+
+    columnsElement.addEventListener("scroll", throttledDebounce(() => {
+        let closest = 0;
+
+        for (let i = 1; i < columns.length; i++) {
+            const distance = Math.abs(columnOffset(i) - columnsElement.scrollLeft);
+
+            if (distance < Math.abs(columnOffset(closest) - columnsElement.scrollLeft)) {
+                closest = i;
+            }
+        }
+
+        if (closest < inputs.length) {
+            inputs[closest].checked = true;
+        }
+    }, 10, 50));
+}
+
+function setupGroups(root = document) {
+    const groups = root.getElementsByClassName("widget-type-group");
 
     if (groups.length == 0) {
         return;
@@ -253,6 +349,8 @@ function setupGroups() {
 
                 for (let i = 0; i < titles.length; i++) {
                     titles[i].classList.remove("widget-group-title-current");
+                    titles[i].setAttribute("aria-selected", "false");
+                    titles[i].tabIndex = -1;
                     tabs[i].classList.remove("widget-group-content-current");
                 }
 
@@ -265,14 +363,37 @@ function setupGroups() {
                 current = t;
 
                 title.classList.add("widget-group-title-current");
+                title.setAttribute("aria-selected", "true");
+                title.tabIndex = 0;
                 tabs[t].classList.add("widget-group-content-current");
             });
+
+            // only the current tab is in the focus order, the arrow keys move between them
+            title.addEventListener("keydown", (event) => {
+                let next;
+
+                if (event.key == "ArrowRight") {
+                    next = (t + 1) % titles.length;
+                } else if (event.key == "ArrowLeft") {
+                    next = (t - 1 + titles.length) % titles.length;
+                } else if (event.key == "Home") {
+                    next = 0;
+                } else if (event.key == "End") {
+                    next = titles.length - 1;
+                } else {
+                    return;
+                }
+
+                event.preventDefault();
+                titles[next].focus();
+                titles[next].click();
+            });
         }
     }
 }
 
-function setupLazyImages() {
-    const images = document.querySelectorAll("img[loading=lazy]");
+function setupLazyImages(root = document) {
+    const images = root.querySelectorAll("img[loading=lazy]");
 
     if (images.length == 0) {
         return;
@@ -310,11 +431,14 @@ function attachExpandToggleButton(collapsibleContainer) {
     const button = document.createElement("button");
     const icon = document.createElement("span");
     icon.classList.add("expand-toggle-button-icon");
+    icon.setAttribute("aria-hidden", "true");
     const textNode = document.createTextNode(showMoreText);
     button.classList.add("expand-toggle-button");
+    button.setAttribute("aria-expanded", "false");
     button.append(textNode, icon);
     button.addEventListener("click", () => {
         expanded = !expanded;
+        button.setAttribute("aria-expanded", expanded ? "true" : "false");
 
         if (expanded) {
             collapsibleContainer.classList.add("container-expanded");
@@ -346,8 +470,8 @@ function attachExpandToggleButton(collapsibleContainer) {
 };
 
 
-function setupCollapsibleLists() {
-    const collapsibleLists = document.querySelectorAll(".list.collapsible-container");
+function setupCollapsibleLists(root = document) {
+    const collapsibleLists = root.querySelectorAll(".list.collapsible-container");
 
     if (collapsibleLists.length == 0) {
         return;
@@ -380,8 +504,8 @@ function setupCollapsibleLists() {
     }
 }
 
-function setupCollapsibleGrids() {
-    const collapsibleGridElements = document.querySelectorAll(".cards-grid.collapsible-container");
+function setupCollapsibleGrids(root = document) {
+    const collapsibleGridElements = root.querySelectorAll(".cards-grid.collapsible-container");
 
     if (collapsibleGridElements.length == 0) {
         return;
@@ -456,8 +580,14 @@ function setupCollapsibleGrids() {
 }
 
 const contentReadyCallbacks = [];
+let contentReady = false;
 
 function afterContentReady(callback) {
+    if (contentReady) {
+        callback();
+        return;
+    }
+
     contentReadyCallbacks.push(callback);
 }
 
@@ -594,6 +724,269 @@ function setupClocks() {
     updateClocks();
 }
 
+// The new widget is placed inside of a temporary wrapper so that the setup
+// functions, which only look at the descendants of the element they're
+// given, also find the widget itself, e.g. when it's a group
+function replaceWidget(element, html) {
+    const wrapper = document.createElement("div");
+    wrapper.innerHTML = html;
+    element.replaceWith(wrapper);
+
+    setupPopovers(wrapper);
+    setupCarousels(wrapper);
+    setupCollapsibleLists(wrapper);
+    setupCollapsibleGrids(wrapper);
+    setupGroups(wrapper);
+    setupMasonries(wrapper);
+    setupLazyImages(wrapper);
+    setupToDos(wrapper);
+    setupLocationPickers(wrapper);
+    setupWatchlists(wrapper);
+    setupTimeTrackings(wrapper);
+    setupPomodoros(wrapper);
+    setupCounters(wrapper);
+    setupQuickLogs(wrapper);
+    setupChoreLists(wrapper);
+    setupPrinters(wrapper);
+    setupCameras(wrapper);
+    setupFrigateThumbnails(wrapper);
+    setupThermostats(wrapper);
+    setupReminderLists(wrapper);
+    setupMediaPosters(wrapper);
+    setupWikipediaArticles(wrapper);
+    setupMemoryPhotos(wrapper);
+    updateRelativeTimeForElements(wrapper.querySelectorAll("[data-dynamic-relative-time]"));
+
+    wrapper.replaceWith(...wrapper.childNodes);
+}
+
+// Widgets which get changed from the dashboard ask to be rendered again with their new data
+async function refreshWidget(element) {
+    const response = await fetch(`${pageData.baseURL}/api/pages/${pageData.slug}/widgets/${element.dataset.widgetId}?${pageContentQuery()}`);
+
+    if (!response.ok || !element.isConnected) {
+        return;
+    }
+
+    replaceWidget(element, await response.text());
+}
+
+document.addEventListener("widget-refresh", (event) => {
+    refreshWidget(event.target.closest("[data-widget-id]")).catch(() => {});
+});
+
+function setupLiveUpdates() {
+    const pending = new Set(pendingWidgetIDs);
+    const query = new URLSearchParams(pageContentQuery());
+
+    if (pending.size > 0) {
+        query.set("pending", [...pending].join(","));
+    }
+
+    const source = new EventSource(`${pageData.baseURL}/api/pages/${pageData.slug}/updates?${query}`);
+
+    source.addEventListener("widget-update", (event) => {
+        const update = JSON.parse(event.data);
+        const element = document.querySelector(`[data-widget-id="${update.id}"]`);
+
+        pending.delete(String(update.id));
+
+        // the server ends the stream once it's sent all pending widgets,
+        // which would otherwise cause the browser to reconnect
+        if (!pageData.liveUpdates && pending.size == 0) {
+            source.close();
+        }
+
+        if (element === null) {
+            return;
+        }
+
+        replaceWidget(element, update.html);
+    });
+}
+
+// Switching between tabs shouldn't cause a refresh every time
+const minHiddenTimeBeforeWakeRefresh = 30 * 1000;
+
+// When the page becomes visible again after a while, such as when the monitor wakes up,
+// the server updates all widgets with outdated data at once and sends back the ones that changed
+function setupRefreshOnWake() {
+    if (document.hidden === undefined) {
+        return;
+    }
+
+    let hiddenAt = 0;
+
+    document.addEventListener("visibilitychange", async () => {
+        if (document.hidden) {
+            hiddenAt = Date.now();
+            return;
+        }
+
+        if (Date.now() - hiddenAt < minHiddenTimeBeforeWakeRefresh) {
+            return;
+        }
+
+        const query = new URLSearchParams(pageContentQuery());
+        query.set("since", pageRenderedAt);
+
+        try {
+            const response = await fetch(`${pageData.baseURL}/api/pages/${pageData.slug}/wake?${query}`, { method: "POST" });
+
+            if (!response.ok) {
+                return;
+            }
+
+            const wake = await response.json();
+            pageRenderedAt = wake["rendered-at"];
+
+            for (const update of wake.widgets) {
+                const element = document.querySelector(`[data-widget-id="${update.id}"]`);
+
+                if (element !== null) {
+                    replaceWidget(element, update.html);
+                }
+            }
+        } catch (error) {
+            console.error("Failed to refresh widgets after waking up:", error);
+        }
+    });
+}
+
+// The content of the page is loaded after the page itself, so the browser can't
+// scroll to the widget linked to through the URL, such as #widget-news, on its own
+function scrollToLinkedWidget() {
+    if (!location.hash.startsWith("#widget-")) {
+        return;
+    }
+
+    const target = document.getElementById(decodeURIComponent(location.hash.substring(1)));
+
+    if (target === null) {
+        return;
+    }
+
+    const panel = target.closest(".widget-group-content");
+
+    if (panel !== null) {
+        document.getElementById(panel.getAttribute("aria-labelledby"))?.click();
+    }
+
+    target.scrollIntoView({ block: "start" });
+}
+
+// How long the rotation stays paused after the last interaction with the page
+const pageRotationResumeDelay = 60 * 1000;
+
+// Goes to the next page of the rotation once rotate-after passes, meant for TVs and status
+// monitors. Interacting with the page pauses the rotation until it's left alone for a while,
+// after which it starts over so that whoever was using it isn't taken away mid-way
+function setupPageRotation() {
+    const indicator = document.createElement("div");
+    indicator.classList.add("page-rotation-progress");
+    indicator.style.setProperty("--rotate-after", `${pageData.rotateAfter}ms`);
+    indicator.setAttribute("aria-hidden", "true");
+    document.body.append(indicator);
+
+    let rotateTimeout = null;
+    let resumeTimeout = null;
+
+    const start = () => {
+        indicator.classList.remove("page-rotation-paused");
+        // restarts the animation of the indicator
+        indicator.style.animation = "none";
+        indicator.offsetWidth;
+        indicator.style.animation = "";
+
+        rotateTimeout = setTimeout(() => {
+            location.href = `${pageData.baseURL}/${pageData.rotateTo}${location.search}`;
+        }, pageData.rotateAfter);
+    };
+
+    const pause = () => {
+        if (rotateTimeout !== null) {
+            clearTimeout(rotateTimeout);
+            rotateTimeout = null;
+            indicator.classList.add("page-rotation-paused");
+        }
+
+        clearTimeout(resumeTimeout);
+        resumeTimeout = setTimeout(start, pageRotationResumeDelay);
+    };
+
+    for (const event of ["pointerdown", "pointermove", "keydown", "wheel", "scroll"]) {
+        document.addEventListener(event, pause, { passive: true });
+    }
+
+    start();
+}
+
+// How often the contents of the idle screen move around, which prevents burn-in on OLED screens
+const idleScreenShiftInterval = 60 * 1000;
+
+// Covers the page with a dimmed clock, along with the current weather when the page has a weather
+// widget, after idle-after passes without any interaction and removes it on the next one
+function setupIdleMode() {
+    const screen = document.createElement("div");
+    screen.classList.add("idle-screen");
+    screen.setAttribute("aria-hidden", "true");
+
+    const content = document.createElement("div");
+    content.classList.add("idle-screen-content");
+
+    const clock = document.createElement("div");
+    clock.classList.add("idle-screen-clock");
+
+    const weather = document.createElement("div");
+    weather.classList.add("idle-screen-weather");
+
+    content.append(clock, weather);
+    screen.append(content);
+    document.body.append(screen);
+
+    let idleTimeout = null;
+    let updateInterval = null;
+    let lastShiftAt = 0;
+
+    const update = () => {
+        clock.textContent = new Date().toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" });
+
+        // live updates keep the weather widget, and so this, up to date
+        const weatherElement = document.querySelector("[data-idle-weather]");
+        weather.textContent = weatherElement === null ? "" : weatherElement.dataset.idleWeather;
+
+        if (Date.now() - lastShiftAt >= idleScreenShiftInterval) {
+            lastShiftAt = Date.now();
+            content.style.setProperty("--idle-x", `${20 + Math.random() * 60}%`);
+            content.style.setProperty("--idle-y", `${20 + Math.random() * 60}%`);
+        }
+    };
+
+    const enter = () => {
+        lastShiftAt = 0;
+        update();
+        updateInterval = setInterval(update, 1000);
+        screen.classList.add("idle-screen-visible");
+    };
+
+    const leave = () => {
+        if (updateInterval !== null) {
+            clearInterval(updateInterval);
+            updateInterval = null;
+            screen.classList.remove("idle-screen-visible");
+        }
+
+        clearTimeout(idleTimeout);
+        idleTimeout = setTimeout(enter, pageData.idleAfter);
+    };
+
+    for (const event of ["pointerdown", "pointermove", "keydown", "wheel", "scroll"]) {
+        document.addEventListener(event, leave, { passive: true });
+    }
+
+    leave();
+}
+
 async function setupPage() {
     const pageElement = document.getElementById("page");
     const pageContentElement = document.getElementById("page-content");
@@ -612,13 +1005,53 @@ async function setupPage() {
         setupMasonries();
         setupDynamicRelativeTime();
         setupLazyImages();
+        setupToDos();
+        setupLocationPickers();
+        setupWatchlists();
+        setupTimeTrackings();
+        setupPomodoros();
+        setupCounters();
+        setupQuickLogs();
+        setupChoreLists();
+        setupPrinters();
+        setupCameras();
+        setupFrigateThumbnails();
+        setupThermostats();
+        setupReminderLists();
+        setupMediaPosters();
+        setupWikipediaArticles();
+        setupMemoryPhotos();
+
+        if (pageData.mobileSwipeableColumns) {
+            setupSwipeableColumns();
+        }
     } finally {
         pageElement.classList.add("content-ready");
+        pageElement.removeAttribute("aria-busy");
+        contentReady = true;
 
         for (let i = 0; i < contentReadyCallbacks.length; i++) {
             contentReadyCallbacks[i]();
         }
 
+        scrollToLinkedWidget();
+
+        if (pageData.liveUpdates || pendingWidgetIDs.length > 0) {
+            setupLiveUpdates();
+        }
+
+        if (pageData.refreshOnWake) {
+            setupRefreshOnWake();
+        }
+
+        if (pageData.rotateTo !== undefined) {
+            setupPageRotation();
+        }
+
+        if (pageData.idleAfter !== undefined) {
+            setupIdleMode();
+        }
+
         setTimeout(() => {
             document.body.classList.add("page-columns-transitioned");
         }, 300);
@@ -0,0 +1,15 @@
+// Posters go through the widget so that browsers don't have to reach TMDB
+function setupMediaPoster(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+
+    element.addEventListener("error", () => element.remove(), { once: true });
+    element.src = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/poster/${element.dataset.mediaPoster}`;
+}
+
+export function setupMediaPosters(root = document) {
+    const elements = root.querySelectorAll("[data-media-poster]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupMediaPoster(elements[i]);
+    }
+}
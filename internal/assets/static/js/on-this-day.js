@@ -0,0 +1,15 @@
+// Photos go through the widget so that browsers don't need the API key of Immich
+function setupMemoryPhoto(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+
+    element.addEventListener("error", () => element.parentElement.remove(), { once: true });
+    element.src = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/photo/${element.dataset.memoryPhoto}`;
+}
+
+export function setupMemoryPhotos(root = document) {
+    const elements = root.querySelectorAll("[data-memory-photo]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupMemoryPhoto(elements[i]);
+    }
+}
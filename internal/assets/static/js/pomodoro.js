@@ -0,0 +1,69 @@
+const errorIndicatorMs = 3000;
+
+function formatClock(ms) {
+    const seconds = Math.max(0, Math.round(ms / 1000));
+
+    return `${String(Math.floor(seconds / 60)).padStart(2, "0")}:${String(seconds % 60).padStart(2, "0")}`;
+}
+
+// The state lives on the server, the countdown only shows what's left of the
+// interval and gets the widget rendered again once it ends so that it moves on
+function setupPomodoro(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}`;
+    const refresh = () => widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+
+    element.addEventListener("click", async (event) => {
+        const button = event.target.closest("[data-pomodoro-action]");
+
+        if (button === null) {
+            return;
+        }
+
+        element.classList.add("pomodoro-busy");
+
+        try {
+            const response = await fetch(`${baseURL}/${button.dataset.pomodoroAction}`, { method: "POST" });
+
+            if (response.ok || response.status === 409) {
+                refresh();
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("pomodoro-busy");
+        element.classList.add("pomodoro-error");
+        setTimeout(() => element.classList.remove("pomodoro-error"), errorIndicatorMs);
+    });
+
+    if (element.dataset.pomodoroEndsAt === undefined) {
+        return;
+    }
+
+    const endsAt = Number(element.dataset.pomodoroEndsAt);
+    const timeElement = element.querySelector(".pomodoro-time");
+
+    const interval = setInterval(() => {
+        // the widget has been rendered again, which sets up its own countdown
+        if (!element.isConnected) {
+            clearInterval(interval);
+            return;
+        }
+
+        const remaining = endsAt - Date.now();
+        timeElement.textContent = formatClock(remaining);
+
+        if (remaining <= 0) {
+            clearInterval(interval);
+            refresh();
+        }
+    }, 1000);
+}
+
+export function setupPomodoros(root = document) {
+    const elements = root.querySelectorAll(".pomodoro");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupPomodoro(elements[i]);
+    }
+}
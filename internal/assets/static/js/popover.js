@@ -173,13 +173,20 @@ function handleHidePopoverOnEscape(event) {
     }
 }
 
-export function setupPopovers() {
-    const targets = document.querySelectorAll("[data-popover-type]");
+export function setupPopovers(root = document) {
+    const targets = root.querySelectorAll("[data-popover-type]");
 
     for (let i = 0; i < targets.length; i++) {
         const target = targets[i];
 
         target.addEventListener("mouseenter", handleMouseEnter);
         target.addEventListener("mouseleave", handleMouseLeave);
+        target.addEventListener("focus", handleMouseEnter);
+        target.addEventListener("blur", handleMouseLeave);
+
+        // so that the popover can also be shown when navigating with the keyboard
+        if (target.tabIndex < 0) {
+            target.tabIndex = 0;
+        }
     }
 }
@@ -0,0 +1,49 @@
+const errorIndicatorMs = 3000;
+
+// The snapshot goes through the widget since the printer usually can't be reached
+// from the browser, it's loaded again every time the widget gets rendered
+function setupPrinter(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}`;
+    const snapshotElement = element.querySelector("[data-printer-snapshot]");
+
+    if (snapshotElement !== null) {
+        snapshotElement.addEventListener("error", () => snapshotElement.remove(), { once: true });
+        snapshotElement.src = `${baseURL}/snapshot?t=${Date.now()}`;
+    }
+
+    if (element.dataset.printerActions === undefined) {
+        return;
+    }
+
+    element.addEventListener("click", async (event) => {
+        const button = event.target.closest("[data-printer-action]");
+
+        if (button === null || (button.dataset.printerConfirm !== undefined && !confirm(button.dataset.printerConfirm))) {
+            return;
+        }
+
+        element.classList.add("printer-busy");
+
+        try {
+            const response = await fetch(`${baseURL}/${button.dataset.printerAction}`, { method: "POST" });
+
+            if (response.ok || response.status === 409) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("printer-busy");
+        element.classList.add("printer-error");
+        setTimeout(() => element.classList.remove("printer-error"), errorIndicatorMs);
+    });
+}
+
+export function setupPrinters(root = document) {
+    const elements = root.querySelectorAll(".printer");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupPrinter(elements[i]);
+    }
+}
@@ -0,0 +1,47 @@
+const errorIndicatorMs = 3000;
+
+// Every change gets the widget rendered again so that the times since
+// each action and the recent entries match what the server has
+function setupQuickLog(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}`;
+
+    const send = async (path, body) => {
+        element.classList.add("quick-log-busy");
+
+        try {
+            const response = await fetch(`${baseURL}/${path}`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify(body || {}),
+            });
+
+            if (response.ok || response.status === 409) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("quick-log-busy");
+        element.classList.add("quick-log-error");
+        setTimeout(() => element.classList.remove("quick-log-error"), errorIndicatorMs);
+    };
+
+    element.addEventListener("click", (event) => {
+        const actionElement = event.target.closest("[data-quick-log-action]");
+
+        if (actionElement !== null) {
+            send("log", { action: actionElement.dataset.quickLogAction });
+        } else if (event.target.closest(".quick-log-undo") !== null) {
+            send("undo");
+        }
+    });
+}
+
+export function setupQuickLogs(root = document) {
+    const elements = root.querySelectorAll(".quick-log");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupQuickLog(elements[i]);
+    }
+}
@@ -0,0 +1,48 @@
+const errorIndicatorMs = 3000;
+
+// The checkbox changes right away and the widget gets rendered again once saved,
+// so that the next due dates match, or goes back if saving fails
+function setupReminders(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const doneURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/done`;
+
+    let errorTimeout = null;
+
+    element.addEventListener("change", async (event) => {
+        const checkbox = event.target.closest(".reminders-checkbox");
+
+        if (checkbox === null) {
+            return;
+        }
+
+        const done = checkbox.checked;
+        checkbox.nextElementSibling.classList.toggle("reminders-done", done);
+
+        try {
+            const response = await fetch(doneURL, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({ reminder: checkbox.dataset.reminder, done }),
+            });
+
+            if (response.ok) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        checkbox.checked = !done;
+        checkbox.nextElementSibling.classList.toggle("reminders-done", !done);
+        element.classList.add("reminders-error");
+        clearTimeout(errorTimeout);
+        errorTimeout = setTimeout(() => element.classList.remove("reminders-error"), errorIndicatorMs);
+    });
+}
+
+export function setupReminderLists(root = document) {
+    const elements = root.querySelectorAll(".reminders");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupReminders(elements[i]);
+    }
+}
@@ -0,0 +1,45 @@
+const errorIndicatorMs = 3000;
+
+function setupThermostat(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}`;
+
+    element.addEventListener("click", async (event) => {
+        const button = event.target.closest("[data-thermostat-direction]");
+
+        if (button === null) {
+            return;
+        }
+
+        const zoneElement = button.closest("[data-thermostat-zone]");
+        element.classList.add("thermostat-busy");
+
+        try {
+            const response = await fetch(`${baseURL}/target`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify({
+                    zone: zoneElement.dataset.thermostatZone,
+                    direction: button.dataset.thermostatDirection,
+                }),
+            });
+
+            if (response.ok) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("thermostat-busy");
+        zoneElement.classList.add("thermostat-error");
+        setTimeout(() => zoneElement.classList.remove("thermostat-error"), errorIndicatorMs);
+    });
+}
+
+export function setupThermostats(root = document) {
+    const elements = root.querySelectorAll("[data-thermostat-actions]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupThermostat(elements[i]);
+    }
+}
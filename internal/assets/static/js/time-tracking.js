@@ -0,0 +1,50 @@
+const errorIndicatorMs = 3000;
+
+// Starting and stopping goes through the widget, after which it gets rendered
+// again so that the totals and the running timer match what the service has
+function setupTimeTracking(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const baseURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}`;
+    const formElement = element.querySelector(".time-tracking-start");
+    const stopElement = element.querySelector(".time-tracking-stop");
+
+    const send = async (action, body) => {
+        element.classList.add("time-tracking-busy");
+
+        try {
+            const response = await fetch(`${baseURL}/${action}`, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify(body || {}),
+            });
+
+            if (response.ok || response.status === 409) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("time-tracking-busy");
+        element.classList.add("time-tracking-error");
+        setTimeout(() => element.classList.remove("time-tracking-error"), errorIndicatorMs);
+    };
+
+    if (formElement !== null) {
+        formElement.addEventListener("submit", (event) => {
+            event.preventDefault();
+            send("start", { description: formElement.querySelector(".time-tracking-input").value.trim() });
+        });
+    }
+
+    if (stopElement !== null) {
+        stopElement.addEventListener("click", () => send("stop"));
+    }
+}
+
+export function setupTimeTrackings(root = document) {
+    const elements = root.querySelectorAll(".time-tracking[data-time-tracking-actions]");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupTimeTracking(elements[i]);
+    }
+}
@@ -0,0 +1,203 @@
+const notesSaveDelayMs = 600;
+const errorIndicatorMs = 3000;
+
+// Changes are shown right away and sent to the server one at a time, in the order
+// they were made. If one of them fails, the list goes back to the last state that
+// the server confirmed, or to the one it sent back if the list was changed elsewhere
+function setupToDo(element) {
+    const widgetID = element.closest("[data-widget-id]").dataset.widgetId;
+    const itemsURL = `${pageData.baseURL}/api/widgets/${widgetID}/items`;
+    const listElement = element.querySelector(".to-do-items");
+    const formElement = element.querySelector(".to-do-add");
+    const inputElement = element.querySelector(".to-do-input");
+    const notesElement = element.querySelector(".to-do-notes");
+
+    let state = {
+        version: Number(element.dataset.version),
+        items: Array.from(listElement.children, (item) => ({
+            text: item.querySelector(".to-do-text").textContent,
+            done: item.querySelector(".to-do-checkbox").checked,
+        })),
+        notes: notesElement ? notesElement.value : "",
+    };
+
+    let confirmed = structuredClone(state);
+    let queue = [];
+    let sending = false;
+    let notesTimeout = null;
+    let errorTimeout = null;
+
+    const renderItems = () => {
+        const items = state.items.map((item, index) => {
+            const itemElement = document.createElement("li");
+            itemElement.className = "to-do-item flex items-center gap-10";
+
+            const checkbox = document.createElement("input");
+            checkbox.className = "to-do-checkbox";
+            checkbox.type = "checkbox";
+            checkbox.checked = item.done;
+            checkbox.setAttribute("aria-label", "Done");
+            checkbox.addEventListener("change", () => {
+                change(() => { state.items[index].done = checkbox.checked; });
+            });
+
+            const text = document.createElement("span");
+            text.className = "to-do-text grow min-width-0 break-all";
+            text.classList.toggle("to-do-done", item.done);
+            text.textContent = item.text;
+
+            const remove = document.createElement("button");
+            remove.className = "to-do-remove";
+            remove.type = "button";
+            remove.title = "Remove";
+            remove.setAttribute("aria-label", "Remove");
+            remove.textContent = "×";
+            remove.addEventListener("click", () => {
+                change(() => { state.items.splice(index, 1); });
+            });
+
+            itemElement.append(checkbox, text, remove);
+            return itemElement;
+        });
+
+        listElement.replaceChildren(...items);
+    };
+
+    const render = () => {
+        renderItems();
+
+        // the notes aren't overwritten while they're being edited
+        if (notesElement && document.activeElement !== notesElement) {
+            notesElement.value = state.notes;
+        }
+    };
+
+    const showError = () => {
+        element.classList.add("to-do-error");
+        clearTimeout(errorTimeout);
+        errorTimeout = setTimeout(() => element.classList.remove("to-do-error"), errorIndicatorMs);
+    };
+
+    const rollback = (to) => {
+        queue = [];
+        confirmed = to;
+        state = structuredClone(to);
+        render();
+        showError();
+    };
+
+    const send = async () => {
+        if (sending || queue.length == 0) {
+            return;
+        }
+
+        sending = true;
+        const request = queue.shift();
+
+        try {
+            const response = await fetch(itemsURL, {
+                method: request.method,
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify(request.body()),
+            });
+
+            if (response.status == 409) {
+                rollback(await response.json());
+            } else if (!response.ok) {
+                rollback(confirmed);
+            } else {
+                confirmed = await response.json();
+
+                // pending changes were made on top of the previous version
+                // and get sent along with the version that they replace
+                if (queue.length == 0) {
+                    state = structuredClone(confirmed);
+                    render();
+                } else {
+                    state.version = confirmed.version;
+                }
+            }
+        } catch {
+            rollback(confirmed);
+        } finally {
+            sending = false;
+            send();
+        }
+    };
+
+    const hasPendingReplacement = () => queue.some((request) => request.method == "PUT");
+
+    const change = (apply) => {
+        apply();
+        renderItems();
+
+        // the body is created when the request gets sent so that it includes both the
+        // latest version and every change made up until then, which makes a pending
+        // replacement of the list also cover the changes that come after it
+        if (!hasPendingReplacement()) {
+            queue.push({
+                method: "PUT",
+                body: () => ({ version: state.version, items: state.items, notes: state.notes }),
+            });
+        }
+
+        send();
+    };
+
+    formElement.addEventListener("submit", (event) => {
+        event.preventDefault();
+        const text = inputElement.value.trim();
+
+        if (text == "") {
+            return;
+        }
+
+        inputElement.value = "";
+        state.items.push({ text, done: false });
+        renderItems();
+
+        if (!hasPendingReplacement()) {
+            queue.push({ method: "POST", body: () => ({ text, done: false }) });
+        }
+
+        send();
+    });
+
+    if (notesElement) {
+        notesElement.addEventListener("input", () => {
+            clearTimeout(notesTimeout);
+            notesTimeout = setTimeout(() => {
+                change(() => { state.notes = notesElement.value; });
+            }, notesSaveDelayMs);
+        });
+    }
+
+    // picks up changes made in other browsers when coming back to the page
+    document.addEventListener("visibilitychange", async () => {
+        if (document.hidden || sending || queue.length > 0 || !element.isConnected) {
+            return;
+        }
+
+        try {
+            const response = await fetch(itemsURL);
+
+            if (!response.ok || sending || queue.length > 0) {
+                return;
+            }
+
+            confirmed = await response.json();
+            state = structuredClone(confirmed);
+            render();
+        } catch {}
+    });
+
+    renderItems();
+}
+
+export function setupToDos(root = document) {
+    const elements = root.querySelectorAll(".to-do");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupToDo(elements[i]);
+    }
+}
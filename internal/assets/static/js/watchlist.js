@@ -0,0 +1,106 @@
+const errorIndicatorMs = 3000;
+
+// Changes are sent to the server, after which the widget gets rendered again since
+// the data of the new symbols has to be fetched first. Removing a symbol replaces
+// the whole watchlist, which is retried once if it was changed somewhere else
+function setupWatchlist(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const watchlistURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/watchlist`;
+    const formElement = widgetElement.querySelector(".market-add");
+    const inputElement = widgetElement.querySelector(".market-input");
+
+    let errorTimeout = null;
+
+    const showError = (message) => {
+        clearTimeout(errorTimeout);
+        formElement.classList.add("market-add-error");
+        inputElement.title = message;
+        errorTimeout = setTimeout(() => {
+            formElement.classList.remove("market-add-error");
+            inputElement.title = "";
+        }, errorIndicatorMs);
+    };
+
+    const send = (method, body) => fetch(watchlistURL, {
+        method: method,
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(body),
+    });
+
+    const refresh = () => {
+        widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+    };
+
+    formElement.addEventListener("submit", async (event) => {
+        event.preventDefault();
+        const symbol = inputElement.value.trim().toUpperCase();
+
+        if (symbol === "") {
+            return;
+        }
+
+        inputElement.disabled = true;
+
+        try {
+            const response = await send("POST", { symbol });
+
+            if (!response.ok) {
+                showError((await response.text()).trim());
+                return;
+            }
+
+            inputElement.value = "";
+            refresh();
+        } catch {
+            showError("Could not add the symbol");
+        } finally {
+            inputElement.disabled = false;
+        }
+    });
+
+    const remove = async (symbol) => {
+        let watchlist = await (await fetch(watchlistURL)).json();
+
+        for (let attempt = 0; attempt < 2; attempt++) {
+            watchlist.symbols = watchlist.symbols.filter((item) => item.symbol !== symbol);
+            const response = await send("PUT", watchlist);
+
+            if (response.ok) {
+                return true;
+            }
+
+            if (response.status !== 409) {
+                break;
+            }
+
+            watchlist = await response.json();
+        }
+
+        return false;
+    };
+
+    for (const button of element.querySelectorAll(".market-remove")) {
+        button.addEventListener("click", async () => {
+            const market = button.closest(".market");
+            market.classList.add("market-removing");
+
+            try {
+                if (await remove(market.dataset.symbol)) {
+                    refresh();
+                    return;
+                }
+            } catch {}
+
+            market.classList.remove("market-removing");
+            showError("Could not remove the symbol");
+        });
+    }
+}
+
+export function setupWatchlists(root = document) {
+    const elements = root.querySelectorAll(".markets-watchlist");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupWatchlist(elements[i]);
+    }
+}
@@ -0,0 +1,32 @@
+const errorIndicatorMs = 3000;
+
+// The widget gets another article and is then rendered again with it
+function setupWikipedia(element) {
+    const widgetElement = element.closest("[data-widget-id]");
+    const nextURL = `${pageData.baseURL}/api/widgets/${widgetElement.dataset.widgetId}/next`;
+
+    element.querySelector(".wikipedia-next").addEventListener("click", async () => {
+        element.classList.add("wikipedia-busy");
+
+        try {
+            const response = await fetch(nextURL, { method: "POST" });
+
+            if (response.ok) {
+                widgetElement.dispatchEvent(new CustomEvent("widget-refresh", { bubbles: true }));
+                return;
+            }
+        } catch {}
+
+        element.classList.remove("wikipedia-busy");
+        element.classList.add("wikipedia-error");
+        setTimeout(() => element.classList.remove("wikipedia-error"), errorIndicatorMs);
+    });
+}
+
+export function setupWikipediaArticles(root = document) {
+    const elements = root.querySelectorAll(".wikipedia");
+
+    for (let i = 0; i < elements.length; i++) {
+        setupWikipedia(elements[i]);
+    }
+}
@@ -4,55 +4,149 @@ import (
 	"fmt"
 	"html/template"
 	"math"
+	"slices"
 	"strconv"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
 
 var (
-	PageTemplate                  = compileTemplate("page.html", "document.html", "page-style-overrides.gotmpl")
-	PageContentTemplate           = compileTemplate("content.html")
-	CalendarTemplate              = compileTemplate("calendar.html", "widget-base.html")
-	ClockTemplate                 = compileTemplate("clock.html", "widget-base.html")
-	BookmarksTemplate             = compileTemplate("bookmarks.html", "widget-base.html")
-	IFrameTemplate                = compileTemplate("iframe.html", "widget-base.html")
-	WeatherTemplate               = compileTemplate("weather.html", "widget-base.html")
-	ForumPostsTemplate            = compileTemplate("forum-posts.html", "widget-base.html")
-	RedditCardsHorizontalTemplate = compileTemplate("reddit-horizontal-cards.html", "widget-base.html")
-	RedditCardsVerticalTemplate   = compileTemplate("reddit-vertical-cards.html", "widget-base.html")
-	ReleasesTemplate              = compileTemplate("releases.html", "widget-base.html")
-	ChangeDetectionTemplate       = compileTemplate("change-detection.html", "widget-base.html")
-	VideosTemplate                = compileTemplate("videos.html", "widget-base.html", "video-card-contents.html")
-	VideosGridTemplate            = compileTemplate("videos-grid.html", "widget-base.html", "video-card-contents.html")
-	MarketsTemplate               = compileTemplate("markets.html", "widget-base.html")
-	RSSListTemplate               = compileTemplate("rss-list.html", "widget-base.html")
-	RSSDetailedListTemplate       = compileTemplate("rss-detailed-list.html", "widget-base.html")
-	RSSHorizontalCardsTemplate    = compileTemplate("rss-horizontal-cards.html", "widget-base.html")
-	RSSHorizontalCards2Template   = compileTemplate("rss-horizontal-cards-2.html", "widget-base.html")
-	MonitorTemplate               = compileTemplate("monitor.html", "widget-base.html")
-	TwitchGamesListTemplate       = compileTemplate("twitch-games-list.html", "widget-base.html")
-	TwitchChannelsTemplate        = compileTemplate("twitch-channels.html", "widget-base.html")
-	RepositoryTemplate            = compileTemplate("repository.html", "widget-base.html")
-	SearchTemplate                = compileTemplate("search.html", "widget-base.html")
-	ExtensionTemplate             = compileTemplate("extension.html", "widget-base.html")
-	GroupTemplate                 = compileTemplate("group.html", "widget-base.html")
-	DNSStatsTemplate              = compileTemplate("dns-stats.html", "widget-base.html")
-	SplitColumnTemplate           = compileTemplate("split-column.html", "widget-base.html")
-	CustomAPITemplate             = compileTemplate("custom-api.html", "widget-base.html")
+	PageTemplate                    = compileTemplate("page.html", "document.html", "page-style-overrides.gotmpl")
+	PageContentTemplate             = compileTemplate("content.html")
+	LoginTemplate                   = compileTemplate("login.html", "document.html", "page-style-overrides.gotmpl")
+	CalendarTemplate                = compileTemplate("calendar.html", "widget-base.html")
+	CalendarEventsTemplate          = compileTemplate("calendar-events.html", "widget-base.html")
+	ClockTemplate                   = compileTemplate("clock.html", "widget-base.html")
+	BookmarksTemplate               = compileTemplate("bookmarks.html", "widget-base.html")
+	BudgetTemplate                  = compileTemplate("budget.html", "widget-base.html")
+	BankAccountsTemplate            = compileTemplate("bank-accounts.html", "widget-base.html")
+	IFrameTemplate                  = compileTemplate("iframe.html", "widget-base.html")
+	WeatherTemplate                 = compileTemplate("weather.html", "widget-base.html")
+	WeatherHintsTemplate            = compileTemplate("weather-hints.html", "widget-base.html")
+	ForumPostsTemplate              = compileTemplate("forum-posts.html", "widget-base.html")
+	RedditCardsHorizontalTemplate   = compileTemplate("reddit-horizontal-cards.html", "widget-base.html")
+	RedditCardsVerticalTemplate     = compileTemplate("reddit-vertical-cards.html", "widget-base.html")
+	ReleasesTemplate                = compileTemplate("releases.html", "widget-base.html")
+	ChangeDetectionTemplate         = compileTemplate("change-detection.html", "widget-base.html")
+	VideosTemplate                  = compileTemplate("videos.html", "widget-base.html", "video-card-contents.html")
+	VideosGridTemplate              = compileTemplate("videos-grid.html", "widget-base.html", "video-card-contents.html")
+	MarketsTemplate                 = compileTemplate("markets.html", "widget-base.html")
+	CryptoWalletsTemplate           = compileTemplate("crypto-wallets.html", "widget-base.html")
+	RSSListTemplate                 = compileTemplate("rss-list.html", "widget-base.html")
+	RSSDetailedListTemplate         = compileTemplate("rss-detailed-list.html", "widget-base.html")
+	RSSHorizontalCardsTemplate      = compileTemplate("rss-horizontal-cards.html", "widget-base.html")
+	RSSHorizontalCards2Template     = compileTemplate("rss-horizontal-cards-2.html", "widget-base.html")
+	MonitorTemplate                 = compileTemplate("monitor.html", "widget-base.html", "threshold-icon.html")
+	MonitorUptimeTemplate           = compileTemplate("monitor-uptime.html", "widget-base.html")
+	TwitchGamesListTemplate         = compileTemplate("twitch-games-list.html", "widget-base.html")
+	TwitchChannelsTemplate          = compileTemplate("twitch-channels.html", "widget-base.html")
+	RepositoryTemplate              = compileTemplate("repository.html", "widget-base.html")
+	GithubNotificationsTemplate     = compileTemplate("github-notifications.html", "widget-base.html")
+	SearchTemplate                  = compileTemplate("search.html", "widget-base.html")
+	ExtensionTemplate               = compileTemplate("extension.html", "widget-base.html")
+	GroupTemplate                   = compileTemplate("group.html", "widget-base.html")
+	DNSStatsTemplate                = compileTemplate("dns-stats.html", "widget-base.html")
+	SplitColumnTemplate             = compileTemplate("split-column.html", "widget-base.html")
+	DockerContainersTemplate        = compileTemplate("docker-containers.html", "widget-base.html")
+	DockerContainersCompactTemplate = compileTemplate("docker-containers-compact.html", "widget-base.html")
+	CustomAPITemplate               = compileTemplate("custom-api.html", "widget-base.html")
+	CustomHTMLTemplate              = compileTemplate("custom-html.html", "widget-base.html")
+	ToDoTemplate                    = compileTemplate("to-do.html", "widget-base.html")
+	ComputedMetricsTemplate         = compileTemplate("computed-metrics.html", "widget-base.html", "threshold-icon.html")
+	ServerStatsTemplate             = compileTemplate("server-stats.html", "widget-base.html")
+	TimeTrackingTemplate            = compileTemplate("time-tracking.html", "widget-base.html")
+	PomodoroTemplate                = compileTemplate("pomodoro.html", "widget-base.html")
+	ActivityWatchTemplate           = compileTemplate("activitywatch.html", "widget-base.html")
+	FitnessTemplate                 = compileTemplate("fitness.html", "widget-base.html")
+	SleepTemplate                   = compileTemplate("sleep.html", "widget-base.html")
+	CounterTemplate                 = compileTemplate("counter.html", "widget-base.html")
+	QuickLogTemplate                = compileTemplate("quick-log.html", "widget-base.html")
+	ChoresTemplate                  = compileTemplate("chores.html", "widget-base.html")
+	SensorsTemplate                 = compileTemplate("sensors.html", "widget-base.html", "threshold-icon.html")
+	PrinterTemplate                 = compileTemplate("3d-printer.html", "widget-base.html")
+	PrinterSuppliesTemplate         = compileTemplate("printer-supplies.html", "widget-base.html")
+	UPSTemplate                     = compileTemplate("ups.html", "widget-base.html")
+	BackupsTemplate                 = compileTemplate("backups.html", "widget-base.html")
+	DiskHealthTemplate              = compileTemplate("disk-health.html", "widget-base.html")
+	StoragePoolsTemplate            = compileTemplate("storage-pools.html", "widget-base.html")
+	SyncthingTemplate               = compileTemplate("syncthing.html", "widget-base.html")
+	MailServerTemplate              = compileTemplate("mail-server.html", "widget-base.html")
+	NextcloudTemplate               = compileTemplate("nextcloud.html", "widget-base.html")
+	LoginEventsTemplate             = compileTemplate("login-events.html", "widget-base.html")
+	BansTemplate                    = compileTemplate("bans.html", "widget-base.html")
+	VPNPeersTemplate                = compileTemplate("vpn-peers.html", "widget-base.html")
+	CloudflareTemplate              = compileTemplate("cloudflare.html", "widget-base.html")
+	CertificatesTemplate            = compileTemplate("certificates.html", "widget-base.html")
+	PublicIPTemplate                = compileTemplate("public-ip.html", "widget-base.html")
+	DDNSTemplate                    = compileTemplate("ddns.html", "widget-base.html")
+	LatencyTemplate                 = compileTemplate("latency.html", "widget-base.html", "threshold-icon.html")
+	CameraTemplate                  = compileTemplate("camera.html", "widget-base.html")
+	FrigateTemplate                 = compileTemplate("frigate.html", "widget-base.html")
+	Zigbee2MQTTTemplate             = compileTemplate("zigbee2mqtt.html", "widget-base.html")
+	EVTemplate                      = compileTemplate("ev.html", "widget-base.html")
+	SolarTemplate                   = compileTemplate("solar.html", "widget-base.html")
+	ThermostatTemplate              = compileTemplate("thermostat.html", "widget-base.html")
+	LibraryTemplate                 = compileTemplate("library.html", "widget-base.html")
+	RemindersTemplate               = compileTemplate("reminders.html", "widget-base.html")
+	DailyGoalsTemplate              = compileTemplate("daily-goals.html", "widget-base.html")
+	ChessTemplate                   = compileTemplate("chess.html", "widget-base.html")
+	MediaReleasesTemplate           = compileTemplate("media-releases.html", "widget-base.html")
+	AnimeTemplate                   = compileTemplate("anime.html", "widget-base.html")
+	ReadingTemplate                 = compileTemplate("reading.html", "widget-base.html")
+	MusicReleasesTemplate           = compileTemplate("music-releases.html", "widget-base.html")
+	EventsTemplate                  = compileTemplate("events.html", "widget-base.html")
+	TimetableTemplate               = compileTemplate("timetable.html", "widget-base.html")
+	WasteCollectionTemplate         = compileTemplate("waste-collection.html", "widget-base.html")
+	CommuteTemplate                 = compileTemplate("commute.html", "widget-base.html")
+	FlightsTemplate                 = compileTemplate("flights.html", "widget-base.html")
+	ConditionsTemplate              = compileTemplate("conditions.html", "widget-base.html")
+	OutdoorTemplate                 = compileTemplate("outdoor.html", "widget-base.html")
+	WikipediaTemplate               = compileTemplate("wikipedia.html", "widget-base.html")
+	OnThisDayTemplate               = compileTemplate("on-this-day.html", "widget-base.html")
+	GitRepositoryTemplate           = compileTemplate("git-repository.html", "widget-base.html")
+	RegistryTemplate                = compileTemplate("registry.html", "widget-base.html")
+	LogTailTemplate                 = compileTemplate("log-tail.html", "widget-base.html")
+	LogCountTemplate                = compileTemplate("log-count.html", "widget-base.html", "threshold-icon.html")
+	HealthchecksTemplate            = compileTemplate("healthchecks.html", "widget-base.html")
+	DependencyUpdatesTemplate       = compileTemplate("dependency-updates.html", "widget-base.html")
+	RemotePageTemplate              = compileTemplate("remote-page.html", "widget-base.html")
+	WidgetSkeletonTemplate          = compileTemplate("widget-skeleton.html")
 )
 
 var GlobalTemplateFunctions = template.FuncMap{
 	"relativeTime":      relativeTimeSince,
 	"formatViewerCount": formatViewerCount,
-	"formatNumber":      intl.Sprint,
+	"formatNumber": func(number any) string {
+		return intl().Sprint(number)
+	},
+	"add": func(a, b int) int {
+		return a + b
+	},
 	"absInt": func(i int) int {
 		return int(math.Abs(float64(i)))
 	},
 	"formatPrice": func(price float64) string {
-		return intl.Sprintf("%.2f", price)
+		return intl().Sprintf("%.2f", price)
+	},
+	"formatCurrency": formatCurrency,
+	"formatPercentChange": func(change float64) string {
+		return intl().Sprintf("%+.2f%%", change)
+	},
+	"formatDecimal": func(value float64, decimals int) string {
+		return intl().Sprintf("%.*f", decimals, value)
 	},
+	"formatBytes": func(bytes uint64) string {
+		return formatBytes(float64(bytes))
+	},
+	"formatBytesPerSecond": func(bytes float64) string {
+		return formatBytes(bytes) + "/s"
+	},
+	"formatDuration": formatDuration,
 	"dynamicRelativeTimeAttrs": func(t time.Time) template.HTMLAttr {
 		return template.HTMLAttr(fmt.Sprintf(`data-dynamic-relative-time="%d"`, t.Unix()))
 	},
@@ -70,7 +164,90 @@ func compileTemplate(primary string, dependencies ...string) *template.Template
 	return t
 }
 
-var intl = message.NewPrinter(language.English)
+// How numbers and prices get formatted, set through the locale property of the config
+type Locale struct {
+	printer                   *message.Printer
+	currencySymbolAfterAmount bool
+}
+
+var currentLocale atomic.Pointer[Locale]
+
+func init() {
+	currentLocale.Store(&Locale{printer: message.NewPrinter(language.English)})
+}
+
+func intl() *message.Printer {
+	return currentLocale.Load().printer
+}
+
+// Languages in which the currency symbol usually comes after the amount, such as 1.234,56 €
+var currencySymbolAfterAmountLanguages = []string{
+	"bg", "cs", "da", "de", "el", "es", "et", "fi", "fr", "hr", "hu", "is", "it", "lt",
+	"lv", "nb", "nn", "no", "pl", "pt", "ro", "ru", "sk", "sl", "sr", "sv", "uk", "vi",
+}
+
+func NewLocale(locale string) (*Locale, error) {
+	if locale == "" {
+		locale = "en"
+	}
+
+	tag, err := language.Parse(locale)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale '%s': %v", locale, err)
+	}
+
+	base, _ := tag.Base()
+
+	return &Locale{
+		printer:                   message.NewPrinter(tag),
+		currencySymbolAfterAmount: slices.Contains(currencySymbolAfterAmountLanguages, base.String()),
+	}, nil
+}
+
+// Changes how numbers and prices get formatted in templates rendered from now on
+func SetLocale(locale *Locale) {
+	currentLocale.Store(locale)
+}
+
+func formatCurrency(symbol string, amount float64) string {
+	locale := currentLocale.Load()
+	formatted := locale.printer.Sprintf("%.2f", amount)
+
+	if symbol == "" {
+		return formatted
+	}
+
+	if locale.currencySymbolAfterAmount {
+		return formatted + "\u00a0" + symbol
+	}
+
+	// the sign goes before the symbol, as in -$12.00
+	sign := ""
+
+	if amount < 0 {
+		sign = "-"
+		formatted = locale.printer.Sprintf("%.2f", -amount)
+	}
+
+	// symbols such as kr or Fr would otherwise run into the amount
+	if last, _ := utf8.DecodeLastRuneInString(symbol); unicode.IsLetter(last) {
+		return sign + symbol + "\u00a0" + formatted
+	}
+
+	return sign + symbol + formatted
+}
+
+// Hours and minutes, such as 5h 07m, or only the minutes when it's less than an hour
+func formatDuration(d time.Duration) string {
+	minutes := int(d.Minutes())
+
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
 
 func formatViewerCount(count int) string {
 	if count < 1_000 {
@@ -88,6 +265,23 @@ func formatViewerCount(count int) string {
 	return fmt.Sprintf("%.1fm", float64(count)/1_000_000)
 }
 
+var byteUnits = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+func formatBytes(bytes float64) string {
+	unit := 0
+
+	for bytes >= 1024 && unit < len(byteUnits)-1 {
+		bytes /= 1024
+		unit++
+	}
+
+	if unit == 0 || bytes >= 100 {
+		return fmt.Sprintf("%.0f %s", bytes, byteUnits[unit])
+	}
+
+	return fmt.Sprintf("%.1f %s", bytes, byteUnits[unit])
+}
+
 func relativeTimeSince(t time.Time) string {
 	delta := time.Since(t)
 
@@ -0,0 +1,187 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ActivityUsage struct {
+	Name     string
+	Duration time.Duration
+}
+
+type Activity struct {
+	Total time.Duration
+	// sorted from the most used, only up to the limit
+	Usage []ActivityUsage
+}
+
+// How much of the most used application or category this one was used, for the bars
+func (a *Activity) Percent(usage ActivityUsage) float64 {
+	if len(a.Usage) == 0 || a.Usage[0].Duration <= 0 {
+		return 0
+	}
+
+	return float64(usage.Duration) / float64(a.Usage[0].Duration) * 100
+}
+
+type activityWatchClassJson struct {
+	Name []string        `json:"name"`
+	Rule json.RawMessage `json:"rule"`
+}
+
+type activityWatchEventJson struct {
+	Duration float64        `json:"duration"`
+	Data     map[string]any `json:"data"`
+}
+
+// A string literal in the query language, which uses the same escapes as JSON
+func activityWatchString(value string) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+// The categories are the ones set up in the web UI, which stores them in the settings
+// of the server. Only categories with rules are passed on, the same way the web UI does
+func fetchActivityWatchCategories(ctx context.Context, client RequestDoer, baseURL string) (string, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/0/settings/classes", nil)
+	classes, err := decodeJsonFromRequest[[]activityWatchClassJson](client, request)
+
+	if err != nil {
+		return "", err
+	}
+
+	rules := make([][2]any, 0, len(classes))
+
+	for i := range classes {
+		var rule struct {
+			Type string `json:"type"`
+		}
+
+		if json.Unmarshal(classes[i].Rule, &rule) != nil || rule.Type == "none" {
+			continue
+		}
+
+		rules = append(rules, [2]any{classes[i].Name, classes[i].Rule})
+	}
+
+	if len(rules) == 0 {
+		return "", errors.New("no categories with rules have been set up")
+	}
+
+	encoded, err := json.Marshal(rules)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// Fetches how long each application, or each category when byCategory is set, has been used
+// today. Only the time during which the computer wasn't AFK is counted, as in the web UI. The
+// buckets of the watchers are those of the given host, or the first ones found if it's empty
+func FetchActivityWatchUsage(ctx context.Context, client RequestDoer, baseURL, hostname string, byCategory bool, limit int) (*Activity, error) {
+	client = clientOrDefault(client)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	bucket := func(prefix string) string {
+		if hostname == "" {
+			return fmt.Sprintf("find_bucket(%s)", activityWatchString(prefix))
+		}
+
+		return fmt.Sprintf("find_bucket(%s, %s)", activityWatchString(prefix), activityWatchString(hostname))
+	}
+
+	query := []string{
+		fmt.Sprintf("events = flood(query_bucket(%s));", bucket("aw-watcher-window_")),
+		fmt.Sprintf("not_afk = flood(query_bucket(%s));", bucket("aw-watcher-afk_")),
+		`not_afk = filter_keyvals(not_afk, "status", ["not-afk"]);`,
+		"events = filter_period_intersect(events, not_afk);",
+	}
+
+	key := "app"
+
+	if byCategory {
+		categories, err := fetchActivityWatchCategories(ctx, client, baseURL)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not get categories: %v", ErrNoContent, err)
+		}
+
+		key = "$category"
+		query = append(query, fmt.Sprintf("events = categorize(events, %s);", categories))
+	}
+
+	query = append(query,
+		fmt.Sprintf("events = merge_events_by_keys(events, [%s]);", activityWatchString(key)),
+		"RETURN = sort_by_duration(events);",
+	)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	body, _ := json.Marshal(map[string]any{
+		"timeperiods": []string{today.Format(time.RFC3339) + "/" + now.Format(time.RFC3339)},
+		"query":       query,
+	})
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/0/query/", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	// one list of events for each of the time periods
+	response, err := decodeJsonFromRequest[[][]activityWatchEventJson](client, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not query usage: %v", ErrNoContent, err)
+	}
+
+	activity := &Activity{}
+
+	if len(response) == 0 {
+		return activity, nil
+	}
+
+	for _, event := range response[0] {
+		duration := time.Duration(event.Duration * float64(time.Second))
+		activity.Total += duration
+
+		// too short to show up as anything other than 0m
+		if duration < time.Minute || (limit > 0 && len(activity.Usage) >= limit) {
+			continue
+		}
+
+		activity.Usage = append(activity.Usage, ActivityUsage{
+			Name:     activityWatchEventName(event.Data[key]),
+			Duration: duration,
+		})
+	}
+
+	return activity, nil
+}
+
+// Apps are strings while categories are lists with their parents first, such as ["Work", "Programming"]
+func activityWatchEventName(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []any:
+		names := make([]string, 0, len(value))
+
+		for _, name := range value {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+
+		return strings.Join(names, " › ")
+	}
+
+	return "Unknown"
+}
@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"net/http"
 	"strings"
 )
@@ -14,10 +15,10 @@ type adguardStatsResponse struct {
 	TopBlockedDomains []map[string]int `json:"top_blocked_domains"`
 }
 
-func FetchAdguardStats(instanceURL, username, password string) (*DNSStats, error) {
+func FetchAdguardStats(ctx context.Context, instanceURL, username, password string) (*DNSStats, error) {
 	requestURL := strings.TrimRight(instanceURL, "/") + "/control/stats"
 
-	request, err := http.NewRequest("GET", requestURL, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 
 	if err != nil {
 		return nil, err
@@ -60,7 +61,8 @@ func FetchAdguardStats(instanceURL, username, password string) (*DNSStats, error
 		}
 
 		stats.TopBlockedDomains = append(stats.TopBlockedDomains, DNSStatsBlockedDomain{
-			Domain: firstDomain,
+			Domain:  firstDomain,
+			Blocked: domain[firstDomain],
 		})
 
 		if stats.BlockedQueries > 0 {
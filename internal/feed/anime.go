@@ -0,0 +1,233 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	AnimeServiceAniList     = "anilist"
+	AnimeServiceMyAnimeList = "myanimelist"
+)
+
+const (
+	aniListEndpoint     = "https://graphql.anilist.co"
+	myAnimeListEndpoint = "https://api.myanimelist.net/v2"
+)
+
+type AnimeEpisode struct {
+	Title string
+	URL   string
+	// 0 when the service doesn't report it
+	Episode int
+	// how many episodes have been watched
+	Progress int
+	AiringAt time.Time
+}
+
+// How many episodes that have already aired are left to watch, 0 when it isn't known
+func (e *AnimeEpisode) Behind() int {
+	return max(e.Episode-1-e.Progress, 0)
+}
+
+const aniListAiringQuery = `query ($userName: String) {
+	MediaListCollection(userName: $userName, type: ANIME, status_in: [CURRENT, REPEATING]) {
+		lists {
+			entries {
+				progress
+				media {
+					siteUrl
+					title { userPreferred }
+					nextAiringEpisode { airingAt episode }
+				}
+			}
+		}
+	}
+}`
+
+// Gets the next episode of each anime on the list that airs before the given time,
+// sorted by when they air
+func FetchAnimeAiring(ctx context.Context, client RequestDoer, service, username, clientID string, until time.Time) ([]AnimeEpisode, error) {
+	client = clientOrDefault(client)
+
+	var episodes []AnimeEpisode
+	var err error
+
+	switch service {
+	case AnimeServiceAniList:
+		episodes, err = fetchAniListAiring(ctx, client, username)
+	case AnimeServiceMyAnimeList:
+		episodes, err = fetchMyAnimeListAiring(ctx, client, username, clientID)
+	default:
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	episodes = slices.DeleteFunc(episodes, func(episode AnimeEpisode) bool {
+		return episode.AiringAt.After(until)
+	})
+
+	slices.SortStableFunc(episodes, func(a, b AnimeEpisode) int {
+		return a.AiringAt.Compare(b.AiringAt)
+	})
+
+	return episodes, nil
+}
+
+func fetchAniListAiring(ctx context.Context, client RequestDoer, username string) ([]AnimeEpisode, error) {
+	body, err := json.Marshal(map[string]any{
+		"query":     aniListAiringQuery,
+		"variables": map[string]string{"userName": username},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", aniListEndpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var episodes []AnimeEpisode
+
+	for _, entry := range gjson.Get(response, "data.MediaListCollection.lists.#.entries|@flatten").Array() {
+		media := entry.Get("media")
+		next := media.Get("nextAiringEpisode")
+
+		// finished or not yet scheduled
+		if !next.Exists() || next.Type == gjson.Null {
+			continue
+		}
+
+		episodes = append(episodes, AnimeEpisode{
+			Title:    media.Get("title.userPreferred").String(),
+			URL:      media.Get("siteUrl").String(),
+			Episode:  int(next.Get("episode").Int()),
+			Progress: int(entry.Get("progress").Int()),
+			AiringAt: time.Unix(next.Get("airingAt").Int(), 0),
+		})
+	}
+
+	return episodes, nil
+}
+
+var myAnimeListWeekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// MyAnimeList only has the day of the week and time of the broadcast in Japan, so this is when
+// it next comes around, which is off for the weeks that the anime takes a break
+func nextMyAnimeListBroadcast(day, clock string, now time.Time) (time.Time, bool) {
+	weekday, known := myAnimeListWeekdays[day]
+
+	if !known {
+		return time.Time{}, false
+	}
+
+	parsed, err := time.Parse("15:04", clock)
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	japan, err := time.LoadLocation("Asia/Tokyo")
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	now = now.In(japan)
+	broadcast := time.Date(now.Year(), now.Month(), now.Day(), parsed.Hour(), parsed.Minute(), 0, 0, japan)
+	broadcast = broadcast.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7)
+
+	if broadcast.Before(now) {
+		broadcast = broadcast.AddDate(0, 0, 7)
+	}
+
+	return broadcast, true
+}
+
+func fetchMyAnimeListAiring(ctx context.Context, client RequestDoer, username, clientID string) ([]AnimeEpisode, error) {
+	query := url.Values{}
+	query.Set("status", "watching")
+	query.Set("fields", "list_status,broadcast,status")
+	query.Set("limit", "1000")
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		myAnimeListEndpoint+"/users/"+url.PathEscape(username)+"/animelist?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-MAL-CLIENT-ID", clientID)
+
+	response, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var episodes []AnimeEpisode
+
+	for _, entry := range gjson.Get(response, "data").Array() {
+		node := entry.Get("node")
+
+		if node.Get("status").String() != "currently_airing" {
+			continue
+		}
+
+		airingAt, ok := nextMyAnimeListBroadcast(
+			strings.ToLower(node.Get("broadcast.day_of_the_week").String()),
+			node.Get("broadcast.start_time").String(),
+			now,
+		)
+
+		if !ok {
+			continue
+		}
+
+		episodes = append(episodes, AnimeEpisode{
+			Title:    node.Get("title").String(),
+			URL:      "https://myanimelist.net/anime/" + strconv.FormatInt(node.Get("id").Int(), 10),
+			Progress: int(entry.Get("list_status.num_episodes_watched").Int()),
+			AiringAt: airingAt,
+		})
+	}
+
+	return episodes, nil
+}
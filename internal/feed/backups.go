@@ -0,0 +1,198 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type Backup struct {
+	Name string
+	Time time.Time
+	// whether the backup itself reported a failure, regardless of its age
+	Failed  bool
+	Message string
+}
+
+// Reads the latest backup from a JSON file written by the backup job, which can be the output of
+// `restic snapshots --json`, `borg list --json`, `borgmatic list --json` or an object such as
+// {"time": "2024-05-01T03:00:00Z", "status": "success", "message": "..."} for anything else
+func ReadBackupStatusFile(path string) (*Backup, error) {
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !gjson.ValidBytes(contents) {
+		return nil, fmt.Errorf("%s does not contain valid JSON", path)
+	}
+
+	parsed := gjson.ParseBytes(contents)
+	var backup *Backup
+
+	switch {
+	// restic, a list of snapshots
+	case parsed.IsArray() && parsed.Get("0.time").Exists():
+		backup = latestBackup(parsed.Array(), "time", time.RFC3339Nano)
+	// borgmatic, a list with the output of borg for each repository
+	case parsed.IsArray() && parsed.Get("0.archives").Exists():
+		backup = latestBackup(parsed.Get("#.archives|@flatten").Array(), "start", "2006-01-02T15:04:05.999999")
+	// borg
+	case parsed.Get("archives").Exists():
+		backup = latestBackup(parsed.Get("archives").Array(), "start", "2006-01-02T15:04:05.999999")
+	case parsed.IsObject():
+		backup, err = parseBackupStatusObject(parsed)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if backup == nil {
+		return nil, errors.New("no backups found")
+	}
+
+	return backup, nil
+}
+
+// Finds the item with the latest time, times without an offset are in local time,
+// which is how borg writes them
+func latestBackup(items []gjson.Result, timeKey, layout string) *Backup {
+	var latest *Backup
+
+	for _, item := range items {
+		value := item.Get(timeKey).String()
+
+		// older versions of borg only have the time
+		if value == "" && timeKey == "start" {
+			value = item.Get("time").String()
+		}
+
+		t, err := time.ParseInLocation(layout, value, time.Local)
+
+		if err != nil {
+			continue
+		}
+
+		if latest == nil || t.After(latest.Time) {
+			latest = &Backup{Time: t}
+		}
+	}
+
+	return latest
+}
+
+func parseBackupStatusObject(parsed gjson.Result) (*Backup, error) {
+	value := parsed.Get("time")
+	backup := &Backup{Message: parsed.Get("message").String()}
+
+	switch value.Type {
+	case gjson.Number:
+		backup.Time = time.Unix(value.Int(), 0)
+	case gjson.String:
+		t, err := time.Parse(time.RFC3339, value.String())
+
+		if err != nil {
+			return nil, fmt.Errorf("time must be in RFC3339 format or a unix timestamp: %v", err)
+		}
+
+		backup.Time = t
+	default:
+		return nil, errors.New("time is missing")
+	}
+
+	switch strings.ToLower(parsed.Get("status").String()) {
+	case "", "success", "succeeded", "ok":
+	default:
+		backup.Failed = true
+	}
+
+	return backup, nil
+}
+
+type pbsSnapshotsResponseJson struct {
+	Data []struct {
+		BackupType   string `json:"backup-type"`
+		BackupID     string `json:"backup-id"`
+		BackupTime   int64  `json:"backup-time"`
+		Comment      string `json:"comment"`
+		Verification *struct {
+			State string `json:"state"`
+		} `json:"verification"`
+	} `json:"data"`
+}
+
+// Returns the latest snapshot of each backup group in the datastore, such as
+// each VM, sorted by name. The token is in the form of user@realm!name:secret
+func FetchProxmoxBackupServerBackups(ctx context.Context, client RequestDoer, baseURL, datastore, namespace, token string) ([]Backup, error) {
+	query := url.Values{}
+
+	if namespace != "" {
+		query.Set("ns", namespace)
+	}
+
+	request, _ := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf("%s/api2/json/admin/datastore/%s/snapshots?%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(datastore), query.Encode()),
+		nil,
+	)
+	request.Header.Set("Authorization", "PBSAPIToken="+token)
+
+	response, err := decodeJsonFromRequest[pbsSnapshotsResponseJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]*Backup)
+	var names []string
+
+	for _, snapshot := range response.Data {
+		group := snapshot.BackupType + "/" + snapshot.BackupID
+		t := time.Unix(snapshot.BackupTime, 0)
+		backup, exists := latest[group]
+
+		if !exists {
+			backup = &Backup{}
+			latest[group] = backup
+			names = append(names, group)
+		} else if !t.After(backup.Time) {
+			continue
+		}
+
+		backup.Time = t
+		backup.Name = group
+		backup.Failed = snapshot.Verification != nil && snapshot.Verification.State == "failed"
+		backup.Message = ""
+
+		if snapshot.Comment != "" {
+			backup.Name = snapshot.Comment
+		}
+
+		if backup.Failed {
+			backup.Message = "Verification failed"
+		}
+	}
+
+	backups := make([]Backup, 0, len(names))
+
+	for _, name := range names {
+		backups = append(backups, *latest[name])
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return backups, nil
+}
@@ -0,0 +1,268 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How many of the alerts with an active decision are requested from CrowdSec,
+// the ones of the community blocklist aren't included so it's rarely reached
+const crowdSecAlertsLimit = 1000
+
+type Ban struct {
+	IP string `json:"ip"`
+	// the jail for Fail2ban and the scenario for CrowdSec
+	Reason string `json:"reason"`
+	// the ISO code of the country, empty when it's not known
+	Country string    `json:"country,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+type BansRequest struct {
+	// empty for the local Fail2ban
+	URL           string
+	Token         string
+	AllowInsecure bool
+	// set for CrowdSec sources, whose Local API is requested instead of Fail2ban
+	CrowdSec *CrowdSecClient
+}
+
+func runFail2banClient(ctx context.Context, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "fail2ban-client", args...).Output()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("fail2ban-client %s: %s", args[0], bytes.TrimSpace(exitErr.Stderr))
+		}
+
+		return "", fmt.Errorf("fail2ban-client %s: %v", args[0], err)
+	}
+
+	return string(output), nil
+}
+
+// The jails are listed on the last line of the status, such as
+//
+//	Status
+//	|- Number of jail:	2
+//	`- Jail list:	nginx-http-auth, sshd
+func parseFail2banJails(status string) []string {
+	jails := make([]string, 0)
+
+	for _, line := range strings.Split(status, "\n") {
+		_, list, found := strings.Cut(line, "Jail list:")
+
+		if !found {
+			continue
+		}
+
+		for _, jail := range strings.Split(list, ",") {
+			if jail = strings.TrimSpace(jail); jail != "" {
+				jails = append(jails, jail)
+			}
+		}
+	}
+
+	return jails
+}
+
+// Each of the banned addresses is on a line of its own along with when it got banned and
+// when the ban ends, in the local time of the server, such as
+//
+//	192.0.2.10 	2024-10-13 09:12:44 + 600 = 2024-10-13 09:22:44
+func parseFail2banBans(jail, output string) []Ban {
+	bans := make([]Ban, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 3 {
+			continue
+		}
+
+		banned, err := time.ParseInLocation("2006-01-02 15:04:05", fields[1]+" "+fields[2], time.Local)
+
+		if err != nil {
+			continue
+		}
+
+		bans = append(bans, Ban{IP: fields[0], Reason: jail, Time: banned})
+	}
+
+	return bans
+}
+
+// Lists the addresses which are currently banned by the Fail2ban running on the same
+// machine as Glance, through its socket, which usually requires running as root
+func FetchLocalFail2banBans(ctx context.Context) ([]Ban, error) {
+	status, err := runFail2banClient(ctx, "status")
+
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, 0)
+
+	for _, jail := range parseFail2banJails(status) {
+		output, err := runFail2banClient(ctx, "get", jail, "banip", "--with-time")
+
+		if err != nil {
+			return nil, err
+		}
+
+		bans = append(bans, parseFail2banBans(jail, output)...)
+	}
+
+	return bans, nil
+}
+
+// Reads the alerts of the Local API of CrowdSec, which requires the credentials of a machine
+// rather than the key of a bouncer since only alerts have the country of the address. The
+// tokens it hands out after logging in last for an hour and get reused until then
+type CrowdSecClient struct {
+	URL       string
+	MachineID string
+	Password  string
+	Client    RequestDoer
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+type crowdSecLoginJson struct {
+	Token  string    `json:"token"`
+	Expire time.Time `json:"expire"`
+}
+
+type crowdSecAlertJson struct {
+	Scenario  string    `json:"scenario"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    struct {
+		Value string `json:"value"`
+		CN    string `json:"cn"`
+	} `json:"source"`
+	Decisions []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"decisions"`
+}
+
+// Must be called with the mutex held
+func (c *CrowdSecClient) token(ctx context.Context) (string, error) {
+	return c.oauth.token(ctx, func(ctx context.Context) (oauthToken, error) {
+		body, _ := json.Marshal(map[string]any{
+			"machine_id": c.MachineID,
+			"password":   c.Password,
+			"scenarios":  []string{},
+		})
+
+		request, _ := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(c.URL, "/")+"/v1/watchers/login", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+
+		response, err := decodeJsonFromRequest[crowdSecLoginJson](clientOrDefault(c.Client), request)
+
+		if err != nil {
+			return oauthToken{}, fmt.Errorf("could not log in: %v", err)
+		}
+
+		return oauthToken{Access: response.Token, Expires: response.Expire}, nil
+	})
+}
+
+// Lists the addresses which are currently banned, or have any other kind of decision such
+// as a captcha, because of the alerts of the machines connected to the Local API
+func (c *CrowdSecClient) FetchBans(ctx context.Context) ([]Ban, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("has_active_decision", "true")
+	query.Set("include_capi", "false")
+	query.Set("limit", fmt.Sprint(crowdSecAlertsLimit))
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.URL, "/")+"/v1/alerts?"+query.Encode(), nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	alerts, err := decodeJsonFromRequest[[]crowdSecAlertJson](clientOrDefault(c.Client), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bans := make([]Ban, 0, len(alerts))
+
+	for _, alert := range alerts {
+		ip := alert.Source.Value
+
+		if len(alert.Decisions) > 0 {
+			ip = alert.Decisions[0].Value
+		}
+
+		bans = append(bans, Ban{
+			IP:      ip,
+			Reason:  alert.Scenario,
+			Country: alert.Source.CN,
+			Time:    alert.CreatedAt,
+		})
+	}
+
+	return bans, nil
+}
+
+func fetchBansTask(ctx context.Context, request *BansRequest) ([]Ban, error) {
+	switch {
+	case request.CrowdSec != nil:
+		return request.CrowdSec.FetchBans(ctx)
+	case request.URL == "":
+		return FetchLocalFail2banBans(ctx)
+	}
+
+	return fetchFromRemoteInstance[[]Ban](ctx, request.URL, "/api/fail2ban", request.Token, request.AllowInsecure)
+}
+
+func FetchBansForSources(ctx context.Context, requests []*BansRequest) ([][]Ban, error) {
+	job := newJob(taskWithContext(ctx, fetchBansTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to fetch bans", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch bans of %d source(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
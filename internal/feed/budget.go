@@ -0,0 +1,225 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BudgetCategory struct {
+	Name     string
+	Spent    float64
+	Budgeted float64
+}
+
+// How much of the budget has been spent, can go over 100
+func (c *BudgetCategory) SpentPercent() float64 {
+	if c.Budgeted <= 0 {
+		if c.Spent > 0 {
+			return 100
+		}
+
+		return 0
+	}
+
+	return c.Spent / c.Budgeted * 100
+}
+
+func (c *BudgetCategory) IsOver() bool {
+	return c.Spent > c.Budgeted
+}
+
+type Budget struct {
+	Categories []BudgetCategory
+	// the symbol of the currency when the service reports it
+	Currency string
+	Month    time.Time
+}
+
+func (b *Budget) TotalSpent() float64 {
+	var total float64
+
+	for i := range b.Categories {
+		total += b.Categories[i].Spent
+	}
+
+	return total
+}
+
+func (b *Budget) TotalBudgeted() float64 {
+	var total float64
+
+	for i := range b.Categories {
+		total += b.Categories[i].Budgeted
+	}
+
+	return total
+}
+
+func monthBounds(now time.Time) (time.Time, time.Time) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	return start, start.AddDate(0, 1, -1)
+}
+
+type fireflyAmountJson struct {
+	Sum            string `json:"sum"`
+	CurrencySymbol string `json:"currency_symbol"`
+}
+
+type fireflyBudgetsResponseJson struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name   string              `json:"name"`
+			Active bool                `json:"active"`
+			Spent  []fireflyAmountJson `json:"spent"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type fireflyBudgetLimitsResponseJson struct {
+	Data []struct {
+		Attributes struct {
+			BudgetID       string `json:"budget_id"`
+			Amount         string `json:"amount"`
+			CurrencySymbol string `json:"currency_symbol"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+func newFireflyRequest(ctx context.Context, baseURL, token, path string, now time.Time) *http.Request {
+	start, end := monthBounds(now)
+
+	query := url.Values{}
+	query.Set("start", start.Format(time.DateOnly))
+	query.Set("end", end.Format(time.DateOnly))
+	query.Set("limit", "100")
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+path+"?"+query.Encode(), nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/vnd.api+json")
+
+	return request
+}
+
+// Only budgets which are active are included, with what was spent from them and
+// their limits for the current month. Firefly reports spending as negative amounts
+func FetchBudgetFromFirefly(ctx context.Context, client RequestDoer, baseURL, token string) (*Budget, error) {
+	client = clientOrDefault(client)
+	now := time.Now()
+
+	budgets, err := decodeJsonFromRequest[fireflyBudgetsResponseJson](client, newFireflyRequest(ctx, baseURL, token, "/api/v1/budgets", now))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get budgets: %v", ErrNoContent, err)
+	}
+
+	limits, err := decodeJsonFromRequest[fireflyBudgetLimitsResponseJson](client, newFireflyRequest(ctx, baseURL, token, "/api/v1/budget-limits", now))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get budget limits: %v", ErrNoContent, err)
+	}
+
+	budgeted := make(map[string]float64, len(limits.Data))
+	start, _ := monthBounds(now)
+	budget := &Budget{Month: start}
+
+	for _, limit := range limits.Data {
+		amount, _ := strconv.ParseFloat(limit.Attributes.Amount, 64)
+		budgeted[limit.Attributes.BudgetID] += amount
+
+		if budget.Currency == "" {
+			budget.Currency = limit.Attributes.CurrencySymbol
+		}
+	}
+
+	for _, b := range budgets.Data {
+		if !b.Attributes.Active {
+			continue
+		}
+
+		category := BudgetCategory{Name: b.Attributes.Name, Budgeted: budgeted[b.ID]}
+
+		for _, spent := range b.Attributes.Spent {
+			amount, _ := strconv.ParseFloat(spent.Sum, 64)
+			category.Spent -= amount
+
+			if budget.Currency == "" {
+				budget.Currency = spent.CurrencySymbol
+			}
+		}
+
+		budget.Categories = append(budget.Categories, category)
+	}
+
+	return budget, nil
+}
+
+type actualBudgetMonthResponseJson struct {
+	Data struct {
+		CategoryGroups []struct {
+			IsIncome   bool `json:"is_income"`
+			Hidden     bool `json:"hidden"`
+			Categories []struct {
+				Name     string `json:"name"`
+				IsIncome bool   `json:"is_income"`
+				Hidden   bool   `json:"hidden"`
+				Budgeted int64  `json:"budgeted"`
+				Spent    int64  `json:"spent"`
+			} `json:"categories"`
+		} `json:"categoryGroups"`
+	} `json:"data"`
+}
+
+// Actual Budget doesn't have an HTTP API of its own, so this goes through
+// actual-http-api, which wraps its API. Amounts are in cents and spending is negative
+func FetchBudgetFromActual(ctx context.Context, client RequestDoer, baseURL, apiKey, syncID, encryptionPassword string) (*Budget, error) {
+	start, _ := monthBounds(time.Now())
+	month := start.Format("2006-01")
+	request, _ := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf("%s/v1/budgets/%s/months/%s", strings.TrimSuffix(baseURL, "/"), url.PathEscape(syncID), month),
+		nil,
+	)
+
+	request.Header.Set("x-api-key", apiKey)
+
+	if encryptionPassword != "" {
+		request.Header.Set("budget-encryption-password", encryptionPassword)
+	}
+
+	response, err := decodeJsonFromRequest[actualBudgetMonthResponseJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get budget month: %v", ErrNoContent, err)
+	}
+
+	budget := &Budget{Month: start}
+
+	for _, group := range response.Data.CategoryGroups {
+		if group.IsIncome || group.Hidden {
+			continue
+		}
+
+		for _, c := range group.Categories {
+			if c.IsIncome || c.Hidden {
+				continue
+			}
+
+			budget.Categories = append(budget.Categories, BudgetCategory{
+				Name:     c.Name,
+				Spent:    math.Abs(float64(min(c.Spent, 0))) / 100,
+				Budgeted: float64(c.Budgeted) / 100,
+			})
+		}
+	}
+
+	return budget, nil
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const defaultCaddyAdminURL = "http://localhost:2019"
+
+type CaddyDiscoveryRequest struct {
+	APIURL        string
+	Group         string
+	CheckBackends bool
+}
+
+type caddyHandlerJson struct {
+	Handler   string `json:"handler"`
+	Upstreams []struct {
+		Dial string `json:"dial"`
+	} `json:"upstreams"`
+	Routes []caddyRouteJson `json:"routes"`
+}
+
+type caddyRouteJson struct {
+	ID    string `json:"@id"`
+	Match []struct {
+		Host []string `json:"host"`
+		Path []string `json:"path"`
+	} `json:"match"`
+	Handle []caddyHandlerJson `json:"handle"`
+}
+
+type caddyServerJson struct {
+	Listen         []string         `json:"listen"`
+	Routes         []caddyRouteJson `json:"routes"`
+	AutomaticHTTPS *struct {
+		Disable bool `json:"disable"`
+	} `json:"automatic_https"`
+}
+
+// Caddyfile site blocks get compiled into a subroute, so the
+// reverse_proxy handler is usually one or more levels deep
+func findCaddyUpstream(handlers []caddyHandlerJson) string {
+	for i := range handlers {
+		if handlers[i].Handler == "reverse_proxy" && len(handlers[i].Upstreams) > 0 {
+			return handlers[i].Upstreams[0].Dial
+		}
+
+		for r := range handlers[i].Routes {
+			if upstream := findCaddyUpstream(handlers[i].Routes[r].Handle); upstream != "" {
+				return upstream
+			}
+		}
+	}
+
+	return ""
+}
+
+// Automatic HTTPS is enabled by default for any server that doesn't only
+// listen on the HTTP port, non-standard ports get included in the URL
+func caddyServerBaseURL(server *caddyServerJson) (string, string) {
+	scheme := "https"
+	var port string
+
+	if len(server.Listen) > 0 {
+		_, port, _ = net.SplitHostPort(server.Listen[0])
+	}
+
+	if port == "80" || (server.AutomaticHTTPS != nil && server.AutomaticHTTPS.Disable) {
+		scheme = "http"
+	}
+
+	if port == "80" || port == "443" {
+		port = ""
+	}
+
+	return scheme, port
+}
+
+// Lists the sites from the config of Caddy's admin API, every route which matches
+// on a host that isn't a wildcard becomes a service named after that host
+func DiscoverServicesFromCaddy(ctx context.Context, request *CaddyDiscoveryRequest) (DiscoveredServices, error) {
+	apiURL := request.APIURL
+
+	if apiURL == "" {
+		apiURL = defaultCaddyAdminURL
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(apiURL, "/")+"/config/apps/http/servers", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := decodeJsonFromRequest[map[string]caddyServerJson](defaultClient, httpRequest)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get servers config: %w", err)
+	}
+
+	services := make(DiscoveredServices, 0)
+
+	for _, server := range servers {
+		scheme, port := caddyServerBaseURL(&server)
+
+		for r := range server.Routes {
+			route := &server.Routes[r]
+
+			for m := range route.Match {
+				for _, host := range route.Match[m].Host {
+					if strings.Contains(host, "*") {
+						continue
+					}
+
+					url := scheme + "://" + host
+
+					if port != "" {
+						url += ":" + port
+					}
+
+					if len(route.Match[m].Path) > 0 {
+						url += strings.TrimSuffix(strings.TrimSuffix(route.Match[m].Path[0], "*"), "/")
+					}
+
+					service := DiscoveredService{
+						Name:  host,
+						URL:   url,
+						Group: request.Group,
+					}
+
+					if route.ID != "" {
+						service.Name = route.ID
+					}
+
+					if request.CheckBackends {
+						if upstream := findCaddyUpstream(route.Handle); upstream != "" && !strings.HasPrefix(upstream, "unix/") {
+							service.CheckURL = "http://" + upstream
+						}
+					}
+
+					services = append(services, service)
+				}
+			}
+		}
+	}
+
+	return services.SortByName(), nil
+}
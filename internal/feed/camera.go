@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Snapshots from webcams are usually around a few hundred kilobytes
+const snapshotMaxSize = 8 << 20
+
+const cameraCaptureTimeout = 15 * time.Second
+
+var errSnapshotTooLarge = errors.New("image is too large")
+
+// Keeps the first limit bytes written to it and drops the rest. Writes never fail, since
+// os/exec stops copying the output of the command once one does, which would then fail
+// commands that e.g. only print a lot of warnings
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.exceeded = true
+		b.Buffer.Write(p[:max(remaining, 0)])
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+type CameraSnapshot struct {
+	ContentType string
+	Image       []byte
+}
+
+type CameraSnapshotRequest struct {
+	// either an http(s) URL which responds with an image or an rtsp(s) URL of a stream
+	URL      string
+	Username string
+	Password string
+	// the Glance instance which captures the snapshot, empty for the one this is running on
+	RemoteURL           string
+	RemoteToken         string
+	RemoteAllowInsecure bool
+}
+
+func fetchSnapshot(client RequestDoer, request *http.Request) (string, []byte, error) {
+	response, err := client.Do(request)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	contentType := response.Header.Get("Content-Type")
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", nil, fmt.Errorf("expected an image but got %q", contentType)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(response.Body, snapshotMaxSize+1))
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(image) > snapshotMaxSize {
+		return "", nil, errSnapshotTooLarge
+	}
+
+	return contentType, image, nil
+}
+
+func IsRTSPURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+
+	return err == nil && (parsed.Scheme == "rtsp" || parsed.Scheme == "rtsps") && parsed.Host != ""
+}
+
+// Grabs a single frame of the stream as a JPEG through ffmpeg, which needs to be installed.
+// The stream is read over TCP since UDP is often blocked or mangled by NAT and containers
+func CaptureRTSPSnapshot(ctx context.Context, streamURL string) (*CameraSnapshot, error) {
+	if !IsRTSPURL(streamURL) {
+		return nil, errors.New("only rtsp and rtsps streams can be captured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cameraCaptureTimeout)
+	defer cancel()
+
+	output := &limitedBuffer{limit: snapshotMaxSize}
+	stderr := &limitedBuffer{limit: 4096}
+
+	command := exec.CommandContext(
+		ctx, "ffmpeg", "-nostdin", "-loglevel", "error",
+		"-rtsp_transport", "tcp", "-i", streamURL,
+		"-frames:v", "1", "-f", "image2", "-c:v", "mjpeg", "pipe:1",
+	)
+	command.Stdout = output
+	command.Stderr = stderr
+
+	err := command.Run()
+
+	if output.exceeded {
+		return nil, errSnapshotTooLarge
+	}
+
+	if err != nil {
+		// the URL usually has the credentials of the camera in it
+		message := strings.ReplaceAll(strings.TrimSpace(stderr.String()), streamURL, "<stream>")
+
+		if message == "" {
+			return nil, fmt.Errorf("ffmpeg: %v", err)
+		}
+
+		return nil, fmt.Errorf("ffmpeg: %v: %s", err, truncateString(message, 256))
+	}
+
+	if output.Len() == 0 {
+		return nil, errors.New("ffmpeg did not capture a frame")
+	}
+
+	return &CameraSnapshot{ContentType: "image/jpeg", Image: output.Bytes()}, nil
+}
+
+func FetchCameraSnapshot(ctx context.Context, client RequestDoer, request *CameraSnapshotRequest) (*CameraSnapshot, error) {
+	if request.RemoteURL != "" {
+		return fetchRemoteCameraSnapshot(ctx, request)
+	}
+
+	if IsRTSPURL(request.URL) {
+		return CaptureRTSPSnapshot(ctx, request.URL)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", request.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != "" || request.Password != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	contentType, image, err := fetchSnapshot(clientOrDefault(client), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &CameraSnapshot{ContentType: contentType, Image: image}, nil
+}
+
+// Asks another Glance instance to capture the stream, for cameras which can only be
+// reached from its network
+func fetchRemoteCameraSnapshot(ctx context.Context, request *CameraSnapshotRequest) (*CameraSnapshot, error) {
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(request.RemoteURL, "/")+"/api/camera-snapshot?url="+url.QueryEscape(request.URL),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.RemoteToken != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+request.RemoteToken)
+	}
+
+	client := defaultClient
+
+	if request.RemoteAllowInsecure {
+		client = defaultInsecureClient
+	}
+
+	contentType, image, err := fetchSnapshot(client, httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &CameraSnapshot{ContentType: contentType, Image: image}, nil
+}
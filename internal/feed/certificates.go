@@ -0,0 +1,200 @@
+package feed
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ACME clients renew certificates once a third of their lifetime is left, which is 30 days
+// for the ones of Let's Encrypt, so certificates that are a couple of days past that point
+// have most likely failed to renew
+const certificateRenewalGracePeriod = 2 * 24 * time.Hour
+
+type ManagedCertificate struct {
+	// the subject followed by the rest of the names the certificate is valid for
+	Domains   []string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (c *ManagedCertificate) IsExpired(now time.Time) bool {
+	return now.After(c.NotAfter)
+}
+
+func (c *ManagedCertificate) IsRenewalOverdue(now time.Time) bool {
+	renewAt := c.NotAfter.Add(-c.NotAfter.Sub(c.NotBefore) / 3)
+
+	return now.After(renewAt.Add(certificateRenewalGracePeriod))
+}
+
+// Only the first certificate of a chain is read, which is the one of the domains
+func parseManagedCertificate(data []byte) (*ManagedCertificate, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+
+		if block == nil {
+			return nil, errors.New("no certificate found")
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		managed := &ManagedCertificate{
+			NotBefore: certificate.NotBefore,
+			NotAfter:  certificate.NotAfter,
+			Issuer:    certificate.Issuer.CommonName,
+		}
+
+		if managed.Issuer == "" && len(certificate.Issuer.Organization) > 0 {
+			managed.Issuer = certificate.Issuer.Organization[0]
+		}
+
+		if certificate.Subject.CommonName != "" {
+			managed.Domains = append(managed.Domains, certificate.Subject.CommonName)
+		}
+
+		for _, name := range certificate.DNSNames {
+			if !slices.Contains(managed.Domains, name) {
+				managed.Domains = append(managed.Domains, name)
+			}
+		}
+
+		return managed, nil
+	}
+}
+
+type traefikACMEJson map[string]*struct {
+	Certificates []struct {
+		// base64 encoded PEM
+		Certificate string `json:"certificate"`
+	} `json:"Certificates"`
+}
+
+// Reads the certificates of all of the resolvers from the acme.json file of Traefik
+func ReadTraefikCertificates(path string) ([]ManagedCertificate, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var resolvers traefikACMEJson
+
+	if err := json.Unmarshal(data, &resolvers); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+
+	certificates := make([]ManagedCertificate, 0)
+
+	for _, resolver := range resolvers {
+		if resolver == nil {
+			continue
+		}
+
+		for _, entry := range resolver.Certificates {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Certificate)
+
+			if err != nil {
+				continue
+			}
+
+			certificate, err := parseManagedCertificate(decoded)
+
+			if err != nil {
+				continue
+			}
+
+			certificates = append(certificates, *certificate)
+		}
+	}
+
+	return certificates, nil
+}
+
+// Reads the certificates from the data directory of Caddy, where each one is stored
+// in certificates/{issuer}/{name}/{name}.crt
+func ReadCaddyCertificates(dataDir string) ([]ManagedCertificate, error) {
+	certificates := make([]ManagedCertificate, 0)
+
+	err := filepath.WalkDir(filepath.Join(dataDir, "certificates"), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".crt") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		if certificate, err := parseManagedCertificate(data); err == nil {
+			certificates = append(certificates, *certificate)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return certificates, nil
+}
+
+// Reads the certificates from the config directory of certbot, where the current one
+// of each certificate is linked to from live/{name}/cert.pem
+func ReadCertbotCertificates(configDir string) ([]ManagedCertificate, error) {
+	live := filepath.Join(configDir, "live")
+	entries, err := os.ReadDir(live)
+
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := make([]ManagedCertificate, 0)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(live, entry.Name(), "cert.pem"))
+
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if certificate, err := parseManagedCertificate(data); err == nil {
+			certificates = append(certificates, *certificate)
+		}
+	}
+
+	return certificates, nil
+}
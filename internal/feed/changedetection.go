@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -15,6 +16,8 @@ type ChangeDetectionWatch struct {
 	LastChanged  time.Time
 	DiffURL      string
 	PreviousHash string
+	// only set for changes received through a webhook
+	Message string
 }
 
 type ChangeDetectionWatches []ChangeDetectionWatch
@@ -35,8 +38,8 @@ type changeDetectionResponseJson struct {
 	PreviousHash string `json:"previous_md5"`
 }
 
-func FetchWatchUUIDsFromChangeDetection(instanceURL string, token string) ([]string, error) {
-	request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/watch", instanceURL), nil)
+func FetchWatchUUIDsFromChangeDetection(ctx context.Context, instanceURL string, token string) ([]string, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/watch", instanceURL), nil)
 
 	if token != "" {
 		request.Header.Add("x-api-key", token)
@@ -57,7 +60,7 @@ func FetchWatchUUIDsFromChangeDetection(instanceURL string, token string) ([]str
 	return uuids, nil
 }
 
-func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []string, token string) (ChangeDetectionWatches, error) {
+func FetchWatchesFromChangeDetection(ctx context.Context, instanceURL string, requestedWatchIDs []string, token string) (ChangeDetectionWatches, error) {
 	watches := make(ChangeDetectionWatches, 0, len(requestedWatchIDs))
 
 	if len(requestedWatchIDs) == 0 {
@@ -67,7 +70,7 @@ func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []str
 	requests := make([]*http.Request, len(requestedWatchIDs))
 
 	for i, repository := range requestedWatchIDs {
-		request, _ := http.NewRequest("GET", fmt.Sprintf("%s/api/v1/watch/%s", instanceURL, repository), nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/watch/%s", instanceURL, repository), nil)
 
 		if token != "" {
 			request.Header.Add("x-api-key", token)
@@ -77,7 +80,7 @@ func FetchWatchesFromChangeDetection(instanceURL string, requestedWatchIDs []str
 	}
 
 	task := decodeJsonFromRequestTask[changeDetectionResponseJson](defaultClient)
-	job := newJob(task, requests).withWorkers(15)
+	job := newJob(task, requests).withWorkers(15).withContext(ctx)
 	responses, errs, err := workerPoolDo(job)
 
 	if err != nil {
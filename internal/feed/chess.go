@@ -0,0 +1,300 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	ChessServiceLichess  = "lichess"
+	ChessServiceChessCom = "chess.com"
+)
+
+const (
+	lichessEndpoint  = "https://lichess.org"
+	chessComEndpoint = "https://api.chess.com"
+)
+
+type ChessRating struct {
+	Name   string
+	Rating int
+	// the rating is still uncertain because of how few games have been played
+	Provisional bool
+}
+
+type ChessGame struct {
+	URL            string
+	Opponent       string
+	OpponentRating int
+	// zero when there's no time limit
+	MoveBy time.Time
+}
+
+func (g *ChessGame) TimeLeftLabel() string {
+	left := time.Until(g.MoveBy)
+
+	switch {
+	case left < time.Hour:
+		return fmt.Sprintf("%dm", max(int(left.Minutes()), 0))
+	case left < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(left.Hours()))
+	}
+
+	return fmt.Sprintf("%d days", int(left.Hours()/24))
+}
+
+func (g *ChessGame) RunningOutOfTime() bool {
+	return !g.MoveBy.IsZero() && time.Until(g.MoveBy) < 24*time.Hour
+}
+
+type ChessPuzzle struct {
+	// only given by Chess.com
+	Title  string
+	URL    string
+	Rating int
+}
+
+type ChessStatus struct {
+	Ratings []ChessRating
+	// correspondence games where it's the player's move
+	Games  []ChessGame
+	Puzzle *ChessPuzzle
+}
+
+// The token is only used by Lichess, which doesn't make ongoing games public
+func FetchChessStatus(ctx context.Context, client RequestDoer, service, username, token string) (*ChessStatus, error) {
+	client = clientOrDefault(client)
+
+	var status *ChessStatus
+	var failed int
+	var err error
+
+	switch service {
+	case ChessServiceLichess:
+		status, failed, err = fetchLichessStatus(ctx, client, username, token)
+	case ChessServiceChessCom:
+		status, failed, err = fetchChessComStatus(ctx, client, username)
+	default:
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	// the games running out of time first, followed by the ones without a limit
+	slices.SortStableFunc(status.Games, func(a, b ChessGame) int {
+		switch {
+		case a.MoveBy.IsZero() && b.MoveBy.IsZero():
+			return 0
+		case a.MoveBy.IsZero():
+			return 1
+		case b.MoveBy.IsZero():
+			return -1
+		}
+
+		return a.MoveBy.Compare(b.MoveBy)
+	})
+
+	if failed > 0 {
+		return status, fmt.Errorf("%w: could not fetch %d section(s)", ErrPartialContent, failed)
+	}
+
+	return status, nil
+}
+
+func fetchChessJson(ctx context.Context, client RequestDoer, endpoint, token string) (gjson.Result, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	return gjson.Parse(body), nil
+}
+
+var lichessPerfs = []struct {
+	key  string
+	name string
+}{
+	{"bullet", "Bullet"},
+	{"blitz", "Blitz"},
+	{"rapid", "Rapid"},
+	{"classical", "Classical"},
+	{"correspondence", "Correspondence"},
+	{"puzzle", "Puzzles"},
+}
+
+func fetchLichessStatus(ctx context.Context, client RequestDoer, username, token string) (*ChessStatus, int, error) {
+	user, err := fetchChessJson(ctx, client, lichessEndpoint+"/api/user/"+url.PathEscape(username), token)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	status := &ChessStatus{}
+	perfs := user.Get("perfs")
+
+	// variants which were never played still have a default rating
+	for _, perf := range lichessPerfs {
+		if rating := perfs.Get(perf.key); rating.Get("games").Int() > 0 {
+			status.Ratings = append(status.Ratings, ChessRating{
+				Name:        perf.name,
+				Rating:      int(rating.Get("rating").Int()),
+				Provisional: rating.Get("prov").Bool(),
+			})
+		}
+	}
+
+	var failed int
+
+	if token != "" {
+		playing, err := fetchChessJson(ctx, client, lichessEndpoint+"/api/account/playing?nb=50", token)
+
+		if err != nil {
+			failed++
+			slog.Error("Failed to fetch Lichess games", "error", err)
+		} else {
+			now := time.Now()
+
+			playing.Get("nowPlaying").ForEach(func(_, game gjson.Result) bool {
+				if !game.Get("isMyTurn").Bool() || game.Get("speed").String() != "correspondence" {
+					return true
+				}
+
+				chessGame := ChessGame{
+					URL:            lichessEndpoint + "/" + game.Get("gameId").String(),
+					Opponent:       game.Get("opponent.username").String(),
+					OpponentRating: int(game.Get("opponent.rating").Int()),
+				}
+
+				if seconds := game.Get("secondsLeft"); seconds.Type == gjson.Number {
+					chessGame.MoveBy = now.Add(time.Duration(seconds.Int()) * time.Second)
+				}
+
+				status.Games = append(status.Games, chessGame)
+				return true
+			})
+		}
+	}
+
+	puzzle, err := fetchChessJson(ctx, client, lichessEndpoint+"/api/puzzle/daily", "")
+
+	if err != nil {
+		failed++
+		slog.Error("Failed to fetch Lichess daily puzzle", "error", err)
+	} else {
+		status.Puzzle = &ChessPuzzle{
+			URL:    lichessEndpoint + "/training/" + puzzle.Get("puzzle.id").String(),
+			Rating: int(puzzle.Get("puzzle.rating").Int()),
+		}
+	}
+
+	return status, failed, nil
+}
+
+var chessComStats = []struct {
+	key    string
+	name   string
+	rating string
+}{
+	{"chess_bullet", "Bullet", "last.rating"},
+	{"chess_blitz", "Blitz", "last.rating"},
+	{"chess_rapid", "Rapid", "last.rating"},
+	{"chess_daily", "Daily", "last.rating"},
+	// there's no current puzzle rating, only the highest
+	{"tactics", "Puzzles", "highest.rating"},
+}
+
+func fetchChessComStatus(ctx context.Context, client RequestDoer, username string) (*ChessStatus, int, error) {
+	username = strings.ToLower(username)
+	player := chessComEndpoint + "/pub/player/" + url.PathEscape(username)
+	stats, err := fetchChessJson(ctx, client, player+"/stats", "")
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	status := &ChessStatus{}
+
+	for _, stat := range chessComStats {
+		if rating := stats.Get(stat.key + "." + stat.rating); rating.Exists() {
+			status.Ratings = append(status.Ratings, ChessRating{
+				Name:   stat.name,
+				Rating: int(rating.Int()),
+			})
+		}
+	}
+
+	var failed int
+
+	// every ongoing game on Chess.com is a daily game
+	games, err := fetchChessJson(ctx, client, player+"/games", "")
+
+	if err != nil {
+		failed++
+		slog.Error("Failed to fetch Chess.com games", "error", err)
+	} else {
+		games.Get("games").ForEach(func(_, game gjson.Result) bool {
+			// players are given as links to their profile in the API
+			white := path.Base(game.Get("white").String())
+			black := path.Base(game.Get("black").String())
+			turn := game.Get("turn").String()
+
+			if (turn == "white" && !strings.EqualFold(white, username)) || (turn == "black" && !strings.EqualFold(black, username)) {
+				return true
+			}
+
+			chessGame := ChessGame{
+				URL:      game.Get("url").String(),
+				Opponent: black,
+			}
+
+			if turn == "black" {
+				chessGame.Opponent = white
+			}
+
+			if moveBy := game.Get("move_by").Int(); moveBy > 0 {
+				chessGame.MoveBy = time.Unix(moveBy, 0)
+			}
+
+			status.Games = append(status.Games, chessGame)
+			return true
+		})
+	}
+
+	puzzle, err := fetchChessJson(ctx, client, chessComEndpoint+"/pub/puzzle", "")
+
+	if err != nil {
+		failed++
+		slog.Error("Failed to fetch Chess.com daily puzzle", "error", err)
+	} else {
+		status.Puzzle = &ChessPuzzle{
+			Title: puzzle.Get("title").String(),
+			URL:   puzzle.Get("url").String(),
+		}
+	}
+
+	return status, failed, nil
+}
@@ -0,0 +1,335 @@
+package feed
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+type ClientOptions struct {
+	ProxyURL      string
+	Timeout       time.Duration
+	AllowInsecure bool
+	// path to a PEM file with certificates that get trusted
+	// in addition to the ones of the system
+	CAFile string
+	// paths to a PEM encoded certificate and key used
+	// to authenticate with servers that require mutual TLS
+	ClientCertFile string
+	ClientKeyFile  string
+	// either ipv4 or ipv6, addresses of the other version are used as a fallback
+	IPPreference string
+	// address of a DNS server or URL of a DNS over HTTPS server
+	DNSResolver string
+	Headers     map[string]string
+	UserAgent   string
+	// cookies sent with every request of the client
+	Cookies map[string]string
+	Login   *LoginOptions
+	// how many times requests that fail with a 429 or 5xx status code are retried,
+	// -1 disables retries. Only applies to the global options, as does the limit
+	// on the number of requests made to the same host at once
+	Retries            int
+	MaxRequestsPerHost int
+}
+
+// The clients used by widgets which don't specify options of their own, built from
+// the options in the server config. Per widget options are applied on top of these
+type GlobalClients struct {
+	options        ClientOptions
+	client         *http.Client
+	insecureClient *http.Client
+	transports     []*http.Transport
+}
+
+// Replaced as a whole when the config gets reloaded, requests that are
+// already being made keep using the clients they started with
+var globalClients atomic.Pointer[GlobalClients]
+
+func init() {
+	clients, err := NewGlobalClients(ClientOptions{})
+
+	if err != nil {
+		panic(err)
+	}
+
+	globalClients.Store(clients)
+}
+
+// Makes requests with whichever global clients are current at the time of the request
+type globalClient struct {
+	insecure bool
+}
+
+func (c globalClient) Do(request *http.Request) (*http.Response, error) {
+	clients := globalClients.Load()
+
+	if c.insecure {
+		return clients.insecureClient.Do(request)
+	}
+
+	return clients.client.Do(request)
+}
+
+func CurrentGlobalClients() *GlobalClients {
+	return globalClients.Load()
+}
+
+func globalUserAgent() string {
+	return globalClients.Load().options.UserAgent
+}
+
+func (o *ClientOptions) retries() int {
+	if o.Retries == 0 {
+		return defaultRequestRetries
+	}
+
+	return max(o.Retries, 0)
+}
+
+func (o *ClientOptions) transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if o.ProxyURL != "" {
+		proxyURL, err := url.Parse(o.ProxyURL)
+
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", o.ProxyURL)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if o.IPPreference != "" || o.DNSResolver != "" {
+		dialer, err := newDialer(o.IPPreference, o.DNSResolver)
+
+		if err != nil {
+			return nil, err
+		}
+
+		transport.DialContext = dialer.DialContext
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: o.AllowInsecure}
+
+	if o.CAFile != "" {
+		contents, err := os.ReadFile(o.CAFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA file: %v", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(contents) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if o.ClientCertFile != "" || o.ClientKeyFile != "" {
+		if o.ClientCertFile == "" || o.ClientKeyFile == "" {
+			return nil, errors.New("both a client certificate and key must be specified")
+		}
+
+		certificate, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %v", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// Sets the user agent of requests that don't already have one
+type userAgentTransport struct {
+	transport http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Header.Get("User-Agent") != "" {
+		return t.transport.RoundTrip(request)
+	}
+
+	// round trippers shouldn't modify the original request
+	request = request.Clone(request.Context())
+	request.Header.Set("User-Agent", t.userAgent)
+
+	return t.transport.RoundTrip(request)
+}
+
+func withUserAgent(transport http.RoundTripper, userAgent string) http.RoundTripper {
+	if userAgent == "" {
+		return transport
+	}
+
+	return &userAgentTransport{transport: transport, userAgent: userAgent}
+}
+
+// Builds the clients without using them yet, so that a config which turns out to be
+// invalid later on doesn't leave its options in place
+func NewGlobalClients(options ClientOptions) (*GlobalClients, error) {
+	transport, err := options.transport()
+
+	if err != nil {
+		return nil, err
+	}
+
+	insecureTransport := transport.Clone()
+	insecureTransport.TLSClientConfig.InsecureSkipVerify = true
+
+	timeout := defaultClientTimeout
+
+	if options.Timeout > 0 {
+		timeout = options.Timeout
+	}
+
+	return &GlobalClients{
+		options: options,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: withRetries(withUserAgent(transport, options.UserAgent), options.retries()),
+		},
+		insecureClient: &http.Client{
+			Timeout:   timeout,
+			Transport: withRetries(withUserAgent(insecureTransport, options.UserAgent), options.retries()),
+		},
+		transports: []*http.Transport{transport, insecureTransport},
+	}, nil
+}
+
+// Makes the clients the ones used by all widgets which don't specify their own options
+func SetGlobalClients(clients *GlobalClients) {
+	previous := globalClients.Swap(clients)
+
+	if clients.options.MaxRequestsPerHost == 0 {
+		globalHostLimiter.setLimit(defaultMaxRequestsPerHost)
+	} else {
+		globalHostLimiter.setLimit(clients.options.MaxRequestsPerHost)
+	}
+
+	if previous == nil || previous == clients {
+		return
+	}
+
+	// connections which are still in use get closed by the idle timeout once they're done
+	for _, transport := range previous.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+type clientWithHeaders struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+// Headers that were already set on the request take precedence, except
+// for the browser user agent which some sources use by default
+func (c *clientWithHeaders) Do(request *http.Request) (*http.Response, error) {
+	for key, value := range c.headers {
+		current := request.Header.Get(key)
+
+		if current == "" || (http.CanonicalHeaderKey(key) == "User-Agent" && current == browserUserAgent) {
+			request.Header.Set(key, value)
+		}
+	}
+
+	return c.client.Do(request)
+}
+
+// Returns a client with the given options applied on top of the global ones,
+// or nil if none of the options differ from the global ones so that the
+// default client gets used
+func (g *GlobalClients) NewClient(options ClientOptions) (RequestDoer, error) {
+	if options.ProxyURL == "" && options.Timeout == 0 && !options.AllowInsecure && options.CAFile == "" && options.ClientCertFile == "" &&
+		options.ClientKeyFile == "" && options.IPPreference == "" && options.DNSResolver == "" &&
+		len(options.Headers) == 0 &&
+		len(options.Cookies) == 0 && options.Login == nil {
+		return nil, nil
+	}
+
+	merged := g.options
+
+	if options.ProxyURL != "" {
+		merged.ProxyURL = options.ProxyURL
+	}
+
+	if options.Timeout > 0 {
+		merged.Timeout = options.Timeout
+	}
+
+	if options.CAFile != "" {
+		merged.CAFile = options.CAFile
+	}
+
+	if options.IPPreference != "" {
+		merged.IPPreference = options.IPPreference
+	}
+
+	if options.DNSResolver != "" {
+		merged.DNSResolver = options.DNSResolver
+	}
+
+	if options.ClientCertFile != "" || options.ClientKeyFile != "" {
+		merged.ClientCertFile = options.ClientCertFile
+		merged.ClientKeyFile = options.ClientKeyFile
+	}
+
+	merged.AllowInsecure = merged.AllowInsecure || options.AllowInsecure
+
+	transport, err := merged.transport()
+
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout:   defaultClientTimeout,
+		Transport: withRetries(withUserAgent(transport, merged.UserAgent), merged.retries()),
+	}
+
+	if merged.Timeout > 0 {
+		client.Timeout = merged.Timeout
+	}
+
+	var doer RequestDoer = client
+
+	if len(options.Headers) > 0 {
+		doer = &clientWithHeaders{client: client, headers: options.Headers}
+	}
+
+	if len(options.Cookies) == 0 && options.Login == nil {
+		return doer, nil
+	}
+
+	// each client gets its own jar so that sessions aren't shared between widgets
+	client.Jar, _ = cookiejar.New(nil)
+
+	return newClientWithCookies(doer, options.Cookies, options.Login), nil
+}
+
+func clientOrDefault(client RequestDoer) RequestDoer {
+	if client == nil {
+		return defaultClient
+	}
+
+	return client
+}
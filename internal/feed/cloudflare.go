@@ -0,0 +1,281 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// The analytics are summed from hourly groups, of which there are at most this many
+const cloudflareAnalyticsHours = 24
+
+type CloudflareAnalytics struct {
+	Requests       int
+	CachedRequests int
+	// the requests which were blocked or challenged because they looked malicious
+	Threats int
+	Bytes   uint64
+}
+
+func (a *CloudflareAnalytics) CachedPercent() float64 {
+	if a.Requests == 0 {
+		return 0
+	}
+
+	return float64(a.CachedRequests) / float64(a.Requests) * 100
+}
+
+type CloudflareTunnel struct {
+	Name string
+	// one of healthy, degraded, down or inactive
+	Status string
+	// the public hostnames the tunnel routes, only known for tunnels managed from the dashboard
+	Hostnames []string
+}
+
+func (t *CloudflareTunnel) IsHealthy() bool {
+	return t.Status == "healthy"
+}
+
+type Cloudflare struct {
+	// nil when no zones are configured
+	Analytics *CloudflareAnalytics
+	// nil when no account is configured
+	Tunnels []CloudflareTunnel
+}
+
+type cloudflareGraphQLResponseJson struct {
+	Data struct {
+		Viewer struct {
+			Zones []struct {
+				Groups []struct {
+					Sum struct {
+						Requests       int    `json:"requests"`
+						CachedRequests int    `json:"cachedRequests"`
+						Threats        int    `json:"threats"`
+						Bytes          uint64 `json:"bytes"`
+					} `json:"sum"`
+				} `json:"httpRequests1hGroups"`
+			} `json:"zones"`
+		} `json:"viewer"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type cloudflareResponseJson[T any] struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result T `json:"result"`
+}
+
+type cloudflareTunnelJson struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type cloudflareTunnelConfigurationJson struct {
+	Config struct {
+		Ingress []struct {
+			Hostname string `json:"hostname"`
+		} `json:"ingress"`
+	} `json:"config"`
+}
+
+const cloudflareAnalyticsQuery = `query($zones: [string!], $since: Time!, $until: Time!, $limit: uint64!) {
+	viewer {
+		zones(filter: { zoneTag_in: $zones }) {
+			httpRequests1hGroups(limit: $limit, filter: { datetime_geq: $since, datetime_lt: $until }) {
+				sum { requests cachedRequests threats bytes }
+			}
+		}
+	}
+}`
+
+func cloudflareGet[T any](ctx context.Context, client RequestDoer, token, path string) (T, error) {
+	return cloudflareRequest[T](ctx, client, token, "GET", path, nil)
+}
+
+// The body is sent as JSON when it's not nil
+func cloudflareRequest[T any](ctx context.Context, client RequestDoer, token, method, path string, body any) (T, error) {
+	var reader io.Reader
+
+	if body != nil {
+		encoded, err := json.Marshal(body)
+
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, method, cloudflareAPIURL+path, reader)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := decodeJsonFromRequest[cloudflareResponseJson[T]](client, request)
+
+	if err != nil {
+		return response.Result, err
+	}
+
+	if !response.Success {
+		if len(response.Errors) > 0 {
+			return response.Result, errors.New(response.Errors[0].Message)
+		}
+
+		return response.Result, errors.New("request was not successful")
+	}
+
+	return response.Result, nil
+}
+
+// Sums the analytics of the last day of the zones through the GraphQL API, which needs
+// a token with the Analytics Read permission for each of them
+func fetchCloudflareAnalytics(ctx context.Context, client RequestDoer, token string, zoneIDs []string) (*CloudflareAnalytics, error) {
+	until := time.Now().UTC().Truncate(time.Hour)
+
+	body, _ := json.Marshal(map[string]any{
+		"query": cloudflareAnalyticsQuery,
+		"variables": map[string]any{
+			"zones": zoneIDs,
+			"since": until.Add(-cloudflareAnalyticsHours * time.Hour).Format(time.RFC3339),
+			"until": until.Format(time.RFC3339),
+			"limit": cloudflareAnalyticsHours,
+		},
+	})
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", cloudflareAPIURL+"/graphql", bytes.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := decodeJsonFromRequest[cloudflareGraphQLResponseJson](client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// errors come with a successful status code
+	if len(response.Errors) > 0 {
+		return nil, errors.New(response.Errors[0].Message)
+	}
+
+	analytics := &CloudflareAnalytics{}
+
+	for _, zone := range response.Data.Viewer.Zones {
+		for _, group := range zone.Groups {
+			analytics.Requests += group.Sum.Requests
+			analytics.CachedRequests += group.Sum.CachedRequests
+			analytics.Threats += group.Sum.Threats
+			analytics.Bytes += group.Sum.Bytes
+		}
+	}
+
+	return analytics, nil
+}
+
+// Lists the tunnels of the account which haven't been deleted, which needs a token with
+// the Cloudflare Tunnel Read permission. Tunnels which are configured locally through
+// cloudflared rather than the dashboard have no hostnames
+func fetchCloudflareTunnels(ctx context.Context, client RequestDoer, token, accountID string) ([]CloudflareTunnel, error) {
+	accountPath := "/accounts/" + url.PathEscape(accountID) + "/cfd_tunnel"
+	tunnels, err := cloudflareGet[[]cloudflareTunnelJson](ctx, client, token, accountPath+"?is_deleted=false")
+
+	if err != nil {
+		return nil, err
+	}
+
+	job := newJob(func(tunnel cloudflareTunnelJson) (CloudflareTunnel, error) {
+		result := CloudflareTunnel{Name: tunnel.Name, Status: tunnel.Status}
+		configuration, err := cloudflareGet[cloudflareTunnelConfigurationJson](ctx, client, token, accountPath+"/"+url.PathEscape(tunnel.ID)+"/configurations")
+
+		if err != nil {
+			return result, nil
+		}
+
+		for _, rule := range configuration.Config.Ingress {
+			// the last rule is the catch-all one without a hostname
+			if rule.Hostname != "" {
+				result.Hostnames = append(result.Hostnames, rule.Hostname)
+			}
+		}
+
+		return result, nil
+	}, tunnels).withWorkers(5).withContext(ctx)
+
+	results, _, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the ones which need attention first
+	slices.SortStableFunc(results, func(a, b CloudflareTunnel) int {
+		if a.IsHealthy() == b.IsHealthy() {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+
+		if a.IsHealthy() {
+			return 1
+		}
+
+		return -1
+	})
+
+	return results, nil
+}
+
+func FetchCloudflare(ctx context.Context, client RequestDoer, token string, zoneIDs []string, accountID string) (*Cloudflare, error) {
+	client = clientOrDefault(client)
+	cloudflare := &Cloudflare{}
+	var errs []error
+
+	if len(zoneIDs) > 0 {
+		analytics, err := fetchCloudflareAnalytics(ctx, client, token, zoneIDs)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not get analytics: %v", err))
+		} else {
+			cloudflare.Analytics = analytics
+		}
+	}
+
+	if accountID != "" {
+		tunnels, err := fetchCloudflareTunnels(ctx, client, token, accountID)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not get tunnels: %v", err))
+		} else {
+			cloudflare.Tunnels = tunnels
+		}
+	}
+
+	if len(errs) > 0 {
+		if cloudflare.Analytics == nil && cloudflare.Tunnels == nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, errors.Join(errs...))
+		}
+
+		return cloudflare, fmt.Errorf("%w: %v", ErrPartialContent, errors.Join(errs...))
+	}
+
+	return cloudflare, nil
+}
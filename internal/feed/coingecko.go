@@ -0,0 +1,156 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const coingeckoAPIURL = "https://api.coingecko.com/api/v3"
+
+type coingeckoCoinResponseJson struct {
+	ID                       string  `json:"id"`
+	Symbol                   string  `json:"symbol"`
+	Name                     string  `json:"name"`
+	CurrentPrice             float64 `json:"current_price"`
+	PriceChangePercentage24h float64 `json:"price_change_percentage_24h"`
+}
+
+type coingeckoChartResponseJson struct {
+	Prices [][2]float64 `json:"prices"`
+}
+
+// Coins are specified by their CoinGecko ID, such as bitcoin or ethereum,
+// and their prices are returned in the given fiat currency. The language
+// changes the names of the coins where CoinGecko has them translated
+func FetchCryptoMarketsFromCoinGecko(ctx context.Context, client RequestDoer, coins []string, currency string, language string) (Markets, error) {
+	client = clientOrDefault(client)
+	currency = strings.ToLower(currency)
+
+	query := url.Values{}
+	query.Set("vs_currency", currency)
+	query.Set("ids", strings.Join(coins, ","))
+
+	if language != "" {
+		query.Set("locale", strings.ToLower(language))
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", coingeckoAPIURL+"/coins/markets?"+query.Encode(), nil)
+	response, err := decodeJsonFromRequest[[]coingeckoCoinResponseJson](client, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	coinsByID := make(map[string]*coingeckoCoinResponseJson, len(response))
+
+	for i := range response {
+		coinsByID[response[i].ID] = &response[i]
+	}
+
+	found := make([]*coingeckoCoinResponseJson, 0, len(coins))
+	var failed int
+
+	for i := range coins {
+		if coin, exists := coinsByID[coins[i]]; exists {
+			found = append(found, coin)
+		} else {
+			failed++
+			slog.Error("Coin not found on CoinGecko", "coin", coins[i])
+		}
+	}
+
+	chartRequests := make([]*http.Request, len(found))
+
+	for i := range found {
+		query := url.Values{}
+		query.Set("vs_currency", currency)
+		query.Set("days", strconv.Itoa(marketChartDays))
+		query.Set("interval", "daily")
+
+		chartRequests[i], _ = http.NewRequestWithContext(ctx, "GET", coingeckoAPIURL+"/coins/"+url.PathEscape(found[i].ID)+"/market_chart?"+query.Encode(), nil)
+	}
+
+	// the public API is heavily rate limited, so the charts are fetched a few at a time
+	job := newJob(decodeJsonFromRequestTask[coingeckoChartResponseJson](client), chartRequests).withWorkers(3).withContext(ctx)
+	charts, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	currencySymbol := CurrencySymbol(strings.ToUpper(currency))
+	markets := make(Markets, 0, len(found))
+
+	for i, coin := range found {
+		market := Market{
+			MarketRequest: MarketRequest{
+				Name:       coin.Name,
+				Symbol:     strings.ToUpper(coin.Symbol),
+				SymbolLink: "https://www.coingecko.com/en/coins/" + coin.ID,
+			},
+			Currency:      currencySymbol,
+			Price:         coin.CurrentPrice,
+			PercentChange: coin.PriceChangePercentage24h,
+		}
+
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch coin chart", "coin", coin.ID, "error", errs[i])
+		} else {
+			prices := make([]float64, len(charts[i].Prices))
+
+			for j := range charts[i].Prices {
+				prices[j] = charts[i].Prices[j][1]
+			}
+
+			if len(prices) > marketChartDays {
+				prices = prices[len(prices)-marketChartDays:]
+			}
+
+			market.SvgChartPoints = SvgPolylineCoordsFromYValues(100, 50, maybeCopySliceWithoutZeroValues(prices))
+		}
+
+		markets = append(markets, market)
+	}
+
+	if len(markets) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return markets, fmt.Errorf("%w: could not fetch data for %d coin(s)", ErrPartialContent, failed)
+	}
+
+	return markets, nil
+}
+
+// Returns the prices of the coins in the given fiat currency, keyed by their CoinGecko ID
+func fetchCryptoPricesFromCoinGecko(ctx context.Context, client RequestDoer, coins []string, currency string) (map[string]float64, error) {
+	currency = strings.ToLower(currency)
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(coins, ","))
+	query.Set("vs_currencies", currency)
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", coingeckoAPIURL+"/simple/price?"+query.Encode(), nil)
+	response, err := decodeJsonFromRequest[map[string]map[string]float64](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(response))
+
+	for coin, byCurrency := range response {
+		if price, exists := byCurrency[currency]; exists {
+			prices[coin] = price
+		}
+	}
+
+	return prices, nil
+}
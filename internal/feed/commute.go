@@ -0,0 +1,237 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	CommuteProviderOSRM   = "osrm"
+	CommuteProviderGoogle = "google"
+	CommuteProviderHERE   = "here"
+)
+
+const (
+	googleRoutesEndpoint = "https://routes.googleapis.com/directions/v2:computeRoutes"
+	hereRoutesEndpoint   = "https://router.hereapi.com/v8/routes"
+)
+
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Parses coordinates in the format of "latitude,longitude"
+func ParseCoordinates(value string) (Coordinates, error) {
+	latitudeValue, longitudeValue, found := strings.Cut(value, ",")
+
+	if !found {
+		return Coordinates{}, errors.New("must be in the format of latitude,longitude")
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(latitudeValue), 64)
+
+	if err != nil || latitude < -90 || latitude > 90 {
+		return Coordinates{}, errors.New("invalid latitude")
+	}
+
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(longitudeValue), 64)
+
+	if err != nil || longitude < -180 || longitude > 180 {
+		return Coordinates{}, errors.New("invalid longitude")
+	}
+
+	return Coordinates{Latitude: latitude, Longitude: longitude}, nil
+}
+
+func (c Coordinates) String() string {
+	return strconv.FormatFloat(c.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(c.Longitude, 'f', -1, 64)
+}
+
+type CommuteRoute struct {
+	Duration time.Duration
+	// how long the route takes without traffic, 0 when the provider doesn't know
+	Baseline time.Duration
+	// in meters
+	Distance float64
+}
+
+type CommuteRequest struct {
+	Provider string
+	// the address of the OSRM server
+	URL    string
+	APIKey string
+	From   Coordinates
+	To     Coordinates
+	Client RequestDoer
+}
+
+// Gets how long it currently takes to drive the route, taking traffic into
+// account for the providers that have traffic data
+func FetchCommuteRoute(ctx context.Context, request *CommuteRequest) (*CommuteRoute, error) {
+	client := clientOrDefault(request.Client)
+
+	var route *CommuteRoute
+	var err error
+
+	switch request.Provider {
+	case CommuteProviderOSRM:
+		route, err = fetchOSRMRoute(ctx, client, request)
+	case CommuteProviderGoogle:
+		route, err = fetchGoogleRoute(ctx, client, request)
+	case CommuteProviderHERE:
+		route, err = fetchHERERoute(ctx, client, request)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", request.Provider)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	return route, nil
+}
+
+func fetchOSRMRoute(ctx context.Context, client RequestDoer, request *CommuteRequest) (*CommuteRoute, error) {
+	// OSRM takes the longitude first
+	points := fmt.Sprintf(
+		"%s,%s;%s,%s",
+		strconv.FormatFloat(request.From.Longitude, 'f', -1, 64), strconv.FormatFloat(request.From.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(request.To.Longitude, 'f', -1, 64), strconv.FormatFloat(request.To.Latitude, 'f', -1, 64),
+	)
+
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(request.URL, "/")+"/route/v1/driving/"+points+"?overview=false",
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := fetchRedactedJson(client, httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if code := gjson.Get(body, "code").String(); code != "Ok" {
+		return nil, fmt.Errorf("no route found: %s", code)
+	}
+
+	route := gjson.Get(body, "routes.0")
+
+	return &CommuteRoute{
+		Duration: time.Duration(route.Get("duration").Float() * float64(time.Second)),
+		Distance: route.Get("distance").Float(),
+	}, nil
+}
+
+func fetchGoogleRoute(ctx context.Context, client RequestDoer, request *CommuteRequest) (*CommuteRoute, error) {
+	waypoint := func(c Coordinates) map[string]any {
+		return map[string]any{
+			"location": map[string]any{
+				"latLng": map[string]float64{"latitude": c.Latitude, "longitude": c.Longitude},
+			},
+		}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"origin":            waypoint(request.From),
+		"destination":       waypoint(request.To),
+		"travelMode":        "DRIVE",
+		"routingPreference": "TRAFFIC_AWARE",
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", googleRoutesEndpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("X-Goog-Api-Key", request.APIKey)
+	// only the requested fields are returned, which also decides how the request is billed
+	httpRequest.Header.Set("X-Goog-FieldMask", "routes.duration,routes.staticDuration,routes.distanceMeters")
+
+	response, _, err := fetchRedactedJson(client, httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	route := gjson.Get(response, "routes.0")
+
+	if !route.Exists() {
+		return nil, errors.New("no route found")
+	}
+
+	// durations are strings such as "1234s"
+	duration, err := time.ParseDuration(route.Get("duration").String())
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse duration: %v", err)
+	}
+
+	baseline, _ := time.ParseDuration(route.Get("staticDuration").String())
+
+	return &CommuteRoute{
+		Duration: duration,
+		Baseline: baseline,
+		Distance: route.Get("distanceMeters").Float(),
+	}, nil
+}
+
+func fetchHERERoute(ctx context.Context, client RequestDoer, request *CommuteRequest) (*CommuteRoute, error) {
+	query := url.Values{}
+	query.Set("transportMode", "car")
+	query.Set("origin", request.From.String())
+	query.Set("destination", request.To.String())
+	query.Set("return", "summary")
+	query.Set("apikey", request.APIKey)
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", hereRoutesEndpoint+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := fetchRedactedJson(client, httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sections := gjson.Get(body, "routes.0.sections")
+
+	if !sections.Exists() {
+		return nil, errors.New("no route found")
+	}
+
+	route := &CommuteRoute{}
+
+	// routes are split into sections, e.g. when taking a ferry
+	for _, section := range sections.Array() {
+		summary := section.Get("summary")
+		route.Duration += time.Duration(summary.Get("duration").Int()) * time.Second
+		route.Baseline += time.Duration(summary.Get("baseDuration").Int()) * time.Second
+		route.Distance += summary.Get("length").Float()
+	}
+
+	return route, nil
+}
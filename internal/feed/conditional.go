@@ -0,0 +1,126 @@
+package feed
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// The validators and body of the last successful response of a URL, sent back
+// with the next request so that the server can respond with a 304 and no body
+// when nothing has changed since
+type cachedValidators struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+type validatorCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedValidators
+}
+
+var globalValidatorCache = &validatorCache{
+	entries: make(map[string]*cachedValidators),
+}
+
+func (c *validatorCache) get(key string) *cachedValidators {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries[key]
+}
+
+func (c *validatorCache) set(key string, entry *cachedValidators) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry == nil {
+		delete(c.entries, key)
+	} else {
+		c.entries[key] = entry
+	}
+}
+
+// Makes the request and reads the body of the response, which is the cached one
+// when the server says that it hasn't changed. The response is then turned into a
+// 200 so that a 304 is handled the same way as if the whole body was sent again.
+// Only GET requests are made conditional and validators set by the user are left as is
+func doConditionalRequest(client RequestDoer, request *http.Request) (*http.Response, []byte, error) {
+	key := request.URL.String()
+	conditional := request.Method == http.MethodGet &&
+		request.Header.Get("If-None-Match") == "" &&
+		request.Header.Get("If-Modified-Since") == ""
+
+	var cached *cachedValidators
+
+	if conditional {
+		cached = globalValidatorCache.get(key)
+	}
+
+	if cached != nil {
+		// the headers can be shared with a request that gets reused for every update
+		request = request.Clone(request.Context())
+
+		if cached.etag != "" {
+			request.Header.Set("If-None-Match", cached.etag)
+		}
+
+		if cached.lastModified != "" {
+			request.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	response, err := clientOrDefault(client).Do(request)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && cached != nil {
+		response.StatusCode = http.StatusOK
+		response.Status = "200 OK"
+
+		return response, cached.body, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !conditional || response.StatusCode != http.StatusOK {
+		return response, body, nil
+	}
+
+	etag := response.Header.Get("ETag")
+	lastModified := response.Header.Get("Last-Modified")
+
+	if etag == "" && lastModified == "" {
+		// the server stopped sending validators, so the old ones are of no use
+		if cached != nil {
+			globalValidatorCache.set(key, nil)
+		}
+
+		return response, body, nil
+	}
+
+	globalValidatorCache.set(key, &cachedValidators{
+		etag:         etag,
+		lastModified: lastModified,
+		body:         body,
+	})
+
+	return response, body, nil
+}
+
+// Makes the next request of every URL fetch the whole response again
+func ClearConditionalRequestCache() {
+	globalValidatorCache.mu.Lock()
+	defer globalValidatorCache.mu.Unlock()
+
+	clear(globalValidatorCache.entries)
+}
@@ -0,0 +1,298 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	ConditionsKindWater = "water"
+	ConditionsKindSki   = "ski"
+)
+
+const (
+	ConditionsProviderOpenMeteo   = "open-meteo"
+	ConditionsProviderPegelOnline = "pegelonline"
+	ConditionsProviderExistenz    = "existenz"
+	ConditionsProviderLiftie      = "liftie"
+)
+
+const (
+	openMeteoForecastEndpoint = "https://api.open-meteo.com/v1/forecast"
+	openMeteoMarineEndpoint   = "https://marine-api.open-meteo.com/v1/marine"
+	pegelOnlineEndpoint       = "https://www.pegelonline.wsv.de/webservices/rest-api/v2"
+	existenzEndpoint          = "https://api.existenz.ch/apiv1"
+	liftieEndpoint            = "https://liftie.info/api"
+)
+
+// Which providers can report on which kind of spot
+var conditionsProviders = map[string][]string{
+	ConditionsKindWater: {ConditionsProviderOpenMeteo, ConditionsProviderPegelOnline, ConditionsProviderExistenz},
+	ConditionsKindSki:   {ConditionsProviderOpenMeteo, ConditionsProviderLiftie},
+}
+
+func ConditionsProvidersFor(kind string) []string {
+	return conditionsProviders[kind]
+}
+
+// Values the provider doesn't report are nil
+type SpotConditions struct {
+	// in celsius
+	WaterTemperature *float64
+	AirTemperature   *float64
+	// in centimeters
+	SnowDepth *float64
+	// in centimeters, fallen over the past 24 hours
+	NewSnow    *float64
+	LiftsOpen  int
+	LiftsTotal int
+	// zero when the provider doesn't say
+	MeasuredAt time.Time
+}
+
+type SpotConditionsRequest struct {
+	Name     string
+	Kind     string
+	Provider string
+	// the ID or name of the measuring station for pegelonline and existenz
+	Station string
+	// the ID of the resort on Liftie
+	Resort   string
+	Location Coordinates
+	// in meters, for the snow of ski resorts from Open-Meteo, which otherwise
+	// uses the elevation of the valley
+	Elevation int
+	Client    RequestDoer
+}
+
+func FetchSpotConditions(ctx context.Context, requests []*SpotConditionsRequest) ([]*SpotConditions, error) {
+	job := newJob(taskWithContext(ctx, fetchSpotConditionsTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch conditions", "spot", requests[i].Name, "provider", requests[i].Provider, "error", errs[i])
+		}
+	}
+
+	if len(requests) > 0 && failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch conditions of %d spot(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
+
+func fetchSpotConditionsTask(ctx context.Context, request *SpotConditionsRequest) (*SpotConditions, error) {
+	client := clientOrDefault(request.Client)
+
+	switch {
+	case request.Provider == ConditionsProviderOpenMeteo && request.Kind == ConditionsKindWater:
+		return fetchOpenMeteoWaterConditions(ctx, client, request)
+	case request.Provider == ConditionsProviderOpenMeteo && request.Kind == ConditionsKindSki:
+		return fetchOpenMeteoSnowConditions(ctx, client, request)
+	case request.Provider == ConditionsProviderPegelOnline:
+		return fetchPegelOnlineConditions(ctx, client, request)
+	case request.Provider == ConditionsProviderExistenz:
+		return fetchExistenzConditions(ctx, client, request)
+	case request.Provider == ConditionsProviderLiftie:
+		return fetchLiftieConditions(ctx, client, request)
+	}
+
+	return nil, fmt.Errorf("unknown provider %q", request.Provider)
+}
+
+func fetchConditionsJson(ctx context.Context, client RequestDoer, requestURL string) (string, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	return body, err
+}
+
+func conditionsValue(value gjson.Result) *float64 {
+	if value.Type != gjson.Number {
+		return nil
+	}
+
+	number := value.Float()
+
+	return &number
+}
+
+// The sea surface temperature, which is only known for coasts and not for lakes
+func fetchOpenMeteoWaterConditions(ctx context.Context, client RequestDoer, request *SpotConditionsRequest) (*SpotConditions, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(request.Location.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(request.Location.Longitude, 'f', -1, 64))
+	query.Set("current", "sea_surface_temperature")
+	query.Set("timeformat", "unixtime")
+
+	body, err := fetchConditionsJson(ctx, client, openMeteoMarineEndpoint+"?"+query.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := gjson.Get(body, "current")
+	temperature := conditionsValue(current.Get("sea_surface_temperature"))
+
+	if temperature == nil {
+		return nil, errors.New("no sea surface temperature for location, which has to be at the coast")
+	}
+
+	return &SpotConditions{
+		WaterTemperature: temperature,
+		MeasuredAt:       time.Unix(current.Get("time").Int(), 0),
+	}, nil
+}
+
+func fetchOpenMeteoSnowConditions(ctx context.Context, client RequestDoer, request *SpotConditionsRequest) (*SpotConditions, error) {
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(request.Location.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(request.Location.Longitude, 'f', -1, 64))
+	query.Set("current", "temperature_2m,snow_depth")
+	query.Set("hourly", "snowfall")
+	query.Set("past_days", "1")
+	query.Set("forecast_days", "1")
+	query.Set("timeformat", "unixtime")
+
+	if request.Elevation > 0 {
+		query.Set("elevation", strconv.Itoa(request.Elevation))
+	}
+
+	body, err := fetchConditionsJson(ctx, client, openMeteoForecastEndpoint+"?"+query.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := gjson.Get(body, "current")
+	conditions := &SpotConditions{
+		AirTemperature: conditionsValue(current.Get("temperature_2m")),
+		MeasuredAt:     time.Unix(current.Get("time").Int(), 0),
+	}
+
+	// reported in meters
+	if depth := conditionsValue(current.Get("snow_depth")); depth != nil {
+		centimeters := *depth * 100
+		conditions.SnowDepth = &centimeters
+	}
+
+	times := gjson.Get(body, "hourly.time").Array()
+	snowfall := gjson.Get(body, "hourly.snowfall").Array()
+
+	if len(times) == len(snowfall) && len(times) > 0 {
+		var fallen float64
+		since := conditions.MeasuredAt.Add(-24 * time.Hour).Unix()
+
+		for i := range times {
+			if t := times[i].Int(); t > since && t <= conditions.MeasuredAt.Unix() {
+				fallen += snowfall[i].Float()
+			}
+		}
+
+		conditions.NewSnow = &fallen
+	}
+
+	return conditions, nil
+}
+
+// The German federal waterways, where only some of the stations measure the water temperature
+func fetchPegelOnlineConditions(ctx context.Context, client RequestDoer, request *SpotConditionsRequest) (*SpotConditions, error) {
+	body, err := fetchConditionsJson(
+		ctx, client,
+		pegelOnlineEndpoint+"/stations/"+url.PathEscape(request.Station)+"/WT/currentmeasurement.json",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	temperature := conditionsValue(gjson.Get(body, "value"))
+
+	if temperature == nil {
+		return nil, errors.New("station does not measure the water temperature")
+	}
+
+	measuredAt, _ := time.Parse(time.RFC3339, gjson.Get(body, "timestamp").String())
+
+	return &SpotConditions{
+		WaterTemperature: temperature,
+		MeasuredAt:       measuredAt,
+	}, nil
+}
+
+// The hydrological stations of the Swiss Federal Office for the Environment, which
+// include many lakes
+func fetchExistenzConditions(ctx context.Context, client RequestDoer, request *SpotConditionsRequest) (*SpotConditions, error) {
+	query := url.Values{}
+	query.Set("locations", request.Station)
+	query.Set("parameters", "temperature")
+	query.Set("app", "glance")
+
+	body, err := fetchConditionsJson(ctx, client, existenzEndpoint+"/hydro/latest?"+query.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, measurement := range gjson.Get(body, "payload").Array() {
+		if measurement.Get("par").String() != "temperature" {
+			continue
+		}
+
+		if temperature := conditionsValue(measurement.Get("val")); temperature != nil {
+			return &SpotConditions{
+				WaterTemperature: temperature,
+				MeasuredAt:       time.Unix(measurement.Get("timestamp").Int(), 0),
+			}, nil
+		}
+	}
+
+	return nil, errors.New("station does not measure the water temperature")
+}
+
+// Liftie scrapes the lift status of ski resorts from their websites
+func fetchLiftieConditions(ctx context.Context, client RequestDoer, request *SpotConditionsRequest) (*SpotConditions, error) {
+	body, err := fetchConditionsJson(ctx, client, liftieEndpoint+"/resort/"+url.PathEscape(request.Resort))
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := gjson.Get(body, "lifts.stats")
+
+	if !stats.Exists() {
+		return nil, errors.New("no lift status for resort")
+	}
+
+	open := int(stats.Get("open").Int())
+
+	return &SpotConditions{
+		LiftsOpen:  open,
+		LiftsTotal: open + int(stats.Get("hold").Int()+stats.Get("scheduled").Int()+stats.Get("closed").Int()),
+	}, nil
+}
@@ -0,0 +1,141 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// A request that gets made before any other so that the session
+// cookies it sets end up in the cookie jar of the client
+type LoginOptions struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	Form    map[string]string
+	Body    string
+}
+
+func (o *LoginOptions) request(ctx context.Context) (*http.Request, error) {
+	method := o.Method
+	body := o.Body
+	contentType := ""
+
+	if len(o.Form) > 0 {
+		form := url.Values{}
+
+		for key, value := range o.Form {
+			form.Set(key, value)
+		}
+
+		body = form.Encode()
+		contentType = "application/x-www-form-urlencoded"
+	}
+
+	if method == "" {
+		if body != "" {
+			method = http.MethodPost
+		} else {
+			method = http.MethodGet
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, o.URL, strings.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	for key, value := range o.Headers {
+		request.Header.Set(key, value)
+	}
+
+	return request, nil
+}
+
+type clientWithCookies struct {
+	client  RequestDoer
+	cookies []*http.Cookie
+	login   *LoginOptions
+
+	mu       sync.Mutex
+	loggedIn bool
+}
+
+func newClientWithCookies(client RequestDoer, cookies map[string]string, login *LoginOptions) *clientWithCookies {
+	c := &clientWithCookies{
+		client: client,
+		login:  login,
+	}
+
+	for name, value := range cookies {
+		c.cookies = append(c.cookies, &http.Cookie{Name: name, Value: value})
+	}
+
+	return c
+}
+
+func (c *clientWithCookies) ensureLoggedIn(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loggedIn {
+		return nil
+	}
+
+	request, err := c.login.request(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	response, err := c.client.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("unexpected status code %d from %s", response.StatusCode, c.login.URL)
+	}
+
+	c.loggedIn = true
+
+	return nil
+}
+
+// Static cookies are sent with every request while cookies set by the
+// login step are handled by the jar of the underlying client, if the
+// session expires the login step is repeated on the next request
+func (c *clientWithCookies) Do(request *http.Request) (*http.Response, error) {
+	if c.login != nil {
+		if err := c.ensureLoggedIn(request.Context()); err != nil {
+			return nil, fmt.Errorf("login failed: %v", err)
+		}
+	}
+
+	for _, cookie := range c.cookies {
+		if _, err := request.Cookie(cookie.Name); err != nil {
+			request.AddCookie(cookie)
+		}
+	}
+
+	response, err := c.client.Do(request)
+
+	if err == nil && c.login != nil && (response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden) {
+		c.mu.Lock()
+		c.loggedIn = false
+		c.mu.Unlock()
+	}
+
+	return response, err
+}
@@ -0,0 +1,269 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	WalletChainBitcoin  = "bitcoin"
+	WalletChainEthereum = "ethereum"
+)
+
+// Used when a wallet doesn't have its own node, both of which
+// don't require an API key and don't log more than they have to
+const (
+	defaultBitcoinExplorerURL = "https://mempool.space/api"
+	defaultEthereumNodeURL    = "https://ethereum-rpc.publicnode.com"
+)
+
+var (
+	bitcoinAddressPattern  = regexp.MustCompile(`^(bc1[02-9ac-hj-np-z]{11,87}|[13][1-9A-HJ-NP-Za-km-z]{25,34})$`)
+	ethereumAddressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+type walletChain struct {
+	symbol string
+	// the CoinGecko ID of the coin, used to get its price
+	coin string
+	// the number of decimals between the base unit and a whole coin
+	decimals int
+}
+
+var walletChains = map[string]walletChain{
+	WalletChainBitcoin:  {symbol: "BTC", coin: "bitcoin", decimals: 8},
+	WalletChainEthereum: {symbol: "ETH", coin: "ethereum", decimals: 18},
+}
+
+type WalletRequest struct {
+	Address string `yaml:"address"`
+	Chain   string `yaml:"chain"`
+	Label   string `yaml:"label"`
+	// an Esplora API, such as a self-hosted mempool, for bitcoin wallets and
+	// a JSON-RPC endpoint for ethereum wallets, so that addresses aren't sent elsewhere
+	Node string `yaml:"node"`
+}
+
+// Fills in the chain from the format of the address when it's left out
+func (r *WalletRequest) Validate() error {
+	r.Address = strings.TrimSpace(r.Address)
+	r.Chain = strings.ToLower(r.Chain)
+
+	if r.Chain == "" {
+		switch {
+		case ethereumAddressPattern.MatchString(r.Address):
+			r.Chain = WalletChainEthereum
+		case bitcoinAddressPattern.MatchString(r.Address):
+			r.Chain = WalletChainBitcoin
+		default:
+			return fmt.Errorf("could not tell which chain the address %s is on, set it with chain", r.Address)
+		}
+	}
+
+	switch r.Chain {
+	case WalletChainBitcoin:
+		if !bitcoinAddressPattern.MatchString(r.Address) {
+			return fmt.Errorf("invalid bitcoin address %s", r.Address)
+		}
+	case WalletChainEthereum:
+		if !ethereumAddressPattern.MatchString(r.Address) {
+			return fmt.Errorf("invalid ethereum address %s", r.Address)
+		}
+	default:
+		return fmt.Errorf("unsupported chain '%s', must be one of bitcoin or ethereum", r.Chain)
+	}
+
+	if r.Node != "" {
+		if _, err := url.ParseRequestURI(r.Node); err != nil {
+			return fmt.Errorf("invalid node URL for %s: %v", r.Address, err)
+		}
+	}
+
+	return nil
+}
+
+type Wallet struct {
+	WalletRequest
+	Symbol   string
+	Balance  float64
+	Value    float64
+	Currency string
+	// false when the price couldn't be fetched, in which case only the balance is shown
+	HasValue bool
+}
+
+// Only the first and last few characters, which is enough to tell wallets
+// apart without putting the whole address on the dashboard
+func (w Wallet) ShortAddress() string {
+	if len(w.Address) <= 14 {
+		return w.Address
+	}
+
+	return w.Address[:8] + "…" + w.Address[len(w.Address)-6:]
+}
+
+type Wallets []Wallet
+
+func (w Wallets) TotalValue() float64 {
+	var total float64
+
+	for i := range w {
+		total += w[i].Value
+	}
+
+	return total
+}
+
+type esploraAddressResponseJson struct {
+	ChainStats struct {
+		FundedTxoSum int64 `json:"funded_txo_sum"`
+		SpentTxoSum  int64 `json:"spent_txo_sum"`
+	} `json:"chain_stats"`
+	MempoolStats struct {
+		FundedTxoSum int64 `json:"funded_txo_sum"`
+		SpentTxoSum  int64 `json:"spent_txo_sum"`
+	} `json:"mempool_stats"`
+}
+
+type ethereumRPCResponseJson struct {
+	Result string `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func fetchWalletBalance(ctx context.Context, client RequestDoer, wallet *WalletRequest) (*big.Int, error) {
+	switch wallet.Chain {
+	case WalletChainBitcoin:
+		base := strings.TrimSuffix(cmp.Or(wallet.Node, defaultBitcoinExplorerURL), "/")
+		request, _ := http.NewRequestWithContext(ctx, "GET", base+"/address/"+url.PathEscape(wallet.Address), nil)
+		response, err := decodeJsonFromRequest[esploraAddressResponseJson](client, request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// unconfirmed transactions are included, the same way wallets show them
+		satoshis := response.ChainStats.FundedTxoSum - response.ChainStats.SpentTxoSum +
+			response.MempoolStats.FundedTxoSum - response.MempoolStats.SpentTxoSum
+
+		return big.NewInt(satoshis), nil
+	case WalletChainEthereum:
+		body, _ := json.Marshal(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "eth_getBalance",
+			"params":  []string{wallet.Address, "latest"},
+		})
+
+		request, _ := http.NewRequestWithContext(ctx, "POST", cmp.Or(wallet.Node, defaultEthereumNodeURL), bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		response, err := decodeJsonFromRequest[ethereumRPCResponseJson](client, request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if response.Error != nil {
+			return nil, errors.New(response.Error.Message)
+		}
+
+		wei, ok := new(big.Int).SetString(strings.TrimPrefix(response.Result, "0x"), 16)
+
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q", response.Result)
+		}
+
+		return wei, nil
+	}
+
+	return nil, fmt.Errorf("unsupported chain '%s'", wallet.Chain)
+}
+
+// Fetches the balances of the wallets and their value in the given currency. Only
+// the IDs of the coins are sent to CoinGecko, the addresses only go to the explorer
+// or node of each wallet
+func FetchWalletBalances(ctx context.Context, client RequestDoer, requests []WalletRequest, currency string) (Wallets, error) {
+	client = clientOrDefault(client)
+
+	job := newJob(func(request WalletRequest) (*big.Int, error) {
+		return fetchWalletBalance(ctx, client, &request)
+	}, requests).withWorkers(3).withContext(ctx)
+
+	balances, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	coins := make([]string, 0, len(walletChains))
+
+	for i := range requests {
+		if coin := walletChains[requests[i].Chain].coin; !slices.Contains(coins, coin) {
+			coins = append(coins, coin)
+		}
+	}
+
+	prices, pricesErr := fetchCryptoPricesFromCoinGecko(ctx, client, coins, currency)
+
+	if pricesErr != nil {
+		slog.Error("Failed to fetch crypto prices", "error", pricesErr)
+	}
+
+	wallets := make(Wallets, 0, len(requests))
+	currencySymbol := CurrencySymbol(strings.ToUpper(currency))
+	var failed int
+
+	for i := range requests {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch wallet balance", "chain", requests[i].Chain, "label", requests[i].Label, "error", errs[i])
+			continue
+		}
+
+		chain := walletChains[requests[i].Chain]
+		balance, _ := new(big.Float).Quo(
+			new(big.Float).SetInt(balances[i]),
+			new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(chain.decimals)), nil)),
+		).Float64()
+
+		wallet := Wallet{
+			WalletRequest: requests[i],
+			Symbol:        chain.symbol,
+			Balance:       balance,
+			Currency:      currencySymbol,
+		}
+
+		if price, exists := prices[chain.coin]; exists {
+			wallet.Value = balance * price
+			wallet.HasValue = true
+		}
+
+		wallets = append(wallets, wallet)
+	}
+
+	if len(wallets) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return wallets, fmt.Errorf("%w: could not fetch the balance of %d wallet(s)", ErrPartialContent, failed)
+	}
+
+	if pricesErr != nil {
+		return wallets, fmt.Errorf("%w: could not fetch prices: %v", ErrPartialContent, pricesErr)
+	}
+
+	return wallets, nil
+}
@@ -2,9 +2,9 @@ package feed
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"html/template"
-	"io"
 	"log/slog"
 	"net/http"
 
@@ -12,18 +12,13 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-func FetchAndParseCustomAPI(req *http.Request, tmpl *template.Template) (template.HTML, error) {
+// Also returns the response body so that it can be shared with other widgets
+func FetchAndParseCustomAPI(ctx context.Context, client RequestDoer, req *http.Request, tmpl *template.Template) (template.HTML, string, error) {
 	emptyBody := template.HTML("")
 
-	resp, err := defaultClient.Do(req)
+	resp, bodyBytes, err := doConditionalRequest(client, req.WithContext(ctx))
 	if err != nil {
-		return emptyBody, err
-	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return emptyBody, err
+		return emptyBody, "", err
 	}
 
 	body := string(bodyBytes)
@@ -35,19 +30,30 @@ func FetchAndParseCustomAPI(req *http.Request, tmpl *template.Template) (templat
 		}
 
 		slog.Error("invalid response JSON in custom API widget", "URL", req.URL.String(), "body", truncatedBody)
-		return emptyBody, errors.New("invalid response JSON")
+		return emptyBody, "", errors.New("invalid response JSON")
 	}
 
-	var templateBuffer bytes.Buffer
-
-	data := CustomAPITemplateData{
+	compiledHTML, err := executeCustomAPITemplate(tmpl, &CustomAPITemplateData{
 		JSON:     DecoratedGJSONResult{gjson.Parse(body)},
 		Response: resp,
-	}
+	})
+
+	return compiledHTML, body, err
+}
+
+// Used when the data comes from another widget rather than from a request
+func ParseCustomAPIData(body []byte, tmpl *template.Template) (template.HTML, error) {
+	return executeCustomAPITemplate(tmpl, &CustomAPITemplateData{
+		JSON: DecoratedGJSONResult{gjson.ParseBytes(body)},
+	})
+}
+
+func executeCustomAPITemplate(tmpl *template.Template, data *CustomAPITemplateData) (template.HTML, error) {
+	var templateBuffer bytes.Buffer
 
-	err = tmpl.Execute(&templateBuffer, &data)
+	err := tmpl.Execute(&templateBuffer, data)
 	if err != nil {
-		return emptyBody, err
+		return template.HTML(""), err
 	}
 
 	return template.HTML(templateBuffer.String()), nil
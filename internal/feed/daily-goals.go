@@ -0,0 +1,303 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	DailyGoalServiceDuolingo = "duolingo"
+	DailyGoalServiceAnki     = "anki"
+	DailyGoalServiceLeetCode = "leetcode"
+)
+
+const (
+	duolingoEndpoint = "https://www.duolingo.com"
+	leetCodeEndpoint = "https://leetcode.com/graphql"
+)
+
+type DailyGoalRequest struct {
+	Service string
+	Name    string
+	// the Duolingo or LeetCode user, unused for Anki
+	Username string
+	// the AnkiConnect instance
+	URL string
+	// how many reviews or submissions complete the day, Duolingo only reports whether it's done
+	Goal int
+}
+
+type DailyGoal struct {
+	Name    string
+	Service string
+	Done    bool
+	Streak  int
+	// what was done today, -1 when the service doesn't report it
+	Today int
+	Goal  int
+}
+
+func FetchDailyGoals(ctx context.Context, client RequestDoer, requests []*DailyGoalRequest) ([]DailyGoal, error) {
+	client = clientOrDefault(client)
+	task := func(ctx context.Context, request *DailyGoalRequest) (DailyGoal, error) {
+		return fetchDailyGoal(ctx, client, request)
+	}
+
+	job := newJob(taskWithContext(ctx, task), requests).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	goals := make([]DailyGoal, 0, len(requests))
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch daily goal", "service", requests[i].Service, "name", requests[i].Name, "error", errs[i])
+			continue
+		}
+
+		goals = append(goals, results[i])
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return goals, fmt.Errorf("%w: could not fetch %d goal(s)", ErrPartialContent, failed)
+	}
+
+	return goals, nil
+}
+
+func fetchDailyGoal(ctx context.Context, client RequestDoer, request *DailyGoalRequest) (DailyGoal, error) {
+	goal := DailyGoal{
+		Name:    request.Name,
+		Service: request.Service,
+		Today:   -1,
+		Goal:    max(request.Goal, 1),
+	}
+
+	var err error
+
+	switch request.Service {
+	case DailyGoalServiceDuolingo:
+		err = fetchDuolingoGoal(ctx, client, request.Username, &goal)
+	case DailyGoalServiceAnki:
+		err = fetchAnkiGoal(ctx, client, request.URL, &goal)
+	case DailyGoalServiceLeetCode:
+		err = fetchLeetCodeGoal(ctx, client, request.Username, &goal)
+	default:
+		err = fmt.Errorf("unknown service %q", request.Service)
+	}
+
+	return goal, err
+}
+
+// Counts the days in a row that something was done, ending today or, since the day
+// isn't over yet, yesterday
+func streakFromDays(days map[string]int, today time.Time) int {
+	day := today
+
+	if days[day.Format(time.DateOnly)] == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+
+	for days[day.Format(time.DateOnly)] > 0 {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return streak
+}
+
+// Duolingo doesn't have a public API, this is what its website uses and it only
+// needs the profile to be public
+func fetchDuolingoGoal(ctx context.Context, client RequestDoer, username string, goal *DailyGoal) error {
+	query := url.Values{}
+	query.Set("username", username)
+	query.Set("fields", "streak,streakData")
+
+	request, err := http.NewRequestWithContext(ctx, "GET", duolingoEndpoint+"/2017-06-30/users?"+query.Encode(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	addBrowserUserAgentHeader(request)
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return err
+	}
+
+	user := gjson.Get(body, "users.0")
+
+	if !user.Exists() {
+		return fmt.Errorf("user %s not found", username)
+	}
+
+	goal.Streak = int(user.Get("streak").Int())
+	// the streak ends today once a lesson has been done, its dates are in the user's timezone
+	goal.Done = user.Get("streakData.currentStreak.endDate").String() == time.Now().Format(time.DateOnly)
+
+	return nil
+}
+
+func fetchAnkiGoal(ctx context.Context, client RequestDoer, baseURL string, goal *DailyGoal) error {
+	var reviews [][2]json.RawMessage
+
+	if err := ankiConnectAction(ctx, client, baseURL, "getNumCardsReviewedByDay", &reviews); err != nil {
+		return err
+	}
+
+	days := make(map[string]int, len(reviews))
+
+	for _, review := range reviews {
+		var day string
+		var count int
+
+		if json.Unmarshal(review[0], &day) != nil || json.Unmarshal(review[1], &count) != nil {
+			continue
+		}
+
+		days[day] = count
+	}
+
+	var today int
+
+	if err := ankiConnectAction(ctx, client, baseURL, "getNumCardsReviewedToday", &today); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	todayDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	days[todayDate.Format(time.DateOnly)] = today
+
+	goal.Today = today
+	goal.Done = today >= goal.Goal
+	goal.Streak = streakFromDays(days, todayDate)
+
+	return nil
+}
+
+func ankiConnectAction(ctx context.Context, client RequestDoer, baseURL, action string, result any) error {
+	body, err := json.Marshal(map[string]any{"action": action, "version": 6})
+
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := decodeJsonFromRequest[struct {
+		Result json.RawMessage `json:"result"`
+		Error  *string         `json:"error"`
+	}](client, request)
+
+	if err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return fmt.Errorf("ankiconnect: %s", *response.Error)
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+const leetCodeCalendarQuery = `query userCalendar($username: String!) {
+	matchedUser(username: $username) {
+		userCalendar {
+			submissionCalendar
+		}
+	}
+}`
+
+func fetchLeetCodeGoal(ctx context.Context, client RequestDoer, username string, goal *DailyGoal) error {
+	body, err := json.Marshal(map[string]any{
+		"query":     leetCodeCalendarQuery,
+		"variables": map[string]string{"username": username},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", leetCodeEndpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Referer", "https://leetcode.com/")
+	addBrowserUserAgentHeader(request)
+
+	response, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return err
+	}
+
+	calendar := gjson.Get(response, "data.matchedUser.userCalendar.submissionCalendar")
+
+	if !calendar.Exists() {
+		if message := gjson.Get(response, "errors.0.message"); message.Exists() {
+			return fmt.Errorf("leetcode: %s", message.String())
+		}
+
+		return fmt.Errorf("user %s not found", username)
+	}
+
+	// the calendar is a JSON encoded object of UTC midnights in unix seconds and the
+	// number of submissions on that day, which is also when LeetCode's days start
+	var submissions map[string]int
+
+	if err := json.Unmarshal([]byte(calendar.String()), &submissions); err != nil {
+		return fmt.Errorf("decoding submission calendar: %v", err)
+	}
+
+	days := make(map[string]int, len(submissions))
+
+	for timestamp, count := range submissions {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(timestamp), 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		days[time.Unix(seconds, 0).UTC().Format(time.DateOnly)] += count
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	goal.Today = days[today.Format(time.DateOnly)]
+	goal.Done = goal.Today >= goal.Goal
+	goal.Streak = streakFromDays(days, today)
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+const (
+	DDNSProviderCloudflare = "cloudflare"
+	DDNSProviderDuckDNS    = "duckdns"
+	DDNSProviderDeSEC      = "desec"
+)
+
+const (
+	duckDNSUpdateURL = "https://www.duckdns.org/update"
+	deSECUpdateURL   = "https://update.dedyn.io/"
+)
+
+type DDNSRecord struct {
+	Provider string
+	// the full name of the record, such as home.example.com or home.duckdns.org
+	Name  string
+	Token string
+}
+
+type cloudflareZoneJson struct {
+	ID string `json:"id"`
+}
+
+type cloudflareDNSRecordJson struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+// Points the A record, or the AAAA one for IPv6 addresses, at the address
+func UpdateDDNSRecord(ctx context.Context, record *DDNSRecord, address netip.Addr) error {
+	switch record.Provider {
+	case DDNSProviderCloudflare:
+		return updateCloudflareDNSRecord(ctx, record, address)
+	case DDNSProviderDuckDNS:
+		return updateDuckDNSRecord(ctx, record, address)
+	case DDNSProviderDeSEC:
+		return updateDeSECRecord(ctx, record, address)
+	}
+
+	return fmt.Errorf("unknown DDNS provider %s", record.Provider)
+}
+
+func ddnsRecordType(address netip.Addr) string {
+	if address.Is4() {
+		return "A"
+	}
+
+	return "AAAA"
+}
+
+// The zone is the closest parent of the name which the token has access to, which needs
+// a token with the Zone Read and DNS Edit permissions. The record is created when it
+// doesn't exist yet and is left alone when it already points at the address
+func updateCloudflareDNSRecord(ctx context.Context, record *DDNSRecord, address netip.Addr) error {
+	var zoneID string
+
+	for candidate := record.Name; strings.Contains(candidate, "."); candidate = candidate[strings.Index(candidate, ".")+1:] {
+		zones, err := cloudflareGet[[]cloudflareZoneJson](ctx, defaultClient, record.Token, "/zones?name="+url.QueryEscape(candidate))
+
+		if err != nil {
+			return fmt.Errorf("could not get zone: %v", err)
+		}
+
+		if len(zones) > 0 {
+			zoneID = zones[0].ID
+			break
+		}
+	}
+
+	if zoneID == "" {
+		return fmt.Errorf("no zone found for %s", record.Name)
+	}
+
+	recordType := ddnsRecordType(address)
+	recordsPath := "/zones/" + url.PathEscape(zoneID) + "/dns_records"
+	records, err := cloudflareGet[[]cloudflareDNSRecordJson](
+		ctx, defaultClient, record.Token,
+		recordsPath+"?type="+recordType+"&name="+url.QueryEscape(record.Name),
+	)
+
+	if err != nil {
+		return fmt.Errorf("could not get record: %v", err)
+	}
+
+	if len(records) == 0 {
+		_, err = cloudflareRequest[cloudflareDNSRecordJson](ctx, defaultClient, record.Token, "POST", recordsPath, map[string]any{
+			"type":    recordType,
+			"name":    record.Name,
+			"content": address.String(),
+			// automatic
+			"ttl": 1,
+		})
+
+		return err
+	}
+
+	if records[0].Content == address.String() {
+		return nil
+	}
+
+	_, err = cloudflareRequest[cloudflareDNSRecordJson](ctx, defaultClient, record.Token, "PATCH", recordsPath+"/"+url.PathEscape(records[0].ID), map[string]any{
+		"content": address.String(),
+	})
+
+	return err
+}
+
+// Responds with OK or KO as plain text, with a successful status code either way
+func updateDuckDNSRecord(ctx context.Context, record *DDNSRecord, address netip.Addr) error {
+	query := url.Values{}
+	query.Set("domains", strings.TrimSuffix(record.Name, ".duckdns.org"))
+	query.Set("token", record.Token)
+
+	if address.Is4() {
+		query.Set("ip", address.String())
+	} else {
+		query.Set("ipv6", address.String())
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", duckDNSUpdateURL+"?"+query.Encode(), nil)
+	body, err := ddnsUpdateRequest(request)
+
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(body, "OK") {
+		return errors.New("update was rejected, check the domain and token")
+	}
+
+	return nil
+}
+
+// Follows the dyndns2 protocol, responding with good or nochg when it worked. Records of
+// the other family would be removed if their address wasn't given, so they're preserved
+func updateDeSECRecord(ctx context.Context, record *DDNSRecord, address netip.Addr) error {
+	query := url.Values{}
+	query.Set("hostname", record.Name)
+
+	if address.Is4() {
+		query.Set("myipv4", address.String())
+		query.Set("myipv6", "preserve")
+	} else {
+		query.Set("myipv4", "preserve")
+		query.Set("myipv6", address.String())
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", deSECUpdateURL+"?"+query.Encode(), nil)
+	request.Header.Set("Authorization", "Token "+record.Token)
+	body, err := ddnsUpdateRequest(request)
+
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(body, "good") && !strings.HasPrefix(body, "nochg") {
+		return fmt.Errorf("update was rejected: %s", truncateString(body, 64))
+	}
+
+	return nil
+}
+
+func ddnsUpdateRequest(request *http.Request) (string, error) {
+	response, err := defaultClient.Do(request)
+
+	if err != nil {
+		// the URL of DuckDNS has the token in it, which shouldn't end up in the logs
+		var urlErr *url.Error
+
+		if errors.As(err, &urlErr) {
+			return "", urlErr.Err
+		}
+
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d, response: %s", response.StatusCode, truncateString(string(body), 128))
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
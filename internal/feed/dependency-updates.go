@@ -0,0 +1,191 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+type DependencyUpdate struct {
+	Repository string
+	// the title of the pull request
+	Title string
+	URL   string
+	// the version being updated to, empty when it isn't in the title
+	Version   string
+	CreatedAt time.Time
+}
+
+type DependencyUpdateGroup struct {
+	Package string
+	Updates []DependencyUpdate
+}
+
+type DependencyUpdatesRequest struct {
+	// either owner/repo on GitHub or the full URL of a repository on a Gitea or Forgejo instance
+	Repository string
+	Token      string
+	// pull requests whose branch starts with one of these are the ones made by the bots
+	BranchPrefixes []string
+	Client         RequestDoer
+}
+
+// The titles which Dependabot and Renovate use by default, with or without a prefix
+// such as "chore(deps): ". Grouped updates, such as "Update all non-major dependencies",
+// don't match either and are kept under their title
+var (
+	dependabotTitlePattern = regexp.MustCompile(`(?i)\bbump (\S+) from \S+ to (\S+)`)
+	renovateTitlePattern   = regexp.MustCompile(
+		`(?i)\bupdate (?:dependency |module |helm release |terraform )?(\S+)(?: docker tag| docker digest| digest| action)? to (\S+)`,
+	)
+)
+
+type dependencyPullRequestResponseJson struct {
+	Title     string `json:"title"`
+	HtmlUrl   string `json:"html_url"`
+	CreatedAt string `json:"created_at"`
+	Head      struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+func FetchDependencyUpdates(ctx context.Context, requests []*DependencyUpdatesRequest) ([]DependencyUpdate, error) {
+	job := newJob(taskWithContext(ctx, fetchDependencyUpdatesOfRepository), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var updates []DependencyUpdate
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch dependency updates", "repository", requests[i].Repository, "error", errs[i])
+			continue
+		}
+
+		updates = append(updates, results[i]...)
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return updates, fmt.Errorf("%w: could not get updates of %d repository(s)", ErrPartialContent, failed)
+	}
+
+	return updates, nil
+}
+
+func fetchDependencyUpdatesOfRepository(ctx context.Context, request *DependencyUpdatesRequest) ([]DependencyUpdate, error) {
+	var requestURL, name, authorization string
+
+	if strings.Contains(request.Repository, "://") {
+		baseURL, repository, err := splitGiteaRepositoryURL(request.Repository)
+
+		if err != nil {
+			return nil, err
+		}
+
+		requestURL = baseURL + "/api/v1/repos/" + repository + "/pulls?state=open&limit=50"
+		name = repository
+		authorization = "token " + request.Token
+	} else {
+		requestURL = "https://api.github.com/repos/" + request.Repository + "/pulls?state=open&per_page=100"
+		name = request.Repository
+		authorization = "Bearer " + request.Token
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Token != "" {
+		httpRequest.Header.Set("Authorization", authorization)
+	}
+
+	pullRequests, err := decodeJsonFromRequest[[]dependencyPullRequestResponseJson](clientOrDefault(request.Client), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make([]DependencyUpdate, 0, len(pullRequests))
+
+	for i := range pullRequests {
+		pullRequest := &pullRequests[i]
+
+		if !slices.ContainsFunc(request.BranchPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(pullRequest.Head.Ref, prefix)
+		}) {
+			continue
+		}
+
+		updates = append(updates, DependencyUpdate{
+			Repository: name,
+			Title:      pullRequest.Title,
+			URL:        pullRequest.HtmlUrl,
+			CreatedAt:  parseRFC3339Time(pullRequest.CreatedAt),
+		})
+	}
+
+	return updates, nil
+}
+
+// Groups the updates by the package they update, with the packages
+// that are pending in the most repositories coming first
+func GroupDependencyUpdates(updates []DependencyUpdate) []DependencyUpdateGroup {
+	indexes := make(map[string]int)
+	var groups []DependencyUpdateGroup
+
+	for _, update := range updates {
+		var name string
+
+		if match := dependabotTitlePattern.FindStringSubmatch(update.Title); match != nil {
+			name, update.Version = match[1], match[2]
+		} else if match := renovateTitlePattern.FindStringSubmatch(update.Title); match != nil {
+			name, update.Version = match[1], match[2]
+		} else {
+			name = update.Title
+		}
+
+		// packages are often written with different casing by different tools
+		key := strings.ToLower(name)
+		index, exists := indexes[key]
+
+		if !exists {
+			index = len(groups)
+			indexes[key] = index
+			groups = append(groups, DependencyUpdateGroup{Package: name})
+		}
+
+		groups[index].Updates = append(groups[index].Updates, update)
+	}
+
+	for i := range groups {
+		slices.SortFunc(groups[i].Updates, func(a, b DependencyUpdate) int {
+			return strings.Compare(strings.ToLower(a.Repository), strings.ToLower(b.Repository))
+		})
+	}
+
+	slices.SortStableFunc(groups, func(a, b DependencyUpdateGroup) int {
+		if order := len(b.Updates) - len(a.Updates); order != 0 {
+			return order
+		}
+
+		return strings.Compare(strings.ToLower(a.Package), strings.ToLower(b.Package))
+	})
+
+	return groups
+}
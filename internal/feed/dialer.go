@@ -0,0 +1,219 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	PreferIPv4 = "ipv4"
+	PreferIPv6 = "ipv6"
+)
+
+// Used instead of the dialer of the default transport when either the
+// IP version is preferred or a DNS resolver is specified, addresses are
+// tried one after the other starting with the ones of the preferred version
+type dialer struct {
+	dialer     *net.Dialer
+	lookup     func(ctx context.Context, host string) ([]net.IP, error)
+	preference string
+}
+
+func newDialer(preference string, resolver string) (*dialer, error) {
+	if preference != "" && preference != PreferIPv4 && preference != PreferIPv6 {
+		return nil, fmt.Errorf("invalid IP preference '%s', must be either ipv4 or ipv6", preference)
+	}
+
+	d := &dialer{
+		dialer:     &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		preference: preference,
+	}
+
+	if resolver == "" {
+		d.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		}
+	} else if strings.HasPrefix(resolver, "https://") {
+		client := &http.Client{Timeout: 10 * time.Second}
+		d.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+			return lookupIPOverHTTPS(ctx, client, resolver, host)
+		}
+	} else {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+
+		netResolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return d.dialer.DialContext(ctx, network, resolver)
+			},
+		}
+
+		d.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+			return netResolver.LookupIP(ctx, "ip", host)
+		}
+	}
+
+	return d, nil
+}
+
+func (d *dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ips, err = d.lookup(ctx, host)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if d.preference != "" {
+		slices.SortStableFunc(ips, func(a, b net.IP) int {
+			return d.rank(a) - d.rank(b)
+		})
+	}
+
+	lastErr := fmt.Errorf("no addresses found for %s", host)
+
+	for _, ip := range ips {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+
+		if err == nil {
+			return conn, nil
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+func (d *dialer) rank(ip net.IP) int {
+	isIPv4 := ip.To4() != nil
+
+	if isIPv4 == (d.preference == PreferIPv4) {
+		return 0
+	}
+
+	return 1
+}
+
+// Resolves both the A and AAAA records of the host through
+// a DNS over HTTPS server using the wire format of RFC 8484
+func lookupIPOverHTTPS(ctx context.Context, client *http.Client, serverURL string, host string) ([]net.IP, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, 2)
+	var lastErr error
+
+	for _, recordType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, err := queryOverHTTPS(ctx, client, serverURL, name, recordType)
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		ips = append(ips, answers...)
+	}
+
+	if len(ips) == 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no addresses found for %s", host)
+		}
+
+		return nil, lastErr
+	}
+
+	return ips, nil
+}
+
+func queryOverHTTPS(ctx context.Context, client *http.Client, serverURL string, name dnsmessage.Name, recordType dnsmessage.Type) ([]net.IP, error) {
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  recordType,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+
+	body, err := query.Pack()
+
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", serverURL, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/dns-message")
+	request.Header.Set("Accept", "application/dns-message")
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from DNS server %s", response.StatusCode, serverURL)
+	}
+
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var message dnsmessage.Message
+
+	if err = message.Unpack(responseBody); err != nil {
+		return nil, err
+	}
+
+	if message.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("DNS query failed: %s", message.RCode)
+	}
+
+	ips := make([]net.IP, 0, len(message.Answers))
+
+	for _, answer := range message.Answers {
+		switch resource := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(resource.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(resource.AAAA[:]))
+		}
+	}
+
+	return ips, nil
+}
@@ -0,0 +1,24 @@
+package feed
+
+import (
+	"sort"
+)
+
+type DiscoveredService struct {
+	Name        string
+	URL         string
+	CheckURL    string
+	Icon        string
+	Group       string
+	Description string
+}
+
+type DiscoveredServices []DiscoveredService
+
+func (s DiscoveredServices) SortByName() DiscoveredServices {
+	sort.SliceStable(s, func(i, j int) bool {
+		return s[i].Name < s[j].Name
+	})
+
+	return s
+}
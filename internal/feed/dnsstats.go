@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"slices"
+	"strings"
+)
+
+// Combines the stats of several instances of the same resolver, such as a primary Pi-hole
+// and its secondaries. Their blocklists are usually kept in sync, so the number of domains
+// being blocked is the highest of any of them rather than their sum
+func MergeDNSStats(all []*DNSStats) *DNSStats {
+	merged := &DNSStats{}
+	blockedByDomain := make(map[string]int)
+	var weightedResponseTime int
+
+	for _, stats := range all {
+		merged.TotalQueries += stats.TotalQueries
+		merged.BlockedQueries += stats.BlockedQueries
+		merged.DomainsBlocked = max(merged.DomainsBlocked, stats.DomainsBlocked)
+		weightedResponseTime += stats.ResponseTime * stats.TotalQueries
+
+		for i := range stats.Series {
+			merged.Series[i].Queries += stats.Series[i].Queries
+			merged.Series[i].Blocked += stats.Series[i].Blocked
+		}
+
+		for _, domain := range stats.TopBlockedDomains {
+			blockedByDomain[domain.Domain] += domain.Blocked
+		}
+	}
+
+	if merged.TotalQueries > 0 {
+		merged.BlockedPercent = int(float64(merged.BlockedQueries) / float64(merged.TotalQueries) * 100)
+		merged.ResponseTime = weightedResponseTime / merged.TotalQueries
+	}
+
+	maxQueriesInSeries := 0
+
+	for i := range merged.Series {
+		series := &merged.Series[i]
+		maxQueriesInSeries = max(maxQueriesInSeries, series.Queries)
+
+		if series.Queries > 0 {
+			series.PercentBlocked = int(float64(series.Blocked) / float64(series.Queries) * 100)
+		}
+	}
+
+	if maxQueriesInSeries > 0 {
+		for i := range merged.Series {
+			merged.Series[i].PercentTotal = int(float64(merged.Series[i].Queries) / float64(maxQueriesInSeries) * 100)
+		}
+	}
+
+	for domain, blocked := range blockedByDomain {
+		merged.TopBlockedDomains = append(merged.TopBlockedDomains, DNSStatsBlockedDomain{
+			Domain:  domain,
+			Blocked: blocked,
+		})
+	}
+
+	slices.SortFunc(merged.TopBlockedDomains, func(a, b DNSStatsBlockedDomain) int {
+		if a.Blocked != b.Blocked {
+			return b.Blocked - a.Blocked
+		}
+
+		return strings.Compare(a.Domain, b.Domain)
+	})
+
+	merged.TopBlockedDomains = merged.TopBlockedDomains[:min(len(merged.TopBlockedDomains), 5)]
+
+	for i := range merged.TopBlockedDomains {
+		if merged.BlockedQueries > 0 {
+			merged.TopBlockedDomains[i].PercentBlocked = int(float64(merged.TopBlockedDomains[i].Blocked) / float64(merged.BlockedQueries) * 100)
+		}
+	}
+
+	return merged
+}
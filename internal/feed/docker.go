@@ -0,0 +1,337 @@
+package feed
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDockerHost = "unix:///var/run/docker.sock"
+	// widgets are usually updated every few minutes, there's no point in keeping connections open until then
+	dockerIdleConnTimeout = 30 * time.Second
+)
+
+type dockerContainerJson struct {
+	Id      string            `json:"Id"`
+	Names   []string          `json:"Names"`
+	Image   string            `json:"Image"`
+	State   string            `json:"State"`
+	Status  string            `json:"Status"`
+	Created int64             `json:"Created"`
+	Labels  map[string]string `json:"Labels"`
+}
+
+// Paths to the PEM encoded files used to connect to a daemon which is
+// protected with TLS, the same ones that get passed to docker --tlsverify
+type DockerTLS struct {
+	CA   string
+	Cert string
+	Key  string
+}
+
+func (t *DockerTLS) config() (*tls.Config, error) {
+	config := &tls.Config{}
+
+	if t.CA != "" {
+		caCert, err := os.ReadFile(t.CA)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA: %v", err)
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CA)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if t.Cert != "" || t.Key != "" {
+		certificate, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %v", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+type dockerClient struct {
+	client  RequestDoer
+	baseURL string
+}
+
+type dockerClientKey struct {
+	host string
+	tls  DockerTLS
+}
+
+// Clients are kept for as long as Glance runs so that discovery and widget updates
+// reuse their connections and the certificates only get read once
+var dockerClients sync.Map
+
+func dockerClientFor(host string, dockerTLS *DockerTLS) (*dockerClient, error) {
+	key := dockerClientKey{host: host}
+
+	if dockerTLS != nil {
+		key.tls = *dockerTLS
+	}
+
+	if cached, ok := dockerClients.Load(key); ok {
+		return cached.(*dockerClient), nil
+	}
+
+	client, baseURL, err := newDockerClient(host, dockerTLS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := dockerClients.LoadOrStore(key, &dockerClient{client: client, baseURL: baseURL})
+
+	return cached.(*dockerClient), nil
+}
+
+// Returns a client along with the base URL that should be used for requests,
+// since when connecting through a unix socket the host part of the URL is ignored
+func newDockerClient(host string, dockerTLS *DockerTLS) (RequestDoer, string, error) {
+	if host == "" {
+		host = defaultDockerHost
+	}
+
+	if socketPath, isSocket := strings.CutPrefix(host, "unix://"); isSocket {
+		client := &http.Client{
+			Timeout: defaultClientTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+				IdleConnTimeout: dockerIdleConnTimeout,
+			},
+		}
+
+		return client, "http://docker", nil
+	}
+
+	if address, isTCP := strings.CutPrefix(host, "tcp://"); isTCP {
+		if dockerTLS != nil {
+			host = "https://" + address
+		} else {
+			host = "http://" + address
+		}
+	}
+
+	parsed, err := url.Parse(host)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, "", fmt.Errorf("invalid docker host: %s", host)
+	}
+
+	if dockerTLS == nil {
+		return defaultClient, strings.TrimRight(host, "/"), nil
+	}
+
+	tlsConfig, err := dockerTLS.config()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &http.Client{
+		Timeout:   defaultClientTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, IdleConnTimeout: dockerIdleConnTimeout},
+	}
+
+	return client, strings.TrimRight(host, "/"), nil
+}
+
+// Labels are either just a key or a key=value pair, same as docker ps --filter label=
+func fetchDockerContainers(ctx context.Context, host string, dockerTLS *DockerTLS, all bool, labels []string) ([]dockerContainerJson, error) {
+	client, err := dockerClientFor(host, dockerTLS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+
+	if all {
+		query.Set("all", "true")
+	}
+
+	if len(labels) > 0 {
+		filters, err := json.Marshal(map[string][]string{"label": labels})
+
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("filters", string(filters))
+	}
+
+	requestURL := client.baseURL + "/containers/json"
+
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJsonFromRequest[[]dockerContainerJson](client.client, request)
+}
+
+const dockerLabelPrefix = "glance."
+
+// Uses the labels of running containers to create a list of services, only
+// containers which have at least the glance.url label are included:
+//
+//	glance.name, glance.url, glance.check-url, glance.icon, glance.group, glance.description
+func DiscoverServicesFromDockerLabels(ctx context.Context, host string) (DiscoveredServices, error) {
+	containers, err := fetchDockerContainers(ctx, host, nil, false, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not list docker containers: %w", err)
+	}
+
+	services := make(DiscoveredServices, 0, len(containers))
+
+	for i := range containers {
+		labels := containers[i].Labels
+		serviceURL := labels[dockerLabelPrefix+"url"]
+
+		if serviceURL == "" {
+			continue
+		}
+
+		name := labels[dockerLabelPrefix+"name"]
+
+		if name == "" && len(containers[i].Names) > 0 {
+			name = strings.TrimPrefix(containers[i].Names[0], "/")
+		}
+
+		services = append(services, DiscoveredService{
+			Name:        name,
+			URL:         serviceURL,
+			CheckURL:    labels[dockerLabelPrefix+"check-url"],
+			Icon:        labels[dockerLabelPrefix+"icon"],
+			Group:       labels[dockerLabelPrefix+"group"],
+			Description: labels[dockerLabelPrefix+"description"],
+		})
+	}
+
+	return services.SortByName(), nil
+}
+
+type DockerContainer struct {
+	Name        string
+	Image       string
+	State       string
+	Status      string
+	Health      string
+	CreatedAt   time.Time
+	URL         string
+	Icon        string
+	Description string
+}
+
+type DockerContainers []DockerContainer
+
+// Running containers come first
+func (c DockerContainers) SortByStateAndName() DockerContainers {
+	sort.SliceStable(c, func(i, j int) bool {
+		if (c[i].State == "running") != (c[j].State == "running") {
+			return c[i].State == "running"
+		}
+
+		return c[i].Name < c[j].Name
+	})
+
+	return c
+}
+
+type DockerContainersRequest struct {
+	Host   string
+	TLS    *DockerTLS
+	All    bool
+	Labels []string
+}
+
+// The health is only included as part of the human readable status, e.g.
+// "Up 2 hours (healthy)", so it has to be extracted from there
+func dockerHealthFromStatus(status string) (string, string) {
+	for _, health := range []string{"healthy", "unhealthy", "health: starting"} {
+		if strings.HasSuffix(status, "("+health+")") {
+			status = strings.TrimSpace(strings.TrimSuffix(status, "("+health+")"))
+
+			if health == "health: starting" {
+				health = "starting"
+			}
+
+			return status, health
+		}
+	}
+
+	return status, ""
+}
+
+func FetchDockerContainers(ctx context.Context, request *DockerContainersRequest) (DockerContainers, error) {
+	containersJson, err := fetchDockerContainers(ctx, request.Host, request.TLS, request.All, request.Labels)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not list docker containers: %v", ErrNoContent, err)
+	}
+
+	containers := make(DockerContainers, 0, len(containersJson))
+
+	for i := range containersJson {
+		container := &containersJson[i]
+		labels := container.Labels
+
+		if labels[dockerLabelPrefix+"hide"] == "true" {
+			continue
+		}
+
+		name := labels[dockerLabelPrefix+"name"]
+
+		if name == "" && len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		status, health := dockerHealthFromStatus(container.Status)
+
+		containers = append(containers, DockerContainer{
+			Name:        name,
+			Image:       container.Image,
+			State:       container.State,
+			Status:      status,
+			Health:      health,
+			CreatedAt:   time.Unix(container.Created, 0),
+			URL:         labels[dockerLabelPrefix+"url"],
+			Icon:        labels[dockerLabelPrefix+"icon"],
+			Description: labels[dockerLabelPrefix+"description"],
+		})
+	}
+
+	return containers.SortByStateAndName(), nil
+}
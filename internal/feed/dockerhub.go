@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -20,7 +21,7 @@ const dockerHubRepoTagURLFormat = "https://hub.docker.com/r/%s/tags?name=%s"
 const dockerHubTagsURLFormat = "https://hub.docker.com/v2/namespaces/%s/repositories/%s/tags"
 const dockerHubSpecificTagURLFormat = "https://hub.docker.com/v2/namespaces/%s/repositories/%s/tags/%s"
 
-func fetchLatestDockerHubRelease(request *ReleaseRequest) (*AppRelease, error) {
+func fetchLatestDockerHubRelease(ctx context.Context, request *ReleaseRequest) (*AppRelease, error) {
 
 	nameParts := strings.Split(request.Repository, "/")
 
@@ -40,7 +41,7 @@ func fetchLatestDockerHubRelease(request *ReleaseRequest) (*AppRelease, error) {
 		requestURL = fmt.Sprintf(dockerHubTagsURLFormat, nameParts[0], nameParts[1])
 	}
 
-	httpRequest, err := http.NewRequest("GET", requestURL, nil)
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,280 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	EVServiceTesla = "tesla"
+	EVServiceOVMS  = "ovms"
+	EVServiceEVCC  = "evcc"
+)
+
+const (
+	teslaAuthEndpoint = "https://fleet-auth.prd.vn.cloud.tesla.com/oauth2/v3/token"
+	ovmsEndpoint      = "https://api.openvehicles.com:6869"
+)
+
+const kilometersPerMile = 1.609344
+
+var teslaRegionEndpoints = map[string]string{
+	"na": "https://fleet-api.prd.na.vn.cloud.tesla.com",
+	"eu": "https://fleet-api.prd.eu.vn.cloud.tesla.com",
+	"cn": "https://fleet-api.prd.cn.vn.cloud.tesla.cn",
+}
+
+func IsTeslaRegion(region string) bool {
+	_, exists := teslaRegionEndpoints[region]
+	return exists
+}
+
+type EVStatus struct {
+	Name string
+	// in percent
+	Battery float64
+	// in kilometers, 0 when not reported
+	Range float64
+	// the level charging stops at in percent, 0 when not reported
+	ChargeLimit float64
+	PluggedIn   bool
+	Charging    bool
+	// the vehicle stopped charging because it reached the limit
+	Complete bool
+	// in kilowatts
+	ChargePower float64
+	// 0 when not charging or not reported
+	TimeToFull time.Duration
+	// Tesla doesn't report anything while the car is asleep and waking it up drains the battery
+	Asleep bool
+}
+
+func (s *EVStatus) StateLabel() string {
+	switch {
+	case s.Asleep:
+		return "Asleep"
+	case s.Charging:
+		return "Charging"
+	case s.PluggedIn && (s.Complete || (s.ChargeLimit > 0 && s.Battery >= s.ChargeLimit)):
+		return "Charged"
+	case s.PluggedIn:
+		return "Plugged in"
+	}
+
+	return "Unplugged"
+}
+
+// Gets the status of a vehicle from Tesla's Fleet API, an OVMS server or an EVCC instance.
+// Tesla only hands out short lived access tokens which get created from the refresh token, which
+// itself changes when that happens, so whoever created the client gets told about the new one
+// through OnRefreshTokenChange in order to keep it
+type EVClient struct {
+	Service string
+	// the EVCC instance or the OVMS server, which defaults to the public one
+	URL string
+	// the VIN for Tesla, the vehicle ID for OVMS
+	Vehicle string
+	// EVCC only, starting from 1
+	Loadpoint int
+	Username  string
+	Password  string
+	// Tesla only
+	Region               string
+	ClientID             string
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+func (c *EVClient) FetchStatus(ctx context.Context, client RequestDoer) (*EVStatus, error) {
+	client = clientOrDefault(client)
+
+	switch c.Service {
+	case EVServiceTesla:
+		return c.fetchTeslaStatus(ctx, client)
+	case EVServiceOVMS:
+		return c.fetchOVMSStatus(ctx, client)
+	case EVServiceEVCC:
+		return c.fetchEVCCStatus(ctx, client)
+	}
+
+	return nil, fmt.Errorf("unknown service %q", c.Service)
+}
+
+// Must be called with the mutex held
+func (c *EVClient) teslaToken(ctx context.Context, client RequestDoer) (string, error) {
+	return c.oauth.refresh(ctx, client, &c.RefreshToken, c.OnRefreshTokenChange, func(ctx context.Context, form url.Values) *http.Request {
+		form.Set("client_id", c.ClientID)
+
+		return newOAuthTokenRequest(ctx, teslaAuthEndpoint, form)
+	})
+}
+
+func (c *EVClient) fetchTeslaStatus(ctx context.Context, client RequestDoer) (*EVStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.teslaToken(ctx, client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// only asking for the charge state, since the location needs its own scope
+	request, _ := http.NewRequestWithContext(
+		ctx, "GET",
+		teslaRegionEndpoints[c.Region]+"/api/1/vehicles/"+url.PathEscape(c.Vehicle)+"/vehicle_data?endpoints=charge_state",
+		nil,
+	)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	body, status, err := fetchRedactedJson(client, request)
+
+	if status == http.StatusRequestTimeout {
+		return &EVStatus{Asleep: true}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := gjson.Get(body, "response")
+	charge := data.Get("charge_state")
+	chargingState := charge.Get("charging_state").String()
+
+	return &EVStatus{
+		Name:        data.Get("display_name").String(),
+		Battery:     charge.Get("battery_level").Float(),
+		Range:       charge.Get("battery_range").Float() * kilometersPerMile,
+		ChargeLimit: charge.Get("charge_limit_soc").Float(),
+		PluggedIn:   chargingState != "" && chargingState != "Disconnected",
+		Charging:    chargingState == "Charging" || chargingState == "Starting",
+		Complete:    chargingState == "Complete",
+		ChargePower: charge.Get("charger_power").Float(),
+		TimeToFull:  time.Duration(charge.Get("minutes_to_full_charge").Float() * float64(time.Minute)),
+	}, nil
+}
+
+func (c *EVClient) fetchOVMSStatus(ctx context.Context, client RequestDoer) (*EVStatus, error) {
+	query := url.Values{}
+	query.Set("username", c.Username)
+	query.Set("password", c.Password)
+
+	baseURL := c.URL
+
+	if baseURL == "" {
+		baseURL = ovmsEndpoint
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(baseURL, "/")+"/api/charge/"+url.PathEscape(c.Vehicle)+"?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the values are mostly strings, which gjson converts
+	charge := gjson.Parse(body)
+	rangeValue := charge.Get("estimatedrange").Float()
+
+	if charge.Get("units").String() == "M" {
+		rangeValue *= kilometersPerMile
+	}
+
+	chargeState := charge.Get("chargestate").String()
+	charging := chargeState == "charging" || chargeState == "topoff" || chargeState == "heating"
+
+	status := &EVStatus{
+		Name:        c.Vehicle,
+		Battery:     charge.Get("soc").Float(),
+		Range:       rangeValue,
+		ChargeLimit: charge.Get("charge_limit_soc").Float(),
+		PluggedIn:   charge.Get("pilotpresent").Int() == 1,
+		Charging:    charging,
+		Complete:    chargeState == "done",
+	}
+
+	if charging {
+		status.ChargePower = charge.Get("chargepower").Float()
+		status.TimeToFull = time.Duration(charge.Get("charge_etr_full").Float() * float64(time.Minute))
+	}
+
+	return status, nil
+}
+
+func (c *EVClient) fetchEVCCStatus(ctx context.Context, client RequestDoer) (*EVStatus, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(c.URL, "/")+"/api/state", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state := gjson.Parse(body)
+
+	// older versions wrap the state in a result object
+	if result := state.Get("result"); result.Exists() {
+		state = result
+	}
+
+	loadpoints := state.Get("loadpoints").Array()
+
+	if c.Loadpoint < 1 || c.Loadpoint > len(loadpoints) {
+		return nil, fmt.Errorf("loadpoint %d not found, there are %d", c.Loadpoint, len(loadpoints))
+	}
+
+	loadpoint := loadpoints[c.Loadpoint-1]
+	name := loadpoint.Get("vehicleTitle").String()
+
+	if name == "" {
+		name = loadpoint.Get("title").String()
+	}
+
+	// newer versions report the limit which actually applies separately
+	limit := loadpoint.Get("effectiveLimitSoc").Float()
+
+	if limit == 0 {
+		limit = loadpoint.Get("limitSoc").Float()
+	}
+
+	charging := loadpoint.Get("charging").Bool()
+
+	status := &EVStatus{
+		Name:        name,
+		Battery:     loadpoint.Get("vehicleSoc").Float(),
+		Range:       loadpoint.Get("vehicleRange").Float(),
+		ChargeLimit: limit,
+		PluggedIn:   loadpoint.Get("connected").Bool(),
+		Charging:    charging,
+	}
+
+	if charging {
+		status.ChargePower = loadpoint.Get("chargePower").Float() / 1000
+		status.TimeToFull = time.Duration(loadpoint.Get("chargeRemainingDuration").Float() * float64(time.Second))
+	}
+
+	return status, nil
+}
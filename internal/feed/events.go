@@ -0,0 +1,310 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	meetupEndpoint      = "https://www.meetup.com"
+	bandsintownEndpoint = "https://rest.bandsintown.com"
+	songkickEndpoint    = "https://api.songkick.com/api/3.0"
+)
+
+type Event struct {
+	Title string
+	// Meetup, Bandsintown or Songkick
+	Source string
+	// the group for Meetup, empty for concerts
+	Group    string
+	Venue    string
+	URL      string
+	StartsAt time.Time
+	IsAllDay bool
+	// in kilometers from the place, -1 when not known
+	Distance float64
+}
+
+type EventsRequest struct {
+	// used for the distance and the timezone that the events are shown in
+	Place *PlaceJson
+	// in kilometers, only applies to concerts since Meetup groups are picked by hand
+	Radius             float64
+	Until              time.Time
+	MeetupGroups       []string
+	BandsintownAppID   string
+	BandsintownArtists []string
+	SongkickAPIKey     string
+	SongkickArtists    []string
+	Client             RequestDoer
+}
+
+type eventsSource struct {
+	name  string
+	fetch func(context.Context) ([]Event, error)
+}
+
+// Gets the events of every group and artist, sorted by when they start
+func FetchEvents(ctx context.Context, request *EventsRequest) ([]Event, error) {
+	client := clientOrDefault(request.Client)
+	now := time.Now()
+	var sources []eventsSource
+
+	for _, group := range request.MeetupGroups {
+		sources = append(sources, eventsSource{group, func(ctx context.Context) ([]Event, error) {
+			return fetchMeetupGroupEvents(ctx, client, group, request.Place, now, request.Until)
+		}})
+	}
+
+	for _, artist := range request.BandsintownArtists {
+		sources = append(sources, eventsSource{artist, func(ctx context.Context) ([]Event, error) {
+			return fetchBandsintownEvents(ctx, client, request.BandsintownAppID, artist, request.Place)
+		}})
+	}
+
+	for _, artist := range request.SongkickArtists {
+		sources = append(sources, eventsSource{artist, func(ctx context.Context) ([]Event, error) {
+			return fetchSongkickEvents(ctx, client, request.SongkickAPIKey, artist, request.Place)
+		}})
+	}
+
+	task := func(ctx context.Context, source eventsSource) ([]Event, error) {
+		return source.fetch(ctx)
+	}
+
+	job := newJob(taskWithContext(ctx, task), sources).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var events []Event
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch events", "source", sources[i].name, "error", errs[i])
+			continue
+		}
+
+		for _, event := range results[i] {
+			// all day ones are kept until the end of their day
+			if (event.IsAllDay && event.StartsAt.Add(24*time.Hour).Before(now)) ||
+				(!event.IsAllDay && event.StartsAt.Before(now)) ||
+				event.StartsAt.After(request.Until) {
+				continue
+			}
+
+			// concerts without a known venue can't be placed, so they're left out rather than
+			// showing ones on the other side of the world
+			if event.Source != "Meetup" && (event.Distance < 0 || event.Distance > request.Radius) {
+				continue
+			}
+
+			if !event.IsAllDay {
+				event.StartsAt = event.StartsAt.In(request.Place.location)
+			}
+
+			events = append(events, event)
+		}
+	}
+
+	if len(sources) > 0 && failed == len(sources) {
+		return nil, ErrNoContent
+	}
+
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return a.StartsAt.Compare(b.StartsAt)
+	})
+
+	if failed > 0 {
+		return events, fmt.Errorf("%w: could not fetch events of %d group(s) or artist(s)", ErrPartialContent, failed)
+	}
+
+	return events, nil
+}
+
+// Every Meetup group has a public calendar with its upcoming events
+func fetchMeetupGroupEvents(ctx context.Context, client RequestDoer, group string, place *PlaceJson, from, to time.Time) ([]Event, error) {
+	calendar, err := fetchCalendarEventsTask(ctx, &CalendarEventsRequest{
+		URL:      meetupEndpoint + "/" + url.PathEscape(group) + "/events/ical/",
+		Name:     group,
+		From:     from,
+		To:       to,
+		Location: place.location,
+		Client:   client,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(calendar))
+
+	for i := range calendar {
+		events = append(events, Event{
+			Title:    calendar[i].Title,
+			Source:   "Meetup",
+			Group:    group,
+			Venue:    calendar[i].Location,
+			URL:      calendar[i].URL,
+			StartsAt: calendar[i].StartsAt,
+			IsAllDay: calendar[i].IsAllDay,
+			Distance: -1,
+		})
+	}
+
+	return events, nil
+}
+
+func fetchBandsintownEvents(ctx context.Context, client RequestDoer, appID, artist string, place *PlaceJson) ([]Event, error) {
+	query := url.Values{}
+	query.Set("app_id", appID)
+	query.Set("date", "upcoming")
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		bandsintownEndpoint+"/artists/"+url.PathEscape(artist)+"/events?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+
+	for _, item := range gjson.Parse(body).Array() {
+		// the local time at the venue, which is assumed to be in the same timezone as the place
+		startsAt, err := time.ParseInLocation("2006-01-02T15:04:05", item.Get("datetime").String(), place.location)
+
+		if err != nil {
+			continue
+		}
+
+		venue := item.Get("venue")
+		title := item.Get("title").String()
+
+		if title == "" {
+			title = artist
+		}
+
+		events = append(events, Event{
+			Title:    title,
+			Source:   "Bandsintown",
+			Venue:    joinVenue(venue.Get("name").String(), venue.Get("city").String()),
+			URL:      item.Get("url").String(),
+			StartsAt: startsAt,
+			Distance: distanceFromPlace(place, venue.Get("latitude"), venue.Get("longitude")),
+		})
+	}
+
+	return events, nil
+}
+
+func fetchSongkickEvents(ctx context.Context, client RequestDoer, apiKey, artist string, place *PlaceJson) ([]Event, error) {
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		songkickEndpoint+"/artists/"+url.PathEscape(artist)+"/calendar.json?apikey="+url.QueryEscape(apiKey),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+
+	for _, item := range gjson.Get(body, "resultsPage.results.event").Array() {
+		start := item.Get("start")
+		// the datetime has the offset of the venue, but is missing when the time isn't known
+		startsAt, err := time.Parse("2006-01-02T15:04:05-0700", start.Get("datetime").String())
+		isAllDay := err != nil
+
+		if isAllDay {
+			startsAt, err = time.ParseInLocation(time.DateOnly, start.Get("date").String(), place.location)
+
+			if err != nil {
+				continue
+			}
+		}
+
+		venue := item.Get("venue")
+		latitude, longitude := venue.Get("lat"), venue.Get("lng")
+
+		// venues which aren't in Songkick's database only have the coordinates of the city
+		if latitude.Type == gjson.Null {
+			latitude, longitude = item.Get("location.lat"), item.Get("location.lng")
+		}
+
+		events = append(events, Event{
+			Title:    item.Get("displayName").String(),
+			Source:   "Songkick",
+			Venue:    joinVenue(venue.Get("displayName").String(), item.Get("location.city").String()),
+			URL:      item.Get("uri").String(),
+			StartsAt: startsAt,
+			IsAllDay: isAllDay,
+			Distance: distanceFromPlace(place, latitude, longitude),
+		})
+	}
+
+	return events, nil
+}
+
+func joinVenue(name, city string) string {
+	if name == "" || city == "" {
+		return name + city
+	}
+
+	return name + ", " + city
+}
+
+// Bandsintown has the coordinates as strings and Songkick as numbers, which gjson handles both of
+func distanceFromPlace(place *PlaceJson, latitude, longitude gjson.Result) float64 {
+	if latitude.String() == "" || longitude.String() == "" {
+		return -1
+	}
+
+	return haversineDistance(place.Latitude, place.Longitude, latitude.Float(), longitude.Float())
+}
+
+// The distance in kilometers between two points on Earth
+func haversineDistance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	const earthRadius = 6371
+
+	toRadians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+
+	dLatitude := toRadians(latitude2 - latitude1)
+	dLongitude := toRadians(longitude2 - longitude1)
+
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const exchangeRateCacheDuration = 24 * time.Hour
+
+type exchangeRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// Rates only change meaningfully once a day for our purposes, so they're
+// shared between widgets and refetched once they're older than a day
+var exchangeRateCache = struct {
+	sync.Mutex
+	rates map[string]exchangeRate
+}{rates: make(map[string]exchangeRate)}
+
+// Yahoo reports some markets in the minor unit of the currency
+var minorCurrencyUnits = map[string]struct {
+	code    string
+	divisor float64
+}{
+	"GBp": {"GBP", 100},
+	"GBX": {"GBP", 100},
+	"ZAc": {"ZAR", 100},
+	"ILA": {"ILS", 100},
+}
+
+func normalizeCurrency(code string, amount float64) (string, float64) {
+	if minor, exists := minorCurrencyUnits[code]; exists {
+		return minor.code, amount / minor.divisor
+	}
+
+	return code, amount
+}
+
+// Returns the exchange rates for the given currency pairs, keyed by
+// the concatenation of both currency codes such as EURUSD
+func fetchExchangeRates(ctx context.Context, client RequestDoer, pairs []string) (map[string]float64, []error) {
+	rates := make(map[string]float64, len(pairs))
+	errs := make([]error, len(pairs))
+	missing := make([]int, 0, len(pairs))
+
+	exchangeRateCache.Lock()
+	for i, pair := range pairs {
+		if cached, exists := exchangeRateCache.rates[pair]; exists && time.Since(cached.fetchedAt) < exchangeRateCacheDuration {
+			rates[pair] = cached.rate
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	exchangeRateCache.Unlock()
+
+	if len(missing) == 0 {
+		return rates, errs
+	}
+
+	requests := make([]*http.Request, len(missing))
+
+	for i, p := range missing {
+		requests[i], _ = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s=X?range=1d&interval=1d", pairs[p]), nil)
+	}
+
+	job := newJob(decodeJsonFromRequestTask[marketResponseJson](clientOrDefault(client)), requests).withContext(ctx)
+	responses, fetchErrs, err := workerPoolDo(job)
+
+	if err != nil {
+		for _, p := range missing {
+			errs[p] = err
+		}
+
+		return rates, errs
+	}
+
+	exchangeRateCache.Lock()
+	defer exchangeRateCache.Unlock()
+
+	for i, p := range missing {
+		if fetchErrs[i] != nil {
+			errs[p] = fetchErrs[i]
+			continue
+		}
+
+		if len(responses[i].Chart.Result) == 0 || responses[i].Chart.Result[0].Meta.RegularMarketPrice == 0 {
+			errs[p] = errors.New("no exchange rate returned")
+			continue
+		}
+
+		rate := responses[i].Chart.Result[0].Meta.RegularMarketPrice
+		rates[pairs[p]] = rate
+		exchangeRateCache.rates[pairs[p]] = exchangeRate{rate: rate, fetchedAt: time.Now()}
+	}
+
+	return rates, errs
+}
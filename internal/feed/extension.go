@@ -1,60 +1,156 @@
 package feed
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"html/template"
 	"io"
 	"log/slog"
 	"net/http"
-	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 type ExtensionType int
 
 const (
 	ExtensionContentHTML    ExtensionType = iota
+	ExtensionContentJSON                  = iota
+	ExtensionContentList                  = iota
 	ExtensionContentUnknown               = iota
 )
 
 var ExtensionStringToType = map[string]ExtensionType{
 	"html": ExtensionContentHTML,
+	"json": ExtensionContentJSON,
+	"list": ExtensionContentList,
 }
 
 const (
 	ExtensionHeaderTitle       = "Widget-Title"
 	ExtensionHeaderContentType = "Widget-Content-Type"
+	ExtensionHeaderCacheTTL    = "Widget-Cache-TTL"
 )
 
+// Extensions are only asked to slow down updates, not to speed them up beyond this
+const minExtensionCacheTTL = 10 * time.Second
+
 type ExtensionRequestOptions struct {
-	URL                 string            `yaml:"url"`
-	FallbackContentType string            `yaml:"fallback-content-type"`
-	Parameters          map[string]string `yaml:"parameters"`
-	AllowHtml           bool              `yaml:"allow-potentially-dangerous-html"`
+	URL                 string             `yaml:"url"`
+	FallbackContentType string             `yaml:"fallback-content-type"`
+	Parameters          map[string]string  `yaml:"parameters"`
+	AllowHtml           bool               `yaml:"allow-potentially-dangerous-html"`
+	Template            *template.Template `yaml:"-"`
 }
 
 type Extension struct {
 	Title   string
 	Content template.HTML
+	// Lists are displayed by Glance itself rather than through the content
+	IsList bool
+	Items  []ExtensionListItem
+	// Zero when the extension doesn't specify how long its content can be cached for
+	CacheTTL time.Duration
+}
+
+type ExtensionListItem struct {
+	Title       string
+	URL         string
+	Description string
+	Time        time.Time
+	Details     []string
+}
+
+type extensionListResponseJson struct {
+	Items []struct {
+		Title       string   `json:"title"`
+		URL         string   `json:"url"`
+		Description string   `json:"description"`
+		Time        int64    `json:"time"`
+		Details     []string `json:"details"`
+	} `json:"items"`
+}
+
+func parseExtensionList(content []byte) ([]ExtensionListItem, error) {
+	var response extensionListResponseJson
+
+	if err := json.Unmarshal(content, &response); err != nil {
+		return nil, fmt.Errorf("extension returned an invalid list: %v", err)
+	}
+
+	items := make([]ExtensionListItem, 0, len(response.Items))
+
+	for i := range response.Items {
+		item := &response.Items[i]
+
+		if item.Title == "" {
+			return nil, fmt.Errorf("item %d of the list returned by the extension has no title", i+1)
+		}
+
+		listItem := ExtensionListItem{
+			Title:       item.Title,
+			URL:         item.URL,
+			Description: item.Description,
+			Details:     item.Details,
+		}
+
+		if item.Time > 0 {
+			listItem.Time = time.Unix(item.Time, 0)
+		}
+
+		items = append(items, listItem)
+	}
+
+	return items, nil
 }
 
-func convertExtensionContent(options ExtensionRequestOptions, content []byte, contentType ExtensionType) template.HTML {
+func convertExtensionContent(options ExtensionRequestOptions, content []byte, contentType ExtensionType) (template.HTML, error) {
 	switch contentType {
 	case ExtensionContentHTML:
 		if options.AllowHtml {
-			return template.HTML(content)
+			return template.HTML(content), nil
 		}
+	case ExtensionContentJSON:
+		// the template escapes everything it outputs, so unlike HTML this is always safe
+		if options.Template == nil {
+			return "", errors.New("extension returned JSON but the widget has no template")
+		}
+
+		if !gjson.ValidBytes(content) {
+			return "", errors.New("extension returned invalid JSON")
+		}
+
+		return ParseCustomAPIData(content, options.Template)
+	}
 
-		fallthrough
-	default:
-		return template.HTML(html.EscapeString(string(content)))
+	return template.HTML(html.EscapeString(string(content))), nil
+}
+
+// The TTL is in seconds, the same as the max-age of Cache-Control
+func parseExtensionCacheTTL(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+
+	if err != nil || seconds <= 0 {
+		return 0
 	}
+
+	return max(time.Duration(seconds)*time.Second, minExtensionCacheTTL)
 }
 
-func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
-	request, _ := http.NewRequest("GET", options.URL, nil)
+func FetchExtension(ctx context.Context, options ExtensionRequestOptions) (Extension, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", options.URL, nil)
+
+	if err != nil {
+		return Extension{}, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
 
-	query := url.Values{}
+	// parameters get added to the ones which are already part of the URL
+	query := request.URL.Query()
 
 	for key, value := range options.Parameters {
 		query.Set(key, value)
@@ -62,7 +158,7 @@ func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
 
 	request.URL.RawQuery = query.Encode()
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := defaultClient.Do(request)
 
 	if err != nil {
 		slog.Error("failed fetching extension", "error", err, "url", options.URL)
@@ -71,6 +167,10 @@ func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
 
 	defer response.Body.Close()
 
+	if response.StatusCode != http.StatusOK {
+		return Extension{}, fmt.Errorf("%w: unexpected status code %d from extension", ErrNoContent, response.StatusCode)
+	}
+
 	body, err := io.ReadAll(response.Body)
 
 	if err != nil {
@@ -78,7 +178,9 @@ func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
 		return Extension{}, fmt.Errorf("%w: could not read body: %w", ErrNoContent, err)
 	}
 
-	extension := Extension{}
+	extension := Extension{
+		CacheTTL: parseExtensionCacheTTL(response.Header.Get(ExtensionHeaderCacheTTL)),
+	}
 
 	if response.Header.Get(ExtensionHeaderTitle) == "" {
 		extension.Title = "Extension"
@@ -96,7 +198,16 @@ func FetchExtension(options ExtensionRequestOptions) (Extension, error) {
 		}
 	}
 
-	extension.Content = convertExtensionContent(options, body, contentType)
+	if contentType == ExtensionContentList {
+		extension.IsList = true
+		extension.Items, err = parseExtensionList(body)
+	} else {
+		extension.Content, err = convertExtensionContent(options, body, contentType)
+	}
+
+	if err != nil {
+		return Extension{}, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
 
 	return extension, nil
 }
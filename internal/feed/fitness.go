@@ -0,0 +1,245 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FitnessServiceStrava = "strava"
+	FitnessServiceFitbit = "fitbit"
+)
+
+const (
+	stravaEndpoint = "https://www.strava.com/api/v3"
+	fitbitEndpoint = "https://api.fitbit.com"
+)
+
+// Makes requests to Strava or Fitbit. Both only hand out short lived access tokens,
+// which get created from the refresh token. The refresh token itself can change when
+// that happens and the previous one stops working, so whoever created the client gets
+// told about the new one through OnRefreshTokenChange in order to keep it
+type FitnessClient struct {
+	Service              string
+	ClientID             string
+	ClientSecret         string
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+	Client               RequestDoer
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+type FitnessActivity struct {
+	Name string
+	Type string
+	// in meters
+	Distance float64
+	Duration time.Duration
+	Start    time.Time
+	URL      string
+}
+
+type Fitness struct {
+	// the most recent first
+	Activities   []FitnessActivity
+	WeekDistance float64
+	WeekDuration time.Duration
+	WeekCount    int
+}
+
+type stravaActivityJson struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	SportType  string    `json:"sport_type"`
+	Distance   float64   `json:"distance"`
+	MovingTime int       `json:"moving_time"`
+	StartDate  time.Time `json:"start_date"`
+}
+
+type fitbitActivitiesResponseJson struct {
+	Activities []struct {
+		ActivityName   string  `json:"activityName"`
+		Distance       float64 `json:"distance"`
+		DistanceUnit   string  `json:"distanceUnit"`
+		ActiveDuration int64   `json:"activeDuration"`
+		StartTime      string  `json:"startTime"`
+	} `json:"activities"`
+}
+
+// Must be called with the mutex held
+func (c *FitnessClient) token(ctx context.Context) (string, error) {
+	return c.oauth.refresh(ctx, clientOrDefault(c.Client), &c.RefreshToken, c.OnRefreshTokenChange, func(ctx context.Context, form url.Values) *http.Request {
+		if c.Service == FitnessServiceStrava {
+			form.Set("client_id", c.ClientID)
+			form.Set("client_secret", c.ClientSecret)
+
+			return newOAuthTokenRequest(ctx, "https://www.strava.com/oauth/token", form)
+		}
+
+		request := newOAuthTokenRequest(ctx, fitbitEndpoint+"/oauth2/token", form)
+		request.SetBasicAuth(c.ClientID, c.ClientSecret)
+
+		return request
+	})
+}
+
+func fitnessGet[T any](ctx context.Context, client RequestDoer, token, endpoint string) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	return decodeJsonFromRequest[T](client, request)
+}
+
+// Must be called with the mutex held
+func (c *FitnessClient) activities(ctx context.Context, token string, after time.Time, limit int) ([]FitnessActivity, error) {
+	if c.Service == FitnessServiceStrava {
+		query := url.Values{}
+		query.Set("per_page", strconv.Itoa(limit))
+
+		if !after.IsZero() {
+			query.Set("after", strconv.FormatInt(after.Unix(), 10))
+		}
+
+		response, err := fitnessGet[[]stravaActivityJson](ctx, clientOrDefault(c.Client), token, stravaEndpoint+"/athlete/activities?"+query.Encode())
+
+		if err != nil {
+			return nil, err
+		}
+
+		activities := make([]FitnessActivity, len(response))
+
+		for i := range response {
+			activities[i] = FitnessActivity{
+				Name:     response[i].Name,
+				Type:     stravaSportName(response[i].SportType),
+				Distance: response[i].Distance,
+				Duration: time.Duration(response[i].MovingTime) * time.Second,
+				Start:    response[i].StartDate,
+				URL:      fmt.Sprintf("https://www.strava.com/activities/%d", response[i].ID),
+			}
+		}
+
+		return activities, nil
+	}
+
+	// fitbit only lists activities either from or until a date
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", "0")
+
+	if after.IsZero() {
+		query.Set("beforeDate", time.Now().AddDate(0, 0, 1).Format(time.DateOnly))
+		query.Set("sort", "desc")
+	} else {
+		query.Set("afterDate", after.Format(time.DateOnly))
+		query.Set("sort", "asc")
+	}
+
+	response, err := fitnessGet[fitbitActivitiesResponseJson](ctx, clientOrDefault(c.Client), token, fitbitEndpoint+"/1/user/-/activities/list.json?"+query.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	activities := make([]FitnessActivity, 0, len(response.Activities))
+
+	for _, a := range response.Activities {
+		start, err := time.Parse(time.RFC3339, a.StartTime)
+
+		if err != nil {
+			continue
+		}
+
+		// distances are in kilometers unless the request asks for another unit system
+		distance := a.Distance * 1000
+
+		if a.DistanceUnit == "Mile" {
+			distance = a.Distance * 1609.344
+		}
+
+		activities = append(activities, FitnessActivity{
+			Name:     a.ActivityName,
+			Type:     a.ActivityName,
+			Distance: distance,
+			Duration: time.Duration(a.ActiveDuration) * time.Millisecond,
+			Start:    start,
+		})
+	}
+
+	return activities, nil
+}
+
+// Sport types are in pascal case, such as TrailRun
+func stravaSportName(sportType string) string {
+	var name strings.Builder
+
+	for i, r := range sportType {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			name.WriteByte(' ')
+		}
+
+		name.WriteRune(r)
+	}
+
+	return name.String()
+}
+
+// Fetches the most recent activities along with the totals for the current week
+func (c *FitnessClient) FetchSummary(ctx context.Context, limit int) (*Fitness, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	weekStart := startOfWeek(time.Now())
+	week, err := c.activities(ctx, token, weekStart, 100)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get activities: %v", ErrNoContent, err)
+	}
+
+	fitness := &Fitness{}
+
+	for i := range week {
+		// fitbit can only filter by date, which is in the timezone of the user
+		if week[i].Start.Before(weekStart) {
+			continue
+		}
+
+		fitness.WeekCount++
+		fitness.WeekDistance += week[i].Distance
+		fitness.WeekDuration += week[i].Duration
+	}
+
+	recent := week
+
+	// the week doesn't have enough activities, so older ones are needed as well
+	if len(recent) < limit {
+		recent, err = c.activities(ctx, token, time.Time{}, limit)
+
+		if err != nil {
+			return fitness, fmt.Errorf("%w: could not get recent activities: %v", ErrPartialContent, err)
+		}
+	}
+
+	slices.SortFunc(recent, func(a, b FitnessActivity) int {
+		return b.Start.Compare(a.Start)
+	})
+
+	fitness.Activities = recent[:min(limit, len(recent))]
+
+	return fitness, nil
+}
@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	aeroDataBoxHost     = "aerodatabox.p.rapidapi.com"
+	aeroDataBoxEndpoint = "https://" + aeroDataBoxHost
+)
+
+type FlightStop struct {
+	// IATA code when there is one, otherwise ICAO
+	Airport  string
+	City     string
+	Terminal string
+	Gate     string
+	// only for arrivals
+	BaggageBelt string
+	// local to the airport
+	Scheduled time.Time
+	// the actual or revised time, zero when it's not known
+	Expected time.Time
+}
+
+func (s *FlightStop) Delay() time.Duration {
+	if s.Expected.IsZero() || s.Scheduled.IsZero() {
+		return 0
+	}
+
+	return s.Expected.Sub(s.Scheduled)
+}
+
+// Expected when known, otherwise scheduled
+func (s *FlightStop) Time() time.Time {
+	if !s.Expected.IsZero() {
+		return s.Expected
+	}
+
+	return s.Scheduled
+}
+
+type Flight struct {
+	Number  string
+	Airline string
+	// as reported by AeroDataBox, e.g. Expected, Boarding, Departed, EnRoute, Arrived, Canceled
+	Status    string
+	Departure FlightStop
+	Arrival   FlightStop
+}
+
+func (f *Flight) HasLanded() bool {
+	return f.Status == "Arrived" || f.Status == "Landed"
+}
+
+// Whether nothing is going to change about the flight anymore
+func (f *Flight) IsFinal() bool {
+	return f.HasLanded() || f.Status == "Canceled" || f.Status == "Diverted"
+}
+
+// Gets the flights with the given number departing on the given date, which is
+// more than one for flights with several legs
+func FetchAeroDataBoxFlights(ctx context.Context, client RequestDoer, apiKey, number string, date time.Time) ([]Flight, error) {
+	query := url.Values{}
+	query.Set("dateLocalRole", "Departure")
+	query.Set("withAircraftImage", "false")
+	query.Set("withLocation", "false")
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		aeroDataBoxEndpoint+"/flights/number/"+url.PathEscape(strings.ReplaceAll(number, " ", ""))+"/"+date.Format(time.DateOnly)+"?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-RapidAPI-Key", apiKey)
+	request.Header.Set("X-RapidAPI-Host", aeroDataBoxHost)
+
+	body, status, err := fetchRedactedJson(clientOrDefault(client), request)
+
+	// the flight doesn't exist or isn't known yet
+	if status == http.StatusNoContent || status == http.StatusNotFound {
+		return nil, fmt.Errorf("flight %s not found on %s", number, date.Format(time.DateOnly))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := gjson.Parse(body).Array()
+	flights := make([]Flight, 0, len(items))
+
+	for _, item := range items {
+		flights = append(flights, Flight{
+			Number:    item.Get("number").String(),
+			Airline:   item.Get("airline.name").String(),
+			Status:    item.Get("status").String(),
+			Departure: aeroDataBoxStop(item.Get("departure")),
+			Arrival:   aeroDataBoxStop(item.Get("arrival")),
+		})
+	}
+
+	return flights, nil
+}
+
+func aeroDataBoxStop(stop gjson.Result) FlightStop {
+	airport := stop.Get("airport.iata").String()
+
+	if airport == "" {
+		airport = stop.Get("airport.icao").String()
+	}
+
+	city := stop.Get("airport.municipalityName").String()
+
+	if city == "" {
+		city = stop.Get("airport.shortName").String()
+	}
+
+	result := FlightStop{
+		Airport:     airport,
+		City:        city,
+		Terminal:    stop.Get("terminal").String(),
+		Gate:        stop.Get("gate").String(),
+		BaggageBelt: stop.Get("baggageBelt").String(),
+		Scheduled:   parseAeroDataBoxTime(stop.Get("scheduledTime.local").String()),
+	}
+
+	// from most to least accurate
+	for _, field := range []string{"runwayTime.local", "revisedTime.local", "predictedTime.local"} {
+		if expected := parseAeroDataBoxTime(stop.Get(field).String()); !expected.IsZero() {
+			result.Expected = expected
+			break
+		}
+	}
+
+	return result
+}
+
+// Times are e.g. 2026-10-15 13:50+02:00, which keeps them in the timezone of the airport
+func parseAeroDataBoxTime(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02 15:04Z07:00", value)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
@@ -0,0 +1,146 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FrigateEvent struct {
+	ID       string
+	Camera   string
+	Label    string
+	SubLabel string
+	// the highest confidence of the detection as a percentage
+	Score     float64
+	Zones     []string
+	StartTime time.Time
+	// zero while the event is still going on
+	EndTime     time.Time
+	HasClip     bool
+	HasSnapshot bool
+}
+
+func (e *FrigateEvent) InProgress() bool {
+	return e.EndTime.IsZero()
+}
+
+type frigateEventJson struct {
+	ID     string  `json:"id"`
+	Camera string  `json:"camera"`
+	Label  string  `json:"label"`
+	Start  float64 `json:"start_time"`
+	End    float64 `json:"end_time"`
+	// a string in older versions, a name and score pair in newer ones
+	SubLabel    json.RawMessage `json:"sub_label"`
+	TopScore    float64         `json:"top_score"`
+	Zones       []string        `json:"zones"`
+	HasClip     bool            `json:"has_clip"`
+	HasSnapshot bool            `json:"has_snapshot"`
+	// newer versions only report the score here
+	Data struct {
+		TopScore float64 `json:"top_score"`
+	} `json:"data"`
+}
+
+// Events are identified by when they started followed by a random suffix, e.g. 1718293844.6132-abc123
+var frigateEventIDPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+-[a-z0-9]+$`)
+
+func IsFrigateEventID(id string) bool {
+	return frigateEventIDPattern.MatchString(id)
+}
+
+func frigateSubLabel(raw json.RawMessage) string {
+	var name string
+
+	if json.Unmarshal(raw, &name) == nil {
+		return name
+	}
+
+	var pair []any
+
+	if json.Unmarshal(raw, &pair) == nil && len(pair) > 0 {
+		name, _ = pair[0].(string)
+	}
+
+	return name
+}
+
+func frigateTime(seconds float64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+
+	whole, fraction := math.Modf(seconds)
+
+	return time.Unix(int64(whole), int64(fraction*1e9))
+}
+
+// Lists the most recent events, optionally only the ones of some cameras or labels
+func FetchFrigateEvents(ctx context.Context, client RequestDoer, baseURL string, cameras, labels []string, limit int) ([]FrigateEvent, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("include_thumbnails", "0")
+
+	if len(cameras) > 0 {
+		query.Set("cameras", strings.Join(cameras, ","))
+	}
+
+	if len(labels) > 0 {
+		query.Set("labels", strings.Join(labels, ","))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+"/api/events?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := decodeJsonFromRequest[[]frigateEventJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]FrigateEvent, 0, len(response))
+
+	for i := range response {
+		event := &response[i]
+
+		events = append(events, FrigateEvent{
+			ID:          event.ID,
+			Camera:      event.Camera,
+			Label:       event.Label,
+			SubLabel:    frigateSubLabel(event.SubLabel),
+			Score:       max(event.TopScore, event.Data.TopScore) * 100,
+			Zones:       event.Zones,
+			StartTime:   frigateTime(event.Start),
+			EndTime:     frigateTime(event.End),
+			HasClip:     event.HasClip,
+			HasSnapshot: event.HasSnapshot,
+		})
+	}
+
+	return events, nil
+}
+
+// Fetches the small image of the object that Frigate keeps for every event
+func FetchFrigateThumbnail(ctx context.Context, client RequestDoer, baseURL, eventID string) (string, []byte, error) {
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(baseURL, "/")+"/api/events/"+url.PathEscape(eventID)+"/thumbnail.jpg",
+		nil,
+	)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fetchSnapshot(clientOrDefault(client), request)
+}
@@ -0,0 +1,437 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"time"
+)
+
+// Reads MaxMind DB files, such as GeoLite2 City or DB-IP City Lite, which map
+// IP addresses to the approximate location they're in. The format is described
+// at https://maxmind.github.io/MaxMind-DB/ and only the parts needed to look up
+// the location of an address are implemented
+type GeoIPDatabase struct {
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	// where the data section starts, after the search tree and its separator
+	dataStart uint
+	// the node where IPv4 addresses start in IPv6 databases
+	ipv4Start uint
+}
+
+var geoIPMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+var errInvalidGeoIPDatabase = errors.New("invalid GeoIP database")
+
+func OpenGeoIPDatabase(path string) (*GeoIPDatabase, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read GeoIP database: %v", err)
+	}
+
+	return parseGeoIPDatabase(data)
+}
+
+func parseGeoIPDatabase(data []byte) (*GeoIPDatabase, error) {
+	metadataStart := bytes.LastIndex(data, geoIPMetadataMarker)
+
+	if metadataStart == -1 {
+		return nil, errInvalidGeoIPDatabase
+	}
+
+	metadataStart += len(geoIPMetadataMarker)
+	decoder := geoIPDecoder{data: data[metadataStart:]}
+	value, _, err := decoder.decode(0, 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidGeoIPDatabase, err)
+	}
+
+	metadata, ok := value.(map[string]any)
+
+	if !ok {
+		return nil, errInvalidGeoIPDatabase
+	}
+
+	db := &GeoIPDatabase{data: data}
+	db.nodeCount, _ = geoIPUint(metadata["node_count"])
+	db.recordSize, _ = geoIPUint(metadata["record_size"])
+	db.ipVersion, _ = geoIPUint(metadata["ip_version"])
+
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, fmt.Errorf("%w: unsupported record size %d", errInvalidGeoIPDatabase, db.recordSize)
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4
+	db.dataStart = treeSize + 16
+
+	if db.nodeCount == 0 || db.dataStart > uint(metadataStart) {
+		return nil, errInvalidGeoIPDatabase
+	}
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+
+		db.ipv4Start = node
+	}
+
+	return db, nil
+}
+
+func (db *GeoIPDatabase) record(node uint, bit uint) uint {
+	nodeSize := db.recordSize / 4
+	b := db.data[node*nodeSize : (node+1)*nodeSize]
+
+	switch db.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// Returns nil without an error when the database doesn't have the address
+func (db *GeoIPDatabase) lookup(address netip.Addr) (map[string]any, error) {
+	address = address.Unmap()
+	node := uint(0)
+
+	if address.Is4() {
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	ip := address.AsSlice()
+
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		node = db.record(node, uint(ip[i/8]>>(7-i%8))&1)
+	}
+
+	if node <= db.nodeCount {
+		return nil, nil
+	}
+
+	decoder := geoIPDecoder{data: db.data[db.dataStart:]}
+	value, _, err := decoder.decode(node-db.nodeCount-16, 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidGeoIPDatabase, err)
+	}
+
+	record, _ := value.(map[string]any)
+
+	return record, nil
+}
+
+// Looks up the city that the address is in, returning nil when it's unknown
+// or the database doesn't have the coordinates or timezone of it
+func (db *GeoIPDatabase) LookupPlace(address netip.Addr) (*PlaceJson, error) {
+	record, err := db.lookup(address)
+
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	location, _ := record["location"].(map[string]any)
+	latitude, hasLatitude := location["latitude"].(float64)
+	longitude, hasLongitude := location["longitude"].(float64)
+	timezone, _ := location["time_zone"].(string)
+
+	if !hasLatitude || !hasLongitude || timezone == "" {
+		return nil, nil
+	}
+
+	var area string
+
+	if subdivisions, _ := record["subdivisions"].([]any); len(subdivisions) > 0 {
+		subdivision, _ := subdivisions[0].(map[string]any)
+		area = geoIPName(subdivision)
+	}
+
+	city, _ := record["city"].(map[string]any)
+	country, _ := record["country"].(map[string]any)
+
+	return NewPlace(geoIPName(city), area, geoIPName(country), latitude, longitude, timezone)
+}
+
+// Looks up the ISO code of the country that the address is in, returning an empty
+// string when it's unknown. Works with country databases as well as city ones
+func (db *GeoIPDatabase) LookupCountry(address netip.Addr) (string, error) {
+	record, err := db.lookup(address)
+
+	if err != nil || record == nil {
+		return "", err
+	}
+
+	country, _ := record["country"].(map[string]any)
+	code, _ := country["iso_code"].(string)
+
+	return code, nil
+}
+
+func geoIPName(record map[string]any) string {
+	names, _ := record["names"].(map[string]any)
+	name, _ := names["en"].(string)
+
+	return name
+}
+
+func geoIPUint(value any) (uint, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return uint(v), true
+	case int32:
+		return uint(v), v >= 0
+	}
+
+	return 0, false
+}
+
+// Creates a place for locations which didn't come from the geocoding API
+func NewPlace(name, area, country string, latitude, longitude float64, timezone string) (*PlaceJson, error) {
+	location, err := time.LoadLocation(timezone)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load location: %v", err)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("%.2f, %.2f", latitude, longitude)
+	}
+
+	return &PlaceJson{
+		Name:      name,
+		Area:      area,
+		Country:   country,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Timezone:  timezone,
+		location:  location,
+	}, nil
+}
+
+type geoIPDecoder struct {
+	data []byte
+}
+
+const (
+	geoIPTypeExtended = iota
+	geoIPTypePointer
+	geoIPTypeString
+	geoIPTypeDouble
+	geoIPTypeBytes
+	geoIPTypeUint16
+	geoIPTypeUint32
+	geoIPTypeMap
+	geoIPTypeInt32
+	geoIPTypeUint64
+	geoIPTypeUint128
+	geoIPTypeArray
+	geoIPTypeContainer
+	geoIPTypeEndMarker
+	geoIPTypeBool
+	geoIPTypeFloat
+)
+
+// Pointers can't point to pointers and nothing valid comes close to this
+const maxGeoIPDecodeDepth = 32
+
+// Decodes the value at the offset, returning the offset right after it
+func (d *geoIPDecoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxGeoIPDecodeDepth {
+		return nil, 0, errors.New("data is nested too deeply")
+	}
+
+	if offset >= uint(len(d.data)) {
+		return nil, 0, errors.New("offset is outside of the data")
+	}
+
+	control := d.data[offset]
+	offset++
+	kind := uint(control >> 5)
+
+	if kind == geoIPTypePointer {
+		pointerSize := uint(control>>3&0x3) + 1
+		b, err := d.read(offset, pointerSize)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var pointer uint
+
+		switch pointerSize {
+		case 1:
+			pointer = uint(control&0x7)<<8 | uint(b[0])
+		case 2:
+			pointer = (uint(control&0x7)<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+		case 3:
+			pointer = (uint(control&0x7)<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+		default:
+			pointer = uint(binary.BigEndian.Uint32(b))
+		}
+
+		value, _, err := d.decode(pointer, depth+1)
+
+		return value, offset + pointerSize, err
+	}
+
+	if kind == geoIPTypeExtended {
+		if offset >= uint(len(d.data)) {
+			return nil, 0, errors.New("offset is outside of the data")
+		}
+
+		kind = 7 + uint(d.data[offset])
+		offset++
+	}
+
+	size := uint(control & 0x1f)
+
+	if size >= 29 {
+		extraBytes := size - 28
+		b, err := d.read(offset, extraBytes)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		offset += extraBytes
+
+		switch extraBytes {
+		case 1:
+			size = 29 + uint(b[0])
+		case 2:
+			size = 285 + (uint(b[0])<<8 | uint(b[1]))
+		default:
+			size = 65821 + (uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]))
+		}
+	}
+
+	switch kind {
+	case geoIPTypeMap:
+		value := make(map[string]any, min(size, 64))
+
+		for range size {
+			key, next, err := d.decode(offset, depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			name, ok := key.(string)
+
+			if !ok {
+				return nil, 0, errors.New("map key is not a string")
+			}
+
+			value[name], offset, err = d.decode(next, depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		return value, offset, nil
+	case geoIPTypeArray:
+		value := make([]any, 0, min(size, 64))
+
+		for range size {
+			item, next, err := d.decode(offset, depth+1)
+
+			if err != nil {
+				return nil, 0, err
+			}
+
+			value = append(value, item)
+			offset = next
+		}
+
+		return value, offset, nil
+	case geoIPTypeBool:
+		return size != 0, offset, nil
+	case geoIPTypeEndMarker:
+		return nil, offset, nil
+	}
+
+	b, err := d.read(offset, size)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset += size
+
+	switch kind {
+	case geoIPTypeString:
+		return string(b), offset, nil
+	case geoIPTypeBytes, geoIPTypeUint128:
+		return b, offset, nil
+	case geoIPTypeDouble:
+		if size != 8 {
+			return nil, 0, errors.New("invalid double size")
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case geoIPTypeFloat:
+		if size != 4 {
+			return nil, 0, errors.New("invalid float size")
+		}
+
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+	case geoIPTypeUint16, geoIPTypeUint32, geoIPTypeUint64:
+		if size > 8 {
+			return nil, 0, errors.New("invalid unsigned integer size")
+		}
+
+		var value uint64
+
+		for _, c := range b {
+			value = value<<8 | uint64(c)
+		}
+
+		return value, offset, nil
+	case geoIPTypeInt32:
+		if size > 4 {
+			return nil, 0, errors.New("invalid integer size")
+		}
+
+		var value uint32
+
+		for _, c := range b {
+			value = value<<8 | uint32(c)
+		}
+
+		return int32(value), offset, nil
+	}
+
+	return nil, 0, fmt.Errorf("unknown data type %d", kind)
+}
+
+func (d *geoIPDecoder) read(offset, size uint) ([]byte, error) {
+	if offset+size > uint(len(d.data)) || offset+size < offset {
+		return nil, errors.New("value is outside of the data")
+	}
+
+	return d.data[offset : offset+size], nil
+}
@@ -0,0 +1,375 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type GitCommit struct {
+	Sha       string
+	Author    string
+	Message   string
+	CreatedAt time.Time
+	URL       string
+}
+
+type GitBranch struct {
+	Name string
+	// the time of the last commit on the branch
+	UpdatedAt time.Time
+	URL       string
+}
+
+type GitTicket struct {
+	Number    int
+	Title     string
+	CreatedAt time.Time
+	URL       string
+}
+
+type GitRepositoryActivity struct {
+	Name string
+	// the address of the repository on the web, empty for local repositories
+	URL              string
+	DefaultBranch    string
+	Stars            int
+	Forks            int
+	Commits          []GitCommit
+	Branches         []GitBranch
+	OpenPullRequests int
+	PullRequests     []GitTicket
+	OpenIssues       int
+	Issues           []GitTicket
+}
+
+type GitRepositoryRequest struct {
+	// the full URL of a repository on a Gitea or Forgejo instance
+	URL   string
+	Token string
+	// the directory of a local repository, which can be bare
+	Path string
+	// a limit of 0 or less skips getting those
+	CommitsLimit      int
+	BranchesLimit     int
+	PullRequestsLimit int
+	IssuesLimit       int
+	Client            RequestDoer
+}
+
+func FetchGitRepositoryActivity(ctx context.Context, request *GitRepositoryRequest) (*GitRepositoryActivity, error) {
+	if request.Path != "" {
+		return fetchLocalGitRepositoryActivity(ctx, request)
+	}
+
+	return fetchGiteaRepositoryActivity(ctx, request)
+}
+
+type giteaRepositoryResponseJson struct {
+	FullName         string `json:"full_name"`
+	HtmlUrl          string `json:"html_url"`
+	DefaultBranch    string `json:"default_branch"`
+	Stars            int    `json:"stars_count"`
+	Forks            int    `json:"forks_count"`
+	OpenIssues       int    `json:"open_issues_count"`
+	OpenPullRequests int    `json:"open_pr_counter"`
+}
+
+type giteaCommitResponseJson struct {
+	Sha     string `json:"sha"`
+	HtmlUrl string `json:"html_url"`
+	Commit  struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+type giteaBranchResponseJson struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Timestamp string `json:"timestamp"`
+	} `json:"commit"`
+}
+
+type giteaTicketResponseJson struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	CreatedAt string `json:"created_at"`
+	HtmlUrl   string `json:"html_url"`
+}
+
+// Gitea doesn't sort branches by their last commit, so more of them are
+// requested to have a better chance of including the most recent ones
+const giteaMaxBranches = 50
+
+func fetchGiteaRepositoryActivity(ctx context.Context, request *GitRepositoryRequest) (*GitRepositoryActivity, error) {
+	baseURL, repository, err := splitGiteaRepositoryURL(request.URL)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	client := clientOrDefault(request.Client)
+	apiURL := baseURL + "/api/v1/repos/" + repository
+
+	newRequest := func(path string) *http.Request {
+		httpRequest, _ := http.NewRequestWithContext(ctx, "GET", apiURL+path, nil)
+
+		if request.Token != "" {
+			httpRequest.Header.Set("Authorization", "token "+request.Token)
+		}
+
+		return httpRequest
+	}
+
+	var details giteaRepositoryResponseJson
+	var detailsErr error
+	var commits []giteaCommitResponseJson
+	var commitsErr error
+	var branches []giteaBranchResponseJson
+	var branchesErr error
+	var pullRequests []giteaTicketResponseJson
+	var pullRequestsErr error
+	var issues []giteaTicketResponseJson
+	var issuesErr error
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		details, detailsErr = decodeJsonFromRequest[giteaRepositoryResponseJson](client, newRequest(""))
+	}()
+
+	if request.CommitsLimit > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			commits, commitsErr = decodeJsonFromRequest[[]giteaCommitResponseJson](
+				client,
+				newRequest(fmt.Sprintf("/commits?limit=%d&stat=false&verification=false&files=false", request.CommitsLimit)),
+			)
+		}()
+	}
+
+	if request.BranchesLimit > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			branches, branchesErr = decodeJsonFromRequest[[]giteaBranchResponseJson](
+				client,
+				newRequest(fmt.Sprintf("/branches?limit=%d", max(request.BranchesLimit, giteaMaxBranches))),
+			)
+		}()
+	}
+
+	if request.PullRequestsLimit > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pullRequests, pullRequestsErr = decodeJsonFromRequest[[]giteaTicketResponseJson](
+				client,
+				newRequest(fmt.Sprintf("/pulls?state=open&sort=recentupdate&limit=%d", request.PullRequestsLimit)),
+			)
+		}()
+	}
+
+	if request.IssuesLimit > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			issues, issuesErr = decodeJsonFromRequest[[]giteaTicketResponseJson](
+				client,
+				newRequest(fmt.Sprintf("/issues?state=open&type=issues&limit=%d", request.IssuesLimit)),
+			)
+		}()
+	}
+
+	wg.Wait()
+
+	if detailsErr != nil {
+		return nil, fmt.Errorf("%w: could not get repository details: %v", ErrNoContent, detailsErr)
+	}
+
+	activity := &GitRepositoryActivity{
+		Name:             details.FullName,
+		URL:              details.HtmlUrl,
+		DefaultBranch:    details.DefaultBranch,
+		Stars:            details.Stars,
+		Forks:            details.Forks,
+		OpenPullRequests: details.OpenPullRequests,
+		OpenIssues:       details.OpenIssues,
+	}
+
+	var failed []string
+
+	if commitsErr != nil {
+		failed = append(failed, "commits")
+	} else {
+		for i := range commits {
+			activity.Commits = append(activity.Commits, GitCommit{
+				Sha:       commits[i].Sha,
+				Author:    commits[i].Commit.Author.Name,
+				Message:   strings.SplitN(commits[i].Commit.Message, "\n", 2)[0],
+				CreatedAt: parseRFC3339Time(commits[i].Commit.Author.Date),
+				URL:       commits[i].HtmlUrl,
+			})
+		}
+	}
+
+	if branchesErr != nil {
+		failed = append(failed, "branches")
+	} else {
+		for i := range branches {
+			activity.Branches = append(activity.Branches, GitBranch{
+				Name:      branches[i].Name,
+				UpdatedAt: parseRFC3339Time(branches[i].Commit.Timestamp),
+				URL:       details.HtmlUrl + "/src/branch/" + branches[i].Name,
+			})
+		}
+
+		activity.Branches = latestGitBranches(activity.Branches, request.BranchesLimit)
+	}
+
+	if pullRequestsErr != nil {
+		failed = append(failed, "pull requests")
+	} else {
+		activity.PullRequests = giteaTickets(pullRequests)
+	}
+
+	if issuesErr != nil {
+		failed = append(failed, "issues")
+	} else {
+		activity.Issues = giteaTickets(issues)
+	}
+
+	if len(failed) > 0 {
+		return activity, fmt.Errorf(
+			"%w: could not get %s: %v",
+			ErrPartialContent,
+			strings.Join(failed, ", "),
+			errors.Join(commitsErr, branchesErr, pullRequestsErr, issuesErr),
+		)
+	}
+
+	return activity, nil
+}
+
+func giteaTickets(response []giteaTicketResponseJson) []GitTicket {
+	tickets := make([]GitTicket, 0, len(response))
+
+	for i := range response {
+		tickets = append(tickets, GitTicket{
+			Number:    response[i].Number,
+			Title:     response[i].Title,
+			CreatedAt: parseRFC3339Time(response[i].CreatedAt),
+			URL:       response[i].HtmlUrl,
+		})
+	}
+
+	return tickets
+}
+
+func latestGitBranches(branches []GitBranch, limit int) []GitBranch {
+	slices.SortFunc(branches, func(a, b GitBranch) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+
+	if len(branches) > limit {
+		branches = branches[:limit]
+	}
+
+	return branches
+}
+
+// Runs git in the repository, which has to be on the machine Glance is running on.
+// Local repositories don't have issues or pull requests
+func fetchLocalGitRepositoryActivity(ctx context.Context, request *GitRepositoryRequest) (*GitRepositoryActivity, error) {
+	activity := &GitRepositoryActivity{
+		Name: strings.TrimSuffix(filepath.Base(filepath.Clean(request.Path)), ".git"),
+	}
+
+	// fails when HEAD is detached, in which case there's no default branch to show
+	if defaultBranch, err := runGit(ctx, request.Path, "symbolic-ref", "--short", "HEAD"); err == nil {
+		activity.DefaultBranch = strings.TrimSpace(defaultBranch)
+	}
+
+	if request.CommitsLimit > 0 {
+		output, err := runGit(
+			ctx, request.Path,
+			"log", "-n", strconv.Itoa(request.CommitsLimit), "--format=%H%x1f%an%x1f%aI%x1f%s", "HEAD", "--",
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			fields := strings.Split(line, "\x1f")
+
+			if len(fields) != 4 {
+				continue
+			}
+
+			activity.Commits = append(activity.Commits, GitCommit{
+				Sha:       fields[0],
+				Author:    fields[1],
+				CreatedAt: parseRFC3339Time(fields[2]),
+				Message:   fields[3],
+			})
+		}
+	}
+
+	if request.BranchesLimit > 0 {
+		output, err := runGit(
+			ctx, request.Path,
+			"for-each-ref", "--sort=-committerdate", "--count="+strconv.Itoa(request.BranchesLimit),
+			"--format=%(refname:short)%1f%(committerdate:iso-strict)", "refs/heads",
+		)
+
+		if err != nil {
+			return activity, fmt.Errorf("%w: could not get branches: %v", ErrPartialContent, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			name, date, found := strings.Cut(line, "\x1f")
+
+			if !found {
+				continue
+			}
+
+			activity.Branches = append(activity.Branches, GitBranch{
+				Name:      name,
+				UpdatedAt: parseRFC3339Time(date),
+			})
+		}
+	}
+
+	return activity, nil
+}
+
+func runGit(ctx context.Context, path string, arguments ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", append([]string{"-C", path}, arguments...)...).Output()
+
+	if err != nil {
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", arguments[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return "", fmt.Errorf("git %s: %v", arguments[0], err)
+	}
+
+	return string(output), nil
+}
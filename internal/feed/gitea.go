@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type giteaReleaseResponseJson struct {
+	TagName     string `json:"tag_name"`
+	PublishedAt string `json:"published_at"`
+	HtmlUrl     string `json:"html_url"`
+}
+
+func fetchLatestCodebergRelease(ctx context.Context, request *ReleaseRequest) (*AppRelease, error) {
+	return fetchLatestGiteaCompatibleRelease(ctx, ReleaseSourceCodeberg, "https://codeberg.org", request.Repository, request.Token)
+}
+
+// The repository of self-hosted instances is the full URL of the repository, such as
+// https://git.example.com/owner/repo, which also works for Forgejo since it has the same API
+func fetchLatestGiteaRelease(ctx context.Context, request *ReleaseRequest) (*AppRelease, error) {
+	baseURL, repository, err := splitGiteaRepositoryURL(request.Repository)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchLatestGiteaCompatibleRelease(ctx, ReleaseSourceGitea, baseURL, repository, request.Token)
+}
+
+// Instances can be hosted under a path, so the owner and the name
+// of the repository are the last two parts of the URL
+func splitGiteaRepositoryURL(repositoryURL string) (string, string, error) {
+	trimmed := strings.TrimSuffix(repositoryURL, "/")
+	schemeEnd := strings.Index(trimmed, "://")
+
+	if schemeEnd == -1 {
+		return "", "", fmt.Errorf("gitea repository must be a full URL such as https://git.example.com/owner/repo, got %s", repositoryURL)
+	}
+
+	parts := strings.Split(trimmed[schemeEnd+3:], "/")
+
+	if len(parts) < 3 || parts[len(parts)-1] == "" || parts[len(parts)-2] == "" {
+		return "", "", fmt.Errorf("gitea repository must be a full URL such as https://git.example.com/owner/repo, got %s", repositoryURL)
+	}
+
+	baseURL := trimmed[:schemeEnd+3] + strings.Join(parts[:len(parts)-2], "/")
+
+	return baseURL, strings.Join(parts[len(parts)-2:], "/"), nil
+}
+
+func fetchLatestGiteaCompatibleRelease(ctx context.Context, source ReleaseSource, baseURL string, repository string, token *string) (*AppRelease, error) {
+	httpRequest, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf("%s/api/v1/repos/%s/releases/latest", baseURL, repository),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if token != nil {
+		httpRequest.Header.Add("Authorization", "token "+(*token))
+	}
+
+	response, err := decodeJsonFromRequest[giteaReleaseResponseJson](defaultClient, httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppRelease{
+		Source:       source,
+		Name:         repository,
+		Version:      normalizeVersionFormat(response.TagName),
+		NotesUrl:     response.HtmlUrl,
+		TimeReleased: parseRFC3339Time(response.PublishedAt),
+	}, nil
+}
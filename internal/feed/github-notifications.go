@@ -0,0 +1,322 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons used for the items that come from searching for
+// assigned issues and pull requests and for requested reviews,
+// they match the ones used by the notifications API
+const (
+	GithubReasonAssign          = "assign"
+	GithubReasonReviewRequested = "review_requested"
+)
+
+var githubReasonLabels = map[string]string{
+	"approval_requested":        "approval requested",
+	GithubReasonAssign:          "assigned",
+	"author":                    "author",
+	"ci_activity":               "CI activity",
+	"comment":                   "comment",
+	"invitation":                "invitation",
+	"manual":                    "subscribed",
+	"member_feature_requested":  "feature requested",
+	"mention":                   "mentioned",
+	GithubReasonReviewRequested: "review requested",
+	"security_alert":            "security alert",
+	"security_advisory_credit":  "advisory credit",
+	"state_change":              "state change",
+	"subscribed":                "watching",
+	"team_mention":              "team mentioned",
+}
+
+type GithubNotification struct {
+	Title     string
+	Url       string
+	Type      string
+	Reason    string
+	UpdatedAt time.Time
+}
+
+func (n GithubNotification) ReasonLabel() string {
+	if label, exists := githubReasonLabels[n.Reason]; exists {
+		return label
+	}
+
+	return strings.ReplaceAll(n.Reason, "_", " ")
+}
+
+var githubTypeLabels = map[string]string{
+	"CheckSuite":                   "workflow",
+	"Commit":                       "commit",
+	"Discussion":                   "discussion",
+	"Issue":                        "issue",
+	"PullRequest":                  "pull request",
+	"Release":                      "release",
+	"RepositoryVulnerabilityAlert": "vulnerability",
+}
+
+func (n GithubNotification) TypeLabel() string {
+	if label, exists := githubTypeLabels[n.Type]; exists {
+		return label
+	}
+
+	return strings.ToLower(n.Type)
+}
+
+// Notifications about things like releases all point to the repository
+func (n GithubNotification) key() string {
+	return n.Url + "\n" + n.Title
+}
+
+type GithubNotificationGroup struct {
+	Repository    string
+	Notifications []GithubNotification
+}
+
+type githubNotificationResponseJson struct {
+	Reason    string `json:"reason"`
+	UpdatedAt string `json:"updated_at"`
+	Subject   struct {
+		Title string `json:"title"`
+		Url   string `json:"url"`
+		Type  string `json:"type"`
+	} `json:"subject"`
+	Repository struct {
+		FullName string `json:"full_name"`
+		HtmlUrl  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+type githubSearchIssuesResponseJson struct {
+	Items []struct {
+		Title         string    `json:"title"`
+		HtmlUrl       string    `json:"html_url"`
+		UpdatedAt     string    `json:"updated_at"`
+		RepositoryUrl string    `json:"repository_url"`
+		PullRequest   *struct{} `json:"pull_request"`
+	} `json:"items"`
+}
+
+// The subject of notifications points to the API, this turns it into the
+// URL of the page, falling back to the repository for things like releases
+// where the API URL can't be mapped to a page
+func githubSubjectHtmlUrl(apiUrl string, repositoryUrl string) string {
+	path, found := strings.CutPrefix(apiUrl, "https://api.github.com/repos/")
+
+	if !found {
+		return repositoryUrl
+	}
+
+	parts := strings.Split(path, "/")
+
+	if len(parts) != 4 {
+		return repositoryUrl
+	}
+
+	switch parts[2] {
+	case "pulls":
+		parts[2] = "pull"
+	case "issues", "discussions":
+	default:
+		return repositoryUrl
+	}
+
+	return "https://github.com/" + strings.Join(parts, "/")
+}
+
+func newGithubRequest(ctx context.Context, token string, path string) *http.Request {
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://api.github.com"+path, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/vnd.github+json")
+
+	return request
+}
+
+func githubSearchQuery(query string, limit int) string {
+	return fmt.Sprintf("/search/issues?q=%s&sort=updated&per_page=%d", url.QueryEscape(query), limit)
+}
+
+// Fetches the unread notifications of the user that the token belongs to along with the
+// open issues and pull requests assigned to them and the pull requests awaiting their
+// review. If reasons is not empty, only the items with one of those reasons are returned
+func FetchGithubNotifications(ctx context.Context, token string, reasons []string, limit int) ([]GithubNotificationGroup, error) {
+	wants := func(reason string) bool {
+		return len(reasons) == 0 || slices.Contains(reasons, reason)
+	}
+
+	var notificationsResponse []githubNotificationResponseJson
+	var notificationsErr error
+	var assignedResponse githubSearchIssuesResponseJson
+	var assignedErr error
+	var reviewsResponse githubSearchIssuesResponseJson
+	var reviewsErr error
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go (func() {
+		defer wg.Done()
+		notificationsResponse, notificationsErr = decodeJsonFromRequest[[]githubNotificationResponseJson](
+			defaultClient,
+			newGithubRequest(ctx, token, fmt.Sprintf("/notifications?per_page=%d", min(limit, 50))),
+		)
+	})()
+
+	if wants(GithubReasonAssign) {
+		wg.Add(1)
+		go (func() {
+			defer wg.Done()
+			assignedResponse, assignedErr = decodeJsonFromRequest[githubSearchIssuesResponseJson](
+				defaultClient,
+				newGithubRequest(ctx, token, githubSearchQuery("is:open archived:false assignee:@me", limit)),
+			)
+		})()
+	}
+
+	if wants(GithubReasonReviewRequested) {
+		wg.Add(1)
+		go (func() {
+			defer wg.Done()
+			reviewsResponse, reviewsErr = decodeJsonFromRequest[githubSearchIssuesResponseJson](
+				defaultClient,
+				newGithubRequest(ctx, token, githubSearchQuery("is:open is:pr archived:false review-requested:@me", limit)),
+			)
+		})()
+	}
+
+	wg.Wait()
+
+	var errs []error
+
+	if notificationsErr != nil {
+		errs = append(errs, fmt.Errorf("could not get notifications: %v", notificationsErr))
+	}
+
+	if assignedErr != nil {
+		errs = append(errs, fmt.Errorf("could not get assigned issues and pull requests: %v", assignedErr))
+	}
+
+	if reviewsErr != nil {
+		errs = append(errs, fmt.Errorf("could not get review requests: %v", reviewsErr))
+	}
+
+	if notificationsErr != nil && (assignedErr != nil || !wants(GithubReasonAssign)) && (reviewsErr != nil || !wants(GithubReasonReviewRequested)) {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, errors.Join(errs...))
+	}
+
+	// the same pull request can show up both as a notification and as a review
+	// request, in which case the notification is kept since it's more specific
+	seen := make(map[string]struct{})
+	groups := make(map[string]*GithubNotificationGroup)
+	notifications := make([]GithubNotification, 0, limit)
+
+	add := func(repository string, notification GithubNotification) {
+		if _, exists := seen[notification.key()]; exists {
+			return
+		}
+
+		seen[notification.key()] = struct{}{}
+
+		if _, exists := groups[repository]; !exists {
+			groups[repository] = &GithubNotificationGroup{Repository: repository}
+		}
+
+		groups[repository].Notifications = append(groups[repository].Notifications, notification)
+		notifications = append(notifications, notification)
+	}
+
+	for i := range notificationsResponse {
+		n := &notificationsResponse[i]
+
+		if !wants(n.Reason) {
+			continue
+		}
+
+		add(n.Repository.FullName, GithubNotification{
+			Title:     n.Subject.Title,
+			Url:       githubSubjectHtmlUrl(n.Subject.Url, n.Repository.HtmlUrl),
+			Type:      n.Subject.Type,
+			Reason:    n.Reason,
+			UpdatedAt: parseRFC3339Time(n.UpdatedAt),
+		})
+	}
+
+	for _, search := range []struct {
+		response *githubSearchIssuesResponseJson
+		reason   string
+	}{
+		{&reviewsResponse, GithubReasonReviewRequested},
+		{&assignedResponse, GithubReasonAssign},
+	} {
+		for i := range search.response.Items {
+			item := &search.response.Items[i]
+			itemType := "Issue"
+
+			if item.PullRequest != nil {
+				itemType = "PullRequest"
+			}
+
+			add(strings.TrimPrefix(item.RepositoryUrl, "https://api.github.com/repos/"), GithubNotification{
+				Title:     item.Title,
+				Url:       item.HtmlUrl,
+				Type:      itemType,
+				Reason:    search.reason,
+				UpdatedAt: parseRFC3339Time(item.UpdatedAt),
+			})
+		}
+	}
+
+	// limit the total amount of items to the most recently updated ones
+	// before grouping so that busy repositories don't push out the rest
+	if len(notifications) > limit {
+		sort.Slice(notifications, func(i, j int) bool {
+			return notifications[i].UpdatedAt.After(notifications[j].UpdatedAt)
+		})
+
+		kept := make(map[string]struct{}, limit)
+
+		for _, notification := range notifications[:limit] {
+			kept[notification.key()] = struct{}{}
+		}
+
+		for _, group := range groups {
+			group.Notifications = slices.DeleteFunc(group.Notifications, func(n GithubNotification) bool {
+				_, exists := kept[n.key()]
+				return !exists
+			})
+		}
+	}
+
+	result := make([]GithubNotificationGroup, 0, len(groups))
+
+	for _, group := range groups {
+		if len(group.Notifications) == 0 {
+			continue
+		}
+
+		sort.Slice(group.Notifications, func(i, j int) bool {
+			return group.Notifications[i].UpdatedAt.After(group.Notifications[j].UpdatedAt)
+		})
+
+		result = append(result, *group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Notifications[0].UpdatedAt.After(result[j].Notifications[0].UpdatedAt)
+	})
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%w: %v", ErrPartialContent, errors.Join(errs...))
+	}
+
+	return result, nil
+}
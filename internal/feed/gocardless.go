@@ -0,0 +1,313 @@
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const goCardlessEndpoint = "https://bankaccountdata.gocardless.com/api/v2"
+
+// Banks only have to allow 4 requests per day for each account and endpoint
+// and transactions from further back than this aren't shown anyway
+const goCardlessTransactionsDays = 30
+
+// Makes requests to GoCardless Bank Account Data, previously Nordigen. The access
+// token gets created from the secrets and refreshed once it expires, and the details
+// of the accounts are kept since they count towards the same limits as the balances
+type GoCardlessClient struct {
+	SecretID  string
+	SecretKey string
+	Client    RequestDoer
+
+	mu             sync.Mutex
+	oauth          oauthRefresher
+	refresh        string
+	refreshExpires time.Time
+	details        map[string]*goCardlessAccountDetailsJson
+}
+
+type goCardlessTokenResponseJson struct {
+	Access         string `json:"access"`
+	AccessExpires  int    `json:"access_expires"`
+	Refresh        string `json:"refresh"`
+	RefreshExpires int    `json:"refresh_expires"`
+}
+
+type goCardlessRequisitionResponseJson struct {
+	Status   string   `json:"status"`
+	Accounts []string `json:"accounts"`
+}
+
+type goCardlessAccountDetailsJson struct {
+	IBAN      string `json:"iban"`
+	Name      string `json:"name"`
+	OwnerName string `json:"ownerName"`
+	Product   string `json:"product"`
+	Currency  string `json:"currency"`
+}
+
+type goCardlessAmountJson struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type goCardlessBalancesResponseJson struct {
+	Balances []struct {
+		BalanceAmount goCardlessAmountJson `json:"balanceAmount"`
+		BalanceType   string               `json:"balanceType"`
+	} `json:"balances"`
+}
+
+type goCardlessTransactionJson struct {
+	BookingDate                       string               `json:"bookingDate"`
+	ValueDate                         string               `json:"valueDate"`
+	TransactionAmount                 goCardlessAmountJson `json:"transactionAmount"`
+	CreditorName                      string               `json:"creditorName"`
+	DebtorName                        string               `json:"debtorName"`
+	RemittanceInformationUnstructured string               `json:"remittanceInformationUnstructured"`
+}
+
+type goCardlessTransactionsResponseJson struct {
+	Transactions struct {
+		Booked  []goCardlessTransactionJson `json:"booked"`
+		Pending []goCardlessTransactionJson `json:"pending"`
+	} `json:"transactions"`
+}
+
+type BankAccount struct {
+	Name         string
+	IBAN         string
+	Balance      float64
+	Currency     string
+	Transactions []BankTransaction
+}
+
+// Only the last four digits, which is enough to tell accounts apart
+func (a *BankAccount) MaskedIBAN() string {
+	if len(a.IBAN) <= 4 {
+		return a.IBAN
+	}
+
+	return "•••• " + a.IBAN[len(a.IBAN)-4:]
+}
+
+type BankTransaction struct {
+	Date        time.Time
+	Description string
+	Amount      float64
+	Currency    string
+	Pending     bool
+}
+
+// Must be called with the mutex held. The access token is created from the secrets, after
+// which the refresh token that comes with it is used until it expires as well
+func (c *GoCardlessClient) token(ctx context.Context) (string, error) {
+	return c.oauth.token(ctx, func(ctx context.Context) (oauthToken, error) {
+		now := time.Now()
+		var request *http.Request
+
+		if c.refresh != "" && now.Add(oauthTokenExpiryMargin).Before(c.refreshExpires) {
+			body, _ := json.Marshal(map[string]string{"refresh": c.refresh})
+			request, _ = http.NewRequestWithContext(ctx, "POST", goCardlessEndpoint+"/token/refresh/", bytes.NewReader(body))
+		} else {
+			body, _ := json.Marshal(map[string]string{"secret_id": c.SecretID, "secret_key": c.SecretKey})
+			request, _ = http.NewRequestWithContext(ctx, "POST", goCardlessEndpoint+"/token/new/", bytes.NewReader(body))
+		}
+
+		request.Header.Set("Content-Type", "application/json")
+		response, err := decodeJsonFromRequest[goCardlessTokenResponseJson](clientOrDefault(c.Client), request)
+
+		if err != nil {
+			c.refresh = ""
+			return oauthToken{}, err
+		}
+
+		// refreshing only returns a new access token
+		if response.Refresh != "" {
+			c.refresh = response.Refresh
+			c.refreshExpires = now.Add(time.Duration(response.RefreshExpires) * time.Second)
+		}
+
+		return oauthToken{
+			Access:  response.Access,
+			Expires: now.Add(time.Duration(response.AccessExpires) * time.Second),
+		}, nil
+	})
+}
+
+func goCardlessGet[T any](ctx context.Context, client RequestDoer, token string, path string) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", goCardlessEndpoint+path, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Accept", "application/json")
+
+	return decodeJsonFromRequest[T](client, request)
+}
+
+// Fetches the accounts that the requisition, which is created when linking a bank,
+// gives access to, along with their recent transactions unless the limit is 0
+func (c *GoCardlessClient) FetchBankAccounts(ctx context.Context, requisitionID string, transactionsLimit int) ([]BankAccount, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	requisition, err := goCardlessGet[goCardlessRequisitionResponseJson](ctx, clientOrDefault(c.Client), token, "/requisitions/"+url.PathEscape(requisitionID)+"/")
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get requisition: %v", ErrNoContent, err)
+	}
+
+	// linked is the only status in which the accounts can be accessed
+	if requisition.Status != "LN" {
+		return nil, fmt.Errorf("%w: requisition is not linked, its status is %s", ErrNoContent, requisition.Status)
+	}
+
+	if c.details == nil {
+		c.details = make(map[string]*goCardlessAccountDetailsJson)
+	}
+
+	accounts := make([]BankAccount, 0, len(requisition.Accounts))
+	var failed int
+
+	for _, id := range requisition.Accounts {
+		account, err := c.fetchAccount(ctx, token, id, transactionsLimit)
+
+		if err != nil {
+			failed++
+			slog.Error("Failed to fetch bank account", "account", id, "error", err)
+			continue
+		}
+
+		accounts = append(accounts, *account)
+	}
+
+	if len(accounts) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return accounts, fmt.Errorf("%w: could not fetch %d account(s)", ErrPartialContent, failed)
+	}
+
+	return accounts, nil
+}
+
+// Must be called with the mutex held
+func (c *GoCardlessClient) fetchAccount(ctx context.Context, token string, id string, transactionsLimit int) (*BankAccount, error) {
+	path := "/accounts/" + url.PathEscape(id)
+	details, exists := c.details[id]
+
+	if !exists {
+		response, err := goCardlessGet[struct {
+			Account goCardlessAccountDetailsJson `json:"account"`
+		}](ctx, clientOrDefault(c.Client), token, path+"/details/")
+
+		if err != nil {
+			return nil, fmt.Errorf("could not get details: %v", err)
+		}
+
+		details = &response.Account
+		c.details[id] = details
+	}
+
+	balances, err := goCardlessGet[goCardlessBalancesResponseJson](ctx, clientOrDefault(c.Client), token, path+"/balances/")
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get balances: %v", err)
+	}
+
+	if len(balances.Balances) == 0 {
+		return nil, errors.New("account has no balances")
+	}
+
+	// banks report different kinds of balances, the available one is closest to what their apps show
+	preference := []string{"interimAvailable", "expected", "closingBooked", "interimBooked"}
+	balance := balances.Balances[0].BalanceAmount
+	bestRank := len(preference)
+
+	for _, b := range balances.Balances {
+		if rank := slices.Index(preference, b.BalanceType); rank != -1 && rank < bestRank {
+			bestRank = rank
+			balance = b.BalanceAmount
+		}
+	}
+
+	amount, err := strconv.ParseFloat(balance.Amount, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance %q", balance.Amount)
+	}
+
+	account := &BankAccount{
+		Name:     cmp.Or(details.Name, details.Product, details.OwnerName),
+		IBAN:     details.IBAN,
+		Balance:  amount,
+		Currency: CurrencySymbol(cmp.Or(balance.Currency, details.Currency)),
+	}
+
+	if transactionsLimit <= 0 {
+		return account, nil
+	}
+
+	from := time.Now().AddDate(0, 0, -goCardlessTransactionsDays).Format(time.DateOnly)
+	transactions, err := goCardlessGet[goCardlessTransactionsResponseJson](ctx, clientOrDefault(c.Client), token, path+"/transactions/?date_from="+from)
+
+	// the balance is still worth showing without the transactions
+	if err != nil {
+		slog.Warn("Failed to fetch bank transactions", "account", id, "error", err)
+		return account, nil
+	}
+
+	for _, t := range transactions.Transactions.Pending {
+		account.Transactions = append(account.Transactions, bankTransactionFromJson(&t, true))
+	}
+
+	for _, t := range transactions.Transactions.Booked {
+		account.Transactions = append(account.Transactions, bankTransactionFromJson(&t, false))
+	}
+
+	slices.SortStableFunc(account.Transactions, func(a, b BankTransaction) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	if len(account.Transactions) > transactionsLimit {
+		account.Transactions = account.Transactions[:transactionsLimit]
+	}
+
+	return account, nil
+}
+
+func bankTransactionFromJson(t *goCardlessTransactionJson, pending bool) BankTransaction {
+	amount, _ := strconv.ParseFloat(t.TransactionAmount.Amount, 64)
+	date, _ := time.Parse(time.DateOnly, cmp.Or(t.BookingDate, t.ValueDate))
+
+	// outgoing payments are described by who they went to and incoming ones by who they came from
+	description := t.CreditorName
+
+	if amount > 0 || description == "" {
+		description = cmp.Or(t.DebtorName, t.CreditorName)
+	}
+
+	return BankTransaction{
+		Date:        date,
+		Description: cmp.Or(description, t.RemittanceInformationUnstructured),
+		Amount:      amount,
+		Currency:    CurrencySymbol(t.TransactionAmount.Currency),
+		Pending:     pending,
+	}
+}
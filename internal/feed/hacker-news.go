@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -18,9 +19,9 @@ type hackerNewsPostResponseJson struct {
 	TimePosted   int64  `json:"time"`
 }
 
-func getHackerNewsPostIds(sort string) ([]int, error) {
-	request, _ := http.NewRequest("GET", fmt.Sprintf("https://hacker-news.firebaseio.com/v0/%sstories.json", sort), nil)
-	response, err := decodeJsonFromRequest[[]int](defaultClient, request)
+func getHackerNewsPostIds(ctx context.Context, client RequestDoer, sort string) ([]int, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://hacker-news.firebaseio.com/v0/%sstories.json", sort), nil)
+	response, err := decodeJsonFromRequest[[]int](client, request)
 
 	if err != nil {
 		return nil, fmt.Errorf("%w: could not fetch list of post IDs", ErrNoContent)
@@ -29,16 +30,16 @@ func getHackerNewsPostIds(sort string) ([]int, error) {
 	return response, nil
 }
 
-func getHackerNewsPostsFromIds(postIds []int, commentsUrlTemplate string) (ForumPosts, error) {
+func getHackerNewsPostsFromIds(ctx context.Context, client RequestDoer, postIds []int, commentsUrlTemplate string) (ForumPosts, error) {
 	requests := make([]*http.Request, len(postIds))
 
 	for i, id := range postIds {
-		request, _ := http.NewRequest("GET", fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id), nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id), nil)
 		requests[i] = request
 	}
 
-	task := decodeJsonFromRequestTask[hackerNewsPostResponseJson](defaultClient)
-	job := newJob(task, requests).withWorkers(30)
+	task := decodeJsonFromRequestTask[hackerNewsPostResponseJson](client)
+	job := newJob(task, requests).withWorkers(30).withContext(ctx)
 	results, errs, err := workerPoolDo(job)
 
 	if err != nil {
@@ -83,8 +84,16 @@ func getHackerNewsPostsFromIds(postIds []int, commentsUrlTemplate string) (Forum
 	return posts, nil
 }
 
-func FetchHackerNewsPosts(sort string, limit int, commentsUrlTemplate string) (ForumPosts, error) {
-	postIds, err := getHackerNewsPostIds(sort)
+var HackerNewsListings = []string{"top", "new", "best", "show", "ask"}
+
+type HackerNewsSource struct {
+	Listing             string
+	CommentsUrlTemplate string
+	Client              RequestDoer
+}
+
+func (s *HackerNewsSource) FetchPosts(ctx context.Context, limit int) (ForumPosts, error) {
+	postIds, err := getHackerNewsPostIds(ctx, clientOrDefault(s.Client), s.Listing)
 
 	if err != nil {
 		return nil, err
@@ -94,5 +103,5 @@ func FetchHackerNewsPosts(sort string, limit int, commentsUrlTemplate string) (F
 		postIds = postIds[:limit]
 	}
 
-	return getHackerNewsPostsFromIds(postIds, commentsUrlTemplate)
+	return getHackerNewsPostsFromIds(ctx, clientOrDefault(s.Client), postIds, s.CommentsUrlTemplate)
 }
@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	HealthcheckStatusUp      = "up"
+	HealthcheckStatusDown    = "down"
+	HealthcheckStatusGrace   = "grace"
+	HealthcheckStatusStarted = "started"
+	HealthcheckStatusPaused  = "paused"
+	HealthcheckStatusNew     = "new"
+)
+
+type Healthcheck struct {
+	Name   string
+	Status string
+	// zero when the check hasn't been pinged yet
+	LastPing time.Time
+	// when the next ping is expected, zero for checks which are new or paused
+	NextPing time.Time
+	// the page of the check, only known with an API key that isn't read-only
+	URL string
+}
+
+func (c *Healthcheck) IsFailing() bool {
+	return c.Status == HealthcheckStatusDown || c.Status == HealthcheckStatusGrace
+}
+
+type healthchecksResponseJson struct {
+	Checks []struct {
+		Name     string `json:"name"`
+		Slug     string `json:"slug"`
+		Status   string `json:"status"`
+		LastPing string `json:"last_ping"`
+		NextPing string `json:"next_ping"`
+		// only included for keys which aren't read-only
+		UpdateURL string `json:"update_url"`
+	} `json:"checks"`
+}
+
+// Checks which are down come first, followed by the late ones, the ones
+// which are running and then the rest, sorted by name
+var healthcheckStatusOrder = []string{
+	HealthcheckStatusDown,
+	HealthcheckStatusGrace,
+	HealthcheckStatusStarted,
+	HealthcheckStatusUp,
+	HealthcheckStatusNew,
+	HealthcheckStatusPaused,
+}
+
+func FetchHealthchecks(ctx context.Context, client RequestDoer, baseURL, apiKey string, tags []string) ([]Healthcheck, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	query := url.Values{}
+
+	// checks have to have all of the tags
+	for _, tag := range tags {
+		query.Add("tag", tag)
+	}
+
+	requestURL := baseURL + "/api/v3/checks/"
+
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request.Header.Set("X-Api-Key", apiKey)
+
+	response, err := decodeJsonFromRequest[healthchecksResponseJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	checks := make([]Healthcheck, 0, len(response.Checks))
+
+	for i := range response.Checks {
+		check := &response.Checks[i]
+		healthcheck := Healthcheck{
+			Name:   check.Name,
+			Status: check.Status,
+		}
+
+		if healthcheck.Name == "" {
+			healthcheck.Name = check.Slug
+		}
+
+		if check.LastPing != "" {
+			healthcheck.LastPing, _ = time.Parse(time.RFC3339, check.LastPing)
+		}
+
+		if check.NextPing != "" {
+			healthcheck.NextPing, _ = time.Parse(time.RFC3339, check.NextPing)
+		}
+
+		// the ID of the check is only in the address used to update it through the API
+		if check.UpdateURL != "" {
+			healthcheck.URL = baseURL + "/checks/" + path.Base(strings.TrimRight(check.UpdateURL, "/")) + "/details/"
+		}
+
+		checks = append(checks, healthcheck)
+	}
+
+	slices.SortStableFunc(checks, func(a, b Healthcheck) int {
+		if order := healthcheckStatusRank(a.Status) - healthcheckStatusRank(b.Status); order != 0 {
+			return order
+		}
+
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return checks, nil
+}
+
+func healthcheckStatusRank(status string) int {
+	if index := slices.Index(healthcheckStatusOrder, status); index != -1 {
+		return index
+	}
+
+	return len(healthcheckStatusOrder)
+}
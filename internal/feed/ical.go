@@ -0,0 +1,712 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CalendarEvent struct {
+	Title    string
+	Location string
+	URL      string
+	Calendar string
+	StartsAt time.Time
+	EndsAt   time.Time
+	IsAllDay bool
+	// only set when the request includes cancelled events
+	IsCancelled bool
+}
+
+type CalendarEvents []CalendarEvent
+
+func (e CalendarEvents) SortByStart() CalendarEvents {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].StartsAt.Equal(e[j].StartsAt) {
+			return e[i].IsAllDay && !e[j].IsAllDay
+		}
+
+		return e[i].StartsAt.Before(e[j].StartsAt)
+	})
+
+	return e
+}
+
+type CalendarEventsRequest struct {
+	URL      string
+	Name     string
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Client   RequestDoer
+	// cancelled events are left out unless this is set
+	IncludeCancelled bool
+}
+
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type icalEvent struct {
+	uid          string
+	summary      string
+	location     string
+	url          string
+	status       string
+	start        time.Time
+	end          time.Time
+	duration     time.Duration
+	isAllDay     bool
+	rrule        string
+	exdates      []time.Time
+	recurrenceID time.Time
+}
+
+// Lines longer than 75 octets get folded by inserting a CRLF
+// followed by a single whitespace character
+func unfoldIcalLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lines := make([]string, 0, 256)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseIcalProperty(line string) (icalProperty, bool) {
+	// the value may itself contain colons (such as in URLs)
+	// but parameter values containing them must be quoted
+	inQuotes := false
+	separator := -1
+
+	for i := 0; i < len(line); i++ {
+		if line[i] == '"' {
+			inQuotes = !inQuotes
+		} else if line[i] == ':' && !inQuotes {
+			separator = i
+			break
+		}
+	}
+
+	if separator == -1 {
+		return icalProperty{}, false
+	}
+
+	parts := strings.Split(line[:separator], ";")
+	property := icalProperty{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[separator+1:],
+	}
+
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(param, "=")
+
+		if found {
+			property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return property, true
+}
+
+var icalTextReplacer = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func parseIcalTime(property icalProperty, defaultLocation *time.Location) (time.Time, bool, error) {
+	value := property.value
+
+	if property.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, defaultLocation)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	location := defaultLocation
+
+	if tzid := property.params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	return t, false, err
+}
+
+// Only supports the subset of ISO 8601 durations that are used in practice, e.g. P1D, PT1H30M, P1W
+func parseIcalDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	var duration time.Duration
+	var number int
+	inTime := false
+
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int(c-'0')
+		case c == 'T':
+			inTime = true
+		case c == 'W':
+			duration += time.Duration(number) * 7 * 24 * time.Hour
+			number = 0
+		case c == 'D':
+			duration += time.Duration(number) * 24 * time.Hour
+			number = 0
+		case c == 'H' && inTime:
+			duration += time.Duration(number) * time.Hour
+			number = 0
+		case c == 'M' && inTime:
+			duration += time.Duration(number) * time.Minute
+			number = 0
+		case c == 'S' && inTime:
+			duration += time.Duration(number) * time.Second
+			number = 0
+		default:
+			return 0, fmt.Errorf("invalid duration: %s", value)
+		}
+	}
+
+	return duration, nil
+}
+
+func parseIcalEvents(r io.Reader, defaultLocation *time.Location) ([]icalEvent, error) {
+	lines, err := unfoldIcalLines(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]icalEvent, 0, 64)
+	var current *icalEvent
+	calendarLocation := defaultLocation
+
+	for _, line := range lines {
+		property, ok := parseIcalProperty(line)
+
+		if !ok {
+			continue
+		}
+
+		if current == nil {
+			if property.name == "X-WR-TIMEZONE" {
+				if loaded, err := time.LoadLocation(property.value); err == nil {
+					calendarLocation = loaded
+				}
+			} else if property.name == "BEGIN" && property.value == "VEVENT" {
+				current = &icalEvent{}
+			}
+
+			continue
+		}
+
+		switch property.name {
+		case "END":
+			if property.value == "VEVENT" {
+				if !current.start.IsZero() {
+					events = append(events, *current)
+				}
+
+				current = nil
+			}
+		case "UID":
+			current.uid = property.value
+		case "SUMMARY":
+			current.summary = icalTextReplacer.Replace(property.value)
+		case "LOCATION":
+			current.location = icalTextReplacer.Replace(property.value)
+		case "URL":
+			current.url = property.value
+		case "STATUS":
+			current.status = strings.ToUpper(property.value)
+		case "RRULE":
+			current.rrule = property.value
+		case "DTSTART":
+			current.start, current.isAllDay, err = parseIcalTime(property, calendarLocation)
+		case "DTEND":
+			current.end, _, err = parseIcalTime(property, calendarLocation)
+		case "DURATION":
+			current.duration, err = parseIcalDuration(property.value)
+		case "RECURRENCE-ID":
+			current.recurrenceID, _, err = parseIcalTime(property, calendarLocation)
+		case "EXDATE":
+			for _, value := range strings.Split(property.value, ",") {
+				property.value = value
+				exdate, _, exdateErr := parseIcalTime(property, calendarLocation)
+
+				if exdateErr == nil {
+					current.exdates = append(current.exdates, exdate)
+				}
+			}
+		}
+
+		if err != nil {
+			slog.Debug("Skipping invalid calendar property", "property", property.name, "error", err)
+			err = nil
+		}
+	}
+
+	return events, nil
+}
+
+func (e *icalEvent) length() time.Duration {
+	if !e.end.IsZero() && e.end.After(e.start) {
+		return e.end.Sub(e.start)
+	}
+
+	if e.duration > 0 {
+		return e.duration
+	}
+
+	if e.isAllDay {
+		return 24 * time.Hour
+	}
+
+	return 0
+}
+
+func (e *icalEvent) toCalendarEvent(startsAt time.Time, calendar string) CalendarEvent {
+	return CalendarEvent{
+		Title:       e.summary,
+		Location:    e.location,
+		URL:         e.url,
+		Calendar:    calendar,
+		StartsAt:    startsAt,
+		EndsAt:      startsAt.Add(e.length()),
+		IsAllDay:    e.isAllDay,
+		IsCancelled: e.status == "CANCELLED",
+	}
+}
+
+// Expands the events, including recurring ones, into the
+// individual occurrences which overlap with the given range
+func expandIcalEvents(events []icalEvent, from, to time.Time, calendar string, includeCancelled bool) (CalendarEvents, error) {
+	expanded := make(CalendarEvents, 0, len(events))
+	// occurrences of recurring events that have been modified are
+	// specified as separate events with a RECURRENCE-ID
+	overridden := make(map[string][]time.Time)
+
+	for i := range events {
+		if !events[i].recurrenceID.IsZero() {
+			overridden[events[i].uid] = append(overridden[events[i].uid], events[i].recurrenceID)
+		}
+	}
+
+	overlaps := func(start time.Time, length time.Duration) bool {
+		return start.Before(to) && (start.Add(length).After(from) || (length == 0 && !start.Before(from)))
+	}
+
+	for i := range events {
+		event := &events[i]
+
+		if event.status == "CANCELLED" && !includeCancelled {
+			continue
+		}
+
+		length := event.length()
+
+		if event.rrule == "" || !event.recurrenceID.IsZero() {
+			if overlaps(event.start, length) {
+				expanded = append(expanded, event.toCalendarEvent(event.start, calendar))
+			}
+
+			continue
+		}
+
+		rule, err := parseRecurrenceRule(event.rrule, event.start.Location())
+
+		if err != nil {
+			slog.Debug("Skipping event with unsupported recurrence rule", "rule", event.rrule, "error", err)
+			continue
+		}
+
+		occurrences := rule.occurrencesBetween(event.start, from.Add(-length), to)
+
+		for _, occurrence := range occurrences {
+			isExcluded := slices.ContainsFunc(event.exdates, occurrence.Equal) ||
+				slices.ContainsFunc(overridden[event.uid], occurrence.Equal)
+
+			if isExcluded || !overlaps(occurrence, length) {
+				continue
+			}
+
+			expanded = append(expanded, event.toCalendarEvent(occurrence, calendar))
+		}
+	}
+
+	return expanded, nil
+}
+
+type recurrenceByDay struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+type recurrenceRule struct {
+	frequency  string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []recurrenceByDay
+	byMonthDay []int
+	byMonth    []time.Month
+}
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func parseRecurrenceRule(value string, location *time.Location) (*recurrenceRule, error) {
+	rule := &recurrenceRule{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		key, value, found := strings.Cut(part, "=")
+
+		if !found {
+			continue
+		}
+
+		var err error
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.frequency = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(value)
+		case "COUNT":
+			rule.count, err = strconv.Atoi(value)
+		case "UNTIL":
+			var isDate bool
+			rule.until, isDate, err = parseIcalTime(icalProperty{value: value, params: map[string]string{}}, location)
+
+			// the UNTIL date is inclusive
+			if isDate {
+				rule.until = rule.until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY value: %s", day)
+				}
+
+				weekday, ok := icalWeekdays[strings.ToUpper(day[len(day)-2:])]
+
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY value: %s", day)
+				}
+
+				byDay := recurrenceByDay{weekday: weekday}
+
+				if len(day) > 2 {
+					byDay.ordinal, err = strconv.Atoi(day[:len(day)-2])
+				}
+
+				rule.byDay = append(rule.byDay, byDay)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, parseErr := strconv.Atoi(day)
+
+				if parseErr != nil {
+					err = parseErr
+					break
+				}
+
+				rule.byMonthDay = append(rule.byMonthDay, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(value, ",") {
+				monthNumber, parseErr := strconv.Atoi(month)
+
+				if parseErr != nil {
+					err = parseErr
+					break
+				}
+
+				rule.byMonth = append(rule.byMonth, time.Month(monthNumber))
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+
+	if rule.interval < 1 {
+		rule.interval = 1
+	}
+
+	switch rule.frequency {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported frequency: %s", rule.frequency)
+	}
+
+	return rule, nil
+}
+
+// Returns the days of the given month matching the BYDAY and BYMONTHDAY parts of the rule
+func (r *recurrenceRule) daysOfMonth(year int, month time.Month, defaultDay int) []int {
+	totalDays := daysInMonth(month, year)
+	days := make([]int, 0, 5)
+
+	if len(r.byMonthDay) > 0 {
+		for _, day := range r.byMonthDay {
+			if day < 0 {
+				day = totalDays + day + 1
+			}
+
+			if day >= 1 && day <= totalDays {
+				days = append(days, day)
+			}
+		}
+	} else if len(r.byDay) > 0 {
+		firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+
+		for _, byDay := range r.byDay {
+			matching := make([]int, 0, 5)
+
+			for day := 1 + (int(byDay.weekday)-int(firstWeekday)+7)%7; day <= totalDays; day += 7 {
+				matching = append(matching, day)
+			}
+
+			if byDay.ordinal == 0 {
+				days = append(days, matching...)
+			} else if byDay.ordinal > 0 && byDay.ordinal <= len(matching) {
+				days = append(days, matching[byDay.ordinal-1])
+			} else if byDay.ordinal < 0 && -byDay.ordinal <= len(matching) {
+				days = append(days, matching[len(matching)+byDay.ordinal])
+			}
+		}
+	} else if defaultDay <= totalDays {
+		days = append(days, defaultDay)
+	}
+
+	slices.Sort(days)
+
+	return slices.Compact(days)
+}
+
+func (r *recurrenceRule) matchesDay(t time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, t.Month()) {
+		return false
+	}
+
+	if len(r.byDay) > 0 && !slices.ContainsFunc(r.byDay, func(d recurrenceByDay) bool { return d.weekday == t.Weekday() }) {
+		return false
+	}
+
+	return true
+}
+
+const maxRecurrenceIterations = 10_000
+
+var errRecurrenceDone = errors.New("done")
+
+// Generates the occurrences of the rule in order, starting with the start time of the event, and
+// returns the ones between from and to. Occurrences before from still have to be generated since
+// they count towards the COUNT limit.
+func (r *recurrenceRule) occurrencesBetween(start, from, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0, 16)
+	generated := 0
+	location := start.Location()
+	hour, minute, second := start.Clock()
+
+	emit := func(t time.Time) error {
+		if t.Before(start) {
+			return nil
+		}
+
+		if !r.until.IsZero() && t.After(r.until) || !t.Before(to) {
+			return errRecurrenceDone
+		}
+
+		generated++
+
+		if r.count > 0 && generated > r.count {
+			return errRecurrenceDone
+		}
+
+		if !t.Before(from) {
+			occurrences = append(occurrences, t)
+		}
+
+		return nil
+	}
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, location)
+	}
+
+	for i := 0; i < maxRecurrenceIterations; i++ {
+		var err error
+
+		switch r.frequency {
+		case "DAILY":
+			t := at(start.Year(), start.Month(), start.Day()+i*r.interval)
+
+			if r.matchesDay(t) {
+				err = emit(t)
+			} else if !t.Before(to) {
+				err = errRecurrenceDone
+			}
+		case "WEEKLY":
+			weekStart := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7+i*7*r.interval)
+			weekdays := make([]int, 0, 7)
+
+			if len(r.byDay) == 0 {
+				weekdays = append(weekdays, (int(start.Weekday())+6)%7)
+			} else {
+				for _, byDay := range r.byDay {
+					weekdays = append(weekdays, (int(byDay.weekday)+6)%7)
+				}
+
+				slices.Sort(weekdays)
+			}
+
+			if !weekStart.Before(to) {
+				err = errRecurrenceDone
+			}
+
+			for _, offset := range weekdays {
+				if err != nil {
+					break
+				}
+
+				err = emit(at(weekStart.Year(), weekStart.Month(), weekStart.Day()+offset))
+			}
+		case "MONTHLY", "YEARLY":
+			var months []time.Time
+
+			if r.frequency == "MONTHLY" {
+				month := time.Date(start.Year(), start.Month()+time.Month(i*r.interval), 1, 0, 0, 0, 0, location)
+
+				if len(r.byMonth) == 0 || slices.Contains(r.byMonth, month.Month()) {
+					months = append(months, month)
+				}
+			} else {
+				byMonth := r.byMonth
+
+				if len(byMonth) == 0 {
+					byMonth = []time.Month{start.Month()}
+				}
+
+				for _, month := range byMonth {
+					months = append(months, time.Date(start.Year()+i*r.interval, month, 1, 0, 0, 0, 0, location))
+				}
+			}
+
+			if len(months) > 0 && !months[0].Before(to) {
+				err = errRecurrenceDone
+			}
+
+			for _, month := range months {
+				for _, day := range r.daysOfMonth(month.Year(), month.Month(), start.Day()) {
+					if err != nil {
+						break
+					}
+
+					err = emit(at(month.Year(), month.Month(), day))
+				}
+			}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	return occurrences
+}
+
+func fetchCalendarEventsTask(ctx context.Context, request *CalendarEventsRequest) (CalendarEvents, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", request.URL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := clientOrDefault(request.Client).Do(httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, request.URL)
+	}
+
+	events, err := parseIcalEvents(response.Body, request.Location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return expandIcalEvents(events, request.From, request.To, request.Name, request.IncludeCancelled)
+}
+
+func FetchCalendarEvents(ctx context.Context, requests []*CalendarEventsRequest) (CalendarEvents, error) {
+	job := newJob(taskWithContext(ctx, fetchCalendarEventsTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	events := make(CalendarEvents, 0, len(requests)*10)
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch calendar", "url", requests[i].URL, "error", errs[i])
+			continue
+		}
+
+		events = append(events, results[i]...)
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	events.SortByStart()
+
+	if failed > 0 {
+		return events, fmt.Errorf("%w: could not fetch %d calendar(s)", ErrPartialContent, failed)
+	}
+
+	return events, nil
+}
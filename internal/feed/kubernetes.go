@@ -0,0 +1,269 @@
+package feed
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kubernetesServiceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+	kubernetesAnnotationPrefix   = "glance/"
+	// discovery runs every few minutes, there's no point in keeping connections open until then
+	kubernetesIdleConnTimeout = 30 * time.Second
+)
+
+type KubernetesDiscoveryRequest struct {
+	APIURL        string
+	Token         string
+	Namespace     string
+	AllowInsecure bool
+	HTTPRoutes    bool
+	// kept between discoveries so that connections get reused and the CA only gets read once
+	clientMu sync.Mutex
+	client   *http.Client
+	apiURL   string
+}
+
+type kubernetesMetadataJson struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type kubernetesIngressListJson struct {
+	Items []struct {
+		Metadata kubernetesMetadataJson `json:"metadata"`
+		Spec     struct {
+			TLS []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"tls"`
+			Rules []struct {
+				Host string `json:"host"`
+				HTTP struct {
+					Paths []struct {
+						Path string `json:"path"`
+					} `json:"paths"`
+				} `json:"http"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+type kubernetesHTTPRouteListJson struct {
+	Items []struct {
+		Metadata kubernetesMetadataJson `json:"metadata"`
+		Spec     struct {
+			Hostnames []string `json:"hostnames"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// When no API URL is specified, the in-cluster service account
+// is used, the same way client-go does it
+func (r *KubernetesDiscoveryRequest) clientAndURL() (*http.Client, string, error) {
+	r.clientMu.Lock()
+	defer r.clientMu.Unlock()
+
+	if r.client != nil {
+		return r.client, r.apiURL, nil
+	}
+
+	apiURL := strings.TrimRight(r.APIURL, "/")
+	tlsConfig := &tls.Config{InsecureSkipVerify: r.AllowInsecure}
+
+	if apiURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+
+		if host == "" || port == "" {
+			return nil, "", errors.New("not running inside of a cluster and no api-url specified")
+		}
+
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		apiURL = "https://" + host + ":" + port
+
+		if !r.AllowInsecure {
+			caCert, err := os.ReadFile(kubernetesServiceAccountPath + "/ca.crt")
+
+			if err != nil {
+				return nil, "", fmt.Errorf("could not read service account CA: %v", err)
+			}
+
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(caCert)
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	r.client = &http.Client{
+		Timeout:   defaultClientTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, IdleConnTimeout: kubernetesIdleConnTimeout},
+	}
+	r.apiURL = apiURL
+
+	return r.client, r.apiURL, nil
+}
+
+// The service account token gets rotated by the kubelet, so it's read again every time
+func (r *KubernetesDiscoveryRequest) token() string {
+	if r.Token != "" {
+		return r.Token
+	}
+
+	contents, err := os.ReadFile(kubernetesServiceAccountPath + "/token")
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(contents))
+}
+
+func kubernetesListPath(group, resource, namespace string) string {
+	if namespace == "" {
+		return "/apis/" + group + "/" + resource
+	}
+
+	return "/apis/" + group + "/namespaces/" + namespace + "/" + resource
+}
+
+func newKubernetesRequest(ctx context.Context, apiURL, token, path string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", apiURL+path, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return request, nil
+}
+
+func serviceFromKubernetesAnnotations(metadata *kubernetesMetadataJson, defaultURL string) (DiscoveredService, bool) {
+	annotations := metadata.Annotations
+
+	if annotations[kubernetesAnnotationPrefix+"enabled"] != "true" {
+		return DiscoveredService{}, false
+	}
+
+	service := DiscoveredService{
+		Name:        annotations[kubernetesAnnotationPrefix+"name"],
+		URL:         annotations[kubernetesAnnotationPrefix+"url"],
+		CheckURL:    annotations[kubernetesAnnotationPrefix+"check-url"],
+		Icon:        annotations[kubernetesAnnotationPrefix+"icon"],
+		Group:       annotations[kubernetesAnnotationPrefix+"group"],
+		Description: annotations[kubernetesAnnotationPrefix+"description"],
+	}
+
+	if service.Name == "" {
+		service.Name = metadata.Name
+	}
+
+	if service.URL == "" {
+		service.URL = defaultURL
+	}
+
+	if service.URL == "" {
+		return DiscoveredService{}, false
+	}
+
+	return service, true
+}
+
+// Lists Ingresses and optionally Gateway API HTTPRoutes which have the
+// glance/enabled: "true" annotation, the URL is inferred from the first
+// host unless a glance/url annotation is present
+func DiscoverServicesFromKubernetes(ctx context.Context, request *KubernetesDiscoveryRequest) (DiscoveredServices, error) {
+	client, apiURL, err := request.clientAndURL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	token := request.token()
+
+	httpRequest, err := newKubernetesRequest(ctx, apiURL, token, kubernetesListPath("networking.k8s.io/v1", "ingresses", request.Namespace))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ingresses, err := decodeJsonFromRequest[kubernetesIngressListJson](client, httpRequest)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not list ingresses: %w", err)
+	}
+
+	services := make(DiscoveredServices, 0, len(ingresses.Items))
+
+	for i := range ingresses.Items {
+		ingress := &ingresses.Items[i]
+		var defaultURL string
+
+		if len(ingress.Spec.Rules) > 0 && ingress.Spec.Rules[0].Host != "" {
+			rule := &ingress.Spec.Rules[0]
+			scheme := "http"
+
+			for _, t := range ingress.Spec.TLS {
+				for _, host := range t.Hosts {
+					if host == rule.Host {
+						scheme = "https"
+					}
+				}
+			}
+
+			defaultURL = scheme + "://" + rule.Host
+
+			if len(rule.HTTP.Paths) > 0 && rule.HTTP.Paths[0].Path != "/" {
+				defaultURL += rule.HTTP.Paths[0].Path
+			}
+		}
+
+		if service, ok := serviceFromKubernetesAnnotations(&ingress.Metadata, defaultURL); ok {
+			services = append(services, service)
+		}
+	}
+
+	if request.HTTPRoutes {
+		httpRequest, err := newKubernetesRequest(ctx, apiURL, token, kubernetesListPath("gateway.networking.k8s.io/v1", "httproutes", request.Namespace))
+
+		if err != nil {
+			return nil, err
+		}
+
+		routes, err := decodeJsonFromRequest[kubernetesHTTPRouteListJson](client, httpRequest)
+
+		if err != nil {
+			slog.Error("Could not list HTTPRoutes", "error", err)
+			return services.SortByName(), fmt.Errorf("%w: could not list HTTPRoutes", ErrPartialContent)
+		}
+
+		for i := range routes.Items {
+			route := &routes.Items[i]
+			var defaultURL string
+
+			if len(route.Spec.Hostnames) > 0 {
+				defaultURL = "https://" + route.Spec.Hostnames[0]
+			}
+
+			if service, ok := serviceFromKubernetesAnnotations(&route.Metadata, defaultURL); ok {
+				services = append(services, service)
+			}
+		}
+	}
+
+	return services.SortByName(), nil
+}
@@ -0,0 +1,234 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How many probes are sent to each target, the latency is the average of the ones which got a reply
+const latencyProbes = 3
+const latencyTimeout = 10 * time.Second
+
+// The most targets a remote instance measures in one request, since each of them runs ping
+const MaxLatencyTargets = 30
+
+type LatencyResult struct {
+	Target string `json:"target"`
+	// zero when none of the probes got a reply
+	Latency time.Duration `json:"latency"`
+	// the percentage of probes which didn't get a reply
+	Loss float64 `json:"loss"`
+	// zero when traceroute wasn't run or failed
+	Hops  int    `json:"hops"`
+	Error string `json:"error,omitempty"`
+}
+
+type LatencyRequest struct {
+	// empty for measuring from the machine Glance is running on
+	URL           string
+	Token         string
+	AllowInsecure bool
+	Targets       []string
+	Traceroute    bool
+}
+
+// Only host names, addresses and ports, which also keeps targets
+// that start with a dash from being passed to ping as options
+var latencyTargetPattern = regexp.MustCompile(`^[A-Za-z0-9_.:\[\]][A-Za-z0-9_.:\[\]-]*$`)
+
+func ValidateLatencyTarget(target string) error {
+	if !latencyTargetPattern.MatchString(target) {
+		return fmt.Errorf("invalid target %q", target)
+	}
+
+	return nil
+}
+
+// Targets with a port are measured by how long it takes to open a TCP connection to
+// them, which works without any privileges. Others are pinged through the ping command
+func MeasureLatency(ctx context.Context, target string, traceroute bool) LatencyResult {
+	ctx, cancel := context.WithTimeout(ctx, latencyTimeout)
+	defer cancel()
+
+	result := LatencyResult{Target: target}
+	host := target
+	var err error
+
+	if h, _, splitErr := net.SplitHostPort(target); splitErr == nil {
+		host = h
+		result.Latency, result.Loss, err = measureTCPLatency(ctx, target)
+	} else {
+		result.Latency, result.Loss, err = measurePingLatency(ctx, target)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.Loss = 100
+		return result
+	}
+
+	if traceroute {
+		if hops, err := countTracerouteHops(ctx, host); err == nil {
+			result.Hops = hops
+		} else {
+			slog.Debug("Failed to run traceroute", "target", host, "error", err)
+		}
+	}
+
+	return result
+}
+
+func measureTCPLatency(ctx context.Context, address string) (time.Duration, float64, error) {
+	dialer := net.Dialer{Timeout: latencyTimeout / latencyProbes}
+	var total time.Duration
+	var replies int
+	var lastErr error
+
+	for range latencyProbes {
+		start := time.Now()
+		connection, err := dialer.DialContext(ctx, "tcp", address)
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		total += time.Since(start)
+		replies++
+		connection.Close()
+	}
+
+	if replies == 0 {
+		return 0, 100, lastErr
+	}
+
+	return total / time.Duration(replies), float64(latencyProbes-replies) / latencyProbes * 100, nil
+}
+
+var (
+	pingLossPattern    = regexp.MustCompile(`([\d.]+)% packet loss`)
+	pingAveragePattern = regexp.MustCompile(`= [\d.]+/([\d.]+)/`)
+)
+
+// Parses the summary that the ping of iputils, BusyBox and BSDs print at the end, such as
+// "3 packets transmitted, 3 received, 0% packet loss" followed by "rtt min/avg/max/mdev = ..."
+func parsePingOutput(output string) (time.Duration, float64, error) {
+	lossMatch := pingLossPattern.FindStringSubmatch(output)
+
+	if lossMatch == nil {
+		return 0, 0, errors.New("could not parse the output of ping")
+	}
+
+	loss, _ := strconv.ParseFloat(lossMatch[1], 64)
+	averageMatch := pingAveragePattern.FindStringSubmatch(output)
+
+	if averageMatch == nil {
+		return 0, loss, nil
+	}
+
+	average, _ := strconv.ParseFloat(averageMatch[1], 64)
+
+	return time.Duration(average * float64(time.Millisecond)), loss, nil
+}
+
+func measurePingLatency(ctx context.Context, host string) (time.Duration, float64, error) {
+	// ping exits with an error when there were no replies, which still prints a summary
+	output, err := exec.CommandContext(ctx, "ping", "-c", strconv.Itoa(latencyProbes), host).CombinedOutput()
+	latency, loss, parseErr := parsePingOutput(string(output))
+
+	if parseErr != nil {
+		if err != nil {
+			return 0, 0, fmt.Errorf("ping: %v: %s", err, truncateString(strings.TrimSpace(string(output)), 128))
+		}
+
+		return 0, 0, parseErr
+	}
+
+	if loss >= 100 {
+		return 0, 100, errors.New("no replies")
+	}
+
+	return latency, loss, nil
+}
+
+var tracerouteHopPattern = regexp.MustCompile(`(?m)^\s*(\d+)\s`)
+
+// The number of the last hop that traceroute printed, which is the one of the
+// target itself when it was reached
+func countTracerouteHops(ctx context.Context, host string) (int, error) {
+	output, err := exec.CommandContext(ctx, "traceroute", "-n", "-q", "1", "-w", "1", "-m", "30", host).Output()
+
+	if err != nil {
+		return 0, fmt.Errorf("traceroute: %v", err)
+	}
+
+	matches := tracerouteHopPattern.FindAllStringSubmatch(string(output), -1)
+
+	if len(matches) == 0 {
+		return 0, errors.New("no hops in the output of traceroute")
+	}
+
+	return strconv.Atoi(matches[len(matches)-1][1])
+}
+
+func MeasureLatencies(ctx context.Context, targets []string, traceroute bool) []LatencyResult {
+	job := newJob(func(target string) (LatencyResult, error) {
+		return MeasureLatency(ctx, target, traceroute), nil
+	}, targets).withWorkers(10).withContext(ctx)
+
+	results, _, _ := workerPoolDo(job)
+
+	return results
+}
+
+func fetchLatenciesTask(ctx context.Context, request *LatencyRequest) ([]LatencyResult, error) {
+	if request.URL == "" {
+		return MeasureLatencies(ctx, request.Targets, request.Traceroute), nil
+	}
+
+	query := url.Values{"target": request.Targets}
+
+	if request.Traceroute {
+		query.Set("traceroute", "true")
+	}
+
+	return fetchFromRemoteInstance[[]LatencyResult](ctx, request.URL, "/api/latency?"+query.Encode(), request.Token, request.AllowInsecure)
+}
+
+func FetchLatenciesForSources(ctx context.Context, requests []*LatencyRequest) ([][]LatencyResult, error) {
+	job := newJob(taskWithContext(ctx, fetchLatenciesTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to measure latencies", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not measure latencies from %d source(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -18,14 +19,14 @@ type lobstersPostResponseJson struct {
 
 type lobstersFeedResponseJson []lobstersPostResponseJson
 
-func getLobstersPostsFromFeed(feedUrl string) (ForumPosts, error) {
-	request, err := http.NewRequest("GET", feedUrl, nil)
+func getLobstersPostsFromFeed(ctx context.Context, client RequestDoer, feedUrl string) (ForumPosts, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", feedUrl, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	feed, err := decodeJsonFromRequest[lobstersFeedResponseJson](defaultClient, request)
+	feed, err := decodeJsonFromRequest[lobstersFeedResponseJson](client, request)
 
 	if err != nil {
 		return nil, err
@@ -55,12 +56,23 @@ func getLobstersPostsFromFeed(feedUrl string) (ForumPosts, error) {
 	return posts, nil
 }
 
-func FetchLobstersPosts(customURL string, instanceURL string, sortBy string, tags []string) (ForumPosts, error) {
+type LobstersSource struct {
+	CustomURL   string
+	InstanceURL string
+	SortBy      string
+	Tags        []string
+	Client      RequestDoer
+}
+
+func (s *LobstersSource) FetchPosts(ctx context.Context, limit int) (ForumPosts, error) {
 	var feedUrl string
 
-	if customURL != "" {
-		feedUrl = customURL
+	if s.CustomURL != "" {
+		feedUrl = s.CustomURL
 	} else {
+		instanceURL := s.InstanceURL
+		sortBy := s.SortBy
+
 		if instanceURL != "" {
 			instanceURL = strings.TrimRight(instanceURL, "/") + "/"
 		} else {
@@ -73,15 +85,15 @@ func FetchLobstersPosts(customURL string, instanceURL string, sortBy string, tag
 			sortBy = "newest"
 		}
 
-		if len(tags) == 0 {
+		if len(s.Tags) == 0 {
 			feedUrl = instanceURL + sortBy + ".json"
 		} else {
-			tags := strings.Join(tags, ",")
+			tags := strings.Join(s.Tags, ",")
 			feedUrl = instanceURL + "t/" + tags + ".json"
 		}
 	}
 
-	posts, err := getLobstersPostsFromFeed(feedUrl)
+	posts, err := getLobstersPostsFromFeed(ctx, clientOrDefault(s.Client), feedUrl)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,226 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	LogCountProviderLoki          = "loki"
+	LogCountProviderElasticsearch = "elasticsearch"
+)
+
+type LogCountRequest struct {
+	Provider string
+	URL      string
+	Username string
+	Password string
+	// the API key of Elasticsearch, used instead of the username and password
+	APIKey string
+	// the tenant of multi-tenant Loki instances
+	Tenant string
+	// a LogQL log query for Loki or a query string query for Elasticsearch
+	Query string
+	// the indices that Elasticsearch searches, such as logs-*
+	Index          string
+	TimestampField string
+	// how long each count is over, the last one is the one that gets shown
+	Period time.Duration
+	// how far back the counts go, which has to be a multiple of the period
+	ChartPeriod time.Duration
+	Client      RequestDoer
+}
+
+type LogCount struct {
+	// how many lines matched in the last period
+	Count int
+	// the count of each period over the chart period, from the oldest to the newest
+	Counts []int
+}
+
+func FetchLogCounts(ctx context.Context, requests []*LogCountRequest) ([]*LogCount, error) {
+	task := func(ctx context.Context, request *LogCountRequest) (*LogCount, error) {
+		var counts []int
+		var err error
+
+		if request.Provider == LogCountProviderElasticsearch {
+			counts, err = fetchElasticsearchCounts(ctx, request)
+		} else {
+			counts, err = fetchLokiCounts(ctx, request)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogCount{Count: counts[len(counts)-1], Counts: counts}, nil
+	}
+
+	job := newJob(taskWithContext(ctx, task), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to count log lines", "query", requests[i].Query, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not count lines of %d query(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
+
+func logCountBuckets(request *LogCountRequest) int {
+	return int(request.ChartPeriod / request.Period)
+}
+
+// Counts the lines through a metric query, which is evaluated at the end of
+// each period and counts the lines over the period before it
+func fetchLokiCounts(ctx context.Context, request *LogCountRequest) ([]int, error) {
+	seconds := int64(request.Period / time.Second)
+	buckets := logCountBuckets(request)
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-time.Duration(buckets-1) * request.Period)
+
+	query := url.Values{}
+	query.Set("query", fmt.Sprintf("sum(count_over_time(%s [%ds]))", request.Query, seconds))
+	query.Set("start", strconv.FormatInt(start.Unix(), 10))
+	query.Set("end", strconv.FormatInt(end.Unix(), 10))
+	query.Set("step", strconv.FormatInt(seconds, 10))
+
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(request.URL, "/")+"/loki/api/v1/query_range?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	if request.Tenant != "" {
+		httpRequest.Header.Set("X-Scope-OrgID", request.Tenant)
+	}
+
+	body, _, err := fetchRedactedJson(clientOrDefault(request.Client), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, buckets)
+
+	// periods without any lines are left out rather than being zero
+	for _, value := range gjson.Get(body, "data.result.0.values").Array() {
+		index := int((value.Get("0").Int() - start.Unix()) / seconds)
+
+		if index >= 0 && index < buckets {
+			count, _ := strconv.ParseFloat(value.Get("1").String(), 64)
+			counts[index] = int(count)
+		}
+	}
+
+	return counts, nil
+}
+
+// Counts the documents through a date histogram whose buckets are
+// shifted so that the last one ends now rather than on the hour
+func fetchElasticsearchCounts(ctx context.Context, request *LogCountRequest) ([]int, error) {
+	interval := request.Period.Milliseconds()
+	buckets := logCountBuckets(request)
+	end := time.Now().UnixMilli()
+	start := end - int64(buckets)*interval
+
+	body, err := json.Marshal(map[string]any{
+		"size":             0,
+		"track_total_hits": false,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []any{
+					map[string]any{"query_string": map[string]any{"query": request.Query}},
+					map[string]any{"range": map[string]any{
+						request.TimestampField: map[string]any{"gte": start, "lt": end, "format": "epoch_millis"},
+					}},
+				},
+			},
+		},
+		"aggs": map[string]any{
+			"counts": map[string]any{
+				"date_histogram": map[string]any{
+					"field":           request.TimestampField,
+					"fixed_interval":  fmt.Sprintf("%dms", interval),
+					"offset":          fmt.Sprintf("%dms", end%interval),
+					"min_doc_count":   0,
+					"extended_bounds": map[string]any{"min": start, "max": end - 1},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, "POST",
+		strings.TrimRight(request.URL, "/")+"/"+url.PathEscape(request.Index)+"/_search",
+		bytes.NewReader(body),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	if request.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "ApiKey "+request.APIKey)
+	} else if request.Username != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	response, _, err := fetchRedactedJson(clientOrDefault(request.Client), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int, buckets)
+
+	for _, bucket := range gjson.Get(response, "aggregations.counts.buckets").Array() {
+		index := int((bucket.Get("key").Int() - start) / interval)
+
+		if index >= 0 && index < buckets {
+			counts[index] = int(bucket.Get("doc_count").Int())
+		}
+	}
+
+	return counts, nil
+}
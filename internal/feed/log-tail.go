@@ -0,0 +1,313 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	LogLevelError   = "error"
+	LogLevelWarning = "warning"
+	LogLevelInfo    = "info"
+	LogLevelDebug   = "debug"
+)
+
+const (
+	// Files are read backwards in chunks of this size, so that only
+	// the end of large files has to be read
+	logTailChunkSize = 64 << 10
+	// Lines longer than this only have their beginning kept while reading
+	logTailMaxLineBytes = 16 << 10
+	// How much of the end of a file gets looked through for matching lines,
+	// so that rare matches in huge files don't mean reading all of it
+	logTailMaxScanBytes = 32 << 20
+	// How many lines are requested from Loki, before they're matched against the patterns
+	logTailMaxLokiLines = 5000
+)
+
+// Levels such as ERROR, [warn] and level=info, along with the "level" field of JSON logs
+var logLevelPattern = regexp.MustCompile(
+	`\b(EMERG|ALERT|CRIT|CRITICAL|FATAL|PANIC|ERROR|ERR|WARN|WARNING|INFO|NOTICE|DEBUG|TRACE)\b|` +
+		`(?i:\blevel=|\blvl=|"level":\s*")"?([a-zA-Z]+)`,
+)
+
+type LogLine struct {
+	Text string
+	// when the line was logged, only known for lines from Loki
+	Time  time.Time
+	Level string
+	// the start and end of the parts of the text which matched a pattern
+	Matches [][2]int
+}
+
+type LogTailRequest struct {
+	// a local file which gets tailed
+	Path string
+	// or a LogQL query which gets run by the Loki instance at URL
+	URL      string
+	Query    string
+	Username string
+	Password string
+	// the tenant of multi-tenant Loki instances
+	Tenant string
+	Since  time.Duration
+	// lines have to match at least one of the patterns, all of them are kept when there are none
+	Patterns      []*regexp.Regexp
+	Limit         int
+	MaxLineLength int
+	Client        RequestDoer
+}
+
+// The last lines that match the patterns, from the oldest to the newest
+func FetchLogTail(ctx context.Context, request *LogTailRequest) ([]LogLine, error) {
+	var lines []LogLine
+	var err error
+
+	if request.Path != "" {
+		lines, err = tailLogFile(request)
+	} else {
+		lines, err = queryLokiLogs(ctx, request)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	slices.Reverse(lines)
+
+	return lines, nil
+}
+
+func tailLogFile(request *LogTailRequest) ([]LogLine, error) {
+	file, err := os.Open(request.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	offset := info.Size()
+	chunk := make([]byte, logTailChunkSize)
+	// the beginning of a line whose start hasn't been read yet
+	var partial []byte
+	lines := make([]LogLine, 0, request.Limit)
+	scanned := 0
+
+	for offset > 0 && len(lines) < request.Limit && scanned < logTailMaxScanBytes {
+		size := min(int64(len(chunk)), offset)
+		offset -= size
+
+		if _, err := file.ReadAt(chunk[:size], offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		scanned += int(size)
+		data := append(chunk[:size:size], partial...)
+
+		for len(lines) < request.Limit {
+			newline := bytes.LastIndexByte(data, '\n')
+
+			if newline == -1 {
+				break
+			}
+
+			if line, ok := newLogLine(string(data[newline+1:]), request); ok {
+				lines = append(lines, line)
+			}
+
+			data = data[:newline]
+		}
+
+		partial = append(partial[:0], data[:min(len(data), logTailMaxLineBytes)]...)
+	}
+
+	// the first line of the file, which doesn't have a newline before it
+	if offset == 0 && len(lines) < request.Limit {
+		if line, ok := newLogLine(string(partial), request); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+func queryLokiLogs(ctx context.Context, request *LogTailRequest) ([]LogLine, error) {
+	query := url.Values{}
+	query.Set("query", request.Query)
+	query.Set("direction", "backward")
+	query.Set("limit", strconv.Itoa(logTailMaxLokiLines))
+	query.Set("start", strconv.FormatInt(time.Now().Add(-request.Since).UnixNano(), 10))
+
+	httpRequest, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(request.URL, "/")+"/loki/api/v1/query_range?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	if request.Tenant != "" {
+		httpRequest.Header.Set("X-Scope-OrgID", request.Tenant)
+	}
+
+	body, _, err := fetchRedactedJson(clientOrDefault(request.Client), httpRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resultType := gjson.Get(body, "data.resultType").String(); resultType != "streams" {
+		return nil, fmt.Errorf("query returned %s rather than log lines", resultType)
+	}
+
+	var lines []LogLine
+
+	for _, stream := range gjson.Get(body, "data.result").Array() {
+		// the level can be a label of the stream, which is more reliable than looking for it in the line
+		level := normalizeLogLevel(stream.Get("stream.level").String())
+
+		if level == "" {
+			level = normalizeLogLevel(stream.Get("stream.detected_level").String())
+		}
+
+		for _, value := range stream.Get("values").Array() {
+			line, ok := newLogLine(value.Get("1").String(), request)
+
+			if !ok {
+				continue
+			}
+
+			nanoseconds, _ := strconv.ParseInt(value.Get("0").String(), 10, 64)
+			line.Time = time.Unix(0, nanoseconds)
+
+			if level != "" {
+				line.Level = level
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	// the lines of each stream are sorted, but the streams aren't interleaved
+	slices.SortStableFunc(lines, func(a, b LogLine) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	if len(lines) > request.Limit {
+		lines = lines[:request.Limit]
+	}
+
+	return lines, nil
+}
+
+func newLogLine(text string, request *LogTailRequest) (LogLine, bool) {
+	text = strings.TrimRight(text, "\r")
+
+	if strings.TrimSpace(text) == "" {
+		return LogLine{}, false
+	}
+
+	var matches [][2]int
+
+	for _, pattern := range request.Patterns {
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			if match[1] > match[0] {
+				matches = append(matches, [2]int{match[0], match[1]})
+			}
+		}
+	}
+
+	if len(request.Patterns) > 0 && len(matches) == 0 {
+		return LogLine{}, false
+	}
+
+	line := LogLine{Text: text, Level: detectLogLevel(text)}
+
+	// lines are matched before being shortened, so only
+	// the matches in what's left of the line are kept
+	if utf8.RuneCountInString(text) > request.MaxLineLength {
+		line.Text = string([]rune(text)[:request.MaxLineLength])
+		matches = slices.DeleteFunc(matches, func(match [2]int) bool { return match[0] >= len(line.Text) })
+
+		for i := range matches {
+			matches[i][1] = min(matches[i][1], len(line.Text))
+		}
+
+		line.Text += "…"
+	}
+
+	if len(matches) == 0 {
+		return line, true
+	}
+
+	// the matches of different patterns can overlap, which get merged
+	slices.SortFunc(matches, func(a, b [2]int) int { return a[0] - b[0] })
+	line.Matches = matches[:1]
+
+	for _, match := range matches[1:] {
+		last := &line.Matches[len(line.Matches)-1]
+
+		if match[0] <= last[1] {
+			last[1] = max(last[1], match[1])
+		} else {
+			line.Matches = append(line.Matches, match)
+		}
+	}
+
+	return line, true
+}
+
+func detectLogLevel(text string) string {
+	match := logLevelPattern.FindStringSubmatch(text)
+
+	if match == nil {
+		return ""
+	}
+
+	if match[1] != "" {
+		return normalizeLogLevel(match[1])
+	}
+
+	return normalizeLogLevel(match[2])
+}
+
+func normalizeLogLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "emerg", "emergency", "alert", "crit", "critical", "fatal", "panic", "err", "error", "eror":
+		return LogLevelError
+	case "warn", "warning":
+		return LogLevelWarning
+	case "info", "information", "notice":
+		return LogLevelInfo
+	case "debug", "trace", "dbug", "trce":
+		return LogLevelDebug
+	}
+
+	return ""
+}
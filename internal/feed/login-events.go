@@ -0,0 +1,245 @@
+package feed
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LoginEvent struct {
+	User   string
+	IP     string
+	Time   time.Time
+	Failed bool
+	// why the login failed, when it's known
+	Reason string
+}
+
+type authentikEventsResponseJson struct {
+	Results []struct {
+		Action string `json:"action"`
+		User   struct {
+			Username string `json:"username"`
+		} `json:"user"`
+		Context struct {
+			// the username that was attempted for failed logins
+			Username string `json:"username"`
+			Message  string `json:"message"`
+		} `json:"context"`
+		ClientIP string    `json:"client_ip"`
+		Created  time.Time `json:"created"`
+	} `json:"results"`
+}
+
+// Fetches the successful and failed logins since the given time from the events of Authentik,
+// which require a token of a user who's allowed to view them, such as one of an admin
+func FetchAuthentikLoginEvents(ctx context.Context, client RequestDoer, baseURL, token string, since time.Time, limit int) ([]LoginEvent, error) {
+	client = clientOrDefault(client)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	events := make([]LoginEvent, 0)
+
+	for _, action := range []string{"login", "login_failed"} {
+		query := url.Values{}
+		query.Set("action", action)
+		query.Set("ordering", "-created")
+		query.Set("page_size", fmt.Sprint(limit))
+
+		request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/v3/events/events/?"+query.Encode(), nil)
+		request.Header.Set("Authorization", "Bearer "+token)
+
+		response, err := decodeJsonFromRequest[authentikEventsResponseJson](client, request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range response.Results {
+			if event.Created.Before(since) {
+				continue
+			}
+
+			user := event.User.Username
+
+			if event.Action == "login_failed" && event.Context.Username != "" {
+				user = event.Context.Username
+			}
+
+			events = append(events, LoginEvent{
+				User:   user,
+				IP:     event.ClientIP,
+				Time:   event.Created,
+				Failed: event.Action == "login_failed",
+				Reason: event.Context.Message,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+const (
+	vaultwardenEventLoggedIn       = 1000
+	vaultwardenEventFailedLogin    = 1005
+	vaultwardenEventFailedLogin2FA = 1006
+)
+
+// Reads the event log of an organization, which Vaultwarden only keeps when it's started with
+// ORG_EVENTS_ENABLED. Requests are made with the personal API key of an owner or admin of the
+// organization, whose access tokens get created when needed and last for a couple of hours
+type VaultwardenClient struct {
+	URL            string
+	ClientID       string
+	ClientSecret   string
+	OrganizationID string
+	Client         RequestDoer
+
+	mu       sync.Mutex
+	deviceID string
+	oauth    oauthRefresher
+	// the names of the members of the organization by their user ID
+	users map[string]string
+}
+
+type vaultwardenEventsJson struct {
+	Data []struct {
+		Type         int       `json:"type"`
+		ActingUserID string    `json:"actingUserId"`
+		IPAddress    string    `json:"ipAddress"`
+		Date         time.Time `json:"date"`
+	} `json:"data"`
+}
+
+type vaultwardenMembersJson struct {
+	Data []struct {
+		UserID string `json:"userId"`
+		Email  string `json:"email"`
+	} `json:"data"`
+}
+
+// Must be called with the mutex held
+func (c *VaultwardenClient) token(ctx context.Context) (string, error) {
+	return c.oauth.token(ctx, func(ctx context.Context) (oauthToken, error) {
+		// logins are tied to a device, which is kept the same so that
+		// Vaultwarden doesn't see a new one each time a token is needed
+		if c.deviceID == "" {
+			c.deviceID = newVaultwardenDeviceID()
+		}
+
+		form := url.Values{}
+		form.Set("grant_type", "client_credentials")
+		form.Set("scope", "api")
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("device_identifier", c.deviceID)
+		form.Set("device_name", "Glance")
+		// the type of the command line client
+		form.Set("device_type", "14")
+
+		request := newOAuthTokenRequest(ctx, strings.TrimSuffix(c.URL, "/")+"/identity/connect/token", form)
+		response, err := decodeJsonFromRequest[oauthTokenResponseJson](clientOrDefault(c.Client), request)
+
+		if err != nil {
+			return oauthToken{}, err
+		}
+
+		return oauthToken{
+			Access:  response.AccessToken,
+			Expires: time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		}, nil
+	})
+}
+
+func newVaultwardenDeviceID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func vaultwardenGet[T any](ctx context.Context, c *VaultwardenClient, token, path string) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.URL, "/")+path, nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	return decodeJsonFromRequest[T](clientOrDefault(c.Client), request)
+}
+
+// Must be called with the mutex held
+func (c *VaultwardenClient) refreshUsers(ctx context.Context, token string) {
+	members, err := vaultwardenGet[vaultwardenMembersJson](ctx, c, token, "/api/organizations/"+url.PathEscape(c.OrganizationID)+"/users")
+
+	if err != nil {
+		return
+	}
+
+	c.users = make(map[string]string, len(members.Data))
+
+	for _, member := range members.Data {
+		c.users[member.UserID] = member.Email
+	}
+}
+
+func (c *VaultwardenClient) FetchLoginEvents(ctx context.Context, since time.Time) ([]LoginEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("start", since.UTC().Format(time.RFC3339))
+	query.Set("end", time.Now().UTC().Format(time.RFC3339))
+
+	response, err := vaultwardenGet[vaultwardenEventsJson](ctx, c, token, "/api/organizations/"+url.PathEscape(c.OrganizationID)+"/events?"+query.Encode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// only refreshed when there's someone new, such as a member who just joined
+	for i := range response.Data {
+		if _, found := c.users[response.Data[i].ActingUserID]; !found {
+			c.refreshUsers(ctx, token)
+			break
+		}
+	}
+
+	events := make([]LoginEvent, 0)
+
+	for _, event := range response.Data {
+		var reason string
+
+		switch event.Type {
+		case vaultwardenEventLoggedIn, vaultwardenEventFailedLogin:
+		case vaultwardenEventFailedLogin2FA:
+			reason = "Wrong two-step login code"
+		default:
+			continue
+		}
+
+		user, found := c.users[event.ActingUserID]
+
+		if !found {
+			user = event.ActingUserID
+		}
+
+		events = append(events, LoginEvent{
+			User:   user,
+			IP:     event.IPAddress,
+			Time:   event.Date,
+			Failed: event.Type != vaultwardenEventLoggedIn,
+			Reason: reason,
+		})
+	}
+
+	return events, nil
+}
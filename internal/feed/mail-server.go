@@ -0,0 +1,340 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	MailServerServiceMailcow  = "mailcow"
+	MailServerServiceStalwart = "stalwart"
+)
+
+// How many entries of the Rspamd history of Mailcow are looked through for the
+// rejects of the last day, the API only allows requesting the most recent ones
+const mailcowRspamdHistoryLimit = 2000
+
+type MailServerClient struct {
+	Service string
+	URL     string
+	APIKey  string
+	Client  RequestDoer
+}
+
+type MailDNSCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+type MailDomainHealth struct {
+	Domain string
+	Checks []MailDNSCheck
+}
+
+func (d *MailDomainHealth) Passed() bool {
+	for i := range d.Checks {
+		if !d.Checks[i].Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+type MailServerStats struct {
+	// the fields below are -1 when the service doesn't report them
+	QueueLength int
+	Deferred    int
+	// within the last 24 hours
+	Rejected int
+	Spam     int
+	Domains  []MailDomainHealth
+}
+
+type mailcowQueueItemJson struct {
+	QueueName string `json:"queue_name"`
+}
+
+type mailcowRspamdHistoryJson struct {
+	Action   string `json:"action"`
+	UnixTime int64  `json:"unix_time"`
+}
+
+type mailcowDKIMJson struct {
+	Selector  string `json:"dkim_selector"`
+	PublicKey string `json:"pubkey"`
+}
+
+type mailcowDomainJson struct {
+	DomainName string `json:"domain_name"`
+}
+
+type stalwartQueueJson struct {
+	Data struct {
+		Total int `json:"total"`
+	} `json:"data"`
+}
+
+type stalwartDNSRecordsJson struct {
+	Data []struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Content string `json:"content"`
+	} `json:"data"`
+}
+
+func mailServerGet[T any](ctx context.Context, c *MailServerClient, path string) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.URL, "/")+path, nil)
+
+	if c.Service == MailServerServiceMailcow {
+		request.Header.Set("X-API-Key", c.APIKey)
+	} else {
+		request.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	return decodeJsonFromRequest[T](clientOrDefault(c.Client), request)
+}
+
+// The value of the p= tag, which is the public key, without the whitespace that
+// long keys are sometimes split with
+func dkimPublicKey(record string) string {
+	for _, tag := range strings.Split(record, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(tag), "=")
+
+		if strings.TrimSpace(name) == "p" {
+			return strings.Join(strings.Fields(value), "")
+		}
+	}
+
+	return ""
+}
+
+func lookupTXTWithPrefix(ctx context.Context, name, prefix string) (string, error) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, name)
+
+	if err != nil {
+		var dnsErr *net.DNSError
+
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	for _, record := range records {
+		if strings.HasPrefix(strings.ToLower(record), strings.ToLower(prefix)) {
+			return record, nil
+		}
+	}
+
+	return "", nil
+}
+
+func checkTXTRecord(ctx context.Context, check, name, prefix string) MailDNSCheck {
+	record, err := lookupTXTWithPrefix(ctx, name, prefix)
+
+	switch {
+	case err != nil:
+		return MailDNSCheck{Name: check, Message: err.Error()}
+	case record == "":
+		return MailDNSCheck{Name: check, Message: "No record found at " + name}
+	}
+
+	return MailDNSCheck{Name: check, Passed: true, Message: record}
+}
+
+// Checks that the domain has MX, SPF and DMARC records, and that the DKIM record is there.
+// When the public key is known, the DKIM record must also have it, since a record with
+// an outdated key is just as bad as a missing one
+func CheckMailDomainDNS(ctx context.Context, domain, dkimSelector, expectedDKIMKey string) MailDomainHealth {
+	health := MailDomainHealth{Domain: domain}
+
+	mx := MailDNSCheck{Name: "MX"}
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+
+	if err != nil || len(records) == 0 {
+		mx.Message = "No MX records found"
+	} else {
+		mx.Passed = true
+		hosts := make([]string, len(records))
+
+		for i := range records {
+			hosts[i] = strings.TrimSuffix(records[i].Host, ".")
+		}
+
+		mx.Message = strings.Join(hosts, ", ")
+	}
+
+	health.Checks = append(health.Checks, mx)
+	health.Checks = append(health.Checks, checkTXTRecord(ctx, "SPF", domain, "v=spf1"))
+
+	dkim := checkTXTRecord(ctx, "DKIM", dkimSelector+"._domainkey."+domain, "v=DKIM1")
+
+	if dkim.Passed && expectedDKIMKey != "" && dkimPublicKey(dkim.Message) != expectedDKIMKey {
+		dkim.Passed = false
+		dkim.Message = "The public key doesn't match the one of the server"
+	}
+
+	health.Checks = append(health.Checks, dkim)
+	health.Checks = append(health.Checks, checkTXTRecord(ctx, "DMARC", "_dmarc."+domain, "v=DMARC1"))
+
+	return health
+}
+
+func (c *MailServerClient) fetchMailcowStats(ctx context.Context, stats *MailServerStats) error {
+	queue, err := mailServerGet[[]mailcowQueueItemJson](ctx, c, "/api/v1/get/mailq/all")
+
+	if err != nil {
+		return fmt.Errorf("could not get queue: %v", err)
+	}
+
+	stats.QueueLength = len(queue)
+	stats.Deferred = 0
+
+	for i := range queue {
+		if queue[i].QueueName == "deferred" {
+			stats.Deferred++
+		}
+	}
+
+	history, err := mailServerGet[[]mailcowRspamdHistoryJson](ctx, c, fmt.Sprintf("/api/v1/get/logs/rspamd-history/%d", mailcowRspamdHistoryLimit))
+
+	if err != nil {
+		return fmt.Errorf("could not get Rspamd history: %v", err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour).Unix()
+	stats.Rejected = 0
+	stats.Spam = 0
+
+	for i := range history {
+		if history[i].UnixTime < since {
+			continue
+		}
+
+		switch history[i].Action {
+		case "reject":
+			stats.Rejected++
+		case "add header", "rewrite subject":
+			stats.Spam++
+		}
+	}
+
+	return nil
+}
+
+func (c *MailServerClient) mailcowDomains(ctx context.Context) ([]string, error) {
+	response, err := mailServerGet[[]mailcowDomainJson](ctx, c, "/api/v1/get/domain/all")
+
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]string, len(response))
+
+	for i := range response {
+		domains[i] = response[i].DomainName
+	}
+
+	return domains, nil
+}
+
+// The selector and public key the server signs with, when it's able to tell
+func (c *MailServerClient) dkimKey(ctx context.Context, domain string) (string, string) {
+	switch c.Service {
+	case MailServerServiceMailcow:
+		response, err := mailServerGet[mailcowDKIMJson](ctx, c, "/api/v1/get/dkim/"+url.PathEscape(domain))
+
+		if err == nil && response.Selector != "" {
+			return response.Selector, strings.Join(strings.Fields(response.PublicKey), "")
+		}
+	case MailServerServiceStalwart:
+		response, err := mailServerGet[stalwartDNSRecordsJson](ctx, c, "/api/dns/records/"+url.PathEscape(domain))
+
+		if err != nil {
+			return "", ""
+		}
+
+		for _, record := range response.Data {
+			selector, _, found := strings.Cut(record.Name, "._domainkey.")
+
+			if record.Type == "TXT" && found {
+				return selector, dkimPublicKey(record.Content)
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// Fetches the queue and the rejects of the last day from the server, when there's one, and checks
+// the DNS records of the domains. With Mailcow, all of its domains are checked when none are given
+func (c *MailServerClient) FetchStats(ctx context.Context, domains []string, dkimSelector string) (*MailServerStats, error) {
+	stats := &MailServerStats{QueueLength: -1, Deferred: -1, Rejected: -1, Spam: -1}
+	var errs []error
+
+	switch c.Service {
+	case MailServerServiceMailcow:
+		if err := c.fetchMailcowStats(ctx, stats); err != nil {
+			errs = append(errs, err)
+		}
+
+		if len(domains) == 0 {
+			var err error
+			domains, err = c.mailcowDomains(ctx)
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not get domains: %v", err))
+			}
+		}
+	case MailServerServiceStalwart:
+		queue, err := mailServerGet[stalwartQueueJson](ctx, c, "/api/queue/messages?limit=1")
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not get queue: %v", err))
+		} else {
+			stats.QueueLength = queue.Data.Total
+		}
+	}
+
+	job := newJob(func(domain string) (MailDomainHealth, error) {
+		selector, key := c.dkimKey(ctx, domain)
+
+		if selector == "" {
+			selector = dkimSelector
+		}
+
+		return CheckMailDomainDNS(ctx, domain, selector, key), nil
+	}, domains).withWorkers(5).withContext(ctx)
+
+	health, _, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Domains = health
+
+	slices.SortFunc(stats.Domains, func(a, b MailDomainHealth) int {
+		return strings.Compare(a.Domain, b.Domain)
+	})
+
+	if len(errs) > 0 {
+		if c.Service != "" && stats.QueueLength < 0 && len(stats.Domains) == 0 {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, errors.Join(errs...))
+		}
+
+		return stats, fmt.Errorf("%w: %v", ErrPartialContent, errors.Join(errs...))
+	}
+
+	return stats, nil
+}
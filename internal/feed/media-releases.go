@@ -0,0 +1,414 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	MediaServiceTMDB  = "tmdb"
+	MediaServiceTrakt = "trakt"
+)
+
+const (
+	tmdbEndpoint         = "https://api.themoviedb.org/3"
+	tmdbImageEndpoint    = "https://image.tmdb.org/t/p/w185"
+	traktEndpoint        = "https://api.trakt.tv"
+	traktRedirectURI     = "urn:ietf:wg:oauth:2.0:oob"
+	traktMaxCalendarDays = 33
+)
+
+type MediaRelease struct {
+	Title string
+	// for episodes, e.g. S02E05 · The Title
+	Episode string
+	IsShow  bool
+	// zero when unknown
+	Date time.Time
+	URL  string
+	// the path of the poster on TMDB, e.g. /abc123.jpg
+	Poster string
+	// out of 10, 0 when not rated
+	Rating float64
+	tmdbID int64
+}
+
+// TMDB image paths are a random name with an extension
+var tmdbPosterPattern = regexp.MustCompile(`^/[A-Za-z0-9]+\.(jpg|png)$`)
+
+func IsTMDBPoster(path string) bool {
+	return tmdbPosterPattern.MatchString(path)
+}
+
+func FetchTMDBPoster(ctx context.Context, client RequestDoer, path string) (string, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", tmdbImageEndpoint+path, nil)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fetchSnapshot(clientOrDefault(client), request)
+}
+
+// Read access tokens are JWTs and get sent as a header, older API keys as a query parameter
+func newTMDBRequest(ctx context.Context, apiKey, path string, query url.Values) (*http.Request, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	if !strings.Contains(apiKey, ".") {
+		query.Set("api_key", apiKey)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", tmdbEndpoint+path+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(apiKey, ".") {
+		request.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	return request, nil
+}
+
+func fetchTMDBJson(ctx context.Context, client RequestDoer, apiKey, path string, query url.Values) (gjson.Result, error) {
+	request, err := newTMDBRequest(ctx, apiKey, path, query)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	return gjson.Parse(body), nil
+}
+
+// The list is one of now-playing, upcoming or trending. Upcoming movies which have already
+// been released are left out, since TMDB includes the ones that came out recently
+func FetchTMDBReleases(ctx context.Context, client RequestDoer, apiKey, list, region string, limit int) ([]MediaRelease, error) {
+	client = clientOrDefault(client)
+	query := url.Values{}
+
+	if region != "" {
+		query.Set("region", region)
+	}
+
+	var path string
+
+	switch list {
+	case "now-playing":
+		path = "/movie/now_playing"
+	case "upcoming":
+		path = "/movie/upcoming"
+	case "trending":
+		path = "/trending/all/week"
+	default:
+		return nil, fmt.Errorf("unknown list %q", list)
+	}
+
+	response, err := fetchTMDBJson(ctx, client, apiKey, path, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	releases := make([]MediaRelease, 0, limit)
+
+	response.Get("results").ForEach(func(_, item gjson.Result) bool {
+		// trending also includes people
+		mediaType := item.Get("media_type").String()
+
+		if mediaType == "person" {
+			return true
+		}
+
+		release := tmdbRelease(item, mediaType == "tv")
+
+		if list == "upcoming" && item.Get("release_date").String() < today {
+			return true
+		}
+
+		releases = append(releases, release)
+		return len(releases) < limit
+	})
+
+	if list == "upcoming" {
+		slices.SortStableFunc(releases, func(a, b MediaRelease) int {
+			return a.Date.Compare(b.Date)
+		})
+	}
+
+	return releases, nil
+}
+
+func tmdbRelease(item gjson.Result, isShow bool) MediaRelease {
+	release := MediaRelease{
+		IsShow: isShow,
+		Poster: item.Get("poster_path").String(),
+		Rating: item.Get("vote_average").Float(),
+		tmdbID: item.Get("id").Int(),
+	}
+
+	date := item.Get("release_date").String()
+	release.Title = item.Get("title").String()
+	release.URL = "https://www.themoviedb.org/movie/" + strconv.FormatInt(release.tmdbID, 10)
+
+	if isShow {
+		date = item.Get("first_air_date").String()
+		release.Title = item.Get("name").String()
+		release.URL = "https://www.themoviedb.org/tv/" + strconv.FormatInt(release.tmdbID, 10)
+	}
+
+	release.Date, _ = time.Parse(time.DateOnly, date)
+
+	return release
+}
+
+// Trakt only hands out short lived access tokens which get created from the refresh token,
+// which itself changes when that happens, so whoever created the client gets told about
+// the new one through OnRefreshTokenChange in order to keep it
+type TraktClient struct {
+	ClientID string
+	// only needed for the calendar
+	ClientSecret         string
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+	// used to get the posters, which Trakt doesn't have
+	TMDBAPIKey string
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+// Must be called with the mutex held
+func (c *TraktClient) token(ctx context.Context, client RequestDoer) (string, error) {
+	return c.oauth.refresh(ctx, client, &c.RefreshToken, c.OnRefreshTokenChange, func(ctx context.Context, form url.Values) *http.Request {
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("redirect_uri", traktRedirectURI)
+
+		return newOAuthTokenRequest(ctx, traktEndpoint+"/oauth/token", form)
+	})
+}
+
+func (c *TraktClient) get(ctx context.Context, client RequestDoer, path, token string) (gjson.Result, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", traktEndpoint+path, nil)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("trakt-api-version", "2")
+	request.Header.Set("trakt-api-key", c.ClientID)
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	return gjson.Parse(body), nil
+}
+
+// The list is either calendar, which has the upcoming episodes of the shows being watched and
+// the releases of the movies on the watchlist for the given number of days, or trending
+func (c *TraktClient) FetchReleases(ctx context.Context, client RequestDoer, list string, days, limit int) ([]MediaRelease, error) {
+	client = clientOrDefault(client)
+
+	var releases []MediaRelease
+	var err error
+
+	switch list {
+	case "calendar":
+		releases, err = c.fetchCalendar(ctx, client, days)
+	case "trending":
+		releases, err = c.fetchTrending(ctx, client, limit)
+	default:
+		return nil, fmt.Errorf("unknown list %q", list)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	if len(releases) > limit {
+		releases = releases[:limit]
+	}
+
+	if c.TMDBAPIKey == "" {
+		return releases, nil
+	}
+
+	if failed := c.addPosters(ctx, client, releases); failed > 0 {
+		return releases, fmt.Errorf("%w: could not fetch %d poster(s)", ErrPartialContent, failed)
+	}
+
+	return releases, nil
+}
+
+func (c *TraktClient) fetchCalendar(ctx context.Context, client RequestDoer, days int) ([]MediaRelease, error) {
+	c.mu.Lock()
+	token, err := c.token(ctx, client)
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dates := time.Now().Format(time.DateOnly) + "/" + strconv.Itoa(min(days, traktMaxCalendarDays))
+
+	shows, err := c.get(ctx, client, "/calendars/my/shows/"+dates, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	movies, err := c.get(ctx, client, "/calendars/my/movies/"+dates, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []MediaRelease
+
+	shows.ForEach(func(_, item gjson.Result) bool {
+		show := item.Get("show")
+		episode := item.Get("episode")
+		release := MediaRelease{
+			Title:   show.Get("title").String(),
+			Episode: fmt.Sprintf("S%02dE%02d", episode.Get("season").Int(), episode.Get("number").Int()),
+			IsShow:  true,
+			URL: fmt.Sprintf(
+				"https://trakt.tv/shows/%s/seasons/%d/episodes/%d",
+				show.Get("ids.slug").String(), episode.Get("season").Int(), episode.Get("number").Int(),
+			),
+			tmdbID: show.Get("ids.tmdb").Int(),
+		}
+
+		if title := episode.Get("title").String(); title != "" {
+			release.Episode += " · " + title
+		}
+
+		release.Date, _ = time.Parse(time.RFC3339, item.Get("first_aired").String())
+		releases = append(releases, release)
+		return true
+	})
+
+	movies.ForEach(func(_, item gjson.Result) bool {
+		movie := item.Get("movie")
+		release := MediaRelease{
+			Title:  movie.Get("title").String(),
+			URL:    "https://trakt.tv/movies/" + movie.Get("ids.slug").String(),
+			tmdbID: movie.Get("ids.tmdb").Int(),
+		}
+
+		release.Date, _ = time.Parse(time.DateOnly, item.Get("released").String())
+		releases = append(releases, release)
+		return true
+	})
+
+	slices.SortStableFunc(releases, func(a, b MediaRelease) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	return releases, nil
+}
+
+func (c *TraktClient) fetchTrending(ctx context.Context, client RequestDoer, limit int) ([]MediaRelease, error) {
+	trending, err := c.get(ctx, client, "/movies/trending?limit="+strconv.Itoa(limit), "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []MediaRelease
+
+	trending.ForEach(func(_, item gjson.Result) bool {
+		movie := item.Get("movie")
+		releases = append(releases, MediaRelease{
+			Title:  movie.Get("title").String(),
+			URL:    "https://trakt.tv/movies/" + movie.Get("ids.slug").String(),
+			tmdbID: movie.Get("ids.tmdb").Int(),
+		})
+
+		return true
+	})
+
+	return releases, nil
+}
+
+// Returns how many of the posters could not be fetched
+func (c *TraktClient) addPosters(ctx context.Context, client RequestDoer, releases []MediaRelease) int {
+	task := func(ctx context.Context, release *MediaRelease) (string, error) {
+		if release.tmdbID == 0 {
+			return "", nil
+		}
+
+		path := "/movie/" + strconv.FormatInt(release.tmdbID, 10)
+
+		if release.IsShow {
+			path = "/tv/" + strconv.FormatInt(release.tmdbID, 10)
+		}
+
+		response, err := fetchTMDBJson(ctx, client, c.TMDBAPIKey, path, nil)
+
+		if err != nil {
+			return "", err
+		}
+
+		return response.Get("poster_path").String(), nil
+	}
+
+	pointers := make([]*MediaRelease, len(releases))
+
+	for i := range releases {
+		pointers[i] = &releases[i]
+	}
+
+	job := newJob(taskWithContext(ctx, task), pointers).withContext(ctx)
+	posters, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		slog.Error("Failed to fetch posters", "error", err)
+		return len(releases)
+	}
+
+	var failed int
+
+	for i := range posters {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch poster", "title", releases[i].Title, "error", errs[i])
+			continue
+		}
+
+		releases[i].Poster = posters[i]
+	}
+
+	return failed
+}
@@ -0,0 +1,378 @@
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	MemorySourceJournal = "journal"
+	MemorySourceImmich  = "immich"
+	MemorySourceGit     = "git"
+)
+
+// Journal entries are recognized by a date in their path, such as 2024-05-17.md
+// or 2024/05/17.md, which is how most journaling and note taking apps name them
+var journalDatePattern = regexp.MustCompile(`(\d{4})[-/_.](\d{2})[-/_.](\d{2})`)
+
+const journalExcerptLength = 200
+
+type MemorySource struct {
+	Type string
+	// shown next to each memory, defaults to a name based on the type
+	Name string
+	// the directory of the journal or the git repository
+	Path   string
+	URL    string
+	APIKey string
+	// only includes the commits of this author for git
+	Author string
+	// the address of a commit, in which {hash} gets replaced with the hash of the commit
+	CommitURL string
+}
+
+func (s *MemorySource) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	switch s.Type {
+	case MemorySourceJournal:
+		return "Journal"
+	case MemorySourceImmich:
+		return "Photos"
+	}
+
+	return filepath.Base(s.Path)
+}
+
+type Memory struct {
+	// midnight of the day in the local timezone
+	Date    time.Time
+	Source  string
+	Title   string
+	Excerpt string
+	URL     string
+	// the ID of the Immich asset, whose thumbnail is fetched separately
+	PhotoID string
+	// the index of the source in the request that the memory came from
+	SourceIndex int
+}
+
+type MemoriesRequest struct {
+	Sources []MemorySource
+	// the same day in each of the previous years that are looked at
+	Days []time.Time
+	// how many photos are included for each day
+	PhotosPerDay int
+	Client       RequestDoer
+}
+
+func FetchMemories(ctx context.Context, request *MemoriesRequest) ([]Memory, error) {
+	client := clientOrDefault(request.Client)
+	indexes := make([]int, len(request.Sources))
+
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	task := func(ctx context.Context, index int) ([]Memory, error) {
+		source := &request.Sources[index]
+		var memories []Memory
+		var err error
+
+		switch source.Type {
+		case MemorySourceJournal:
+			memories, err = fetchJournalMemories(source, request.Days)
+		case MemorySourceImmich:
+			memories, err = fetchImmichMemories(ctx, client, source, request.Days, request.PhotosPerDay)
+		case MemorySourceGit:
+			memories, err = fetchGitMemories(ctx, source, request.Days)
+		default:
+			err = fmt.Errorf("unknown source type %q", source.Type)
+		}
+
+		for i := range memories {
+			memories[i].Source = source.DisplayName()
+			memories[i].SourceIndex = index
+		}
+
+		return memories, err
+	}
+
+	job := newJob(taskWithContext(ctx, task), indexes).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var memories []Memory
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch memories", "source", request.Sources[i].DisplayName(), "error", errs[i])
+			continue
+		}
+
+		memories = append(memories, results[i]...)
+	}
+
+	if len(request.Sources) > 0 && failed == len(request.Sources) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return memories, fmt.Errorf("%w: could not fetch memories from %d source(s)", ErrPartialContent, failed)
+	}
+
+	return memories, nil
+}
+
+func fetchJournalMemories(source *MemorySource, days []time.Time) ([]Memory, error) {
+	wanted := make(map[string]time.Time, len(days))
+
+	for _, day := range days {
+		wanted[day.Format(time.DateOnly)] = day
+	}
+
+	var memories []Memory
+
+	err := filepath.WalkDir(source.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != source.Path && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(path), ".md") {
+			return nil
+		}
+
+		relative, _ := filepath.Rel(source.Path, path)
+		match := journalDatePattern.FindStringSubmatch(filepath.ToSlash(relative))
+
+		if match == nil {
+			return nil
+		}
+
+		day, found := wanted[match[1]+"-"+match[2]+"-"+match[3]]
+
+		if !found {
+			return nil
+		}
+
+		title, excerpt, err := readJournalEntry(path)
+
+		if err != nil {
+			slog.Warn("Failed to read journal entry", "path", path, "error", err)
+			return nil
+		}
+
+		if title == "" {
+			title = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+
+		memories = append(memories, Memory{Date: day, Title: title, Excerpt: excerpt})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return memories, nil
+}
+
+// The title is the first heading and the excerpt is the first paragraph, skipping the front matter
+func readJournalEntry(path string) (string, string, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	var title string
+	var paragraph []string
+	inFrontMatter := false
+	lineNumber := 0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
+
+		if line == "---" && (lineNumber == 1 || inFrontMatter) {
+			inFrontMatter = !inFrontMatter
+			continue
+		}
+
+		if inFrontMatter {
+			continue
+		}
+
+		if line == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if title == "" && len(paragraph) == 0 {
+				title = strings.TrimSpace(strings.TrimLeft(line, "#"))
+				continue
+			}
+
+			if len(paragraph) > 0 {
+				break
+			}
+
+			continue
+		}
+
+		paragraph = append(paragraph, strings.TrimLeft(line, "-*> "))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	excerpt := strings.Join(paragraph, " ")
+
+	if utf8.RuneCountInString(excerpt) > journalExcerptLength {
+		excerpt = string([]rune(excerpt)[:journalExcerptLength]) + "…"
+	}
+
+	return title, excerpt, nil
+}
+
+func fetchImmichMemories(ctx context.Context, client RequestDoer, source *MemorySource, days []time.Time, perDay int) ([]Memory, error) {
+	baseURL := strings.TrimRight(source.URL, "/")
+	var memories []Memory
+
+	for _, day := range days {
+		body, err := json.Marshal(map[string]any{
+			"takenAfter":  day.Format(time.RFC3339),
+			"takenBefore": day.AddDate(0, 0, 1).Format(time.RFC3339),
+			"type":        "IMAGE",
+			"size":        perDay,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		request, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/api/search/metadata", bytes.NewReader(body))
+
+		if err != nil {
+			return nil, err
+		}
+
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("x-api-key", source.APIKey)
+
+		response, _, err := fetchRedactedJson(client, request)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, asset := range gjson.Get(response, "assets.items").Array() {
+			id := asset.Get("id").String()
+
+			memories = append(memories, Memory{
+				Date:    day,
+				Title:   asset.Get("originalFileName").String(),
+				URL:     baseURL + "/photos/" + id,
+				PhotoID: id,
+			})
+		}
+	}
+
+	return memories, nil
+}
+
+func FetchImmichThumbnail(ctx context.Context, client RequestDoer, source *MemorySource, id string) (string, []byte, error) {
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(source.URL, "/")+"/api/assets/"+id+"/thumbnail?size=thumbnail",
+		nil,
+	)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	request.Header.Set("x-api-key", source.APIKey)
+
+	return fetchSnapshot(clientOrDefault(client), request)
+}
+
+// Runs git in the repository, which has to be on the machine Glance is running on
+func fetchGitMemories(ctx context.Context, source *MemorySource, days []time.Time) ([]Memory, error) {
+	var memories []Memory
+
+	for _, day := range days {
+		arguments := []string{
+			"log", "--all", "--no-merges",
+			"--since=" + day.Format(time.RFC3339),
+			"--until=" + day.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339),
+			"--format=%H%x1f%s",
+		}
+
+		if source.Author != "" {
+			arguments = append(arguments, "--author="+source.Author)
+		}
+
+		output, err := runGit(ctx, source.Path, arguments...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			hash, subject, found := strings.Cut(line, "\x1f")
+
+			if !found {
+				continue
+			}
+
+			memory := Memory{Date: day, Title: subject}
+
+			if source.CommitURL != "" {
+				memory.URL = strings.ReplaceAll(source.CommitURL, "{hash}", hash)
+			}
+
+			memories = append(memories, memory)
+		}
+	}
+
+	return memories, nil
+}
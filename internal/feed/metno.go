@@ -0,0 +1,250 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Met.no requires an identifying user agent and blocks generic ones
+const metNoUserAgent = "glance (+https://github.com/glanceapp/glance)"
+
+type metNoForecastResponseJson struct {
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature   float64 `json:"air_temperature"`
+						RelativeHumidity float64 `json:"relative_humidity"`
+						WindSpeed        float64 `json:"wind_speed"`
+					} `json:"details"`
+				} `json:"instant"`
+				Next1Hours *metNoPeriodJson `json:"next_1_hours"`
+				Next6Hours *metNoPeriodJson `json:"next_6_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+type metNoPeriodJson struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details struct {
+		PrecipitationAmount        float64  `json:"precipitation_amount"`
+		ProbabilityOfPrecipitation *float64 `json:"probability_of_precipitation"`
+	} `json:"details"`
+}
+
+// The probability isn't available everywhere, in which case
+// any amount of expected precipitation counts as certain
+func (p *metNoPeriodJson) precipitationProbability() int {
+	if p.Details.ProbabilityOfPrecipitation != nil {
+		return int(math.Round(*p.Details.ProbabilityOfPrecipitation))
+	}
+
+	if p.Details.PrecipitationAmount > 0 {
+		return 100
+	}
+
+	return 0
+}
+
+type metNoSunResponseJson struct {
+	Properties struct {
+		Sunrise struct {
+			Time string `json:"time"`
+		} `json:"sunrise"`
+		Sunset struct {
+			Time string `json:"time"`
+		} `json:"sunset"`
+	} `json:"properties"`
+}
+
+var metNoSymbolCodes = map[string]int{
+	"clearsky":          0,
+	"fair":              1,
+	"partlycloudy":      2,
+	"cloudy":            3,
+	"fog":               45,
+	"lightrain":         61,
+	"rain":              63,
+	"heavyrain":         65,
+	"lightrainshowers":  80,
+	"rainshowers":       81,
+	"heavyrainshowers":  82,
+	"lightsleet":        66,
+	"sleet":             67,
+	"heavysleet":        67,
+	"lightsleetshowers": 66,
+	"sleetshowers":      67,
+	"heavysleetshowers": 67,
+	"lightsnow":         71,
+	"snow":              73,
+	"heavysnow":         75,
+	"lightsnowshowers":  85,
+	"snowshowers":       85,
+	"heavysnowshowers":  86,
+}
+
+// Maps the symbols of Met.no, such as rainshowers_day, to the closest WMO weather code
+// https://api.met.no/weatherapi/weathericon/2.0/documentation
+func metNoSymbolCodeToWeatherCode(symbol string) int {
+	symbol, _, _ = strings.Cut(symbol, "_")
+
+	if strings.Contains(symbol, "thunder") {
+		return 95
+	}
+
+	if code, ok := metNoSymbolCodes[symbol]; ok {
+		return code
+	}
+
+	return 3
+}
+
+func metNoRequest(ctx context.Context, requestUrl string) *http.Request {
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+
+	if globalUserAgent() == "" {
+		request.Header.Set("User-Agent", metNoUserAgent)
+	}
+
+	return request
+}
+
+type metNoWeatherProvider struct{}
+
+func (metNoWeatherProvider) MaxForecastDays() int {
+	return 9
+}
+
+func (metNoWeatherProvider) FetchWeather(ctx context.Context, place *PlaceJson, units UnitSystem, forecastDays int) (*Weather, error) {
+	query := url.Values{}
+
+	// coordinates with more than 4 decimals get rejected
+	query.Add("lat", fmt.Sprintf("%.4f", place.Latitude))
+	query.Add("lon", fmt.Sprintf("%.4f", place.Longitude))
+
+	request := metNoRequest(ctx, "https://api.met.no/weatherapi/locationforecast/2.0/complete?"+query.Encode())
+	forecastJson, err := decodeJsonFromRequest[metNoForecastResponseJson](defaultClient, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	timeseries := forecastJson.Properties.Timeseries
+
+	if len(timeseries) == 0 || timeseries[0].Data.Next1Hours == nil {
+		return nil, fmt.Errorf("%w: unexpected response from Met.no", ErrNoContent)
+	}
+
+	now := time.Now().In(place.location)
+
+	query.Add("date", now.Format("2006-01-02"))
+	query.Add("offset", now.Format("-07:00"))
+
+	request = metNoRequest(ctx, "https://api.met.no/weatherapi/sunrise/3.0/sun?"+query.Encode())
+	sunJson, err := decodeJsonFromRequest[metNoSunResponseJson](defaultClient, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not fetch sunrise and sunset: %v", ErrNoContent, err)
+	}
+
+	var response WeatherResponseJson
+	current := &timeseries[0].Data
+
+	response.Current.Temperature = current.Instant.Details.AirTemperature
+	response.Current.ApparentTemperature = apparentTemperature(
+		current.Instant.Details.AirTemperature,
+		current.Instant.Details.RelativeHumidity,
+		current.Instant.Details.WindSpeed,
+	)
+	response.Current.WeatherCode = metNoSymbolCodeToWeatherCode(current.Next1Hours.Summary.SymbolCode)
+
+	// the first couple of days are hourly, after which the steps become longer
+	for i := range timeseries {
+		entry := &timeseries[i]
+
+		if entry.Data.Next1Hours == nil || (i > 0 && entry.Time.Sub(timeseries[i-1].Time) != time.Hour) {
+			break
+		}
+
+		response.Hourly.Time = append(response.Hourly.Time, entry.Time.Unix())
+		response.Hourly.Temperature = append(response.Hourly.Temperature, entry.Data.Instant.Details.AirTemperature)
+		response.Hourly.PrecipitationProbability = append(response.Hourly.PrecipitationProbability, entry.Data.Next1Hours.precipitationProbability())
+		response.Hourly.WeatherCode = append(response.Hourly.WeatherCode, metNoSymbolCodeToWeatherCode(entry.Data.Next1Hours.Summary.SymbolCode))
+		// m/s to km/h
+		response.Hourly.WindSpeed = append(response.Hourly.WindSpeed, entry.Data.Instant.Details.WindSpeed*3.6)
+	}
+
+	// the daily forecast is made up from the entries of each day, with the
+	// conditions being the ones of the entry which is closest to midday
+	middayDistance := make([]time.Duration, 0, forecastDays)
+
+	for i := range timeseries {
+		entry := &timeseries[i]
+		day := localMidnightUnix(entry.Time, place.location)
+		period := entry.Data.Next6Hours
+
+		if period == nil {
+			period = entry.Data.Next1Hours
+		}
+
+		temperature := entry.Data.Instant.Details.AirTemperature
+		last := len(response.Daily.Time) - 1
+
+		if last < 0 || response.Daily.Time[last] != day {
+			response.Daily.Time = append(response.Daily.Time, day)
+			response.Daily.TemperatureMin = append(response.Daily.TemperatureMin, temperature)
+			response.Daily.TemperatureMax = append(response.Daily.TemperatureMax, temperature)
+			response.Daily.PrecipitationProbabilityMax = append(response.Daily.PrecipitationProbabilityMax, 0)
+			response.Daily.WeatherCode = append(response.Daily.WeatherCode, 0)
+			middayDistance = append(middayDistance, math.MaxInt64)
+			last++
+		}
+
+		response.Daily.TemperatureMin[last] = min(response.Daily.TemperatureMin[last], temperature)
+		response.Daily.TemperatureMax[last] = max(response.Daily.TemperatureMax[last], temperature)
+
+		if period == nil {
+			continue
+		}
+
+		response.Daily.PrecipitationProbabilityMax[last] = max(response.Daily.PrecipitationProbabilityMax[last], period.precipitationProbability())
+		distance := entry.Time.Sub(time.Unix(day, 0).Add(12 * time.Hour)).Abs()
+
+		if distance < middayDistance[last] {
+			middayDistance[last] = distance
+			response.Daily.WeatherCode[last] = metNoSymbolCodeToWeatherCode(period.Summary.SymbolCode)
+		}
+	}
+
+	// there's no sunrise or sunset during polar days and nights
+	midnight := localMidnightUnix(now, place.location)
+	sunrise := metNoParseSunTime(sunJson.Properties.Sunrise.Time, midnight)
+	sunset := metNoParseSunTime(sunJson.Properties.Sunset.Time, midnight+int64((23*time.Hour).Seconds()))
+
+	response.Daily.Sunrise = []int64{sunrise}
+	response.Daily.Sunset = []int64{sunset}
+
+	padHourlyForecastToMidnight(&response, place.location)
+
+	return weatherFromResponse(&response, place, units, forecastDays), nil
+}
+
+func metNoParseSunTime(value string, fallback int64) int64 {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix()
+		}
+	}
+
+	return fallback
+}
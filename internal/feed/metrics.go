@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// Counters for the requests made by widgets, exposed through the metrics endpoint
+var requestMetrics struct {
+	responses   [6]atomic.Uint64 // indexed by the first digit of the status code
+	failed      atomic.Uint64
+	retries     atomic.Uint64
+	tasks       atomic.Uint64
+	failedTasks atomic.Uint64
+}
+
+type RequestMetrics struct {
+	// keyed by the class of the status code, such as 2xx
+	Responses   map[string]uint64
+	Failed      uint64
+	Retries     uint64
+	Tasks       uint64
+	FailedTasks uint64
+}
+
+func GetRequestMetrics() RequestMetrics {
+	metrics := RequestMetrics{
+		Responses:   make(map[string]uint64, 5),
+		Failed:      requestMetrics.failed.Load(),
+		Retries:     requestMetrics.retries.Load(),
+		Tasks:       requestMetrics.tasks.Load(),
+		FailedTasks: requestMetrics.failedTasks.Load(),
+	}
+
+	for class := 1; class <= 5; class++ {
+		metrics.Responses[strconv.Itoa(class)+"xx"] = requestMetrics.responses[class].Load()
+	}
+
+	return metrics
+}
+
+func recordResponse(response *http.Response, err error) {
+	if err != nil {
+		requestMetrics.failed.Add(1)
+		return
+	}
+
+	if class := response.StatusCode / 100; class >= 1 && class <= 5 {
+		requestMetrics.responses[class].Add(1)
+	}
+}
+
+func recordTask(err error) {
+	requestMetrics.tasks.Add(1)
+
+	if err != nil {
+		requestMetrics.failedTasks.Add(1)
+	}
+}
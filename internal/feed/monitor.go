@@ -11,6 +11,8 @@ type SiteStatusRequest struct {
 	URL           string `yaml:"url"`
 	CheckURL      string `yaml:"check-url"`
 	AllowInsecure bool   `yaml:"allow-insecure"`
+	// defaults to GET
+	Method string `yaml:"-"`
 }
 
 type SiteStatus struct {
@@ -20,14 +22,23 @@ type SiteStatus struct {
 	Error        error
 }
 
-func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
+func getSiteStatusTask(ctx context.Context, statusRequest *SiteStatusRequest) (SiteStatus, error) {
 	var url string
 	if statusRequest.CheckURL != "" {
 		url = statusRequest.CheckURL
 	} else {
 		url = statusRequest.URL
 	}
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	method := http.MethodGet
+
+	if statusRequest.Method != "" {
+		method = statusRequest.Method
+	}
+
+	ctx, cancel := context.WithTimeout(withoutRetries(ctx), time.Second*3)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
 
 	if err != nil {
 		return SiteStatus{
@@ -35,9 +46,6 @@ func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
 		}, nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
-	request = request.WithContext(ctx)
 	requestSentAt := time.Now()
 	var response *http.Response
 
@@ -65,8 +73,8 @@ func getSiteStatusTask(statusRequest *SiteStatusRequest) (SiteStatus, error) {
 	return status, nil
 }
 
-func FetchStatusForSites(requests []*SiteStatusRequest) ([]SiteStatus, error) {
-	job := newJob(getSiteStatusTask, requests).withWorkers(20)
+func FetchStatusForSites(ctx context.Context, requests []*SiteStatusRequest) ([]SiteStatus, error) {
+	job := newJob(taskWithContext(ctx, getSiteStatusTask), requests).withWorkers(20).withContext(ctx)
 	results, _, err := workerPoolDo(job)
 
 	if err != nil {
@@ -0,0 +1,313 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	MusicServiceMusicBrainz = "musicbrainz"
+	MusicServiceSpotify     = "spotify"
+)
+
+const (
+	musicBrainzEndpoint  = "https://musicbrainz.org/ws/2"
+	coverArtEndpoint     = "https://coverartarchive.org"
+	spotifyEndpoint      = "https://api.spotify.com/v1"
+	spotifyTokenEndpoint = "https://accounts.spotify.com/api/token"
+	musicBrainzUserAgent = "glance (+https://github.com/glanceapp/glance)"
+	// how many artists get looked up in a single search, which keeps the URL at a sane length
+	musicBrainzArtistsPerSearch = 40
+)
+
+type MusicRelease struct {
+	Title  string
+	Artist string
+	// Album, Single, EP, etc.
+	Type    string
+	URL     string
+	Artwork string
+	Date    time.Time
+	id      string
+}
+
+func sortMusicReleases(releases []MusicRelease) {
+	slices.SortStableFunc(releases, func(a, b MusicRelease) int {
+		return b.Date.Compare(a.Date)
+	})
+}
+
+// MusicBrainz asks for at most one request per second, so the artists are looked up in
+// batches one after the other rather than all at once
+func FetchMusicBrainzReleases(ctx context.Context, client RequestDoer, artists []string, since time.Time) ([]MusicRelease, error) {
+	client = clientOrDefault(client)
+
+	dates := fmt.Sprintf("firstreleasedate:[%s TO %s]", since.Format(time.DateOnly), time.Now().Format(time.DateOnly))
+	var releases []MusicRelease
+
+	for i := 0; i < len(artists); i += musicBrainzArtistsPerSearch {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%w: %v", ErrNoContent, ctx.Err())
+			case <-time.After(time.Second):
+			}
+		}
+
+		batch := artists[i:min(i+musicBrainzArtistsPerSearch, len(artists))]
+		ids := make([]string, len(batch))
+
+		for j := range batch {
+			ids[j] = "arid:" + batch[j]
+		}
+
+		found, err := searchMusicBrainzReleaseGroups(ctx, client, "("+strings.Join(ids, " OR ")+") AND "+dates)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		releases = append(releases, found...)
+	}
+
+	sortMusicReleases(releases)
+
+	return releases, nil
+}
+
+func searchMusicBrainzReleaseGroups(ctx context.Context, client RequestDoer, search string) ([]MusicRelease, error) {
+	query := url.Values{}
+	query.Set("query", search)
+	query.Set("fmt", "json")
+	query.Set("limit", "100")
+
+	request, err := http.NewRequestWithContext(ctx, "GET", musicBrainzEndpoint+"/release-group?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	if globalUserAgent() == "" {
+		request.Header.Set("User-Agent", musicBrainzUserAgent)
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []MusicRelease
+
+	for _, group := range gjson.Get(body, "release-groups").Array() {
+		// only the year or month is known for some, which can't be placed in a week
+		date, err := time.Parse(time.DateOnly, group.Get("first-release-date").String())
+
+		if err != nil {
+			continue
+		}
+
+		var artist strings.Builder
+
+		for _, credit := range group.Get("artist-credit").Array() {
+			artist.WriteString(credit.Get("name").String())
+			artist.WriteString(credit.Get("joinphrase").String())
+		}
+
+		id := group.Get("id").String()
+
+		releases = append(releases, MusicRelease{
+			Title:   group.Get("title").String(),
+			Artist:  artist.String(),
+			Type:    group.Get("primary-type").String(),
+			URL:     "https://musicbrainz.org/release-group/" + id,
+			Artwork: coverArtEndpoint + "/release-group/" + id + "/front-250",
+			Date:    date,
+			id:      id,
+		})
+	}
+
+	return releases, nil
+}
+
+var spotifyAlbumTypes = map[string]string{
+	"album":       "Album",
+	"single":      "Single",
+	"compilation": "Compilation",
+}
+
+// The access tokens that Spotify hands out last for an hour and get created from the refresh
+// token, which may change when that happens, so whoever created the client gets told about
+// the new one through OnRefreshTokenChange in order to keep it
+type SpotifyClient struct {
+	ClientID             string
+	ClientSecret         string
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+// Must be called with the mutex held
+func (c *SpotifyClient) token(ctx context.Context, client RequestDoer) (string, error) {
+	return c.oauth.refresh(ctx, client, &c.RefreshToken, c.OnRefreshTokenChange, func(ctx context.Context, form url.Values) *http.Request {
+		request := newOAuthTokenRequest(ctx, spotifyTokenEndpoint, form)
+		request.SetBasicAuth(c.ClientID, c.ClientSecret)
+
+		return request
+	})
+}
+
+func (c *SpotifyClient) get(ctx context.Context, client RequestDoer, requestURL, token string) (gjson.Result, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	return gjson.Parse(body), nil
+}
+
+// Gets the albums and singles of the artists that the user follows which came out since the given time
+func (c *SpotifyClient) FetchReleases(ctx context.Context, client RequestDoer, since time.Time) ([]MusicRelease, error) {
+	client = clientOrDefault(client)
+
+	c.mu.Lock()
+	token, err := c.token(ctx, client)
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var artists []string
+	next := spotifyEndpoint + "/me/following?type=artist&limit=50"
+
+	for next != "" {
+		page, err := c.get(ctx, client, next, token)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: could not fetch followed artists: %v", ErrNoContent, err)
+		}
+
+		for _, artist := range page.Get("artists.items.#.id").Array() {
+			artists = append(artists, artist.String())
+		}
+
+		next = page.Get("artists.next").String()
+	}
+
+	task := func(ctx context.Context, artist string) ([]MusicRelease, error) {
+		// newest first within each group, so 50 is more than enough to get to the older ones
+		albums, err := c.get(ctx, client, spotifyEndpoint+"/artists/"+url.PathEscape(artist)+"/albums?include_groups=album,single&limit=50", token)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var releases []MusicRelease
+
+		for _, album := range albums.Get("items").Array() {
+			if album.Get("release_date_precision").String() != "day" {
+				continue
+			}
+
+			date, err := time.Parse(time.DateOnly, album.Get("release_date").String())
+
+			if err != nil || date.Before(since) {
+				continue
+			}
+
+			var names []string
+
+			for _, name := range album.Get("artists.#.name").Array() {
+				names = append(names, name.String())
+			}
+
+			releases = append(releases, MusicRelease{
+				Title:  album.Get("name").String(),
+				Artist: strings.Join(names, ", "),
+				// EPs are reported as singles
+				Type:    spotifyAlbumTypes[album.Get("album_type").String()],
+				URL:     album.Get("external_urls.spotify").String(),
+				Artwork: spotifyAlbumArtwork(album.Get("images")),
+				Date:    date,
+				id:      album.Get("id").String(),
+			})
+		}
+
+		return releases, nil
+	}
+
+	job := newJob(taskWithContext(ctx, task), artists).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var releases []MusicRelease
+	// releases by several of the followed artists show up for each of them
+	seen := make(map[string]bool)
+	failed := 0
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+
+		for _, release := range results[i] {
+			if seen[release.id] {
+				continue
+			}
+
+			seen[release.id] = true
+			releases = append(releases, release)
+		}
+	}
+
+	if len(artists) > 0 && failed == len(artists) {
+		return nil, fmt.Errorf("%w: could not fetch releases: %v", ErrNoContent, errs[0])
+	}
+
+	sortMusicReleases(releases)
+
+	if failed > 0 {
+		return releases, fmt.Errorf("%w: could not fetch releases of %d artist(s)", ErrPartialContent, failed)
+	}
+
+	return releases, nil
+}
+
+// The images are sorted from largest to smallest, the one closest to 300px is plenty for a thumbnail
+func spotifyAlbumArtwork(images gjson.Result) string {
+	artwork := ""
+
+	for _, image := range images.Array() {
+		if artwork == "" || image.Get("width").Int() >= 300 {
+			artwork = image.Get("url").String()
+		}
+	}
+
+	return artwork
+}
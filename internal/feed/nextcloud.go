@@ -0,0 +1,186 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// What Nextcloud recommends at the least, lower limits show up as a warning in its admin settings
+const nextcloudRecommendedPHPMemoryLimit = 512 << 20
+
+type NextcloudServerInfo struct {
+	Version        string
+	ActiveUsers5m  int
+	ActiveUsers1h  int
+	ActiveUsers24h int
+	Users          int
+	Files          int
+	FreeSpaceBytes uint64
+	DatabaseBytes  uint64
+	PHPVersion     string
+	Warnings       []string
+	AppUpdates     []string
+	ServerUpdate   string
+}
+
+type nextcloudServerInfoJson struct {
+	OCS struct {
+		Data struct {
+			Nextcloud struct {
+				System struct {
+					Version   string `json:"version"`
+					FreeSpace uint64 `json:"freespace"`
+					Apps      *struct {
+						// an empty array rather than an object when there are none
+						AppUpdates json.RawMessage `json:"app_updates"`
+					} `json:"apps"`
+					Update *struct {
+						Available        bool   `json:"available"`
+						AvailableVersion string `json:"available_version"`
+					} `json:"update"`
+				} `json:"system"`
+				Storage struct {
+					NumUsers int `json:"num_users"`
+					NumFiles int `json:"num_files"`
+				} `json:"storage"`
+			} `json:"nextcloud"`
+			Server struct {
+				PHP struct {
+					Version     string `json:"version"`
+					MemoryLimit int64  `json:"memory_limit"`
+					// false when the extension isn't loaded, in which case there are no stats
+					OPcache json.RawMessage `json:"opcache"`
+				} `json:"php"`
+				Database struct {
+					Size any `json:"size"`
+				} `json:"database"`
+			} `json:"server"`
+			ActiveUsers struct {
+				Last5Minutes int `json:"last5minutes"`
+				Last1Hour    int `json:"last1hour"`
+				Last24Hours  int `json:"last24hours"`
+			} `json:"activeUsers"`
+		} `json:"data"`
+	} `json:"ocs"`
+}
+
+type nextcloudOPcacheJson struct {
+	Enabled     bool `json:"opcache_enabled"`
+	MemoryUsage struct {
+		UsedMemory float64 `json:"used_memory"`
+		FreeMemory float64 `json:"free_memory"`
+	} `json:"memory_usage"`
+	InternedStringsUsage struct {
+		BufferSize float64 `json:"buffer_size"`
+		FreeMemory float64 `json:"free_memory"`
+	} `json:"interned_strings_usage"`
+	Statistics struct {
+		NumCachedKeys float64 `json:"num_cached_keys"`
+		MaxCachedKeys float64 `json:"max_cached_keys"`
+		OOMRestarts   int     `json:"oom_restarts"`
+	} `json:"opcache_statistics"`
+}
+
+// The same checks as the ones of the admin overview of Nextcloud, which warns when
+// any of the OPcache buffers is almost full since that slows everything down
+func nextcloudOPcacheWarnings(raw json.RawMessage) []string {
+	var opcache nextcloudOPcacheJson
+
+	if json.Unmarshal(raw, &opcache) != nil {
+		return []string{"OPcache is not available"}
+	}
+
+	if !opcache.Enabled {
+		return []string{"OPcache is disabled"}
+	}
+
+	var warnings []string
+	memory := opcache.MemoryUsage
+
+	if total := memory.UsedMemory + memory.FreeMemory; total > 0 && memory.FreeMemory/total < 0.1 {
+		warnings = append(warnings, "OPcache memory is almost full, increase opcache.memory_consumption")
+	}
+
+	if interned := opcache.InternedStringsUsage; interned.BufferSize > 0 && interned.FreeMemory/interned.BufferSize < 0.1 {
+		warnings = append(warnings, "OPcache interned strings buffer is almost full, increase opcache.interned_strings_buffer")
+	}
+
+	if keys := opcache.Statistics; keys.MaxCachedKeys > 0 && keys.NumCachedKeys/keys.MaxCachedKeys > 0.9 {
+		warnings = append(warnings, "OPcache is almost out of keys, increase opcache.max_accelerated_files")
+	}
+
+	if opcache.Statistics.OOMRestarts > 0 {
+		warnings = append(warnings, fmt.Sprintf("OPcache restarted %d time(s) after running out of memory", opcache.Statistics.OOMRestarts))
+	}
+
+	return warnings
+}
+
+// Requests the serverinfo app, either with its token, which can be set through occ, or
+// with the credentials of an admin. Apps and updates only get checked when asked for
+func FetchNextcloudServerInfo(ctx context.Context, client RequestDoer, baseURL, token, username, password string) (*NextcloudServerInfo, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(baseURL, "/")+"/ocs/v2.php/apps/serverinfo/api/v1/info?format=json&skipApps=false&skipUpdate=false", nil)
+	request.Header.Set("OCS-APIRequest", "true")
+
+	if token != "" {
+		request.Header.Set("NC-Token", token)
+	} else {
+		request.SetBasicAuth(username, password)
+	}
+
+	response, err := decodeJsonFromRequest[nextcloudServerInfoJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	data := &response.OCS.Data
+	system := &data.Nextcloud.System
+	php := &data.Server.PHP
+
+	info := &NextcloudServerInfo{
+		Version:        system.Version,
+		ActiveUsers5m:  data.ActiveUsers.Last5Minutes,
+		ActiveUsers1h:  data.ActiveUsers.Last1Hour,
+		ActiveUsers24h: data.ActiveUsers.Last24Hours,
+		Users:          data.Nextcloud.Storage.NumUsers,
+		Files:          data.Nextcloud.Storage.NumFiles,
+		FreeSpaceBytes: system.FreeSpace,
+		PHPVersion:     php.Version,
+	}
+
+	// a string with some databases and a number with others
+	switch size := data.Server.Database.Size.(type) {
+	case float64:
+		info.DatabaseBytes = uint64(size)
+	case string:
+		fmt.Sscan(size, &info.DatabaseBytes)
+	}
+
+	// -1 means there's no limit
+	if php.MemoryLimit > 0 && php.MemoryLimit < nextcloudRecommendedPHPMemoryLimit {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("PHP memory limit is below the recommended %d MB", nextcloudRecommendedPHPMemoryLimit>>20))
+	}
+
+	info.Warnings = append(info.Warnings, nextcloudOPcacheWarnings(php.OPcache)...)
+
+	var appUpdates map[string]string
+
+	if system.Apps != nil && json.Unmarshal(system.Apps.AppUpdates, &appUpdates) == nil {
+		for app, version := range appUpdates {
+			info.AppUpdates = append(info.AppUpdates, app+" "+version)
+		}
+
+		slices.Sort(info.AppUpdates)
+	}
+
+	if system.Update != nil && system.Update.Available {
+		info.ServerUpdate = system.Update.AvailableVersion
+	}
+
+	return info, nil
+}
@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultNtfyServerURL = "https://ntfy.sh"
+
+type NtfyNotification struct {
+	Title   string
+	Message string
+	// comma separated, tags which match the name of an emoji get shown as one
+	Tags string
+}
+
+// Publishes the notification to the topic on the server, or on ntfy.sh if it's empty.
+// The token is only needed for topics which are protected
+func SendNtfyNotification(ctx context.Context, client RequestDoer, serverURL, topic, token string, notification *NtfyNotification) error {
+	request, _ := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		strings.TrimSuffix(cmp.Or(serverURL, defaultNtfyServerURL), "/")+"/"+url.PathEscape(topic),
+		strings.NewReader(notification.Message),
+	)
+
+	if notification.Title != "" {
+		request.Header.Set("Title", notification.Title)
+	}
+
+	if notification.Tags != "" {
+		request.Header.Set("Tags", notification.Tags)
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := clientOrDefault(client).Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 256))
+		return fmt.Errorf("unexpected status code %d, response: %s", response.StatusCode, body)
+	}
+
+	return nil
+}
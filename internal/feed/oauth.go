@@ -0,0 +1,107 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Access tokens get replaced this long before they expire so
+// that they don't expire in the middle of an update
+const oauthTokenExpiryMargin = time.Minute
+
+type oauthToken struct {
+	Access  string
+	Expires time.Time
+}
+
+// The response of token endpoints which follow the OAuth spec
+type oauthTokenResponseJson struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Keeps the short lived access token that a service hands out, getting a new one only
+// once it's about to expire. The client it belongs to has to hold its mutex while using it
+type oauthRefresher struct {
+	access        string
+	accessExpires time.Time
+}
+
+// Returns the current access token, or the one from fetch if it's about to expire
+func (r *oauthRefresher) token(ctx context.Context, fetch func(context.Context) (oauthToken, error)) (string, error) {
+	if r.access != "" && time.Now().Add(oauthTokenExpiryMargin).Before(r.accessExpires) {
+		return r.access, nil
+	}
+
+	token, err := fetch(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("could not get access token: %v", err)
+	}
+
+	if token.Access == "" {
+		return "", errors.New("could not get access token: response did not include one")
+	}
+
+	r.access = token.Access
+	r.accessExpires = token.Expires
+
+	return r.access, nil
+}
+
+// For services which follow the OAuth spec and create access tokens from a refresh token. The
+// grant type and the refresh token get added to the form, which newRequest sends to the token
+// endpoint of the service. The refresh token gets replaced when a new one comes along
+func (r *oauthRefresher) refresh(
+	ctx context.Context,
+	client RequestDoer,
+	refreshToken *string,
+	onRefreshTokenChange func(string),
+	newRequest func(ctx context.Context, form url.Values) *http.Request,
+) (string, error) {
+	return r.token(ctx, func(ctx context.Context) (oauthToken, error) {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", *refreshToken)
+
+		response, err := decodeJsonFromRequest[oauthTokenResponseJson](client, newRequest(ctx, form))
+
+		if err != nil {
+			return oauthToken{}, err
+		}
+
+		rotateRefreshToken(refreshToken, response.RefreshToken, onRefreshTokenChange)
+
+		return oauthToken{
+			Access:  response.AccessToken,
+			Expires: time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		}, nil
+	})
+}
+
+// The previous refresh token stops working once a new one has been handed out,
+// so whoever created the client gets told about it in order to keep it
+func rotateRefreshToken(current *string, latest string, onChange func(string)) {
+	if latest == "" || latest == *current {
+		return
+	}
+
+	*current = latest
+
+	if onChange != nil {
+		onChange(latest)
+	}
+}
+
+func newOAuthTokenRequest(ctx context.Context, endpoint string, form url.Values) *http.Request {
+	request, _ := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return request
+}
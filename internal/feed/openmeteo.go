@@ -1,11 +1,13 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,13 +30,21 @@ type PlaceJson struct {
 
 type WeatherResponseJson struct {
 	Daily struct {
-		Sunrise []int64 `json:"sunrise"`
-		Sunset  []int64 `json:"sunset"`
+		Time                        []int64   `json:"time"`
+		Sunrise                     []int64   `json:"sunrise"`
+		Sunset                      []int64   `json:"sunset"`
+		WeatherCode                 []int     `json:"weather_code"`
+		TemperatureMax              []float64 `json:"temperature_2m_max"`
+		TemperatureMin              []float64 `json:"temperature_2m_min"`
+		PrecipitationProbabilityMax []int     `json:"precipitation_probability_max"`
 	} `json:"daily"`
 
 	Hourly struct {
+		Time                     []int64   `json:"time"`
 		Temperature              []float64 `json:"temperature_2m"`
 		PrecipitationProbability []int     `json:"precipitation_probability"`
+		WeatherCode              []int     `json:"weather_code"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
 	} `json:"hourly"`
 
 	Current struct {
@@ -44,12 +54,46 @@ type WeatherResponseJson struct {
 	} `json:"current"`
 }
 
+// Open-Meteo returns metric values unless told otherwise
+func (r *WeatherResponseJson) convertUnits(units UnitSystem) {
+	convert := func(values []float64, convert func(float64) float64) {
+		for i := range values {
+			values[i] = convert(values[i])
+		}
+	}
+
+	convert(r.Daily.TemperatureMax, units.Temperature)
+	convert(r.Daily.TemperatureMin, units.Temperature)
+	convert(r.Hourly.Temperature, units.Temperature)
+	convert(r.Hourly.WindSpeed, units.WindSpeed)
+	r.Current.Temperature = units.Temperature(r.Current.Temperature)
+	r.Current.ApparentTemperature = units.Temperature(r.Current.ApparentTemperature)
+}
+
 type weatherColumn struct {
 	Temperature      int
 	Scale            float64
 	HasPrecipitation bool
 }
 
+type WeatherForecastHour struct {
+	Time                     time.Time
+	Temperature              int
+	PrecipitationProbability int
+	WeatherCode              int
+	WindSpeed                int
+}
+
+type WeatherForecastDay struct {
+	Date                     time.Time
+	TemperatureMin           int
+	TemperatureMax           int
+	PrecipitationProbability int
+	WeatherCode              int
+}
+
+const weatherForecastHours = 6
+
 var commonCountryAbbreviations = map[string]string{
 	"US":  "United States",
 	"USA": "United States",
@@ -82,10 +126,17 @@ func parsePlaceName(name string) (string, string) {
 	return parts[0] + ", " + expandCountryAbbreviations(parts[2]), strings.TrimSpace(parts[1])
 }
 
-func FetchPlaceFromName(location string) (*PlaceJson, error) {
+// The language is a two letter code such as de, which changes the language
+// of the returned place names and defaults to English when left empty
+func FetchPlaceFromName(ctx context.Context, location string, language string) (*PlaceJson, error) {
 	location, area := parsePlaceName(location)
-	requestUrl := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=10&language=en&format=json", url.QueryEscape(location))
-	request, _ := http.NewRequest("GET", requestUrl, nil)
+
+	if language == "" {
+		language = "en"
+	}
+
+	requestUrl := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=10&language=%s&format=json", url.QueryEscape(location), url.QueryEscape(language))
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 	responseJson, err := decodeJsonFromRequest[PlacesResponseJson](defaultClient, request)
 
 	if err != nil {
@@ -130,35 +181,49 @@ func barIndexFromHour(h int) int {
 	return h / 2
 }
 
-// TODO: bunch of spaget, refactor
-func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
-	query := url.Values{}
-	var temperatureUnit string
+type openMeteoWeatherProvider struct{}
 
-	if units == "imperial" {
-		temperatureUnit = "fahrenheit"
-	} else {
-		temperatureUnit = "celsius"
-	}
+func (openMeteoWeatherProvider) MaxForecastDays() int {
+	return 16
+}
+
+func (openMeteoWeatherProvider) FetchWeather(ctx context.Context, place *PlaceJson, units UnitSystem, forecastDays int) (*Weather, error) {
+	query := url.Values{}
 
 	query.Add("latitude", fmt.Sprintf("%f", place.Latitude))
 	query.Add("longitude", fmt.Sprintf("%f", place.Longitude))
 	query.Add("timeformat", "unixtime")
 	query.Add("timezone", place.Timezone)
-	query.Add("forecast_days", "1")
 	query.Add("current", "temperature_2m,apparent_temperature,weather_code")
-	query.Add("hourly", "temperature_2m,precipitation_probability")
-	query.Add("daily", "sunrise,sunset")
-	query.Add("temperature_unit", temperatureUnit)
+
+	if forecastDays > 0 {
+		// the hourly forecast needs at least the next day
+		// in order to cover the hours that come after midnight
+		query.Add("forecast_days", strconv.Itoa(max(forecastDays, 2)))
+		query.Add("hourly", "temperature_2m,precipitation_probability,weather_code")
+		query.Add("daily", "sunrise,sunset,weather_code,temperature_2m_max,temperature_2m_min,precipitation_probability_max")
+	} else {
+		query.Add("forecast_days", "1")
+		query.Add("hourly", "temperature_2m,precipitation_probability")
+		query.Add("daily", "sunrise,sunset")
+	}
 
 	requestUrl := "https://api.open-meteo.com/v1/forecast?" + query.Encode()
-	request, _ := http.NewRequest("GET", requestUrl, nil)
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 	responseJson, err := decodeJsonFromRequest[WeatherResponseJson](defaultClient, request)
 
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
 	}
 
+	return weatherFromResponse(&responseJson, place, units, forecastDays), nil
+}
+
+// Other providers convert their responses to the same shape as the one of Open-Meteo,
+// with the hourly forecast starting from midnight, so that this part can be shared
+// TODO: bunch of spaget, refactor
+func weatherFromResponse(responseJson *WeatherResponseJson, place *PlaceJson, units UnitSystem, forecastDays int) *Weather {
+	responseJson.convertUnits(units)
 	now := time.Now().In(place.location)
 	bars := make([]weatherColumn, 0, 24)
 	currentBar := barIndexFromHour(now.Hour())
@@ -169,7 +234,7 @@ func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
 		sunsetBar = 0
 	}
 
-	if len(responseJson.Hourly.Temperature) == 24 {
+	if len(responseJson.Hourly.Temperature) >= 24 && len(responseJson.Hourly.PrecipitationProbability) >= 24 {
 		temperatures := make([]int, 12)
 		precipitations := make([]bool, 12)
 
@@ -205,7 +270,7 @@ func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
 		}
 	}
 
-	return &Weather{
+	weather := &Weather{
 		Temperature:         int(responseJson.Current.Temperature),
 		ApparentTemperature: int(responseJson.Current.ApparentTemperature),
 		WeatherCode:         responseJson.Current.WeatherCode,
@@ -213,5 +278,131 @@ func FetchWeatherForPlace(place *PlaceJson, units string) (*Weather, error) {
 		SunriseColumn:       sunriseBar,
 		SunsetColumn:        sunsetBar,
 		Columns:             bars,
-	}, nil
+	}
+
+	if forecastDays > 0 {
+		weather.Hourly = hourlyForecastFromResponse(responseJson, now, place.location)
+		weather.Daily = dailyForecastFromResponse(responseJson, forecastDays, place.location)
+	}
+
+	return weather
+}
+
+// Returns every hour of today and tomorrow, including the ones that have already passed
+func FetchHourlyForecastForPlace(ctx context.Context, place *PlaceJson, units UnitSystem) ([]WeatherForecastHour, error) {
+	query := url.Values{}
+
+	query.Add("latitude", fmt.Sprintf("%f", place.Latitude))
+	query.Add("longitude", fmt.Sprintf("%f", place.Longitude))
+	query.Add("timeformat", "unixtime")
+	query.Add("timezone", place.Timezone)
+	query.Add("forecast_days", "2")
+	query.Add("hourly", "temperature_2m,precipitation_probability,weather_code,wind_speed_10m")
+
+	requestUrl := "https://api.open-meteo.com/v1/forecast?" + query.Encode()
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+	responseJson, err := decodeJsonFromRequest[WeatherResponseJson](defaultClient, request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	responseJson.convertUnits(units)
+	hourly := &responseJson.Hourly
+
+	if len(hourly.Temperature) != len(hourly.Time) ||
+		len(hourly.PrecipitationProbability) != len(hourly.Time) ||
+		len(hourly.WeatherCode) != len(hourly.Time) ||
+		len(hourly.WindSpeed) != len(hourly.Time) {
+		return nil, fmt.Errorf("%w: unexpected hourly forecast response", ErrNoContent)
+	}
+
+	hours := make([]WeatherForecastHour, len(hourly.Time))
+
+	for i := range hourly.Time {
+		hours[i] = WeatherForecastHour{
+			Time:                     time.Unix(hourly.Time[i], 0).In(place.location),
+			Temperature:              int(math.Round(hourly.Temperature[i])),
+			PrecipitationProbability: hourly.PrecipitationProbability[i],
+			WeatherCode:              hourly.WeatherCode[i],
+			WindSpeed:                int(math.Round(hourly.WindSpeed[i])),
+		}
+	}
+
+	return hours, nil
+}
+
+func hourlyForecastFromResponse(response *WeatherResponseJson, now time.Time, location *time.Location) []WeatherForecastHour {
+	hourly := &response.Hourly
+
+	if len(hourly.Temperature) != len(hourly.Time) ||
+		len(hourly.PrecipitationProbability) != len(hourly.Time) ||
+		len(hourly.WeatherCode) != len(hourly.Time) {
+		return nil
+	}
+
+	hours := make([]WeatherForecastHour, 0, weatherForecastHours)
+	currentHour := now.Truncate(time.Hour)
+
+	for i := range hourly.Time {
+		t := time.Unix(hourly.Time[i], 0).In(location)
+
+		if t.Before(currentHour) {
+			continue
+		}
+
+		hours = append(hours, WeatherForecastHour{
+			Time:                     t,
+			Temperature:              int(math.Round(hourly.Temperature[i])),
+			PrecipitationProbability: hourly.PrecipitationProbability[i],
+			WeatherCode:              hourly.WeatherCode[i],
+		})
+
+		if len(hours) == weatherForecastHours {
+			break
+		}
+	}
+
+	return hours
+}
+
+func dailyForecastFromResponse(response *WeatherResponseJson, forecastDays int, location *time.Location) []WeatherForecastDay {
+	daily := &response.Daily
+
+	if len(daily.TemperatureMax) != len(daily.Time) ||
+		len(daily.TemperatureMin) != len(daily.Time) ||
+		len(daily.PrecipitationProbabilityMax) != len(daily.Time) ||
+		len(daily.WeatherCode) != len(daily.Time) {
+		return nil
+	}
+
+	days := make([]WeatherForecastDay, 0, forecastDays)
+
+	for i := 0; i < len(daily.Time) && i < forecastDays; i++ {
+		days = append(days, WeatherForecastDay{
+			Date:                     time.Unix(daily.Time[i], 0).In(location),
+			TemperatureMin:           int(math.Round(daily.TemperatureMin[i])),
+			TemperatureMax:           int(math.Round(daily.TemperatureMax[i])),
+			PrecipitationProbability: daily.PrecipitationProbabilityMax[i],
+			WeatherCode:              daily.WeatherCode[i],
+		})
+	}
+
+	return days
+}
+
+func (h *WeatherForecastHour) Icon() string {
+	return WeatherCodeAsIcon(h.WeatherCode)
+}
+
+func (h *WeatherForecastHour) Condition() string {
+	return WeatherCodeAsString(h.WeatherCode)
+}
+
+func (d *WeatherForecastDay) Icon() string {
+	return WeatherCodeAsIcon(d.WeatherCode)
+}
+
+func (d *WeatherForecastDay) Condition() string {
+	return WeatherCodeAsString(d.WeatherCode)
 }
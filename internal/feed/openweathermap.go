@@ -0,0 +1,168 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type openWeatherMapResponseJson struct {
+	Current struct {
+		Temp      float64                       `json:"temp"`
+		FeelsLike float64                       `json:"feels_like"`
+		Weather   []openWeatherMapConditionJson `json:"weather"`
+	} `json:"current"`
+
+	Hourly []struct {
+		Time      int64                         `json:"dt"`
+		Temp      float64                       `json:"temp"`
+		WindSpeed float64                       `json:"wind_speed"`
+		Pop       float64                       `json:"pop"`
+		Weather   []openWeatherMapConditionJson `json:"weather"`
+	} `json:"hourly"`
+
+	Daily []struct {
+		Time    int64 `json:"dt"`
+		Sunrise int64 `json:"sunrise"`
+		Sunset  int64 `json:"sunset"`
+		Temp    struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"temp"`
+		Pop     float64                       `json:"pop"`
+		Weather []openWeatherMapConditionJson `json:"weather"`
+	} `json:"daily"`
+}
+
+type openWeatherMapConditionJson struct {
+	ID int `json:"id"`
+}
+
+func openWeatherMapConditionsToWeatherCode(conditions []openWeatherMapConditionJson) int {
+	if len(conditions) == 0 {
+		return 0
+	}
+
+	return openWeatherMapConditionToWeatherCode(conditions[0].ID)
+}
+
+// Maps the condition codes of OpenWeatherMap to the closest WMO weather code
+// https://openweathermap.org/weather-conditions
+func openWeatherMapConditionToWeatherCode(id int) int {
+	switch {
+	case id >= 200 && id < 300:
+		return 95
+	case id == 300 || id == 310:
+		return 51
+	case id == 302 || id == 312 || id == 314:
+		return 55
+	case id >= 300 && id < 400:
+		return 53
+	case id == 500:
+		return 61
+	case id == 501:
+		return 63
+	case id >= 502 && id <= 504:
+		return 65
+	case id == 511:
+		return 66
+	case id == 520:
+		return 80
+	case id == 521:
+		return 81
+	case id >= 500 && id < 600:
+		return 82
+	case id == 600:
+		return 71
+	case id == 601:
+		return 73
+	case id == 602:
+		return 75
+	case id >= 611 && id <= 616:
+		return 67
+	case id == 620 || id == 621:
+		return 85
+	case id >= 600 && id < 700:
+		return 86
+	case id >= 700 && id < 800:
+		return 45
+	case id == 800:
+		return 0
+	case id == 801:
+		return 1
+	case id == 802:
+		return 2
+	default:
+		return 3
+	}
+}
+
+type openWeatherMapProvider struct {
+	apiKey string
+}
+
+func (p *openWeatherMapProvider) MaxForecastDays() int {
+	return 8
+}
+
+// Uses the One Call API 3.0, which returns the hourly forecast for
+// the next 48 hours and the daily forecast for the next 8 days
+func (p *openWeatherMapProvider) FetchWeather(ctx context.Context, place *PlaceJson, units UnitSystem, forecastDays int) (*Weather, error) {
+	query := url.Values{}
+
+	query.Add("lat", fmt.Sprintf("%f", place.Latitude))
+	query.Add("lon", fmt.Sprintf("%f", place.Longitude))
+	query.Add("units", "metric")
+	query.Add("exclude", "minutely,alerts")
+	query.Add("appid", p.apiKey)
+
+	requestUrl := "https://api.openweathermap.org/data/3.0/onecall?" + query.Encode()
+	request, _ := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+	responseJson, err := decodeJsonFromRequest[openWeatherMapResponseJson](defaultClient, request)
+
+	if err != nil {
+		// the key is part of the URL which is included in the error
+		return nil, fmt.Errorf("%w: %s", ErrNoContent, strings.ReplaceAll(err.Error(), p.apiKey, "REDACTED"))
+	}
+
+	if len(responseJson.Hourly) == 0 || len(responseJson.Daily) == 0 {
+		return nil, fmt.Errorf("%w: unexpected response from OpenWeatherMap", ErrNoContent)
+	}
+
+	var response WeatherResponseJson
+
+	response.Current.Temperature = responseJson.Current.Temp
+	response.Current.ApparentTemperature = responseJson.Current.FeelsLike
+	response.Current.WeatherCode = openWeatherMapConditionsToWeatherCode(responseJson.Current.Weather)
+
+	for i := range responseJson.Hourly {
+		hour := &responseJson.Hourly[i]
+
+		response.Hourly.Time = append(response.Hourly.Time, hour.Time)
+		response.Hourly.Temperature = append(response.Hourly.Temperature, hour.Temp)
+		response.Hourly.PrecipitationProbability = append(response.Hourly.PrecipitationProbability, int(hour.Pop*100))
+		response.Hourly.WeatherCode = append(response.Hourly.WeatherCode, openWeatherMapConditionsToWeatherCode(hour.Weather))
+		// m/s to km/h
+		response.Hourly.WindSpeed = append(response.Hourly.WindSpeed, hour.WindSpeed*3.6)
+	}
+
+	for i := range responseJson.Daily {
+		day := &responseJson.Daily[i]
+
+		// the time of the daily forecast is at noon
+		response.Daily.Time = append(response.Daily.Time, localMidnightUnix(time.Unix(day.Time, 0), place.location))
+		response.Daily.Sunrise = append(response.Daily.Sunrise, day.Sunrise)
+		response.Daily.Sunset = append(response.Daily.Sunset, day.Sunset)
+		response.Daily.WeatherCode = append(response.Daily.WeatherCode, openWeatherMapConditionsToWeatherCode(day.Weather))
+		response.Daily.TemperatureMin = append(response.Daily.TemperatureMin, day.Temp.Min)
+		response.Daily.TemperatureMax = append(response.Daily.TemperatureMax, day.Temp.Max)
+		response.Daily.PrecipitationProbabilityMax = append(response.Daily.PrecipitationProbabilityMax, int(day.Pop*100))
+	}
+
+	padHourlyForecastToMidnight(&response, place.location)
+
+	return weatherFromResponse(&response, place, units, forecastDays), nil
+}
@@ -0,0 +1,117 @@
+package feed
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const openMeteoAirQualityEndpoint = "https://air-quality-api.open-meteo.com/v1/air-quality"
+
+const (
+	AirQualityIndexEuropean = "european"
+	AirQualityIndexUS       = "us"
+)
+
+// The pollen types Open-Meteo knows about, which are only available in Europe
+var PollenTypes = []string{"alder", "birch", "grass", "mugwort", "olive", "ragweed"}
+
+type PollenCount struct {
+	Type string
+	// in grains per cubic meter
+	Value float64
+}
+
+type OutdoorConditions struct {
+	UVIndex float64
+	// the highest UV index of the day
+	UVIndexMax float64
+	// nil when not known for the location
+	AirQuality *float64
+	// only includes the types that are known for the location, sorted by
+	// their value from highest to lowest
+	Pollen     []PollenCount
+	MeasuredAt time.Time
+}
+
+// The pollen type with the highest count, nil when none are known
+func (c *OutdoorConditions) WorstPollen() *PollenCount {
+	if len(c.Pollen) == 0 {
+		return nil
+	}
+
+	return &c.Pollen[0]
+}
+
+func FetchOutdoorConditions(ctx context.Context, client RequestDoer, place *PlaceJson, index string, pollenTypes []string) (*OutdoorConditions, error) {
+	aqiVariable := index + "_aqi"
+	current := []string{"uv_index", aqiVariable}
+
+	for _, pollenType := range pollenTypes {
+		current = append(current, pollenType+"_pollen")
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(place.Latitude, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(place.Longitude, 'f', -1, 64))
+	query.Set("current", strings.Join(current, ","))
+	query.Set("hourly", "uv_index")
+	query.Set("forecast_days", "1")
+	query.Set("timezone", place.Timezone)
+	query.Set("timeformat", "unixtime")
+
+	request, err := http.NewRequestWithContext(ctx, "GET", openMeteoAirQualityEndpoint+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	body, _, err := fetchRedactedJson(clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	currentJson := gjson.Get(body, "current")
+
+	if !currentJson.Exists() {
+		return nil, fmt.Errorf("%w: no current conditions in response", ErrNoContent)
+	}
+
+	conditions := &OutdoorConditions{
+		UVIndex:    currentJson.Get("uv_index").Float(),
+		MeasuredAt: time.Unix(currentJson.Get("time").Int(), 0),
+	}
+
+	conditions.UVIndexMax = conditions.UVIndex
+
+	for _, value := range gjson.Get(body, "hourly.uv_index").Array() {
+		conditions.UVIndexMax = max(conditions.UVIndexMax, value.Float())
+	}
+
+	if aqi := currentJson.Get(aqiVariable); aqi.Type == gjson.Number {
+		value := aqi.Float()
+		conditions.AirQuality = &value
+	}
+
+	for _, pollenType := range pollenTypes {
+		// null outside of Europe
+		if value := currentJson.Get(pollenType + "_pollen"); value.Type == gjson.Number {
+			conditions.Pollen = append(conditions.Pollen, PollenCount{Type: pollenType, Value: value.Float()})
+		}
+	}
+
+	slices.SortStableFunc(conditions.Pollen, func(a, b PollenCount) int {
+		return cmp.Compare(b.Value, a.Value)
+	})
+
+	return conditions, nil
+}
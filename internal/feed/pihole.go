@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -39,7 +40,7 @@ func (p *piholeTopBlockedDomains) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-func FetchPiholeStats(instanceURL, token string) (*DNSStats, error) {
+func FetchPiholeStats(ctx context.Context, instanceURL, token string) (*DNSStats, error) {
 	if token == "" {
 		return nil, errors.New("missing API token")
 	}
@@ -47,7 +48,7 @@ func FetchPiholeStats(instanceURL, token string) (*DNSStats, error) {
 	requestURL := strings.TrimRight(instanceURL, "/") +
 		"/admin/api.php?summaryRaw&topItems&overTimeData10mins&auth=" + token
 
-	request, err := http.NewRequest("GET", requestURL, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 
 	if err != nil {
 		return nil, err
@@ -73,6 +74,7 @@ func FetchPiholeStats(instanceURL, token string) (*DNSStats, error) {
 			domains = append(domains, DNSStatsBlockedDomain{
 				Domain:         domain,
 				PercentBlocked: int(float64(count) / float64(responseJson.BlockedQueries) * 100),
+				Blocked:        count,
 			})
 		}
 
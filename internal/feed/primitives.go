@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"math"
 	"sort"
 	"time"
@@ -22,6 +23,12 @@ type ForumPost struct {
 
 type ForumPosts []ForumPost
 
+// Implemented by every site that the forum widget can display posts from
+type ForumSource interface {
+	// The limit is a hint, sources which return a fixed number of posts may ignore it
+	FetchPosts(ctx context.Context, limit int) (ForumPosts, error)
+}
+
 type Calendar struct {
 	CurrentDay        int
 	CurrentWeekNumber int
@@ -38,6 +45,8 @@ type Weather struct {
 	SunriseColumn       int
 	SunsetColumn        int
 	Columns             []weatherColumn
+	Hourly              []WeatherForecastHour
+	Daily               []WeatherForecastDay
 }
 
 type AppRelease struct {
@@ -88,6 +97,15 @@ var currencyToSymbol = map[string]string{
 	"PHP": "₱",
 }
 
+// Returns the code itself for currencies that don't have a known symbol
+func CurrencySymbol(code string) string {
+	if symbol, exists := currencyToSymbol[code]; exists {
+		return symbol
+	}
+
+	return code
+}
+
 type DNSStats struct {
 	TotalQueries      int
 	BlockedQueries    int
@@ -108,6 +126,8 @@ type DNSStatsSeries struct {
 type DNSStatsBlockedDomain struct {
 	Domain         string
 	PercentBlocked int
+	// how many times it was blocked
+	Blocked int
 }
 
 type MarketRequest struct {
@@ -115,14 +135,37 @@ type MarketRequest struct {
 	Symbol     string `yaml:"symbol"`
 	ChartLink  string `yaml:"chart-link"`
 	SymbolLink string `yaml:"symbol-link"`
+	// overrides the currency reported by the source
+	CurrencyCode string `yaml:"currency"`
+	// hides the price, for markets where only the change is meaningful
+	PercentOnly bool `yaml:"percent-only"`
 }
 
+const (
+	MarketKindIndex        = "index"
+	MarketKindFuture       = "future"
+	MarketKindCurrencyPair = "currency-pair"
+)
+
 type Market struct {
 	MarketRequest
-	Currency       string  `yaml:"-"`
-	Price          float64 `yaml:"-"`
-	PercentChange  float64 `yaml:"-"`
-	SvgChartPoints string  `yaml:"-"`
+	Currency       string    `yaml:"-"`
+	Price          float64   `yaml:"-"`
+	PercentChange  float64   `yaml:"-"`
+	SvgChartPoints string    `yaml:"-"`
+	Earnings       time.Time `yaml:"-"`
+	ExDividend     time.Time `yaml:"-"`
+	// empty for ordinary securities such as stocks and funds
+	Kind string `yaml:"-"`
+}
+
+// Exchange rates need more precision than prices to show any movement
+func (m Market) PriceDecimals() int {
+	if m.Kind == MarketKindCurrencyPair {
+		return 4
+	}
+
+	return 2
 }
 
 type Markets []Market
@@ -171,13 +214,38 @@ var weatherCodeTable = map[int]string{
 }
 
 func (w *Weather) WeatherCodeAsString() string {
-	if weatherCode, ok := weatherCodeTable[w.WeatherCode]; ok {
+	return WeatherCodeAsString(w.WeatherCode)
+}
+
+func WeatherCodeAsString(code int) string {
+	if weatherCode, ok := weatherCodeTable[code]; ok {
 		return weatherCode
 	}
 
 	return ""
 }
 
+// Groups the weather codes into the handful of conditions
+// for which there are icons
+func WeatherCodeAsIcon(code int) string {
+	switch {
+	case code <= 1:
+		return "clear"
+	case code == 2:
+		return "partly-cloudy"
+	case code == 3:
+		return "cloudy"
+	case code == 45 || code == 48:
+		return "fog"
+	case code >= 71 && code <= 77, code == 85, code == 86:
+		return "snow"
+	case code >= 95:
+		return "thunderstorm"
+	default:
+		return "rain"
+	}
+}
+
 const depreciatePostsOlderThanHours = 7
 const maxDepreciation = 0.9
 const maxDepreciationAfterHours = 24
@@ -214,6 +282,12 @@ func (p ForumPosts) SortByEngagement() {
 	})
 }
 
+func (p ForumPosts) SortByNewest() {
+	sort.Slice(p, func(i, j int) bool {
+		return p[i].TimePosted.After(p[j].TimePosted)
+	})
+}
+
 func (s *ForumPost) HasTargetUrl() bool {
 	return s.TargetUrl != ""
 }
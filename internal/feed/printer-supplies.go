@@ -0,0 +1,500 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type PrinterSupply struct {
+	Name string
+	// such as #00FFFF, empty when unknown
+	Color string
+	// from 0 to 100, or -1 when the printer doesn't know
+	Level int
+	// the level at which the printer considers the supply low, 0 when it doesn't say
+	LowLevel int
+}
+
+type PrinterAlert struct {
+	Text    string
+	IsError bool
+}
+
+type PrinterSupplies struct {
+	Model    string
+	State    string
+	Message  string
+	Alerts   []PrinterAlert
+	Supplies []PrinterSupply
+}
+
+// Either URL for IPP or SNMPHost for SNMP
+type PrinterSuppliesRequest struct {
+	URL       string
+	SNMPHost  string
+	Community string
+}
+
+func FetchPrinterSupplies(ctx context.Context, client RequestDoer, requests []PrinterSuppliesRequest) ([]*PrinterSupplies, []error, error) {
+	job := newJob(func(request PrinterSuppliesRequest) (*PrinterSupplies, error) {
+		if request.SNMPHost != "" {
+			return fetchPrinterSuppliesFromSNMP(ctx, request.SNMPHost, request.Community)
+		}
+
+		return fetchPrinterSuppliesFromIPP(ctx, clientOrDefault(client), request.URL)
+	}, requests).withWorkers(len(requests)).withContext(ctx)
+
+	return workerPoolDo(job)
+}
+
+const (
+	ippTagOperationAttributes = 0x01
+	ippTagEndOfAttributes     = 0x03
+	ippTagInteger             = 0x21
+	ippTagEnum                = 0x23
+	ippTagTextWithLanguage    = 0x35
+	ippTagNameWithLanguage    = 0x36
+	ippTagKeyword             = 0x44
+	ippTagURI                 = 0x45
+	ippTagCharset             = 0x47
+	ippTagNaturalLanguage     = 0x48
+
+	ippOperationGetPrinterAttributes = 0x000b
+)
+
+var ippRequestedAttributes = []string{
+	"printer-make-and-model",
+	"printer-state",
+	"printer-state-message",
+	"printer-state-reasons",
+	"marker-names",
+	"marker-colors",
+	"marker-levels",
+	"marker-low-levels",
+}
+
+func ippAppendAttribute(data []byte, tag byte, name, value string) []byte {
+	data = append(data, tag)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(name)))
+	data = append(data, name...)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(value)))
+	return append(data, value...)
+}
+
+// Printers are usually given as ipp://, which is HTTP on port 631, or ipps:// for HTTPS
+func ippHTTPURL(printerURL string) (string, error) {
+	parsed, err := url.Parse(printerURL)
+
+	if err != nil {
+		return "", err
+	}
+
+	switch parsed.Scheme {
+	case "ipp":
+		parsed.Scheme = "http"
+	case "ipps":
+		parsed.Scheme = "https"
+	case "http", "https":
+		return printerURL, nil
+	default:
+		return "", fmt.Errorf("unsupported scheme %q", parsed.Scheme)
+	}
+
+	if parsed.Port() == "" {
+		parsed.Host += ":631"
+	}
+
+	return parsed.String(), nil
+}
+
+func fetchPrinterSuppliesFromIPP(ctx context.Context, client RequestDoer, printerURL string) (*PrinterSupplies, error) {
+	httpURL, err := ippHTTPURL(printerURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// version 2.0, the operation and a request ID of 1
+	body := []byte{2, 0}
+	body = binary.BigEndian.AppendUint16(body, ippOperationGetPrinterAttributes)
+	body = binary.BigEndian.AppendUint32(body, 1)
+	body = append(body, ippTagOperationAttributes)
+	body = ippAppendAttribute(body, ippTagCharset, "attributes-charset", "utf-8")
+	body = ippAppendAttribute(body, ippTagNaturalLanguage, "attributes-natural-language", "en")
+	body = ippAppendAttribute(body, ippTagURI, "printer-uri", printerURL)
+
+	for i, attribute := range ippRequestedAttributes {
+		name := "requested-attributes"
+
+		// additional values of an attribute don't repeat its name
+		if i > 0 {
+			name = ""
+		}
+
+		body = ippAppendAttribute(body, ippTagKeyword, name, attribute)
+	}
+
+	body = append(body, ippTagEndOfAttributes)
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", httpURL, bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/ipp")
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := ippDecodeResponse(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	printer := &PrinterSupplies{}
+
+	if values := attributes["printer-make-and-model"]; len(values) > 0 {
+		printer.Model, _ = values[0].(string)
+	}
+
+	if values := attributes["printer-state-message"]; len(values) > 0 {
+		printer.Message, _ = values[0].(string)
+	}
+
+	if values := attributes["printer-state"]; len(values) > 0 {
+		state, _ := values[0].(int64)
+
+		switch state {
+		case 3:
+			printer.State = "Idle"
+		case 4:
+			printer.State = "Printing"
+		case 5:
+			printer.State = "Stopped"
+		}
+	}
+
+	for _, value := range attributes["printer-state-reasons"] {
+		if reason, ok := value.(string); ok {
+			if alert, ok := ippStateReasonAlert(reason); ok {
+				printer.Alerts = append(printer.Alerts, alert)
+			}
+		}
+	}
+
+	names := attributes["marker-names"]
+	colors := attributes["marker-colors"]
+	levels := attributes["marker-levels"]
+	lowLevels := attributes["marker-low-levels"]
+
+	for i := range names {
+		supply := PrinterSupply{Level: -1}
+		supply.Name, _ = names[i].(string)
+
+		if i < len(colors) {
+			color, _ := colors[i].(string)
+
+			// can be "none" or a combination such as #00FFFF#FF00FF for multi color cartridges
+			if len(color) >= 7 && color[0] == '#' {
+				supply.Color = color[:7]
+			}
+		}
+
+		// negative levels mean that the printer doesn't know or can only tell that some is left
+		if i < len(levels) {
+			if level, ok := levels[i].(int64); ok && level >= 0 {
+				supply.Level = int(min(level, 100))
+			}
+		}
+
+		if i < len(lowLevels) {
+			if level, ok := lowLevels[i].(int64); ok && level > 0 {
+				supply.LowLevel = int(level)
+			}
+		}
+
+		printer.Supplies = append(printer.Supplies, supply)
+	}
+
+	return printer, nil
+}
+
+// Reasons are keywords such as media-empty-error or toner-low-warning, those which end
+// in -report are only informational and none means that everything is fine
+func ippStateReasonAlert(reason string) (PrinterAlert, bool) {
+	if reason == "none" || strings.HasSuffix(reason, "-report") {
+		return PrinterAlert{}, false
+	}
+
+	alert := PrinterAlert{IsError: !strings.HasSuffix(reason, "-warning")}
+	reason = strings.TrimSuffix(strings.TrimSuffix(reason, "-warning"), "-error")
+	alert.Text = strings.ReplaceAll(reason, "-", " ")
+
+	if alert.Text != "" {
+		alert.Text = strings.ToUpper(alert.Text[:1]) + alert.Text[1:]
+	}
+
+	return alert, true
+}
+
+// Returns the values of the attributes in the response, which are either an int64 or a string
+func ippDecodeResponse(data []byte) (map[string][]any, error) {
+	if len(data) < 8 {
+		return nil, errors.New("invalid response")
+	}
+
+	// anything from 0x0100 onwards is an error
+	if status := binary.BigEndian.Uint16(data[2:4]); status >= 0x0100 {
+		return nil, fmt.Errorf("printer responded with status 0x%04x", status)
+	}
+
+	attributes := make(map[string][]any)
+	data = data[8:]
+	var name string
+
+	for len(data) > 0 {
+		tag := data[0]
+		data = data[1:]
+
+		if tag == ippTagEndOfAttributes {
+			break
+		}
+
+		// the start of another group of attributes
+		if tag < 0x10 {
+			continue
+		}
+
+		if len(data) < 2 {
+			return nil, errors.New("unexpected end of response")
+		}
+
+		nameLength := int(binary.BigEndian.Uint16(data))
+
+		if len(data) < 2+nameLength+2 {
+			return nil, errors.New("unexpected end of response")
+		}
+
+		if nameLength > 0 {
+			name = string(data[2 : 2+nameLength])
+		}
+
+		data = data[2+nameLength:]
+		valueLength := int(binary.BigEndian.Uint16(data))
+
+		if len(data) < 2+valueLength {
+			return nil, errors.New("unexpected end of response")
+		}
+
+		value := data[2 : 2+valueLength]
+		data = data[2+valueLength:]
+
+		switch {
+		case (tag == ippTagInteger || tag == ippTagEnum) && len(value) == 4:
+			attributes[name] = append(attributes[name], int64(int32(binary.BigEndian.Uint32(value))))
+		case tag == ippTagTextWithLanguage || tag == ippTagNameWithLanguage:
+			// the language comes first, followed by the text, each with its length
+			if len(value) >= 2 {
+				languageLength := int(binary.BigEndian.Uint16(value))
+
+				if len(value) >= 2+languageLength+2 {
+					attributes[name] = append(attributes[name], string(value[2+languageLength+2:]))
+				}
+			}
+		case tag >= 0x40 && tag <= 0x4f:
+			attributes[name] = append(attributes[name], string(value))
+		default:
+			attributes[name] = append(attributes[name], nil)
+		}
+	}
+
+	return attributes, nil
+}
+
+// From the Host Resources and Printer MIBs, which network printers have supported for decades
+const (
+	snmpOIDDeviceDescription    = "1.3.6.1.2.1.25.3.2.1.3.1"
+	snmpOIDPrinterStatus        = "1.3.6.1.2.1.25.3.5.1.1.1"
+	snmpOIDPrinterErrorState    = "1.3.6.1.2.1.25.3.5.1.2.1"
+	snmpOIDMarkerSuppliesTable  = "1.3.6.1.2.1.43.11.1.1"
+	snmpOIDMarkerColorantValues = "1.3.6.1.2.1.43.12.1.1.4"
+)
+
+// Columns of the marker supplies table
+const (
+	snmpSuppliesColumnColorant    = "3"
+	snmpSuppliesColumnDescription = "6"
+	snmpSuppliesColumnMaxCapacity = "8"
+	snmpSuppliesColumnLevel       = "9"
+)
+
+// The bits of hrPrinterDetectedErrorState, starting from the highest bit of the first byte
+var snmpPrinterErrorStates = []PrinterAlert{
+	{Text: "Low paper"},
+	{Text: "No paper", IsError: true},
+	{Text: "Low toner"},
+	{Text: "No toner", IsError: true},
+	{Text: "Door open", IsError: true},
+	{Text: "Jammed", IsError: true},
+	{Text: "Offline", IsError: true},
+	{Text: "Service requested", IsError: true},
+	{Text: "Input tray missing", IsError: true},
+	{Text: "Output tray missing", IsError: true},
+	{Text: "Marker supply missing", IsError: true},
+	{Text: "Output near full"},
+	{Text: "Output full", IsError: true},
+	{Text: "Input tray empty", IsError: true},
+	{Text: "Overdue maintenance"},
+}
+
+var snmpColorantColors = map[string]string{
+	"black":         "#000000",
+	"photo black":   "#000000",
+	"matte black":   "#000000",
+	"cyan":          "#00FFFF",
+	"light cyan":    "#A0FFFF",
+	"magenta":       "#FF00FF",
+	"light magenta": "#FFA0FF",
+	"yellow":        "#FFFF00",
+	"gray":          "#808080",
+	"grey":          "#808080",
+}
+
+func fetchPrinterSuppliesFromSNMP(ctx context.Context, host, community string) (*PrinterSupplies, error) {
+	session, err := newSNMPSession(ctx, host, community)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer session.Close()
+
+	general, err := session.get(ctx, snmpOIDDeviceDescription, snmpOIDPrinterStatus, snmpOIDPrinterErrorState)
+
+	if err != nil {
+		return nil, err
+	}
+
+	printer := &PrinterSupplies{}
+
+	for i := range general {
+		switch general[i].OID {
+		case snmpOIDDeviceDescription:
+			printer.Model = strings.TrimSpace(general[i].String())
+		case snmpOIDPrinterStatus:
+			status, _ := general[i].Int()
+
+			switch status {
+			case 3:
+				printer.State = "Idle"
+			case 4:
+				printer.State = "Printing"
+			case 5:
+				printer.State = "Warming up"
+			}
+		case snmpOIDPrinterErrorState:
+			bits := general[i].String()
+
+			for bit := range snmpPrinterErrorStates {
+				if bit/8 < len(bits) && bits[bit/8]&(0x80>>(bit%8)) != 0 {
+					printer.Alerts = append(printer.Alerts, snmpPrinterErrorStates[bit])
+				}
+			}
+		}
+	}
+
+	table, err := session.walk(ctx, snmpOIDMarkerSuppliesTable)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not get supplies: %v", err)
+	}
+
+	// colors are optional, not all printers have them
+	colorants, _ := session.walk(ctx, snmpOIDMarkerColorantValues)
+	colorantNames := make(map[string]string, len(colorants))
+
+	for i := range colorants {
+		colorantNames[strings.TrimPrefix(colorants[i].OID, snmpOIDMarkerColorantValues+".")] = colorants[i].String()
+	}
+
+	// the rows are indexed by the device and the supply, such as 1.2
+	type supplyRow struct {
+		colorant    int64
+		description string
+		maxCapacity int64
+		level       int64
+	}
+
+	rows := make(map[string]*supplyRow)
+	var order []string
+
+	for i := range table {
+		column, index, _ := strings.Cut(strings.TrimPrefix(table[i].OID, snmpOIDMarkerSuppliesTable+"."), ".")
+		row, exists := rows[index]
+
+		if !exists {
+			row = &supplyRow{}
+			rows[index] = row
+			order = append(order, index)
+		}
+
+		switch column {
+		case snmpSuppliesColumnColorant:
+			row.colorant, _ = table[i].Int()
+		case snmpSuppliesColumnDescription:
+			row.description = strings.TrimSpace(strings.Trim(table[i].String(), "\x00"))
+		case snmpSuppliesColumnMaxCapacity:
+			row.maxCapacity, _ = table[i].Int()
+		case snmpSuppliesColumnLevel:
+			row.level, _ = table[i].Int()
+		}
+	}
+
+	for _, index := range order {
+		row := rows[index]
+		supply := PrinterSupply{Name: row.description, Level: -1}
+
+		// negative capacities and levels mean that it's unknown or that there's no limit
+		if row.maxCapacity > 0 && row.level >= 0 {
+			supply.Level = int(min(row.level*100/row.maxCapacity, 100))
+		}
+
+		if row.colorant > 0 {
+			device, _, _ := strings.Cut(index, ".")
+			colorant := strings.ToLower(colorantNames[fmt.Sprintf("%s.%d", device, row.colorant)])
+			supply.Color = snmpColorantColors[colorant]
+
+			if supply.Name == "" {
+				supply.Name = colorant
+			}
+		}
+
+		// not a supply that can be shown in any meaningful way
+		if supply.Name == "" {
+			continue
+		}
+
+		printer.Supplies = append(printer.Supplies, supply)
+	}
+
+	return printer, nil
+}
@@ -0,0 +1,297 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	PrinterServiceOctoPrint = "octoprint"
+	PrinterServiceMoonraker = "moonraker"
+)
+
+const (
+	PrinterStateIdle      = "idle"
+	PrinterStatePrinting  = "printing"
+	PrinterStatePaused    = "paused"
+	PrinterStateComplete  = "complete"
+	PrinterStateCancelled = "cancelled"
+	PrinterStateError     = "error"
+	PrinterStateOffline   = "offline"
+)
+
+var ErrPrinterActionNotAllowed = errors.New("the printer can't do that right now")
+
+// Makes requests to the API of OctoPrint or Moonraker, the latter of which is what
+// Klipper based printers run and doesn't require an API key unless set up to
+type PrinterClient struct {
+	Service string
+	URL     string
+	APIKey  string
+	Client  RequestDoer
+}
+
+type PrinterTemperature struct {
+	Actual float64
+	Target float64
+}
+
+type PrinterStatus struct {
+	State    string
+	FileName string
+	// from 0 to 100
+	Progress  float64
+	Elapsed   time.Duration
+	Remaining time.Duration
+	Nozzle    PrinterTemperature
+	Bed       PrinterTemperature
+}
+
+func (s *PrinterStatus) StateLabel() string {
+	switch s.State {
+	case PrinterStatePrinting:
+		return "Printing"
+	case PrinterStatePaused:
+		return "Paused"
+	case PrinterStateComplete:
+		return "Finished"
+	case PrinterStateCancelled:
+		return "Cancelled"
+	case PrinterStateError:
+		return "Error"
+	case PrinterStateOffline:
+		return "Offline"
+	}
+
+	return "Idle"
+}
+
+func (s *PrinterStatus) HasJob() bool {
+	return s.State == PrinterStatePrinting || s.State == PrinterStatePaused
+}
+
+type octoPrintJobJson struct {
+	State string `json:"state"`
+	Job   struct {
+		File struct {
+			Name string `json:"name"`
+		} `json:"file"`
+	} `json:"job"`
+	Progress struct {
+		Completion    *float64 `json:"completion"`
+		PrintTime     *int     `json:"printTime"`
+		PrintTimeLeft *int     `json:"printTimeLeft"`
+	} `json:"progress"`
+}
+
+type octoPrintTemperatureJson struct {
+	Actual float64 `json:"actual"`
+	Target float64 `json:"target"`
+}
+
+type octoPrintPrinterJson struct {
+	Temperature struct {
+		Tool0 octoPrintTemperatureJson `json:"tool0"`
+		Bed   octoPrintTemperatureJson `json:"bed"`
+	} `json:"temperature"`
+}
+
+type moonrakerTemperatureJson struct {
+	Temperature float64 `json:"temperature"`
+	Target      float64 `json:"target"`
+}
+
+type moonrakerQueryJson struct {
+	Result struct {
+		Status struct {
+			PrintStats struct {
+				State         string  `json:"state"`
+				Filename      string  `json:"filename"`
+				PrintDuration float64 `json:"print_duration"`
+			} `json:"print_stats"`
+			VirtualSDCard struct {
+				Progress float64 `json:"progress"`
+			} `json:"virtual_sdcard"`
+			Extruder  moonrakerTemperatureJson `json:"extruder"`
+			HeaterBed moonrakerTemperatureJson `json:"heater_bed"`
+		} `json:"status"`
+	} `json:"result"`
+}
+
+func (c *PrinterClient) newRequest(ctx context.Context, method, path string, body any) *http.Request {
+	var reader io.Reader
+
+	if body != nil {
+		encoded, _ := json.Marshal(body)
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, reader)
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.APIKey != "" {
+		request.Header.Set("X-Api-Key", c.APIKey)
+	}
+
+	return request
+}
+
+func (c *PrinterClient) FetchStatus(ctx context.Context) (*PrinterStatus, error) {
+	if c.Service == PrinterServiceOctoPrint {
+		return c.fetchOctoPrintStatus(ctx)
+	}
+
+	return c.fetchMoonrakerStatus(ctx)
+}
+
+func (c *PrinterClient) fetchOctoPrintStatus(ctx context.Context) (*PrinterStatus, error) {
+	client := clientOrDefault(c.Client)
+	job, err := decodeJsonFromRequest[octoPrintJobJson](client, c.newRequest(ctx, "GET", "/api/job", nil))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get job: %v", ErrNoContent, err)
+	}
+
+	status := &PrinterStatus{State: octoPrintState(job.State)}
+
+	if status.State == PrinterStateOffline {
+		return status, nil
+	}
+
+	if status.HasJob() {
+		status.FileName = job.Job.File.Name
+
+		if job.Progress.Completion != nil {
+			status.Progress = *job.Progress.Completion
+		}
+
+		if job.Progress.PrintTime != nil {
+			status.Elapsed = time.Duration(*job.Progress.PrintTime) * time.Second
+		}
+
+		if job.Progress.PrintTimeLeft != nil {
+			status.Remaining = time.Duration(*job.Progress.PrintTimeLeft) * time.Second
+		}
+	}
+
+	printer, err := decodeJsonFromRequest[octoPrintPrinterJson](client, c.newRequest(ctx, "GET", "/api/printer?exclude=sd,state", nil))
+
+	if err != nil {
+		return status, fmt.Errorf("%w: could not get temperatures: %v", ErrPartialContent, err)
+	}
+
+	status.Nozzle = PrinterTemperature(printer.Temperature.Tool0)
+	status.Bed = PrinterTemperature(printer.Temperature.Bed)
+
+	return status, nil
+}
+
+// The state is a human readable text, such as "Printing from SD" or "Offline after error"
+func octoPrintState(state string) string {
+	switch {
+	case strings.HasPrefix(state, "Offline"), state == "Closed", state == "Connecting":
+		return PrinterStateOffline
+	case strings.HasPrefix(state, "Error"):
+		return PrinterStateError
+	case strings.HasPrefix(state, "Paus"):
+		return PrinterStatePaused
+	case strings.HasPrefix(state, "Printing"), strings.HasPrefix(state, "Sending"), state == "Cancelling", state == "Resuming", state == "Starting", state == "Finishing":
+		return PrinterStatePrinting
+	}
+
+	return PrinterStateIdle
+}
+
+func (c *PrinterClient) fetchMoonrakerStatus(ctx context.Context) (*PrinterStatus, error) {
+	request := c.newRequest(ctx, "GET", "/printer/objects/query?print_stats&virtual_sdcard&extruder&heater_bed", nil)
+	response, err := decodeJsonFromRequest[moonrakerQueryJson](clientOrDefault(c.Client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get printer status: %v", ErrNoContent, err)
+	}
+
+	stats := &response.Result.Status
+	status := &PrinterStatus{
+		Nozzle: PrinterTemperature{Actual: stats.Extruder.Temperature, Target: stats.Extruder.Target},
+		Bed:    PrinterTemperature{Actual: stats.HeaterBed.Temperature, Target: stats.HeaterBed.Target},
+	}
+
+	switch stats.PrintStats.State {
+	case "printing", "paused", "complete", "cancelled", "error":
+		status.State = stats.PrintStats.State
+	default:
+		status.State = PrinterStateIdle
+	}
+
+	if status.HasJob() {
+		status.FileName = stats.PrintStats.Filename
+		status.Progress = stats.VirtualSDCard.Progress * 100
+		status.Elapsed = time.Duration(stats.PrintStats.PrintDuration * float64(time.Second))
+
+		// estimated from how long the progress so far took, the same way Mainsail and Fluidd do by default
+		if progress := stats.VirtualSDCard.Progress; progress > 0 {
+			status.Remaining = time.Duration(float64(status.Elapsed)/progress) - status.Elapsed
+		}
+	}
+
+	return status, nil
+}
+
+// Pauses, resumes or cancels the current print job, the action being one of "pause",
+// "resume" or "cancel". ErrPrinterActionNotAllowed is returned when the printer is
+// not in a state where the action makes sense, such as pausing when it's not printing
+func (c *PrinterClient) ControlJob(ctx context.Context, action string) error {
+	var request *http.Request
+
+	if c.Service == PrinterServiceOctoPrint {
+		body := map[string]string{"command": action}
+
+		if action != "cancel" {
+			body = map[string]string{"command": "pause", "action": action}
+		}
+
+		request = c.newRequest(ctx, "POST", "/api/job", body)
+	} else {
+		request = c.newRequest(ctx, "POST", "/printer/print/"+action, nil)
+	}
+
+	response, err := clientOrDefault(c.Client).Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	// octoprint responds with 409 and moonraker with 400 when the state doesn't allow it
+	if response.StatusCode == http.StatusConflict || (c.Service == PrinterServiceMoonraker && response.StatusCode == http.StatusBadRequest) {
+		return ErrPrinterActionNotAllowed
+	}
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// Fetches a still image from the webcam of the printer, returning it along with its content type
+func (c *PrinterClient) FetchSnapshot(ctx context.Context, snapshotURL string) (string, []byte, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", snapshotURL, nil)
+
+	if c.APIKey != "" && strings.HasPrefix(snapshotURL, c.URL) {
+		request.Header.Set("X-Api-Key", c.APIKey)
+	}
+
+	return fetchSnapshot(clientOrDefault(c.Client), request)
+}
@@ -0,0 +1,124 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Services which respond with the address that the request came from, as plain text.
+// The second one only has an AAAA record so that it's always reached over IPv6
+const (
+	publicIPv4URL = "https://api.ipify.org"
+	publicIPv6URL = "https://api6.ipify.org"
+)
+
+// Finds the public IPv4 address of the server or its IPv6 one when ipv6 is true
+func FetchPublicIP(ctx context.Context, ipv6 bool) (netip.Addr, error) {
+	url := publicIPv4URL
+
+	if ipv6 {
+		url = publicIPv6URL
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	response, err := defaultClient.Do(request)
+
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 64))
+
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	address, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid address from %s: %v", url, err)
+	}
+
+	if address.Is4() == ipv6 {
+		return netip.Addr{}, fmt.Errorf("%s responded with an address of the wrong family", url)
+	}
+
+	return address, nil
+}
+
+// Looks the address up on DNS based blocklists such as zen.spamhaus.org, returning the
+// ones which have it listed. The lists answer with an address in 127.0.0.0/8 for the
+// addresses that are on them and with nothing for the ones that aren't
+func CheckDNSBlocklists(ctx context.Context, address netip.Addr, blocklists []string) ([]string, error) {
+	listedOn := make([]string, 0)
+	var errs []error
+
+	for _, blocklist := range blocklists {
+		answers, err := net.DefaultResolver.LookupHost(ctx, dnsblQueryName(address)+"."+blocklist)
+
+		if err != nil {
+			var dnsErr *net.DNSError
+
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				continue
+			}
+
+			errs = append(errs, fmt.Errorf("%s: %v", blocklist, err))
+			continue
+		}
+
+		listed := false
+
+		for _, answer := range answers {
+			// Spamhaus and others answer with 127.255.255.x when they refuse to answer the
+			// query, which happens when it's made through a public resolver
+			if strings.HasPrefix(answer, "127.255.255.") {
+				errs = append(errs, fmt.Errorf("%s: query was refused with %s", blocklist, answer))
+				listed = false
+				break
+			}
+
+			if strings.HasPrefix(answer, "127.") {
+				listed = true
+			}
+		}
+
+		if listed {
+			listedOn = append(listedOn, blocklist)
+		}
+	}
+
+	if len(errs) > 0 {
+		return listedOn, errors.Join(errs...)
+	}
+
+	return listedOn, nil
+}
+
+// The octets of IPv4 addresses and the nibbles of IPv6 ones in reverse order
+func dnsblQueryName(address netip.Addr) string {
+	bytes := address.AsSlice()
+	parts := make([]string, 0, len(bytes)*2)
+
+	for i := len(bytes) - 1; i >= 0; i-- {
+		if address.Is4() {
+			parts = append(parts, strconv.Itoa(int(bytes[i])))
+		} else {
+			parts = append(parts, strconv.FormatUint(uint64(bytes[i]&0xf), 16), strconv.FormatUint(uint64(bytes[i]>>4), 16))
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
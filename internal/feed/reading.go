@@ -0,0 +1,355 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	ReadingSourceGoodreads  = "goodreads"
+	ReadingSourceStoryGraph = "storygraph"
+)
+
+const (
+	goodreadsEndpoint    = "https://www.goodreads.com"
+	openLibraryEndpoint  = "https://openlibrary.org"
+	openLibraryCovers    = "https://covers.openlibrary.org"
+	maxReadingExportSize = 16 << 20
+)
+
+type Book struct {
+	Title  string
+	Author string
+	URL    string
+	Cover  string
+	isbn   string
+	// 0 when not known
+	Page  int
+	Pages int
+	// -1 when not known
+	Percent int
+}
+
+// Goodreads only has the progress in the feed of the user's updates, with titles such as
+// "Alex is on page 120 of 350 of The Book" or "Alex is 45% done with The Book"
+var (
+	goodreadsPageUpdatePattern    = regexp.MustCompile(`is on page (\d+) of (\d+) of (.+)$`)
+	goodreadsPercentUpdatePattern = regexp.MustCompile(`is (\d+)% done with (.+)$`)
+)
+
+// The source is the user ID for Goodreads and the path or URL of the export for StoryGraph
+func FetchCurrentlyReading(ctx context.Context, client RequestDoer, source, location string) ([]Book, error) {
+	client = clientOrDefault(client)
+
+	var books []Book
+	var err error
+
+	switch source {
+	case ReadingSourceGoodreads:
+		books, err = fetchGoodreadsCurrentlyReading(ctx, client, location)
+	case ReadingSourceStoryGraph:
+		books, err = fetchStoryGraphCurrentlyReading(ctx, client, location)
+	default:
+		return nil, fmt.Errorf("unknown source %q", source)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	addOpenLibraryCovers(ctx, client, books)
+
+	return books, nil
+}
+
+func fetchGoodreadsCurrentlyReading(ctx context.Context, client RequestDoer, userID string) ([]Book, error) {
+	shelf, err := fetchGoodreadsFeed(ctx, client, "/review/list_rss/"+url.PathEscape(userID)+"?shelf=currently-reading")
+
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]Book, 0, len(shelf.Items))
+
+	for _, item := range shelf.Items {
+		book := Book{
+			Title:   strings.TrimSpace(item.Title),
+			Author:  item.Custom["author_name"],
+			URL:     item.Link,
+			Cover:   item.Custom["book_medium_image_url"],
+			isbn:    item.Custom["isbn"],
+			Percent: -1,
+		}
+
+		// books without a cover have a placeholder image
+		if strings.Contains(book.Cover, "nophoto") {
+			book.Cover = ""
+		}
+
+		books = append(books, book)
+	}
+
+	if len(books) == 0 {
+		return books, nil
+	}
+
+	updates, err := fetchGoodreadsFeed(ctx, client, "/user/updates_rss/"+url.PathEscape(userID))
+
+	if err != nil {
+		slog.Error("Failed to fetch Goodreads updates", "error", err)
+		return books, nil
+	}
+
+	// the updates are sorted from newest to oldest, so the first one for each book is its progress
+	for _, item := range updates.Items {
+		var title string
+		var page, pages, percent int
+
+		if match := goodreadsPageUpdatePattern.FindStringSubmatch(item.Title); match != nil {
+			page, _ = strconv.Atoi(match[1])
+			pages, _ = strconv.Atoi(match[2])
+			title = match[3]
+
+			if pages > 0 {
+				percent = min(page*100/pages, 100)
+			}
+		} else if match := goodreadsPercentUpdatePattern.FindStringSubmatch(item.Title); match != nil {
+			percent, _ = strconv.Atoi(match[1])
+			title = match[2]
+		} else {
+			continue
+		}
+
+		for i := range books {
+			if books[i].Percent == -1 && sameBookTitle(books[i].Title, title) {
+				books[i].Page = page
+				books[i].Pages = pages
+				books[i].Percent = percent
+				break
+			}
+		}
+	}
+
+	return books, nil
+}
+
+// Titles on the shelf include the series, e.g. "The Book (The Series, #1)", which
+// the updates may leave out
+func sameBookTitle(shelf, update string) bool {
+	shelf = strings.ToLower(strings.TrimSpace(shelf))
+	update = strings.ToLower(strings.TrimSpace(update))
+
+	return shelf == update || strings.HasPrefix(shelf, update+" (") || strings.HasPrefix(update, shelf+" (")
+}
+
+func fetchGoodreadsFeed(ctx context.Context, client RequestDoer, path string) (*gofeed.Feed, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", goodreadsEndpoint+path, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addBrowserUserAgentHeader(request)
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from Goodreads, the profile may be private", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxReadingExportSize))
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := feedParser.ParseString(string(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// The export is a CSV file with a row for each book, which has to be downloaded from
+// StoryGraph's settings since it doesn't have an API
+func fetchStoryGraphCurrentlyReading(ctx context.Context, client RequestDoer, location string) ([]Book, error) {
+	var contents []byte
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		request, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := client.Do(request)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch export: %v", err)
+		}
+
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("could not fetch export: unexpected status code %d", response.StatusCode)
+		}
+
+		contents, err = io.ReadAll(io.LimitReader(response.Body, maxReadingExportSize))
+
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch export: %v", err)
+		}
+	} else {
+		file, err := os.Open(location)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read export: %v", err)
+		}
+
+		defer file.Close()
+
+		contents, err = io.ReadAll(io.LimitReader(file, maxReadingExportSize))
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read export: %v", err)
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(contents))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse export: %v", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("export is empty")
+	}
+
+	columns := make(map[string]int, len(rows[0]))
+
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"title", "authors", "read status"} {
+		if _, exists := columns[name]; !exists {
+			return nil, fmt.Errorf("export is missing the %s column", name)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, exists := columns[name]; exists && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+
+		return ""
+	}
+
+	var books []Book
+
+	for _, row := range rows[1:] {
+		if field(row, "read status") != "currently-reading" {
+			continue
+		}
+
+		books = append(books, Book{
+			Title:   field(row, "title"),
+			Author:  field(row, "authors"),
+			isbn:    field(row, "isbn/uid"),
+			Percent: -1,
+		})
+	}
+
+	return books, nil
+}
+
+// Looks up the covers of the books which don't have one on Open Library, using the ISBN when
+// there is one and searching for the title and author otherwise
+func addOpenLibraryCovers(ctx context.Context, client RequestDoer, books []Book) {
+	var pending []*Book
+
+	for i := range books {
+		if books[i].Cover != "" {
+			continue
+		}
+
+		// StoryGraph has its own IDs for books without an ISBN
+		isbn := strings.ReplaceAll(books[i].isbn, "-", "")
+
+		if len(isbn) == 10 || len(isbn) == 13 {
+			books[i].Cover = openLibraryCovers + "/b/isbn/" + isbn + "-M.jpg"
+			continue
+		}
+
+		pending = append(pending, &books[i])
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	task := func(ctx context.Context, book *Book) (string, error) {
+		query := url.Values{}
+		query.Set("title", book.Title)
+		query.Set("author", book.Author)
+		query.Set("fields", "cover_i")
+		query.Set("limit", "1")
+
+		request, err := http.NewRequestWithContext(ctx, "GET", openLibraryEndpoint+"/search.json?"+query.Encode(), nil)
+
+		if err != nil {
+			return "", err
+		}
+
+		body, _, err := fetchRedactedJson(client, request)
+
+		if err != nil {
+			return "", err
+		}
+
+		if id := gjson.Get(body, "docs.0.cover_i").Int(); id > 0 {
+			return openLibraryCovers + "/b/id/" + strconv.FormatInt(id, 10) + "-M.jpg", nil
+		}
+
+		return "", nil
+	}
+
+	job := newJob(taskWithContext(ctx, task), pending).withContext(ctx)
+	covers, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return
+	}
+
+	for i := range covers {
+		if errs[i] != nil {
+			slog.Error("Failed to look up book cover", "title", pending[i].Title, "error", errs[i])
+			continue
+		}
+
+		pending[i].Cover = covers[i]
+	}
+}
@@ -1,8 +1,10 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"html"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -44,38 +46,50 @@ func templateRedditCommentsURL(template, subreddit, postId, postPath string) str
 	return template
 }
 
-func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate, requestUrlTemplate string, showFlairs bool) (ForumPosts, error) {
+type SubredditPostsRequest struct {
+	Subreddit           string
+	SortBy              string
+	TopPeriod           string
+	Search              string
+	CommentsUrlTemplate string
+	RequestUrlTemplate  string
+	ShowFlairs          bool
+	Limit               int
+	Client              RequestDoer
+}
+
+func FetchSubredditPosts(ctx context.Context, request SubredditPostsRequest) (ForumPosts, error) {
 	query := url.Values{}
 	var requestUrl string
 
-	if search != "" {
-		query.Set("q", search+" subreddit:"+subreddit)
-		query.Set("sort", sort)
+	if request.Search != "" {
+		query.Set("q", request.Search+" subreddit:"+request.Subreddit)
+		query.Set("sort", request.SortBy)
 	}
 
-	if sort == "top" {
-		query.Set("t", topPeriod)
+	if request.SortBy == "top" {
+		query.Set("t", request.TopPeriod)
 	}
 
-	if search != "" {
+	if request.Search != "" {
 		requestUrl = fmt.Sprintf("https://www.reddit.com/search.json?%s", query.Encode())
 	} else {
-		requestUrl = fmt.Sprintf("https://www.reddit.com/r/%s/%s.json?%s", subreddit, sort, query.Encode())
+		requestUrl = fmt.Sprintf("https://www.reddit.com/r/%s/%s.json?%s", request.Subreddit, request.SortBy, query.Encode())
 	}
 
-	if requestUrlTemplate != "" {
-		requestUrl = strings.ReplaceAll(requestUrlTemplate, "{REQUEST-URL}", requestUrl)
+	if request.RequestUrlTemplate != "" {
+		requestUrl = strings.ReplaceAll(request.RequestUrlTemplate, "{REQUEST-URL}", requestUrl)
 	}
 
-	request, err := http.NewRequest("GET", requestUrl, nil)
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
 	// Required to increase rate limit, otherwise Reddit randomly returns 429 even after just 2 requests
-	addBrowserUserAgentHeader(request)
-	responseJson, err := decodeJsonFromRequest[subredditResponseJson](defaultClient, request)
+	addBrowserUserAgentHeader(httpRequest)
+	responseJson, err := decodeJsonFromRequest[subredditResponseJson](clientOrDefault(request.Client), httpRequest)
 
 	if err != nil {
 		return nil, err
@@ -96,10 +110,10 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 
 		var commentsUrl string
 
-		if commentsUrlTemplate == "" {
+		if request.CommentsUrlTemplate == "" {
 			commentsUrl = "https://www.reddit.com" + post.Permalink
 		} else {
-			commentsUrl = templateRedditCommentsURL(commentsUrlTemplate, subreddit, post.Id, post.Permalink)
+			commentsUrl = templateRedditCommentsURL(request.CommentsUrlTemplate, request.Subreddit, post.Id, post.Permalink)
 		}
 
 		forumPost := ForumPost{
@@ -119,7 +133,7 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 			forumPost.TargetUrl = post.Url
 		}
 
-		if showFlairs && post.Flair != "" {
+		if request.ShowFlairs && post.Flair != "" {
 			forumPost.Tags = append(forumPost.Tags, post.Flair)
 		}
 
@@ -127,11 +141,11 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 			forumPost.IsCrosspost = true
 			forumPost.TargetUrlDomain = "r/" + post.ParentList[0].Subreddit
 
-			if commentsUrlTemplate == "" {
+			if request.CommentsUrlTemplate == "" {
 				forumPost.TargetUrl = "https://www.reddit.com" + post.ParentList[0].Permalink
 			} else {
 				forumPost.TargetUrl = templateRedditCommentsURL(
-					commentsUrlTemplate,
+					request.CommentsUrlTemplate,
 					post.ParentList[0].Subreddit,
 					post.ParentList[0].Id,
 					post.ParentList[0].Permalink,
@@ -142,5 +156,47 @@ func FetchSubredditPosts(subreddit, sort, topPeriod, search, commentsUrlTemplate
 		posts = append(posts, forumPost)
 	}
 
+	if request.Limit > 0 && len(posts) > request.Limit {
+		posts = posts[:request.Limit]
+	}
+
+	return posts, nil
+}
+
+// Fetches the posts of each subreddit separately and merges them, the
+// order of the merged posts is left up to the caller to decide
+func FetchPostsFromSubreddits(ctx context.Context, requests []SubredditPostsRequest) (ForumPosts, error) {
+	if len(requests) == 1 {
+		return FetchSubredditPosts(ctx, requests[0])
+	}
+
+	job := newJob(taskWithContext(ctx, FetchSubredditPosts), requests).withWorkers(5).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	posts := make(ForumPosts, 0, len(requests)*25)
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			slog.Error("Failed to fetch subreddit posts", "subreddit", requests[i].Subreddit, "error", errs[i])
+			continue
+		}
+
+		posts = append(posts, results[i]...)
+	}
+
+	if failed == len(requests) {
+		return nil, ErrNoContent
+	}
+
+	if failed > 0 {
+		return posts, fmt.Errorf("%w: could not fetch posts from %d subreddit(s)", ErrPartialContent, failed)
+	}
+
 	return posts, nil
 }
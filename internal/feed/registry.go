@@ -0,0 +1,353 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	RegistryProviderDistribution = "registry"
+	RegistryProviderHarbor       = "harbor"
+)
+
+// The catalog is paginated, registries with more repositories than
+// this get reported as having this many
+const registryMaxCatalogPages = 20
+
+var registryNextPagePattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+type RegistryPull struct {
+	Repository string
+	// the tag or the digest that got pulled
+	Reference string
+	// who pulled it, either a user or the address of the client
+	By   string
+	Time time.Time
+}
+
+// Digests are separated from the repository with an @ rather than a colon
+func (p RegistryPull) ReferenceSeparator() string {
+	if strings.Contains(p.Reference, ":") {
+		return "@"
+	}
+
+	return ":"
+}
+
+type RegistryRepository struct {
+	Name  string
+	Pulls int
+}
+
+type RegistryStats struct {
+	Repositories int
+	StorageUsed  uint64
+	// how much the storage can hold, based on the free space of the filesystem
+	// it's on, zero when there's no storage path or the filesystem can't be read
+	StorageTotal uint64
+	Pulls        []RegistryPull
+	// the most pulled repositories, only available with Harbor
+	TopRepositories []RegistryRepository
+}
+
+func (s *RegistryStats) StorageUsedPercent() float64 {
+	if s.StorageTotal == 0 {
+		return 0
+	}
+
+	return float64(s.StorageUsed) / float64(s.StorageTotal) * 100
+}
+
+type RegistryRequest struct {
+	Provider string
+	URL      string
+	Username string
+	Password string
+	// the directory the registry stores its data in, which has to be
+	// accessible to Glance for its size and capacity to be known
+	StoragePath       string
+	PullsLimit        int
+	RepositoriesLimit int
+	Client            RequestDoer
+}
+
+func FetchRegistryStats(ctx context.Context, request *RegistryRequest) (*RegistryStats, error) {
+	var stats *RegistryStats
+	var err error
+
+	switch request.Provider {
+	case RegistryProviderHarbor:
+		stats, err = fetchHarborStats(ctx, request)
+	default:
+		stats, err = fetchDistributionStats(ctx, request)
+	}
+
+	if stats == nil {
+		return nil, err
+	}
+
+	if request.StoragePath != "" {
+		if storageErr := readRegistryStorage(request, stats); storageErr != nil {
+			err = errors.Join(err, fmt.Errorf("%w: could not read storage: %v", ErrPartialContent, storageErr))
+		}
+	}
+
+	return stats, err
+}
+
+func readRegistryStorage(request *RegistryRequest, stats *RegistryStats) error {
+	// Harbor knows how much its artifacts take up, the size of the directory
+	// only gets used for registries which don't report it
+	if request.Provider != RegistryProviderHarbor {
+		size, err := directorySize(request.StoragePath)
+
+		if err != nil {
+			return err
+		}
+
+		stats.StorageUsed = size
+	}
+
+	filesystem := readMountpointsStats([]string{request.StoragePath})
+
+	if len(filesystem) == 1 && filesystem[0].TotalBytes > filesystem[0].UsedBytes {
+		stats.StorageTotal = stats.StorageUsed + filesystem[0].TotalBytes - filesystem[0].UsedBytes
+	}
+
+	return nil
+}
+
+func directorySize(path string) (uint64, error) {
+	var size uint64
+
+	err := filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+
+			if err != nil {
+				return err
+			}
+
+			size += uint64(info.Size())
+		}
+
+		return nil
+	})
+
+	return size, err
+}
+
+func newRegistryRequest(ctx context.Context, request *RegistryRequest, requestURL string) (*http.Request, error) {
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	return httpRequest, nil
+}
+
+// The registry API doesn't say how much is pulled, the pulls come from the
+// notifications the registry sends to the widget instead
+func fetchDistributionStats(ctx context.Context, request *RegistryRequest) (*RegistryStats, error) {
+	client := clientOrDefault(request.Client)
+	baseURL := strings.TrimRight(request.URL, "/")
+	next := "/v2/_catalog?n=1000"
+	stats := &RegistryStats{}
+
+	for range registryMaxCatalogPages {
+		// the link to the next page is usually relative but can also be absolute
+		if !strings.HasPrefix(next, "http://") && !strings.HasPrefix(next, "https://") {
+			next = baseURL + next
+		}
+
+		httpRequest, err := newRegistryRequest(ctx, request, next)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		response, err := client.Do(httpRequest)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%w: unexpected status code %d from catalog", ErrNoContent, response.StatusCode)
+		}
+
+		stats.Repositories += len(gjson.GetBytes(body, "repositories").Array())
+		match := registryNextPagePattern.FindStringSubmatch(response.Header.Get("Link"))
+
+		if match == nil {
+			break
+		}
+
+		next = match[1]
+	}
+
+	return stats, nil
+}
+
+func fetchHarborStats(ctx context.Context, request *RegistryRequest) (*RegistryStats, error) {
+	client := clientOrDefault(request.Client)
+	apiURL := strings.TrimRight(request.URL, "/") + "/api/v2.0"
+
+	var statistics, pulls, repositories string
+	var statisticsErr, pullsErr, repositoriesErr error
+	var wg sync.WaitGroup
+
+	fetch := func(path string, body *string, err *error) {
+		defer wg.Done()
+		httpRequest, requestErr := newRegistryRequest(ctx, request, apiURL+path)
+
+		if requestErr != nil {
+			*err = requestErr
+			return
+		}
+
+		*body, _, *err = fetchRedactedJson(client, httpRequest)
+	}
+
+	wg.Add(1)
+	go fetch("/statistics", &statistics, &statisticsErr)
+
+	if request.PullsLimit > 0 {
+		query := url.Values{}
+		query.Set("q", "operation=pull")
+		query.Set("sort", "-op_time")
+		query.Set("page_size", fmt.Sprint(request.PullsLimit))
+
+		wg.Add(1)
+		go fetch("/audit-logs?"+query.Encode(), &pulls, &pullsErr)
+	}
+
+	if request.RepositoriesLimit > 0 {
+		wg.Add(1)
+		go fetch(fmt.Sprintf("/repositories?sort=-pull_count&page_size=%d", request.RepositoriesLimit), &repositories, &repositoriesErr)
+	}
+
+	wg.Wait()
+
+	if statisticsErr != nil {
+		return nil, fmt.Errorf("%w: could not get statistics: %v", ErrNoContent, statisticsErr)
+	}
+
+	stats := &RegistryStats{
+		Repositories: int(gjson.Get(statistics, "total_repo_count").Int()),
+		StorageUsed:  gjson.Get(statistics, "total_storage_consumption").Uint(),
+	}
+
+	for _, entry := range gjson.Get(pulls, "@this").Array() {
+		// resources look like library/nginx:latest or library/nginx@sha256:...
+		resource := entry.Get("resource").String()
+		repository, reference := resource, ""
+
+		if at := strings.LastIndex(resource, "@"); at != -1 {
+			repository, reference = resource[:at], resource[at+1:]
+		} else if colon := strings.LastIndex(resource, ":"); colon > strings.LastIndex(resource, "/") {
+			repository, reference = resource[:colon], resource[colon+1:]
+		}
+
+		stats.Pulls = append(stats.Pulls, RegistryPull{
+			Repository: repository,
+			Reference:  reference,
+			By:         entry.Get("username").String(),
+			Time:       parseRFC3339Time(entry.Get("op_time").String()),
+		})
+	}
+
+	for _, repository := range gjson.Get(repositories, "@this").Array() {
+		stats.TopRepositories = append(stats.TopRepositories, RegistryRepository{
+			Name:  repository.Get("name").String(),
+			Pulls: int(repository.Get("pull_count").Int()),
+		})
+	}
+
+	var failed []string
+
+	if pullsErr != nil {
+		failed = append(failed, "pulls")
+	}
+
+	if repositoriesErr != nil {
+		failed = append(failed, "repositories")
+	}
+
+	if len(failed) > 0 {
+		return stats, fmt.Errorf(
+			"%w: could not get %s: %v",
+			ErrPartialContent,
+			strings.Join(failed, ", "),
+			errors.Join(pullsErr, repositoriesErr),
+		)
+	}
+
+	return stats, nil
+}
+
+// Parses the notifications sent by the registry, of which only the pulls of
+// manifests are kept since pulling an image also pulls each of its layers
+func ParseRegistryNotifications(body []byte) []RegistryPull {
+	var pulls []RegistryPull
+
+	for _, event := range gjson.GetBytes(body, "events").Array() {
+		if event.Get("action").String() != "pull" || !strings.Contains(event.Get("target.mediaType").String(), "manifest") {
+			continue
+		}
+
+		reference := event.Get("target.tag").String()
+
+		if reference == "" {
+			reference = event.Get("target.digest").String()
+		}
+
+		by := event.Get("actor.name").String()
+
+		if by == "" {
+			by = event.Get("request.addr").String()
+
+			if host, _, err := net.SplitHostPort(by); err == nil {
+				by = host
+			}
+		}
+
+		pulls = append(pulls, RegistryPull{
+			Repository: event.Get("target.repository").String(),
+			Reference:  reference,
+			By:         by,
+			Time:       parseRFC3339Time(event.Get("timestamp").String()),
+		})
+	}
+
+	return pulls
+}
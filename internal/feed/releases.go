@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,6 +14,8 @@ const (
 	ReleaseSourceGithub    ReleaseSource = "github"
 	ReleaseSourceGitlab    ReleaseSource = "gitlab"
 	ReleaseSourceDockerHub ReleaseSource = "dockerhub"
+	// self-hosted Gitea and Forgejo instances
+	ReleaseSourceGitea ReleaseSource = "gitea"
 )
 
 type ReleaseRequest struct {
@@ -21,8 +24,8 @@ type ReleaseRequest struct {
 	Token      *string
 }
 
-func FetchLatestReleases(requests []*ReleaseRequest) (AppReleases, error) {
-	job := newJob(fetchLatestReleaseTask, requests).withWorkers(20)
+func FetchLatestReleases(ctx context.Context, requests []*ReleaseRequest) (AppReleases, error) {
+	job := newJob(taskWithContext(ctx, fetchLatestReleaseTask), requests).withWorkers(20).withContext(ctx)
 	results, errs, err := workerPoolDo(job)
 
 	if err != nil {
@@ -56,16 +59,18 @@ func FetchLatestReleases(requests []*ReleaseRequest) (AppReleases, error) {
 	return releases, nil
 }
 
-func fetchLatestReleaseTask(request *ReleaseRequest) (*AppRelease, error) {
+func fetchLatestReleaseTask(ctx context.Context, request *ReleaseRequest) (*AppRelease, error) {
 	switch request.Source {
 	case ReleaseSourceCodeberg:
-		return fetchLatestCodebergRelease(request)
+		return fetchLatestCodebergRelease(ctx, request)
 	case ReleaseSourceGithub:
-		return fetchLatestGithubRelease(request)
+		return fetchLatestGithubRelease(ctx, request)
 	case ReleaseSourceGitlab:
-		return fetchLatestGitLabRelease(request)
+		return fetchLatestGitLabRelease(ctx, request)
 	case ReleaseSourceDockerHub:
-		return fetchLatestDockerHubRelease(request)
+		return fetchLatestDockerHubRelease(ctx, request)
+	case ReleaseSourceGitea:
+		return fetchLatestGiteaRelease(ctx, request)
 	}
 
 	return nil, errors.New("unsupported source")
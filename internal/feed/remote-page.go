@@ -0,0 +1,193 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type RemoteWidget struct {
+	ID    uint64 `json:"id"`
+	Slug  string `json:"slug"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	// the widget as rendered by the other instance, which gets sanitized once fetched
+	HTML string `json:"html"`
+}
+
+type RemotePage struct {
+	Slug    string         `json:"slug"`
+	Title   string         `json:"title"`
+	Widgets []RemoteWidget `json:"widgets"`
+}
+
+// The elements used by the templates of widgets which can't run scripts or load other documents.
+// Anything else gets removed along with its contents, since e.g. the text of a script tag would
+// otherwise end up being shown
+var remoteHTMLAllowedElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Blockquote: true, atom.Br: true,
+	atom.Button: true, atom.Code: true, atom.Del: true, atom.Details: true, atom.Div: true,
+	atom.Em: true, atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.I: true, atom.Img: true, atom.Input: true, atom.Ins: true, atom.Kbd: true,
+	atom.Label: true, atom.Li: true, atom.Mark: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.S: true, atom.Section: true, atom.Small: true, atom.Span: true,
+	atom.Strong: true, atom.Sub: true, atom.Summary: true, atom.Sup: true, atom.Table: true,
+	atom.Tbody: true, atom.Td: true, atom.Th: true, atom.Thead: true, atom.Time: true, atom.Tr: true,
+	atom.U: true, atom.Ul: true, atom.Svg: true,
+}
+
+// SVG elements aren't atoms, only the ones that draw something are allowed
+var remoteHTMLAllowedSVGElements = map[string]bool{
+	"svg": true, "g": true, "path": true, "polyline": true, "polygon": true, "line": true,
+	"circle": true, "ellipse": true, "rect": true, "text": true, "tspan": true,
+}
+
+// Gets the widgets of a page from another instance of Glance which has its federation-token set.
+// Since the HTML of the widgets gets shown as part of this instance, anything in it which could run
+// scripts is removed, so that the other instance can't make requests to this one as the user
+func FetchRemotePage(ctx context.Context, client RequestDoer, baseURL, token, page string) (*RemotePage, error) {
+	base, err := url.Parse(strings.TrimRight(baseURL, "/") + "/")
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		strings.TrimRight(baseURL, "/")+"/api/federation/pages/"+url.PathEscape(page),
+		nil,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := decodeJsonFromRequest[RemotePage](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	for i := range response.Widgets {
+		sanitized, err := sanitizeRemoteHTML(response.Widgets[i].HTML, base)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: sanitizing widget %d: %v", ErrNoContent, response.Widgets[i].ID, err)
+		}
+
+		response.Widgets[i].HTML = sanitized
+	}
+
+	return &response, nil
+}
+
+func sanitizeRemoteHTML(remoteHTML string, base *url.URL) (string, error) {
+	nodes, err := html.ParseFragment(
+		strings.NewReader(remoteHTML),
+		&html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body},
+	)
+
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+
+	for _, node := range nodes {
+		if !sanitizeRemoteHTMLNode(node, base) {
+			continue
+		}
+
+		if err := html.Render(&builder, node); err != nil {
+			return "", err
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// Returns false when the node should be removed
+func sanitizeRemoteHTMLNode(node *html.Node, base *url.URL) bool {
+	switch node.Type {
+	case html.TextNode:
+		return true
+	case html.ElementNode:
+		if node.Namespace == "svg" {
+			if !remoteHTMLAllowedSVGElements[node.Data] {
+				return false
+			}
+		} else if !remoteHTMLAllowedElements[node.DataAtom] {
+			return false
+		}
+	default:
+		return false
+	}
+
+	attributes := node.Attr[:0]
+
+	for _, attribute := range node.Attr {
+		key := strings.ToLower(attribute.Key)
+
+		switch {
+		case strings.HasPrefix(key, "on"), key == "srcdoc", key == "srcset", key == "formaction":
+			continue
+		// the ID belongs to the other instance, leaving it in would make the page send
+		// the requests of interactive widgets to whichever widget has it on this one
+		case key == "data-widget-id":
+			continue
+		case key == "href" || key == "src":
+			resolved, ok := resolveRemoteHTMLURL(attribute.Val, base)
+
+			if !ok {
+				continue
+			}
+
+			attribute.Val = resolved
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	node.Attr = attributes
+
+	for child := node.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if !sanitizeRemoteHTMLNode(child, base) {
+			node.RemoveChild(child)
+		}
+
+		child = next
+	}
+
+	return true
+}
+
+// Relative links point to the other instance, such as the images it proxies
+func resolveRemoteHTMLURL(value string, base *url.URL) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+
+	if err != nil {
+		return "", false
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return value, true
+	}
+
+	resolved := base.ResolveReference(parsed)
+
+	switch resolved.Scheme {
+	case "http", "https", "mailto", "tel":
+		return resolved.String(), true
+	}
+
+	return "", false
+}
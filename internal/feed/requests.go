@@ -2,37 +2,36 @@ package feed
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
+
+	"github.com/tidwall/gjson"
 )
 
 const defaultClientTimeout = 5 * time.Second
 
-var defaultClient = &http.Client{
-	Timeout: defaultClientTimeout,
-}
-
-var insecureClientTransport = &http.Transport{
-	TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-}
-
-var defaultInsecureClient = &http.Client{
-	Timeout:   defaultClientTimeout,
-	Transport: insecureClientTransport,
-}
+var defaultClient RequestDoer = globalClient{}
+var defaultInsecureClient RequestDoer = globalClient{insecure: true}
 
 type RequestDoer interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
+const browserUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:123.0) Gecko/20100101 Firefox/123.0"
+
+// For sources which block requests that don't look like they're coming
+// from a browser, the user agent set in the config takes precedence
 func addBrowserUserAgentHeader(request *http.Request) {
-	request.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:123.0) Gecko/20100101 Firefox/123.0")
+	if globalUserAgent() == "" {
+		request.Header.Set("User-Agent", browserUserAgent)
+	}
 }
 
 func truncateString(s string, maxLen int) string {
@@ -79,6 +78,44 @@ func decodeJsonFromRequest[T any](client RequestDoer, request *http.Request) (T,
 	return result, nil
 }
 
+// Same as decodeJsonFromRequest but leaves the body to be read through gjson and doesn't include
+// the URL in errors, for APIs which take credentials as query parameters
+func fetchRedactedJson(client RequestDoer, request *http.Request) (string, int, error) {
+	response, err := client.Do(request)
+
+	if err != nil {
+		var urlErr *url.Error
+
+		if errors.As(err, &urlErr) {
+			return "", 0, urlErr.Err
+		}
+
+		return "", 0, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 4<<20))
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", response.StatusCode, fmt.Errorf(
+			"unexpected status code %d, response: %s",
+			response.StatusCode,
+			truncateString(string(body), 256),
+		)
+	}
+
+	if !gjson.Valid(string(body)) {
+		return "", response.StatusCode, errors.New("response is not valid JSON")
+	}
+
+	return string(body), response.StatusCode, nil
+}
+
 func decodeJsonFromRequestTask[T any](client RequestDoer) func(*http.Request) (T, error) {
 	return func(request *http.Request) (T, error) {
 		return decodeJsonFromRequest[T](client, request)
@@ -154,13 +191,21 @@ func (job *workerPoolJob[I, O]) withWorkers(workers int) *workerPoolJob[I, O] {
 	return job
 }
 
-// func (job *workerPoolJob[I, O]) withContext(ctx context.Context) *workerPoolJob[I, O] {
-// 	if ctx != nil {
-// 		job.ctx = ctx
-// 	}
+func (job *workerPoolJob[I, O]) withContext(ctx context.Context) *workerPoolJob[I, O] {
+	if ctx != nil {
+		job.ctx = ctx
+	}
 
-// 	return job
-// }
+	return job
+}
+
+// For tasks which make their own requests, so that they
+// get cancelled along with the job they're a part of
+func taskWithContext[I any, O any](ctx context.Context, task func(context.Context, I) (O, error)) func(I) (O, error) {
+	return func(input I) (O, error) {
+		return task(ctx, input)
+	}
+}
 
 func newJob[I any, O any](task func(I) (O, error), data []I) *workerPoolJob[I, O] {
 	return &workerPoolJob[I, O]{
@@ -191,6 +236,7 @@ func workerPoolDo[I any, O any](job *workerPoolJob[I, O]) ([]O, []error, error)
 
 			for t := range tasksQueue {
 				t.output, t.err = job.task(t.input)
+				recordTask(t.err)
 				resultsQueue <- t
 			}
 		}()
@@ -0,0 +1,192 @@
+package feed
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRequestRetries     = 2
+	defaultMaxRequestsPerHost = 6
+	retryBaseDelay            = 500 * time.Millisecond
+	retryMaxDelay             = 10 * time.Second
+)
+
+// Limits the number of requests that are in flight to the same host at once,
+// shared between all clients since rate limits apply regardless of the client
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	hosts map[string]chan struct{}
+}
+
+var globalHostLimiter = &hostLimiter{
+	limit: defaultMaxRequestsPerHost,
+	hosts: make(map[string]chan struct{}),
+}
+
+func (l *hostLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit == limit {
+		return
+	}
+
+	l.limit = limit
+	l.hosts = make(map[string]chan struct{})
+}
+
+// The returned function must be called once the request is done
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	l.mu.Lock()
+
+	if l.limit <= 0 {
+		l.mu.Unlock()
+		return func() {}, nil
+	}
+
+	slots, exists := l.hosts[host]
+
+	if !exists {
+		slots = make(chan struct{}, l.limit)
+		l.hosts[host] = slots
+	}
+
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Frees up the slot of the host once the body has been read
+type limitedBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *limitedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
+
+type noRetriesContextKey struct{}
+
+// Used for requests where the status code is the point, such as when monitoring sites. They
+// also don't wait for the limit of requests per host, since how long the request took would
+// otherwise include the wait and it would count towards the timeout of the request
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesContextKey{}, true)
+}
+
+// Retries requests that fail due to rate limits or the server being temporarily
+// unavailable, waiting exponentially longer between each attempt unless the
+// server says how long to wait for through the Retry-After header
+type retryTransport struct {
+	transport http.RoundTripper
+	retries   int
+	limiter   *hostLimiter
+}
+
+func withRetries(transport http.RoundTripper, retries int) http.RoundTripper {
+	return &retryTransport{transport: transport, retries: retries, limiter: globalHostLimiter}
+}
+
+func isRetryableStatusCode(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+func (t *retryTransport) canRetry(request *http.Request) bool {
+	if t.retries <= 0 || request.Context().Value(noRetriesContextKey{}) != nil {
+		return false
+	}
+
+	return request.Method == http.MethodGet || request.Method == http.MethodHead
+}
+
+func (t *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	limited := ctx.Value(noRetriesContextKey{}) == nil
+
+	for attempt := 0; ; attempt++ {
+		release := func() {}
+
+		if limited {
+			var err error
+			release, err = t.limiter.acquire(ctx, request.URL.Host)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		span := startRequestSpan(request)
+		response, err := t.transport.RoundTrip(request)
+		recordResponse(response, err)
+		span.endRequest(response, err)
+
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		response.Body = &limitedBody{ReadCloser: response.Body, release: release}
+
+		if !isRetryableStatusCode(response.StatusCode) || attempt >= t.retries || !t.canRetry(request) {
+			return response, nil
+		}
+
+		delay := retryDelay(attempt, response.Header.Get("Retry-After"))
+
+		// no point in waiting if the request is going to time out by then
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return response, nil
+		}
+
+		io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+		response.Body.Close()
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		// round trippers shouldn't modify the original request
+		request = request.Clone(ctx)
+		requestMetrics.retries.Add(1)
+	}
+}
+
+func retryDelay(attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return min(time.Duration(seconds)*time.Second, retryMaxDelay)
+		}
+
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return min(max(time.Until(at), 0), retryMaxDelay)
+		}
+	}
+
+	// capped so that the shift doesn't overflow with a high number of retries
+	delay := retryBaseDelay << min(attempt, 8)
+	jitter := time.Duration(rand.Int64N(int64(retryBaseDelay)))
+
+	return min(delay+jitter, retryMaxDelay)
+}
@@ -1,13 +1,14 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"html"
-	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -58,13 +59,60 @@ func shortenFeedDescriptionLen(description string, maxLen int) string {
 }
 
 type RSSFeedRequest struct {
-	Url             string            `yaml:"url"`
-	Title           string            `yaml:"title"`
-	HideCategories  bool              `yaml:"hide-categories"`
-	HideDescription bool              `yaml:"hide-description"`
-	ItemLinkPrefix  string            `yaml:"item-link-prefix"`
-	Headers         map[string]string `yaml:"headers"`
-	IsDetailed      bool              `yaml:"-"`
+	Url               string            `yaml:"url"`
+	Title             string            `yaml:"title"`
+	HideCategories    bool              `yaml:"hide-categories"`
+	HideDescription   bool              `yaml:"hide-description"`
+	ItemLinkPrefix    string            `yaml:"item-link-prefix"`
+	Headers           map[string]string `yaml:"headers"`
+	Limit             int               `yaml:"limit"`
+	IncludeCategories []string          `yaml:"include-categories"`
+	ExcludeKeywords   []string          `yaml:"exclude-keywords"`
+	IsDetailed        bool              `yaml:"-"`
+	Client            RequestDoer       `yaml:"-"`
+	excludePatterns   []*regexp.Regexp  `yaml:"-"`
+}
+
+func (r *RSSFeedRequest) CompileExcludeKeywords() error {
+	r.excludePatterns = make([]*regexp.Regexp, 0, len(r.ExcludeKeywords))
+
+	for _, keyword := range r.ExcludeKeywords {
+		pattern, err := regexp.Compile("(?i)" + keyword)
+
+		if err != nil {
+			return fmt.Errorf("invalid exclude keyword %q: %v", keyword, err)
+		}
+
+		r.excludePatterns = append(r.excludePatterns, pattern)
+	}
+
+	return nil
+}
+
+// Filters are applied before the items of all feeds get merged so that
+// a feed which posts often doesn't push out the items of the others
+func (r *RSSFeedRequest) shouldIncludeItem(item *gofeed.Item) bool {
+	if len(r.IncludeCategories) > 0 && !slices.ContainsFunc(item.Categories, func(category string) bool {
+		return slices.ContainsFunc(r.IncludeCategories, func(included string) bool {
+			return strings.EqualFold(strings.TrimSpace(category), included)
+		})
+	}) {
+		return false
+	}
+
+	if len(r.excludePatterns) == 0 {
+		return true
+	}
+
+	description := sanitizeFeedDescription(item.Description)
+
+	for _, pattern := range r.excludePatterns {
+		if pattern.MatchString(item.Title) || pattern.MatchString(description) {
+			return false
+		}
+	}
+
+	return true
 }
 
 type RSSFeedItems []RSSFeedItem
@@ -79,8 +127,8 @@ func (f RSSFeedItems) SortByNewest() RSSFeedItems {
 
 var feedParser = gofeed.NewParser()
 
-func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
-	req, err := http.NewRequest("GET", request.Url, nil)
+func getItemsFromRSSFeedTask(ctx context.Context, request RSSFeedRequest) ([]RSSFeedItem, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", request.Url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -89,21 +137,15 @@ func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
 		req.Header.Add(key, value)
 	}
 
-	resp, err := defaultClient.Do(req)
+	resp, body, err := doConditionalRequest(request.Client, req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, request.Url)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	feed, err := feedParser.ParseString(string(body))
 
 	if err != nil {
@@ -115,6 +157,10 @@ func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
 	for i := range feed.Items {
 		item := feed.Items[i]
 
+		if !request.shouldIncludeItem(item) {
+			continue
+		}
+
 		rssItem := RSSFeedItem{
 			ChannelURL: feed.Link,
 		}
@@ -200,6 +246,10 @@ func getItemsFromRSSFeedTask(request RSSFeedRequest) ([]RSSFeedItem, error) {
 		items = append(items, rssItem)
 	}
 
+	if request.Limit > 0 && len(items) > request.Limit {
+		items = items.SortByNewest()[:request.Limit]
+	}
+
 	return items, nil
 }
 
@@ -233,8 +283,8 @@ func findThumbnailInItemExtensions(item *gofeed.Item) string {
 	return recursiveFindThumbnailInExtensions(media)
 }
 
-func GetItemsFromRSSFeeds(requests []RSSFeedRequest) (RSSFeedItems, error) {
-	job := newJob(getItemsFromRSSFeedTask, requests).withWorkers(10)
+func GetItemsFromRSSFeeds(ctx context.Context, requests []RSSFeedRequest) (RSSFeedItems, error) {
+	job := newJob(taskWithContext(ctx, getItemsFromRSSFeedTask), requests).withWorkers(10).withContext(ctx)
 	feeds, errs, err := workerPoolDo(job)
 
 	if err != nil {
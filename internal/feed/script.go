@@ -0,0 +1,343 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	// Anything returned beyond this is most likely a mistake in
+	// the script and would only end up being kept in memory
+	maxScriptOutputSize   = 1 << 20
+	maxScriptResponseSize = 4 << 20
+	maxScriptRequests     = 50
+	// how deeply tables can be nested when converting them to JSON,
+	// which also stops tables that reference themselves
+	maxScriptValueDepth = 64
+)
+
+// Functions of the standard library which could reach outside of the script,
+// load other code or print to the output of Glance
+var scriptRemovedGlobals = []string{
+	"collectgarbage", "dofile", "load", "loadfile", "loadstring",
+	"module", "require", "print", "_printregs",
+}
+
+type Script struct {
+	name  string
+	proto *lua.FunctionProto
+}
+
+type ScriptRequest struct {
+	Script     *Script
+	Parameters map[string]string
+	Client     RequestDoer
+}
+
+// Compiles the script once so that syntax errors show up when the config gets loaded
+func CompileScript(name string, source string) (*Script, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+
+	if err != nil {
+		return nil, errors.New(strings.TrimSpace(err.Error()))
+	}
+
+	proto, err := lua.Compile(chunk, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Script{name: name, proto: proto}, nil
+}
+
+// Runs the script in a Lua state of its own which only has the base, string, table and math
+// libraries along with the functions below. The value returned by the script gets converted
+// to JSON. The script gets stopped once the context is done, e.g. when the request timeout
+// of the widget is reached
+//
+//	params             the parameters from the config of the widget
+//	http.get(url, h)   makes a GET request with the optional headers and returns the body and status code
+//	json.decode(s)     json.encode(v)
+func RunScript(ctx context.Context, request *ScriptRequest) ([]byte, error) {
+	state := lua.NewState(lua.Options{SkipOpenLibs: true, CallStackSize: 200})
+	defer state.Close()
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+
+	for _, name := range scriptRemovedGlobals {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	if stringLib, ok := state.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+		stringLib.RawSetString("dump", lua.LNil)
+		stringLib.RawSetString("rep", state.NewFunction(scriptStringRep))
+	}
+
+	params := state.NewTable()
+
+	for key, value := range request.Parameters {
+		params.RawSetString(key, lua.LString(value))
+	}
+
+	requests := 0
+
+	state.SetGlobal("params", params)
+	state.SetGlobal("http", state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"get": func(L *lua.LState) int {
+			if requests++; requests > maxScriptRequests {
+				L.RaiseError("more than %d requests were made", maxScriptRequests)
+			}
+
+			return scriptHTTPGet(ctx, clientOrDefault(request.Client), L)
+		},
+	}))
+	state.SetGlobal("json", state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"decode": scriptJSONDecode,
+		"encode": scriptJSONEncode,
+	}))
+
+	state.SetContext(ctx)
+	state.Push(state.NewFunctionFromProto(request.Script.proto))
+	err := state.PCall(0, 1, nil)
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %v", request.Script.name, ctx.Err())
+	}
+
+	if err != nil {
+		return nil, scriptError(err)
+	}
+
+	value, err := scriptValueToGo(state.Get(-1), 0)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: returned value %v", request.Script.name, err)
+	}
+
+	output, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", request.Script.name, err)
+	}
+
+	if len(output) > maxScriptOutputSize {
+		return nil, fmt.Errorf("%s: returned value is larger than %d bytes as JSON", request.Script.name, maxScriptOutputSize)
+	}
+
+	return output, nil
+}
+
+// Keeps only the message, since the Lua traceback doesn't add much for scripts this small
+func scriptError(err error) error {
+	var apiErr *lua.ApiError
+
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		return errors.New(apiErr.Object.String())
+	}
+
+	return err
+}
+
+func scriptHTTPGet(ctx context.Context, client RequestDoer, L *lua.LState) int {
+	requestURL := L.CheckString(1)
+	headers := L.OptTable(2, nil)
+
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		L.RaiseError("%v", err)
+	}
+
+	if headers != nil {
+		headers.ForEach(func(key, value lua.LValue) {
+			request.Header.Set(key.String(), value.String())
+		})
+	}
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		L.RaiseError("%v", err)
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxScriptResponseSize+1))
+
+	if err != nil {
+		L.RaiseError("%v", err)
+	}
+
+	if len(body) > maxScriptResponseSize {
+		L.RaiseError("response from %s is larger than %d bytes", requestURL, maxScriptResponseSize)
+	}
+
+	L.Push(lua.LString(body))
+	L.Push(lua.LNumber(response.StatusCode))
+
+	return 2
+}
+
+// The one from the standard library would happily try to allocate gigabytes
+func scriptStringRep(L *lua.LState) int {
+	str := L.CheckString(1)
+	count := L.CheckInt(2)
+
+	if len(str) > 0 && count > maxScriptOutputSize/len(str) {
+		L.RaiseError("string.rep result would be larger than %d bytes", maxScriptOutputSize)
+	}
+
+	L.Push(lua.LString(strings.Repeat(str, max(count, 0))))
+
+	return 1
+}
+
+func scriptJSONDecode(L *lua.LState) int {
+	var value any
+
+	if err := json.Unmarshal([]byte(L.CheckString(1)), &value); err != nil {
+		L.RaiseError("decoding JSON: %v", err)
+	}
+
+	L.Push(goValueToScript(L, value))
+
+	return 1
+}
+
+func scriptJSONEncode(L *lua.LState) int {
+	value, err := scriptValueToGo(L.CheckAny(1), 0)
+
+	if err != nil {
+		L.RaiseError("encoding JSON: %v", err)
+	}
+
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		L.RaiseError("encoding JSON: %v", err)
+	}
+
+	L.Push(lua.LString(encoded))
+
+	return 1
+}
+
+func goValueToScript(L *lua.LState, value any) lua.LValue {
+	switch value := value.(type) {
+	case string:
+		return lua.LString(value)
+	case float64:
+		return lua.LNumber(value)
+	case bool:
+		return lua.LBool(value)
+	case []any:
+		table := L.CreateTable(len(value), 0)
+
+		for _, item := range value {
+			table.Append(goValueToScript(L, item))
+		}
+
+		return table
+	case map[string]any:
+		table := L.CreateTable(0, len(value))
+
+		for key, item := range value {
+			table.RawSetString(key, goValueToScript(L, item))
+		}
+
+		return table
+	}
+
+	return lua.LNil
+}
+
+// Tables whose keys are 1 to n become arrays, any other table becomes an object. Empty
+// tables become empty arrays, since templates usually range over them
+func scriptValueToGo(value lua.LValue, depth int) (any, error) {
+	if depth > maxScriptValueDepth {
+		return nil, fmt.Errorf("is nested more than %d levels deep", maxScriptValueDepth)
+	}
+
+	switch value := value.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(value), nil
+	case lua.LNumber:
+		return float64(value), nil
+	case lua.LString:
+		return string(value), nil
+	case *lua.LTable:
+		entries, length := 0, value.MaxN()
+		value.ForEach(func(lua.LValue, lua.LValue) { entries++ })
+
+		if entries == length {
+			array := make([]any, 0, length)
+
+			for i := 1; i <= length; i++ {
+				item, err := scriptValueToGo(value.RawGetInt(i), depth+1)
+
+				if err != nil {
+					return nil, err
+				}
+
+				array = append(array, item)
+			}
+
+			return array, nil
+		}
+
+		object := make(map[string]any, entries)
+		var err error
+
+		value.ForEach(func(key, item lua.LValue) {
+			if err != nil {
+				return
+			}
+
+			var name string
+
+			switch key := key.(type) {
+			case lua.LString:
+				name = string(key)
+			case lua.LNumber:
+				name = strconv.FormatFloat(float64(key), 'f', -1, 64)
+			default:
+				err = fmt.Errorf("has a key of type %s", key.Type())
+				return
+			}
+
+			object[name], err = scriptValueToGo(item, depth+1)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return object, nil
+	}
+
+	return nil, fmt.Errorf("contains a %s, which can't be converted to JSON", value.Type())
+}
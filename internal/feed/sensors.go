@@ -0,0 +1,88 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+type esphomeSensorResponseJson struct {
+	// null while the sensor doesn't have a reading
+	Value *float64 `json:"value"`
+}
+
+// Reads the sensors with the given object IDs from the REST API of the web server
+// component of an ESPHome device, one request for each since that's all it supports
+func FetchESPHomeSensorValues(ctx context.Context, client RequestDoer, baseURL string, ids []string) ([]float64, []error, error) {
+	client = clientOrDefault(client)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	// the devices don't handle many connections at once
+	job := newJob(func(id string) (float64, error) {
+		request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/sensor/"+url.PathEscape(id), nil)
+		response, err := decodeJsonFromRequest[esphomeSensorResponseJson](client, request)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if response.Value == nil {
+			return 0, errors.New("sensor has no reading")
+		}
+
+		return *response.Value, nil
+	}, ids).withWorkers(2).withContext(ctx)
+
+	return workerPoolDo(job)
+}
+
+// Reads the values at the given paths of the JSON returned by the URL, using the
+// same path syntax as the custom-api widget
+func FetchJSONSensorValues(ctx context.Context, client RequestDoer, url string, paths []string) ([]float64, []error, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	response, err := clientOrDefault(client).Do(request)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status code %d for %s", response.StatusCode, url)
+	}
+
+	if !gjson.ValidBytes(body) {
+		return nil, nil, fmt.Errorf("invalid JSON from %s", url)
+	}
+
+	values := make([]float64, len(paths))
+	errs := make([]error, len(paths))
+
+	for i, path := range paths {
+		result := gjson.GetBytes(body, path)
+
+		switch {
+		case !result.Exists():
+			errs[i] = fmt.Errorf("nothing found at %s", path)
+		case result.Type != gjson.Number && result.Type != gjson.String:
+			errs[i] = fmt.Errorf("value at %s is not a number", path)
+		default:
+			values[i] = result.Float()
+		}
+	}
+
+	return values, errs, nil
+}
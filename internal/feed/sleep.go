@@ -0,0 +1,330 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ouraEndpoint     = "https://api.ouraring.com/v2/usercollection"
+	withingsEndpoint = "https://wbsapi.withings.net/v2"
+)
+
+// How many nights are shown in the trend, including the last one
+const sleepTrendDays = 7
+
+type SleepNight struct {
+	Day             time.Time
+	Score           int
+	Duration        time.Duration
+	LowestHeartRate int
+	// only reported by Oura
+	HRV int
+	// in percent
+	Efficiency int
+}
+
+type Sleep struct {
+	// the oldest first
+	Nights []SleepNight
+}
+
+func (s *Sleep) LastNight() *SleepNight {
+	if len(s.Nights) == 0 {
+		return nil
+	}
+
+	return &s.Nights[len(s.Nights)-1]
+}
+
+func (s *Sleep) AverageDuration() time.Duration {
+	var total time.Duration
+	var count int
+
+	for i := range s.Nights {
+		if s.Nights[i].Duration > 0 {
+			total += s.Nights[i].Duration
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return total / time.Duration(count)
+}
+
+// The scores of the nights, or how long they were if there are no scores
+func (s *Sleep) TrendChart() string {
+	values := make([]float64, 0, len(s.Nights))
+
+	for i := range s.Nights {
+		if s.Nights[i].Score > 0 {
+			values = append(values, float64(s.Nights[i].Score))
+		}
+	}
+
+	if len(values) >= 2 {
+		return SvgPolylineCoordsFromYValues(100, 30, values)
+	}
+
+	values = values[:0]
+
+	for i := range s.Nights {
+		if s.Nights[i].Duration > 0 {
+			values = append(values, s.Nights[i].Duration.Hours())
+		}
+	}
+
+	return SvgPolylineCoordsFromYValues(100, 30, values)
+}
+
+func sleepTrendRange() (time.Time, time.Time) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return today.AddDate(0, 0, -(sleepTrendDays - 1)), today
+}
+
+// Sorts the nights and leaves out the ones which have nothing to show
+func newSleep(nights map[string]*SleepNight) (*Sleep, error) {
+	sleep := &Sleep{Nights: make([]SleepNight, 0, len(nights))}
+
+	for _, night := range nights {
+		if night.Score > 0 || night.Duration > 0 {
+			sleep.Nights = append(sleep.Nights, *night)
+		}
+	}
+
+	if len(sleep.Nights) == 0 {
+		return nil, fmt.Errorf("%w: no sleep was recorded in the last %d days", ErrNoContent, sleepTrendDays)
+	}
+
+	slices.SortFunc(sleep.Nights, func(a, b SleepNight) int {
+		return a.Day.Compare(b.Day)
+	})
+
+	return sleep, nil
+}
+
+type ouraResponseJson[T any] struct {
+	Data []T `json:"data"`
+}
+
+type ouraDailySleepJson struct {
+	Day   string `json:"day"`
+	Score int    `json:"score"`
+}
+
+type ouraSleepPeriodJson struct {
+	Day                string `json:"day"`
+	TotalSleepDuration int    `json:"total_sleep_duration"`
+	LowestHeartRate    int    `json:"lowest_heart_rate"`
+	AverageHRV         int    `json:"average_hrv"`
+	Efficiency         int    `json:"efficiency"`
+}
+
+func ouraGet[T any](ctx context.Context, client RequestDoer, token, path string, start, end time.Time) ([]T, error) {
+	query := url.Values{}
+	query.Set("start_date", start.Format(time.DateOnly))
+	// the end date is exclusive
+	query.Set("end_date", end.AddDate(0, 0, 1).Format(time.DateOnly))
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", ouraEndpoint+path+"?"+query.Encode(), nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := decodeJsonFromRequest[ouraResponseJson[T]](client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// The score comes from the daily summary while everything else comes from the
+// longest sleep period of each day, which leaves out naps
+func FetchSleepFromOura(ctx context.Context, client RequestDoer, token string) (*Sleep, error) {
+	client = clientOrDefault(client)
+	start, end := sleepTrendRange()
+
+	daily, err := ouraGet[ouraDailySleepJson](ctx, client, token, "/daily_sleep", start, end)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get daily sleep: %v", ErrNoContent, err)
+	}
+
+	periods, err := ouraGet[ouraSleepPeriodJson](ctx, client, token, "/sleep", start, end)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get sleep periods: %v", ErrNoContent, err)
+	}
+
+	nights := make(map[string]*SleepNight)
+
+	night := func(day string) *SleepNight {
+		if nights[day] == nil {
+			date, _ := time.ParseInLocation(time.DateOnly, day, time.Local)
+			nights[day] = &SleepNight{Day: date}
+		}
+
+		return nights[day]
+	}
+
+	for i := range daily {
+		night(daily[i].Day).Score = daily[i].Score
+	}
+
+	for i := range periods {
+		period := &periods[i]
+		n := night(period.Day)
+		duration := time.Duration(period.TotalSleepDuration) * time.Second
+
+		if duration <= n.Duration {
+			continue
+		}
+
+		n.Duration = duration
+		n.LowestHeartRate = period.LowestHeartRate
+		n.HRV = period.AverageHRV
+		n.Efficiency = period.Efficiency
+	}
+
+	return newSleep(nights)
+}
+
+// Makes requests to Withings, whose access tokens only last a few hours and get created
+// from the refresh token. A new refresh token comes with each access token and the previous
+// one stops working, so whoever created the client gets told about it through OnRefreshTokenChange
+type WithingsClient struct {
+	ClientID             string
+	ClientSecret         string
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+	Client               RequestDoer
+
+	mu    sync.Mutex
+	oauth oauthRefresher
+}
+
+// Withings responds with a status of 200 even when a request fails, the status in
+// the body is what tells whether it did
+type withingsResponseJson[T any] struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+	Body   T      `json:"body"`
+}
+
+type withingsSleepSummaryJson struct {
+	Series []struct {
+		Date string `json:"date"`
+		Data struct {
+			SleepScore      int     `json:"sleep_score"`
+			TotalSleepTime  int     `json:"total_sleep_time"`
+			HRMin           int     `json:"hr_min"`
+			SleepEfficiency float64 `json:"sleep_efficiency"`
+		} `json:"data"`
+	} `json:"series"`
+}
+
+func withingsPost[T any](ctx context.Context, client RequestDoer, token, path string, form url.Values) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "POST", withingsEndpoint+path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := decodeJsonFromRequest[withingsResponseJson[T]](client, request)
+
+	if err != nil {
+		return response.Body, err
+	}
+
+	if response.Status != 0 {
+		return response.Body, fmt.Errorf("status %d: %s", response.Status, response.Error)
+	}
+
+	return response.Body, nil
+}
+
+// Must be called with the mutex held. Withings wraps the usual response of
+// token endpoints in its own, so the refresh token has to be handled here
+func (c *WithingsClient) token(ctx context.Context) (string, error) {
+	return c.oauth.token(ctx, func(ctx context.Context) (oauthToken, error) {
+		form := url.Values{}
+		form.Set("action", "requesttoken")
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+		form.Set("refresh_token", c.RefreshToken)
+
+		response, err := withingsPost[oauthTokenResponseJson](ctx, clientOrDefault(c.Client), "", "/oauth2", form)
+
+		if err != nil {
+			return oauthToken{}, err
+		}
+
+		rotateRefreshToken(&c.RefreshToken, response.RefreshToken, c.OnRefreshTokenChange)
+
+		return oauthToken{
+			Access:  response.AccessToken,
+			Expires: time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		}, nil
+	})
+}
+
+func (c *WithingsClient) FetchSleep(ctx context.Context) (*Sleep, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	start, end := sleepTrendRange()
+
+	form := url.Values{}
+	form.Set("action", "getsummary")
+	form.Set("startdateymd", start.Format(time.DateOnly))
+	form.Set("enddateymd", end.Format(time.DateOnly))
+	form.Set("data_fields", "sleep_score,total_sleep_time,hr_min,sleep_efficiency")
+
+	response, err := withingsPost[withingsSleepSummaryJson](ctx, clientOrDefault(c.Client), token, "/sleep", form)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get sleep summary: %v", ErrNoContent, err)
+	}
+
+	nights := make(map[string]*SleepNight)
+
+	for _, s := range response.Series {
+		duration := time.Duration(s.Data.TotalSleepTime) * time.Second
+
+		// the longest sleep of each day, leaving out naps
+		if existing, ok := nights[s.Date]; ok && existing.Duration >= duration {
+			continue
+		}
+
+		day, _ := time.ParseInLocation(time.DateOnly, s.Date, time.Local)
+
+		nights[s.Date] = &SleepNight{
+			Day:             day,
+			Score:           s.Data.SleepScore,
+			Duration:        duration,
+			LowestHeartRate: s.Data.HRMin,
+			Efficiency:      int(s.Data.SleepEfficiency * 100),
+		}
+	}
+
+	return newSleep(nights)
+}
@@ -0,0 +1,329 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+type DiskHealth struct {
+	Name   string `json:"name"`
+	Model  string `json:"model"`
+	Serial string `json:"serial"`
+	Passed bool   `json:"passed"`
+	// nil when the disk doesn't report it
+	TemperatureC *float64 `json:"temperature-c,omitempty"`
+	// -1 when the disk doesn't report it, which is the case for NVMe drives
+	ReallocatedSectors int64 `json:"reallocated-sectors"`
+	PowerOnHours       int64 `json:"power-on-hours"`
+}
+
+type DiskHealthRequest struct {
+	// either empty for the local machine, the address of a remote Glance instance or
+	// the address of Scrutiny when Scrutiny is set
+	URL           string
+	Token         string
+	AllowInsecure bool
+	Scrutiny      bool
+}
+
+type smartctlScanJson struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+}
+
+type smartctlDeviceJson struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature *struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours int64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes *struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+}
+
+// The lower two bits of the exit status mean that the command couldn't run at all,
+// while the others describe problems with the disk and still come with the JSON
+const smartctlFatalExitStatus = 0b11
+
+func runSmartctl(ctx context.Context, arguments ...string) ([]byte, error) {
+	output, err := exec.CommandContext(ctx, "smartctl", append(arguments, "--json")...).Output()
+
+	var exitErr *exec.ExitError
+
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	if exitErr != nil && exitErr.ExitCode()&smartctlFatalExitStatus != 0 {
+		var response smartctlDeviceJson
+
+		if json.Unmarshal(output, &response) == nil && len(response.Smartctl.Messages) > 0 {
+			return nil, errors.New(response.Smartctl.Messages[0].String)
+		}
+
+		return nil, fmt.Errorf("smartctl exited with status %d", exitErr.ExitCode())
+	}
+
+	return output, nil
+}
+
+// Reads the health of the disks of the machine Glance is running on through smartctl, which
+// needs to be installed and allowed to access the disks. Disks that don't report their SMART
+// status, such as the ones behind most USB enclosures, are left out
+func FetchLocalDiskHealth(ctx context.Context) ([]DiskHealth, error) {
+	output, err := runSmartctl(ctx, "--scan-open")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var scan smartctlScanJson
+
+	if err := json.Unmarshal(output, &scan); err != nil {
+		return nil, fmt.Errorf("could not parse output of smartctl: %v", err)
+	}
+
+	disks := make([]DiskHealth, 0, len(scan.Devices))
+
+	for _, device := range scan.Devices {
+		output, err := runSmartctl(ctx, "--all", "--device", device.Type, device.Name)
+
+		if err != nil {
+			slog.Warn("Failed to read SMART data", "device", device.Name, "error", err)
+			continue
+		}
+
+		var response smartctlDeviceJson
+
+		if err := json.Unmarshal(output, &response); err != nil || response.SmartStatus == nil {
+			continue
+		}
+
+		disk := DiskHealth{
+			Name:               device.Name,
+			Model:              response.ModelName,
+			Serial:             response.SerialNumber,
+			Passed:             response.SmartStatus.Passed,
+			PowerOnHours:       response.PowerOnTime.Hours,
+			ReallocatedSectors: -1,
+		}
+
+		if response.Temperature != nil {
+			disk.TemperatureC = &response.Temperature.Current
+		}
+
+		if response.ATASmartAttributes != nil {
+			for _, attribute := range response.ATASmartAttributes.Table {
+				if attribute.ID == 5 {
+					disk.ReallocatedSectors = attribute.Raw.Value
+					break
+				}
+			}
+		}
+
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+type scrutinySummaryJson struct {
+	Data struct {
+		Summary map[string]struct {
+			Device scrutinyDeviceJson `json:"device"`
+			Smart  *struct {
+				Temp         float64 `json:"temp"`
+				PowerOnHours int64   `json:"power_on_hours"`
+			} `json:"smart"`
+		} `json:"summary"`
+	} `json:"data"`
+}
+
+type scrutinyDeviceJson struct {
+	WWN          string `json:"wwn"`
+	DeviceName   string `json:"device_name"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	Label        string `json:"label"`
+	HostID       string `json:"host_id"`
+	// 0 when passing, otherwise a bitmask of whether SMART and Scrutiny's own checks failed
+	DeviceStatus int `json:"device_status"`
+}
+
+type scrutinyDetailsJson struct {
+	Data struct {
+		SmartResults []struct {
+			Attrs map[string]struct {
+				RawValue int64 `json:"raw_value"`
+			} `json:"attrs"`
+		} `json:"smart_results"`
+	} `json:"data"`
+}
+
+// The summary doesn't include the attributes, which are only part of the details of each disk
+func FetchDiskHealthFromScrutiny(ctx context.Context, client RequestDoer, baseURL string) ([]DiskHealth, error) {
+	client = clientOrDefault(client)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/summary", nil)
+	summary, err := decodeJsonFromRequest[scrutinySummaryJson](client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	disks := make([]DiskHealth, 0, len(summary.Data.Summary))
+	wwns := make([]string, 0, len(summary.Data.Summary))
+
+	for wwn, entry := range summary.Data.Summary {
+		device := &entry.Device
+		name := device.Label
+
+		if name == "" {
+			name = "/dev/" + device.DeviceName
+		}
+
+		if device.HostID != "" {
+			name = device.HostID + " " + name
+		}
+
+		disk := DiskHealth{
+			Name:               name,
+			Model:              device.ModelName,
+			Serial:             device.SerialNumber,
+			Passed:             device.DeviceStatus == 0,
+			ReallocatedSectors: -1,
+		}
+
+		if entry.Smart != nil {
+			disk.TemperatureC = &entry.Smart.Temp
+			disk.PowerOnHours = entry.Smart.PowerOnHours
+		}
+
+		disks = append(disks, disk)
+		wwns = append(wwns, wwn)
+	}
+
+	job := newJob(func(wwn string) (int64, error) {
+		request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+"/api/device/"+url.PathEscape(wwn)+"/details", nil)
+		details, err := decodeJsonFromRequest[scrutinyDetailsJson](client, request)
+
+		if err != nil {
+			return -1, err
+		}
+
+		if len(details.Data.SmartResults) == 0 {
+			return -1, nil
+		}
+
+		if attribute, ok := details.Data.SmartResults[0].Attrs["5"]; ok {
+			return attribute.RawValue, nil
+		}
+
+		return -1, nil
+	}, wwns).withWorkers(5).withContext(ctx)
+
+	reallocated, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range disks {
+		if errs[i] != nil {
+			slog.Warn("Failed to fetch disk details from Scrutiny", "wwn", wwns[i], "error", errs[i])
+			continue
+		}
+
+		disks[i].ReallocatedSectors = reallocated[i]
+	}
+
+	return disks, nil
+}
+
+func fetchDiskHealthTask(ctx context.Context, request *DiskHealthRequest) ([]DiskHealth, error) {
+	var disks []DiskHealth
+	var err error
+
+	switch {
+	case request.Scrutiny:
+		client := defaultClient
+
+		if request.AllowInsecure {
+			client = defaultInsecureClient
+		}
+
+		disks, err = FetchDiskHealthFromScrutiny(ctx, client, request.URL)
+	case request.URL == "":
+		disks, err = FetchLocalDiskHealth(ctx)
+	default:
+		disks, err = fetchFromRemoteInstance[[]DiskHealth](ctx, request.URL, "/api/disk-health", request.Token, request.AllowInsecure)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(disks, func(a, b DiskHealth) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return disks, nil
+}
+
+func FetchDiskHealthForSources(ctx context.Context, requests []*DiskHealthRequest) ([][]DiskHealth, error) {
+	job := newJob(taskWithContext(ctx, fetchDiskHealthTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to fetch disk health", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch disk health of %d source(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
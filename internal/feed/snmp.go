@@ -0,0 +1,449 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Only as much of SNMPv2c as is needed to read a few values from a device
+// on the local network, which is enough to not need a library for it
+
+const (
+	snmpTimeout = 3 * time.Second
+	snmpRetries = 2
+	// how many values are asked for at once while walking a table
+	snmpMaxRepetitions = 25
+	// guards against devices that keep on answering with values outside of what was asked
+	snmpMaxWalkValues = 5000
+)
+
+const (
+	berTagInteger     = 0x02
+	berTagOctetString = 0x04
+	berTagNull        = 0x05
+	berTagOID         = 0x06
+	berTagSequence    = 0x30
+
+	snmpTagCounter32      = 0x41
+	snmpTagGauge32        = 0x42
+	snmpTagTimeTicks      = 0x43
+	snmpTagCounter64      = 0x46
+	snmpTagEndOfMibView   = 0x82
+	snmpTagGetRequest     = 0xa0
+	snmpTagResponse       = 0xa2
+	snmpTagGetBulkRequest = 0xa5
+)
+
+type snmpVariable struct {
+	OID string
+	// an int64 for numbers, a string for octet strings and nil when there's no such object
+	Value any
+	// there's nothing left to walk
+	endOfMibView bool
+}
+
+func (v *snmpVariable) Int() (int64, bool) {
+	value, ok := v.Value.(int64)
+	return value, ok
+}
+
+func (v *snmpVariable) String() string {
+	value, _ := v.Value.(string)
+	return value
+}
+
+type snmpSession struct {
+	conn      net.Conn
+	community string
+	requestID int32
+}
+
+func newSNMPSession(ctx context.Context, host, community string) (*snmpSession, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "161")
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &snmpSession{conn: conn, community: community, requestID: rand.Int32()}, nil
+}
+
+func (s *snmpSession) Close() error {
+	return s.conn.Close()
+}
+
+func (s *snmpSession) get(ctx context.Context, oids ...string) ([]snmpVariable, error) {
+	return s.exchange(ctx, snmpTagGetRequest, 0, 0, oids)
+}
+
+// Returns all values under the given OID, in order
+func (s *snmpSession) walk(ctx context.Context, root string) ([]snmpVariable, error) {
+	var values []snmpVariable
+	oid := root
+
+	for {
+		response, err := s.exchange(ctx, snmpTagGetBulkRequest, 0, snmpMaxRepetitions, []string{oid})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(response) == 0 {
+			return values, nil
+		}
+
+		for i := range response {
+			if response[i].endOfMibView || !strings.HasPrefix(response[i].OID, root+".") {
+				return values, nil
+			}
+
+			values = append(values, response[i])
+		}
+
+		next := response[len(response)-1].OID
+
+		if next == oid || len(values) > snmpMaxWalkValues {
+			return nil, errors.New("device keeps returning the same values")
+		}
+
+		oid = next
+	}
+}
+
+func (s *snmpSession) exchange(ctx context.Context, pduTag byte, field1, field2 int, oids []string) ([]snmpVariable, error) {
+	s.requestID++
+
+	var bindings []byte
+
+	for _, oid := range oids {
+		encoded, err := berEncodeOID(oid)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bindings = append(bindings, berTLV(berTagSequence, append(encoded, berTagNull, 0))...)
+	}
+
+	pdu := berEncodeInteger(int64(s.requestID))
+	pdu = append(pdu, berEncodeInteger(int64(field1))...)
+	pdu = append(pdu, berEncodeInteger(int64(field2))...)
+	pdu = append(pdu, berTLV(berTagSequence, bindings)...)
+
+	// version 1 is SNMPv2c
+	message := berEncodeInteger(1)
+	message = append(message, berTLV(berTagOctetString, []byte(s.community))...)
+	message = append(message, berTLV(pduTag, pdu)...)
+	message = berTLV(berTagSequence, message)
+
+	buffer := make([]byte, 64<<10)
+	var lastErr error
+
+	for range snmpRetries + 1 {
+		deadline := time.Now().Add(snmpTimeout)
+
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+
+		s.conn.SetDeadline(deadline)
+
+		if _, err := s.conn.Write(message); err != nil {
+			return nil, err
+		}
+
+		for {
+			n, err := s.conn.Read(buffer)
+
+			if err != nil {
+				lastErr = err
+				break
+			}
+
+			values, requestID, err := snmpDecodeResponse(buffer[:n])
+
+			if err != nil {
+				return nil, err
+			}
+
+			// a late response to a request that was retried
+			if requestID != s.requestID {
+				continue
+			}
+
+			return values, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if !errors.Is(lastErr, os.ErrDeadlineExceeded) {
+			return nil, lastErr
+		}
+	}
+
+	return nil, errors.New("no response, check the address and community")
+}
+
+func snmpDecodeResponse(data []byte) ([]snmpVariable, int32, error) {
+	message := berReader(data)
+	tag, content, err := message.next()
+
+	if err != nil || tag != berTagSequence {
+		return nil, 0, errors.New("invalid response")
+	}
+
+	message = berReader(content)
+	// version and community
+	message.next()
+	message.next()
+
+	tag, content, err = message.next()
+
+	if err != nil || tag != snmpTagResponse {
+		return nil, 0, errors.New("invalid response")
+	}
+
+	pdu := berReader(content)
+	requestID, err := pdu.nextInteger()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	errorStatus, err := pdu.nextInteger()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if errorStatus != 0 {
+		return nil, int32(requestID), fmt.Errorf("device responded with error status %d", errorStatus)
+	}
+
+	pdu.next()
+	tag, content, err = pdu.next()
+
+	if err != nil || tag != berTagSequence {
+		return nil, 0, errors.New("invalid response")
+	}
+
+	bindings := berReader(content)
+	var values []snmpVariable
+
+	for len(bindings) > 0 {
+		tag, content, err = bindings.next()
+
+		if err != nil || tag != berTagSequence {
+			return nil, 0, errors.New("invalid variable binding")
+		}
+
+		binding := berReader(content)
+		tag, oid, err := binding.next()
+
+		if err != nil || tag != berTagOID {
+			return nil, 0, errors.New("invalid variable binding")
+		}
+
+		variable := snmpVariable{OID: berDecodeOID(oid)}
+		tag, value, err := binding.next()
+
+		if err != nil {
+			return nil, 0, errors.New("invalid variable binding")
+		}
+
+		switch tag {
+		case berTagInteger:
+			variable.Value = berDecodeInteger(value)
+		case snmpTagCounter32, snmpTagGauge32, snmpTagTimeTicks, snmpTagCounter64:
+			var n uint64
+
+			for _, b := range value {
+				n = n<<8 | uint64(b)
+			}
+
+			variable.Value = int64(n)
+		case berTagOctetString:
+			variable.Value = string(value)
+		case snmpTagEndOfMibView:
+			variable.endOfMibView = true
+		}
+
+		values = append(values, variable)
+	}
+
+	return values, int32(requestID), nil
+}
+
+type berReader []byte
+
+func (r *berReader) next() (byte, []byte, error) {
+	data := *r
+
+	if len(data) < 2 {
+		return 0, nil, errors.New("unexpected end of data")
+	}
+
+	tag := data[0]
+	length := int(data[1])
+	offset := 2
+
+	if length&0x80 != 0 {
+		size := length & 0x7f
+
+		if size == 0 || size > 4 || len(data) < offset+size {
+			return 0, nil, errors.New("invalid length")
+		}
+
+		length = 0
+
+		for _, b := range data[offset : offset+size] {
+			length = length<<8 | int(b)
+		}
+
+		offset += size
+	}
+
+	if length < 0 || len(data)-offset < length {
+		return 0, nil, errors.New("unexpected end of data")
+	}
+
+	*r = data[offset+length:]
+
+	return tag, data[offset : offset+length], nil
+}
+
+func (r *berReader) nextInteger() (int64, error) {
+	tag, value, err := r.next()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if tag != berTagInteger {
+		return 0, errors.New("expected an integer")
+	}
+
+	return berDecodeInteger(value), nil
+}
+
+func berTLV(tag byte, value []byte) []byte {
+	encoded := []byte{tag}
+	length := len(value)
+
+	if length < 0x80 {
+		encoded = append(encoded, byte(length))
+	} else {
+		var size []byte
+
+		for ; length > 0; length >>= 8 {
+			size = append([]byte{byte(length)}, size...)
+		}
+
+		encoded = append(encoded, 0x80|byte(len(size)))
+		encoded = append(encoded, size...)
+	}
+
+	return append(encoded, value...)
+}
+
+func berEncodeInteger(value int64) []byte {
+	var encoded []byte
+
+	for {
+		encoded = append([]byte{byte(value)}, encoded...)
+
+		if value >= -128 && value < 128 {
+			break
+		}
+
+		value >>= 8
+	}
+
+	return berTLV(berTagInteger, encoded)
+}
+
+func berDecodeInteger(value []byte) int64 {
+	var n int64
+
+	for i, b := range value {
+		// sign extension
+		if i == 0 && b&0x80 != 0 {
+			n = -1
+		}
+
+		n = n<<8 | int64(b)
+	}
+
+	return n
+}
+
+func berEncodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(oid, ".")
+
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %s", oid)
+	}
+
+	numbers := make([]uint64, len(parts))
+
+	for i := range parts {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %s", oid)
+		}
+
+		numbers[i] = n
+	}
+
+	var encoded []byte
+
+	// the first two numbers are combined into one
+	for _, n := range append([]uint64{numbers[0]*40 + numbers[1]}, numbers[2:]...) {
+		part := []byte{byte(n & 0x7f)}
+
+		for n >>= 7; n > 0; n >>= 7 {
+			part = append([]byte{byte(n&0x7f) | 0x80}, part...)
+		}
+
+		encoded = append(encoded, part...)
+	}
+
+	return berTLV(berTagOID, encoded), nil
+}
+
+func berDecodeOID(value []byte) string {
+	var parts []string
+	var n uint64
+
+	for _, b := range value {
+		n = n<<7 | uint64(b&0x7f)
+
+		if b&0x80 != 0 {
+			continue
+		}
+
+		if parts == nil {
+			parts = append(parts, strconv.FormatUint(min(n/40, 2), 10), strconv.FormatUint(n-min(n/40, 2)*40, 10))
+		} else {
+			parts = append(parts, strconv.FormatUint(n, 10))
+		}
+
+		n = 0
+	}
+
+	return strings.Join(parts, ".")
+}
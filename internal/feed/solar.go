@@ -0,0 +1,204 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	SolarServiceEVCC      = "evcc"
+	SolarServiceFronius   = "fronius"
+	SolarServiceSolarEdge = "solaredge"
+)
+
+const solarEdgeEndpoint = "https://monitoringapi.solaredge.com"
+
+// Power is in watts and energy in watt-hours
+type SolarStatus struct {
+	Production  float64
+	Consumption float64
+	// positive when importing from the grid, negative when exporting to it
+	Grid float64
+	// positive when discharging, negative when charging
+	BatteryPower float64
+	// in percent, -1 when there's no battery
+	BatteryLevel float64
+	// -1 when the source doesn't report it
+	ProducedToday float64
+}
+
+type SolarRequest struct {
+	Service string
+	// the EVCC instance or the inverter, SolarEdge always goes through its cloud
+	URL    string
+	SiteID string
+	APIKey string
+}
+
+func FetchSolarStatus(ctx context.Context, client RequestDoer, request *SolarRequest) (*SolarStatus, error) {
+	client = clientOrDefault(client)
+
+	switch request.Service {
+	case SolarServiceEVCC:
+		return fetchEVCCSolarStatus(ctx, client, request.URL)
+	case SolarServiceFronius:
+		return fetchFroniusSolarStatus(ctx, client, request.URL)
+	case SolarServiceSolarEdge:
+		return fetchSolarEdgeStatus(ctx, client, request.SiteID, request.APIKey)
+	}
+
+	return nil, fmt.Errorf("unknown service %q", request.Service)
+}
+
+func fetchSolarJson(ctx context.Context, client RequestDoer, endpoint string) (gjson.Result, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	body, _, err := fetchRedactedJson(client, request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	return gjson.Parse(body), nil
+}
+
+func fetchEVCCSolarStatus(ctx context.Context, client RequestDoer, baseURL string) (*SolarStatus, error) {
+	state, err := fetchSolarJson(ctx, client, strings.TrimRight(baseURL, "/")+"/api/state")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// older versions wrap the state in a result object
+	if result := state.Get("result"); result.Exists() {
+		state = result
+	}
+
+	status := &SolarStatus{
+		Production:    state.Get("pvPower").Float(),
+		Consumption:   state.Get("homePower").Float(),
+		BatteryLevel:  -1,
+		ProducedToday: -1,
+	}
+
+	// newer versions group everything about the grid together
+	if grid := state.Get("grid.power"); grid.Exists() {
+		status.Grid = grid.Float()
+	} else {
+		status.Grid = state.Get("gridPower").Float()
+	}
+
+	if state.Get("batteryConfigured").Bool() {
+		status.BatteryPower = state.Get("batteryPower").Float()
+		status.BatteryLevel = state.Get("batterySoc").Float()
+	}
+
+	return status, nil
+}
+
+func fetchFroniusSolarStatus(ctx context.Context, client RequestDoer, baseURL string) (*SolarStatus, error) {
+	response, err := fetchSolarJson(ctx, client, strings.TrimRight(baseURL, "/")+"/solar_api/v1/GetPowerFlowRealtimeData.fcgi")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if code := response.Get("Head.Status.Code").Int(); code != 0 {
+		return nil, fmt.Errorf("inverter responded with status %d: %s", code, response.Get("Head.Status.Reason").String())
+	}
+
+	data := response.Get("Body.Data")
+	site := data.Get("Site")
+
+	// PV is null rather than 0 at night and the load is negative since it's power leaving the site
+	status := &SolarStatus{
+		Production:    site.Get("P_PV").Float(),
+		Consumption:   -site.Get("P_Load").Float(),
+		Grid:          site.Get("P_Grid").Float(),
+		BatteryPower:  site.Get("P_Akku").Float(),
+		BatteryLevel:  -1,
+		ProducedToday: -1,
+	}
+
+	// only older inverters report it, newer ones return null
+	if energy := site.Get("E_Day"); energy.Type == gjson.Number {
+		status.ProducedToday = energy.Float()
+	}
+
+	data.Get("Inverters").ForEach(func(_, inverter gjson.Result) bool {
+		if soc := inverter.Get("SOC"); soc.Exists() {
+			status.BatteryLevel = soc.Float()
+			return false
+		}
+
+		return true
+	})
+
+	return status, nil
+}
+
+// SolarEdge only allows 300 requests a day, this makes two of them
+func fetchSolarEdgeStatus(ctx context.Context, client RequestDoer, siteID, apiKey string) (*SolarStatus, error) {
+	query := "?api_key=" + url.QueryEscape(apiKey)
+	base := solarEdgeEndpoint + "/site/" + url.PathEscape(siteID)
+
+	flow, err := fetchSolarJson(ctx, client, base+"/currentPowerFlow"+query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	overview, err := fetchSolarJson(ctx, client, base+"/overview"+query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	flow = flow.Get("siteCurrentPowerFlow")
+	multiplier := 1.0
+
+	switch strings.ToLower(flow.Get("unit").String()) {
+	case "kw":
+		multiplier = 1000
+	case "mw":
+		multiplier = 1000000
+	}
+
+	status := &SolarStatus{
+		Production:    flow.Get("PV.currentPower").Float() * multiplier,
+		Consumption:   flow.Get("LOAD.currentPower").Float() * multiplier,
+		Grid:          flow.Get("GRID.currentPower").Float() * multiplier,
+		BatteryLevel:  -1,
+		ProducedToday: overview.Get("overview.lastDayData.energy").Float(),
+	}
+
+	// the power is always positive, the direction is given by the connections between the elements
+	for _, connection := range flow.Get("connections").Array() {
+		from := strings.ToUpper(connection.Get("from").String())
+		to := strings.ToUpper(connection.Get("to").String())
+
+		switch {
+		case to == "GRID":
+			status.Grid = -status.Grid
+		case to == "STORAGE":
+			status.BatteryPower = -flow.Get("STORAGE.currentPower").Float() * multiplier
+		case from == "STORAGE":
+			status.BatteryPower = flow.Get("STORAGE.currentPower").Float() * multiplier
+		}
+	}
+
+	if storage := flow.Get("STORAGE"); storage.Exists() {
+		status.BatteryLevel = storage.Get("chargeLevel").Float()
+	}
+
+	return status, nil
+}
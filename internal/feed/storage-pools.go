@@ -0,0 +1,311 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	StoragePoolTypeZFS    = "zfs"
+	StoragePoolTypeMDRAID = "mdraid"
+)
+
+type StoragePool struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// as reported by zpool, such as ONLINE or DEGRADED, while arrays are either
+	// ACTIVE, DEGRADED or INACTIVE
+	Health    string `json:"health"`
+	SizeBytes uint64 `json:"size-bytes"`
+	// nil for arrays, which don't know how much of them the filesystem on top uses
+	UsedBytes *uint64 `json:"used-bytes,omitempty"`
+	// such as scrub or resilver for pools and resync or recovery for arrays, empty
+	// when nothing is in progress
+	Operation string `json:"operation,omitempty"`
+	// in percent, only while an operation is in progress
+	Progress float64 `json:"progress,omitempty"`
+	// what zpool status says about the last scrub or resilver that finished
+	LastScan string `json:"last-scan,omitempty"`
+}
+
+func (p *StoragePool) IsHealthy() bool {
+	return p.Health == "ONLINE" || p.Health == "ACTIVE"
+}
+
+func (p *StoragePool) UsedPercent() float64 {
+	if p.UsedBytes == nil || p.SizeBytes == 0 {
+		return 0
+	}
+
+	return float64(*p.UsedBytes) / float64(p.SizeBytes) * 100
+}
+
+type StoragePoolsRequest struct {
+	// empty for the local machine
+	URL           string
+	Token         string
+	AllowInsecure bool
+}
+
+var zpoolProgressPattern = regexp.MustCompile(`([\d.]+)% done`)
+
+func fetchZFSPools(ctx context.Context) ([]StoragePool, error) {
+	output, err := exec.CommandContext(ctx, "zpool", "list", "-H", "-p", "-o", "name,size,allocated,health").Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("zpool list: %v", err)
+	}
+
+	pools := make([]StoragePool, 0)
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\t")
+
+		if len(fields) != 4 {
+			continue
+		}
+
+		size, _ := strconv.ParseUint(fields[1], 10, 64)
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+
+		pool := StoragePool{
+			Name:      fields[0],
+			Type:      StoragePoolTypeZFS,
+			Health:    fields[3],
+			SizeBytes: size,
+			UsedBytes: &used,
+		}
+
+		status, err := exec.CommandContext(ctx, "zpool", "status", pool.Name).Output()
+
+		if err != nil {
+			slog.Warn("Failed to get status of ZFS pool", "pool", pool.Name, "error", err)
+		} else {
+			parseZpoolScan(&pool, string(status))
+		}
+
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
+// The scan section spans the lines from "scan:" until the next section, such as
+//
+//	scan: scrub in progress since Sun Oct 13 00:24:01 2024
+//		1.22T / 2.00T scanned at 1.03G/s, 500G / 2.00T issued at 421M/s
+//		0B repaired, 24.41% done, 01:02:03 to go
+//
+// or a single line once it's done
+func parseZpoolScan(pool *StoragePool, status string) {
+	var scan []string
+
+	for _, line := range strings.Split(status, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if len(scan) == 0 {
+			if strings.HasPrefix(trimmed, "scan:") {
+				scan = append(scan, strings.TrimSpace(strings.TrimPrefix(trimmed, "scan:")))
+			}
+
+			continue
+		}
+
+		if trimmed == "" || strings.HasSuffix(strings.Fields(trimmed)[0], ":") {
+			break
+		}
+
+		scan = append(scan, trimmed)
+	}
+
+	if len(scan) == 0 {
+		return
+	}
+
+	if !strings.Contains(scan[0], "in progress") {
+		if scan[0] != "none requested" {
+			pool.LastScan = scan[0]
+		}
+
+		return
+	}
+
+	pool.Operation = strings.Fields(scan[0])[0]
+
+	if match := zpoolProgressPattern.FindStringSubmatch(strings.Join(scan, " ")); match != nil {
+		pool.Progress, _ = strconv.ParseFloat(match[1], 64)
+	}
+}
+
+var mdstatProgressPattern = regexp.MustCompile(`(\w+)\s*=\s*([\d.]+)%`)
+
+// Arrays look like the following, where the underscore in [U_] is a missing member
+//
+//	md0 : active raid1 sdb1[1] sda1[0]
+//	      976630464 blocks super 1.2 [2/1] [U_]
+//	      [==>..................]  recovery = 12.6% (123456/976630464) finish=100.0min speed=100000K/sec
+func parseMDStat(r io.Reader) ([]StoragePool, error) {
+	arrays := make([]StoragePool, 0)
+	var array *StoragePool
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			array = nil
+			continue
+		}
+
+		if len(fields) >= 3 && fields[1] == ":" && strings.HasPrefix(fields[0], "md") {
+			arrays = append(arrays, StoragePool{
+				Name:   fields[0],
+				Type:   StoragePoolTypeMDRAID,
+				Health: "ACTIVE",
+			})
+			array = &arrays[len(arrays)-1]
+
+			if fields[2] != "active" {
+				array.Health = "INACTIVE"
+			}
+
+			continue
+		}
+
+		if array == nil {
+			continue
+		}
+
+		if len(fields) >= 2 && fields[1] == "blocks" {
+			blocks, _ := strconv.ParseUint(fields[0], 10, 64)
+			array.SizeBytes = blocks * 1024
+
+			if members := fields[len(fields)-1]; strings.HasPrefix(members, "[") && strings.Contains(members, "_") {
+				array.Health = "DEGRADED"
+			}
+
+			continue
+		}
+
+		if match := mdstatProgressPattern.FindStringSubmatch(line); match != nil {
+			array.Operation = match[1]
+			array.Progress, _ = strconv.ParseFloat(match[2], 64)
+		}
+	}
+
+	return arrays, scanner.Err()
+}
+
+func fetchMDRAIDArrays() ([]StoragePool, error) {
+	file, err := os.Open("/proc/mdstat")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return parseMDStat(file)
+}
+
+// Reads the ZFS pools of the machine Glance is running on through zpool and its
+// Linux software RAID arrays from /proc/mdstat, whichever of them are present
+func FetchLocalStoragePools(ctx context.Context) ([]StoragePool, error) {
+	var pools []StoragePool
+
+	if _, err := exec.LookPath("zpool"); err == nil {
+		zfs, err := fetchZFSPools(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, zfs...)
+	}
+
+	arrays, err := fetchMDRAIDArrays()
+
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("could not read /proc/mdstat: %v", err)
+	}
+
+	pools = append(pools, arrays...)
+
+	if pools == nil {
+		pools = []StoragePool{}
+	}
+
+	return pools, nil
+}
+
+func fetchStoragePoolsTask(ctx context.Context, request *StoragePoolsRequest) ([]StoragePool, error) {
+	var pools []StoragePool
+	var err error
+
+	if request.URL == "" {
+		pools, err = FetchLocalStoragePools(ctx)
+	} else {
+		pools, err = fetchFromRemoteInstance[[]StoragePool](ctx, request.URL, "/api/storage-pools", request.Token, request.AllowInsecure)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the ones which need attention first
+	slices.SortStableFunc(pools, func(a, b StoragePool) int {
+		if a.IsHealthy() == b.IsHealthy() {
+			return strings.Compare(a.Name, b.Name)
+		}
+
+		if a.IsHealthy() {
+			return 1
+		}
+
+		return -1
+	})
+
+	return pools, nil
+}
+
+func FetchStoragePoolsForSources(ctx context.Context, requests []*StoragePoolsRequest) ([][]StoragePool, error) {
+	job := newJob(taskWithContext(ctx, fetchStoragePoolsTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to fetch storage pools", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch storage pools of %d source(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
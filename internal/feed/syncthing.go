@@ -0,0 +1,231 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type SyncthingFolder struct {
+	ID     string
+	Label  string
+	Paused bool
+	// such as idle, scanning, syncing or error
+	State string
+	// in percent, how much of the global state of the folder this device has
+	Completion float64
+	// the files, directories and deletions this device still needs
+	NeedItems int
+	// the items which could not be synced
+	Errors int
+}
+
+func (f *SyncthingFolder) Name() string {
+	if f.Label != "" {
+		return f.Label
+	}
+
+	return f.ID
+}
+
+func (f *SyncthingFolder) StateLabel() string {
+	switch f.State {
+	case "idle":
+		return "Up to date"
+	case "scanning":
+		return "Scanning"
+	case "syncing":
+		return "Syncing"
+	case "sync-preparing":
+		return "Preparing"
+	case "scan-waiting", "sync-waiting", "clean-waiting":
+		return "Waiting"
+	case "cleaning":
+		return "Cleaning"
+	case "error":
+		return "Error"
+	}
+
+	return "Unknown"
+}
+
+type SyncthingDevice struct {
+	Name      string
+	Connected bool
+	Paused    bool
+}
+
+type Syncthing struct {
+	Folders []SyncthingFolder
+	// the other devices, without this one
+	Devices []SyncthingDevice
+}
+
+func (s *Syncthing) ConnectedDevices() int {
+	var connected int
+
+	for i := range s.Devices {
+		if s.Devices[i].Connected {
+			connected++
+		}
+	}
+
+	return connected
+}
+
+type syncthingFolderConfigJson struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	Paused bool   `json:"paused"`
+}
+
+type syncthingDeviceConfigJson struct {
+	DeviceID string `json:"deviceID"`
+	Name     string `json:"name"`
+	Paused   bool   `json:"paused"`
+}
+
+type syncthingFolderStatusJson struct {
+	State          string `json:"state"`
+	GlobalBytes    int64  `json:"globalBytes"`
+	InSyncBytes    int64  `json:"inSyncBytes"`
+	NeedTotalItems int    `json:"needTotalItems"`
+	PullErrors     int    `json:"pullErrors"`
+}
+
+type syncthingConnectionsJson struct {
+	Connections map[string]struct {
+		Connected bool `json:"connected"`
+	} `json:"connections"`
+}
+
+type syncthingSystemStatusJson struct {
+	MyID string `json:"myID"`
+}
+
+func syncthingGet[T any](ctx context.Context, client RequestDoer, baseURL, apiKey, path string) (T, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", baseURL+path, nil)
+	request.Header.Set("X-API-Key", apiKey)
+
+	return decodeJsonFromRequest[T](client, request)
+}
+
+// Fetches the folders along with how far along they are in syncing, and whether
+// each of the other devices is connected, from the REST API of Syncthing
+func FetchSyncthingStatus(ctx context.Context, client RequestDoer, baseURL, apiKey string) (*Syncthing, error) {
+	client = clientOrDefault(client)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	folders, err := syncthingGet[[]syncthingFolderConfigJson](ctx, client, baseURL, apiKey, "/rest/config/folders")
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get folders: %v", ErrNoContent, err)
+	}
+
+	job := newJob(func(folder syncthingFolderConfigJson) (*syncthingFolderStatusJson, error) {
+		if folder.Paused {
+			return nil, nil
+		}
+
+		status, err := syncthingGet[syncthingFolderStatusJson](ctx, client, baseURL, apiKey, "/rest/db/status?folder="+url.QueryEscape(folder.ID))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &status, nil
+	}, folders).withWorkers(5).withContext(ctx)
+
+	statuses, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	syncthing := &Syncthing{Folders: make([]SyncthingFolder, len(folders))}
+	var failed int
+
+	for i := range folders {
+		folder := &syncthing.Folders[i]
+		folder.ID = folders[i].ID
+		folder.Label = folders[i].Label
+		folder.Paused = folders[i].Paused
+
+		if errs[i] != nil {
+			failed++
+			folder.State = "unknown"
+			slog.Warn("Failed to get Syncthing folder status", "folder", folder.ID, "error", errs[i])
+			continue
+		}
+
+		status := statuses[i]
+
+		if status == nil {
+			continue
+		}
+
+		folder.State = status.State
+		folder.NeedItems = status.NeedTotalItems
+		folder.Errors = status.PullErrors
+		folder.Completion = 100
+
+		if status.GlobalBytes > 0 {
+			folder.Completion = float64(status.InSyncBytes) / float64(status.GlobalBytes) * 100
+		}
+	}
+
+	slices.SortFunc(syncthing.Folders, func(a, b SyncthingFolder) int {
+		return strings.Compare(strings.ToLower(a.Name()), strings.ToLower(b.Name()))
+	})
+
+	devices, err := syncthingGet[[]syncthingDeviceConfigJson](ctx, client, baseURL, apiKey, "/rest/config/devices")
+
+	if err != nil {
+		return syncthing, fmt.Errorf("%w: could not get devices: %v", ErrPartialContent, err)
+	}
+
+	system, err := syncthingGet[syncthingSystemStatusJson](ctx, client, baseURL, apiKey, "/rest/system/status")
+
+	if err != nil {
+		return syncthing, fmt.Errorf("%w: could not get system status: %v", ErrPartialContent, err)
+	}
+
+	connections, err := syncthingGet[syncthingConnectionsJson](ctx, client, baseURL, apiKey, "/rest/system/connections")
+
+	if err != nil {
+		return syncthing, fmt.Errorf("%w: could not get connections: %v", ErrPartialContent, err)
+	}
+
+	for i := range devices {
+		if devices[i].DeviceID == system.MyID {
+			continue
+		}
+
+		name := devices[i].Name
+
+		if name == "" {
+			// the first group of the ID is what Syncthing shows for devices without a name
+			name, _, _ = strings.Cut(devices[i].DeviceID, "-")
+		}
+
+		syncthing.Devices = append(syncthing.Devices, SyncthingDevice{
+			Name:      name,
+			Connected: connections.Connections[devices[i].DeviceID].Connected,
+			Paused:    devices[i].Paused,
+		})
+	}
+
+	slices.SortFunc(syncthing.Devices, func(a, b SyncthingDevice) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	if failed > 0 {
+		return syncthing, fmt.Errorf("%w: could not get status of %d folder(s)", ErrPartialContent, failed)
+	}
+
+	return syncthing, nil
+}
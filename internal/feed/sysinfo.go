@@ -0,0 +1,249 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SystemStats struct {
+	Hostname      string                  `json:"hostname"`
+	UptimeSeconds uint64                  `json:"uptime-seconds"`
+	CPU           SystemCPUStats          `json:"cpu"`
+	Memory        SystemMemoryStats       `json:"memory"`
+	Swap          SystemMemoryStats       `json:"swap"`
+	Mountpoints   []SystemMountpointStats `json:"mountpoints"`
+	Network       SystemNetworkStats      `json:"network"`
+}
+
+type SystemCPUStats struct {
+	LoadPercent  float64  `json:"load-percent"`
+	Load1        float64  `json:"load1"`
+	Load15       float64  `json:"load15"`
+	Cores        int      `json:"cores"`
+	TemperatureC *float64 `json:"temperature-c,omitempty"`
+}
+
+type SystemMemoryStats struct {
+	TotalBytes  uint64  `json:"total-bytes"`
+	UsedBytes   uint64  `json:"used-bytes"`
+	UsedPercent float64 `json:"used-percent"`
+}
+
+type SystemMountpointStats struct {
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total-bytes"`
+	UsedBytes   uint64  `json:"used-bytes"`
+	UsedPercent float64 `json:"used-percent"`
+}
+
+type SystemNetworkStats struct {
+	ReceiveBytesPerSecond  float64 `json:"receive-bytes-per-second"`
+	TransmitBytesPerSecond float64 `json:"transmit-bytes-per-second"`
+}
+
+func newSystemMemoryStats(total, available uint64) SystemMemoryStats {
+	stats := SystemMemoryStats{TotalBytes: total}
+
+	if total > 0 && available <= total {
+		stats.UsedBytes = total - available
+		stats.UsedPercent = float64(stats.UsedBytes) / float64(total) * 100
+	}
+
+	return stats
+}
+
+// The counters read from the system which are used to calculate
+// CPU usage and network throughput between two samples
+type systemCounters struct {
+	cpuIdle       uint64
+	cpuTotal      uint64
+	networkRx     uint64
+	networkTx     uint64
+	takenAt       time.Time
+	networkFailed bool
+}
+
+type systemStatsSampler struct {
+	mu        sync.Mutex
+	previous  *systemCounters
+	lastStats *SystemStats
+}
+
+var localSystemStatsSampler systemStatsSampler
+
+const minSystemStatsSampleInterval = time.Second
+const initialSystemStatsSampleInterval = 500 * time.Millisecond
+
+func (s *systemStatsSampler) sample() (*SystemStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous != nil && time.Since(s.previous.takenAt) < minSystemStatsSampleInterval && s.lastStats != nil {
+		return s.lastStats, nil
+	}
+
+	if s.previous == nil {
+		previous, err := readSystemCounters()
+
+		if err != nil {
+			return nil, err
+		}
+
+		s.previous = previous
+		time.Sleep(initialSystemStatsSampleInterval)
+	}
+
+	current, err := readSystemCounters()
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := readSystemStats()
+
+	if err != nil {
+		return nil, err
+	}
+
+	previous := s.previous
+	elapsed := current.takenAt.Sub(previous.takenAt).Seconds()
+
+	if current.cpuTotal > previous.cpuTotal {
+		totalDelta := float64(current.cpuTotal - previous.cpuTotal)
+		idleDelta := float64(current.cpuIdle - previous.cpuIdle)
+		stats.CPU.LoadPercent = (1 - idleDelta/totalDelta) * 100
+	}
+
+	// counters get reset when an interface goes down or is removed
+	if !current.networkFailed && elapsed > 0 && current.networkRx >= previous.networkRx && current.networkTx >= previous.networkTx {
+		stats.Network.ReceiveBytesPerSecond = float64(current.networkRx-previous.networkRx) / elapsed
+		stats.Network.TransmitBytesPerSecond = float64(current.networkTx-previous.networkTx) / elapsed
+	}
+
+	stats.CPU.Cores = runtime.NumCPU()
+	stats.Hostname, _ = os.Hostname()
+
+	s.previous = current
+	s.lastStats = stats
+
+	return stats, nil
+}
+
+// Returns the stats of the machine Glance is running on, CPU usage and network throughput
+// are averaged since the last call so the first one takes a little longer
+func FetchLocalSystemStats() (*SystemStats, error) {
+	return localSystemStatsSampler.sample()
+}
+
+type SystemStatsRequest struct {
+	URL           string
+	Token         string
+	AllowInsecure bool
+	Mountpoints   []string
+}
+
+func (r *SystemStatsRequest) isLocal() bool {
+	return r.URL == ""
+}
+
+func fetchSystemStatsTask(ctx context.Context, request *SystemStatsRequest) (*SystemStats, error) {
+	var stats *SystemStats
+	var err error
+
+	if request.isLocal() {
+		stats, err = FetchLocalSystemStats()
+
+		if err == nil && len(request.Mountpoints) > 0 {
+			// mountpoints that were explicitly specified get read directly, which
+			// allows showing host filesystems that are mounted inside of a container
+			statsCopy := *stats
+			statsCopy.Mountpoints = readMountpointsStats(request.Mountpoints)
+			stats = &statsCopy
+		}
+
+		return stats, err
+	}
+
+	remoteStats, err := fetchFromRemoteInstance[SystemStats](ctx, request.URL, "/api/server-stats", request.Token, request.AllowInsecure)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Mountpoints) > 0 {
+		filtered := make([]SystemMountpointStats, 0, len(request.Mountpoints))
+
+		for _, mountpoint := range remoteStats.Mountpoints {
+			if slices.Contains(request.Mountpoints, mountpoint.Path) {
+				filtered = append(filtered, mountpoint)
+			}
+		}
+
+		remoteStats.Mountpoints = filtered
+	}
+
+	return &remoteStats, nil
+}
+
+// Requests one of the endpoints which Glance makes available to other instances when
+// the stats-api-token is set, such as /api/server-stats
+func fetchFromRemoteInstance[T any](ctx context.Context, baseURL, path, token string, allowInsecure bool) (T, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(baseURL, "/")+path, nil)
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := defaultClient
+
+	if allowInsecure {
+		client = defaultInsecureClient
+	}
+
+	return decodeJsonFromRequest[T](client, request)
+}
+
+func FetchSystemStatsForServers(ctx context.Context, requests []*SystemStatsRequest) ([]*SystemStats, error) {
+	job := newJob(taskWithContext(ctx, fetchSystemStatsTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to fetch server stats", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch stats of %d server(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
+
+var errSystemStatsNotSupported = errors.New("reading system stats is only supported on Linux")
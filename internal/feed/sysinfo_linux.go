@@ -0,0 +1,306 @@
+package feed
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func readSystemCounters() (*systemCounters, error) {
+	counters := &systemCounters{takenAt: time.Now()}
+	contents, err := os.ReadFile("/proc/stat")
+
+	if err != nil {
+		return nil, err
+	}
+
+	line, _, _ := strings.Cut(string(contents), "\n")
+	fields := strings.Fields(line)
+
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return nil, errors.New("unexpected format of /proc/stat")
+	}
+
+	// user nice system idle iowait irq softirq steal, guest time is already included in user
+	for i, field := range fields[1:min(len(fields), 9)] {
+		value, err := strconv.ParseUint(field, 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("unexpected format of /proc/stat: %v", err)
+		}
+
+		counters.cpuTotal += value
+
+		if i == 3 || i == 4 {
+			counters.cpuIdle += value
+		}
+	}
+
+	counters.networkRx, counters.networkTx, err = readNetworkCounters()
+	counters.networkFailed = err != nil
+
+	return counters, nil
+}
+
+// Only physical interfaces are counted since traffic going through
+// bridges and virtual interfaces would otherwise be counted twice
+func readNetworkCounters() (uint64, uint64, error) {
+	file, err := os.Open("/proc/net/dev")
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer file.Close()
+
+	var physicalRx, physicalTx, allRx, allTx uint64
+	var hasPhysical bool
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		name, values, found := strings.Cut(scanner.Text(), ":")
+
+		if !found {
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(values)
+
+		if name == "lo" || len(fields) < 9 {
+			continue
+		}
+
+		rx, _ := strconv.ParseUint(fields[0], 10, 64)
+		tx, _ := strconv.ParseUint(fields[8], 10, 64)
+		allRx += rx
+		allTx += tx
+
+		if _, err := os.Stat("/sys/class/net/" + name + "/device"); err == nil {
+			hasPhysical = true
+			physicalRx += rx
+			physicalTx += tx
+		}
+	}
+
+	if !hasPhysical {
+		return allRx, allTx, scanner.Err()
+	}
+
+	return physicalRx, physicalTx, scanner.Err()
+}
+
+func readMemInfo() (map[string]uint64, error) {
+	file, err := os.Open("/proc/meminfo")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+
+		if !found {
+			continue
+		}
+
+		fields := strings.Fields(value)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		number, err := strconv.ParseUint(fields[0], 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		if len(fields) > 1 && fields[1] == "kB" {
+			number *= 1024
+		}
+
+		values[key] = number
+	}
+
+	return values, scanner.Err()
+}
+
+var ignoredFilesystemTypes = []string{
+	"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs", "debugfs", "devpts", "devtmpfs",
+	"efivarfs", "fusectl", "hugetlbfs", "mqueue", "nsfs", "overlay", "proc", "pstore", "ramfs",
+	"rpc_pipefs", "securityfs", "squashfs", "sysfs", "tmpfs", "tracefs", "fuse.lxcfs", "fuse.portal",
+}
+
+// Mountpoints backed by block devices or network filesystems, excluding
+// bind mounts and other filesystems that are mounted more than once
+func readMountpoints() ([]string, error) {
+	file, err := os.Open("/proc/mounts")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	mountpoints := make([]string, 0, 8)
+	seenDevices := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) < 3 || slices.Contains(ignoredFilesystemTypes, fields[2]) || seenDevices[fields[0]] {
+			continue
+		}
+
+		if !strings.HasPrefix(fields[0], "/dev/") && !strings.Contains(fields[0], ":") && fields[2] != "zfs" {
+			continue
+		}
+
+		if strings.HasPrefix(fields[0], "/dev/loop") {
+			continue
+		}
+
+		seenDevices[fields[0]] = true
+		// spaces and other special characters are octal escaped
+		path, err := strconv.Unquote(`"` + strings.ReplaceAll(fields[1], `"`, `\"`) + `"`)
+
+		if err != nil {
+			path = fields[1]
+		}
+
+		mountpoints = append(mountpoints, path)
+	}
+
+	return mountpoints, scanner.Err()
+}
+
+func readMountpointsStats(paths []string) []SystemMountpointStats {
+	stats := make([]SystemMountpointStats, 0, len(paths))
+
+	for _, path := range paths {
+		var statfs syscall.Statfs_t
+
+		if err := syscall.Statfs(path, &statfs); err != nil || statfs.Blocks == 0 {
+			continue
+		}
+
+		total := statfs.Blocks * uint64(statfs.Bsize)
+		// reserved blocks aren't available to regular users but
+		// are still counted as used, same as df does it
+		used := (statfs.Blocks - statfs.Bfree) * uint64(statfs.Bsize)
+		available := statfs.Bavail * uint64(statfs.Bsize)
+		mountpoint := SystemMountpointStats{
+			Path:       path,
+			TotalBytes: total,
+			UsedBytes:  used,
+		}
+
+		if used+available > 0 {
+			mountpoint.UsedPercent = float64(used) / float64(used+available) * 100
+		}
+
+		stats = append(stats, mountpoint)
+	}
+
+	return stats
+}
+
+var cpuTemperatureSensors = []string{"coretemp", "k10temp", "zenpower", "cpu_thermal", "soc_thermal", "cpu-thermal"}
+
+func readCPUTemperature() (float64, bool) {
+	hwmons, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	var highest float64
+	var found bool
+
+	for _, hwmon := range hwmons {
+		name, err := os.ReadFile(hwmon + "/name")
+
+		if err != nil || !slices.Contains(cpuTemperatureSensors, strings.TrimSpace(string(name))) {
+			continue
+		}
+
+		inputs, _ := filepath.Glob(hwmon + "/temp*_input")
+
+		for _, input := range inputs {
+			if value, ok := readMillidegrees(input); ok && (!found || value > highest) {
+				highest = value
+				found = true
+			}
+		}
+	}
+
+	if found {
+		return highest, true
+	}
+
+	return readMillidegrees("/sys/class/thermal/thermal_zone0/temp")
+}
+
+func readMillidegrees(path string) (float64, bool) {
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(contents)), 64)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return value / 1000, true
+}
+
+func readSystemStats() (*SystemStats, error) {
+	stats := &SystemStats{}
+
+	if contents, err := os.ReadFile("/proc/uptime"); err == nil {
+		if fields := strings.Fields(string(contents)); len(fields) > 0 {
+			uptime, _ := strconv.ParseFloat(fields[0], 64)
+			stats.UptimeSeconds = uint64(uptime)
+		}
+	}
+
+	if contents, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if fields := strings.Fields(string(contents)); len(fields) >= 3 {
+			stats.CPU.Load1, _ = strconv.ParseFloat(fields[0], 64)
+			stats.CPU.Load15, _ = strconv.ParseFloat(fields[2], 64)
+		}
+	}
+
+	if temperature, ok := readCPUTemperature(); ok {
+		stats.CPU.TemperatureC = &temperature
+	}
+
+	memInfo, err := readMemInfo()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read memory info: %v", err)
+	}
+
+	stats.Memory = newSystemMemoryStats(memInfo["MemTotal"], memInfo["MemAvailable"])
+	stats.Swap = newSystemMemoryStats(memInfo["SwapTotal"], memInfo["SwapFree"])
+
+	mountpoints, err := readMountpoints()
+
+	if err == nil {
+		stats.Mountpoints = readMountpointsStats(mountpoints)
+	}
+
+	return stats, nil
+}
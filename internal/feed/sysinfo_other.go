@@ -0,0 +1,15 @@
+//go:build !linux
+
+package feed
+
+func readSystemCounters() (*systemCounters, error) {
+	return nil, errSystemStatsNotSupported
+}
+
+func readSystemStats() (*SystemStats, error) {
+	return nil, errSystemStatsNotSupported
+}
+
+func readMountpointsStats(paths []string) []SystemMountpointStats {
+	return nil
+}
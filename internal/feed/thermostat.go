@@ -0,0 +1,517 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	ThermostatServiceHomeAssistant = "homeassistant"
+	ThermostatServiceTado          = "tado"
+	ThermostatServiceNest          = "nest"
+)
+
+const (
+	tadoEndpoint     = "https://my.tado.com/api/v2"
+	tadoAuthEndpoint = "https://login.tado.com/oauth2/token"
+	// the client which Tado's own apps use, it's the only one allowed to get tokens
+	tadoClientID       = "1bb50063-6b0c-4d11-bd99-387f4a91cc46"
+	nestEndpoint       = "https://smartdevicemanagement.googleapis.com/v1"
+	googleAuthEndpoint = "https://oauth2.googleapis.com/token"
+)
+
+var ErrThermostatNotAdjustable = errors.New("the target temperature of this zone can't be changed")
+
+type ThermostatZone struct {
+	// the entity ID for Home Assistant, the zone ID for Tado and the device name for Nest
+	ID      string
+	Name    string
+	Current float64
+	// NaN when there's no single target, such as when the thermostat is off
+	Target float64
+	// in percent, -1 when not reported
+	Humidity float64
+	// what the thermostat is set to do, such as heat, cool, auto or off
+	Mode string
+	// what it's currently doing, such as heating, cooling or idle
+	Action string
+	Unit   string
+	Step   float64
+	Min    float64
+	Max    float64
+}
+
+func (z *ThermostatZone) HasTarget() bool {
+	return !math.IsNaN(z.Target)
+}
+
+func (z *ThermostatZone) IsActive() bool {
+	return z.Action == "heating" || z.Action == "cooling"
+}
+
+// Gets the zones from Home Assistant's climate entities, Tado or Nest. Tado only hands out
+// short lived access tokens which get created from the refresh token, which itself changes
+// when that happens, so whoever created the client gets told about the new one through
+// OnRefreshTokenChange in order to keep it
+type ThermostatClient struct {
+	Service string
+	Client  RequestDoer
+	// Home Assistant only
+	URL      string
+	Token    string
+	Entities []string
+	// Nest only
+	ProjectID    string
+	ClientID     string
+	ClientSecret string
+	// Tado and Nest
+	RefreshToken         string
+	OnRefreshTokenChange func(string)
+
+	mu         sync.Mutex
+	oauth      oauthRefresher
+	tadoHomeID int64
+}
+
+func (c *ThermostatClient) FetchZones(ctx context.Context) ([]ThermostatZone, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.Service {
+	case ThermostatServiceHomeAssistant:
+		return c.fetchHomeAssistantZones(ctx)
+	case ThermostatServiceTado:
+		return c.fetchTadoZones(ctx)
+	case ThermostatServiceNest:
+		return c.fetchNestZones(ctx)
+	}
+
+	return nil, fmt.Errorf("unknown service %q", c.Service)
+}
+
+// Changes the target temperature of the zone, in the unit the zone reports temperatures in
+func (c *ThermostatClient) SetTarget(ctx context.Context, zone *ThermostatZone, target float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.Service {
+	case ThermostatServiceHomeAssistant:
+		return c.setHomeAssistantTarget(ctx, zone, target)
+	case ThermostatServiceTado:
+		return c.setTadoTarget(ctx, zone, target)
+	case ThermostatServiceNest:
+		return c.setNestTarget(ctx, zone, target)
+	}
+
+	return fmt.Errorf("unknown service %q", c.Service)
+}
+
+func (c *ThermostatClient) request(ctx context.Context, method, endpoint, token string, body any) (string, error) {
+	var reader io.Reader
+
+	if body != nil {
+		encoded, err := json.Marshal(body)
+
+		if err != nil {
+			return "", err
+		}
+
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+
+	if err != nil {
+		return "", err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := clientOrDefault(c.Client).Do(request)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, 4<<20))
+
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return "", fmt.Errorf(
+			"unexpected status code %d for %s, response: %s",
+			response.StatusCode,
+			request.URL,
+			truncateString(string(responseBody), 256),
+		)
+	}
+
+	return string(responseBody), nil
+}
+
+// Must be called with the mutex held
+func (c *ThermostatClient) token(ctx context.Context) (string, error) {
+	return c.oauth.refresh(ctx, clientOrDefault(c.Client), &c.RefreshToken, c.OnRefreshTokenChange, func(ctx context.Context, form url.Values) *http.Request {
+		if c.Service == ThermostatServiceTado {
+			form.Set("client_id", tadoClientID)
+
+			return newOAuthTokenRequest(ctx, tadoAuthEndpoint, form)
+		}
+
+		form.Set("client_id", c.ClientID)
+		form.Set("client_secret", c.ClientSecret)
+
+		return newOAuthTokenRequest(ctx, googleAuthEndpoint, form)
+	})
+}
+
+func (c *ThermostatClient) fetchHomeAssistantZones(ctx context.Context) ([]ThermostatZone, error) {
+	baseURL := strings.TrimRight(c.URL, "/")
+	config, err := c.request(ctx, "GET", baseURL+"/api/config", c.Token, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := c.request(ctx, "GET", baseURL+"/api/states", c.Token, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unit := gjson.Get(config, "unit_system.temperature").String()
+	byID := make(map[string]gjson.Result)
+
+	for _, state := range gjson.Parse(states).Array() {
+		if id := state.Get("entity_id").String(); strings.HasPrefix(id, "climate.") {
+			byID[id] = state
+		}
+	}
+
+	entities := c.Entities
+
+	if len(entities) == 0 {
+		for id := range byID {
+			entities = append(entities, id)
+		}
+
+		slices.Sort(entities)
+	}
+
+	zones := make([]ThermostatZone, 0, len(entities))
+
+	for _, id := range entities {
+		state, exists := byID[id]
+
+		if !exists {
+			return nil, fmt.Errorf("entity %s not found", id)
+		}
+
+		attributes := state.Get("attributes")
+		zone := ThermostatZone{
+			ID:       id,
+			Name:     attributes.Get("friendly_name").String(),
+			Current:  attributes.Get("current_temperature").Float(),
+			Target:   math.NaN(),
+			Humidity: -1,
+			Mode:     state.Get("state").String(),
+			Action:   attributes.Get("hvac_action").String(),
+			Unit:     unit,
+			Step:     attributes.Get("target_temp_step").Float(),
+			Min:      attributes.Get("min_temp").Float(),
+			Max:      attributes.Get("max_temp").Float(),
+		}
+
+		// thermostats in heat_cool mode have a range rather than a single target
+		if target := attributes.Get("temperature"); target.Type == gjson.Number {
+			zone.Target = target.Float()
+		}
+
+		if humidity := attributes.Get("current_humidity"); humidity.Type == gjson.Number {
+			zone.Humidity = humidity.Float()
+		}
+
+		if zone.Name == "" {
+			zone.Name = id
+		}
+
+		if zone.Step == 0 {
+			zone.Step = 0.5
+		}
+
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+func (c *ThermostatClient) setHomeAssistantTarget(ctx context.Context, zone *ThermostatZone, target float64) error {
+	_, err := c.request(
+		ctx, "POST",
+		strings.TrimRight(c.URL, "/")+"/api/services/climate/set_temperature",
+		c.Token,
+		map[string]any{"entity_id": zone.ID, "temperature": target},
+	)
+
+	return err
+}
+
+// Must be called with the mutex held
+func (c *ThermostatClient) tadoHome(ctx context.Context, token string) (int64, error) {
+	if c.tadoHomeID != 0 {
+		return c.tadoHomeID, nil
+	}
+
+	me, err := c.request(ctx, "GET", tadoEndpoint+"/me", token, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	id := gjson.Get(me, "homes.0.id").Int()
+
+	if id == 0 {
+		return 0, errors.New("no homes found in tado account")
+	}
+
+	c.tadoHomeID = id
+
+	return id, nil
+}
+
+func (c *ThermostatClient) fetchTadoZones(ctx context.Context) ([]ThermostatZone, error) {
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	home, err := c.tadoHome(ctx, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	homeURL := tadoEndpoint + "/homes/" + strconv.FormatInt(home, 10)
+	zonesBody, err := c.request(ctx, "GET", homeURL+"/zones", token, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	statesBody, err := c.request(ctx, "GET", homeURL+"/zoneStates", token, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	states := gjson.Get(statesBody, "zoneStates")
+	zones := make([]ThermostatZone, 0)
+
+	for _, zoneData := range gjson.Parse(zonesBody).Array() {
+		zoneType := zoneData.Get("type").String()
+
+		// hot water zones don't have a room temperature
+		if zoneType != "HEATING" && zoneType != "AIR_CONDITIONING" {
+			continue
+		}
+
+		id := zoneData.Get("id").String()
+		state := states.Get(gjson.Escape(id))
+		setting := state.Get("setting")
+
+		zone := ThermostatZone{
+			ID:       id,
+			Name:     zoneData.Get("name").String(),
+			Current:  state.Get("sensorDataPoints.insideTemperature.celsius").Float(),
+			Target:   math.NaN(),
+			Humidity: -1,
+			Mode:     "heat",
+			Action:   "idle",
+			Unit:     "°C",
+			Step:     0.5,
+			Min:      5,
+			Max:      25,
+		}
+
+		if zoneType == "AIR_CONDITIONING" {
+			zone.Mode = strings.ToLower(setting.Get("mode").String())
+			zone.Min = 16
+			zone.Max = 30
+		}
+
+		if humidity := state.Get("sensorDataPoints.humidity.percentage"); humidity.Exists() {
+			zone.Humidity = humidity.Float()
+		}
+
+		if setting.Get("power").String() == "OFF" {
+			zone.Mode = "off"
+			zone.Action = "off"
+		} else if target := setting.Get("temperature.celsius"); target.Exists() {
+			zone.Target = target.Float()
+		}
+
+		if state.Get("activityDataPoints.heatingPower.percentage").Float() > 0 {
+			zone.Action = "heating"
+		} else if state.Get("activityDataPoints.acPower.value").String() == "ON" {
+			zone.Action = "cooling"
+		}
+
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+// Overrides the schedule until the next time it changes, which is what the app does by default
+func (c *ThermostatClient) setTadoTarget(ctx context.Context, zone *ThermostatZone, target float64) error {
+	// air conditioners need their mode and fan speed along with the temperature
+	if zone.Mode != "heat" {
+		return ErrThermostatNotAdjustable
+	}
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	home, err := c.tadoHome(ctx, token)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.request(
+		ctx, "PUT",
+		tadoEndpoint+"/homes/"+strconv.FormatInt(home, 10)+"/zones/"+url.PathEscape(zone.ID)+"/overlay",
+		token,
+		map[string]any{
+			"setting": map[string]any{
+				"type":        "HEATING",
+				"power":       "ON",
+				"temperature": map[string]any{"celsius": target},
+			},
+			"termination": map[string]any{"typeSkillBasedApp": "NEXT_TIME_BLOCK"},
+		},
+	)
+
+	return err
+}
+
+func (c *ThermostatClient) fetchNestZones(ctx context.Context) ([]ThermostatZone, error) {
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.request(ctx, "GET", nestEndpoint+"/enterprises/"+url.PathEscape(c.ProjectID)+"/devices", token, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]ThermostatZone, 0)
+
+	for _, device := range gjson.Get(body, "devices").Array() {
+		if device.Get("type").String() != "sdm.devices.types.THERMOSTAT" {
+			continue
+		}
+
+		traits := device.Get("traits")
+		trait := func(name string) gjson.Result {
+			return traits.Get(gjson.Escape("sdm.devices.traits." + name))
+		}
+
+		zone := ThermostatZone{
+			ID:       device.Get("name").String(),
+			Name:     trait("Info").Get("customName").String(),
+			Current:  trait("Temperature").Get("ambientTemperatureCelsius").Float(),
+			Target:   math.NaN(),
+			Humidity: -1,
+			Mode:     strings.ToLower(trait("ThermostatMode").Get("mode").String()),
+			Action:   strings.ToLower(trait("ThermostatHvac").Get("status").String()),
+			Unit:     "°C",
+			Step:     0.5,
+			Min:      9,
+			Max:      32,
+		}
+
+		if zone.Name == "" {
+			zone.Name = device.Get("parentRelations.0.displayName").String()
+		}
+
+		if humidity := trait("Humidity").Get("ambientHumidityPercent"); humidity.Exists() {
+			zone.Humidity = humidity.Float()
+		}
+
+		if zone.Action == "off" {
+			zone.Action = "idle"
+		}
+
+		// in heatcool mode there's a range rather than a single target
+		switch zone.Mode {
+		case "heat":
+			zone.Target = trait("ThermostatTemperatureSetpoint").Get("heatCelsius").Float()
+		case "cool":
+			zone.Target = trait("ThermostatTemperatureSetpoint").Get("coolCelsius").Float()
+		case "off":
+			zone.Action = "off"
+		}
+
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+func (c *ThermostatClient) setNestTarget(ctx context.Context, zone *ThermostatZone, target float64) error {
+	var command map[string]any
+
+	switch zone.Mode {
+	case "heat":
+		command = map[string]any{
+			"command": "sdm.devices.commands.ThermostatTemperatureSetpoint.SetHeat",
+			"params":  map[string]any{"heatCelsius": target},
+		}
+	case "cool":
+		command = map[string]any{
+			"command": "sdm.devices.commands.ThermostatTemperatureSetpoint.SetCool",
+			"params":  map[string]any{"coolCelsius": target},
+		}
+	default:
+		return ErrThermostatNotAdjustable
+	}
+
+	token, err := c.token(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.request(ctx, "POST", nestEndpoint+"/"+zone.ID+":executeCommand", token, command)
+
+	return err
+}
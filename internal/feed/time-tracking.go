@@ -0,0 +1,329 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	togglEndpoint    = "https://api.track.toggl.com/api/v9"
+	clockifyEndpoint = "https://api.clockify.me/api/v1"
+)
+
+var ErrNoRunningTimer = errors.New("no timer is running")
+
+// Makes requests to either Toggl Track or Clockify. The workspace, and for Clockify
+// the ID of the user, get looked up from the token the first time they're needed
+type TimeTrackingClient struct {
+	Service     string
+	Token       string
+	WorkspaceID string
+
+	mu     sync.Mutex
+	userID string
+}
+
+type TimeEntry struct {
+	Description string
+	Start       time.Time
+}
+
+type TimeTracking struct {
+	Today   time.Duration
+	Week    time.Duration
+	Running *TimeEntry
+}
+
+type timeEntry struct {
+	description string
+	start       time.Time
+	// zero while the timer is running
+	stop time.Time
+}
+
+type togglTimeEntryJson struct {
+	ID          int64      `json:"id"`
+	Description string     `json:"description"`
+	Start       time.Time  `json:"start"`
+	Stop        *time.Time `json:"stop"`
+}
+
+type clockifyTimeEntryJson struct {
+	ID           string `json:"id"`
+	Description  string `json:"description"`
+	TimeInterval struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"timeInterval"`
+}
+
+func (c *TimeTrackingClient) newRequest(ctx context.Context, method, path string, body any) *http.Request {
+	var reader io.Reader
+
+	if body != nil {
+		encoded, _ := json.Marshal(body)
+		reader = bytes.NewReader(encoded)
+	}
+
+	var request *http.Request
+
+	if c.Service == "toggl" {
+		request, _ = http.NewRequestWithContext(ctx, method, togglEndpoint+path, reader)
+		request.SetBasicAuth(c.Token, "api_token")
+	} else {
+		request, _ = http.NewRequestWithContext(ctx, method, clockifyEndpoint+path, reader)
+		request.Header.Set("X-Api-Key", c.Token)
+	}
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	return request
+}
+
+// For requests which change something, where only whether they succeeded matters
+func doTimeTrackingRequest(request *http.Request) error {
+	response, err := defaultClient.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 256))
+		return fmt.Errorf("unexpected status code %d, response: %s", response.StatusCode, body)
+	}
+
+	return nil
+}
+
+// Must be called with the mutex held
+func (c *TimeTrackingClient) identify(ctx context.Context) error {
+	if c.WorkspaceID != "" && (c.Service == "toggl" || c.userID != "") {
+		return nil
+	}
+
+	if c.Service == "toggl" {
+		response, err := decodeJsonFromRequest[struct {
+			DefaultWorkspaceID int64 `json:"default_workspace_id"`
+		}](defaultClient, c.newRequest(ctx, "GET", "/me", nil))
+
+		if err != nil {
+			return fmt.Errorf("could not get user: %v", err)
+		}
+
+		c.WorkspaceID = strconv.FormatInt(response.DefaultWorkspaceID, 10)
+		return nil
+	}
+
+	response, err := decodeJsonFromRequest[struct {
+		ID              string `json:"id"`
+		ActiveWorkspace string `json:"activeWorkspace"`
+	}](defaultClient, c.newRequest(ctx, "GET", "/user", nil))
+
+	if err != nil {
+		return fmt.Errorf("could not get user: %v", err)
+	}
+
+	c.userID = response.ID
+
+	if c.WorkspaceID == "" {
+		c.WorkspaceID = response.ActiveWorkspace
+	}
+
+	return nil
+}
+
+// Must be called with the mutex held
+func (c *TimeTrackingClient) entries(ctx context.Context, from, to time.Time) ([]timeEntry, error) {
+	if c.Service == "toggl" {
+		query := url.Values{}
+		query.Set("start_date", from.Format(time.RFC3339))
+		query.Set("end_date", to.Format(time.RFC3339))
+
+		response, err := decodeJsonFromRequest[[]togglTimeEntryJson](defaultClient, c.newRequest(ctx, "GET", "/me/time_entries?"+query.Encode(), nil))
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries := make([]timeEntry, len(response))
+
+		for i := range response {
+			entries[i] = timeEntry{description: response[i].Description, start: response[i].Start}
+
+			if response[i].Stop != nil {
+				entries[i].stop = *response[i].Stop
+			}
+		}
+
+		return entries, nil
+	}
+
+	query := url.Values{}
+	query.Set("start", from.UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("end", to.UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("page-size", "1000")
+
+	path := fmt.Sprintf("/workspaces/%s/user/%s/time-entries?%s", url.PathEscape(c.WorkspaceID), url.PathEscape(c.userID), query.Encode())
+	response, err := decodeJsonFromRequest[[]clockifyTimeEntryJson](defaultClient, c.newRequest(ctx, "GET", path, nil))
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]timeEntry, len(response))
+
+	for i := range response {
+		entries[i] = timeEntry{description: response[i].Description, start: response[i].TimeInterval.Start}
+
+		if response[i].TimeInterval.End != nil {
+			entries[i].stop = *response[i].TimeInterval.End
+		}
+	}
+
+	return entries, nil
+}
+
+// Weeks start on Monday
+func startOfWeek(now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+}
+
+// Returns the time tracked today and this week, including the timer that's running
+func (c *TimeTrackingClient) FetchSummary(ctx context.Context) (*TimeTracking, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.identify(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekStart := startOfWeek(now)
+
+	// entries which started the day before the week can still run into it
+	entries, err := c.entries(ctx, weekStart.AddDate(0, 0, -1), now.Add(time.Minute))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not get time entries: %v", ErrNoContent, err)
+	}
+
+	summary := &TimeTracking{}
+
+	for _, entry := range entries {
+		stop := entry.stop
+
+		if stop.IsZero() {
+			stop = now
+			summary.Running = &TimeEntry{Description: entry.description, Start: entry.start}
+		}
+
+		summary.Week += overlap(entry.start, stop, weekStart, now)
+		summary.Today += overlap(entry.start, stop, today, now)
+	}
+
+	return summary, nil
+}
+
+func overlap(start, stop, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+
+	if stop.After(to) {
+		stop = to
+	}
+
+	return max(stop.Sub(start), 0)
+}
+
+func (c *TimeTrackingClient) StartTimer(ctx context.Context, description string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.identify(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if c.Service == "toggl" {
+		workspaceID, _ := strconv.ParseInt(c.WorkspaceID, 10, 64)
+
+		return doTimeTrackingRequest(c.newRequest(ctx, "POST", "/workspaces/"+url.PathEscape(c.WorkspaceID)+"/time_entries", map[string]any{
+			"created_with": "Glance",
+			"description":  description,
+			"workspace_id": workspaceID,
+			"start":        now.Format(time.RFC3339),
+			"duration":     -1,
+		}))
+	}
+
+	return doTimeTrackingRequest(c.newRequest(ctx, "POST", "/workspaces/"+url.PathEscape(c.WorkspaceID)+"/time-entries", map[string]any{
+		"description": description,
+		"start":       now.Format("2006-01-02T15:04:05Z"),
+	}))
+}
+
+func (c *TimeTrackingClient) StopTimer(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.identify(ctx); err != nil {
+		return err
+	}
+
+	if c.Service == "toggl" {
+		current, err := decodeJsonFromRequest[*togglTimeEntryJson](defaultClient, c.newRequest(ctx, "GET", "/me/time_entries/current", nil))
+
+		if err != nil {
+			return err
+		}
+
+		if current == nil {
+			return ErrNoRunningTimer
+		}
+
+		return doTimeTrackingRequest(c.newRequest(ctx, "PATCH", fmt.Sprintf("/workspaces/%s/time_entries/%d/stop", url.PathEscape(c.WorkspaceID), current.ID), nil))
+	}
+
+	path := fmt.Sprintf("/workspaces/%s/user/%s/time-entries", url.PathEscape(c.WorkspaceID), url.PathEscape(c.userID))
+	request := c.newRequest(ctx, "PATCH", path, map[string]any{
+		"end": time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	})
+
+	response, err := defaultClient.Do(request)
+
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	// clockify responds with 404 when there's nothing to stop
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNoRunningTimer
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type Lesson struct {
+	Subject  string
+	Teacher  string
+	Room     string
+	StartsAt time.Time
+	EndsAt   time.Time
+	// substitutions, room changes and other changes to the usual lesson
+	IsChanged   bool
+	IsCancelled bool
+	// what the school wrote about the change, if anything
+	Note string
+}
+
+// The lessons of a timetable published as a calendar, such as the one exported by the
+// school's software. Calendars only mark cancellations, so no lesson shows up as changed
+func FetchICSTimetable(ctx context.Context, client RequestDoer, calendarURL string, from, to time.Time, location *time.Location) ([]Lesson, error) {
+	events, err := fetchCalendarEventsTask(ctx, &CalendarEventsRequest{
+		URL:              calendarURL,
+		From:             from,
+		To:               to,
+		Location:         location,
+		Client:           client,
+		IncludeCancelled: true,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	lessons := make([]Lesson, 0, len(events))
+
+	for i := range events {
+		if events[i].IsAllDay {
+			continue
+		}
+
+		lessons = append(lessons, Lesson{
+			Subject:     events[i].Title,
+			Room:        events[i].Location,
+			StartsAt:    events[i].StartsAt.In(location),
+			EndsAt:      events[i].EndsAt.In(location),
+			IsCancelled: events[i].IsCancelled,
+		})
+	}
+
+	sortLessons(lessons)
+
+	return lessons, nil
+}
+
+func sortLessons(lessons []Lesson) {
+	slices.SortStableFunc(lessons, func(a, b Lesson) int {
+		return a.StartsAt.Compare(b.StartsAt)
+	})
+}
+
+type WebUntisRequest struct {
+	// e.g. nessa.webuntis.com
+	Server   string
+	School   string
+	Username string
+	Password string
+	// when empty, the timetable of the user that logs in
+	Class    string
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+	Client   RequestDoer
+}
+
+// WebUntis has a JSON-RPC API which uses the session cookie set when logging in
+type webUntisSession struct {
+	endpoint string
+	cookie   string
+	client   RequestDoer
+}
+
+func (s *webUntisSession) call(ctx context.Context, method string, params any) (gjson.Result, error) {
+	body, err := json.Marshal(map[string]any{
+		"id":      "glance",
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", s.endpoint, bytes.NewReader(body))
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if s.cookie != "" {
+		request.Header.Set("Cookie", s.cookie)
+	}
+
+	response, err := s.client.Do(request)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return gjson.Result{}, fmt.Errorf("unexpected status code %d from %s", response.StatusCode, method)
+	}
+
+	for _, cookie := range response.Cookies() {
+		if cookie.Name == "JSESSIONID" {
+			s.cookie = cookie.Name + "=" + cookie.Value
+		}
+	}
+
+	body, err = io.ReadAll(response.Body)
+
+	if err != nil {
+		return gjson.Result{}, err
+	}
+
+	result := gjson.ParseBytes(body)
+
+	if message := result.Get("error.message"); message.Exists() {
+		return gjson.Result{}, fmt.Errorf("%s: %s", method, message.String())
+	}
+
+	return result.Get("result"), nil
+}
+
+func FetchWebUntisTimetable(ctx context.Context, request *WebUntisRequest) ([]Lesson, error) {
+	session := &webUntisSession{
+		endpoint: "https://" + request.Server + "/WebUntis/jsonrpc.do?school=" + url.QueryEscape(request.School),
+		client:   clientOrDefault(request.Client),
+	}
+
+	user, err := session.call(ctx, "authenticate", map[string]string{
+		"user":     request.Username,
+		"password": request.Password,
+		"client":   "glance",
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not log in: %v", ErrNoContent, err)
+	}
+
+	// sessions are kept around by WebUntis until they're logged out of
+	defer session.call(context.WithoutCancel(ctx), "logout", map[string]string{})
+
+	lessons, err := fetchWebUntisLessons(ctx, session, request, user)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	return lessons, nil
+}
+
+const (
+	webUntisElementClass   = 1
+	webUntisElementStudent = 5
+)
+
+func fetchWebUntisLessons(ctx context.Context, session *webUntisSession, request *WebUntisRequest, user gjson.Result) ([]Lesson, error) {
+	var elementID, elementType int64
+
+	if request.Class != "" {
+		classes, err := session.call(ctx, "getKlassen", map[string]string{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, class := range classes.Array() {
+			if strings.EqualFold(class.Get("name").String(), request.Class) {
+				elementID, elementType = class.Get("id").Int(), webUntisElementClass
+				break
+			}
+		}
+
+		if elementID == 0 {
+			return nil, fmt.Errorf("class %q not found", request.Class)
+		}
+	} else if user.Get("personType").Int() == webUntisElementStudent {
+		elementID, elementType = user.Get("personId").Int(), webUntisElementStudent
+	} else if user.Get("klasseId").Int() != 0 {
+		elementID, elementType = user.Get("klasseId").Int(), webUntisElementClass
+	} else {
+		return nil, errors.New("the account isn't a student's, so the class has to be specified")
+	}
+
+	fields := []string{"name", "longname"}
+
+	timetable, err := session.call(ctx, "getTimetable", map[string]any{
+		"options": map[string]any{
+			"element":       map[string]any{"id": elementID, "type": elementType, "keyType": "id"},
+			"startDate":     request.From.In(request.Location).Format("20060102"),
+			"endDate":       request.To.In(request.Location).Format("20060102"),
+			"showSubstText": true,
+			"showInfo":      true,
+			"subjectFields": fields,
+			"teacherFields": fields,
+			"roomFields":    fields,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lessons []Lesson
+
+	for _, item := range timetable.Array() {
+		date := item.Get("date").String()
+		startsAt, err := parseWebUntisTime(date, item.Get("startTime").Int(), request.Location)
+
+		if err != nil {
+			continue
+		}
+
+		endsAt, err := parseWebUntisTime(date, item.Get("endTime").Int(), request.Location)
+
+		if err != nil {
+			continue
+		}
+
+		lesson := Lesson{
+			Subject:     joinWebUntisNames(item.Get("su"), "longname"),
+			Teacher:     joinWebUntisNames(item.Get("te"), "name"),
+			Room:        joinWebUntisNames(item.Get("ro"), "name"),
+			StartsAt:    startsAt,
+			EndsAt:      endsAt,
+			IsCancelled: item.Get("code").String() == "cancelled",
+			IsChanged:   item.Get("code").String() == "irregular",
+		}
+
+		// the original teacher or room is set when it was changed
+		if item.Get("te.#(orgid>0)").Exists() || item.Get("ro.#(orgid>0)").Exists() {
+			lesson.IsChanged = true
+		}
+
+		var notes []string
+
+		for _, note := range []string{item.Get("substText").String(), item.Get("info").String()} {
+			if note != "" {
+				notes = append(notes, note)
+			}
+		}
+
+		lesson.Note = strings.Join(notes, " · ")
+
+		if lesson.Subject == "" {
+			lesson.Subject = item.Get("lstext").String()
+		}
+
+		lessons = append(lessons, lesson)
+	}
+
+	sortLessons(lessons)
+
+	return lessons, nil
+}
+
+// The date is e.g. 20261015 and the time 745 for 07:45
+func parseWebUntisTime(date string, clock int64, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation("20060102 15:04", fmt.Sprintf("%s %02d:%02d", date, clock/100, clock%100), location)
+}
+
+// Uses the short name when the long one isn't there
+func joinWebUntisNames(elements gjson.Result, field string) string {
+	var names []string
+
+	for _, element := range elements.Array() {
+		name := element.Get(field).String()
+
+		if name == "" {
+			name = element.Get("name").String()
+		}
+
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,246 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Spans are sent to an OTLP endpoint using the JSON encoding over HTTP,
+// which is simple enough not to warrant pulling in the OpenTelemetry SDK
+
+const (
+	tracingExportInterval = 5 * time.Second
+	// spans past this get dropped when the endpoint can't keep up
+	tracingMaxBufferedSpans = 4096
+	tracingServiceName      = "glance"
+)
+
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+)
+
+type Span struct {
+	traceID    [16]byte
+	spanID     [8]byte
+	parentID   [8]byte
+	name       string
+	kind       int
+	start      time.Time
+	attributes []string
+}
+
+type spanContextKey struct{}
+
+var tracer struct {
+	mu       sync.Mutex
+	endpoint string
+	spans    []map[string]any
+	started  bool
+	client   *http.Client
+}
+
+// An empty endpoint disables tracing, the path of the traces is added to it
+// unless it's already there, e.g. http://tempo:4318 becomes http://tempo:4318/v1/traces
+func SetTracingEndpoint(endpoint string) {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	if endpoint != "" && !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint = strings.TrimRight(endpoint, "/") + "/v1/traces"
+	}
+
+	tracer.endpoint = endpoint
+
+	if endpoint != "" && !tracer.started {
+		tracer.started = true
+		// not the default client so that exporting doesn't get traced itself
+		tracer.client = &http.Client{Timeout: 10 * time.Second}
+		go exportSpansEvery(tracingExportInterval)
+	}
+}
+
+func tracingEnabled() bool {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	return tracer.endpoint != ""
+}
+
+// Returns a nil span when tracing is disabled, which is safe to use. The
+// attributes are pairs of keys and values, the same as the ones of slog
+func StartSpan(ctx context.Context, name string, attributes ...string) (context.Context, *Span) {
+	return startSpan(ctx, name, spanKindInternal, attributes...)
+}
+
+// For the requests handled by the server, such as the ones that render pages
+func StartServerSpan(ctx context.Context, name string, attributes ...string) (context.Context, *Span) {
+	return startSpan(ctx, name, spanKindServer, attributes...)
+}
+
+func startSpan(ctx context.Context, name string, kind int, attributes ...string) (context.Context, *Span) {
+	if !tracingEnabled() {
+		return ctx, nil
+	}
+
+	span := &Span{
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+
+	rand.Read(span.spanID[:])
+
+	if parent, ok := ctx.Value(spanContextKey{}).(*Span); ok {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+func (s *Span) SetAttributes(attributes ...string) {
+	if s == nil {
+		return
+	}
+
+	s.attributes = append(s.attributes, attributes...)
+}
+
+// The span is marked as failed when err isn't nil
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	attributes := make([]map[string]any, 0, len(s.attributes)/2)
+
+	for i := 0; i+1 < len(s.attributes); i += 2 {
+		attributes = append(attributes, map[string]any{
+			"key":   s.attributes[i],
+			"value": map[string]string{"stringValue": s.attributes[i+1]},
+		})
+	}
+
+	span := map[string]any{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(time.Now().UnixNano(), 10),
+		"attributes":        attributes,
+	}
+
+	if s.parentID != [8]byte{} {
+		span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+
+	if err != nil {
+		span["status"] = map[string]any{"code": 2, "message": err.Error()}
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+
+	if len(tracer.spans) < tracingMaxBufferedSpans {
+		tracer.spans = append(tracer.spans, span)
+	}
+}
+
+// The query isn't included in the URL since it can contain things like API keys
+func startRequestSpan(request *http.Request) *Span {
+	_, span := startSpan(
+		request.Context(),
+		request.Method,
+		spanKindClient,
+		"http.request.method", request.Method,
+		"server.address", request.URL.Host,
+		"url.full", request.URL.Scheme+"://"+request.URL.Host+request.URL.Path,
+	)
+
+	return span
+}
+
+func (s *Span) endRequest(response *http.Response, err error) {
+	if s == nil {
+		return
+	}
+
+	if err == nil {
+		s.SetAttributes("http.response.status_code", strconv.Itoa(response.StatusCode))
+
+		if response.StatusCode >= 400 {
+			err = fmt.Errorf("status code %d", response.StatusCode)
+		}
+	}
+
+	s.End(err)
+}
+
+func exportSpansEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		tracer.mu.Lock()
+		spans := tracer.spans
+		endpoint := tracer.endpoint
+		tracer.spans = nil
+		tracer.mu.Unlock()
+
+		if len(spans) == 0 || endpoint == "" {
+			continue
+		}
+
+		if err := exportSpans(endpoint, spans); err != nil {
+			slog.Error("Failed to export traces", "endpoint", endpoint, "error", err)
+		}
+	}
+}
+
+func exportSpans(endpoint string, spans []map[string]any) error {
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": []map[string]any{{
+					"key":   "service.name",
+					"value": map[string]string{"stringValue": tracingServiceName},
+				}},
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]string{"name": tracingServiceName},
+				"spans": spans,
+			}},
+		}},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	response, err := tracer.client.Post(endpoint, "application/json", bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
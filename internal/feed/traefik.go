@@ -0,0 +1,162 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type TraefikDiscoveryRequest struct {
+	APIURL        string
+	Username      string
+	Password      string
+	Group         string
+	AllowInsecure bool
+	CheckBackends bool
+}
+
+type traefikRouterJson struct {
+	Name     string    `json:"name"`
+	Provider string    `json:"provider"`
+	Service  string    `json:"service"`
+	Rule     string    `json:"rule"`
+	Status   string    `json:"status"`
+	TLS      *struct{} `json:"tls"`
+}
+
+type traefikServiceJson struct {
+	Name         string `json:"name"`
+	LoadBalancer *struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+	} `json:"loadBalancer"`
+}
+
+var traefikRuleHostPattern = regexp.MustCompile("Host\\(\\s*`([^`]+)`")
+var traefikRulePathPattern = regexp.MustCompile("PathPrefix\\(\\s*`([^`]+)`")
+
+func newTraefikRequest(ctx context.Context, request *TraefikDiscoveryRequest, path string) (*http.Request, error) {
+	// the API paginates responses with a default of 100 items per page
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(request.APIURL, "/")+path+"?per_page=1000", nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Username != "" {
+		httpRequest.SetBasicAuth(request.Username, request.Password)
+	}
+
+	return httpRequest, nil
+}
+
+// Names of routers and services are suffixed with @provider, which
+// can be omitted when referencing a service from the same provider
+func traefikQualifiedName(name, provider string) string {
+	if strings.Contains(name, "@") {
+		return name
+	}
+
+	return name + "@" + provider
+}
+
+// Lists the HTTP routers from Traefik's API, the URL is inferred from the first
+// Host and PathPrefix matchers of the router's rule, using https if it has TLS enabled
+func DiscoverServicesFromTraefik(ctx context.Context, request *TraefikDiscoveryRequest) (DiscoveredServices, error) {
+	client := defaultClient
+
+	if request.AllowInsecure {
+		client = defaultInsecureClient
+	}
+
+	httpRequest, err := newTraefikRequest(ctx, request, "/api/http/routers")
+
+	if err != nil {
+		return nil, err
+	}
+
+	routers, err := decodeJsonFromRequest[[]traefikRouterJson](client, httpRequest)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not list routers: %w", err)
+	}
+
+	backends := make(map[string]string)
+
+	if request.CheckBackends {
+		httpRequest, err := newTraefikRequest(ctx, request, "/api/http/services")
+
+		if err != nil {
+			return nil, err
+		}
+
+		traefikServices, err := decodeJsonFromRequest[[]traefikServiceJson](client, httpRequest)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not list services: %w", err)
+		}
+
+		for i := range traefikServices {
+			loadBalancer := traefikServices[i].LoadBalancer
+
+			if loadBalancer != nil && len(loadBalancer.Servers) > 0 {
+				backends[traefikServices[i].Name] = loadBalancer.Servers[0].URL
+			}
+		}
+	}
+
+	services := make(DiscoveredServices, 0, len(routers))
+	indexByAddress := make(map[string]int, len(routers))
+
+	for i := range routers {
+		router := &routers[i]
+
+		if router.Provider == "internal" || router.Status != "enabled" {
+			continue
+		}
+
+		hostMatch := traefikRuleHostPattern.FindStringSubmatch(router.Rule)
+
+		if hostMatch == nil {
+			continue
+		}
+
+		address := hostMatch[1]
+
+		if pathMatch := traefikRulePathPattern.FindStringSubmatch(router.Rule); pathMatch != nil && pathMatch[1] != "/" {
+			address += pathMatch[1]
+		}
+
+		scheme := "http"
+
+		if router.TLS != nil {
+			scheme = "https"
+		}
+
+		// the same service is commonly exposed through separate routers
+		// for http and https, in which case the https one is preferred
+		name, _, _ := strings.Cut(router.Name, "@")
+		service := DiscoveredService{
+			Name:     name,
+			URL:      scheme + "://" + address,
+			CheckURL: backends[traefikQualifiedName(router.Service, router.Provider)],
+			Group:    request.Group,
+		}
+
+		if index, exists := indexByAddress[address]; exists {
+			if scheme == "https" {
+				services[index] = service
+			}
+
+			continue
+		}
+
+		indexByAddress[address] = len(services)
+		services = append(services, service)
+	}
+
+	return services.SortByName(), nil
+}
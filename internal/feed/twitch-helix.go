@@ -0,0 +1,274 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const twitchHelixEndpoint = "https://api.twitch.tv/helix"
+const twitchOAuthEndpoint = "https://id.twitch.tv/oauth2"
+
+// Helix only returns up to 100 streams per page, this is plenty for anyone
+const twitchHelixMaxPages = 5
+
+// Makes requests to the Helix API on behalf of a user. The client ID and the
+// ID of the user are taken from the access token, and if a refresh token along
+// with the client secret are specified, the access token gets refreshed once
+// it expires
+type TwitchHelixClient struct {
+	AccessToken  string
+	RefreshToken string
+	ClientID     string
+	ClientSecret string
+
+	mu        sync.Mutex
+	userID    string
+	validated bool
+}
+
+type twitchValidateResponseJson struct {
+	ClientID string `json:"client_id"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+type twitchRefreshResponseJson struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (c *TwitchHelixClient) canRefresh() bool {
+	return c.RefreshToken != "" && c.ClientSecret != ""
+}
+
+// Must be called with the mutex held
+func (c *TwitchHelixClient) refresh(ctx context.Context) error {
+	if !c.canRefresh() {
+		return errors.New("access token is invalid or has expired")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", c.RefreshToken)
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	request, _ := http.NewRequestWithContext(ctx, "POST", twitchOAuthEndpoint+"/token", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := decodeJsonFromRequest[twitchRefreshResponseJson](defaultClient, request)
+
+	if err != nil {
+		return fmt.Errorf("could not refresh access token: %v", err)
+	}
+
+	c.AccessToken = response.AccessToken
+
+	// refresh tokens of public clients get rotated on every use
+	if response.RefreshToken != "" {
+		c.RefreshToken = response.RefreshToken
+	}
+
+	return nil
+}
+
+// Must be called with the mutex held
+func (c *TwitchHelixClient) validate(ctx context.Context) error {
+	if c.validated {
+		return nil
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", twitchOAuthEndpoint+"/validate", nil)
+	request.Header.Set("Authorization", "OAuth "+c.AccessToken)
+	response, err := decodeJsonFromRequest[twitchValidateResponseJson](defaultClient, request)
+
+	if err != nil {
+		if c.ClientID == "" || !c.canRefresh() {
+			return fmt.Errorf("could not validate access token: %v", err)
+		}
+
+		if err = c.refresh(ctx); err != nil {
+			return err
+		}
+
+		request, _ = http.NewRequestWithContext(ctx, "GET", twitchOAuthEndpoint+"/validate", nil)
+		request.Header.Set("Authorization", "OAuth "+c.AccessToken)
+		response, err = decodeJsonFromRequest[twitchValidateResponseJson](defaultClient, request)
+
+		if err != nil {
+			return fmt.Errorf("could not validate access token: %v", err)
+		}
+	}
+
+	if c.ClientID == "" {
+		c.ClientID = response.ClientID
+	}
+
+	c.userID = response.UserID
+	c.validated = true
+
+	return nil
+}
+
+// Adds the authorization headers and refreshes the access token
+// once if it has expired, only used for requests without a body
+func (c *TwitchHelixClient) Do(request *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	token, clientID := c.AccessToken, c.ClientID
+	c.mu.Unlock()
+
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set("Client-Id", clientID)
+
+	response, err := defaultClient.Do(request)
+
+	if err != nil || response.StatusCode != http.StatusUnauthorized || !c.canRefresh() {
+		return response, err
+	}
+
+	response.Body.Close()
+
+	c.mu.Lock()
+
+	// another request could have already refreshed the token
+	if c.AccessToken == token {
+		err = c.refresh(request.Context())
+	}
+
+	token = c.AccessToken
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	retry := request.Clone(request.Context())
+	retry.Header.Set("Authorization", "Bearer "+token)
+
+	return defaultClient.Do(retry)
+}
+
+type twitchHelixStreamsResponseJson struct {
+	Data []struct {
+		UserID      string `json:"user_id"`
+		UserLogin   string `json:"user_login"`
+		UserName    string `json:"user_name"`
+		GameName    string `json:"game_name"`
+		Title       string `json:"title"`
+		ViewerCount int    `json:"viewer_count"`
+		StartedAt   string `json:"started_at"`
+	} `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+type twitchHelixUsersResponseJson struct {
+	Data []struct {
+		ID              string `json:"id"`
+		ProfileImageUrl string `json:"profile_image_url"`
+	} `json:"data"`
+}
+
+var twitchCategorySlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Helix doesn't return the slug of categories, this matches
+// the one used by Twitch for the vast majority of them
+func twitchCategorySlug(name string) string {
+	return strings.Trim(twitchCategorySlugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func FetchFollowedLiveChannelsFromTwitch(ctx context.Context, client *TwitchHelixClient) (TwitchChannels, error) {
+	client.mu.Lock()
+	err := client.validate(ctx)
+	userID := client.userID
+	client.mu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	channels := make(TwitchChannels, 0)
+	userIDs := make([]string, 0)
+	cursor := ""
+
+	for page := 0; page < twitchHelixMaxPages; page++ {
+		query := url.Values{}
+		query.Set("user_id", userID)
+		query.Set("first", "100")
+
+		if cursor != "" {
+			query.Set("after", cursor)
+		}
+
+		request, _ := http.NewRequestWithContext(ctx, "GET", twitchHelixEndpoint+"/streams/followed?"+query.Encode(), nil)
+		response, err := decodeJsonFromRequest[twitchHelixStreamsResponseJson](client, request)
+
+		if err != nil {
+			if page == 0 {
+				return nil, fmt.Errorf("%w: could not fetch followed streams: %v", ErrNoContent, err)
+			}
+
+			return channels, fmt.Errorf("%w: could not fetch all followed streams: %v", ErrPartialContent, err)
+		}
+
+		for i := range response.Data {
+			stream := &response.Data[i]
+			startedAt, _ := time.Parse(time.RFC3339, stream.StartedAt)
+
+			channels = append(channels, TwitchChannel{
+				Login:        stream.UserLogin,
+				Exists:       true,
+				Name:         stream.UserName,
+				StreamTitle:  stream.Title,
+				IsLive:       true,
+				LiveSince:    startedAt,
+				Category:     stream.GameName,
+				CategorySlug: twitchCategorySlug(stream.GameName),
+				ViewersCount: stream.ViewerCount,
+			})
+
+			userIDs = append(userIDs, stream.UserID)
+		}
+
+		cursor = response.Pagination.Cursor
+
+		if cursor == "" {
+			break
+		}
+	}
+
+	avatars := make(map[string]string, len(userIDs))
+
+	for start := 0; start < len(userIDs); start += 100 {
+		query := url.Values{}
+
+		for _, id := range userIDs[start:min(start+100, len(userIDs))] {
+			query.Add("id", id)
+		}
+
+		request, _ := http.NewRequestWithContext(ctx, "GET", twitchHelixEndpoint+"/users?"+query.Encode(), nil)
+		response, err := decodeJsonFromRequest[twitchHelixUsersResponseJson](client, request)
+
+		if err != nil {
+			return channels, fmt.Errorf("%w: could not fetch channel avatars: %v", ErrPartialContent, err)
+		}
+
+		for i := range response.Data {
+			avatars[response.Data[i].ID] = response.Data[i].ProfileImageUrl
+		}
+	}
+
+	for i := range channels {
+		channels[i].AvatarUrl = avatars[userIDs[i]]
+	}
+
+	return channels, nil
+}
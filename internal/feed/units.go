@@ -0,0 +1,62 @@
+package feed
+
+// Feeds always request metric values from their sources and convert them
+// through these helpers, so that every widget converts units the same way
+type UnitSystem string
+
+const (
+	MetricUnits   UnitSystem = "metric"
+	ImperialUnits UnitSystem = "imperial"
+)
+
+func (u UnitSystem) IsValid() bool {
+	return u == MetricUnits || u == ImperialUnits
+}
+
+func (u UnitSystem) Temperature(celsius float64) float64 {
+	if u == ImperialUnits {
+		return celsius*9/5 + 32
+	}
+
+	return celsius
+}
+
+func (u UnitSystem) TemperatureSymbol() string {
+	if u == ImperialUnits {
+		return "°F"
+	}
+
+	return "°C"
+}
+
+func (u UnitSystem) WindSpeed(kmh float64) float64 {
+	if u == ImperialUnits {
+		return kmh / 1.609344
+	}
+
+	return kmh
+}
+
+func (u UnitSystem) WindSpeedSymbol() string {
+	if u == ImperialUnits {
+		return "mph"
+	}
+
+	return "km/h"
+}
+
+func (u UnitSystem) Distance(km float64) float64 {
+	if u == ImperialUnits {
+		return km / 1.609344
+	}
+
+	return km
+}
+
+func (u UnitSystem) DistanceSymbol() string {
+	if u == ImperialUnits {
+		return "mi"
+	}
+
+	return "km"
+}
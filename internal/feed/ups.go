@@ -0,0 +1,284 @@
+package feed
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	UPSServiceNUT     = "nut"
+	UPSServiceApcupsd = "apcupsd"
+)
+
+const upsTimeout = 10 * time.Second
+
+type UPSStatus struct {
+	Model      string
+	OnBattery  bool
+	LowBattery bool
+	Charging   bool
+	// in percent, -1 when the UPS doesn't report it
+	Charge float64
+	Load   float64
+	// 0 when the UPS doesn't report it
+	Runtime      time.Duration
+	InputVoltage float64
+}
+
+func (s *UPSStatus) StatusLabel() string {
+	switch {
+	case s.OnBattery && s.LowBattery:
+		return "Low battery"
+	case s.OnBattery:
+		return "On battery"
+	case s.Charging:
+		return "Charging"
+	}
+
+	return "Online"
+}
+
+func dialUPS(ctx context.Context, address, defaultPort string) (net.Conn, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultPort)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, upsTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	return conn, nil
+}
+
+func parseUPSFloat(value string, fallback float64) float64 {
+	// apcupsd includes the unit, such as "100.0 Percent"
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+	number, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		return fallback
+	}
+
+	return number
+}
+
+// Reads the variables of the UPS from a NUT server. The name of the UPS can be left
+// empty when the server only has one, in which case the first one it lists is used
+func FetchUPSStatusFromNUT(ctx context.Context, address, name string) (*UPSStatus, error) {
+	conn, err := dialUPS(ctx, address, "3493")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	// each line of the response is one item, with the values of each item quoted
+	list := func(command, prefix string) ([][]string, error) {
+		if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+			return nil, err
+		}
+
+		var items [][]string
+
+		for {
+			line, err := reader.ReadString('\n')
+
+			if err != nil {
+				return nil, err
+			}
+
+			line = strings.TrimSpace(line)
+
+			if strings.HasPrefix(line, "ERR ") {
+				return nil, fmt.Errorf("server responded with %s", strings.TrimPrefix(line, "ERR "))
+			}
+
+			if strings.HasPrefix(line, "END LIST") {
+				return items, nil
+			}
+
+			if strings.HasPrefix(line, prefix+" ") {
+				items = append(items, splitNUTLine(strings.TrimPrefix(line, prefix+" ")))
+			}
+		}
+	}
+
+	if name == "" {
+		upses, err := list("LIST UPS", "UPS")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(upses) == 0 || len(upses[0]) == 0 {
+			return nil, errors.New("server has no UPS")
+		}
+
+		name = upses[0][0]
+	}
+
+	variables, err := list("LIST VAR "+name, "VAR")
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(variables))
+
+	for _, variable := range variables {
+		// the name of the UPS, the name of the variable and its value
+		if len(variable) == 3 {
+			values[variable[1]] = variable[2]
+		}
+	}
+
+	fmt.Fprint(conn, "LOGOUT\n")
+
+	status := &UPSStatus{
+		Model:        strings.TrimSpace(values["ups.mfr"] + " " + values["ups.model"]),
+		Charge:       parseUPSFloat(values["battery.charge"], -1),
+		Load:         parseUPSFloat(values["ups.load"], -1),
+		Runtime:      time.Duration(parseUPSFloat(values["battery.runtime"], 0)) * time.Second,
+		InputVoltage: parseUPSFloat(values["input.voltage"], 0),
+	}
+
+	// flags such as "OL CHRG" or "OB DISCHRG LB"
+	for _, flag := range strings.Fields(values["ups.status"]) {
+		switch flag {
+		case "OB":
+			status.OnBattery = true
+		case "LB":
+			status.LowBattery = true
+		case "CHRG":
+			status.Charging = true
+		}
+	}
+
+	return status, nil
+}
+
+// Splits a line such as `ups battery.charge "100"` into its parts, the quoted ones
+// can contain spaces as well as quotes and backslashes escaped with a backslash
+func splitNUTLine(line string) []string {
+	var parts []string
+	var part strings.Builder
+	quoted, escaped, started := false, false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			part.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started || part.Len() > 0 {
+				parts = append(parts, part.String())
+				part.Reset()
+				started = false
+			}
+		default:
+			part.WriteRune(r)
+		}
+	}
+
+	if started || part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+
+	return parts
+}
+
+// Reads the status from the network information server of apcupsd, which sends it as
+// lines such as "BCHARGE  : 100.0 Percent", each prefixed with its length
+func FetchUPSStatusFromApcupsd(ctx context.Context, address string) (*UPSStatus, error) {
+	conn, err := dialUPS(ctx, address, "3551")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	request := binary.BigEndian.AppendUint16(nil, uint16(len("status")))
+
+	if _, err := conn.Write(append(request, "status"...)); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	values := make(map[string]string)
+
+	for {
+		var length uint16
+
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+
+		// the end of the status
+		if length == 0 {
+			break
+		}
+
+		line := make([]byte, length)
+
+		if _, err := io.ReadFull(reader, line); err != nil {
+			return nil, err
+		}
+
+		if key, value, found := strings.Cut(string(line), ":"); found {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("server sent an empty status")
+	}
+
+	status := &UPSStatus{
+		Model:        values["MODEL"],
+		Charge:       parseUPSFloat(values["BCHARGE"], -1),
+		Load:         parseUPSFloat(values["LOADPCT"], -1),
+		Runtime:      time.Duration(parseUPSFloat(values["TIMELEFT"], 0) * float64(time.Minute)),
+		InputVoltage: parseUPSFloat(values["LINEV"], 0),
+	}
+
+	// such as "ONLINE", "ONBATT LOWBATT" or "COMMLOST"
+	for _, flag := range strings.Fields(values["STATUS"]) {
+		switch flag {
+		case "ONBATT":
+			status.OnBattery = true
+		case "LOWBATT":
+			status.LowBattery = true
+		case "CHARGING":
+			status.Charging = true
+		case "COMMLOST":
+			return nil, errors.New("apcupsd lost the connection to the UPS")
+		}
+	}
+
+	return status, nil
+}
@@ -38,18 +38,31 @@ func SvgPolylineCoordsFromYValues(width float64, height float64, values []float6
 		return ""
 	}
 
+	return SvgPolylineCoordsFromYValuesInRange(width, height, values, slices.Min(values), slices.Max(values))
+}
+
+// Same as SvgPolylineCoordsFromYValues but with a fixed scale, useful for values such as percentages
+func SvgPolylineCoordsFromYValuesInRange(width float64, height float64, values []float64, min float64, max float64) string {
+	if len(values) < 2 {
+		return ""
+	}
+
 	verticalPadding := height * 0.02
 	height -= verticalPadding * 2
 	coordinates := make([]string, len(values))
 	distanceBetweenPoints := width / float64(len(values)-1)
-	min := slices.Min(values)
-	max := slices.Max(values)
 
 	for i := range values {
+		y := 0.5
+
+		if max > min {
+			y = (max - values[i]) / (max - min)
+		}
+
 		coordinates[i] = fmt.Sprintf(
 			"%.2f,%.2f",
 			float64(i)*distanceBetweenPoints,
-			((max-values[i])/(max-min))*height+verticalPadding,
+			y*height+verticalPadding,
 		)
 	}
 
@@ -0,0 +1,257 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTailscaleSocket = "/var/run/tailscale/tailscaled.sock"
+
+// WireGuard starts a new handshake every 2 minutes while there's traffic and
+// drops the session after 3, so peers without one since then aren't connected
+const wireGuardOnlineHandshakeAge = 3 * time.Minute
+
+type VPNPeer struct {
+	Name string `json:"name,omitempty"`
+	// only for WireGuard peers, which don't have names of their own
+	PublicKey string `json:"public-key,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Online    bool   `json:"online"`
+	// zero when there hasn't been one, for peers from the API of Tailscale
+	// it's when they were last seen by its coordination server instead
+	LastHandshake time.Time `json:"last-handshake"`
+	// nil when they're not known, which is the case for the API of Tailscale
+	RxBytes *uint64 `json:"rx-bytes,omitempty"`
+	TxBytes *uint64 `json:"tx-bytes,omitempty"`
+}
+
+type VPNPeersRequest struct {
+	// empty for the local WireGuard interfaces
+	URL           string
+	Token         string
+	AllowInsecure bool
+	Tailscale     bool
+	// the API of Tailscale is used when there's a key, otherwise the local API of tailscaled
+	TailscaleSocket string
+	TailscaleAPIKey string
+	Tailnet         string
+}
+
+// Each interface has a line with its own keys followed by a line for each of its peers,
+// with the interface, public key, preshared key, endpoint, allowed IPs, the Unix time of the
+// latest handshake, the received and sent bytes, and the persistent keepalive
+func parseWireGuardDump(dump string, now time.Time) []VPNPeer {
+	peers := make([]VPNPeer, 0)
+
+	for _, line := range strings.Split(dump, "\n") {
+		fields := strings.Split(line, "\t")
+
+		if len(fields) != 9 {
+			continue
+		}
+
+		peer := VPNPeer{
+			PublicKey: fields[1],
+			Interface: fields[0],
+		}
+
+		if fields[4] != "(none)" {
+			address, _, _ := strings.Cut(fields[4], ",")
+			peer.Address = strings.TrimSuffix(strings.TrimSuffix(address, "/32"), "/128")
+		}
+
+		if handshake, _ := strconv.ParseInt(fields[5], 10, 64); handshake > 0 {
+			peer.LastHandshake = time.Unix(handshake, 0)
+			peer.Online = now.Sub(peer.LastHandshake) < wireGuardOnlineHandshakeAge
+		}
+
+		rx, _ := strconv.ParseUint(fields[6], 10, 64)
+		tx, _ := strconv.ParseUint(fields[7], 10, 64)
+		peer.RxBytes = &rx
+		peer.TxBytes = &tx
+
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+// Lists the peers of all of the WireGuard interfaces of the machine Glance is running
+// on through wg, which needs to run as root or with the CAP_NET_ADMIN capability
+func FetchLocalWireGuardPeers(ctx context.Context) ([]VPNPeer, error) {
+	output, err := exec.CommandContext(ctx, "wg", "show", "all", "dump").Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("wg show: %v", err)
+	}
+
+	return parseWireGuardDump(string(output), time.Now()), nil
+}
+
+type tailscaleStatusJson struct {
+	Peer map[string]struct {
+		HostName      string    `json:"HostName"`
+		DNSName       string    `json:"DNSName"`
+		TailscaleIPs  []string  `json:"TailscaleIPs"`
+		Online        bool      `json:"Online"`
+		LastHandshake time.Time `json:"LastHandshake"`
+		RxBytes       uint64    `json:"RxBytes"`
+		TxBytes       uint64    `json:"TxBytes"`
+	} `json:"Peer"`
+}
+
+type tailscaleDevicesJson struct {
+	Devices []struct {
+		Name               string    `json:"name"`
+		Hostname           string    `json:"hostname"`
+		Addresses          []string  `json:"addresses"`
+		LastSeen           time.Time `json:"lastSeen"`
+		ConnectedToControl bool      `json:"connectedToControl"`
+	} `json:"devices"`
+}
+
+// The MagicDNS name without the name of the tailnet, which is what Tailscale shows
+func tailscaleMachineName(dnsName, hostName string) string {
+	if name, _, _ := strings.Cut(dnsName, "."); name != "" {
+		return name
+	}
+
+	return hostName
+}
+
+// Reads the peers of this machine from tailscaled, which includes the traffic to each
+// of them. The local API only accepts requests with its own name as the host
+func FetchTailscaleLocalPeers(ctx context.Context, socketPath string) ([]VPNPeer, error) {
+	if socketPath == "" {
+		socketPath = defaultTailscaleSocket
+	}
+
+	client := &http.Client{
+		Timeout: defaultClientTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "http://local-tailscaled.sock/localapi/v0/status", nil)
+	status, err := decodeJsonFromRequest[tailscaleStatusJson](client, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]VPNPeer, 0, len(status.Peer))
+
+	for _, node := range status.Peer {
+		peer := VPNPeer{
+			Name:          tailscaleMachineName(node.DNSName, node.HostName),
+			Online:        node.Online,
+			LastHandshake: node.LastHandshake,
+			RxBytes:       &node.RxBytes,
+			TxBytes:       &node.TxBytes,
+		}
+
+		if len(node.TailscaleIPs) > 0 {
+			peer.Address = node.TailscaleIPs[0]
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// Lists all of the devices of a tailnet, including the one Glance is running on, through
+// the API of Tailscale, which only knows whether they're connected to it but not their traffic
+func FetchTailscaleAPIPeers(ctx context.Context, client RequestDoer, tailnet, apiKey string) ([]VPNPeer, error) {
+	if tailnet == "" {
+		tailnet = "-"
+	}
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://api.tailscale.com/api/v2/tailnet/"+url.PathEscape(tailnet)+"/devices", nil)
+	request.Header.Set("Authorization", "Bearer "+apiKey)
+
+	response, err := decodeJsonFromRequest[tailscaleDevicesJson](clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]VPNPeer, 0, len(response.Devices))
+
+	for _, device := range response.Devices {
+		peer := VPNPeer{
+			Name:          tailscaleMachineName(device.Name, device.Hostname),
+			Online:        device.ConnectedToControl,
+			LastHandshake: device.LastSeen,
+		}
+
+		if len(device.Addresses) > 0 {
+			peer.Address = device.Addresses[0]
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+func fetchVPNPeersTask(ctx context.Context, request *VPNPeersRequest) ([]VPNPeer, error) {
+	switch {
+	case request.Tailscale && request.TailscaleAPIKey != "":
+		client := defaultClient
+
+		if request.AllowInsecure {
+			client = defaultInsecureClient
+		}
+
+		return FetchTailscaleAPIPeers(ctx, client, request.Tailnet, request.TailscaleAPIKey)
+	case request.Tailscale:
+		return FetchTailscaleLocalPeers(ctx, request.TailscaleSocket)
+	case request.URL == "":
+		return FetchLocalWireGuardPeers(ctx)
+	}
+
+	return fetchFromRemoteInstance[[]VPNPeer](ctx, request.URL, "/api/wireguard", request.Token, request.AllowInsecure)
+}
+
+func FetchVPNPeersForSources(ctx context.Context, requests []*VPNPeersRequest) ([][]VPNPeer, error) {
+	job := newJob(taskWithContext(ctx, fetchVPNPeersTask), requests).withWorkers(10).withContext(ctx)
+	results, errs, err := workerPoolDo(job)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+
+	for i := range results {
+		if errs[i] != nil {
+			failed++
+			results[i] = nil
+			slog.Error("Failed to fetch VPN peers", "url", requests[i].URL, "error", errs[i])
+		}
+	}
+
+	if failed == len(requests) {
+		return results, ErrNoContent
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%w: could not fetch VPN peers of %d source(s)", ErrPartialContent, failed)
+	}
+
+	return results, nil
+}
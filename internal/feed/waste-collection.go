@@ -0,0 +1,131 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const recollectEndpoint = "https://api.recollect.net/api"
+
+type WasteCollection struct {
+	// midnight of the day in the requested location
+	Date time.Time
+	// what gets picked up, as named by the source
+	Bins []string
+}
+
+// Municipal calendars usually have an all day event for each bin, titled with the
+// type of waste, so the events on the same day are merged into one collection
+func FetchICSWasteCollections(ctx context.Context, client RequestDoer, calendarURL string, from, to time.Time, location *time.Location) ([]WasteCollection, error) {
+	events, err := fetchCalendarEventsTask(ctx, &CalendarEventsRequest{
+		URL:      calendarURL,
+		From:     from,
+		To:       to,
+		Location: location,
+		Client:   client,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	events.SortByStart()
+	var collections []WasteCollection
+
+	for i := range events {
+		startsAt := events[i].StartsAt
+
+		// all day events are dates without a timezone and should not be shifted
+		if !events[i].IsAllDay {
+			startsAt = startsAt.In(location)
+		}
+
+		day := time.Date(startsAt.Year(), startsAt.Month(), startsAt.Day(), 0, 0, 0, 0, location)
+		collections = addWasteCollection(collections, day, strings.TrimSpace(events[i].Title))
+	}
+
+	return collections, nil
+}
+
+// ReCollect powers the waste calendars of many cities in North America, which look up
+// the place and service IDs when an address is entered
+func FetchRecollectCollections(ctx context.Context, client RequestDoer, placeID, serviceID string, from, to time.Time, location *time.Location) ([]WasteCollection, error) {
+	query := url.Values{}
+	query.Set("after", from.In(location).Format(time.DateOnly))
+	query.Set("before", to.In(location).Format(time.DateOnly))
+	query.Set("nomerge", "1")
+	query.Set("hide", "reminder_only,event_private")
+	query.Set("locale", "en")
+
+	request, err := http.NewRequestWithContext(
+		ctx, "GET",
+		recollectEndpoint+"/places/"+url.PathEscape(placeID)+"/services/"+url.PathEscape(serviceID)+"/events?"+query.Encode(),
+		nil,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	body, _, err := fetchRedactedJson(clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	var collections []WasteCollection
+
+	for _, event := range gjson.Get(body, "events").Array() {
+		day, err := time.ParseInLocation(time.DateOnly, event.Get("day").String(), location)
+
+		if err != nil {
+			continue
+		}
+
+		for _, flag := range event.Get("flags").Array() {
+			// holidays and reminders are flags as well
+			if eventType := flag.Get("event_type").String(); eventType != "" && eventType != "pickup" {
+				continue
+			}
+
+			name := flag.Get("subject").String()
+
+			if name == "" {
+				name = flag.Get("name").String()
+			}
+
+			collections = addWasteCollection(collections, day, name)
+		}
+	}
+
+	slices.SortStableFunc(collections, func(a, b WasteCollection) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	return collections, nil
+}
+
+func addWasteCollection(collections []WasteCollection, day time.Time, bin string) []WasteCollection {
+	if bin == "" {
+		return collections
+	}
+
+	for i := range collections {
+		if collections[i].Date.Equal(day) {
+			if !slices.Contains(collections[i].Bins, bin) {
+				collections[i].Bins = append(collections[i].Bins, bin)
+			}
+
+			return collections
+		}
+	}
+
+	return append(collections, WasteCollection{Date: day, Bins: []string{bin}})
+}
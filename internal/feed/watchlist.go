@@ -0,0 +1,205 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Large enough for thousands of symbols
+const maxWatchlistSize = 1 << 20
+
+type watchlistItemJson struct {
+	Symbol      string `json:"symbol"`
+	Name        string `json:"name"`
+	SymbolLink  string `json:"symbol-link"`
+	ChartLink   string `json:"chart-link"`
+	Currency    string `json:"currency"`
+	PercentOnly bool   `json:"percent-only"`
+}
+
+// Loads the symbols of a watchlist which is kept outside of the config, either from a
+// file or from a URL. The watchlist can be a CSV file, such as the one exported from
+// Yahoo Finance, or a JSON array of either symbols or objects with the same properties
+// as the markets in the config
+func FetchWatchlist(ctx context.Context, client RequestDoer, source string) ([]MarketRequest, error) {
+	var contents []byte
+	var isJSON bool
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		request, err := http.NewRequestWithContext(ctx, "GET", source, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := clientOrDefault(client).Do(request)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch watchlist: %v", err)
+		}
+
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("could not fetch watchlist: unexpected status code %d", response.StatusCode)
+		}
+
+		contents, err = io.ReadAll(io.LimitReader(response.Body, maxWatchlistSize))
+
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch watchlist: %v", err)
+		}
+
+		isJSON = strings.Contains(response.Header.Get("Content-Type"), "json")
+	} else {
+		file, err := os.Open(source)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read watchlist: %v", err)
+		}
+
+		defer file.Close()
+
+		contents, err = io.ReadAll(io.LimitReader(file, maxWatchlistSize))
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read watchlist: %v", err)
+		}
+	}
+
+	// the content type of files served as is usually doesn't say much
+	isJSON = isJSON || strings.HasSuffix(strings.ToLower(source), ".json")
+
+	return parseWatchlist(contents, isJSON)
+}
+
+func parseWatchlist(contents []byte, isJSON bool) ([]MarketRequest, error) {
+	var requests []MarketRequest
+	var err error
+
+	if isJSON {
+		requests, err = parseJSONWatchlist(contents)
+	} else {
+		requests, err = parseCSVWatchlist(contents)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(requests))
+	unique := requests[:0]
+
+	for _, request := range requests {
+		request.Symbol = strings.ToUpper(strings.TrimSpace(request.Symbol))
+
+		if request.Symbol == "" || seen[request.Symbol] {
+			continue
+		}
+
+		seen[request.Symbol] = true
+		unique = append(unique, request)
+	}
+
+	if len(unique) == 0 {
+		return nil, errors.New("watchlist does not contain any symbols")
+	}
+
+	return unique, nil
+}
+
+func parseJSONWatchlist(contents []byte) ([]MarketRequest, error) {
+	var symbols []string
+
+	if err := json.Unmarshal(contents, &symbols); err == nil {
+		requests := make([]MarketRequest, len(symbols))
+
+		for i := range symbols {
+			requests[i].Symbol = symbols[i]
+		}
+
+		return requests, nil
+	}
+
+	var items []watchlistItemJson
+
+	if err := json.Unmarshal(contents, &items); err != nil {
+		return nil, fmt.Errorf("watchlist must be an array of symbols or objects: %v", err)
+	}
+
+	requests := make([]MarketRequest, len(items))
+
+	for i := range items {
+		requests[i] = MarketRequest{
+			Symbol:       items[i].Symbol,
+			Name:         items[i].Name,
+			SymbolLink:   items[i].SymbolLink,
+			ChartLink:    items[i].ChartLink,
+			CurrencyCode: items[i].Currency,
+			PercentOnly:  items[i].PercentOnly,
+		}
+	}
+
+	return requests, nil
+}
+
+// Uses the symbol and name columns if there's a header row which has a symbol
+// column, otherwise the first column is the symbol and the second one the name
+func parseCSVWatchlist(contents []byte) ([]MarketRequest, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(contents, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV watchlist: %v", err)
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	symbolColumn, nameColumn := 0, 1
+
+	if header := slices.IndexFunc(records[0], isSymbolHeader); header != -1 {
+		symbolColumn = header
+		nameColumn = slices.IndexFunc(records[0], func(column string) bool {
+			return strings.EqualFold(strings.TrimSpace(column), "name")
+		})
+		records = records[1:]
+	}
+
+	requests := make([]MarketRequest, 0, len(records))
+
+	for _, record := range records {
+		if symbolColumn >= len(record) {
+			continue
+		}
+
+		request := MarketRequest{Symbol: record[symbolColumn]}
+
+		if nameColumn >= 0 && nameColumn < len(record) {
+			request.Name = strings.TrimSpace(record[nameColumn])
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+func isSymbolHeader(column string) bool {
+	column = strings.TrimSpace(column)
+
+	return strings.EqualFold(column, "symbol") || strings.EqualFold(column, "ticker")
+}
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+type WeatherProvider interface {
+	FetchWeather(ctx context.Context, place *PlaceJson, units UnitSystem, forecastDays int) (*Weather, error)
+	// the number of days, including today, that the provider can forecast
+	MaxForecastDays() int
+}
+
+const (
+	OpenMeteoWeatherProvider      = "open-meteo"
+	OpenWeatherMapWeatherProvider = "openweathermap"
+	MetNoWeatherProvider          = "met.no"
+)
+
+// The places are always looked up through Open-Meteo's geocoding
+// regardless of the provider since it doesn't require a key
+func NewWeatherProvider(name string, apiKey string) (WeatherProvider, error) {
+	switch name {
+	case "", OpenMeteoWeatherProvider:
+		return openMeteoWeatherProvider{}, nil
+	case OpenWeatherMapWeatherProvider:
+		if apiKey == "" {
+			return nil, fmt.Errorf("api-key is required for the %s provider", name)
+		}
+
+		return &openWeatherMapProvider{apiKey: apiKey}, nil
+	case MetNoWeatherProvider:
+		return metNoWeatherProvider{}, nil
+	default:
+		return nil, fmt.Errorf(
+			"unknown weather provider '%s', must be one of %s, %s or %s",
+			name, OpenMeteoWeatherProvider, OpenWeatherMapWeatherProvider, MetNoWeatherProvider,
+		)
+	}
+}
+
+// Unlike Open-Meteo, the other providers only return the forecast starting from the current
+// hour, the hours of today that have already passed get the values of the first one available
+func padHourlyForecastToMidnight(response *WeatherResponseJson, location *time.Location) {
+	hourly := &response.Hourly
+
+	if len(hourly.Time) == 0 {
+		return
+	}
+
+	first := time.Unix(hourly.Time[0], 0).In(location)
+	midnight := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, location)
+	missing := int(first.Sub(midnight) / time.Hour)
+
+	if missing <= 0 {
+		return
+	}
+
+	times := make([]int64, missing)
+	temperatures := make([]float64, missing)
+	precipitations := make([]int, missing)
+	codes := make([]int, missing)
+	windSpeeds := make([]float64, missing)
+
+	for i := range missing {
+		times[i] = midnight.Add(time.Duration(i) * time.Hour).Unix()
+		temperatures[i] = hourly.Temperature[0]
+		codes[i] = hourly.WeatherCode[0]
+		windSpeeds[i] = hourly.WindSpeed[0]
+	}
+
+	hourly.Time = append(times, hourly.Time...)
+	hourly.Temperature = append(temperatures, hourly.Temperature...)
+	hourly.PrecipitationProbability = append(precipitations, hourly.PrecipitationProbability...)
+	hourly.WeatherCode = append(codes, hourly.WeatherCode...)
+	hourly.WindSpeed = append(windSpeeds, hourly.WindSpeed...)
+}
+
+func localMidnightUnix(t time.Time, location *time.Location) int64 {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location).Unix()
+}
+
+// The Australian apparent temperature, for providers which don't return a feels like temperature
+func apparentTemperature(celsius float64, relativeHumidity float64, windSpeedMs float64) float64 {
+	vaporPressure := relativeHumidity / 100 * 6.105 * math.Exp(17.27*celsius/(237.7+celsius))
+	return celsius + 0.33*vaporPressure - 0.70*windSpeedMs - 4.00
+}
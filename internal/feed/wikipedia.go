@@ -0,0 +1,152 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Wikimedia asks for a user agent that says who's making the requests
+const wikipediaUserAgent = "glance (+https://github.com/glanceapp/glance)"
+
+// Categories and portals can be huge, only this many of their articles get picked from
+const wikipediaMaxTitles = 2000
+
+type WikipediaArticle struct {
+	Title string
+	// a short description such as "Species of bird"
+	Description  string
+	Extract      string
+	URL          string
+	ThumbnailURL string
+}
+
+func wikipediaHost(language string) string {
+	return "https://" + language + ".wikipedia.org"
+}
+
+func newWikipediaRequest(ctx context.Context, requestURL string) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if globalUserAgent() == "" {
+		request.Header.Set("User-Agent", wikipediaUserAgent)
+	}
+
+	return request, nil
+}
+
+// Gets the summary of a random article, or of the article with the given title when set
+func FetchWikipediaArticle(ctx context.Context, client RequestDoer, language, title string) (*WikipediaArticle, error) {
+	path := "/api/rest_v1/page/random/summary"
+
+	if title != "" {
+		path = "/api/rest_v1/page/summary/" + url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+	}
+
+	request, err := newWikipediaRequest(ctx, wikipediaHost(language)+path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	body, _, err := fetchRedactedJson(clientOrDefault(client), request)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+	}
+
+	summary := gjson.Parse(body)
+
+	if summary.Get("type").String() == "disambiguation" {
+		return nil, fmt.Errorf("%w: %s is a disambiguation page", ErrNoContent, summary.Get("title").String())
+	}
+
+	return &WikipediaArticle{
+		Title:        summary.Get("title").String(),
+		Description:  summary.Get("description").String(),
+		Extract:      summary.Get("extract").String(),
+		URL:          summary.Get("content_urls.desktop.page").String(),
+		ThumbnailURL: summary.Get("thumbnail.source").String(),
+	}, nil
+}
+
+// Gets the titles of the articles in a category, not including the ones in its subcategories
+func FetchWikipediaCategoryTitles(ctx context.Context, client RequestDoer, language, category string) ([]string, error) {
+	query := url.Values{}
+	query.Set("action", "query")
+	query.Set("format", "json")
+	query.Set("list", "categorymembers")
+	query.Set("cmtitle", "Category:"+strings.TrimPrefix(category, "Category:"))
+	query.Set("cmtype", "page")
+	query.Set("cmnamespace", "0")
+	query.Set("cmlimit", "500")
+
+	return fetchWikipediaTitles(ctx, client, language, query, "query.categorymembers.#.title", "cmcontinue")
+}
+
+// Gets the titles of the articles that a portal links to
+func FetchWikipediaPortalTitles(ctx context.Context, client RequestDoer, language, portal string) ([]string, error) {
+	query := url.Values{}
+	query.Set("action", "query")
+	query.Set("format", "json")
+	query.Set("prop", "links")
+	query.Set("titles", "Portal:"+strings.TrimPrefix(portal, "Portal:"))
+	query.Set("plnamespace", "0")
+	query.Set("pllimit", "500")
+
+	// the pages are keyed by their ID, of which there's only the one of the portal
+	return fetchWikipediaTitles(ctx, client, language, query, "query.pages.*.links.#.title", "plcontinue")
+}
+
+func fetchWikipediaTitles(ctx context.Context, client RequestDoer, language string, query url.Values, titlesPath, continueParam string) ([]string, error) {
+	client = clientOrDefault(client)
+	var titles []string
+
+	for len(titles) < wikipediaMaxTitles {
+		request, err := newWikipediaRequest(ctx, wikipediaHost(language)+"/w/api.php?"+query.Encode())
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		body, _, err := fetchRedactedJson(client, request)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoContent, err)
+		}
+
+		if info := gjson.Get(body, "error.info"); info.Exists() {
+			return nil, fmt.Errorf("%w: %s", ErrNoContent, info.String())
+		}
+
+		for _, title := range gjson.Get(body, titlesPath).Array() {
+			titles = append(titles, title.String())
+		}
+
+		next := gjson.Get(body, "continue")
+
+		if !next.Get(continueParam).Exists() {
+			break
+		}
+
+		// everything in continue has to be sent back to get the next batch
+		next.ForEach(func(key, value gjson.Result) bool {
+			query.Set(key.String(), value.String())
+			return true
+		})
+	}
+
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("%w: no articles found", ErrNoContent)
+	}
+
+	return titles, nil
+}
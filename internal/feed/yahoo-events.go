@@ -0,0 +1,233 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How far ahead events get shown, anything further away isn't worth the space
+const marketEventWindow = 14 * 24 * time.Hour
+
+type marketQuoteResponseJson struct {
+	QuoteResponse struct {
+		Result []struct {
+			Symbol                 string `json:"symbol"`
+			EarningsTimestamp      int64  `json:"earningsTimestamp"`
+			EarningsTimestampStart int64  `json:"earningsTimestampStart"`
+			ExDividendDate         int64  `json:"exDividendDate"`
+		} `json:"result"`
+	} `json:"quoteResponse"`
+}
+
+// Unlike the chart endpoint, the quote endpoint needs a crumb which is tied to
+// a cookie, both of which are kept for as long as Yahoo keeps accepting them
+var yahooSession = struct {
+	sync.Mutex
+	cookies []*http.Cookie
+	crumb   string
+}{}
+
+var errYahooUnauthorized = errors.New("yahoo rejected the crumb")
+
+func fetchYahooCrumb(ctx context.Context, client RequestDoer) ([]*http.Cookie, string, error) {
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://fc.yahoo.com", nil)
+	addBrowserUserAgentHeader(request)
+	response, err := client.Do(request)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	response.Body.Close()
+
+	// the response is usually a 404, all that matters is the cookie that comes with it
+	cookies := response.Cookies()
+
+	if len(cookies) == 0 {
+		return nil, "", errors.New("yahoo did not set a cookie")
+	}
+
+	request, _ = http.NewRequestWithContext(ctx, "GET", "https://query1.finance.yahoo.com/v1/test/getcrumb", nil)
+	addBrowserUserAgentHeader(request)
+
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+
+	response, err = client.Do(request)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	crumb := strings.TrimSpace(string(body))
+
+	if response.StatusCode != http.StatusOK || crumb == "" {
+		return nil, "", fmt.Errorf("could not get crumb, status code %d", response.StatusCode)
+	}
+
+	return cookies, crumb, nil
+}
+
+func fetchMarketQuotes(ctx context.Context, client RequestDoer, symbols []string) (marketQuoteResponseJson, error) {
+	var result marketQuoteResponseJson
+
+	yahooSession.Lock()
+	defer yahooSession.Unlock()
+
+	if yahooSession.crumb == "" {
+		cookies, crumb, err := fetchYahooCrumb(ctx, client)
+
+		if err != nil {
+			return result, err
+		}
+
+		yahooSession.cookies = cookies
+		yahooSession.crumb = crumb
+	}
+
+	query := url.Values{}
+	query.Set("symbols", strings.Join(symbols, ","))
+	query.Set("fields", "earningsTimestamp,earningsTimestampStart,exDividendDate")
+	query.Set("crumb", yahooSession.crumb)
+
+	request, _ := http.NewRequestWithContext(ctx, "GET", "https://query1.finance.yahoo.com/v7/finance/quote?"+query.Encode(), nil)
+	addBrowserUserAgentHeader(request)
+
+	for _, cookie := range yahooSession.cookies {
+		request.AddCookie(cookie)
+	}
+
+	response, err := client.Do(request)
+
+	if err != nil {
+		return result, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusUnauthorized {
+		yahooSession.crumb = ""
+		return result, errYahooUnauthorized
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&result)
+
+	return result, err
+}
+
+// Adds the upcoming earnings and ex-dividend dates of the markets that have them,
+// which is usually only the case for stocks rather than indices, futures or currencies
+func AddMarketEventsFromYahoo(ctx context.Context, client RequestDoer, markets Markets) error {
+	if len(markets) == 0 {
+		return nil
+	}
+
+	client = clientOrDefault(client)
+	symbols := make([]string, len(markets))
+
+	for i := range markets {
+		symbols[i] = markets[i].Symbol
+	}
+
+	response, err := fetchMarketQuotes(ctx, client, symbols)
+
+	// the crumb expires every now and then, in which case a new one is fetched once
+	if errors.Is(err, errYahooUnauthorized) {
+		response, err = fetchMarketQuotes(ctx, client, symbols)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not fetch market events: %v", err)
+	}
+
+	now := time.Now()
+
+	for _, quote := range response.QuoteResponse.Result {
+		for i := range markets {
+			if !strings.EqualFold(markets[i].Symbol, quote.Symbol) {
+				continue
+			}
+
+			// the earnings timestamp is the last earnings call until the next one is
+			// announced, while the start is the beginning of the expected date range
+			for _, timestamp := range []int64{quote.EarningsTimestamp, quote.EarningsTimestampStart} {
+				if earnings := time.Unix(timestamp, 0); timestamp != 0 && upcomingMarketEvent(earnings, now) {
+					markets[i].Earnings = earnings
+					break
+				}
+			}
+
+			if exDividend := time.Unix(quote.ExDividendDate, 0); quote.ExDividendDate != 0 && upcomingMarketEvent(exDividend, now) {
+				markets[i].ExDividend = exDividend
+			}
+		}
+	}
+
+	return nil
+}
+
+func upcomingMarketEvent(t, now time.Time) bool {
+	return daysBetween(now, t) >= 0 && t.Sub(now) <= marketEventWindow
+}
+
+// Counts calendar days rather than 24 hour periods, so that something
+// happening tomorrow morning isn't shown as being today
+func daysBetween(from, to time.Time) int {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+type MarketEvent struct {
+	Label string
+	Date  time.Time
+}
+
+// The closest of the upcoming events, nil if there aren't any
+func (m Market) NextEvent() *MarketEvent {
+	var event *MarketEvent
+
+	if !m.Earnings.IsZero() {
+		event = &MarketEvent{Label: "earnings", Date: m.Earnings}
+	}
+
+	if !m.ExDividend.IsZero() && (event == nil || m.ExDividend.Before(event.Date)) {
+		event = &MarketEvent{Label: "ex-div", Date: m.ExDividend}
+	}
+
+	return event
+}
+
+func (e MarketEvent) In() string {
+	switch days := daysBetween(time.Now(), e.Date); days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %dd", days)
+	}
+}
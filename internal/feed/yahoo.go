@@ -1,9 +1,14 @@
 package feed
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 )
 
 type marketResponseJson struct {
@@ -12,6 +17,9 @@ type marketResponseJson struct {
 			Meta struct {
 				Currency           string  `json:"currency"`
 				Symbol             string  `json:"symbol"`
+				ShortName          string  `json:"shortName"`
+				LongName           string  `json:"longName"`
+				InstrumentType     string  `json:"instrumentType"`
 				RegularMarketPrice float64 `json:"regularMarketPrice"`
 				ChartPreviousClose float64 `json:"chartPreviousClose"`
 			} `json:"meta"`
@@ -27,15 +35,15 @@ type marketResponseJson struct {
 // TODO: allow changing chart time frame
 const marketChartDays = 21
 
-func FetchMarketsDataFromYahoo(marketRequests []MarketRequest) (Markets, error) {
+func FetchMarketsDataFromYahoo(ctx context.Context, client RequestDoer, marketRequests []MarketRequest) (Markets, error) {
 	requests := make([]*http.Request, 0, len(marketRequests))
 
 	for i := range marketRequests {
-		request, _ := http.NewRequest("GET", fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=1mo&interval=1d", marketRequests[i].Symbol), nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s?range=1mo&interval=1d", url.PathEscape(marketRequests[i].Symbol)), nil)
 		requests = append(requests, request)
 	}
 
-	job := newJob(decodeJsonFromRequestTask[marketResponseJson](defaultClient), requests)
+	job := newJob(decodeJsonFromRequestTask[marketResponseJson](clientOrDefault(client)), requests).withContext(ctx)
 	responses, errs, err := workerPoolDo(job)
 
 	if err != nil {
@@ -43,6 +51,7 @@ func FetchMarketsDataFromYahoo(marketRequests []MarketRequest) (Markets, error)
 	}
 
 	markets := make(Markets, 0, len(responses))
+	currencyCodes := make([]string, 0, len(responses))
 	var failed int
 
 	for i := range responses {
@@ -74,21 +83,37 @@ func FetchMarketsDataFromYahoo(marketRequests []MarketRequest) (Markets, error)
 
 		points := SvgPolylineCoordsFromYValues(100, 50, maybeCopySliceWithoutZeroValues(prices))
 
-		currency, exists := currencyToSymbol[response.Chart.Result[0].Meta.Currency]
+		request := marketRequests[i]
+		kind := yahooMarketKind(response.Chart.Result[0].Meta.InstrumentType, request.Symbol)
+		currencyCode, price := response.Chart.Result[0].Meta.Currency, response.Chart.Result[0].Meta.RegularMarketPrice
 
-		if !exists {
-			currency = response.Chart.Result[0].Meta.Currency
+		// indices are in points and exchange rates are already a ratio between two currencies,
+		// so neither gets a currency symbol nor gets converted to the requested currency
+		if kind == MarketKindIndex || kind == MarketKindCurrencyPair {
+			currencyCode = ""
+		} else {
+			currencyCode, price = normalizeCurrency(currencyCode, price)
 		}
 
+		// the name is optional, and symbols added from the dashboard never have one
+		if request.Name == "" {
+			request.Name = cmp.Or(response.Chart.Result[0].Meta.ShortName, response.Chart.Result[0].Meta.LongName)
+		}
+
+		if request.Name == "" && kind == MarketKindCurrencyPair {
+			request.Name = currencyPairName(request.Symbol)
+		}
+
+		currencyCodes = append(currencyCodes, currencyCode)
 		markets = append(markets, Market{
-			MarketRequest: marketRequests[i],
-			Price:         response.Chart.Result[0].Meta.RegularMarketPrice,
-			Currency:      currency,
+			MarketRequest: request,
+			Price:         price,
 			PercentChange: percentChange(
 				response.Chart.Result[0].Meta.RegularMarketPrice,
 				previous,
 			),
 			SvgChartPoints: points,
+			Kind:           kind,
 		})
 	}
 
@@ -96,9 +121,105 @@ func FetchMarketsDataFromYahoo(marketRequests []MarketRequest) (Markets, error)
 		return nil, ErrNoContent
 	}
 
+	failed += convertMarketCurrencies(ctx, client, markets, currencyCodes)
+
 	if failed > 0 {
 		return markets, fmt.Errorf("%w: could not fetch data for %d market(s)", ErrPartialContent, failed)
 	}
 
 	return markets, nil
 }
+
+// The instrument type is missing from some responses, in which case the
+// conventions Yahoo uses for the symbols of each kind are the fallback
+func yahooMarketKind(instrumentType, symbol string) string {
+	switch instrumentType {
+	case "INDEX":
+		return MarketKindIndex
+	case "FUTURE":
+		return MarketKindFuture
+	case "CURRENCY":
+		return MarketKindCurrencyPair
+	}
+
+	if instrumentType != "" {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(symbol, "^"):
+		return MarketKindIndex
+	case strings.HasSuffix(symbol, "=F"):
+		return MarketKindFuture
+	case strings.HasSuffix(symbol, "=X"):
+		return MarketKindCurrencyPair
+	}
+
+	return ""
+}
+
+// EURUSD=X becomes EUR/USD, and JPY=X, which is how Yahoo names pairs against the dollar, becomes USD/JPY
+func currencyPairName(symbol string) string {
+	pair := strings.TrimSuffix(strings.ToUpper(symbol), "=X")
+
+	switch len(pair) {
+	case 6:
+		return pair[:3] + "/" + pair[3:]
+	case 3:
+		return "USD/" + pair
+	}
+
+	return symbol
+}
+
+// Converts the prices of markets which requested a currency different from the
+// one reported by the source, returns the number of markets that couldn't be
+// converted and are instead shown in their original currency
+func convertMarketCurrencies(ctx context.Context, client RequestDoer, markets Markets, sourceCodes []string) int {
+	targetCodes := make([]string, len(markets))
+	pairs := make([]string, 0, len(markets))
+
+	for i := range markets {
+		targetCodes[i] = sourceCodes[i]
+		target := strings.ToUpper(markets[i].CurrencyCode)
+
+		if target == "" || target == sourceCodes[i] || sourceCodes[i] == "" {
+			continue
+		}
+
+		targetCodes[i] = target
+
+		if pair := sourceCodes[i] + target; !slices.Contains(pairs, pair) {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	var failed int
+	var rates map[string]float64
+	var errs []error
+
+	if len(pairs) > 0 {
+		rates, errs = fetchExchangeRates(ctx, client, pairs)
+
+		for i := range pairs {
+			if errs[i] != nil {
+				slog.Error("Failed to fetch exchange rate", "pair", pairs[i], "error", errs[i])
+			}
+		}
+	}
+
+	for i := range markets {
+		if targetCodes[i] != sourceCodes[i] {
+			if rate, exists := rates[sourceCodes[i]+targetCodes[i]]; exists {
+				markets[i].Price *= rate
+			} else {
+				failed++
+				targetCodes[i] = sourceCodes[i]
+			}
+		}
+
+		markets[i].Currency = CurrencySymbol(targetCodes[i])
+	}
+
+	return failed
+}
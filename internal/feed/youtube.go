@@ -1,6 +1,7 @@
 package feed
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -37,7 +38,7 @@ func parseYoutubeFeedTime(t string) time.Time {
 	return parsedTime
 }
 
-func FetchYoutubeChannelUploads(channelIds []string, videoUrlTemplate string, includeShorts bool) (Videos, error) {
+func FetchYoutubeChannelUploads(ctx context.Context, channelIds []string, videoUrlTemplate string, includeShorts bool) (Videos, error) {
 	requests := make([]*http.Request, 0, len(channelIds))
 
 	for i := range channelIds {
@@ -49,11 +50,11 @@ func FetchYoutubeChannelUploads(channelIds []string, videoUrlTemplate string, in
 			feedUrl = "https://www.youtube.com/feeds/videos.xml?channel_id=" + channelIds[i]
 		}
 
-		request, _ := http.NewRequest("GET", feedUrl, nil)
+		request, _ := http.NewRequestWithContext(ctx, "GET", feedUrl, nil)
 		requests = append(requests, request)
 	}
 
-	job := newJob(decodeXmlFromRequestTask[youtubeFeedResponseXml](defaultClient), requests).withWorkers(30)
+	job := newJob(decodeXmlFromRequestTask[youtubeFeedResponseXml](defaultClient), requests).withWorkers(30).withContext(ctx)
 
 	responses, errs, err := workerPoolDo(job)
 
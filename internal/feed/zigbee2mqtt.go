@@ -0,0 +1,246 @@
+package feed
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const zigbee2MQTTTimeout = 15 * time.Second
+
+// The frontend sends everything it has cached right after connecting and then only
+// sends changes, so once it goes quiet for this long there's nothing left to wait for
+const zigbee2MQTTQuietPeriod = 500 * time.Millisecond
+
+type Zigbee2MQTTDevice struct {
+	Name  string
+	Model string
+	// in percent, -1 when the device doesn't report it
+	Battery    int
+	BatteryLow bool
+	// from 0 to 255, -1 when the device doesn't report it
+	LinkQuality int
+	// zero unless last_seen is enabled in Zigbee2MQTT
+	LastSeen time.Time
+	// only known when availability is enabled in Zigbee2MQTT
+	Offline bool
+}
+
+type zigbee2MQTTMessage struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type zigbee2MQTTDeviceJson struct {
+	FriendlyName string `json:"friendly_name"`
+	Type         string `json:"type"`
+	Disabled     bool   `json:"disabled"`
+	Definition   *struct {
+		Vendor      string `json:"vendor"`
+		Description string `json:"description"`
+	} `json:"definition"`
+}
+
+type zigbee2MQTTStateJson struct {
+	Battery     *float64        `json:"battery"`
+	BatteryLow  bool            `json:"battery_low"`
+	LinkQuality *float64        `json:"linkquality"`
+	LastSeen    json.RawMessage `json:"last_seen"`
+}
+
+// last_seen is either an ISO 8601 date or milliseconds since the epoch, depending on how it's configured
+func parseZigbee2MQTTLastSeen(raw json.RawMessage) time.Time {
+	var milliseconds int64
+
+	if json.Unmarshal(raw, &milliseconds) == nil && milliseconds > 0 {
+		return time.UnixMilli(milliseconds)
+	}
+
+	var date string
+
+	if json.Unmarshal(raw, &date) != nil {
+		return time.Time{}
+	}
+
+	parsed, err := time.Parse(time.RFC3339, date)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return parsed
+}
+
+func isZigbee2MQTTOffline(raw json.RawMessage) bool {
+	// older versions publish the state on its own, newer ones as {"state":"offline"}
+	var state string
+
+	if json.Unmarshal(raw, &state) != nil {
+		var availability struct {
+			State string `json:"state"`
+		}
+
+		json.Unmarshal(raw, &availability)
+		state = availability.State
+	}
+
+	return state == "offline"
+}
+
+func zigbee2MQTTWebsocketConfig(baseURL, token string, allowInsecure bool) (*websocket.Config, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	origin := parsed.Scheme + "://" + parsed.Host
+
+	switch parsed.Scheme {
+	case "http":
+		parsed.Scheme = "ws"
+	case "https":
+		parsed.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", parsed.Scheme)
+	}
+
+	parsed.Path += "/api"
+
+	if token != "" {
+		parsed.RawQuery = url.Values{"token": {token}}.Encode()
+	}
+
+	config, err := websocket.NewConfig(parsed.String(), origin)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.Dialer = &net.Dialer{}
+
+	if allowInsecure {
+		config.TlsConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return config, nil
+}
+
+// Reads the devices and their last known state through the websocket API that the Zigbee2MQTT
+// frontend uses, since device states usually aren't retained on the MQTT broker
+func FetchZigbee2MQTTDevices(ctx context.Context, baseURL, token string, allowInsecure bool) ([]Zigbee2MQTTDevice, error) {
+	config, err := zigbee2MQTTWebsocketConfig(baseURL, token, allowInsecure)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, zigbee2MQTTTimeout)
+	defer cancel()
+
+	conn, err := config.DialContext(ctx)
+
+	if err != nil {
+		var dialErr *websocket.DialError
+
+		// the error includes the URL, which has the token in it
+		if errors.As(err, &dialErr) {
+			return nil, dialErr.Err
+		}
+
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	var devices []zigbee2MQTTDeviceJson
+	payloads := make(map[string]json.RawMessage)
+
+	for {
+		var message zigbee2MQTTMessage
+
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			var netErr net.Error
+
+			if devices != nil && errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+
+			return nil, fmt.Errorf("reading from websocket: %v", err)
+		}
+
+		if message.Topic == "bridge/devices" {
+			if err := json.Unmarshal(message.Payload, &devices); err != nil {
+				return nil, fmt.Errorf("parsing devices: %v", err)
+			}
+
+			if devices == nil {
+				devices = []zigbee2MQTTDeviceJson{}
+			}
+		} else if !strings.HasPrefix(message.Topic, "bridge/") {
+			payloads[message.Topic] = message.Payload
+		}
+
+		if quietUntil := time.Now().Add(zigbee2MQTTQuietPeriod); devices != nil && quietUntil.Before(deadline) {
+			conn.SetReadDeadline(quietUntil)
+		}
+	}
+
+	result := make([]Zigbee2MQTTDevice, 0, len(devices))
+
+	for i := range devices {
+		device := &devices[i]
+
+		if device.Type == "Coordinator" || device.Disabled {
+			continue
+		}
+
+		d := Zigbee2MQTTDevice{
+			Name:        device.FriendlyName,
+			Battery:     -1,
+			LinkQuality: -1,
+			Offline:     isZigbee2MQTTOffline(payloads[device.FriendlyName+"/availability"]),
+		}
+
+		if device.Definition != nil {
+			d.Model = strings.TrimSpace(device.Definition.Vendor + " " + device.Definition.Description)
+		}
+
+		if payload, ok := payloads[device.FriendlyName]; ok {
+			var state zigbee2MQTTStateJson
+
+			if json.Unmarshal(payload, &state) == nil {
+				if state.Battery != nil {
+					d.Battery = int(*state.Battery)
+				}
+
+				if state.LinkQuality != nil {
+					d.LinkQuality = int(*state.LinkQuality)
+				}
+
+				d.BatteryLow = state.BatteryLow
+				d.LastSeen = parseZigbee2MQTTLastSeen(state.LastSeen)
+			}
+		}
+
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Name < result[b].Name
+	})
+
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package glance
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+// Shared by the application that was started and all of the ones
+// that replaced it, so that requests go to the latest one
+type liveApplication struct {
+	app atomic.Pointer[Application]
+	// prevents two reloads from happening at the same time
+	reloadMu sync.Mutex
+}
+
+func (a *Application) registerAdminRoutes(mux *apiMux) {
+	protect := a.adminMiddleware
+
+	mux.document("GET /api/admin/pages", "Lists the pages along with their widgets", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminPagesRequest)))
+	mux.document("POST /api/admin/pages/{page}/refresh", "Updates all widgets of the page", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminPageRefreshRequest)))
+	mux.document("POST /api/admin/widgets/{widget}/refresh", "Updates the widget and the widgets nested inside of it, which can be referred to by its ID or id property", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminWidgetRefreshRequest)))
+	mux.document("GET /api/admin/health", "The same as /healthz", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminHealthRequest)))
+	mux.document("POST /api/admin/cache/flush", "Makes every widget get updated the next time it's needed", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminCacheFlushRequest)))
+	mux.document("POST /api/admin/config/reload", "Reads the config file again and applies it", apiSecurityToken, protect(http.HandlerFunc(a.HandleAdminConfigReloadRequest)))
+}
+
+func (a *Application) adminMiddleware(next http.Handler) http.Handler {
+	return requireBearerToken(a.Config.Server.AdminToken.String(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// Responds with 401 unless the request has the token in its Authorization header
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r, token) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasBearerToken(r *http.Request, token string) bool {
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+type adminPage struct {
+	Slug    string        `json:"slug"`
+	Title   string        `json:"title"`
+	Widgets []adminWidget `json:"widgets"`
+}
+
+type adminWidget struct {
+	ID    uint64 `json:"id"`
+	Slug  string `json:"slug"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	// nil for widgets which never get updated, such as containers
+	LastUpdateAt        *time.Time `json:"last-update-at"`
+	LastSuccessAt       *time.Time `json:"last-success-at"`
+	ConsecutiveFailures int        `json:"consecutive-failures"`
+}
+
+func newAdminWidget(w widget.Widget) adminWidget {
+	result := adminWidget{
+		ID:    w.GetID(),
+		Slug:  w.GetSlug(),
+		Type:  w.GetType(),
+		Title: w.GetTitle(),
+	}
+
+	if stats, ok := widget.GetUpdateStats(w); ok {
+		result.ConsecutiveFailures = stats.ConsecutiveFailures
+
+		if !stats.LastUpdateAt.IsZero() {
+			result.LastUpdateAt = &stats.LastUpdateAt
+		}
+
+		if !stats.LastSuccessAt.IsZero() {
+			result.LastSuccessAt = &stats.LastSuccessAt
+		}
+	}
+
+	return result
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (a *Application) HandleAdminPagesRequest(w http.ResponseWriter, r *http.Request) {
+	pages := make([]adminPage, 0, len(a.Config.Pages))
+
+	for p := range a.Config.Pages {
+		page := &a.Config.Pages[p]
+		result := adminPage{
+			Slug:    page.Slug,
+			Title:   page.Title,
+			Widgets: make([]adminWidget, 0),
+		}
+
+		// widgets nested inside of groups and split columns come right after them
+		widget.WalkWidgets(page.widgets(), func(w widget.Widget) {
+			result.Widgets = append(result.Widgets, newAdminWidget(w))
+		})
+
+		pages = append(pages, result)
+	}
+
+	writeAdminJSON(w, http.StatusOK, pages)
+}
+
+type adminRefreshResponse struct {
+	Updated int `json:"updated"`
+	Failed  int `json:"failed"`
+}
+
+// Updates the widgets along with the ones nested inside of them, waiting
+// for all of them to finish so that the response reflects the outcome
+func refreshWidgets(widgets widget.Widgets, maxConcurrent int) adminRefreshResponse {
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	slots := make(chan struct{}, maxConcurrent)
+	updated := 0
+
+	widget.WalkWidgets(widgets, func(w widget.Widget) {
+		if _, ok := widget.GetUpdateStats(w); !ok {
+			return
+		}
+
+		updated++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			widget.ForceUpdate(context.Background(), w)
+
+			if stats, _ := widget.GetUpdateStats(w); stats.ConsecutiveFailures > 0 {
+				failed.Add(1)
+			}
+		}()
+	})
+
+	wg.Wait()
+
+	return adminRefreshResponse{Updated: updated, Failed: int(failed.Load())}
+}
+
+func (a *Application) HandleAdminPageRefreshRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+
+	if !exists {
+		writeAdminError(w, http.StatusNotFound, errors.New("page not found"))
+		return
+	}
+
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	writeAdminJSON(w, http.StatusOK, refreshWidgets(page.widgets(), page.maxConcurrentUpdates()))
+}
+
+func (a *Application) HandleAdminWidgetRefreshRequest(w http.ResponseWriter, r *http.Request) {
+	pageWidget, exists := a.findWidget(r.PathValue("widget"))
+
+	if !exists {
+		writeAdminError(w, http.StatusNotFound, errors.New("widget not found"))
+		return
+	}
+
+	// pages render their widgets while holding their lock,
+	// so it has to be held while the widget gets updated too
+	page := a.pageByWidget[pageWidget]
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	writeAdminJSON(w, http.StatusOK, refreshWidgets(widget.Widgets{pageWidget}, page.maxConcurrentUpdates()))
+}
+
+// Rather than updating everything at once, the widgets get
+// updated the next time they're needed, such as when their page is opened
+func (a *Application) HandleAdminCacheFlushRequest(w http.ResponseWriter, r *http.Request) {
+	expired := 0
+
+	for p := range a.Config.Pages {
+		widget.WalkWidgets(a.Config.Pages[p].widgets(), func(w widget.Widget) {
+			if _, ok := widget.GetUpdateStats(w); ok {
+				widget.ExpireCache(w)
+				expired++
+			}
+		})
+	}
+
+	feed.ClearConditionalRequestCache()
+
+	writeAdminJSON(w, http.StatusOK, map[string]int{"expired": expired})
+}
+
+// Parses the config file again and replaces the application with one made from it,
+// leaving the current one in place if the config isn't valid. The server options
+// which are used when starting the server, such as the host and port, require a restart
+func (a *Application) HandleAdminConfigReloadRequest(w http.ResponseWriter, r *http.Request) {
+	live := a.live
+	live.reloadMu.Lock()
+	defer live.reloadMu.Unlock()
+
+	current := live.app.Load()
+
+	config, err := NewConfigFromFile(current.configPath)
+
+	if err != nil {
+		slog.Error("Failed to reload config", "error", err)
+		writeAdminError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	next, err := newApplication(config, current.history, current.storage)
+
+	if err == nil {
+		next.handler, err = next.newHandler()
+	}
+
+	if err != nil {
+		if next != nil {
+			next.stopWidgets()
+		}
+
+		slog.Error("Failed to reload config", "error", err)
+		writeAdminError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	next.configPath = current.configPath
+	next.live = live
+	next.updates = current.updates
+	next.Config.Server.StartedAt = current.Config.Server.StartedAt
+
+	if next.Config.Server.Host != current.Config.Server.Host || next.Config.Server.Port != current.Config.Server.Port {
+		slog.Warn("The host and port of the server only change after a restart")
+	}
+
+	config.settings.apply()
+	live.app.Store(next)
+	current.stopWidgets()
+	slog.Info("Reloaded config", "path", current.configPath)
+
+	writeAdminJSON(w, http.StatusOK, map[string]int{
+		"pages":   len(next.Config.Pages),
+		"widgets": len(next.widgetByID),
+	})
+}
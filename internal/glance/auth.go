@@ -0,0 +1,350 @@
+package glance
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/widget"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const sessionCookieName = "glance_session"
+const defaultSessionDuration = 30 * 24 * time.Hour
+
+type AuthUser struct {
+	PasswordHash widget.OptionalEnvString `yaml:"password-hash"`
+}
+
+type Auth struct {
+	SecretKey            widget.OptionalEnvString `yaml:"secret-key"`
+	SessionDuration      widget.DurationField     `yaml:"session-duration"`
+	Users                map[string]AuthUser      `yaml:"users"`
+	ProxyUserHeader      string                   `yaml:"proxy-user-header"`
+	ProxyUsers           []string                 `yaml:"proxy-allowed-users"`
+	ProxyTrustedNetworks []string                 `yaml:"proxy-trusted-networks"`
+	secretKey            []byte                   `yaml:"-"`
+	dummyHash            []byte                   `yaml:"-"`
+	trustedProxies       []netip.Prefix           `yaml:"-"`
+}
+
+func (a *Auth) Enabled() bool {
+	return len(a.Users) > 0 || a.ProxyUserHeader != ""
+}
+
+func (a *Auth) usesPasswords() bool {
+	return len(a.Users) > 0
+}
+
+func (a *Auth) initialize() error {
+	if !a.Enabled() {
+		return nil
+	}
+
+	if a.usesPasswords() && a.ProxyUserHeader != "" {
+		return errors.New("auth: users and proxy-user-header cannot be used at the same time")
+	}
+
+	if a.ProxyUserHeader != "" {
+		// anyone who can reach Glance directly could otherwise claim to be any user
+		if len(a.ProxyTrustedNetworks) == 0 {
+			return errors.New("auth: proxy-trusted-networks must be set when using proxy-user-header")
+		}
+
+		for _, network := range a.ProxyTrustedNetworks {
+			prefix, err := parseTrustedNetwork(network)
+
+			if err != nil {
+				return fmt.Errorf("auth: proxy-trusted-networks: %v", err)
+			}
+
+			a.trustedProxies = append(a.trustedProxies, prefix)
+		}
+	}
+
+	for username, user := range a.Users {
+		if user.PasswordHash == "" {
+			return fmt.Errorf("auth: user %s has no password-hash", username)
+		}
+
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			return fmt.Errorf("auth: password-hash of user %s is not a valid bcrypt hash", username)
+		}
+	}
+
+	if a.usesPasswords() {
+		dummyHash, err := bcrypt.GenerateFromPassword([]byte("glance"), bcrypt.DefaultCost)
+
+		if err != nil {
+			return fmt.Errorf("auth: %v", err)
+		}
+
+		a.dummyHash = dummyHash
+	}
+
+	if a.SessionDuration == 0 {
+		a.SessionDuration = widget.DurationField(defaultSessionDuration)
+	}
+
+	if a.SecretKey != "" {
+		a.secretKey = []byte(a.SecretKey)
+	} else if a.usesPasswords() {
+		// sessions will not survive a restart without a configured key
+		a.secretKey = make([]byte, 32)
+
+		if _, err := rand.Read(a.secretKey); err != nil {
+			return fmt.Errorf("auth: could not generate secret key: %v", err)
+		}
+
+		slog.Warn("No auth secret-key specified, sessions will be invalidated when the server restarts")
+	}
+
+	return nil
+}
+
+// Either a network such as 172.18.0.0/16 or a single address
+func parseTrustedNetwork(network string) (netip.Prefix, error) {
+	if strings.Contains(network, "/") {
+		prefix, err := netip.ParsePrefix(network)
+
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid network %s", network)
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	address, err := netip.ParseAddr(network)
+
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address %s", network)
+	}
+
+	return netip.PrefixFrom(address, address.BitLen()), nil
+}
+
+func (a *Auth) isFromTrustedProxy(r *http.Request) bool {
+	addressPort, err := netip.ParseAddrPort(r.RemoteAddr)
+
+	if err != nil {
+		return false
+	}
+
+	address := addressPort.Addr().Unmap()
+
+	for i := range a.trustedProxies {
+		if a.trustedProxies[i].Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Auth) signSessionPayload(payload []byte) []byte {
+	mac := hmac.New(sha256.New, a.secretKey)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// The session token is stateless and consists of the username and the
+// expiry time, signed with the secret key
+func (a *Auth) createSessionToken(username string, expiresAt time.Time) string {
+	payload := []byte(username + "\n" + strconv.FormatInt(expiresAt.Unix(), 10))
+	signature := a.signSessionPayload(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (a *Auth) usernameFromSessionToken(token string) (string, bool) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+
+	if !found {
+		return "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+
+	if err != nil {
+		return "", false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+
+	if err != nil || !hmac.Equal(signature, a.signSessionPayload(payload)) {
+		return "", false
+	}
+
+	username, expiresAt, found := bytes.Cut(payload, []byte("\n"))
+
+	if !found {
+		return "", false
+	}
+
+	expiresAtUnix, err := strconv.ParseInt(string(expiresAt), 10, 64)
+
+	if err != nil || time.Now().Unix() > expiresAtUnix {
+		return "", false
+	}
+
+	if _, exists := a.Users[string(username)]; !exists {
+		return "", false
+	}
+
+	return string(username), true
+}
+
+func (a *Auth) isRequestAuthenticated(r *http.Request) bool {
+	if a.ProxyUserHeader != "" {
+		user := r.Header.Get(a.ProxyUserHeader)
+
+		if user == "" {
+			return false
+		}
+
+		if !a.isFromTrustedProxy(r) {
+			slog.Warn("Ignoring user header of request that didn't come from a trusted proxy", "remote", r.RemoteAddr)
+			return false
+		}
+
+		if len(a.ProxyUsers) == 0 {
+			return true
+		}
+
+		for i := range a.ProxyUsers {
+			if a.ProxyUsers[i] == user {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+
+	if err != nil {
+		return false
+	}
+
+	_, valid := a.usernameFromSessionToken(cookie.Value)
+
+	return valid
+}
+
+func (a *Auth) checkCredentials(username, password string) bool {
+	user, exists := a.Users[username]
+
+	if !exists {
+		// compare against a dummy hash anyway to not leak
+		// which usernames exist through response timing
+		bcrypt.CompareHashAndPassword(a.dummyHash, []byte(password))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func (a *Application) authMiddleware(next http.Handler) http.Handler {
+	auth := &a.Config.Auth
+
+	if !auth.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.isRequestAuthenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if auth.ProxyUserHeader != "" || strings.HasPrefix(r.URL.Path, "/api/") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+
+		http.Redirect(w, r, a.Config.Server.BaseURL+"/login", http.StatusSeeOther)
+	})
+}
+
+type loginTemplateData struct {
+	App   *Application
+	Error string
+}
+
+func (d loginTemplateData) Theme() *Theme {
+	return &d.App.Config.Theme
+}
+
+func (a *Application) HandleLoginPageRequest(w http.ResponseWriter, r *http.Request) {
+	a.renderLoginPage(w, http.StatusOK, "")
+}
+
+func (a *Application) renderLoginPage(w http.ResponseWriter, status int, errorMessage string) {
+	var responseBytes bytes.Buffer
+	err := assets.LoginTemplate.Execute(&responseBytes, loginTemplateData{
+		App:   a,
+		Error: errorMessage,
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(responseBytes.Bytes())
+}
+
+func (a *Application) HandleLoginRequest(w http.ResponseWriter, r *http.Request) {
+	auth := &a.Config.Auth
+	username := r.PostFormValue("username")
+	password := r.PostFormValue("password")
+
+	if !auth.checkCredentials(username, password) {
+		slog.Warn("Failed login attempt", "username", username, "remote", r.RemoteAddr)
+		a.renderLoginPage(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(auth.SessionDuration))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    auth.createSessionToken(username, expiresAt),
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, a.Config.Server.BaseURL+"/", http.StatusSeeOther)
+}
+
+func (a *Application) HandleLogoutRequest(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, a.Config.Server.BaseURL+"/login", http.StatusSeeOther)
+}
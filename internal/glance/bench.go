@@ -0,0 +1,315 @@
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+const (
+	benchDefaultWidgets    = 120
+	benchDefaultIterations = 200
+	// how many times every widget gets updated to measure the refresh throughput
+	benchRefreshRuns = 5
+	benchPageSlug    = "bench"
+)
+
+// Renders a synthetic page whose widgets fetch their data from mocked upstreams, so that
+// changes to the updating of widgets and the templates can be measured without the network
+type benchHarness struct {
+	upstream *httptest.Server
+	app      *Application
+	handler  http.Handler
+	page     *Page
+}
+
+func newBenchHarness(widgets int) (*benchHarness, error) {
+	upstream := httptest.NewServer(http.HandlerFunc(serveBenchUpstream))
+	harness := &benchHarness{upstream: upstream}
+
+	config, err := newConfigFromYml([]byte(benchConfig(upstream.URL, widgets)), ".")
+
+	if err != nil {
+		upstream.Close()
+		return nil, fmt.Errorf("creating benchmark config: %v", err)
+	}
+
+	harness.app, err = NewApplication(config)
+
+	if err != nil {
+		upstream.Close()
+		return nil, err
+	}
+
+	harness.handler, err = harness.app.newHandler()
+
+	if err != nil {
+		harness.close()
+		return nil, err
+	}
+
+	harness.page = harness.app.slugToPage[benchPageSlug]
+
+	// the first update also opens the connections to the upstreams, which isn't part of what gets measured
+	harness.refresh()
+
+	failed := 0
+
+	widget.WalkWidgets(harness.page.widgets(), func(w widget.Widget) {
+		if stats, ok := widget.GetUpdateStats(w); ok && stats.ConsecutiveFailures > 0 {
+			failed++
+		}
+	})
+
+	if failed > 0 {
+		harness.close()
+		return nil, fmt.Errorf("%d widget(s) failed to update from the mocked upstreams", failed)
+	}
+
+	return harness, nil
+}
+
+func (h *benchHarness) close() {
+	h.app.stopWidgets()
+	h.upstream.Close()
+}
+
+// Updates every widget of the page, the same way as when all of them are outdated
+func (h *benchHarness) refresh() time.Duration {
+	widget.WalkWidgets(h.page.widgets(), func(w widget.Widget) {
+		if _, ok := widget.GetUpdateStats(w); ok {
+			widget.ExpireCache(w)
+		}
+	})
+
+	feed.ClearConditionalRequestCache()
+	start := time.Now()
+	h.page.UpdateOutdatedWidgets(context.Background(), start, "")
+
+	return time.Since(start)
+}
+
+// Renders the content of the page through the same handler that serves it
+func (h *benchHarness) render() error {
+	request := httptest.NewRequest("GET", "/api/pages/"+benchPageSlug+"/content/", nil)
+	recorder := httptest.NewRecorder()
+	h.handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		return fmt.Errorf("rendering the page responded with status %d", recorder.Code)
+	}
+
+	return nil
+}
+
+func benchConfig(upstreamURL string, widgets int) string {
+	var builder strings.Builder
+	columns := [3]strings.Builder{}
+
+	for i := range widgets {
+		column := &columns[i%len(columns)]
+
+		// each widget gets its own address so that none of them share a response
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(column, `
+          - type: rss
+            limit: 10
+            feeds:
+              - url: %s/feed.xml?widget=%d
+`, upstreamURL, i)
+		case 1:
+			fmt.Fprintf(column, `
+          - type: monitor
+            sites:
+              - title: First
+                url: %[1]s/status?widget=%[2]d&site=1
+              - title: Second
+                url: %[1]s/status?widget=%[2]d&site=2
+              - title: Third
+                url: %[1]s/status?widget=%[2]d&site=3
+`, upstreamURL, i)
+		case 2:
+			fmt.Fprintf(column, `
+          - type: custom-api
+            url: %s/api.json?widget=%d
+            template: |
+              <ul class="list list-gap-10">
+              {{ range .JSON.Array "items" }}
+                <li class="flex justify-between"><span>{{ .String "name" }}</span><span>{{ .Int "value" | formatNumber }}</span></li>
+              {{ end }}
+              </ul>
+`, upstreamURL, i)
+		default:
+			fmt.Fprintf(column, `
+          - type: bookmarks
+            groups:
+              - links:
+                  - title: First %[1]d
+                    url: https://example.com/%[1]d/1
+                  - title: Second %[1]d
+                    url: https://example.com/%[1]d/2
+                  - title: Third %[1]d
+                    url: https://example.com/%[1]d/3
+`, i)
+		}
+	}
+
+	builder.WriteString("pages:\n  - name: Bench\n    slug: " + benchPageSlug + "\n    columns:\n")
+
+	for i := range columns {
+		size := "small"
+
+		if i == 1 {
+			size = "full"
+		}
+
+		fmt.Fprintf(&builder, "      - size: %s\n        widgets:%s", size, columns[i].String())
+	}
+
+	return builder.String()
+}
+
+func serveBenchUpstream(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/feed.xml":
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Bench</title><link>https://example.com</link>`)
+
+		for i := range 20 {
+			fmt.Fprintf(
+				w,
+				`<item><title>Article number %[1]d with a reasonably long title</title><link>https://example.com/articles/%[1]d</link><pubDate>%[2]s</pubDate><description>The description of article %[1]d.</description></item>`,
+				i, time.Now().Add(-time.Duration(i)*time.Hour).Format(time.RFC1123Z),
+			)
+		}
+
+		fmt.Fprint(w, `</channel></rss>`)
+	case "/api.json":
+		items := make([]map[string]any, 10)
+
+		for i := range items {
+			items[i] = map[string]any{"name": fmt.Sprintf("Item %d", i), "value": i * 1234}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	default:
+		w.Write([]byte("ok"))
+	}
+}
+
+type benchResult struct {
+	Widgets    int `json:"widgets"`
+	Iterations int `json:"iterations"`
+	// the time it takes to update every widget of the page
+	RefreshMs               float64 `json:"refresh-ms"`
+	RefreshWidgetsPerSecond float64 `json:"refresh-widgets-per-second"`
+	RenderP50Ms             float64 `json:"render-p50-ms"`
+	RenderP95Ms             float64 `json:"render-p95-ms"`
+	RenderP99Ms             float64 `json:"render-p99-ms"`
+	// per render of the page
+	RenderAllocs uint64 `json:"render-allocs"`
+	RenderBytes  uint64 `json:"render-bytes"`
+}
+
+func runBenchmark(widgets int, iterations int) (*benchResult, error) {
+	harness, err := newBenchHarness(widgets)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer harness.close()
+
+	result := &benchResult{Widgets: widgets, Iterations: iterations}
+	var refreshTotal time.Duration
+
+	for range benchRefreshRuns {
+		refreshTotal += harness.refresh()
+	}
+
+	refreshAverage := refreshTotal / benchRefreshRuns
+	result.RefreshMs = durationToMs(refreshAverage)
+	result.RefreshWidgetsPerSecond = float64(widgets) / refreshAverage.Seconds()
+
+	durations := make([]time.Duration, iterations)
+	var before, after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	for i := range iterations {
+		start := time.Now()
+
+		if err := harness.render(); err != nil {
+			return nil, err
+		}
+
+		durations[i] = time.Since(start)
+	}
+
+	runtime.ReadMemStats(&after)
+	slices.Sort(durations)
+
+	result.RenderP50Ms = durationToMs(durationPercentile(durations, 50))
+	result.RenderP95Ms = durationToMs(durationPercentile(durations, 95))
+	result.RenderP99Ms = durationToMs(durationPercentile(durations, 99))
+	result.RenderAllocs = (after.Mallocs - before.Mallocs) / uint64(iterations)
+	result.RenderBytes = (after.TotalAlloc - before.TotalAlloc) / uint64(iterations)
+
+	return result, nil
+}
+
+// Expects the durations to be sorted
+func durationPercentile(durations []time.Duration, percentile int) time.Duration {
+	return durations[min(len(durations)-1, len(durations)*percentile/100)]
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func runBench(options *CliOptions) int {
+	result, err := runBenchmark(options.BenchWidgets, options.BenchIterations)
+
+	if err != nil {
+		fmt.Printf("benchmark failed: %v\n", err)
+		return 1
+	}
+
+	if options.OutputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+
+		return 0
+	}
+
+	fmt.Printf("widgets              %d\n", result.Widgets)
+	fmt.Printf("refresh              %.2fms (%.0f widgets/s)\n", result.RefreshMs, result.RefreshWidgetsPerSecond)
+	fmt.Printf("render p50           %.2fms\n", result.RenderP50Ms)
+	fmt.Printf("render p95           %.2fms\n", result.RenderP95Ms)
+	fmt.Printf("render p99           %.2fms\n", result.RenderP99Ms)
+	fmt.Printf("allocs per render    %d (%s)\n", result.RenderAllocs, formatBenchBytes(result.RenderBytes))
+
+	return 0
+}
+
+func formatBenchBytes(bytes uint64) string {
+	if bytes < 1<<20 {
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	}
+
+	return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+}
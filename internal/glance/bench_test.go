@@ -0,0 +1,41 @@
+package glance
+
+import "testing"
+
+func BenchmarkRenderPage(b *testing.B) {
+	harness, err := newBenchHarness(benchDefaultWidgets)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer harness.close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if err := harness.render(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRefreshPage(b *testing.B) {
+	harness, err := newBenchHarness(benchDefaultWidgets)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer harness.close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		harness.refresh()
+	}
+
+	b.ReportMetric(float64(benchDefaultWidgets*b.N)/b.Elapsed().Seconds(), "widgets/s")
+}
@@ -1,6 +1,7 @@
 package glance
 
 import (
+	"errors"
 	"flag"
 	"os"
 )
@@ -10,14 +11,46 @@ type CliIntent uint8
 const (
 	CliIntentServe       CliIntent = iota
 	CliIntentCheckConfig           = iota
+	CliIntentImport                = iota
+	CliIntentValidate              = iota
+	CliIntentLint                  = iota
+	CliIntentBench                 = iota
 )
 
 type CliOptions struct {
 	Intent     CliIntent
 	ConfigPath string
+	ImportFrom string
+	ImportPath string
+	// the format of the output of the lint and bench commands, either text or json
+	OutputFormat string
+	// skips the checks which make requests, such as whether icons can be reached
+	LintOffline     bool
+	BenchWidgets    int
+	BenchIterations int
 }
 
 func ParseCliOptions() (*CliOptions, error) {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		return parseImportCliOptions(os.Args[2:])
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		return parseValidateCliOptions(os.Args[2:])
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		return parseBenchCliOptions(os.Args[2:])
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if len(os.Args) < 3 || os.Args[2] != "lint" {
+			return nil, errors.New(lintUsage)
+		}
+
+		return parseLintCliOptions(os.Args[3:])
+	}
+
 	flags := flag.NewFlagSet("", flag.ExitOnError)
 
 	checkConfig := flags.Bool("check-config", false, "Check whether the config is valid")
@@ -40,3 +73,112 @@ func ParseCliOptions() (*CliOptions, error) {
 		ConfigPath: *configPath,
 	}, nil
 }
+
+func parseValidateCliOptions(args []string) (*CliOptions, error) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	configPath := flags.String("config", "glance.yml", "Set config path")
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() > 1 {
+		return nil, errors.New("usage: glance validate [--config <file> | <file>]")
+	}
+
+	if flags.NArg() == 1 {
+		*configPath = flags.Arg(0)
+	}
+
+	return &CliOptions{
+		Intent:     CliIntentValidate,
+		ConfigPath: *configPath,
+	}, nil
+}
+
+const lintUsage = "usage: glance config lint [--format text|json] [--offline] [--config <file> | <file>]"
+
+func parseLintCliOptions(args []string) (*CliOptions, error) {
+	flags := flag.NewFlagSet("config lint", flag.ExitOnError)
+
+	configPath := flags.String("config", "glance.yml", "Set config path")
+	format := flags.String("format", "text", "The format of the output, either text or json")
+	offline := flags.Bool("offline", false, "Skip the checks which make requests")
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() > 1 || (*format != "text" && *format != "json") {
+		return nil, errors.New(lintUsage)
+	}
+
+	if flags.NArg() == 1 {
+		*configPath = flags.Arg(0)
+	}
+
+	return &CliOptions{
+		Intent:       CliIntentLint,
+		ConfigPath:   *configPath,
+		OutputFormat: *format,
+		LintOffline:  *offline,
+	}, nil
+}
+
+const benchUsage = "usage: glance bench [--widgets <count>] [--iterations <count>] [--format text|json]"
+
+func parseBenchCliOptions(args []string) (*CliOptions, error) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+
+	widgets := flags.Int("widgets", benchDefaultWidgets, "How many widgets the synthetic page has")
+	iterations := flags.Int("iterations", benchDefaultIterations, "How many times the page gets rendered")
+	format := flags.String("format", "text", "The format of the output, either text or json")
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() > 0 || *widgets < 1 || *iterations < 1 || (*format != "text" && *format != "json") {
+		return nil, errors.New(benchUsage)
+	}
+
+	return &CliOptions{
+		Intent:          CliIntentBench,
+		OutputFormat:    *format,
+		BenchWidgets:    *widgets,
+		BenchIterations: *iterations,
+	}, nil
+}
+
+func parseImportCliOptions(args []string) (*CliOptions, error) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+
+	from := flags.String("from", "", "The application to import from, one of: homepage, dashy, heimdall")
+
+	err := flags.Parse(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if *from == "" {
+		return nil, errors.New("usage: glance import --from homepage|dashy|heimdall <file>")
+	}
+
+	if flags.NArg() != 1 {
+		return nil, errors.New("expected exactly one file to import")
+	}
+
+	return &CliOptions{
+		Intent:     CliIntentImport,
+		ImportFrom: *from,
+		ImportPath: flags.Arg(0),
+	}, nil
+}
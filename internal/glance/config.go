@@ -3,44 +3,160 @@ package glance
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
 )
 
 type Config struct {
-	Server   Server   `yaml:"server"`
-	Theme    Theme    `yaml:"theme"`
-	Branding Branding `yaml:"branding"`
-	Pages    []Page   `yaml:"pages"`
+	Server     Server              `yaml:"server"`
+	Auth       Auth                `yaml:"auth"`
+	Theme      Theme               `yaml:"theme"`
+	Branding   Branding            `yaml:"branding"`
+	Units      feed.UnitSystem     `yaml:"units"`
+	QuietHours widget.TimeWindow   `yaml:"quiet-hours"`
+	Locale     string              `yaml:"locale"`
+	Currency   string              `yaml:"currency"`
+	Profiles   map[string]*Profile `yaml:"profiles"`
+	Pages      []Page              `yaml:"pages"`
+	settings   *globalSettings     `yaml:"-"`
 }
 
-func NewConfigFromYml(contents io.Reader) (*Config, error) {
-	config := NewConfig()
+// The settings of a config which are shared by everything rather than belonging to the
+// application made from it. They're only built when the config gets parsed and get applied
+// once the application has been made, so that a reloaded config which turns out to be
+// invalid doesn't leave any of them in place
+type globalSettings struct {
+	clients         *feed.GlobalClients
+	widgets         *widget.Settings
+	locale          *assets.Locale
+	tracingEndpoint string
+}
 
+func (s *globalSettings) apply() {
+	feed.SetGlobalClients(s.clients)
+	widget.ApplySettings(s.widgets)
+	assets.SetLocale(s.locale)
+	feed.SetTracingEndpoint(s.tracingEndpoint)
+}
+
+// Includes in the config are relative to the current working directory
+func NewConfigFromYml(contents io.Reader) (*Config, error) {
 	contentBytes, err := io.ReadAll(contents)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = yaml.Unmarshal(contentBytes, config)
+	return newConfigFromYml(contentBytes, ".")
+}
+
+// Includes in the config are relative to the directory of the config file
+func NewConfigFromFile(path string) (*Config, error) {
+	contents, err := os.ReadFile(path)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return newConfigFromYml(contents, filepath.Dir(path))
+}
+
+func newConfigFromYml(contents []byte, dir string) (*Config, error) {
+	config := NewConfig()
+
+	document, _, err := parseConfigNode(contents, dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// empty configs fail the checks below just the same
+	if len(document.Content) > 0 {
+		if err = document.Decode(config); err != nil {
+			return nil, err
+		}
+	}
+
 	if err = configIsValid(config); err != nil {
 		return nil, err
 	}
 
+	if err = config.Theme.resolve(nil, false); err != nil {
+		return nil, fmt.Errorf("theme: %v", err)
+	}
+
 	for p := range config.Pages {
-		for c := range config.Pages[p].Columns {
-			for w := range config.Pages[p].Columns[c].Widgets {
-				if err := config.Pages[p].Columns[c].Widgets[w].Initialize(); err != nil {
-					return nil, err
+		if theme := config.Pages[p].Theme; theme != nil {
+			if err = theme.resolve(&config.Theme, false); err != nil {
+				return nil, fmt.Errorf("Page %d theme: %v", p+1, err)
+			}
+		}
+	}
+
+	if err = config.Auth.initialize(); err != nil {
+		return nil, err
+	}
+
+	settings := &globalSettings{tracingEndpoint: config.Server.TracingEndpoint.String()}
+
+	settings.clients, err = feed.NewGlobalClients(feed.ClientOptions{
+		ProxyURL:           config.Server.Proxy.String(),
+		Timeout:            time.Duration(config.Server.RequestTimeout),
+		CAFile:             config.Server.CAFile,
+		UserAgent:          config.Server.UserAgent.String(),
+		IPPreference:       config.Server.IPPreference,
+		DNSResolver:        config.Server.DNSResolver,
+		Retries:            config.Server.RequestRetries,
+		MaxRequestsPerHost: config.Server.MaxRequestsPerHost,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("server: %v", err)
+	}
+
+	settings.widgets, err = widget.NewSettings(settings.clients, config.Units, config.Currency, config.QuietHours)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.locale, err = assets.NewLocale(config.Locale); err != nil {
+		return nil, err
+	}
+
+	config.settings = settings
+
+	if err = assignWidgetSlugs(config.Pages); err != nil {
+		return nil, err
+	}
+
+	err = widget.InitializeWithSettings(settings.widgets, func() error {
+		for p := range config.Pages {
+			for c := range config.Pages[p].Columns {
+				for w := range config.Pages[p].Columns[c].Widgets {
+					if err := widget.Initialize(config.Pages[p].Columns[c].Widgets[w]); err != nil {
+						return err
+					}
 				}
 			}
 		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = initializeProfiles(config); err != nil {
+		return nil, err
 	}
 
 	return config, nil
@@ -56,6 +172,23 @@ func NewConfig() *Config {
 }
 
 func configIsValid(config *Config) error {
+	// pages can come from different files, which makes it easy to end up with the same slug twice
+	pageBySlug := make(map[string]int, len(config.Pages))
+
+	for i := range config.Pages {
+		slug := config.Pages[i].Slug
+
+		if slug == "" {
+			slug = titleToSlug(config.Pages[i].Title)
+		}
+
+		if other, exists := pageBySlug[slug]; exists {
+			return fmt.Errorf("Page %d (%s) has the same slug as page %d (%s): '%s', set a different slug for one of them", i+1, config.Pages[i].Title, other+1, config.Pages[other].Title, slug)
+		}
+
+		pageBySlug[slug] = i
+	}
+
 	for i := range config.Pages {
 		if config.Pages[i].Title == "" {
 			return fmt.Errorf("Page %d has no title", i+1)
@@ -65,6 +198,10 @@ func configIsValid(config *Config) error {
 			return fmt.Errorf("Page %d: width can only be either wide or slim", i+1)
 		}
 
+		if config.Pages[i].MaxConcurrentUpdates < 0 {
+			return fmt.Errorf("Page %d: max-concurrent-updates must be positive", i+1)
+		}
+
 		if len(config.Pages[i].Columns) == 0 {
 			return fmt.Errorf("Page %d has no columns", i+1)
 		}
@@ -98,3 +235,82 @@ func configIsValid(config *Config) error {
 
 	return nil
 }
+
+var widgetSlugPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Widgets without an id get one made from the slug of their page and their type,
+// such as home-rss, with a number added for every widget of the same type after
+// the first, which keeps it the same for as long as the order of the widgets is.
+// Done before the widgets get initialized since some of them get rendered then
+func assignWidgetSlugs(pages []Page) error {
+	var err error
+	taken := make(map[string]bool)
+
+	for p := range pages {
+		widget.WalkWidgets(pages[p].widgets(), func(w widget.Widget) {
+			slug := w.GetSlug()
+
+			if slug == "" || err != nil {
+				return
+			}
+
+			if !widgetSlugPattern.MatchString(slug) {
+				err = fmt.Errorf("invalid id '%s' of %s widget, it can only contain letters, numbers, - and _", slug, w.GetType())
+				return
+			}
+
+			// these would be mistaken for the IDs given to widgets when the config is loaded
+			if _, parseErr := strconv.ParseUint(slug, 10, 64); parseErr == nil {
+				err = fmt.Errorf("invalid id '%s' of %s widget, it can't be only numbers", slug, w.GetType())
+				return
+			}
+
+			if taken[slug] {
+				err = fmt.Errorf("id '%s' of %s widget is already used by another widget", slug, w.GetType())
+				return
+			}
+
+			taken[slug] = true
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for p := range pages {
+		page := &pages[p]
+		pageSlug := page.Slug
+		counts := make(map[string]int)
+
+		if pageSlug == "" {
+			pageSlug = titleToSlug(page.Title)
+		}
+
+		widget.WalkWidgets(page.widgets(), func(w widget.Widget) {
+			if w.GetSlug() != "" {
+				return
+			}
+
+			base := pageSlug + "-" + w.GetType()
+			slug := base
+
+			for {
+				counts[base]++
+
+				if counts[base] > 1 {
+					slug = base + "-" + strconv.Itoa(counts[base])
+				}
+
+				if !taken[slug] {
+					break
+				}
+			}
+
+			w.SetSlug(slug)
+			taken[slug] = true
+		})
+	}
+
+	return nil
+}
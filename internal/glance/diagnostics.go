@@ -0,0 +1,47 @@
+package glance
+
+import (
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	expvar.Publish("glance_requests", expvar.Func(func() any {
+		return feed.GetRequestMetrics()
+	}))
+}
+
+// Registers the pprof and expvar handlers, wrapped with protect if it isn't nil
+func registerDiagnosticsRoutes(mux *http.ServeMux, protect func(http.Handler) http.Handler) {
+	if protect == nil {
+		protect = func(next http.Handler) http.Handler { return next }
+	}
+
+	mux.Handle("GET /debug/pprof/", protect(http.HandlerFunc(pprof.Index)))
+	mux.Handle("GET /debug/pprof/cmdline", protect(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("GET /debug/pprof/profile", protect(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("GET /debug/pprof/symbol", protect(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("GET /debug/pprof/trace", protect(http.HandlerFunc(pprof.Trace)))
+	mux.Handle("GET /debug/vars", protect(expvar.Handler()))
+}
+
+func (a *Application) diagnosticsMiddleware(next http.Handler) http.Handler {
+	return requireBearerToken(a.Config.Server.DiagnosticsToken.String(), next)
+}
+
+// Runs on its own address so that the profiles can be fetched by go tool pprof,
+// which can't send a token, without making them reachable through the main server
+func serveDiagnostics(address string) {
+	mux := http.NewServeMux()
+	registerDiagnosticsRoutes(mux, nil)
+
+	slog.Info("Serving diagnostics", "address", address)
+
+	if err := http.ListenAndServe(address, mux); err != nil {
+		slog.Error("Diagnostics server error", "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package glance
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+type federatedWidget struct {
+	ID    uint64        `json:"id"`
+	Slug  string        `json:"slug"`
+	Type  string        `json:"type"`
+	Title string        `json:"title"`
+	HTML  template.HTML `json:"html"`
+}
+
+type federatedPage struct {
+	Slug    string            `json:"slug"`
+	Title   string            `json:"title"`
+	Widgets []federatedWidget `json:"widgets"`
+}
+
+// Renders the widgets of a page for the remote-page widget of another instance,
+// updating the ones which are outdated the same way as when the page is visited
+func (a *Application) HandleFederationPageRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+
+	if !exists {
+		writeAdminJSON(w, http.StatusNotFound, map[string]string{"error": "page not found"})
+		return
+	}
+
+	now := time.Now()
+	ctx, span := feed.StartServerSpan(r.Context(), "render "+page.Slug, "page.slug", page.Slug)
+	defer span.End(nil)
+
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	page.UpdateOutdatedWidgets(ctx, now, "")
+
+	result := federatedPage{
+		Slug:    page.Slug,
+		Title:   page.Title,
+		Widgets: make([]federatedWidget, 0),
+	}
+
+	for _, pageWidget := range page.widgets() {
+		if !pageWidget.IsVisible(now, "") {
+			continue
+		}
+
+		// widgets of pages with async-load can still be updating in the background,
+		// in which case the other instance gets their skeleton until its next update
+		html, rendered := widget.TryRender(pageWidget, &now, widget.Widget.Render)
+
+		if !rendered {
+			html = widget.RenderSkeleton(pageWidget)
+		}
+
+		result.Widgets = append(result.Widgets, federatedWidget{
+			ID:    pageWidget.GetID(),
+			Slug:  pageWidget.GetSlug(),
+			Type:  pageWidget.GetType(),
+			Title: pageWidget.GetTitle(),
+			HTML:  html,
+		})
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	writeAdminJSON(w, http.StatusOK, result)
+}
@@ -3,18 +3,21 @@ package glance
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
 	"github.com/glanceapp/glance/internal/widget"
 )
 
@@ -27,9 +30,30 @@ type Application struct {
 	Config     Config
 	slugToPage map[string]*Page
 	widgetByID map[uint64]widget.Widget
+	// includes the widgets nested inside of other widgets
+	widgetBySlug map[string]widget.Widget
+	// the page each widget is on, including the nested ones
+	pageByWidget map[widget.Widget]*Page
+	// sorted so that the profiles are matched against user agents in the same order
+	profileNames []string
+	// widgets which receive data through /api/webhooks/{token}
+	widgetByWebhookToken map[string]widget.Widget
+	history              *widget.HistoryStore
+	storage              *widget.Storage
+	// nil when update checks are disabled
+	updates *updateChecker
+	// nil when no GeoIP database is configured
+	geoip *feed.GeoIPDatabase
+	// stops what the widgets do in the background
+	stopWidgets context.CancelFunc
+	// used to reload the config through the admin API
+	configPath string
+	handler    http.Handler
+	live       *liveApplication
 }
 
 type Theme struct {
+	Preset                   string                `yaml:"preset"`
 	BackgroundColor          *widget.HSLColorField `yaml:"background-color"`
 	PrimaryColor             *widget.HSLColorField `yaml:"primary-color"`
 	PositiveColor            *widget.HSLColorField `yaml:"positive-color"`
@@ -37,16 +61,55 @@ type Theme struct {
 	Light                    bool                  `yaml:"light"`
 	ContrastMultiplier       float32               `yaml:"contrast-multiplier"`
 	TextSaturationMultiplier float32               `yaml:"text-saturation-multiplier"`
+	FontFamily               string                `yaml:"font-family"`
+	FontSize                 float32               `yaml:"font-size"`
 	CustomCSSFile            string                `yaml:"custom-css-file"`
+	ReduceMotion             bool                  `yaml:"reduce-motion"`
+	IndicatorShapes          bool                  `yaml:"indicator-shapes"`
+	// used instead of the theme when the browser prefers a light or dark color scheme
+	PrefersLight *Theme `yaml:"prefers-light"`
+	PrefersDark  *Theme `yaml:"prefers-dark"`
 }
 
 type Server struct {
 	Host       string    `yaml:"host"`
 	Port       uint16    `yaml:"port"`
 	AssetsPath string    `yaml:"assets-path"`
+	DataPath   string    `yaml:"data-path"`
 	BaseURL    string    `yaml:"base-url"`
 	AssetsHash string    `yaml:"-"`
 	StartedAt  time.Time `yaml:"-"` // used in custom css file
+	// when set, the stats of the machine Glance is running on are made available at /api/server-stats,
+	// /api/disk-health and /api/storage-pools so that they can be shown by the widgets of other instances
+	StatsAPIToken widget.OptionalEnvString `yaml:"stats-api-token"`
+	// when set, the rendered widgets of each page are made available under /api/federation
+	// so that they can be shown by the remote-page widget of other instances
+	FederationToken widget.OptionalEnvString `yaml:"federation-token"`
+	// when set, Prometheus metrics are made available at /metrics
+	MetricsToken widget.OptionalEnvString `yaml:"metrics-token"`
+	// when set, the admin API is made available under /api/admin
+	AdminToken widget.OptionalEnvString `yaml:"admin-token"`
+	// when set, pprof profiles and expvar variables are made available under /debug
+	DiagnosticsToken widget.OptionalEnvString `yaml:"diagnostics-token"`
+	// the same as the token but on a separate address without requiring it
+	DiagnosticsAddress string `yaml:"diagnostics-address"`
+	// when set, traces of page renders, widget updates and their requests get sent there
+	TracingEndpoint widget.OptionalEnvString `yaml:"tracing-endpoint"`
+	// applied to all outgoing requests unless a widget specifies its own
+	Proxy              widget.OptionalEnvString `yaml:"proxy"`
+	CAFile             string                   `yaml:"ca-file"`
+	RequestTimeout     widget.DurationField     `yaml:"request-timeout"`
+	UserAgent          widget.OptionalEnvString `yaml:"user-agent"`
+	IPPreference       string                   `yaml:"ip-preference"`
+	DNSResolver        string                   `yaml:"dns-resolver"`
+	RequestRetries     int                      `yaml:"request-retries"`
+	MaxRequestsPerHost int                      `yaml:"max-requests-per-host"`
+	// periodically checks whether a newer version has been released
+	CheckForUpdates bool `yaml:"check-for-updates"`
+	// used to find where visitors are for widgets with visitor-location
+	GeoIPDatabase string `yaml:"geoip-database"`
+	// trusts the location headers of Cloudflare and CloudFront, as well as the address in X-Forwarded-For
+	GeoIPProxyHeaders bool `yaml:"geoip-proxy-headers"`
 }
 
 type Branding struct {
@@ -58,45 +121,210 @@ type Branding struct {
 }
 
 type Column struct {
-	Size    string         `yaml:"size"`
-	Widgets widget.Widgets `yaml:"widgets"`
+	Size          string         `yaml:"size"`
+	HideWhenEmpty bool           `yaml:"hide-when-empty"`
+	Widgets       widget.Widgets `yaml:"widgets"`
 }
 
 type templateData struct {
-	App  *Application
-	Page *Page
+	App    *Application
+	Page   *Page
+	now    time.Time
+	layout widget.Layout
+	// widget type to style, an empty type applies to all widgets
+	previewStyles map[string]string
+	// widgets that aren't ready get rendered as skeletons instead of waiting for them
+	async bool
+	// the widgets which were either rendered as skeletons or with outdated data
+	pendingWidgets []string
+	// nil when the page isn't being shown with a profile
+	profile *Profile
+	// nil when the location of the visitor isn't known
+	visitorPlace *feed.PlaceJson
+}
+
+func (d *templateData) Theme() *Theme {
+	if d.Page != nil && d.Page.Theme != nil {
+		return d.Page.Theme
+	}
+
+	return &d.App.Config.Theme
+}
+
+func (d *templateData) ResourceHints() widget.ResourceHints {
+	return widget.CollectResourceHints(d.Page.widgets())
+}
+
+func (d *templateData) IsWidgetVisible(w widget.Widget) bool {
+	return w.IsVisible(d.now, d.layout) && !d.profile.hides(w)
+}
+
+func (d *templateData) RenderWidget(w widget.Widget) template.HTML {
+	w = visitorWidget(w, d.visitorPlace, &d.now)
+
+	if !d.async {
+		return renderWidgetWithPreviewStyle(w, d.previewStyles)
+	}
+
+	html, rendered := widget.TryRender(w, &d.now, func(w widget.Widget) template.HTML {
+		return renderWidgetWithPreviewStyle(w, d.previewStyles)
+	})
+
+	if !rendered || w.RequiresUpdate(&d.now) {
+		d.pendingWidgets = append(d.pendingWidgets, strconv.FormatUint(w.GetID(), 10))
+	}
+
+	if !rendered {
+		return widget.RenderSkeleton(w)
+	}
+
+	return html
+}
+
+func renderWidgetWithPreviewStyle(w widget.Widget, previewStyles map[string]string) template.HTML {
+	if style, exists := previewStyles[w.GetType()]; exists {
+		return widget.RenderWithStyle(w, style)
+	}
+
+	if style, exists := previewStyles[""]; exists {
+		return widget.RenderWithStyle(w, style)
+	}
+
+	return w.Render()
+}
+
+// Parses values in the format of [type:]style, such as rss:horizontal-cards, which
+// allow trying out different styles of widgets without having to change the config
+func parsePreviewStyles(values []string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	styles := make(map[string]string, len(values))
+
+	for _, value := range values {
+		widgetType, style, found := strings.Cut(value, ":")
+
+		if !found {
+			widgetType, style = "", value
+		}
+
+		styles[widgetType] = style
+	}
+
+	return styles
 }
 
 type Page struct {
-	Title                 string   `yaml:"name"`
-	Slug                  string   `yaml:"slug"`
-	Width                 string   `yaml:"width"`
-	ShowMobileHeader      bool     `yaml:"show-mobile-header"`
-	HideDesktopNavigation bool     `yaml:"hide-desktop-navigation"`
-	CenterVertically      bool     `yaml:"center-vertically"`
-	Columns               []Column `yaml:"columns"`
-	PrimaryColumnIndex    int8     `yaml:"-"`
-	mu                    sync.Mutex
-}
-
-func (p *Page) UpdateOutdatedWidgets() {
-	now := time.Now()
+	Title                 string `yaml:"name"`
+	Slug                  string `yaml:"slug"`
+	Width                 string `yaml:"width"`
+	ShowMobileHeader      bool   `yaml:"show-mobile-header"`
+	HideDesktopNavigation bool   `yaml:"hide-desktop-navigation"`
+	CenterVertically      bool   `yaml:"center-vertically"`
+	LiveUpdates           bool   `yaml:"live-updates"`
+	// columns are shown side by side and switched between by swiping rather than one at a time
+	MobileSwipeableColumns bool `yaml:"mobile-swipeable-columns"`
+	// the links to the other pages are always shown rather than behind the menu button
+	MobileStickyPageLinks bool `yaml:"mobile-sticky-page-links"`
+	// widgets without data get rendered as placeholders rather than delaying the page
+	AsyncLoad bool `yaml:"async-load"`
+	// widgets with data older than this get updated when the page becomes visible again
+	RefreshOnWake widget.DurationField `yaml:"refresh-on-wake"`
+	// how many widgets of the page get updated at the same time
+	MaxConcurrentUpdates int `yaml:"max-concurrent-updates"`
+	// widgets which take longer than this to update get rendered as placeholders
+	UpdateTimeout widget.DurationField `yaml:"update-timeout"`
+	// goes to the next page which has it set after this long without any interaction
+	RotateAfter widget.DurationField `yaml:"rotate-after"`
+	RotateTo    *Page                `yaml:"-"`
+	// covers the page with a dimmed clock after this long without any interaction
+	IdleAfter          widget.DurationField `yaml:"idle-after"`
+	Theme              *Theme               `yaml:"theme"`
+	Columns            []Column             `yaml:"columns"`
+	PrimaryColumnIndex int8                 `yaml:"-"`
+	mu                 sync.Mutex
+}
+
+const defaultMaxConcurrentUpdates = 10
+
+func (p *Page) widgets() []widget.Widget {
+	widgets := make([]widget.Widget, 0)
+
+	for c := range p.Columns {
+		widgets = append(widgets, p.Columns[c].Widgets...)
+	}
+
+	return widgets
+}
+
+// The pages with rotate-after go to the one after them in the config
+// which also has it, and the last one goes back to the first
+func linkPageRotation(pages []Page) {
+	rotating := make([]*Page, 0)
+
+	for p := range pages {
+		if pages[p].RotateAfter > 0 {
+			rotating = append(rotating, &pages[p])
+		}
+	}
+
+	// a single page would only keep reloading itself
+	if len(rotating) < 2 {
+		return
+	}
+
+	for i, page := range rotating {
+		page.RotateTo = rotating[(i+1)%len(rotating)]
+	}
+}
+
+// Includes the widgets nested inside of other widgets
+func (p *Page) allWidgets() []widget.Widget {
+	all := make([]widget.Widget, 0)
+	widget.WalkWidgets(p.widgets(), func(w widget.Widget) {
+		all = append(all, w)
+	})
+
+	return all
+}
+
+func (p *Page) maxConcurrentUpdates() int {
+	if p.MaxConcurrentUpdates <= 0 {
+		return defaultMaxConcurrentUpdates
+	}
+
+	return p.MaxConcurrentUpdates
+}
+
+// The context is only used for tracing, the updates aren't cancelled along with it
+func (p *Page) UpdateOutdatedWidgets(ctx context.Context, now time.Time, layout widget.Layout) {
+	ctx = context.WithoutCancel(ctx)
 
 	var wg sync.WaitGroup
-	context := context.Background()
+	slots := make(chan struct{}, p.maxConcurrentUpdates())
 
 	for c := range p.Columns {
 		for w := range p.Columns[c].Widgets {
-			widget := p.Columns[c].Widgets[w]
+			pageWidget := p.Columns[c].Widgets[w]
 
-			if !widget.RequiresUpdate(&now) {
+			if !pageWidget.IsVisible(now, layout) {
+				continue
+			}
+
+			if !pageWidget.RequiresUpdate(&now) {
+				widget.RecordCacheHit(pageWidget)
 				continue
 			}
 
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				widget.Update(context)
+
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				widget.UpdateIfRequired(ctx, pageWidget, &now)
 			}()
 		}
 	}
@@ -104,6 +332,26 @@ func (p *Page) UpdateOutdatedWidgets() {
 	wg.Wait()
 }
 
+// Returns false if the updates didn't finish before the timeout
+func (p *Page) updateOutdatedWidgetsWithin(ctx context.Context, now time.Time, layout widget.Layout, timeout time.Duration) bool {
+	done := make(chan struct{})
+
+	go func() {
+		p.UpdateOutdatedWidgets(ctx, now, layout)
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // TODO: fix, currently very simple, lots of uncovered edge cases
 func titleToSlug(s string) string {
 	s = strings.ToLower(s)
@@ -126,18 +374,84 @@ func NewApplication(config *Config) (*Application, error) {
 		return nil, fmt.Errorf("no pages configured")
 	}
 
+	history, err := widget.NewHistoryStore(config.Server.DataPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := widget.NewStorage(config.Server.DataPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := newApplication(config, history, storage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.settings.apply()
+
+	if config.Server.CheckForUpdates {
+		app.updates = newUpdateChecker(buildVersion)
+
+		if app.updates == nil {
+			slog.Warn("Update checks are not available for development builds", "version", buildVersion)
+		}
+	}
+
+	return app, nil
+}
+
+// The stores are passed in so that they can be kept when the config gets reloaded
+func newApplication(config *Config, history *widget.HistoryStore, storage *widget.Storage) (_ *Application, err error) {
+	if len(config.Pages) == 0 {
+		return nil, fmt.Errorf("no pages configured")
+	}
+
+	ctx, stopWidgets := context.WithCancel(context.Background())
+
+	defer func() {
+		if err != nil {
+			stopWidgets()
+		}
+	}()
+
 	app := &Application{
-		Version:    buildVersion,
-		Config:     *config,
-		slugToPage: make(map[string]*Page),
-		widgetByID: make(map[uint64]widget.Widget),
+		Version:              buildVersion,
+		Config:               *config,
+		slugToPage:           make(map[string]*Page),
+		widgetByID:           make(map[uint64]widget.Widget),
+		widgetBySlug:         make(map[string]widget.Widget),
+		pageByWidget:         make(map[widget.Widget]*Page),
+		widgetByWebhookToken: make(map[string]widget.Widget),
+		history:              history,
+		storage:              storage,
+		stopWidgets:          stopWidgets,
 	}
 
 	app.Config.Server.AssetsHash = assets.PublicFSHash
 	app.slugToPage[""] = &config.Pages[0]
 
+	if config.Server.GeoIPDatabase != "" {
+		geoip, err := feed.OpenGeoIPDatabase(config.Server.GeoIPDatabase)
+
+		if err != nil {
+			return nil, err
+		}
+
+		app.geoip = geoip
+	}
+
 	providers := &widget.Providers{
 		AssetResolver: app.AssetPath,
+		DataBus:       widget.NewDataBus(),
+		History:       history,
+		Storage:       storage,
+		GeoIP:         app.geoip,
+		Context:       ctx,
 	}
 
 	for p := range config.Pages {
@@ -162,6 +476,37 @@ func NewApplication(config *Config) (*Application, error) {
 				app.widgetByID[widget.GetID()] = widget
 
 				widget.SetProviders(providers)
+
+				if err := providers.DataBus.Register(widget, &page.mu); err != nil {
+					return nil, err
+				}
+			}
+
+			if err := app.registerWebhookReceivers(column.Widgets); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for p := range config.Pages {
+		widget.WalkWidgets(config.Pages[p].widgets(), func(w widget.Widget) {
+			app.widgetBySlug[w.GetSlug()] = w
+			app.pageByWidget[w] = &config.Pages[p]
+		})
+	}
+
+	linkPageRotation(config.Pages)
+
+	for name := range config.Profiles {
+		app.profileNames = append(app.profileNames, name)
+	}
+
+	slices.Sort(app.profileNames)
+
+	for p := range config.Pages {
+		for c := range config.Pages[p].Columns {
+			if err := providers.DataBus.Validate(config.Pages[p].Columns[c].Widgets); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -171,6 +516,12 @@ func NewApplication(config *Config) (*Application, error) {
 	config.Server.BaseURL = strings.TrimRight(config.Server.BaseURL, "/")
 	config.Theme.CustomCSSFile = app.TransformUserDefinedAssetPath(config.Theme.CustomCSSFile)
 
+	for p := range config.Pages {
+		if theme := config.Pages[p].Theme; theme != nil {
+			theme.CustomCSSFile = app.TransformUserDefinedAssetPath(theme.CustomCSSFile)
+		}
+	}
+
 	if config.Branding.FaviconURL == "" {
 		config.Branding.FaviconURL = app.AssetPath("favicon.png")
 	} else {
@@ -191,12 +542,13 @@ func (a *Application) HandlePageRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	pageData := templateData{
-		Page: page,
-		App:  a,
+		Page:    page,
+		App:     a,
+		profile: a.profileFor(r),
 	}
 
 	var responseBytes bytes.Buffer
-	err := assets.PageTemplate.Execute(&responseBytes, pageData)
+	err := assets.PageTemplate.Execute(&responseBytes, &pageData)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -216,15 +568,45 @@ func (a *Application) HandlePageContentRequest(w http.ResponseWriter, r *http.Re
 	}
 
 	pageData := templateData{
-		Page: page,
+		Page:          page,
+		now:           time.Now(),
+		layout:        widget.ParseLayout(r.URL.Query().Get("layout")),
+		previewStyles: parsePreviewStyles(r.URL.Query()["preview-style"]),
+		async:         page.AsyncLoad,
+		profile:       a.profileFor(r),
+		visitorPlace:  a.visitorPlace(r),
 	}
 
+	ctx, span := feed.StartServerSpan(r.Context(), "render "+page.Slug, "page.slug", page.Slug, "page.layout", string(pageData.layout))
+	defer span.End(nil)
+
 	page.mu.Lock()
 	defer page.mu.Unlock()
-	page.UpdateOutdatedWidgets()
+
+	if page.AsyncLoad {
+		// started after rendering so that the widgets with outdated data can still be
+		// shown as they are, the page then gets the updated ones through the updates stream.
+		// The updates outlive the request, so they keep the span but not its cancellation
+		defer func() {
+			go func() {
+				page.mu.Lock()
+				defer page.mu.Unlock()
+				page.UpdateOutdatedWidgets(context.WithoutCancel(ctx), pageData.now, pageData.layout)
+			}()
+		}()
+	} else if page.UpdateTimeout > 0 {
+		// the widgets that didn't make it keep updating in the background and
+		// get rendered the same way as they would be on pages with async-load
+		pageData.async = !page.updateOutdatedWidgetsWithin(ctx, pageData.now, pageData.layout, time.Duration(page.UpdateTimeout))
+	} else {
+		page.UpdateOutdatedWidgets(ctx, pageData.now, pageData.layout)
+	}
+
+	// lets the page ask only for the widgets that have changed since when it wakes up
+	w.Header().Set("X-Rendered-At", strconv.FormatInt(pageData.now.UnixMilli(), 10))
 
 	var responseBytes bytes.Buffer
-	err := assets.PageContentTemplate.Execute(&responseBytes, pageData)
+	err := assets.PageContentTemplate.Execute(&responseBytes, &pageData)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -232,9 +614,318 @@ func (a *Application) HandlePageContentRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// the page waits for these through the updates stream
+	if len(pageData.pendingWidgets) > 0 {
+		w.Header().Set("X-Pending-Widgets", strings.Join(pageData.pendingWidgets, ","))
+	}
+
 	w.Write(responseBytes.Bytes())
 }
 
+// Renders a single widget of the page, which can be nested inside of another
+// one, updating it first if needed the same way as when rendering the page
+func (a *Application) HandleWidgetContentRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+
+	if !exists {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	pageWidget, exists := a.findWidget(r.PathValue("widget"))
+
+	if !exists || !slices.Contains(page.allWidgets(), pageWidget) {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	now := time.Now()
+	pageWidget = widget.ForVisitor(pageWidget, a.visitorPlace(r))
+	ctx, span := feed.StartServerSpan(r.Context(), "render "+pageWidget.GetSlug(), "page.slug", page.Slug, "widget.id", pageWidget.GetSlug())
+	defer span.End(nil)
+
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	if pageWidget.RequiresUpdate(&now) {
+		widget.UpdateIfRequired(context.WithoutCancel(ctx), pageWidget, &now)
+	} else {
+		widget.RecordCacheHit(pageWidget)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(renderWidgetWithPreviewStyle(pageWidget, parsePreviewStyles(r.URL.Query()["preview-style"]))))
+}
+
+const pageUpdatesCheckInterval = 5 * time.Second
+const pageUpdatesKeepAliveInterval = 30 * time.Second
+
+// Shorter since these are widgets which the page is showing placeholders for
+const pendingWidgetsCheckInterval = 250 * time.Millisecond
+
+type widgetUpdateEvent struct {
+	ID   uint64        `json:"id"`
+	HTML template.HTML `json:"html"`
+}
+
+// The IDs of the widgets that the page is waiting for, as sent
+// in the X-Pending-Widgets header of the page content response
+func parsePendingWidgets(value string) map[uint64]bool {
+	pending := make(map[uint64]bool)
+
+	for _, id := range strings.Split(value, ",") {
+		if parsed, err := strconv.ParseUint(id, 10, 64); err == nil {
+			pending[parsed] = true
+		}
+	}
+
+	return pending
+}
+
+// Streams the newly rendered HTML of widgets as server-sent events whenever they
+// get updated, which also keeps the widgets on the page updating in the background
+// for as long as at least one client is connected. Pages which load widgets async or
+// have an update timeout also get the widgets which weren't ready when the page was
+// rendered through it, after which the stream ends unless the page has live updates
+func (a *Application) HandlePageUpdatesRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+	pending := parsePendingWidgets(r.URL.Query().Get("pending"))
+
+	profile := a.profileFor(r)
+
+	if !exists || !(profile.liveUpdates(page) || ((page.AsyncLoad || page.UpdateTimeout > 0) && len(pending) > 0)) {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// prevents nginx from buffering the response
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	layout := widget.ParseLayout(r.URL.Query().Get("layout"))
+	previewStyles := parsePreviewStyles(r.URL.Query()["preview-style"])
+	place := a.visitorPlace(r)
+	generations := make(map[uint64]uint64)
+	render := func(w widget.Widget) template.HTML {
+		return renderWidgetWithPreviewStyle(w, previewStyles)
+	}
+
+	page.mu.Lock()
+	for _, pageWidget := range page.widgets() {
+		generations[pageWidget.GetID()] = widget.UpdateGeneration(widget.ForVisitor(pageWidget, place))
+	}
+	page.mu.Unlock()
+
+	ticker := time.NewTicker(pageUpdatesCheckInterval)
+	defer ticker.Stop()
+
+	if len(pending) > 0 {
+		ticker.Reset(pendingWidgetsCheckInterval)
+	}
+
+	lastWriteAt := time.Now()
+	lastCheckAt := time.Now()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		events := make([]widgetUpdateEvent, 0)
+
+		now := time.Now()
+
+		page.mu.Lock()
+
+		if len(pending) > 0 {
+			for _, pageWidget := range page.widgets() {
+				id := pageWidget.GetID()
+
+				if !pending[id] || pageWidget.RequiresUpdate(&now) {
+					continue
+				}
+
+				pageWidget = visitorWidget(pageWidget, place, &now)
+				html, rendered := widget.TryRender(pageWidget, &now, render)
+
+				if !rendered {
+					continue
+				}
+
+				delete(pending, id)
+				generations[id] = widget.UpdateGeneration(pageWidget)
+				events = append(events, widgetUpdateEvent{ID: id, HTML: html})
+			}
+
+			if len(pending) == 0 {
+				ticker.Reset(pageUpdatesCheckInterval)
+			}
+		}
+
+		if profile.liveUpdates(page) && now.Sub(lastCheckAt) >= pageUpdatesCheckInterval {
+			lastCheckAt = now
+			page.UpdateOutdatedWidgets(r.Context(), now, layout)
+
+			// widgets that become visible only show up once the page is reloaded
+			for _, pageWidget := range page.widgets() {
+				if pending[pageWidget.GetID()] || !pageWidget.IsVisible(now, layout) {
+					continue
+				}
+
+				pageWidget = visitorWidget(pageWidget, place, &now)
+				generation := widget.UpdateGeneration(pageWidget)
+
+				if generations[pageWidget.GetID()] == generation {
+					continue
+				}
+
+				generations[pageWidget.GetID()] = generation
+				events = append(events, widgetUpdateEvent{ID: pageWidget.GetID(), HTML: render(pageWidget)})
+			}
+		}
+
+		page.mu.Unlock()
+
+		for i := range events {
+			data, err := json.Marshal(&events[i])
+
+			if err != nil {
+				slog.Error("Failed to encode widget update", "error", err)
+				continue
+			}
+
+			fmt.Fprintf(w, "event: widget-update\ndata: %s\n\n", data)
+		}
+
+		if !profile.liveUpdates(page) && len(pending) == 0 {
+			flusher.Flush()
+			return
+		}
+
+		if len(events) == 0 && time.Since(lastWriteAt) < pageUpdatesKeepAliveInterval {
+			continue
+		}
+
+		// comments are ignored by the browser but keep proxies from closing the connection
+		if len(events) == 0 {
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+
+		flusher.Flush()
+		lastWriteAt = time.Now()
+	}
+}
+
+type pageWakeResponse struct {
+	RenderedAt int64               `json:"rendered-at"`
+	Widgets    []widgetUpdateEvent `json:"widgets"`
+}
+
+// Called when the page becomes visible again, such as after the monitor wakes up,
+// in which case all widgets with data older than the refresh-on-wake duration of the
+// page get updated at once, responding with the widgets which changed since the page
+// was last rendered at the given time
+func (a *Application) HandlePageWakeRequest(w http.ResponseWriter, r *http.Request) {
+	page, exists := a.slugToPage[r.PathValue("page")]
+
+	if !exists {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	refreshOnWake := a.profileFor(r).refreshOnWake(page)
+	place := a.visitorPlace(r)
+
+	if refreshOnWake <= 0 {
+		a.HandleNotFound(w, r)
+		return
+	}
+
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	layout := widget.ParseLayout(r.URL.Query().Get("layout"))
+	previewStyles := parsePreviewStyles(r.URL.Query()["preview-style"])
+	sinceTime := time.UnixMilli(since)
+	now := time.Now()
+
+	page.mu.Lock()
+	defer page.mu.Unlock()
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, page.maxConcurrentUpdates())
+	visible := make([]widget.Widget, 0)
+
+	for _, pageWidget := range page.widgets() {
+		if !pageWidget.IsVisible(now, layout) {
+			continue
+		}
+
+		pageWidget = widget.ForVisitor(pageWidget, place)
+		visible = append(visible, pageWidget)
+
+		// containers are skipped since their widgets get walked through on their own
+		widget.WalkWidgets(widget.Widgets{pageWidget}, func(nested widget.Widget) {
+			if _, ok := widget.GetUpdateStats(nested); !ok {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				slots <- struct{}{}
+				defer func() { <-slots }()
+
+				widget.UpdateIfOlderThan(context.Background(), nested, &now, refreshOnWake)
+			}()
+		})
+	}
+
+	wg.Wait()
+
+	response := pageWakeResponse{
+		RenderedAt: now.UnixMilli(),
+		Widgets:    make([]widgetUpdateEvent, 0),
+	}
+
+	for _, pageWidget := range visible {
+		changed := false
+
+		widget.WalkWidgets(widget.Widgets{pageWidget}, func(nested widget.Widget) {
+			if stats, ok := widget.GetUpdateStats(nested); ok && stats.LastUpdateAt.After(sinceTime) {
+				changed = true
+			}
+		})
+
+		if changed {
+			response.Widgets = append(response.Widgets, widgetUpdateEvent{
+				ID:   pageWidget.GetID(),
+				HTML: renderWidgetWithPreviewStyle(pageWidget, previewStyles),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&response)
+}
+
 func (a *Application) HandleNotFound(w http.ResponseWriter, r *http.Request) {
 	// TODO: add proper not found page
 	w.WriteHeader(http.StatusNotFound)
@@ -252,42 +943,247 @@ func FileServerWithCache(fs http.FileSystem, cacheDuration time.Duration) http.H
 }
 
 func (a *Application) HandleWidgetRequest(w http.ResponseWriter, r *http.Request) {
-	widgetValue := r.PathValue("widget")
-
-	widgetID, err := strconv.ParseUint(widgetValue, 10, 64)
+	widget, exists := a.findWidget(r.PathValue("widget"))
 
-	if err != nil {
+	if !exists {
 		a.HandleNotFound(w, r)
 		return
 	}
 
-	widget, exists := a.widgetByID[widgetID]
+	widget.HandleRequest(w, r)
+}
+
+// Widgets can be referred to either by their ID or by their slug
+func (a *Application) findWidget(value string) (widget.Widget, bool) {
+	if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+		if w, exists := a.widgetByID[id]; exists {
+			return w, true
+		}
+	}
+
+	w, exists := a.widgetBySlug[value]
+
+	return w, exists
+}
+
+func (a *Application) registerWebhookReceivers(widgets widget.Widgets) error {
+	var err error
+
+	widget.WalkWidgets(widgets, func(w widget.Widget) {
+		token := widget.WebhookToken(w)
+
+		if token == "" || err != nil {
+			return
+		}
+
+		if _, exists := a.widgetByWebhookToken[token]; exists {
+			err = fmt.Errorf("webhook-token of %s widget is already used by another widget", w.GetType())
+			return
+		}
+
+		a.widgetByWebhookToken[token] = w
+	})
+
+	return err
+}
+
+// The token is what authenticates the request, so it isn't behind the login
+func (a *Application) HandleWebhookRequest(w http.ResponseWriter, r *http.Request) {
+	receiver, exists := a.widgetByWebhookToken[r.PathValue("token")]
 
 	if !exists {
 		a.HandleNotFound(w, r)
 		return
 	}
 
-	widget.HandleRequest(w, r)
+	widget.HandleWebhook(receiver, w, r)
+}
+
+func (a *Application) HandleServerStatsRequest(w http.ResponseWriter, r *http.Request) {
+	stats, err := feed.FetchLocalSystemStats()
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func (a *Application) HandleDiskHealthRequest(w http.ResponseWriter, r *http.Request) {
+	disks, err := feed.FetchLocalDiskHealth(r.Context())
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(disks)
+}
+
+func (a *Application) HandleStoragePoolsRequest(w http.ResponseWriter, r *http.Request) {
+	pools, err := feed.FetchLocalStoragePools(r.Context())
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pools)
+}
+
+func (a *Application) HandleFail2banRequest(w http.ResponseWriter, r *http.Request) {
+	bans, err := feed.FetchLocalFail2banBans(r.Context())
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bans)
+}
+
+func (a *Application) HandleWireGuardRequest(w http.ResponseWriter, r *http.Request) {
+	peers, err := feed.FetchLocalWireGuardPeers(r.Context())
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(peers)
+}
+
+// Measures the latencies from the machine Glance is running on to the targets
+// given in the query, so that other instances can use it as a vantage point
+func (a *Application) HandleLatencyRequest(w http.ResponseWriter, r *http.Request) {
+	targets := r.URL.Query()["target"]
+
+	if len(targets) == 0 || len(targets) > feed.MaxLatencyTargets {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "between 1 and %d targets must be given", feed.MaxLatencyTargets)
+		return
+	}
+
+	for _, target := range targets {
+		if err := feed.ValidateLatencyTarget(target); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	results := feed.MeasureLatencies(r.Context(), targets, r.URL.Query().Get("traceroute") == "true")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// Captures a frame of the RTSP stream given in the query, so that cameras which can
+// only be reached from the network of this instance can be shown by other instances
+func (a *Application) HandleCameraSnapshotRequest(w http.ResponseWriter, r *http.Request) {
+	streamURL := r.URL.Query().Get("url")
+
+	if !feed.IsRTSPURL(streamURL) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("url must be an rtsp or rtsps stream"))
+		return
+	}
+
+	snapshot, err := feed.CaptureRTSPSnapshot(r.Context(), streamURL)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", snapshot.ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(snapshot.Image)
 }
 
 func (a *Application) AssetPath(asset string) string {
 	return a.Config.Server.BaseURL + "/static/" + a.Config.Server.AssetsHash + "/" + asset
 }
 
-func (a *Application) Serve() error {
+// Builds the routes of the application, which get replaced
+// along with the application when the config gets reloaded
+func (a *Application) newHandler() (http.Handler, error) {
 	// TODO: add gzip support, static files must have their gzipped contents cached
-	// TODO: add HTTPS support
-	mux := http.NewServeMux()
+	mux := newAPIMux()
+
+	protect := a.authMiddleware
+
+	mux.Handle("GET /{$}", protect(http.HandlerFunc(a.HandlePageRequest)))
+	mux.Handle("GET /{page}", protect(http.HandlerFunc(a.HandlePageRequest)))
+
+	mux.document("GET /api/pages/{page}/content/{$}", "Renders the widgets of the page", apiSecuritySession, protect(http.HandlerFunc(a.HandlePageContentRequest)))
+	mux.document("GET /api/pages/{page}/updates", "Streams the widgets of the page as they get updated", apiSecuritySession, protect(http.HandlerFunc(a.HandlePageUpdatesRequest)))
+	mux.document("POST /api/pages/{page}/wake", "Updates the outdated widgets of the page", apiSecuritySession, protect(http.HandlerFunc(a.HandlePageWakeRequest)))
+	mux.document("GET /api/pages/{page}/widgets/{widget}", "Renders a single widget of the page, which can be referred to by its ID or id property", apiSecuritySession, protect(http.HandlerFunc(a.HandleWidgetContentRequest)))
+	mux.Handle("/api/widgets/{widget}/{path...}", protect(http.HandlerFunc(a.HandleWidgetRequest)))
+
+	if a.usesVisitorLocation() {
+		mux.document("POST /api/location", "Sets the location used by widgets with visitor-location for the visitor", apiSecuritySession, protect(http.HandlerFunc(a.HandleSetLocationRequest)))
+		mux.document("DELETE /api/location", "Goes back to the approximate location of the visitor", apiSecuritySession, protect(http.HandlerFunc(a.HandleResetLocationRequest)))
+	}
+
+	if len(a.widgetByWebhookToken) > 0 {
+		mux.document("POST /api/webhooks/{token}", "Sends data to the widget with the token", apiSecurityNone, http.HandlerFunc(a.HandleWebhookRequest))
+	}
+
+	if a.Config.Auth.usesPasswords() {
+		mux.HandleFunc("GET /login", a.HandleLoginPageRequest)
+		mux.HandleFunc("POST /login", a.HandleLoginRequest)
+		mux.HandleFunc("GET /logout", a.HandleLogoutRequest)
+	}
+	if a.Config.Server.StatsAPIToken != "" {
+		protectStats := func(handler http.HandlerFunc) http.Handler {
+			return requireBearerToken(a.Config.Server.StatsAPIToken.String(), handler)
+		}
 
-	mux.HandleFunc("GET /{$}", a.HandlePageRequest)
-	mux.HandleFunc("GET /{page}", a.HandlePageRequest)
+		mux.document("GET /api/server-stats", "Stats of the machine Glance is running on", apiSecurityToken, protectStats(a.HandleServerStatsRequest))
+		mux.document("GET /api/disk-health", "SMART health of the disks of the machine Glance is running on", apiSecurityToken, protectStats(a.HandleDiskHealthRequest))
+		mux.document("GET /api/storage-pools", "ZFS pools and RAID arrays of the machine Glance is running on", apiSecurityToken, protectStats(a.HandleStoragePoolsRequest))
+		mux.document("GET /api/fail2ban", "Addresses banned by the Fail2ban of the machine Glance is running on", apiSecurityToken, protectStats(a.HandleFail2banRequest))
+		mux.document("GET /api/wireguard", "Peers of the WireGuard interfaces of the machine Glance is running on", apiSecurityToken, protectStats(a.HandleWireGuardRequest))
+		mux.document("GET /api/latency", "Latencies from the machine Glance is running on to the targets given in the query", apiSecurityToken, protectStats(a.HandleLatencyRequest))
+		mux.document("GET /api/camera-snapshot", "A frame of the RTSP stream given in the query, captured by the machine Glance is running on", apiSecurityToken, protectStats(a.HandleCameraSnapshotRequest))
+	}
+
+	if a.Config.Server.FederationToken != "" {
+		mux.document("GET /api/federation/pages/{page}", "The rendered widgets of the page, as shown by the remote-page widget of another instance", apiSecurityToken, requireBearerToken(a.Config.Server.FederationToken.String(), http.HandlerFunc(a.HandleFederationPageRequest)))
+	}
+
+	if a.Config.Server.MetricsToken != "" {
+		mux.document("GET /metrics", "Metrics in the Prometheus format", apiSecurityToken, requireBearerToken(a.Config.Server.MetricsToken.String(), http.HandlerFunc(a.HandleMetricsRequest)))
+	}
+
+	if a.Config.Server.AdminToken != "" {
+		a.registerAdminRoutes(mux)
+	}
 
-	mux.HandleFunc("GET /api/pages/{page}/content/{$}", a.HandlePageContentRequest)
-	mux.HandleFunc("/api/widgets/{widget}/{path...}", a.HandleWidgetRequest)
-	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, _ *http.Request) {
+	if a.Config.Server.DiagnosticsToken != "" {
+		registerDiagnosticsRoutes(mux.ServeMux, a.diagnosticsMiddleware)
+	}
+
+	mux.document("GET /api/healthz", "Responds with 200 while the server is running", apiSecurityNone, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
-	})
+	}))
+	mux.document("GET /healthz", "The health of the server, along with the widgets that are failing to update for those with access to it", apiSecurityNone, http.HandlerFunc(a.HandleHealthRequest))
+	mux.document("GET /api/status", "The version of Glance and whether an update is available", apiSecurityNone, http.HandlerFunc(a.HandleStatusRequest))
+	mux.document("GET /api/openapi.json", "This document", apiSecurityNone, a.handleOpenAPIRequest(mux))
 
 	mux.Handle(
 		fmt.Sprintf("GET /static/%s/{path...}", a.Config.Server.AssetsHash),
@@ -298,17 +1194,47 @@ func (a *Application) Serve() error {
 		absAssetsPath, err := filepath.Abs(a.Config.Server.AssetsPath)
 
 		if err != nil {
-			return fmt.Errorf("invalid assets path: %s", a.Config.Server.AssetsPath)
+			return nil, fmt.Errorf("invalid assets path: %s", a.Config.Server.AssetsPath)
 		}
 
 		slog.Info("Serving assets", "path", absAssetsPath)
 		assetsFS := FileServerWithCache(http.Dir(a.Config.Server.AssetsPath), 2*time.Hour)
-		mux.Handle("/assets/{path...}", http.StripPrefix("/assets/", assetsFS))
+		mux.Handle("/assets/{path...}", protect(http.StripPrefix("/assets/", assetsFS)))
 	}
 
+	return mux, nil
+}
+
+func (a *Application) Serve() error {
+	// TODO: add HTTPS support
+	handler, err := a.newHandler()
+
+	if err != nil {
+		return err
+	}
+
+	a.handler = handler
+	a.live = &liveApplication{}
+	a.live.app.Store(a)
+
 	server := http.Server{
-		Addr:    fmt.Sprintf("%s:%d", a.Config.Server.Host, a.Config.Server.Port),
-		Handler: mux,
+		Addr: fmt.Sprintf("%s:%d", a.Config.Server.Host, a.Config.Server.Port),
+		// requests always go to the latest application in case the config was reloaded
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a.live.app.Load().handler.ServeHTTP(w, r)
+		}),
+	}
+
+	if a.Config.Server.DataPath != "" {
+		go a.history.SaveEvery(time.Minute)
+	}
+
+	if a.updates != nil {
+		go a.updates.run()
+	}
+
+	if a.Config.Server.DiagnosticsAddress != "" {
+		go serveDiagnostics(a.Config.Server.DiagnosticsAddress)
 	}
 
 	a.Config.Server.StartedAt = time.Now()
@@ -0,0 +1,309 @@
+package glance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type importedLink struct {
+	Title string `yaml:"title"`
+	URL   string `yaml:"url"`
+	Icon  string `yaml:"icon,omitempty"`
+}
+
+type importedBookmarkGroup struct {
+	Title string         `yaml:"title,omitempty"`
+	Links []importedLink `yaml:"links"`
+}
+
+type importedSite struct {
+	Title    string `yaml:"title"`
+	URL      string `yaml:"url"`
+	CheckURL string `yaml:"check-url,omitempty"`
+	Icon     string `yaml:"icon,omitempty"`
+}
+
+type importedWidget struct {
+	Type   string                  `yaml:"type"`
+	Groups []importedBookmarkGroup `yaml:"groups,omitempty"`
+	Sites  []importedSite          `yaml:"sites,omitempty"`
+}
+
+type importResult struct {
+	groups []importedBookmarkGroup
+	sites  []importedSite
+}
+
+// Converts the services and bookmarks from another dashboard's config into
+// a list of bookmarks and monitor widgets that can be pasted into a column
+func ImportConfig(from string, contents io.Reader) ([]byte, error) {
+	contentBytes, err := io.ReadAll(contents)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result *importResult
+
+	switch from {
+	case "homepage":
+		result, err = importFromHomepage(contentBytes)
+	case "dashy":
+		result, err = importFromDashy(contentBytes)
+	case "heimdall":
+		result, err = importFromHeimdall(contentBytes)
+	default:
+		return nil, fmt.Errorf("unsupported import source: %s", from)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	widgets := make([]importedWidget, 0, 2)
+
+	if len(result.groups) > 0 {
+		widgets = append(widgets, importedWidget{Type: "bookmarks", Groups: result.groups})
+	}
+
+	if len(result.sites) > 0 {
+		widgets = append(widgets, importedWidget{Type: "monitor", Sites: result.sites})
+	}
+
+	if len(widgets) == 0 {
+		return nil, fmt.Errorf("no services or bookmarks found in %s config", from)
+	}
+
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(widgets); err != nil {
+		return nil, err
+	}
+
+	return output.Bytes(), nil
+}
+
+// Homepage uses both simple-icons (si-) and material design icons (mdi-), the
+// latter of which aren't supported and get dropped. Plain names refer to the
+// dashboard-icons project which is the same one used by the di: prefix
+func convertHomepageIcon(icon string) string {
+	if icon == "" || strings.HasPrefix(icon, "mdi-") {
+		return ""
+	}
+
+	if strings.HasPrefix(icon, "http://") || strings.HasPrefix(icon, "https://") || strings.HasPrefix(icon, "/") {
+		return icon
+	}
+
+	if name, found := strings.CutPrefix(icon, "si-"); found {
+		return "si:" + name
+	}
+
+	return "di:" + icon
+}
+
+type homepageService struct {
+	Href        string `yaml:"href"`
+	Icon        string `yaml:"icon"`
+	SiteMonitor string `yaml:"siteMonitor"`
+}
+
+type homepageBookmark struct {
+	Href string `yaml:"href"`
+	Icon string `yaml:"icon"`
+}
+
+// Accepts both services.yaml and bookmarks.yaml. Both are lists of single-key
+// maps where the key is the group name, the difference being that services
+// map to an object while bookmarks map to a list of objects.
+func importFromHomepage(contents []byte) (*importResult, error) {
+	var groups []map[string][]map[string]yaml.Node
+
+	if err := yaml.Unmarshal(contents, &groups); err != nil {
+		return nil, fmt.Errorf("could not parse homepage config: %v", err)
+	}
+
+	result := &importResult{}
+
+	for _, group := range groups {
+		for groupName, entries := range group {
+			bookmarkGroup := importedBookmarkGroup{Title: groupName}
+
+			for _, entry := range entries {
+				for name, node := range entry {
+					var href, icon string
+
+					if node.Kind == yaml.SequenceNode {
+						var bookmarks []homepageBookmark
+
+						if err := node.Decode(&bookmarks); err != nil || len(bookmarks) == 0 {
+							continue
+						}
+
+						href, icon = bookmarks[0].Href, bookmarks[0].Icon
+					} else {
+						var service homepageService
+
+						if err := node.Decode(&service); err != nil {
+							continue
+						}
+
+						href, icon = service.Href, service.Icon
+
+						if service.SiteMonitor != "" {
+							site := importedSite{
+								Title: name,
+								URL:   service.SiteMonitor,
+								Icon:  convertHomepageIcon(service.Icon),
+							}
+
+							if href != "" {
+								site.URL = href
+								site.CheckURL = service.SiteMonitor
+							}
+
+							result.sites = append(result.sites, site)
+						}
+					}
+
+					if href == "" {
+						continue
+					}
+
+					bookmarkGroup.Links = append(bookmarkGroup.Links, importedLink{
+						Title: name,
+						URL:   href,
+						Icon:  convertHomepageIcon(icon),
+					})
+				}
+			}
+
+			if len(bookmarkGroup.Links) > 0 {
+				result.groups = append(result.groups, bookmarkGroup)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Dashy icons prefixed with hl- come from the same dashboard-icons project,
+// font awesome and favicon icons have no equivalent so they get dropped
+func convertDashyIcon(icon string) string {
+	if strings.HasPrefix(icon, "http://") || strings.HasPrefix(icon, "https://") {
+		return icon
+	}
+
+	if name, found := strings.CutPrefix(icon, "si-"); found {
+		return "si:" + name
+	}
+
+	if name, found := strings.CutPrefix(icon, "hl-"); found {
+		return "di:" + name
+	}
+
+	return ""
+}
+
+type dashyConfig struct {
+	Sections []struct {
+		Name  string `yaml:"name"`
+		Items []struct {
+			Title          string `yaml:"title"`
+			URL            string `yaml:"url"`
+			Icon           string `yaml:"icon"`
+			StatusCheck    bool   `yaml:"statusCheck"`
+			StatusCheckURL string `yaml:"statusCheckUrl"`
+		} `yaml:"items"`
+	} `yaml:"sections"`
+}
+
+func importFromDashy(contents []byte) (*importResult, error) {
+	var config dashyConfig
+
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("could not parse dashy config: %v", err)
+	}
+
+	result := &importResult{}
+
+	for _, section := range config.Sections {
+		group := importedBookmarkGroup{Title: section.Name}
+
+		for _, item := range section.Items {
+			if item.URL == "" {
+				continue
+			}
+
+			icon := convertDashyIcon(item.Icon)
+
+			group.Links = append(group.Links, importedLink{
+				Title: item.Title,
+				URL:   item.URL,
+				Icon:  icon,
+			})
+
+			if item.StatusCheck {
+				result.sites = append(result.sites, importedSite{
+					Title:    item.Title,
+					URL:      item.URL,
+					CheckURL: item.StatusCheckURL,
+					Icon:     icon,
+				})
+			}
+		}
+
+		if len(group.Links) > 0 {
+			result.groups = append(result.groups, group)
+		}
+	}
+
+	return result, nil
+}
+
+type heimdallItem struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Icon  string `json:"icon"`
+}
+
+// Heimdall's export is a flat JSON list of items with no grouping, icons are
+// paths to its own storage so only absolute URLs are kept
+func importFromHeimdall(contents []byte) (*importResult, error) {
+	var items []heimdallItem
+
+	if err := json.Unmarshal(contents, &items); err != nil {
+		return nil, fmt.Errorf("could not parse heimdall export: %v", err)
+	}
+
+	group := importedBookmarkGroup{}
+
+	for _, item := range items {
+		if item.URL == "" {
+			continue
+		}
+
+		link := importedLink{Title: item.Title, URL: item.URL}
+
+		if strings.HasPrefix(item.Icon, "http://") || strings.HasPrefix(item.Icon, "https://") {
+			link.Icon = item.Icon
+		}
+
+		group.Links = append(group.Links, link)
+	}
+
+	result := &importResult{}
+
+	if len(group.Links) > 0 {
+		result.groups = append(result.groups, group)
+	}
+
+	return result, nil
+}
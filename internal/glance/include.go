@@ -0,0 +1,229 @@
+package glance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/glanceapp/glance/internal/widget"
+
+	"gopkg.in/yaml.v3"
+)
+
+const includeDirective = "$include"
+
+// Replaces every `$include: path` in the config with the contents of the files it points to.
+// When used as an item of a list, such as the pages or the widgets of a column, and the file
+// contains a list itself, its items get added to that list. Paths are relative to the file
+// they're in and can be glob patterns, which makes it possible to include a whole directory
+type configIncluder struct {
+	// the file that each node came from, used to point to the right file in errors
+	files map[*yaml.Node]string
+	// the files currently being included, used to detect files that include themselves
+	including []string
+}
+
+func newConfigIncluder() *configIncluder {
+	return &configIncluder{files: make(map[*yaml.Node]string)}
+}
+
+func includePath(node *yaml.Node) (*yaml.Node, bool) {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 || node.Content[0].Value != includeDirective {
+		return nil, false
+	}
+
+	return node.Content[1], true
+}
+
+func (c *configIncluder) expand(node *yaml.Node, dir string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := c.expand(child, dir); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		content := make([]*yaml.Node, 0, len(node.Content))
+
+		for _, item := range node.Content {
+			pathNode, isInclude := includePath(item)
+
+			if !isInclude {
+				if err := c.expand(item, dir); err != nil {
+					return err
+				}
+
+				content = append(content, item)
+				continue
+			}
+
+			included, err := c.load(pathNode, dir)
+
+			if err != nil {
+				return err
+			}
+
+			for _, root := range included {
+				if root.Kind == yaml.SequenceNode {
+					content = append(content, root.Content...)
+				} else {
+					content = append(content, root)
+				}
+			}
+		}
+
+		node.Content = content
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			value := node.Content[i]
+			pathNode, isInclude := includePath(value)
+
+			if !isInclude {
+				if err := c.expand(value, dir); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			included, err := c.load(pathNode, dir)
+
+			if err != nil {
+				return err
+			}
+
+			if len(included) != 1 {
+				return fmt.Errorf("line %d: %s of %s must match exactly one file, matched %d", pathNode.Line, includeDirective, node.Content[i-1].Value, len(included))
+			}
+
+			node.Content[i] = included[0]
+		}
+	}
+
+	return nil
+}
+
+// Returns the root node of each of the files that the path matches, with their own includes expanded
+func (c *configIncluder) load(pathNode *yaml.Node, dir string) ([]*yaml.Node, error) {
+	// the same as any other value that can come from the environment
+	var pattern widget.OptionalEnvString
+
+	if err := pathNode.Decode(&pattern); err != nil {
+		return nil, fmt.Errorf("line %d: %s: %v", pathNode.Line, includeDirective, err)
+	}
+
+	if pattern == "" {
+		return nil, fmt.Errorf("line %d: %s requires a path", pathNode.Line, includeDirective)
+	}
+
+	fullPattern := pattern.String()
+
+	if !filepath.IsAbs(fullPattern) {
+		fullPattern = filepath.Join(dir, fullPattern)
+	}
+
+	paths, err := filepath.Glob(fullPattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid %s pattern '%s': %v", pathNode.Line, includeDirective, pattern, err)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("line %d: no files match %s '%s'", pathNode.Line, includeDirective, pattern)
+	}
+
+	// glob already sorts them, this makes it explicit since the order of pages depends on it
+	sort.Strings(paths)
+	roots := make([]*yaml.Node, 0, len(paths))
+
+	for _, path := range paths {
+		root, err := c.loadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if root != nil {
+			roots = append(roots, root)
+		}
+	}
+
+	return roots, nil
+}
+
+func (c *configIncluder) loadFile(path string) (*yaml.Node, error) {
+	absPath, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(c.including, absPath) {
+		return nil, fmt.Errorf("%s includes itself: %s", path, strings.Join(append(c.including, absPath), " -> "))
+	}
+
+	contents, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not include file: %v", err)
+	}
+
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	// empty files don't add anything
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	c.including = append(c.including, absPath)
+	err = c.expand(&document, filepath.Dir(path))
+	c.including = c.including[:len(c.including)-1]
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	root := document.Content[0]
+	c.markFile(root, path)
+
+	return root, nil
+}
+
+// Nodes that already have a file came from a file included by this one
+func (c *configIncluder) markFile(node *yaml.Node, path string) {
+	if _, exists := c.files[node]; exists {
+		return
+	}
+
+	c.files[node] = path
+
+	for _, child := range node.Content {
+		c.markFile(child, path)
+	}
+}
+
+// Parses the config and expands its includes, dir is the directory that the paths
+// of the includes in the config are relative to
+func parseConfigNode(contents []byte, dir string) (*yaml.Node, *configIncluder, error) {
+	var document yaml.Node
+
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, nil, err
+	}
+
+	includer := newConfigIncluder()
+
+	if err := includer.expand(&document, dir); err != nil {
+		return nil, nil, err
+	}
+
+	return &document, includer, nil
+}
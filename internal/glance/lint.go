@@ -0,0 +1,491 @@
+package glance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/widget"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configProblemError   = "error"
+	configProblemWarning = "warning"
+)
+
+// A problem with the config as reported by the validate and lint commands
+type configProblem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+func (p *configProblem) location() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Matches the errors of the YAML parser and the ones returned by
+// widgets and fields which point to a line, and sometimes a column, of the config file
+var errorLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+)(?:, column (\d+))?: (.*)$`)
+
+func newConfigProblemFromError(err error, configPath string) configProblem {
+	problem := configProblem{
+		File:     configPath,
+		Severity: configProblemError,
+		Rule:     "invalid",
+		Message:  err.Error(),
+	}
+
+	var lineErr *configLineError
+
+	if errors.As(err, &lineErr) {
+		if lineErr.File != "" {
+			problem.File = lineErr.File
+		}
+
+		problem.Line, problem.Message = lineErr.Line, lineErr.Message
+	} else if matches := errorLinePattern.FindStringSubmatch(err.Error()); matches != nil {
+		problem.Line, _ = strconv.Atoi(matches[1])
+		problem.Column, _ = strconv.Atoi(matches[2])
+		problem.Message = matches[3]
+	}
+
+	return problem
+}
+
+type rateLimitedWidget struct {
+	minCache time.Duration
+	// the limit doesn't apply when this field is set, such as a token
+	exemptField string
+	reason      string
+}
+
+// Widgets whose APIs block or throttle clients which make requests too often
+var rateLimitedWidgets = map[string]rateLimitedWidget{
+	"reddit":             {10 * time.Minute, "", "Reddit blocks clients which make requests too often"},
+	"markets":            {5 * time.Minute, "", "Yahoo Finance blocks clients which make requests too often"},
+	"releases":           {30 * time.Minute, "token", "GitHub only allows 60 requests per hour without a token"},
+	"repository":         {30 * time.Minute, "token", "GitHub only allows 60 requests per hour without a token"},
+	"dependency-updates": {30 * time.Minute, "token", "GitHub only allows 60 requests per hour without a token"},
+}
+
+const (
+	lintMaxLimit   = 100
+	lintMaxSources = 50
+)
+
+var (
+	// Fields whose value is something that gets requested
+	lintSourceFields = []string{"url", "check-url", "subreddit", "repository"}
+	// Lists of things that all get requested every time the widget updates
+	lintSourceLists = []string{"sites", "feeds", "repositories", "channels", "markets", "queries"}
+	// Fields with addresses that don't get requested by the widget, along with the nested widgets of groups
+	lintSkippedFields = []string{"widgets", "links", "header-actions", "title-url", "icon"}
+	// Widgets with addresses that only get linked to or embedded
+	lintNonFetchingWidgets = []string{"bookmarks", "html", "iframe", "search"}
+)
+
+// Looks for things in the config which are valid but most likely not what was intended,
+// along with the problems reported by validating it
+type configLinter struct {
+	configPath string
+	files      map[*yaml.Node]string
+	problems   []configProblem
+	// the first node with each source, keyed by the widget type, the field and its value
+	sources map[string]*yaml.Node
+	icons   map[string][]*yaml.Node
+}
+
+func lintConfig(contents []byte, dir string, configPath string, offline bool) []configProblem {
+	linter := &configLinter{
+		configPath: configPath,
+		problems:   make([]configProblem, 0),
+		sources:    make(map[string]*yaml.Node),
+		icons:      make(map[string][]*yaml.Node),
+	}
+
+	for _, err := range validateConfig(contents, dir) {
+		linter.problems = append(linter.problems, newConfigProblemFromError(err, configPath))
+	}
+
+	document, includer, err := parseConfigNode(contents, dir)
+
+	// already reported by the validation
+	if err != nil {
+		return linter.problems
+	}
+
+	linter.files = includer.files
+	linter.walkValues(document)
+	linter.findWidgets(document)
+
+	if !offline {
+		linter.checkIcons()
+	}
+
+	slices.SortStableFunc(linter.problems, func(a, b configProblem) int {
+		if order := strings.Compare(a.File, b.File); order != 0 {
+			return order
+		}
+
+		return a.Line - b.Line
+	})
+
+	return linter.problems
+}
+
+func (l *configLinter) file(node *yaml.Node) string {
+	if file, exists := l.files[node]; exists {
+		return file
+	}
+
+	return l.configPath
+}
+
+func (l *configLinter) warn(node *yaml.Node, rule string, format string, args ...any) {
+	l.problems = append(l.problems, configProblem{
+		File:     l.file(node),
+		Line:     node.Line,
+		Severity: configProblemWarning,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *configLinter) hasProblemAt(node *yaml.Node) bool {
+	file := l.file(node)
+
+	return slices.ContainsFunc(l.problems, func(problem configProblem) bool {
+		return problem.File == file && problem.Line == node.Line
+	})
+}
+
+// Finds the environment variables and secret files which are missing, all of them
+// rather than only the first one like loading the config does, as well as the icons
+func (l *configLinter) walkValues(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		l.findMissingEnvVariables(node)
+		return
+	}
+
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if key.Value == "icon" && value.Kind == yaml.ScalarNode {
+				var icon widget.CustomIcon
+
+				if value.Decode(&icon) == nil && (strings.HasPrefix(icon.URL, "https://") || strings.HasPrefix(icon.URL, "http://")) {
+					l.icons[icon.URL] = append(l.icons[icon.URL], value)
+				}
+			}
+		}
+	}
+
+	for _, child := range node.Content {
+		l.walkValues(child)
+	}
+}
+
+func (l *configLinter) findMissingEnvVariables(node *yaml.Node) {
+	// the first one that's missing is already reported by the validation
+	if l.hasProblemAt(node) {
+		return
+	}
+
+	for _, match := range widget.EnvFieldPattern.FindAllStringSubmatch(node.Value, -1) {
+		prefix, key := match[1], match[2]
+
+		if prefix == `\` {
+			continue
+		}
+
+		if path, isFile := strings.CutPrefix(key, "file:"); isFile {
+			if _, err := os.Stat(path); err != nil {
+				l.warn(node, "missing-env", "secret file %s can't be read: %v", path, err)
+			}
+
+			continue
+		}
+
+		if _, found := os.LookupEnv(key); !found {
+			l.warn(node, "missing-env", "environment variable %s is not set", key)
+		}
+	}
+}
+
+func (l *configLinter) findWidgets(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if key.Value == "widgets" && value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					if item.Kind == yaml.MappingNode {
+						l.lintWidget(item)
+					}
+				}
+			}
+
+			l.findWidgets(value)
+		}
+
+		return
+	}
+
+	for _, child := range node.Content {
+		l.findWidgets(child)
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func (l *configLinter) lintWidget(node *yaml.Node) {
+	typeNode := mappingValue(node, "type")
+
+	if typeNode == nil {
+		return
+	}
+
+	widgetType := typeNode.Value
+	l.checkCache(node, widgetType)
+	l.checkLimits(node, widgetType)
+
+	if !slices.Contains(lintNonFetchingWidgets, widgetType) {
+		l.findDuplicateSources(node, widgetType)
+	}
+}
+
+func (l *configLinter) checkCache(node *yaml.Node, widgetType string) {
+	limited, exists := rateLimitedWidgets[widgetType]
+	cacheNode := mappingValue(node, "cache")
+
+	if !exists || cacheNode == nil {
+		return
+	}
+
+	if limited.exemptField != "" && mappingValue(node, limited.exemptField) != nil {
+		return
+	}
+
+	var cache widget.DurationField
+
+	// invalid durations are reported by the validation
+	if cacheNode.Decode(&cache) != nil {
+		return
+	}
+
+	if time.Duration(cache) < limited.minCache {
+		l.warn(
+			cacheNode, "aggressive-cache",
+			"cache of %s widget is %s, %s, consider at least %s",
+			widgetType, cacheNode.Value, limited.reason, formatLintDuration(limited.minCache),
+		)
+	}
+}
+
+func formatLintDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
+
+func (l *configLinter) checkLimits(node *yaml.Node, widgetType string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.Value == "limit" || strings.HasSuffix(key.Value, "-limit") {
+			if limit, err := strconv.Atoi(value.Value); err == nil && limit > lintMaxLimit {
+				l.warn(
+					value, "large-limit",
+					"%s of %s widget is %d, more than %d items make the page slow to load and hard to read",
+					key.Value, widgetType, limit, lintMaxLimit,
+				)
+			}
+		}
+
+		if slices.Contains(lintSourceLists, key.Value) && value.Kind == yaml.SequenceNode && len(value.Content) > lintMaxSources {
+			l.warn(
+				key, "too-many-sources",
+				"%s widget has %d %s, which all get requested every time it updates, consider splitting it into several widgets",
+				widgetType, len(value.Content), key.Value,
+			)
+		}
+	}
+}
+
+func (l *configLinter) findDuplicateSources(node *yaml.Node, widgetType string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if slices.Contains(lintSkippedFields, key.Value) {
+				continue
+			}
+
+			if value.Kind == yaml.ScalarNode && slices.Contains(lintSourceFields, key.Value) {
+				l.checkSource(value, widgetType, key.Value)
+			} else if value.Kind == yaml.SequenceNode && key.Value == "repositories" {
+				for _, item := range value.Content {
+					if item.Kind == yaml.ScalarNode {
+						l.checkSource(item, widgetType, "repository")
+					} else {
+						l.findDuplicateSources(item, widgetType)
+					}
+				}
+			} else {
+				l.findDuplicateSources(value, widgetType)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			l.findDuplicateSources(child, widgetType)
+		}
+	}
+}
+
+func (l *configLinter) checkSource(node *yaml.Node, widgetType string, field string) {
+	if node.Value == "" {
+		return
+	}
+
+	key := widgetType + " " + field + " " + node.Value
+	first, exists := l.sources[key]
+
+	if !exists {
+		l.sources[key] = node
+		return
+	}
+
+	l.warn(
+		node, "duplicate-source",
+		"%s %s is also requested by the %s widget at %s:%d, widgets don't share what they fetch so it gets requested twice",
+		field, node.Value, widgetType, l.file(first), first.Line,
+	)
+}
+
+const lintIconTimeout = 10 * time.Second
+
+func (l *configLinter) checkIcons() {
+	urls := make([]string, 0, len(l.icons))
+
+	for url := range l.icons {
+		urls = append(urls, url)
+	}
+
+	// keeps the order of the warnings the same between runs
+	slices.Sort(urls)
+
+	client := &http.Client{Timeout: lintIconTimeout}
+	errs := make([]error, len(urls))
+	slots := make(chan struct{}, 10)
+	var wg sync.WaitGroup
+
+	for i, url := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			errs[i] = checkIconURL(client, url)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, url := range urls {
+		if errs[i] == nil {
+			continue
+		}
+
+		for _, node := range l.icons[url] {
+			l.warn(node, "unreachable-icon", "icon %s can't be loaded: %v", url, errs[i])
+		}
+	}
+}
+
+func checkIconURL(client *http.Client, url string) error {
+	response, err := client.Head(url)
+
+	// not every server supports HEAD requests
+	if err == nil && (response.StatusCode == http.StatusMethodNotAllowed || response.StatusCode == http.StatusNotImplemented) {
+		response.Body.Close()
+		response, err = client.Get(url)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("server responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func runLint(options *CliOptions) int {
+	contents, err := os.ReadFile(options.ConfigPath)
+
+	if err != nil {
+		fmt.Printf("failed reading config file: %v\n", err)
+		return 1
+	}
+
+	problems := lintConfig(contents, filepath.Dir(options.ConfigPath), options.ConfigPath, options.LintOffline)
+
+	if options.OutputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(problems)
+	} else if len(problems) == 0 {
+		fmt.Printf("%s: no problems found\n", options.ConfigPath)
+	} else {
+		for i := range problems {
+			problem := &problems[i]
+			fmt.Printf("%s: %s: %s (%s)\n", problem.location(), problem.Severity, problem.Message, problem.Rule)
+		}
+	}
+
+	if len(problems) > 0 {
+		return 1
+	}
+
+	return 0
+}
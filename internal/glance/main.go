@@ -3,6 +3,7 @@ package glance
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 func Main() int {
@@ -13,15 +14,23 @@ func Main() int {
 		return 1
 	}
 
-	configFile, err := os.Open(options.ConfigPath)
+	if options.Intent == CliIntentImport {
+		return runImport(options)
+	}
 
-	if err != nil {
-		fmt.Printf("failed opening config file: %v\n", err)
-		return 1
+	if options.Intent == CliIntentValidate {
+		return runValidate(options)
 	}
 
-	config, err := NewConfigFromYml(configFile)
-	configFile.Close()
+	if options.Intent == CliIntentLint {
+		return runLint(options)
+	}
+
+	if options.Intent == CliIntentBench {
+		return runBench(options)
+	}
+
+	config, err := NewConfigFromFile(options.ConfigPath)
 
 	if err != nil {
 		fmt.Printf("failed parsing config file: %v\n", err)
@@ -36,6 +45,8 @@ func Main() int {
 			return 1
 		}
 
+		app.configPath = options.ConfigPath
+
 		if err := app.Serve(); err != nil {
 			fmt.Printf("http server error: %v\n", err)
 			return 1
@@ -44,3 +55,47 @@ func Main() int {
 
 	return 0
 }
+
+func runValidate(options *CliOptions) int {
+	contents, err := os.ReadFile(options.ConfigPath)
+
+	if err != nil {
+		fmt.Printf("failed reading config file: %v\n", err)
+		return 1
+	}
+
+	errs := validateConfig(contents, filepath.Dir(options.ConfigPath))
+
+	if len(errs) == 0 {
+		fmt.Printf("%s: config is valid\n", options.ConfigPath)
+		return 0
+	}
+
+	for _, err := range errs {
+		problem := newConfigProblemFromError(err, options.ConfigPath)
+		fmt.Printf("%s: %s\n", problem.location(), problem.Message)
+	}
+
+	return 1
+}
+
+func runImport(options *CliOptions) int {
+	importFile, err := os.Open(options.ImportPath)
+
+	if err != nil {
+		fmt.Printf("failed opening file to import: %v\n", err)
+		return 1
+	}
+
+	output, err := ImportConfig(options.ImportFrom, importFile)
+	importFile.Close()
+
+	if err != nil {
+		fmt.Printf("failed importing config: %v\n", err)
+		return 1
+	}
+
+	os.Stdout.Write(output)
+
+	return 0
+}
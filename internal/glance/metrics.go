@@ -0,0 +1,249 @@
+package glance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+const defaultMaxFailingDuration = time.Hour
+
+type pageWidgetStats struct {
+	page   *Page
+	widget widget.Widget
+	stats  widget.UpdateStats
+}
+
+func (a *Application) collectWidgetStats() []pageWidgetStats {
+	collected := make([]pageWidgetStats, 0, len(a.widgetByID))
+
+	for p := range a.Config.Pages {
+		page := &a.Config.Pages[p]
+
+		for c := range page.Columns {
+			widget.WalkWidgets(page.Columns[c].Widgets, func(w widget.Widget) {
+				if stats, ok := widget.GetUpdateStats(w); ok {
+					collected = append(collected, pageWidgetStats{page: page, widget: w, stats: stats})
+				}
+			})
+		}
+	}
+
+	return collected
+}
+
+var prometheusLabelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Writes the metrics in the Prometheus text format, which is simple
+// enough not to warrant pulling in the client library
+func (a *Application) HandleMetricsRequest(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	collected := a.collectWidgetStats()
+	now := time.Now()
+
+	labels := make([]string, len(collected))
+
+	for i := range collected {
+		labels[i] = fmt.Sprintf(
+			`{id="%s",type="%s",title="%s",page="%s"}`,
+			prometheusLabelValueReplacer.Replace(collected[i].widget.GetSlug()),
+			prometheusLabelValueReplacer.Replace(collected[i].widget.GetType()),
+			prometheusLabelValueReplacer.Replace(collected[i].widget.GetTitle()),
+			prometheusLabelValueReplacer.Replace(collected[i].page.Slug),
+		)
+	}
+
+	widgetMetric := func(name, metricType, help string, value func(*widget.UpdateStats) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+
+		for i := range collected {
+			fmt.Fprintf(&b, "%s%s %s\n", name, labels[i], value(&collected[i].stats))
+		}
+	}
+
+	formatUint := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	formatSeconds := func(d time.Duration) string { return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) }
+	formatTimestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return "0"
+		}
+
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	widgetMetric("glance_widget_updates_total", "counter", "Number of times the widget was updated.", func(s *widget.UpdateStats) string {
+		return formatUint(s.Updates)
+	})
+	widgetMetric("glance_widget_update_failures_total", "counter", "Number of updates of the widget that failed.", func(s *widget.UpdateStats) string {
+		return formatUint(s.Failures)
+	})
+	widgetMetric("glance_widget_consecutive_failures", "gauge", "Number of updates in a row that failed.", func(s *widget.UpdateStats) string {
+		return strconv.Itoa(s.ConsecutiveFailures)
+	})
+	widgetMetric("glance_widget_failing_seconds", "gauge", "How long the widget has been failing to update for, 0 if its last update succeeded.", func(s *widget.UpdateStats) string {
+		if s.FailingSince.IsZero() {
+			return "0"
+		}
+
+		return formatSeconds(now.Sub(s.FailingSince))
+	})
+	widgetMetric("glance_widget_last_success_timestamp_seconds", "gauge", "Unix time of the last successful update, 0 if there hasn't been one.", func(s *widget.UpdateStats) string {
+		return formatTimestamp(s.LastSuccessAt)
+	})
+	widgetMetric("glance_widget_last_update_duration_seconds", "gauge", "How long the last update took.", func(s *widget.UpdateStats) string {
+		return formatSeconds(s.LastUpdateDuration)
+	})
+	widgetMetric("glance_widget_update_duration_seconds_total", "counter", "Time spent updating the widget.", func(s *widget.UpdateStats) string {
+		return formatSeconds(s.UpdateDuration)
+	})
+	widgetMetric("glance_widget_cache_hits_total", "counter", "Number of times the cached data of the widget was used.", func(s *widget.UpdateStats) string {
+		return formatUint(s.CacheHits)
+	})
+	widgetMetric("glance_widget_cache_misses_total", "counter", "Number of times the widget had to be updated.", func(s *widget.UpdateStats) string {
+		return formatUint(s.CacheMisses)
+	})
+
+	requests := feed.GetRequestMetrics()
+
+	b.WriteString("# HELP glance_http_requests_total Number of HTTP requests made by widgets, by status code class.\n")
+	b.WriteString("# TYPE glance_http_requests_total counter\n")
+
+	for _, class := range []string{"1xx", "2xx", "3xx", "4xx", "5xx"} {
+		fmt.Fprintf(&b, "glance_http_requests_total{code=\"%s\"} %d\n", class, requests.Responses[class])
+	}
+
+	fmt.Fprintf(&b, "glance_http_requests_total{code=\"error\"} %d\n", requests.Failed)
+
+	for _, metric := range []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"glance_http_request_retries_total", "Number of HTTP requests that were retried.", requests.Retries},
+		{"glance_feed_tasks_total", "Number of tasks run by the worker pool used for fetching data.", requests.Tasks},
+		{"glance_feed_task_failures_total", "Number of tasks run by the worker pool that failed.", requests.FailedTasks},
+	} {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", metric.name, metric.help, metric.name, metric.name, metric.value)
+	}
+
+	b.WriteString("# HELP glance_start_timestamp_seconds Unix time of when the server was started.\n")
+	fmt.Fprintf(&b, "# TYPE glance_start_timestamp_seconds gauge\nglance_start_timestamp_seconds %d\n", a.Config.Server.StartedAt.Unix())
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+type healthResponse struct {
+	Status         string                `json:"status"`
+	Config         string                `json:"config"`
+	Version        string                `json:"version"`
+	StartedAt      time.Time             `json:"started-at"`
+	Widgets        int                   `json:"widgets"`
+	FailingWidgets []failingWidgetHealth `json:"failing-widgets"`
+}
+
+type failingWidgetHealth struct {
+	ID                  uint64     `json:"id"`
+	Slug                string     `json:"slug"`
+	Type                string     `json:"type"`
+	FailingSince        time.Time  `json:"failing-since"`
+	ConsecutiveFailures int        `json:"consecutive-failures"`
+	LastSuccessAt       *time.Time `json:"last-success-at"`
+}
+
+// When the server is protected by auth or a metrics token, the widgets that are failing
+// and the max-failing parameter are only for the ones who have access to it. Everyone
+// else only gets the status, since the failing widgets give away parts of the config
+func (a *Application) HandleHealthRequest(w http.ResponseWriter, r *http.Request) {
+	var detailed bool
+
+	if a.Config.Server.MetricsToken != "" {
+		detailed = hasBearerToken(r, a.Config.Server.MetricsToken.String())
+	} else {
+		detailed = !a.Config.Auth.Enabled() || a.Config.Auth.isRequestAuthenticated(r)
+	}
+
+	a.writeHealth(w, r, detailed)
+}
+
+func (a *Application) HandleAdminHealthRequest(w http.ResponseWriter, r *http.Request) {
+	a.writeHealth(w, r, true)
+}
+
+// Responds with 503 when any widget has been failing to update for longer than
+// the duration given through the max-failing parameter, which defaults to an hour.
+// The config is always reported as valid since the server doesn't start otherwise
+func (a *Application) writeHealth(w http.ResponseWriter, r *http.Request, detailed bool) {
+	maxFailing := defaultMaxFailingDuration
+
+	if value := r.URL.Query().Get("max-failing"); detailed && value != "" {
+		parsed, err := time.ParseDuration(value)
+
+		if err != nil || parsed <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid max-failing duration"))
+			return
+		}
+
+		maxFailing = parsed
+	}
+
+	collected := a.collectWidgetStats()
+	now := time.Now()
+
+	response := healthResponse{
+		Status:         "ok",
+		Config:         "valid",
+		Version:        a.Version,
+		StartedAt:      a.Config.Server.StartedAt,
+		Widgets:        len(collected),
+		FailingWidgets: make([]failingWidgetHealth, 0),
+	}
+
+	for i := range collected {
+		stats := &collected[i].stats
+
+		if stats.FailingSince.IsZero() {
+			continue
+		}
+
+		failing := failingWidgetHealth{
+			ID:                  collected[i].widget.GetID(),
+			Slug:                collected[i].widget.GetSlug(),
+			Type:                collected[i].widget.GetType(),
+			FailingSince:        stats.FailingSince,
+			ConsecutiveFailures: stats.ConsecutiveFailures,
+		}
+
+		if !stats.LastSuccessAt.IsZero() {
+			failing.LastSuccessAt = &stats.LastSuccessAt
+		}
+
+		response.FailingWidgets = append(response.FailingWidgets, failing)
+
+		if now.Sub(stats.FailingSince) > maxFailing {
+			response.Status = "unhealthy"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if response.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if !detailed {
+		json.NewEncoder(w).Encode(map[string]string{"status": response.Status})
+		return
+	}
+
+	json.NewEncoder(w).Encode(&response)
+}
@@ -0,0 +1,127 @@
+package glance
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type apiSecurity int
+
+const (
+	apiSecurityNone apiSecurity = iota
+	// the session cookie, which is only required when auth is enabled
+	apiSecuritySession
+	// one of the tokens from the server config sent as a bearer token
+	apiSecurityToken
+)
+
+type apiRoute struct {
+	method   string
+	path     string
+	summary  string
+	security apiSecurity
+}
+
+// A mux which also keeps track of the routes that are part of the API,
+// so that the OpenAPI document always matches what's actually registered
+type apiMux struct {
+	*http.ServeMux
+	routes []apiRoute
+}
+
+func newAPIMux() *apiMux {
+	return &apiMux{ServeMux: http.NewServeMux()}
+}
+
+// The pattern must include the method, routes which accept any method can't be documented
+func (m *apiMux) document(pattern string, summary string, security apiSecurity, handler http.Handler) {
+	m.Handle(pattern, handler)
+
+	method, path, _ := strings.Cut(pattern, " ")
+
+	m.routes = append(m.routes, apiRoute{
+		method:   strings.ToLower(method),
+		path:     path,
+		summary:  summary,
+		security: security,
+	})
+}
+
+var routeWildcardPattern = regexp.MustCompile(`\{([^}.$]+)(?:\.\.\.)?\}`)
+
+func (m *apiMux) openAPIDocument(version string, sessionAuth bool) map[string]any {
+	paths := make(map[string]map[string]any)
+
+	for _, route := range m.routes {
+		path := strings.TrimSuffix(route.path, "{$}")
+		parameters := make([]map[string]any, 0)
+
+		for _, match := range routeWildcardPattern.FindAllStringSubmatch(path, -1) {
+			parameters = append(parameters, map[string]any{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]string{"type": "string"},
+			})
+		}
+
+		path = routeWildcardPattern.ReplaceAllString(path, "{$1}")
+
+		responses := map[string]any{
+			"200": map[string]string{"description": "OK"},
+		}
+
+		operation := map[string]any{
+			"summary":   route.summary,
+			"responses": responses,
+		}
+
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if route.security == apiSecurityToken {
+			operation["security"] = []map[string][]string{{"token": {}}}
+			responses["401"] = map[string]string{"description": "Missing or invalid token"}
+		} else if route.security == apiSecuritySession && sessionAuth {
+			operation["security"] = []map[string][]string{{"session": {}}}
+			responses["401"] = map[string]string{"description": "Not logged in"}
+		}
+
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+
+		paths[path][route.method] = operation
+	}
+
+	securitySchemes := map[string]any{
+		"token": map[string]string{"type": "http", "scheme": "bearer"},
+	}
+
+	if sessionAuth {
+		securitySchemes["session"] = map[string]string{"type": "apiKey", "in": "cookie", "name": sessionCookieName}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "Glance",
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"securitySchemes": securitySchemes,
+		},
+	}
+}
+
+// Only the routes enabled through the config are included, such as the ones of the admin API
+func (a *Application) handleOpenAPIRequest(mux *apiMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mux.openAPIDocument(a.Version, a.Config.Auth.Enabled()))
+	}
+}
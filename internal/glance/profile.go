@@ -0,0 +1,175 @@
+package glance
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+// Overrides for a kind of screen, such as a TV or an e-ink display, picked
+// through the profile query parameter of the page or by the user agent
+type Profile struct {
+	// parts of the user agent, any of which picks the profile when it isn't in the URL
+	UserAgents    []string              `yaml:"user-agents"`
+	FontScale     float32               `yaml:"font-scale"`
+	Density       string                `yaml:"density"`
+	HideWidgets   []string              `yaml:"hide-widgets"`
+	LiveUpdates   *bool                 `yaml:"live-updates"`
+	RefreshOnWake *widget.DurationField `yaml:"refresh-on-wake"`
+	Name          string                `yaml:"-"`
+	hidden        map[string]bool
+}
+
+var profileDensities = []string{"compact", "comfortable", "spacious"}
+
+// The query parameter value which turns off the profile picked by the user agent
+const noProfile = "none"
+
+// Widgets are hidden either by their id or by their type, which are passed in
+// so that the ones that don't match any widget can be pointed out as typos
+func (p *Profile) initialize(name string, widgetNames map[string]bool) error {
+	p.Name = name
+
+	if !widgetSlugPattern.MatchString(name) || name == noProfile {
+		return errors.New("name can only contain letters, numbers, - and _ and can't be none")
+	}
+
+	if p.FontScale < 0 {
+		return errors.New("font-scale must be a positive number")
+	}
+
+	if p.Density != "" && !slices.Contains(profileDensities, p.Density) {
+		return fmt.Errorf("invalid density '%s', must be one of compact, comfortable or spacious", p.Density)
+	}
+
+	p.hidden = make(map[string]bool, len(p.HideWidgets))
+
+	for _, hidden := range p.HideWidgets {
+		if !widgetNames[hidden] {
+			return fmt.Errorf("hide-widgets: no widget has the id or type '%s'", hidden)
+		}
+
+		p.hidden[hidden] = true
+	}
+
+	for i := range p.UserAgents {
+		p.UserAgents[i] = strings.ToLower(p.UserAgents[i])
+	}
+
+	return nil
+}
+
+func initializeProfiles(config *Config) error {
+	widgetNames := make(map[string]bool)
+
+	for p := range config.Pages {
+		widget.WalkWidgets(config.Pages[p].widgets(), func(w widget.Widget) {
+			widgetNames[w.GetSlug()] = true
+			widgetNames[w.GetType()] = true
+		})
+	}
+
+	for name, profile := range config.Profiles {
+		if profile == nil {
+			profile = &Profile{}
+			config.Profiles[name] = profile
+		}
+
+		if err := profile.initialize(name, widgetNames); err != nil {
+			return fmt.Errorf("profile %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// The profile in the URL takes precedence over the user agent, the profiles
+// are checked against the user agent in the alphabetical order of their names
+func (a *Application) profileFor(r *http.Request) *Profile {
+	if name := r.URL.Query().Get("profile"); name != "" {
+		return a.Config.Profiles[name]
+	}
+
+	userAgent := strings.ToLower(r.UserAgent())
+
+	if userAgent == "" {
+		return nil
+	}
+
+	for _, name := range a.profileNames {
+		profile := a.Config.Profiles[name]
+
+		for _, part := range profile.UserAgents {
+			if strings.Contains(userAgent, part) {
+				return profile
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Profile) hides(w widget.Widget) bool {
+	return p != nil && (p.hidden[w.GetSlug()] || p.hidden[w.GetType()])
+}
+
+func (p *Profile) liveUpdates(page *Page) bool {
+	if p != nil && p.LiveUpdates != nil {
+		return *p.LiveUpdates
+	}
+
+	return page.LiveUpdates
+}
+
+func (p *Profile) refreshOnWake(page *Page) time.Duration {
+	if p != nil && p.RefreshOnWake != nil {
+		return time.Duration(*p.RefreshOnWake)
+	}
+
+	return time.Duration(page.RefreshOnWake)
+}
+
+func (d *templateData) LiveUpdates() bool {
+	return d.profile.liveUpdates(d.Page)
+}
+
+func (d *templateData) RefreshOnWake() bool {
+	return d.profile.refreshOnWake(d.Page) > 0
+}
+
+// Lets custom CSS target the profile through profile-{name}
+func (d *templateData) ProfileClasses() string {
+	if d.profile == nil {
+		return ""
+	}
+
+	classes := "profile-" + d.profile.Name + " "
+
+	if d.profile.Density != "" {
+		classes += "profile-density-" + d.profile.Density + " "
+	}
+
+	return classes
+}
+
+// Scales the font size of the theme, which is what the sizes of everything else are relative to
+func (d *templateData) ProfileFontSizeCSS() template.CSS {
+	if d.profile == nil || d.profile.FontScale == 0 {
+		return ""
+	}
+
+	fontSize := d.Theme().FontSize
+
+	if fontSize == 0 {
+		fontSize = defaultFontSize
+	}
+
+	return template.CSS(strconv.FormatFloat(float64(fontSize*d.profile.FontScale)/defaultFontSize*10, 'f', 3, 64) + "px")
+}
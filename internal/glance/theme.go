@@ -0,0 +1,263 @@
+package glance
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+func hsl(hue uint16, saturation uint8, lightness uint8) *widget.HSLColorField {
+	return &widget.HSLColorField{Hue: hue, Saturation: saturation, Lightness: lightness}
+}
+
+// Same as the themes listed in docs/themes.md
+var themePresets = map[string]Theme{
+	"teal-city": {
+		BackgroundColor:    hsl(225, 14, 15),
+		PrimaryColor:       hsl(157, 47, 65),
+		ContrastMultiplier: 1.1,
+	},
+	"catppuccin-frappe": {
+		BackgroundColor:    hsl(229, 19, 23),
+		PrimaryColor:       hsl(222, 74, 74),
+		PositiveColor:      hsl(96, 44, 68),
+		NegativeColor:      hsl(359, 68, 71),
+		ContrastMultiplier: 1.2,
+	},
+	"catppuccin-macchiato": {
+		BackgroundColor:    hsl(232, 23, 18),
+		PrimaryColor:       hsl(220, 83, 75),
+		PositiveColor:      hsl(105, 48, 72),
+		NegativeColor:      hsl(351, 74, 73),
+		ContrastMultiplier: 1.2,
+	},
+	"catppuccin-mocha": {
+		BackgroundColor:    hsl(240, 21, 15),
+		PrimaryColor:       hsl(217, 92, 83),
+		PositiveColor:      hsl(115, 54, 76),
+		NegativeColor:      hsl(347, 70, 65),
+		ContrastMultiplier: 1.2,
+	},
+	"camouflage": {
+		BackgroundColor:    hsl(186, 21, 20),
+		PrimaryColor:       hsl(97, 13, 80),
+		ContrastMultiplier: 1.2,
+	},
+	"kanagawa-dark": {
+		BackgroundColor:    hsl(240, 13, 14),
+		PrimaryColor:       hsl(51, 33, 68),
+		NegativeColor:      hsl(358, 100, 68),
+		ContrastMultiplier: 1.2,
+	},
+	"tucan": {
+		BackgroundColor: hsl(50, 1, 6),
+		PrimaryColor:    hsl(24, 97, 58),
+		NegativeColor:   hsl(209, 88, 54),
+	},
+	"catppuccin-latte": {
+		Light:              true,
+		BackgroundColor:    hsl(220, 23, 95),
+		PrimaryColor:       hsl(220, 91, 54),
+		PositiveColor:      hsl(109, 58, 40),
+		NegativeColor:      hsl(347, 87, 44),
+		ContrastMultiplier: 1.0,
+	},
+	"peachy": {
+		Light:                    true,
+		BackgroundColor:          hsl(28, 40, 77),
+		PrimaryColor:             hsl(155, 100, 20),
+		NegativeColor:            hsl(0, 100, 60),
+		ContrastMultiplier:       1.1,
+		TextSaturationMultiplier: 0.5,
+	},
+	"zebra": {
+		Light:           true,
+		BackgroundColor: hsl(0, 0, 95),
+		PrimaryColor:    hsl(0, 0, 10),
+		NegativeColor:   hsl(0, 90, 50),
+	},
+	// the colorblind presets use colors from the Okabe-Ito palette which
+	// remain distinguishable with all common types of color blindness
+	"high-contrast": {
+		BackgroundColor:    hsl(0, 0, 3),
+		PrimaryColor:       hsl(50, 100, 60),
+		PositiveColor:      hsl(145, 80, 55),
+		NegativeColor:      hsl(0, 100, 70),
+		ContrastMultiplier: 1.5,
+		IndicatorShapes:    true,
+	},
+	"high-contrast-light": {
+		Light:              true,
+		BackgroundColor:    hsl(0, 0, 100),
+		PrimaryColor:       hsl(230, 100, 35),
+		PositiveColor:      hsl(145, 100, 22),
+		NegativeColor:      hsl(0, 100, 38),
+		ContrastMultiplier: 1.5,
+		IndicatorShapes:    true,
+	},
+	"colorblind": {
+		BackgroundColor: hsl(220, 10, 10),
+		PrimaryColor:    hsl(41, 100, 60),
+		PositiveColor:   hsl(202, 77, 63),
+		NegativeColor:   hsl(26, 100, 55),
+		IndicatorShapes: true,
+	},
+	"colorblind-light": {
+		Light:           true,
+		BackgroundColor: hsl(0, 0, 97),
+		PrimaryColor:    hsl(202, 100, 35),
+		PositiveColor:   hsl(202, 100, 35),
+		NegativeColor:   hsl(26, 100, 42),
+		IndicatorShapes: true,
+	},
+}
+
+// Fonts bundled in static/fonts, along with a shorthand for the font of the operating system
+var fontFamilies = map[string]string{
+	"jetbrains-mono":  "'JetBrains Mono', monospace",
+	"fira-sans":       "'Fira Sans', sans-serif",
+	"open-sans":       "'Open Sans', sans-serif",
+	"source-serif":    "'Source Serif 4', serif",
+	"source-code-pro": "'Source Code Pro', monospace",
+	"system":          "system-ui, -apple-system, 'Segoe UI', Roboto, sans-serif",
+}
+
+// Anything other than the names of the bundled fonts is used as is and
+// has to be a list of font families, which could otherwise break out of
+// the style element they're placed in
+var fontFamilyPattern = regexp.MustCompile(`^[a-zA-Z0-9 ,'"-]+$`)
+
+// The font size of the page in pixels, which the sizes of everything else are relative to
+const defaultFontSize = 13
+
+func (t *Theme) inheritColorsFrom(other *Theme, inheritScheme bool) {
+	if t.BackgroundColor == nil {
+		t.BackgroundColor = other.BackgroundColor
+	}
+
+	if t.PrimaryColor == nil {
+		t.PrimaryColor = other.PrimaryColor
+	}
+
+	if t.PositiveColor == nil {
+		t.PositiveColor = other.PositiveColor
+	}
+
+	if t.NegativeColor == nil {
+		t.NegativeColor = other.NegativeColor
+	}
+
+	if t.ContrastMultiplier == 0 {
+		t.ContrastMultiplier = other.ContrastMultiplier
+	}
+
+	if t.TextSaturationMultiplier == 0 {
+		t.TextSaturationMultiplier = other.TextSaturationMultiplier
+	}
+
+	if t.FontFamily == "" {
+		t.FontFamily = other.FontFamily
+	}
+
+	if t.FontSize == 0 {
+		t.FontSize = other.FontSize
+	}
+
+	if inheritScheme {
+		t.Light = t.Light || other.Light
+	}
+
+	t.IndicatorShapes = t.IndicatorShapes || other.IndicatorShapes
+}
+
+// Properties that aren't set are taken from the preset if one is specified,
+// otherwise from the parent theme, which is the global theme for pages and
+// the theme they're defined in for the prefers-light and prefers-dark variants
+func (t *Theme) resolve(parent *Theme, isVariant bool) error {
+	if t.Preset != "" {
+		preset, exists := themePresets[t.Preset]
+
+		if !exists {
+			return fmt.Errorf("unknown preset '%s'", t.Preset)
+		}
+
+		t.inheritColorsFrom(&preset, true)
+	} else if parent != nil {
+		t.inheritColorsFrom(parent, !isVariant)
+	}
+
+	if t.FontFamily != "" {
+		if _, exists := fontFamilies[t.FontFamily]; !exists && !fontFamilyPattern.MatchString(t.FontFamily) {
+			return fmt.Errorf("invalid font-family '%s'", t.FontFamily)
+		}
+	}
+
+	if t.FontSize < 0 {
+		return errors.New("font-size must be a positive number")
+	}
+
+	if parent != nil && t.CustomCSSFile == "" {
+		t.CustomCSSFile = parent.CustomCSSFile
+	}
+
+	if parent != nil {
+		t.ReduceMotion = t.ReduceMotion || parent.ReduceMotion
+		t.IndicatorShapes = t.IndicatorShapes || parent.IndicatorShapes
+	}
+
+	for _, variant := range []*Theme{t.PrefersLight, t.PrefersDark} {
+		if variant == nil {
+			continue
+		}
+
+		if isVariant || variant.PrefersLight != nil || variant.PrefersDark != nil {
+			return errors.New("prefers-light and prefers-dark cannot be nested")
+		}
+
+		if err := variant.resolve(t, true); err != nil {
+			return err
+		}
+	}
+
+	if parent != nil && !isVariant && t.Preset == "" && t.PrefersLight == nil && t.PrefersDark == nil {
+		t.PrefersLight = parent.PrefersLight
+		t.PrefersDark = parent.PrefersDark
+	}
+
+	return nil
+}
+
+func (t *Theme) FontFamilyCSS() template.CSS {
+	if family, exists := fontFamilies[t.FontFamily]; exists {
+		return template.CSS(family)
+	}
+
+	return template.CSS(t.FontFamily)
+}
+
+// The rem unit of the page, which is 10px for the default font size
+func (t *Theme) RootFontSizeCSS() template.CSS {
+	return template.CSS(strconv.FormatFloat(float64(t.FontSize)/defaultFontSize*10, 'f', 3, 64) + "px")
+}
+
+func (t *Theme) HasVariants() bool {
+	return t.PrefersLight != nil || t.PrefersDark != nil
+}
+
+// Returns whether the light scheme should be used depending
+// on the color scheme that the browser prefers
+func (t *Theme) IsLightWhenPreferring(light bool) bool {
+	if light && t.PrefersLight != nil {
+		return t.PrefersLight.Light
+	}
+
+	if !light && t.PrefersDark != nil {
+		return t.PrefersDark.Light
+	}
+
+	return t.Light
+}
@@ -0,0 +1,197 @@
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+const (
+	updateCheckRepository = "glanceapp/glance"
+	updateCheckInterval   = 12 * time.Hour
+	updateCheckTimeout    = 30 * time.Second
+)
+
+// Builds made through go install don't go through the release
+// pipeline which sets the version, but the module version is known
+func init() {
+	if buildVersion != "dev" {
+		return
+	}
+
+	info, ok := debug.ReadBuildInfo()
+
+	if ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		buildVersion = info.Main.Version
+	}
+}
+
+type availableUpdate struct {
+	Version  string
+	NotesURL string
+}
+
+type updateChecker struct {
+	current   string
+	mu        sync.Mutex
+	latest    *feed.AppRelease
+	checkedAt time.Time
+}
+
+// Returns nil for development builds since there's nothing to compare their version to
+func newUpdateChecker(current string) *updateChecker {
+	if _, ok := parseReleaseVersion(current); !ok {
+		return nil
+	}
+
+	return &updateChecker{current: current}
+}
+
+func (c *updateChecker) run() {
+	for {
+		c.check()
+		time.Sleep(updateCheckInterval)
+	}
+}
+
+func (c *updateChecker) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+
+	// failures get logged by the fetcher and the previous result is kept until the next check
+	releases, err := feed.FetchLatestReleases(ctx, []*feed.ReleaseRequest{{
+		Source:     feed.ReleaseSourceGithub,
+		Repository: updateCheckRepository,
+	}})
+
+	if err != nil || len(releases) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.latest = &releases[0]
+	c.checkedAt = time.Now()
+	c.mu.Unlock()
+
+	if isNewerReleaseVersion(releases[0].Version, c.current) {
+		slog.Info("A new version of Glance is available", "current", c.current, "latest", releases[0].Version)
+	}
+}
+
+func (c *updateChecker) available() *availableUpdate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.latest == nil || !isNewerReleaseVersion(c.latest.Version, c.current) {
+		return nil
+	}
+
+	return &availableUpdate{
+		Version:  c.latest.Version,
+		NotesURL: c.latest.NotesUrl,
+	}
+}
+
+// Pre-release suffixes are ignored since the latest release on GitHub is never a pre-release
+func parseReleaseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	parts := strings.Split(version, ".")
+
+	if len(parts) != 3 {
+		return parsed, false
+	}
+
+	for i := range parts {
+		number, err := strconv.Atoi(parts[i])
+
+		if err != nil {
+			return parsed, false
+		}
+
+		parsed[i] = number
+	}
+
+	return parsed, true
+}
+
+func isNewerReleaseVersion(version string, than string) bool {
+	parsed, ok := parseReleaseVersion(version)
+
+	if !ok {
+		return false
+	}
+
+	parsedThan, ok := parseReleaseVersion(than)
+
+	if !ok {
+		return false
+	}
+
+	for i := range parsed {
+		if parsed[i] != parsedThan[i] {
+			return parsed[i] > parsedThan[i]
+		}
+	}
+
+	return false
+}
+
+// Used by the footer, nil unless update checks are enabled and a newer version has been found
+func (a *Application) AvailableUpdate() *availableUpdate {
+	if a.updates == nil {
+		return nil
+	}
+
+	return a.updates.available()
+}
+
+type statusResponse struct {
+	Version   string                `json:"version"`
+	StartedAt time.Time             `json:"started-at"`
+	Update    *updateStatusResponse `json:"update"`
+}
+
+type updateStatusResponse struct {
+	Available     bool       `json:"available"`
+	LatestVersion string     `json:"latest-version"`
+	NotesURL      string     `json:"notes-url"`
+	CheckedAt     *time.Time `json:"checked-at"`
+}
+
+// The update is null when update checks are disabled or the build is a development one
+func (a *Application) HandleStatusRequest(w http.ResponseWriter, r *http.Request) {
+	response := statusResponse{
+		Version:   a.Version,
+		StartedAt: a.Config.Server.StartedAt,
+	}
+
+	if a.updates != nil {
+		a.updates.mu.Lock()
+		update := &updateStatusResponse{}
+
+		if a.updates.latest != nil {
+			checkedAt := a.updates.checkedAt
+			update.Available = isNewerReleaseVersion(a.updates.latest.Version, a.updates.current)
+			update.LatestVersion = a.updates.latest.Version
+			update.NotesURL = a.updates.latest.NotesUrl
+			update.CheckedAt = &checkedAt
+		}
+
+		a.updates.mu.Unlock()
+		response.Update = update
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(&response)
+}
@@ -0,0 +1,265 @@
+package glance
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/glanceapp/glance/internal/widget"
+
+	"gopkg.in/yaml.v3"
+)
+
+// An error that can be pointed to a specific line of the config file,
+// or of one of the files included by it when File is set
+type configLineError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *configLineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	widgetsType         = reflect.TypeOf(widget.Widgets{})
+)
+
+// Returns all of the problems with the config rather than stopping at the first one
+// like loading it does, including fields that don't exist, which would otherwise get
+// silently ignored and are usually typos
+func validateConfig(contents []byte, dir string) []error {
+	document, includer, err := parseConfigNode(contents, dir)
+
+	if err != nil {
+		return []error{err}
+	}
+
+	validator := &configValidator{files: includer.files}
+	validator.findUnknownFields(document, reflect.TypeOf(Config{}), "")
+
+	if _, err := newConfigFromYml(contents, dir); err != nil {
+		validator.errs = append(validator.errs, err)
+	}
+
+	return validator.errs
+}
+
+type configValidator struct {
+	files map[*yaml.Node]string
+	errs  []error
+}
+
+type yamlFields struct {
+	types map[string]reflect.Type
+	// set when the struct has an inline map which accepts any key
+	anyKey bool
+}
+
+func structYAMLFields(t reflect.Type, fields *yamlFields) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("yaml")
+		name, options, _ := strings.Cut(tag, ",")
+
+		if name == "-" {
+			continue
+		}
+
+		if strings.Contains(options, "inline") {
+			inlineType := field.Type
+
+			if inlineType.Kind() == reflect.Pointer {
+				inlineType = inlineType.Elem()
+			}
+
+			if inlineType.Kind() == reflect.Map {
+				fields.anyKey = true
+			} else if inlineType.Kind() == reflect.Struct {
+				structYAMLFields(inlineType, fields)
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fields.types[name] = field.Type
+	}
+}
+
+func (v *configValidator) findUnknownFields(node *yaml.Node, t reflect.Type, context string) {
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Content {
+			v.findUnknownFields(child, t, context)
+		}
+
+		return
+	}
+
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == widgetsType {
+		v.findUnknownWidgetFields(node)
+		return
+	}
+
+	// types with their own unmarshaling report their own errors
+	if reflect.PointerTo(t).Implements(yamlUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+
+		for _, item := range node.Content {
+			v.findUnknownFields(item, t.Elem(), context)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 1; i < len(node.Content); i += 2 {
+			v.findUnknownFields(node.Content[i], t.Elem(), context)
+		}
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+
+		fields := yamlFields{types: make(map[string]reflect.Type)}
+		structYAMLFields(t, &fields)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			if key.Value == "<<" {
+				v.findUnknownFields(value, t, context)
+				continue
+			}
+
+			fieldType, exists := fields.types[key.Value]
+
+			if !exists {
+				if !fields.anyKey {
+					v.errs = append(v.errs, v.unknownFieldError(key, context, fields.types))
+				}
+
+				continue
+			}
+
+			v.findUnknownFields(value, fieldType, context)
+		}
+	}
+}
+
+func (v *configValidator) findUnknownWidgetFields(node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for _, item := range node.Content {
+		if item.Kind == yaml.AliasNode {
+			item = item.Alias
+		}
+
+		meta := struct {
+			Type string `yaml:"type"`
+		}{}
+
+		if err := item.Decode(&meta); err != nil {
+			continue
+		}
+
+		w, err := widget.New(meta.Type)
+
+		// reported when loading the config
+		if err != nil {
+			continue
+		}
+
+		v.findUnknownFields(item, reflect.TypeOf(w).Elem(), meta.Type+" widget")
+	}
+}
+
+func (v *configValidator) unknownFieldError(key *yaml.Node, context string, known map[string]reflect.Type) error {
+	message := fmt.Sprintf("unknown field '%s'", key.Value)
+
+	if context != "" {
+		message += " in " + context
+	}
+
+	if suggestion := closestFieldName(key.Value, known); suggestion != "" {
+		message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+	}
+
+	return &configLineError{File: v.files[key], Line: key.Line, Message: message}
+}
+
+// Suggests a field when the unknown one is most likely a typo of it
+func closestFieldName(name string, known map[string]reflect.Type) string {
+	names := make([]string, 0, len(known))
+
+	for candidate := range known {
+		names = append(names, candidate)
+	}
+
+	// makes the suggestion deterministic when there's a tie
+	sort.Strings(names)
+
+	best, bestDistance := "", max(2, len(name)/3)+1
+
+	for _, candidate := range names {
+		if distance := levenshteinDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+func levenshteinDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,250 @@
+package glance
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/glanceapp/glance/internal/widget"
+)
+
+type locationHeaders struct {
+	latitude  string
+	longitude string
+	timezone  string
+	city      string
+	area      string
+	country   string
+}
+
+var visitorLocationHeaders = []locationHeaders{
+	// Cloudflare, with the "Add visitor location headers" managed transform
+	{"CF-IPLatitude", "CF-IPLongitude", "CF-Timezone", "CF-IPCity", "CF-Region", "CF-IPCountry"},
+	// CloudFront, with the headers added to the origin request policy
+	{
+		"CloudFront-Viewer-Latitude", "CloudFront-Viewer-Longitude", "CloudFront-Viewer-Time-Zone",
+		"CloudFront-Viewer-City", "CloudFront-Viewer-Country-Region-Name", "CloudFront-Viewer-Country-Name",
+	},
+}
+
+// Set through the location picker of widgets with visitor-location
+const locationCookieName = "glance_location"
+
+// Long enough to not have to pick the location again, but not forever
+const locationCookieDuration = 365 * 24 * time.Hour
+
+// The location of the visitor, which is either the one they picked or their approximate
+// one from the headers of the proxy in front of Glance or from looking up their address
+// in the GeoIP database. Returns nil when none of them are available, in which case
+// widgets use the location from their config
+func (a *Application) visitorPlace(r *http.Request) *feed.PlaceJson {
+	if place := placeFromCookie(r); place != nil {
+		return place
+	}
+
+	trustHeaders := a.Config.Server.GeoIPProxyHeaders
+
+	if trustHeaders {
+		if place := placeFromHeaders(r.Header); place != nil {
+			return place
+		}
+	}
+
+	if a.geoip == nil {
+		return nil
+	}
+
+	address := visitorAddress(r, trustHeaders)
+
+	if !address.IsValid() || address.IsPrivate() || address.IsLoopback() {
+		return nil
+	}
+
+	place, err := a.geoip.LookupPlace(address)
+
+	if err != nil {
+		slog.Warn("Failed to look up the location of visitor", "address", address, "error", err)
+	}
+
+	return place
+}
+
+func placeFromHeaders(header http.Header) *feed.PlaceJson {
+	for _, names := range visitorLocationHeaders {
+		latitude, err := strconv.ParseFloat(header.Get(names.latitude), 64)
+
+		if err != nil {
+			continue
+		}
+
+		longitude, err := strconv.ParseFloat(header.Get(names.longitude), 64)
+
+		if err != nil {
+			continue
+		}
+
+		place, err := feed.NewPlace(
+			header.Get(names.city),
+			header.Get(names.area),
+			header.Get(names.country),
+			latitude,
+			longitude,
+			header.Get(names.timezone),
+		)
+
+		if err == nil {
+			return place
+		}
+	}
+
+	return nil
+}
+
+// The place gets stored rather than its name so that it
+// doesn't have to be looked up again on every request
+func placeFromCookie(r *http.Request) *feed.PlaceJson {
+	cookie, err := r.Cookie(locationCookieName)
+
+	if err != nil {
+		return nil
+	}
+
+	values, err := url.ParseQuery(cookie.Value)
+
+	if err != nil {
+		return nil
+	}
+
+	latitude, err := strconv.ParseFloat(values.Get("latitude"), 64)
+
+	if err != nil {
+		return nil
+	}
+
+	longitude, err := strconv.ParseFloat(values.Get("longitude"), 64)
+
+	if err != nil {
+		return nil
+	}
+
+	place, err := feed.NewPlace(values.Get("name"), values.Get("area"), values.Get("country"), latitude, longitude, values.Get("timezone"))
+
+	if err != nil {
+		return nil
+	}
+
+	return place
+}
+
+type visitorLocationResponse struct {
+	Name    string `json:"name"`
+	Area    string `json:"area"`
+	Country string `json:"country"`
+}
+
+// Looks up the location that the visitor picked, which then takes the place of
+// their approximate location for all widgets with visitor-location
+func (a *Application) HandleSetLocationRequest(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("location"))
+
+	if name == "" {
+		http.Error(w, "location is required", http.StatusBadRequest)
+		return
+	}
+
+	place, err := feed.FetchPlaceFromName(r.Context(), name, "")
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values := url.Values{}
+	values.Set("name", place.Name)
+	values.Set("area", place.Area)
+	values.Set("country", place.Country)
+	values.Set("latitude", strconv.FormatFloat(place.Latitude, 'f', -1, 64))
+	values.Set("longitude", strconv.FormatFloat(place.Longitude, 'f', -1, 64))
+	values.Set("timezone", place.Timezone)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     locationCookieName,
+		Value:    values.Encode(),
+		Path:     "/",
+		Expires:  time.Now().Add(locationCookieDuration),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&visitorLocationResponse{
+		Name:    place.Name,
+		Area:    place.Area,
+		Country: place.Country,
+	})
+}
+
+// Goes back to the approximate location of the visitor
+func (a *Application) HandleResetLocationRequest(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     locationCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// The address of the visitor is only taken from the headers when they're trusted,
+// since otherwise anyone could make Glance think that they're anywhere
+func visitorAddress(r *http.Request, trustHeaders bool) netip.Addr {
+	if trustHeaders {
+		if address, err := netip.ParseAddr(r.Header.Get("CF-Connecting-IP")); err == nil {
+			return address
+		}
+
+		first, _, _ := strings.Cut(r.Header.Get("X-Forwarded-For"), ",")
+
+		if address, err := netip.ParseAddr(strings.TrimSpace(first)); err == nil {
+			return address
+		}
+	}
+
+	if addressPort, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return addressPort.Addr()
+	}
+
+	return netip.Addr{}
+}
+
+func (a *Application) usesVisitorLocation() bool {
+	for _, w := range a.widgetByID {
+		if widget.UsesVisitorLocation(w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Widgets with visitor-location get swapped for their copy for the place of the
+// visitor, which gets updated right away since it isn't part of the updates of the page
+func visitorWidget(w widget.Widget, place *feed.PlaceJson, now *time.Time) widget.Widget {
+	located := widget.ForVisitor(w, place)
+
+	if located != w {
+		widget.UpdateIfRequired(context.Background(), located, now)
+	}
+
+	return located
+}
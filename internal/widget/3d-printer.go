@@ -0,0 +1,139 @@
+//go:build !slim || widget_3d_printer
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("3d-printer", func() Widget { return &Printer{} })
+}
+
+type Printer struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Service           string              `yaml:"service"`
+	URL               URLField            `yaml:"url"`
+	APIKey            OptionalEnvString   `yaml:"api-key"`
+	SnapshotURL       URLField            `yaml:"snapshot-url"`
+	AllowActions      bool                `yaml:"allow-actions"`
+	Status            *feed.PrinterStatus `yaml:"-"`
+	printer           *feed.PrinterClient `yaml:"-"`
+}
+
+func (widget *Printer) Initialize() error {
+	widget.withTitle("3D Printer").withCacheDuration(1 * time.Minute)
+
+	switch widget.Service {
+	case feed.PrinterServiceOctoPrint:
+		if widget.APIKey == "" {
+			return errors.New("api-key must be specified for octoprint in 3d-printer widget")
+		}
+	case feed.PrinterServiceMoonraker:
+	default:
+		return errors.New("3d printer service must be either 'octoprint' or 'moonraker'")
+	}
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for 3d-printer widget")
+	}
+
+	widget.withTitleURL(string(widget.URL))
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("3d-printer widget: %v", err)
+	}
+
+	widget.printer = &feed.PrinterClient{
+		Service: widget.Service,
+		URL:     string(widget.URL),
+		APIKey:  widget.APIKey.String(),
+		Client:  widget.client,
+	}
+
+	return nil
+}
+
+func (widget *Printer) Update(ctx context.Context) {
+	status, err := widget.printer.FetchStatus(ctx)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Status = status
+}
+
+func (widget *Printer) Render() template.HTML {
+	return widget.render(widget, assets.PrinterTemplate)
+}
+
+// GET /snapshot responds with an image from the webcam, so that it can be shown without the
+// printer having to be reachable from the browser. POST /pause, /resume and /cancel control
+// the current print job and are only available with allow-actions
+func (widget *Printer) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+
+	switch {
+	case path == "snapshot" && widget.SnapshotURL != "":
+		widget.handleSnapshotRequest(w, r)
+	case widget.AllowActions && (path == "pause" || path == "resume" || path == "cancel"):
+		widget.handleActionRequest(w, r, path)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (widget *Printer) handleSnapshotRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, image, err := widget.printer.FetchSnapshot(r.Context(), string(widget.SnapshotURL))
+
+	if err != nil {
+		slog.Error("Failed to fetch printer snapshot", "widget", widget.GetSlug(), "error", err)
+		http.Error(w, "could not get the snapshot", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(image)
+}
+
+func (widget *Printer) handleActionRequest(w http.ResponseWriter, r *http.Request, action string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := widget.printer.ControlJob(r.Context(), action)
+
+	if errors.Is(err, feed.ErrPrinterActionNotAllowed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to control print job", "widget", widget.GetSlug(), "action", action, "error", err)
+		http.Error(w, "could not "+action+" the print", http.StatusBadGateway)
+		return
+	}
+
+	ExpireCache(widget)
+	w.WriteHeader(http.StatusNoContent)
+}
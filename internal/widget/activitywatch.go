@@ -0,0 +1,70 @@
+//go:build !slim || widget_activitywatch
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("activitywatch", func() Widget { return &ActivityWatch{} })
+}
+
+type ActivityWatch struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               URLField       `yaml:"url"`
+	Hostname          string         `yaml:"hostname"`
+	GroupBy           string         `yaml:"group-by"`
+	Limit             int            `yaml:"limit"`
+	Activity          *feed.Activity `yaml:"-"`
+}
+
+func (widget *ActivityWatch) Initialize() error {
+	widget.withTitle("Screen Time").withCacheDuration(5 * time.Minute)
+
+	if widget.URL == "" {
+		widget.URL = "http://localhost:5600"
+	}
+
+	widget.withTitleURL(string(widget.URL))
+
+	switch widget.GroupBy {
+	case "":
+		widget.GroupBy = "app"
+	case "app", "category":
+	default:
+		return errors.New("group-by must be either 'app' or 'category'")
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 8
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("activitywatch widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *ActivityWatch) Update(ctx context.Context) {
+	activity, err := feed.FetchActivityWatchUsage(ctx, widget.client, string(widget.URL), widget.Hostname, widget.GroupBy == "category", widget.Limit)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Activity = activity
+}
+
+func (widget *ActivityWatch) Render() template.HTML {
+	return widget.render(widget, assets.ActivityWatchTemplate)
+}
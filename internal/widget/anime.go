@@ -0,0 +1,150 @@
+//go:build !slim || widget_anime
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("anime", func() Widget { return &Anime{} })
+}
+
+type Anime struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Service           string              `yaml:"service"`
+	Username          string              `yaml:"username"`
+	ClientID          OptionalEnvString   `yaml:"client-id"`
+	Days              int                 `yaml:"days"`
+	Timezone          string              `yaml:"timezone"`
+	HourFormat        string              `yaml:"hour-format"`
+	CollapseAfter     int                 `yaml:"collapse-after"`
+	Episodes          []feed.AnimeEpisode `yaml:"-"`
+	location          *time.Location
+}
+
+func (widget *Anime) Initialize() error {
+	widget.withTitle("Airing").withCacheDuration(time.Hour)
+
+	if widget.Username == "" {
+		return errors.New("username must be specified for anime widget")
+	}
+
+	switch widget.Service {
+	case feed.AnimeServiceAniList:
+		widget.withTitleURL("https://anilist.co/user/" + url.PathEscape(widget.Username) + "/animelist")
+	case feed.AnimeServiceMyAnimeList:
+		if widget.ClientID == "" {
+			return errors.New("client-id must be specified for myanimelist in anime widget")
+		}
+
+		widget.withTitleURL("https://myanimelist.net/animelist/" + url.PathEscape(widget.Username))
+	default:
+		return errors.New("service for anime widget must be either anilist or myanimelist")
+	}
+
+	if widget.Days <= 0 {
+		widget.Days = 1
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if widget.HourFormat == "" {
+		widget.HourFormat = "24h"
+	} else if widget.HourFormat != "12h" && widget.HourFormat != "24h" {
+		return fmt.Errorf("invalid hour format '%s' for anime widget, must be either 12h or 24h", widget.HourFormat)
+	}
+
+	widget.location = time.Local
+
+	if widget.Timezone != "" {
+		location, err := time.LoadLocation(widget.Timezone)
+
+		if err != nil {
+			return fmt.Errorf("invalid timezone '%s' for anime widget: %v", widget.Timezone, err)
+		}
+
+		widget.location = location
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("anime widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Anime) Update(ctx context.Context) {
+	// until the end of the last day rather than the same time on it
+	now := time.Now().In(widget.location)
+	until := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, widget.location).AddDate(0, 0, widget.Days)
+
+	episodes, err := feed.FetchAnimeAiring(ctx, widget.client, widget.Service, widget.Username, widget.ClientID.String(), until)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range episodes {
+		episodes[i].AiringAt = episodes[i].AiringAt.In(widget.location)
+	}
+
+	widget.Episodes = episodes
+}
+
+func (widget *Anime) AirsToday(t time.Time) bool {
+	now := time.Now().In(widget.location)
+	return t.Year() == now.Year() && t.YearDay() == now.YearDay()
+}
+
+func (widget *Anime) AiringLabel(t time.Time) string {
+	clock := t.Format("15:04")
+
+	if widget.HourFormat == "12h" {
+		clock = t.Format("3:04pm")
+	}
+
+	now := time.Now().In(widget.location)
+
+	switch {
+	case widget.AirsToday(t):
+		return "Today, " + clock
+	case widget.AirsToday(t.AddDate(0, 0, -1)):
+		return "Tomorrow, " + clock
+	case t.Sub(now) < 6*24*time.Hour:
+		return t.Format("Monday") + ", " + clock
+	}
+
+	return t.Format("Jan 2") + ", " + clock
+}
+
+// Worked out when rendering so that it stays accurate in between updates
+func (widget *Anime) Countdown(t time.Time) string {
+	left := time.Until(t)
+
+	switch {
+	case left <= 0:
+		return "aired"
+	case left < time.Hour:
+		return fmt.Sprintf("in %dm", max(int(left.Minutes()), 1))
+	case left < 24*time.Hour:
+		return fmt.Sprintf("in %dh %02dm", int(left.Hours()), int(left.Minutes())%60)
+	}
+
+	return fmt.Sprintf("in %dd %dh", int(left.Hours())/24, int(left.Hours())%24)
+}
+
+func (widget *Anime) Render() template.HTML {
+	return widget.render(widget, assets.AnimeTemplate)
+}
@@ -0,0 +1,146 @@
+//go:build !slim || widget_backups
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("backups", func() Widget { return &Backups{} })
+}
+
+type backupsPBS struct {
+	URL       URLField          `yaml:"url"`
+	Datastore string            `yaml:"datastore"`
+	Namespace string            `yaml:"namespace"`
+	Token     OptionalEnvString `yaml:"token"`
+}
+
+type backupsItem struct {
+	feed.Backup
+	IsStale bool
+}
+
+type backupsJob struct {
+	Name    string        `yaml:"name"`
+	File    string        `yaml:"file"`
+	PBS     *backupsPBS   `yaml:"pbs"`
+	MaxAge  DurationField `yaml:"max-age"`
+	Backups []backupsItem `yaml:"-"`
+	Error   string        `yaml:"-"`
+}
+
+type Backups struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Jobs              []backupsJob  `yaml:"jobs"`
+	MaxAge            DurationField `yaml:"max-age"`
+}
+
+func (widget *Backups) Initialize() error {
+	widget.withTitle("Backups").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Jobs) == 0 {
+		return errors.New("no jobs specified for backups widget")
+	}
+
+	if widget.MaxAge == 0 {
+		widget.MaxAge = DurationField(26 * time.Hour)
+	}
+
+	for i := range widget.Jobs {
+		job := &widget.Jobs[i]
+
+		if (job.File == "") == (job.PBS == nil) {
+			return fmt.Errorf("job %d in backups widget needs either file or pbs", i+1)
+		}
+
+		if job.PBS != nil && (job.PBS.URL == "" || job.PBS.Datastore == "" || job.PBS.Token == "") {
+			return fmt.Errorf("url, datastore and token must be specified for pbs of job %d in backups widget", i+1)
+		}
+
+		if job.Name == "" && job.PBS != nil {
+			job.Name = job.PBS.Datastore
+		}
+
+		if job.Name == "" {
+			return fmt.Errorf("name must be specified for job %d in backups widget", i+1)
+		}
+
+		if job.MaxAge == 0 {
+			job.MaxAge = widget.MaxAge
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("backups widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Backups) Update(ctx context.Context) {
+	now := time.Now()
+	var failed int
+
+	for i := range widget.Jobs {
+		job := &widget.Jobs[i]
+		var backups []feed.Backup
+		var err error
+
+		if job.PBS != nil {
+			backups, err = feed.FetchProxmoxBackupServerBackups(ctx, widget.client, string(job.PBS.URL), job.PBS.Datastore, job.PBS.Namespace, job.PBS.Token.String())
+
+			if err == nil && len(backups) == 0 {
+				err = errors.New("datastore has no backups")
+			}
+		} else {
+			var backup *feed.Backup
+
+			if backup, err = feed.ReadBackupStatusFile(job.File); err == nil {
+				backups = []feed.Backup{*backup}
+			}
+		}
+
+		if err != nil {
+			failed++
+			job.Error = err.Error()
+			job.Backups = nil
+			continue
+		}
+
+		job.Error = ""
+		job.Backups = make([]backupsItem, len(backups))
+
+		for j := range backups {
+			job.Backups[j] = backupsItem{
+				Backup:  backups[j],
+				IsStale: now.Sub(backups[j].Time) > time.Duration(job.MaxAge),
+			}
+		}
+	}
+
+	if failed == len(widget.Jobs) {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not check any backups", feed.ErrNoContent))
+		return
+	}
+
+	if failed > 0 {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not check %d job(s)", feed.ErrPartialContent, failed))
+		return
+	}
+
+	widget.canContinueUpdateAfterHandlingErr(nil)
+}
+
+func (widget *Backups) Render() template.HTML {
+	return widget.render(widget, assets.BackupsTemplate)
+}
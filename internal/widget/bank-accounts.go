@@ -0,0 +1,81 @@
+//go:build !slim || widget_bank_accounts
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("bank-accounts", func() Widget { return &BankAccounts{} })
+}
+
+type BankAccounts struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	SecretID          OptionalEnvString      `yaml:"secret-id"`
+	SecretKey         OptionalEnvString      `yaml:"secret-key"`
+	RequisitionID     OptionalEnvString      `yaml:"requisition-id"`
+	Transactions      int                    `yaml:"transactions"`
+	HideTransactions  bool                   `yaml:"hide-transactions"`
+	Accounts          []feed.BankAccount     `yaml:"-"`
+	goCardless        *feed.GoCardlessClient `yaml:"-"`
+}
+
+func (widget *BankAccounts) Initialize() error {
+	// banks only have to allow 4 requests per day for each account, which leaves
+	// a bit of room for restarts, since every restart updates the widget again
+	widget.withTitle("Bank Accounts").withCacheDuration(8 * time.Hour)
+
+	if widget.SecretID == "" || widget.SecretKey == "" {
+		return errors.New("secret-id and secret-key must be specified for bank-accounts widget")
+	}
+
+	if widget.RequisitionID == "" {
+		return errors.New("requisition-id must be specified for bank-accounts widget")
+	}
+
+	if widget.Transactions <= 0 {
+		widget.Transactions = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("bank-accounts widget: %v", err)
+	}
+
+	widget.goCardless = &feed.GoCardlessClient{
+		SecretID:  widget.SecretID.String(),
+		SecretKey: widget.SecretKey.String(),
+		Client:    widget.client,
+	}
+
+	return nil
+}
+
+func (widget *BankAccounts) Update(ctx context.Context) {
+	limit := widget.Transactions
+
+	// hidden transactions aren't fetched either, so that they don't use up the limits
+	if widget.HideTransactions {
+		limit = 0
+	}
+
+	accounts, err := widget.goCardless.FetchBankAccounts(ctx, widget.RequisitionID.String(), limit)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Accounts = accounts
+}
+
+func (widget *BankAccounts) Render() template.HTML {
+	return widget.render(widget, assets.BankAccountsTemplate)
+}
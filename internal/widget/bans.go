@@ -0,0 +1,200 @@
+//go:build !slim || widget_bans
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("bans", func() Widget { return &Bans{} })
+}
+
+type bansSource struct {
+	Type          string            `yaml:"type"`
+	Name          string            `yaml:"name"`
+	URL           string            `yaml:"url"`
+	Token         OptionalEnvString `yaml:"token"`
+	MachineID     OptionalEnvString `yaml:"machine-id"`
+	Password      OptionalEnvString `yaml:"password"`
+	AllowInsecure bool              `yaml:"allow-insecure"`
+}
+
+type bansItem struct {
+	feed.Ban
+	Source string
+}
+
+type bansCount struct {
+	Name  string
+	Count int
+}
+
+type Bans struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Sources           []bansSource `yaml:"sources"`
+	Limit             int          `yaml:"limit"`
+	Bans              []bansItem   `yaml:"-"`
+	Total             int          `yaml:"-"`
+	ByReason          []bansCount  `yaml:"-"`
+	ByCountry         []bansCount  `yaml:"-"`
+	requests          []*feed.BansRequest
+}
+
+func (widget *Bans) Initialize() error {
+	widget.withTitle("Bans").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Sources) == 0 {
+		widget.Sources = []bansSource{{Type: "local"}}
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 10
+	}
+
+	widget.requests = make([]*feed.BansRequest, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.Type == "" {
+			source.Type = "local"
+		}
+
+		request := &feed.BansRequest{
+			URL:           source.URL,
+			Token:         source.Token.String(),
+			AllowInsecure: source.AllowInsecure,
+		}
+
+		switch source.Type {
+		case "local", "remote":
+			if source.Type == "remote" && source.URL == "" {
+				return errors.New("missing url for remote source in bans widget")
+			}
+
+			if source.Name == "" {
+				source.Name = "Fail2ban"
+			}
+		case "crowdsec":
+			if source.URL == "" || source.MachineID == "" || source.Password == "" {
+				return errors.New("url, machine-id and password must be specified for crowdsec source in bans widget")
+			}
+
+			if source.Name == "" {
+				source.Name = "CrowdSec"
+			}
+
+			// the HTTP options of the widget apply to all of its CrowdSec
+			// sources, which can also each allow insecure connections
+			options := widget.httpClientOptions
+			options.AllowInsecure = options.AllowInsecure || source.AllowInsecure
+
+			if err := options.initializeClient(); err != nil {
+				return fmt.Errorf("bans widget: %v", err)
+			}
+
+			request.CrowdSec = &feed.CrowdSecClient{
+				URL:       source.URL,
+				MachineID: source.MachineID.String(),
+				Password:  source.Password.String(),
+				Client:    options.client,
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in bans widget, must be one of local, remote or crowdsec", source.Type)
+		}
+
+		widget.requests[i] = request
+	}
+
+	return nil
+}
+
+// The addresses of CrowdSec decisions can also be ranges, which are left without a country
+func (widget *Bans) lookupCountry(ip string) string {
+	if widget.Providers == nil || widget.Providers.GeoIP == nil {
+		return ""
+	}
+
+	address, err := netip.ParseAddr(ip)
+
+	if err != nil {
+		return ""
+	}
+
+	country, _ := widget.Providers.GeoIP.LookupCountry(address)
+
+	return country
+}
+
+func topBansCounts(counts map[string]int) []bansCount {
+	top := make([]bansCount, 0, len(counts))
+
+	for name, count := range counts {
+		top = append(top, bansCount{Name: name, Count: count})
+	}
+
+	slices.SortFunc(top, func(a, b bansCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return top[:min(5, len(top))]
+}
+
+func (widget *Bans) Update(ctx context.Context) {
+	results, err := feed.FetchBansForSources(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	bans := make([]bansItem, 0)
+	byReason := make(map[string]int)
+	byCountry := make(map[string]int)
+
+	for i := range results {
+		for j := range results[i] {
+			ban := bansItem{Ban: results[i][j], Source: widget.Sources[i].Name}
+
+			if ban.Country == "" {
+				ban.Country = widget.lookupCountry(ban.IP)
+			}
+
+			byReason[ban.Reason]++
+
+			if ban.Country != "" {
+				byCountry[ban.Country]++
+			}
+
+			bans = append(bans, ban)
+		}
+	}
+
+	slices.SortFunc(bans, func(a, b bansItem) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	widget.Total = len(bans)
+	widget.ByReason = topBansCounts(byReason)
+	widget.ByCountry = topBansCounts(byCountry)
+	widget.Bans = bans[:min(widget.Limit, len(bans))]
+}
+
+func (widget *Bans) Render() template.HTML {
+	return widget.render(widget, assets.BansTemplate)
+}
@@ -1,34 +1,185 @@
+//go:build !slim || widget_bookmarks
+
 package widget
 
 import (
+	"context"
+	"errors"
 	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("bookmarks", func() Widget { return &Bookmarks{} })
+}
+
+type bookmarkLink struct {
+	Title     string     `yaml:"title"`
+	URL       string     `yaml:"url"`
+	Icon      CustomIcon `yaml:"icon"`
+	SameTab   bool       `yaml:"same-tab"`
+	HideArrow bool       `yaml:"hide-arrow"`
+	// only used when checking the health of links
+	CheckURL      string `yaml:"check-url"`
+	AllowInsecure bool   `yaml:"allow-insecure"`
+	IsDown        bool   `yaml:"-"`
+}
+
+type bookmarkGroup struct {
+	Title string         `yaml:"title"`
+	Color *HSLColorField `yaml:"color"`
+	Links []bookmarkLink `yaml:"links"`
+}
+
 type Bookmarks struct {
-	widgetBase `yaml:",inline"`
-	cachedHTML template.HTML `yaml:"-"`
-	Groups     []struct {
-		Title string         `yaml:"title"`
-		Color *HSLColorField `yaml:"color"`
-		Links []struct {
-			Title     string     `yaml:"title"`
-			URL       string     `yaml:"url"`
-			Icon      CustomIcon `yaml:"icon"`
-			SameTab   bool       `yaml:"same-tab"`
-			HideArrow bool       `yaml:"hide-arrow"`
-		} `yaml:"links"`
-	} `yaml:"groups"`
+	widgetBase   `yaml:",inline"`
+	cachedHTML   template.HTML     `yaml:"-"`
+	Groups       []bookmarkGroup   `yaml:"groups"`
+	Discovery    *serviceDiscovery `yaml:"discovery"`
+	CheckHealth  bool              `yaml:"check-health"`
+	staticGroups []bookmarkGroup   `yaml:"-"`
 }
 
 func (widget *Bookmarks) Initialize() error {
-	widget.withTitle("Bookmarks").withError(nil)
-	widget.cachedHTML = widget.render(widget, assets.BookmarksTemplate)
+	widget.withTitle("Bookmarks")
+
+	if widget.Discovery == nil && !widget.CheckHealth {
+		widget.withError(nil)
+		widget.cachedHTML = widget.render(widget, assets.BookmarksTemplate)
+
+		return nil
+	}
+
+	if widget.Discovery != nil {
+		if err := widget.Discovery.validate(); err != nil {
+			return err
+		}
+
+		widget.withCacheDuration(time.Minute)
+	} else {
+		widget.withCacheDuration(5 * time.Minute)
+	}
+
+	widget.staticGroups = widget.Groups
 
 	return nil
 }
 
+func (widget *Bookmarks) Update(ctx context.Context) {
+	var err error
+
+	if widget.Discovery != nil {
+		var services feed.DiscoveredServices
+		services, err = widget.Discovery.discover(ctx)
+
+		if err == nil || errors.Is(err, feed.ErrPartialContent) {
+			widget.Groups = mergeDiscoveredServicesIntoBookmarks(widget.staticGroups, services)
+		}
+	}
+
+	if widget.CheckHealth {
+		widget.checkLinksHealth(ctx)
+	}
+
+	widget.canContinueUpdateAfterHandlingErr(err)
+	widget.cachedHTML = widget.render(widget, assets.BookmarksTemplate)
+}
+
+// Uses HEAD requests and only considers links to be down when they can't be reached
+// or respond with a server error, since plenty of sites don't allow HEAD requests
+// or require authentication, both of which still mean that they're up
+func (widget *Bookmarks) checkLinksHealth(ctx context.Context) {
+	requests := make([]*feed.SiteStatusRequest, 0)
+	links := make([]*bookmarkLink, 0)
+
+	for g := range widget.Groups {
+		for l := range widget.Groups[g].Links {
+			link := &widget.Groups[g].Links[l]
+			url := link.URL
+
+			if link.CheckURL != "" {
+				url = link.CheckURL
+			}
+
+			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+				continue
+			}
+
+			requests = append(requests, &feed.SiteStatusRequest{
+				URL:           url,
+				AllowInsecure: link.AllowInsecure,
+				Method:        http.MethodHead,
+			})
+			links = append(links, link)
+		}
+	}
+
+	if len(requests) == 0 {
+		return
+	}
+
+	statuses, err := feed.FetchStatusForSites(ctx, requests)
+
+	if err != nil {
+		slog.Error("Failed to check the health of bookmarks", "error", err)
+		return
+	}
+
+	for i := range statuses {
+		links[i].IsDown = statuses[i].Error != nil || statuses[i].Code >= 500
+	}
+}
+
+// Discovered services get added to the group with the same title if one
+// exists, otherwise new groups are created after the static ones
+func mergeDiscoveredServicesIntoBookmarks(static []bookmarkGroup, services feed.DiscoveredServices) []bookmarkGroup {
+	groups := make([]bookmarkGroup, len(static), len(static)+len(services))
+
+	for i := range static {
+		groups[i] = static[i]
+		groups[i].Links = append([]bookmarkLink(nil), static[i].Links...)
+	}
+
+	for i := range services {
+		service := &services[i]
+		index := -1
+
+		for g := range groups {
+			if groups[g].Title == service.Group {
+				index = g
+				break
+			}
+		}
+
+		if index == -1 {
+			groups = append(groups, bookmarkGroup{Title: service.Group})
+			index = len(groups) - 1
+		}
+
+		groups[index].Links = append(groups[index].Links, bookmarkLink{
+			Title: service.Name,
+			URL:   service.URL,
+			Icon:  newCustomIconFromString(service.Icon),
+		})
+	}
+
+	return groups
+}
+
 func (widget *Bookmarks) Render() template.HTML {
 	return widget.cachedHTML
 }
+
+func (widget *Bookmarks) resourceHints(hints *ResourceHints) {
+	for g := range widget.Groups {
+		for l := range widget.Groups[g].Links {
+			hints.addOrigin(widget.Groups[g].Links[l].Icon.URL)
+		}
+	}
+}
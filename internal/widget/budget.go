@@ -0,0 +1,111 @@
+//go:build !slim || widget_budget
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("budget", func() Widget { return &Budget{} })
+}
+
+type Budget struct {
+	widgetBase         `yaml:",inline"`
+	httpClientOptions  `yaml:",inline"`
+	Service            string            `yaml:"service"`
+	URL                URLField          `yaml:"url"`
+	Token              OptionalEnvString `yaml:"token"`
+	BudgetID           OptionalEnvString `yaml:"budget-id"`
+	EncryptionPassword OptionalEnvString `yaml:"encryption-password"`
+	Categories         []string          `yaml:"categories"`
+	Currency           string            `yaml:"currency"`
+	Summary            *feed.Budget      `yaml:"-"`
+}
+
+func (widget *Budget) Initialize() error {
+	widget.withTitle("Budget").withTitleURL(string(widget.URL)).withCacheDuration(30 * time.Minute)
+
+	switch widget.Service {
+	case "firefly":
+	case "actual":
+		if widget.BudgetID == "" {
+			return errors.New("budget-id must be specified for budget widget when using actual")
+		}
+	default:
+		return errors.New("budget service must be either 'firefly' or 'actual'")
+	}
+
+	if widget.URL == "" || widget.Token == "" {
+		return errors.New("url and token must be specified for budget widget")
+	}
+
+	if err := withDefaultCurrency(&widget.Currency); err != nil {
+		return fmt.Errorf("budget widget: %v", err)
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("budget widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Budget) Update(ctx context.Context) {
+	var budget *feed.Budget
+	var err error
+
+	if widget.Service == "firefly" {
+		budget, err = feed.FetchBudgetFromFirefly(ctx, widget.client, string(widget.URL), widget.Token.String())
+	} else {
+		budget, err = feed.FetchBudgetFromActual(ctx, widget.client, string(widget.URL), widget.Token.String(), widget.BudgetID.String(), widget.EncryptionPassword.String())
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	if budget.Currency == "" {
+		budget.Currency = feed.CurrencySymbol(widget.Currency)
+	}
+
+	budget.Categories = widget.filterCategories(budget.Categories)
+	widget.Summary = budget
+}
+
+// Categories that are listed get shown in the same order, otherwise the
+// ones that have nothing budgeted or spent this month are left out
+func (widget *Budget) filterCategories(categories []feed.BudgetCategory) []feed.BudgetCategory {
+	if len(widget.Categories) == 0 {
+		return slices.DeleteFunc(categories, func(c feed.BudgetCategory) bool {
+			return c.Budgeted == 0 && c.Spent == 0
+		})
+	}
+
+	filtered := make([]feed.BudgetCategory, 0, len(widget.Categories))
+
+	for _, name := range widget.Categories {
+		index := slices.IndexFunc(categories, func(c feed.BudgetCategory) bool {
+			return strings.EqualFold(c.Name, name)
+		})
+
+		if index != -1 {
+			filtered = append(filtered, categories[index])
+		}
+	}
+
+	return filtered
+}
+
+func (widget *Budget) Render() template.HTML {
+	return widget.render(widget, assets.BudgetTemplate)
+}
@@ -0,0 +1,175 @@
+//go:build !slim || widget_calendar_events
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("calendar-events", func() Widget { return &CalendarEvents{} })
+}
+
+type calendarEventsDay struct {
+	Date   time.Time
+	Label  string
+	Events feed.CalendarEvents
+}
+
+type CalendarEvents struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Calendars         []struct {
+		URL  OptionalEnvString `yaml:"url"`
+		Name string            `yaml:"name"`
+	} `yaml:"calendars"`
+	Days          int                 `yaml:"days"`
+	Timezone      string              `yaml:"timezone"`
+	HourFormat    string              `yaml:"hour-format"`
+	Limit         int                 `yaml:"limit"`
+	CollapseAfter int                 `yaml:"collapse-after"`
+	Groups        []calendarEventsDay `yaml:"-"`
+	location      *time.Location      `yaml:"-"`
+}
+
+func (widget *CalendarEvents) Initialize() error {
+	widget.withTitle("Upcoming Events").withCacheDuration(1 * time.Hour)
+
+	if len(widget.Calendars) == 0 {
+		return errors.New("no calendars specified for calendar-events widget")
+	}
+
+	for i := range widget.Calendars {
+		if widget.Calendars[i].URL == "" {
+			return errors.New("missing url for calendar in calendar-events widget")
+		}
+	}
+
+	if widget.Days <= 0 {
+		widget.Days = 7
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 50
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 3
+	}
+
+	if widget.HourFormat == "" {
+		widget.HourFormat = "24h"
+	} else if widget.HourFormat != "12h" && widget.HourFormat != "24h" {
+		return fmt.Errorf("invalid hour format '%s' for calendar-events widget, must be either 12h or 24h", widget.HourFormat)
+	}
+
+	widget.location = time.Local
+
+	if widget.Timezone != "" {
+		location, err := time.LoadLocation(widget.Timezone)
+
+		if err != nil {
+			return fmt.Errorf("invalid timezone '%s' for calendar-events widget: %v", widget.Timezone, err)
+		}
+
+		widget.location = location
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("calendar-events widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *CalendarEvents) Update(ctx context.Context) {
+	now := time.Now().In(widget.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, widget.location)
+	until := today.AddDate(0, 0, widget.Days)
+
+	requests := make([]*feed.CalendarEventsRequest, len(widget.Calendars))
+
+	for i := range widget.Calendars {
+		requests[i] = &feed.CalendarEventsRequest{
+			URL:      string(widget.Calendars[i].URL),
+			Name:     widget.Calendars[i].Name,
+			From:     now,
+			To:       until,
+			Location: widget.location,
+			Client:   widget.client,
+		}
+	}
+
+	events, err := feed.FetchCalendarEvents(ctx, requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	if len(events) > widget.Limit {
+		events = events[:widget.Limit]
+	}
+
+	widget.Groups = groupCalendarEventsByDay(events, today, widget.location)
+}
+
+// Events that started before today but are still ongoing get placed under today
+func groupCalendarEventsByDay(events feed.CalendarEvents, today time.Time, location *time.Location) []calendarEventsDay {
+	groups := make([]calendarEventsDay, 0, 7)
+
+	for i := range events {
+		event := &events[i]
+		startsAt := event.StartsAt
+
+		// all day events are dates without a timezone and should not be shifted
+		if !event.IsAllDay {
+			startsAt = startsAt.In(location)
+			event.StartsAt = startsAt
+			event.EndsAt = event.EndsAt.In(location)
+		}
+
+		day := time.Date(startsAt.Year(), startsAt.Month(), startsAt.Day(), 0, 0, 0, 0, location)
+
+		if day.Before(today) {
+			day = today
+		}
+
+		if len(groups) == 0 || !groups[len(groups)-1].Date.Equal(day) {
+			groups = append(groups, calendarEventsDay{
+				Date:  day,
+				Label: calendarEventsDayLabel(day, today),
+			})
+		}
+
+		groups[len(groups)-1].Events = append(groups[len(groups)-1].Events, *event)
+	}
+
+	return groups
+}
+
+func calendarEventsDayLabel(day, today time.Time) string {
+	if day.Equal(today) {
+		return "Today"
+	}
+
+	if day.Equal(today.AddDate(0, 0, 1)) {
+		return "Tomorrow"
+	}
+
+	return day.Format("Monday, January 2")
+}
+
+func (widget *CalendarEvents) Render() template.HTML {
+	return widget.render(widget, assets.CalendarEventsTemplate)
+}
+
+func (widget *CalendarEvents) isEmpty() bool {
+	return len(widget.Groups) == 0
+}
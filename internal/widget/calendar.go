@@ -1,3 +1,5 @@
+//go:build !slim || widget_calendar
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("calendar", func() Widget { return &Calendar{} })
+}
+
 type Calendar struct {
 	widgetBase `yaml:",inline"`
 	Calendar   *feed.Calendar
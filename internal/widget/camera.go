@@ -0,0 +1,134 @@
+//go:build !slim || widget_camera
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("camera", func() Widget { return &Camera{} })
+}
+
+type cameraRemote struct {
+	URL           string            `yaml:"url"`
+	Token         OptionalEnvString `yaml:"token"`
+	AllowInsecure bool              `yaml:"allow-insecure"`
+}
+
+type Camera struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               OptionalEnvString `yaml:"url"`
+	Username          OptionalEnvString `yaml:"username"`
+	Password          OptionalEnvString `yaml:"password"`
+	Remote            *cameraRemote     `yaml:"remote"`
+	CapturedAt        time.Time         `yaml:"-"`
+	request           *feed.CameraSnapshotRequest
+	snapshotMu        sync.RWMutex
+	// kept in memory and served through the widget, so that the camera
+	// doesn't have to be reachable from the browser
+	snapshot *feed.CameraSnapshot
+}
+
+func (widget *Camera) Initialize() error {
+	widget.withTitle("Camera").withCacheDuration(time.Minute)
+
+	url := widget.URL.String()
+
+	if url == "" {
+		return errors.New("url must be specified for camera widget")
+	}
+
+	if !feed.IsRTSPURL(url) {
+		if err := validateURL(url); err != nil {
+			return fmt.Errorf("invalid url for camera widget, must be an http, https, rtsp or rtsps URL: %v", err)
+		}
+
+		if widget.Remote != nil {
+			return errors.New("remote can only be used with rtsp streams in camera widget")
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("camera widget: %v", err)
+	}
+
+	widget.request = &feed.CameraSnapshotRequest{
+		URL:      url,
+		Username: widget.Username.String(),
+		Password: widget.Password.String(),
+	}
+
+	if widget.Remote != nil {
+		if widget.Remote.URL == "" {
+			return errors.New("missing url for remote in camera widget")
+		}
+
+		widget.request.RemoteURL = widget.Remote.URL
+		widget.request.RemoteToken = widget.Remote.Token.String()
+		widget.request.RemoteAllowInsecure = widget.Remote.AllowInsecure
+	}
+
+	return nil
+}
+
+func (widget *Camera) Update(ctx context.Context) {
+	snapshot, err := feed.FetchCameraSnapshot(ctx, widget.client, widget.request)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.snapshotMu.Lock()
+	widget.snapshot = snapshot
+	widget.snapshotMu.Unlock()
+
+	widget.CapturedAt = time.Now()
+}
+
+func (widget *Camera) Render() template.HTML {
+	return widget.render(widget, assets.CameraTemplate)
+}
+
+// GET /snapshot responds with the image from the last update, the URL has the time it was
+// captured at in it so that browsers only load it again when there's a newer one
+func (widget *Camera) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("path") != "snapshot" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	widget.snapshotMu.RLock()
+	snapshot := widget.snapshot
+	widget.snapshotMu.RUnlock()
+
+	if snapshot == nil {
+		http.Error(w, "no snapshot yet", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", snapshot.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(snapshot.Image)
+}
@@ -0,0 +1,183 @@
+//go:build !slim || widget_certificates
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("certificates", func() Widget { return &Certificates{} })
+}
+
+type certificatesSource struct {
+	Type string `yaml:"type"`
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+type certificatesItem struct {
+	feed.ManagedCertificate
+	Source         string
+	DaysLeft       int
+	Expired        bool
+	RenewalOverdue bool
+}
+
+func (c *certificatesItem) Name() string {
+	if len(c.Domains) == 0 {
+		return "Unknown"
+	}
+
+	return c.Domains[0]
+}
+
+type Certificates struct {
+	widgetBase    `yaml:",inline"`
+	Sources       []certificatesSource `yaml:"sources"`
+	CollapseAfter int                  `yaml:"collapse-after"`
+	Certificates  []certificatesItem   `yaml:"-"`
+	// the ones which expired or failed to renew
+	NeedAttention int `yaml:"-"`
+}
+
+// Where Caddy keeps its data when it's not told otherwise, which is /data/caddy in its container
+func defaultCaddyDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "caddy")
+	}
+
+	home, _ := os.UserHomeDir()
+
+	return filepath.Join(home, ".local", "share", "caddy")
+}
+
+func (widget *Certificates) Initialize() error {
+	widget.withTitle("Certificates").withCacheDuration(time.Hour)
+
+	if len(widget.Sources) == 0 {
+		return fmt.Errorf("no sources specified for certificates widget")
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		switch source.Type {
+		case "traefik":
+			if source.Path == "" {
+				return fmt.Errorf("path to acme.json must be specified for traefik source in certificates widget")
+			}
+
+			if source.Name == "" {
+				source.Name = "Traefik"
+			}
+		case "caddy":
+			if source.Path == "" {
+				source.Path = defaultCaddyDataDir()
+			}
+
+			if source.Name == "" {
+				source.Name = "Caddy"
+			}
+		case "certbot":
+			if source.Path == "" {
+				source.Path = "/etc/letsencrypt"
+			}
+
+			if source.Name == "" {
+				source.Name = "Certbot"
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in certificates widget, must be one of traefik, caddy or certbot", source.Type)
+		}
+	}
+
+	return nil
+}
+
+func (widget *Certificates) Update(ctx context.Context) {
+	now := time.Now()
+	certificates := make([]certificatesItem, 0)
+	var failed int
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+		var read []feed.ManagedCertificate
+		var err error
+
+		switch source.Type {
+		case "traefik":
+			read, err = feed.ReadTraefikCertificates(source.Path)
+		case "caddy":
+			read, err = feed.ReadCaddyCertificates(source.Path)
+		case "certbot":
+			read, err = feed.ReadCertbotCertificates(source.Path)
+		}
+
+		if err != nil {
+			failed++
+			slog.Error("Failed to read certificates", "source", source.Name, "path", source.Path, "error", err)
+			continue
+		}
+
+		for j := range read {
+			certificates = append(certificates, certificatesItem{
+				ManagedCertificate: read[j],
+				Source:             source.Name,
+				DaysLeft:           int(read[j].NotAfter.Sub(now).Hours() / 24),
+				Expired:            read[j].IsExpired(now),
+				RenewalOverdue:     read[j].IsRenewalOverdue(now),
+			})
+		}
+	}
+
+	var err error
+
+	if failed == len(widget.Sources) {
+		err = fmt.Errorf("%w: could not read certificates from any source", feed.ErrNoContent)
+	} else if failed > 0 {
+		err = fmt.Errorf("%w: could not read certificates from %d source(s)", feed.ErrPartialContent, failed)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	// the ones which expire the soonest first
+	slices.SortFunc(certificates, func(a, b certificatesItem) int {
+		if c := a.NotAfter.Compare(b.NotAfter); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	widget.NeedAttention = 0
+
+	for i := range certificates {
+		if certificates[i].Expired || certificates[i].RenewalOverdue {
+			widget.NeedAttention++
+		}
+	}
+
+	widget.Certificates = certificates
+}
+
+func (widget *Certificates) Render() template.HTML {
+	return widget.render(widget, assets.CertificatesTemplate)
+}
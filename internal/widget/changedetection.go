@@ -1,26 +1,50 @@
+//go:build !slim || widget_change_detection
+
 package widget
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/glanceapp/glance/internal/assets"
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("change-detection", func() Widget { return &ChangeDetection{} })
+}
+
+const (
+	// changes older than the newest ones get dropped once there are this many
+	changeDetectionMaxWebhookItems  = 100
+	changeDetectionMaxWebhookSize   = 64 << 10
+	changeDetectionMaxMessageLength = 1000
+)
+
 type ChangeDetection struct {
 	widgetBase       `yaml:",inline"`
 	ChangeDetections feed.ChangeDetectionWatches `yaml:"-"`
 	WatchUUIDs       []string                    `yaml:"watches"`
 	InstanceURL      string                      `yaml:"instance-url"`
 	Token            OptionalEnvString           `yaml:"token"`
-	Limit            int                         `yaml:"limit"`
-	CollapseAfter    int                         `yaml:"collapse-after"`
+	// when set, the changes are received through /api/webhooks/{token}
+	// rather than being fetched from the API of the instance
+	WebhookToken  OptionalEnvString `yaml:"webhook-token"`
+	Limit         int               `yaml:"limit"`
+	CollapseAfter int               `yaml:"collapse-after"`
 }
 
 func (widget *ChangeDetection) Initialize() error {
-	widget.withTitle("Change Detection").withCacheDuration(1 * time.Hour)
+	widget.withTitle("Change Detection")
 
 	if widget.Limit <= 0 {
 		widget.Limit = 10
@@ -30,6 +54,14 @@ func (widget *ChangeDetection) Initialize() error {
 		widget.CollapseAfter = 5
 	}
 
+	if widget.WebhookToken != "" {
+		// nothing to update, the changes are read from the storage when rendering
+		widget.withError(nil)
+		return nil
+	}
+
+	widget.withCacheDuration(1 * time.Hour)
+
 	if widget.InstanceURL == "" {
 		widget.InstanceURL = "https://www.changedetection.io"
 	}
@@ -39,7 +71,7 @@ func (widget *ChangeDetection) Initialize() error {
 
 func (widget *ChangeDetection) Update(ctx context.Context) {
 	if len(widget.WatchUUIDs) == 0 {
-		uuids, err := feed.FetchWatchUUIDsFromChangeDetection(widget.InstanceURL, string(widget.Token))
+		uuids, err := feed.FetchWatchUUIDsFromChangeDetection(ctx, widget.InstanceURL, string(widget.Token))
 
 		if !widget.canContinueUpdateAfterHandlingErr(err) {
 			return
@@ -48,7 +80,7 @@ func (widget *ChangeDetection) Update(ctx context.Context) {
 		widget.WatchUUIDs = uuids
 	}
 
-	watches, err := feed.FetchWatchesFromChangeDetection(widget.InstanceURL, widget.WatchUUIDs, string(widget.Token))
+	watches, err := feed.FetchWatchesFromChangeDetection(ctx, widget.InstanceURL, widget.WatchUUIDs, string(widget.Token))
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -61,6 +93,110 @@ func (widget *ChangeDetection) Update(ctx context.Context) {
 	widget.ChangeDetections = watches
 }
 
+// Widgets with the same webhook token show the same changes
+func (widget *ChangeDetection) storageKey() string {
+	return "change-detection:" + widget.WebhookToken.String()
+}
+
 func (widget *ChangeDetection) Render() template.HTML {
+	if widget.WebhookToken != "" {
+		var watches feed.ChangeDetectionWatches
+
+		if err := widget.Providers.Storage.get(widget.storageKey(), &watches); err != nil {
+			slog.Error("Failed to read changes received through webhook", "error", err)
+		}
+
+		if len(watches) > widget.Limit {
+			watches = watches[:widget.Limit]
+		}
+
+		widget.ChangeDetections = watches
+	}
+
 	return widget.render(widget, assets.ChangeDetectionTemplate)
 }
+
+func (widget *ChangeDetection) webhookToken() string {
+	return widget.WebhookToken.String()
+}
+
+// Besides its own fields, the title and message fields of the JSON sent by
+// Apprise are accepted so that changedetection.io can send notifications
+// directly through its json:// and jsons:// notification URLs
+type changeDetectionWebhookRequest struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	DiffURL string `json:"diff-url"`
+	Message string `json:"message"`
+}
+
+func isWebhookURLValid(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(value)
+
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func (widget *ChangeDetection) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	var request changeDetectionWebhookRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, changeDetectionMaxWebhookSize)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	request.Title = strings.TrimSpace(request.Title)
+	request.Message = strings.TrimSpace(request.Message)
+
+	var invalid error
+
+	switch {
+	case !isWebhookURLValid(request.URL) || !isWebhookURLValid(request.DiffURL):
+		invalid = errors.New("urls must start with http:// or https://")
+	case request.Title == "" && request.URL == "":
+		invalid = errors.New("either a title or a url is required")
+	case utf8.RuneCountInString(request.Message) > changeDetectionMaxMessageLength:
+		invalid = fmt.Errorf("message cannot be longer than %d characters", changeDetectionMaxMessageLength)
+	}
+
+	if invalid != nil {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Title == "" {
+		parsed, _ := url.Parse(request.URL)
+		request.Title = strings.TrimPrefix(parsed.Host, "www.") + strings.TrimRight(parsed.Path, "/")
+	}
+
+	var watches feed.ChangeDetectionWatches
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &watches, func() error {
+		watches = append(feed.ChangeDetectionWatches{{
+			Title:       request.Title,
+			URL:         request.URL,
+			DiffURL:     request.DiffURL,
+			Message:     request.Message,
+			LastChanged: time.Now(),
+		}}, watches...)
+
+		if len(watches) > changeDetectionMaxWebhookItems {
+			watches = watches[:changeDetectionMaxWebhookItems]
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to store change received through webhook", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// lets pages with live updates know that the widget has to be rendered again
+	widget.updates.Add(1)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+//go:build !slim || widget_chess
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("chess", func() Widget { return &Chess{} })
+}
+
+type Chess struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Service           string            `yaml:"service"`
+	Username          string            `yaml:"username"`
+	Token             OptionalEnvString `yaml:"token"`
+	CollapseAfter     int               `yaml:"collapse-after"`
+	Status            *feed.ChessStatus `yaml:"-"`
+}
+
+func (widget *Chess) Initialize() error {
+	if widget.Username == "" {
+		return errors.New("username must be specified for chess widget")
+	}
+
+	switch widget.Service {
+	case feed.ChessServiceLichess:
+		widget.withTitle("Lichess").withTitleURL("https://lichess.org/@/" + url.PathEscape(widget.Username))
+	case feed.ChessServiceChessCom:
+		if widget.Token != "" {
+			return errors.New("token is only used by lichess in chess widget")
+		}
+
+		widget.withTitle("Chess.com").withTitleURL("https://www.chess.com/member/" + url.PathEscape(widget.Username))
+	default:
+		return errors.New("service for chess widget must be either lichess or chess.com")
+	}
+
+	widget.withCacheDuration(15 * time.Minute)
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("chess widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Chess) Update(ctx context.Context) {
+	status, err := feed.FetchChessStatus(ctx, widget.client, widget.Service, widget.Username, widget.Token.String())
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Status = status
+}
+
+// Lichess needs a token to tell whose turn it is in ongoing games
+func (widget *Chess) ShowsGames() bool {
+	return widget.Service == feed.ChessServiceChessCom || widget.Token != ""
+}
+
+func (widget *Chess) Render() template.HTML {
+	return widget.render(widget, assets.ChessTemplate)
+}
@@ -0,0 +1,227 @@
+//go:build !slim || widget_chores
+
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("chores", func() Widget { return &Chores{} })
+}
+
+const choresMaxRequestSize = 4 << 10
+
+// Which chores were done in the period that's stored, which starts
+// over once the chores have been rotated to the next period
+type choresState struct {
+	Period string   `json:"period"`
+	Done   []string `json:"done"`
+}
+
+type chore struct {
+	Name     string
+	Assignee string
+	Next     string
+	Done     bool
+}
+
+type Chores struct {
+	widgetBase `yaml:",inline"`
+	StorageKey string    `yaml:"storage-key"`
+	Members    []string  `yaml:"members"`
+	ChoreNames []string  `yaml:"chores"`
+	Rotate     string    `yaml:"rotate"`
+	StartDate  time.Time `yaml:"start-date"`
+	Chores     []chore   `yaml:"-"`
+	PeriodEnds time.Time `yaml:"-"`
+}
+
+func (widget *Chores) Initialize() error {
+	widget.withTitle("Chores").withError(nil)
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	if len(widget.Members) == 0 || len(widget.ChoreNames) == 0 {
+		return errors.New("members and chores must be specified for chores widget")
+	}
+
+	for i := range widget.ChoreNames {
+		widget.ChoreNames[i] = strings.TrimSpace(widget.ChoreNames[i])
+
+		if widget.ChoreNames[i] == "" {
+			return errors.New("chores cannot be empty")
+		}
+
+		if slices.Contains(widget.ChoreNames[:i], widget.ChoreNames[i]) {
+			return fmt.Errorf("chore %s is specified more than once", widget.ChoreNames[i])
+		}
+	}
+
+	switch widget.Rotate {
+	case "":
+		widget.Rotate = "weekly"
+	case "daily", "weekly":
+	default:
+		return errors.New("rotate must be either daily or weekly")
+	}
+
+	if widget.StartDate.IsZero() {
+		// a Monday, so that weeks start on Monday
+		widget.StartDate = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return nil
+}
+
+// Widgets with the same storage key share which chores are done
+func (widget *Chores) storageKey() string {
+	return "chores:" + widget.StorageKey
+}
+
+// How many periods have passed since the start date, along with the date
+// that the current period started on. Weeks start on Monday
+func (widget *Chores) period(now time.Time) (int, time.Time) {
+	// dates in UTC so that every day is 24 hours long
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(widget.StartDate.Year(), widget.StartDate.Month(), widget.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	length := 1
+
+	if widget.Rotate == "weekly" {
+		today = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		length = 7
+	}
+
+	days := int(today.Sub(start).Hours() / 24)
+	index := days / length
+
+	// the start date is in the future
+	if days < 0 {
+		index = (days - length + 1) / length
+	}
+
+	return index, today
+}
+
+func (widget *Chores) assignee(choreIndex, period int) string {
+	count := len(widget.Members)
+
+	return widget.Members[((choreIndex+period)%count+count)%count]
+}
+
+// Forgets what was done in previous periods
+func (widget *Chores) withCurrentPeriod(state *choresState, now time.Time) {
+	_, start := widget.period(now)
+	period := start.Format(time.DateOnly)
+
+	if state.Period != period {
+		state.Period = period
+		state.Done = nil
+	}
+}
+
+func (widget *Chores) Render() template.HTML {
+	var state choresState
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &state); err != nil {
+		slog.Error("Failed to read chores", "key", widget.StorageKey, "error", err)
+	}
+
+	now := time.Now()
+	widget.withCurrentPeriod(&state, now)
+	period, start := widget.period(now)
+
+	if widget.Rotate == "weekly" {
+		widget.PeriodEnds = start.AddDate(0, 0, 7)
+	} else {
+		widget.PeriodEnds = start.AddDate(0, 0, 1)
+	}
+
+	widget.Chores = make([]chore, len(widget.ChoreNames))
+
+	for i, name := range widget.ChoreNames {
+		widget.Chores[i] = chore{
+			Name:     name,
+			Assignee: widget.assignee(i, period),
+			Next:     widget.assignee(i, period+1),
+			Done:     slices.Contains(state.Done, name),
+		}
+	}
+
+	return widget.render(widget, assets.ChoresTemplate)
+}
+
+type choresRequest struct {
+	Chore string `json:"chore"`
+	Done  bool   `json:"done"`
+}
+
+// POST /done marks the chore in the body as done, or not done, for the current period
+func (widget *Chores) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("path") != "done" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request choresRequest
+
+	if err := decodeJSONRequest(w, r, &request, choresMaxRequestSize); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !slices.Contains(widget.ChoreNames, request.Chore) {
+		http.Error(w, "unknown chore", http.StatusBadRequest)
+		return
+	}
+
+	var state choresState
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+		widget.withCurrentPeriod(&state, time.Now())
+		state.Done = slices.DeleteFunc(state.Done, func(name string) bool {
+			// chores which have been removed from the config since
+			return name == request.Chore || !slices.Contains(widget.ChoreNames, name)
+		})
+
+		if request.Done {
+			state.Done = append(state.Done, request.Chore)
+		}
+
+		return nil
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err != nil {
+		slog.Error("Failed to update chores", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if state.Done == nil {
+		state.Done = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&state)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_clock
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/assets"
 )
 
+func init() {
+	register("clock", func() Widget { return &Clock{} })
+}
+
 type Clock struct {
 	widgetBase `yaml:",inline"`
 	cachedHTML template.HTML `yaml:"-"`
@@ -0,0 +1,60 @@
+//go:build !slim || widget_cloudflare
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("cloudflare", func() Widget { return &Cloudflare{} })
+}
+
+type Cloudflare struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	APIToken          OptionalEnvString `yaml:"api-token"`
+	Zones             []string          `yaml:"zones"`
+	AccountID         string            `yaml:"account-id"`
+	HideHostnames     bool              `yaml:"hide-hostnames"`
+	Data              *feed.Cloudflare  `yaml:"-"`
+}
+
+func (widget *Cloudflare) Initialize() error {
+	widget.withTitle("Cloudflare").withTitleURL("https://dash.cloudflare.com").withCacheDuration(5 * time.Minute)
+
+	if widget.APIToken == "" {
+		return errors.New("api-token must be specified for cloudflare widget")
+	}
+
+	if len(widget.Zones) == 0 && widget.AccountID == "" {
+		return errors.New("either zones or account-id must be specified for cloudflare widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("cloudflare widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Cloudflare) Update(ctx context.Context) {
+	data, err := feed.FetchCloudflare(ctx, widget.client, widget.APIToken.String(), widget.Zones, widget.AccountID)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Data = data
+}
+
+func (widget *Cloudflare) Render() template.HTML {
+	return widget.render(widget, assets.CloudflareTemplate)
+}
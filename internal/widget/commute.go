@@ -0,0 +1,166 @@
+//go:build !slim || widget_commute
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("commute", func() Widget { return &Commute{} })
+}
+
+// How many times the route has to have been checked before the usual duration
+// is worked out from the history rather than taken from the provider
+const commuteMinHistory = 6
+
+type Commute struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Provider          string             `yaml:"provider"`
+	URL               string             `yaml:"url"`
+	APIKey            OptionalEnvString  `yaml:"api-key"`
+	FromValue         string             `yaml:"from"`
+	ToValue           string             `yaml:"to"`
+	Times             []TimeWindow       `yaml:"times"`
+	WorseBy           int                `yaml:"worse-by"`
+	Route             *feed.CommuteRoute `yaml:"-"`
+	// the median of the durations from the past week, 0 when not known
+	Usual     time.Duration `yaml:"-"`
+	CheckedAt time.Time     `yaml:"-"`
+	from      feed.Coordinates
+	to        feed.Coordinates
+}
+
+func (widget *Commute) Initialize() error {
+	widget.withTitle("Commute").withCacheDuration(5 * time.Minute)
+
+	switch widget.Provider {
+	case feed.CommuteProviderOSRM:
+		if widget.URL == "" {
+			return errors.New("url must be specified for osrm in commute widget")
+		}
+	case feed.CommuteProviderGoogle, feed.CommuteProviderHERE:
+		if widget.APIKey == "" {
+			return fmt.Errorf("api-key must be specified for %s in commute widget", widget.Provider)
+		}
+	default:
+		return errors.New("provider for commute widget must be one of osrm, google or here")
+	}
+
+	var err error
+
+	if widget.from, err = feed.ParseCoordinates(widget.FromValue); err != nil {
+		return fmt.Errorf("invalid from '%s' for commute widget: %v", widget.FromValue, err)
+	}
+
+	if widget.to, err = feed.ParseCoordinates(widget.ToValue); err != nil {
+		return fmt.Errorf("invalid to '%s' for commute widget: %v", widget.ToValue, err)
+	}
+
+	if widget.WorseBy <= 0 {
+		widget.WorseBy = 20
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("commute widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Commute) historyKey() string {
+	return "commute:" + widget.Provider + ":" + widget.from.String() + ":" + widget.to.String()
+}
+
+// Whether the route should be checked right now, which is always the case when
+// no times are set
+func (widget *Commute) isCommuteTime(now time.Time) bool {
+	if len(widget.Times) == 0 {
+		return true
+	}
+
+	for i := range widget.Times {
+		if widget.Times[i].contains(now) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (widget *Commute) Update(ctx context.Context) {
+	now := time.Now()
+
+	// the last duration keeps being shown outside of the commute times, so that
+	// the provider doesn't get asked for it when nobody's going anywhere
+	if widget.Route != nil && !widget.isCommuteTime(now) {
+		widget.scheduleNextUpdate()
+		return
+	}
+
+	route, err := feed.FetchCommuteRoute(ctx, &feed.CommuteRequest{
+		Provider: widget.Provider,
+		URL:      widget.URL,
+		APIKey:   widget.APIKey.String(),
+		From:     widget.from,
+		To:       widget.to,
+		Client:   widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	history := widget.Providers.History
+	key := widget.historyKey()
+	// taken before recording the current one so that it doesn't count towards what's usual
+	past := history.Values(key, now.Add(-historyRetention))
+	history.Record(key, route.Duration.Seconds(), now)
+
+	widget.Usual = route.Baseline
+
+	if len(past) >= commuteMinHistory {
+		slices.Sort(past)
+		widget.Usual = time.Duration(past[len(past)/2] * float64(time.Second))
+	}
+
+	widget.Route = route
+	widget.CheckedAt = now
+}
+
+func (widget *Commute) IsWorse() bool {
+	if widget.Route == nil || widget.Usual <= 0 {
+		return false
+	}
+
+	// a couple of minutes more on a short route isn't worth flagging
+	difference := widget.Route.Duration - widget.Usual
+
+	return difference >= 2*time.Minute && difference*100 >= widget.Usual*time.Duration(widget.WorseBy)
+}
+
+func (widget *Commute) Delay() time.Duration {
+	return (widget.Route.Duration - widget.Usual).Round(time.Minute)
+}
+
+func (widget *Commute) DistanceLabel() string {
+	return fmt.Sprintf("%.1f km", widget.Route.Distance/1000)
+}
+
+// Whether the duration is from earlier, because the route isn't checked outside of the commute times
+func (widget *Commute) IsOutdated() bool {
+	return !widget.isCommuteTime(time.Now())
+}
+
+func (widget *Commute) Render() template.HTML {
+	return widget.render(widget, assets.CommuteTemplate)
+}
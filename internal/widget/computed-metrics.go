@@ -0,0 +1,196 @@
+//go:build !slim || widget_computed_metrics
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+	"github.com/tidwall/gjson"
+)
+
+func init() {
+	register("computed-metrics", func() Widget { return &ComputedMetrics{} })
+}
+
+type computedMetric struct {
+	Label      string         `yaml:"label"`
+	Expression string         `yaml:"expression"`
+	Prefix     string         `yaml:"prefix"`
+	Suffix     string         `yaml:"suffix"`
+	Decimals   *int           `yaml:"decimals"`
+	Thresholds thresholds     `yaml:"thresholds"`
+	Value      float64        `yaml:"-"`
+	Threshold  *threshold     `yaml:"-"`
+	Error      string         `yaml:"-"`
+	expression expressionNode `yaml:"-"`
+}
+
+// Whole numbers are shown without decimals unless specified otherwise
+func (m *computedMetric) DecimalPlaces() int {
+	if m.Decimals != nil {
+		return *m.Decimals
+	}
+
+	if m.Value == math.Trunc(m.Value) {
+		return 0
+	}
+
+	return 2
+}
+
+type ComputedMetrics struct {
+	widgetBase `yaml:",inline"`
+	Metrics    []computedMetric `yaml:"metrics"`
+	sources    []string         `yaml:"-"`
+}
+
+func (widget *ComputedMetrics) Initialize() error {
+	widget.withTitle("Metrics").withCacheDuration(1 * time.Minute)
+
+	if len(widget.Metrics) == 0 {
+		return errors.New("no metrics specified for computed-metrics widget")
+	}
+
+	seen := make(map[string]bool)
+
+	for i := range widget.Metrics {
+		metric := &widget.Metrics[i]
+
+		if metric.Expression == "" {
+			return fmt.Errorf("missing expression for metric %d in computed-metrics widget", i+1)
+		}
+
+		expression, err := parseExpression(metric.Expression)
+
+		if err != nil {
+			return fmt.Errorf("invalid expression for metric %d in computed-metrics widget: %v", i+1, err)
+		}
+
+		metric.expression = expression
+
+		for _, source := range expressionSources(expression) {
+			if !seen[source] {
+				seen[source] = true
+				widget.sources = append(widget.sources, source)
+			}
+		}
+
+		if err := metric.Thresholds.validate(); err != nil {
+			return fmt.Errorf("invalid thresholds for metric %d in computed-metrics widget: %v", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func resolveExpressionReference(data map[string]gjson.Result, source, path string) (expressionValue, error) {
+	result, exists := data[source]
+
+	if !exists {
+		return expressionValue{}, fmt.Errorf("no data available from %s", source)
+	}
+
+	if path != "" {
+		result = result.Get(path)
+	}
+
+	if !result.Exists() {
+		return expressionValue{}, fmt.Errorf("nothing found at %s in %s", path, source)
+	}
+
+	if result.IsArray() {
+		values := result.Array()
+		list := make([]float64, len(values))
+
+		for i := range values {
+			list[i] = values[i].Float()
+		}
+
+		return expressionValue{list: list, isList: true}, nil
+	}
+
+	return expressionValue{number: result.Float()}, nil
+}
+
+func (widget *ComputedMetrics) Update(ctx context.Context) {
+	data := make(map[string]gjson.Result, len(widget.sources))
+
+	for _, source := range widget.sources {
+		sourceData, err := widget.Providers.DataBus.data(ctx, widget, source)
+
+		if err != nil {
+			slog.Error("Failed to get data for computed metrics", "source", source, "error", err)
+			continue
+		}
+
+		data[source] = gjson.ParseBytes(sourceData)
+	}
+
+	resolve := func(source, path string) (expressionValue, error) {
+		return resolveExpressionReference(data, source, path)
+	}
+
+	var failed int
+
+	for i := range widget.Metrics {
+		metric := &widget.Metrics[i]
+		metric.Threshold = noThreshold
+		metric.Error = ""
+
+		value, err := metric.expression.evaluate(resolve)
+
+		if err == nil {
+			metric.Value, err = value.scalar()
+		}
+
+		if err != nil {
+			failed++
+			metric.Error = err.Error()
+			continue
+		}
+
+		metric.Threshold = metric.Thresholds.Match(metric.Value)
+	}
+
+	if failed == len(widget.Metrics) {
+		err := fmt.Errorf("%w: could not compute any metrics", feed.ErrNoContent)
+		widget.canContinueUpdateAfterHandlingErr(err)
+		return
+	}
+
+	if failed > 0 {
+		err := fmt.Errorf("%w: could not compute %d metric(s)", feed.ErrPartialContent, failed)
+		widget.canContinueUpdateAfterHandlingErr(err)
+		return
+	}
+
+	widget.canContinueUpdateAfterHandlingErr(nil)
+}
+
+func (widget *ComputedMetrics) sharedDataSources() []string {
+	return widget.sources
+}
+
+func (widget *ComputedMetrics) sharedData() any {
+	values := make(map[string]any, len(widget.Metrics))
+
+	for i := range widget.Metrics {
+		if widget.Metrics[i].Error == "" {
+			values[widget.Metrics[i].Label] = widget.Metrics[i].Value
+		}
+	}
+
+	return values
+}
+
+func (widget *ComputedMetrics) Render() template.HTML {
+	return widget.render(widget, assets.ComputedMetricsTemplate)
+}
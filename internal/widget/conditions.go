@@ -0,0 +1,168 @@
+//go:build !slim || widget_conditions
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("conditions", func() Widget { return &Conditions{} })
+}
+
+type conditionsSpot struct {
+	Name          string               `yaml:"name"`
+	URL           string               `yaml:"url"`
+	Kind          string               `yaml:"type"`
+	Provider      string               `yaml:"provider"`
+	Station       string               `yaml:"station"`
+	Resort        string               `yaml:"resort"`
+	LocationValue string               `yaml:"location"`
+	Elevation     int                  `yaml:"elevation"`
+	Months        []int                `yaml:"months"`
+	Conditions    *feed.SpotConditions `yaml:"-"`
+	request       *feed.SpotConditionsRequest
+}
+
+func (spot *conditionsSpot) inSeason(now time.Time) bool {
+	return len(spot.Months) == 0 || slices.Contains(spot.Months, int(now.Month()))
+}
+
+type Conditions struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Spots             []conditionsSpot `yaml:"spots"`
+	Units             feed.UnitSystem  `yaml:"units"`
+	CollapseAfter     int              `yaml:"collapse-after"`
+	// the spots that are in season
+	Visible []*conditionsSpot `yaml:"-"`
+}
+
+func (widget *Conditions) Initialize() error {
+	widget.withTitle("Conditions").withCacheDuration(time.Hour)
+
+	if len(widget.Spots) == 0 {
+		return errors.New("no spots specified for conditions widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("conditions widget: %v", err)
+	}
+
+	for i := range widget.Spots {
+		spot := &widget.Spots[i]
+
+		if spot.Name == "" {
+			return errors.New("missing name for spot in conditions widget")
+		}
+
+		if spot.Kind != feed.ConditionsKindWater && spot.Kind != feed.ConditionsKindSki {
+			return fmt.Errorf("type for spot %s in conditions widget must be either water or ski", spot.Name)
+		}
+
+		if providers := feed.ConditionsProvidersFor(spot.Kind); !slices.Contains(providers, spot.Provider) {
+			return fmt.Errorf("provider for %s spot %s in conditions widget must be one of %s", spot.Kind, spot.Name, strings.Join(providers, ", "))
+		}
+
+		for _, month := range spot.Months {
+			if month < 1 || month > 12 {
+				return fmt.Errorf("invalid month %d for spot %s in conditions widget, must be between 1 and 12", month, spot.Name)
+			}
+		}
+
+		spot.request = &feed.SpotConditionsRequest{
+			Name:      spot.Name,
+			Kind:      spot.Kind,
+			Provider:  spot.Provider,
+			Station:   spot.Station,
+			Resort:    spot.Resort,
+			Elevation: spot.Elevation,
+			Client:    widget.client,
+		}
+
+		switch spot.Provider {
+		case feed.ConditionsProviderOpenMeteo:
+			location, err := feed.ParseCoordinates(spot.LocationValue)
+
+			if err != nil {
+				return fmt.Errorf("invalid location '%s' for spot %s in conditions widget: %v", spot.LocationValue, spot.Name, err)
+			}
+
+			spot.request.Location = location
+		case feed.ConditionsProviderPegelOnline, feed.ConditionsProviderExistenz:
+			if spot.Station == "" {
+				return fmt.Errorf("station must be specified for %s spot %s in conditions widget", spot.Provider, spot.Name)
+			}
+		case feed.ConditionsProviderLiftie:
+			if spot.Resort == "" {
+				return fmt.Errorf("resort must be specified for liftie spot %s in conditions widget", spot.Name)
+			}
+		}
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("conditions widget: %v", err)
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *Conditions) Update(ctx context.Context) {
+	now := time.Now()
+	var visible []*conditionsSpot
+	var requests []*feed.SpotConditionsRequest
+
+	// spots that are out of season aren't fetched at all
+	for i := range widget.Spots {
+		if widget.Spots[i].inSeason(now) {
+			visible = append(visible, &widget.Spots[i])
+			requests = append(requests, widget.Spots[i].request)
+		}
+	}
+
+	var conditions []*feed.SpotConditions
+	var err error
+
+	if len(requests) > 0 {
+		conditions, err = feed.FetchSpotConditions(ctx, requests)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range visible {
+		visible[i].Conditions = conditions[i]
+	}
+
+	widget.Visible = visible
+}
+
+func (widget *Conditions) Temperature(celsius *float64) string {
+	return fmt.Sprintf("%.1f%s", widget.Units.Temperature(*celsius), widget.Units.TemperatureSymbol())
+}
+
+func (widget *Conditions) Snow(centimeters *float64) string {
+	if widget.Units == feed.ImperialUnits {
+		return fmt.Sprintf("%.0f in", *centimeters/2.54)
+	}
+
+	return fmt.Sprintf("%.0f cm", *centimeters)
+}
+
+func (widget *Conditions) Render() template.HTML {
+	return widget.render(widget, assets.ConditionsTemplate)
+}
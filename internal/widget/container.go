@@ -18,13 +18,14 @@ func (widget *containerWidgetBase) Update(ctx context.Context) {
 		widget := widget.Widgets[w]
 
 		if !widget.RequiresUpdate(&now) {
+			RecordCacheHit(widget)
 			continue
 		}
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			widget.Update(ctx)
+			UpdateIfRequired(ctx, widget, &now)
 		}()
 	}
 
@@ -37,6 +38,10 @@ func (widget *containerWidgetBase) SetProviders(providers *Providers) {
 	}
 }
 
+func (widget *containerWidgetBase) children() Widgets {
+	return widget.Widgets
+}
+
 func (widget *containerWidgetBase) RequiresUpdate(now *time.Time) bool {
 	for i := range widget.Widgets {
 		if widget.Widgets[i].RequiresUpdate(now) {
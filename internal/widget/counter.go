@@ -0,0 +1,153 @@
+//go:build !slim || widget_counter
+
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("counter", func() Widget { return &Counter{} })
+}
+
+// The period is when the count was last changed, as the date of the day or week it
+// started, so that it can be told whether the count has to start over
+type counterState struct {
+	Value  int    `json:"value"`
+	Period string `json:"period"`
+}
+
+type Counter struct {
+	widgetBase `yaml:",inline"`
+	StorageKey string       `yaml:"storage-key"`
+	Unit       string       `yaml:"unit"`
+	Step       int          `yaml:"step"`
+	Goal       int          `yaml:"goal"`
+	Reset      string       `yaml:"reset"`
+	State      counterState `yaml:"-"`
+}
+
+func (widget *Counter) Initialize() error {
+	widget.withTitle("Counter").withError(nil)
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	if widget.Step <= 0 {
+		widget.Step = 1
+	}
+
+	if widget.Goal < 0 {
+		widget.Goal = 0
+	}
+
+	switch widget.Reset {
+	case "":
+		widget.Reset = "never"
+	case "never", "daily", "weekly":
+	default:
+		return errors.New("reset must be one of never, daily or weekly")
+	}
+
+	return nil
+}
+
+// Widgets with the same storage key show the same count
+func (widget *Counter) storageKey() string {
+	return "counter:" + widget.StorageKey
+}
+
+func (widget *Counter) currentPeriod(now time.Time) string {
+	switch widget.Reset {
+	case "daily":
+		return now.Format(time.DateOnly)
+	case "weekly":
+		// weeks start on Monday
+		return now.AddDate(0, 0, -((int(now.Weekday()) + 6) % 7)).Format(time.DateOnly)
+	}
+
+	return ""
+}
+
+// Starts the count over if it was last changed in a previous period
+func (widget *Counter) withCurrentPeriod(state *counterState) {
+	period := widget.currentPeriod(time.Now())
+
+	if state.Period != period {
+		state.Value = 0
+		state.Period = period
+	}
+}
+
+func (widget *Counter) GoalPercent() float64 {
+	if widget.Goal == 0 {
+		return 0
+	}
+
+	return min(float64(widget.State.Value)/float64(widget.Goal)*100, 100)
+}
+
+func (widget *Counter) Render() template.HTML {
+	widget.State = counterState{}
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &widget.State); err != nil {
+		slog.Error("Failed to read counter", "key", widget.StorageKey, "error", err)
+	}
+
+	widget.withCurrentPeriod(&widget.State)
+
+	return widget.render(widget, assets.CounterTemplate)
+}
+
+// POST /increment and POST /decrement change the count by the step, which doesn't
+// go below 0, while POST /reset sets it back to 0. All of them respond with the count
+func (widget *Counter) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("path")
+
+	if action != "increment" && action != "decrement" && action != "reset" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var state counterState
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+		widget.withCurrentPeriod(&state)
+
+		switch action {
+		case "increment":
+			state.Value += widget.Step
+		case "decrement":
+			state.Value = max(state.Value-widget.Step, 0)
+		case "reset":
+			state.Value = 0
+		}
+
+		return nil
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err != nil {
+		slog.Error("Failed to update counter", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&state)
+}
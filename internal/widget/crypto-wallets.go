@@ -0,0 +1,71 @@
+//go:build !slim || widget_crypto_wallets
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("crypto-wallets", func() Widget { return &CryptoWallets{} })
+}
+
+// Balances are only kept in memory for as long as they're cached and never
+// get written to the storage or the history, since they say a lot about someone
+type CryptoWallets struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Requests          []feed.WalletRequest `yaml:"wallets"`
+	Currency          string               `yaml:"currency"`
+	ShowAddresses     bool                 `yaml:"show-addresses"`
+	Wallets           feed.Wallets         `yaml:"-"`
+}
+
+func (widget *CryptoWallets) Initialize() error {
+	widget.withTitle("Wallets").withCacheDuration(time.Hour)
+
+	if len(widget.Requests) == 0 {
+		return errors.New("no wallets specified for crypto-wallets widget")
+	}
+
+	for i := range widget.Requests {
+		if err := widget.Requests[i].Validate(); err != nil {
+			return fmt.Errorf("crypto-wallets widget: %v", err)
+		}
+	}
+
+	if err := withDefaultCurrency(&widget.Currency); err != nil {
+		return fmt.Errorf("crypto-wallets widget: %v", err)
+	}
+
+	if widget.Currency == "" {
+		widget.Currency = "USD"
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("crypto-wallets widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *CryptoWallets) Update(ctx context.Context) {
+	wallets, err := feed.FetchWalletBalances(ctx, widget.client, widget.Requests, widget.Currency)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Wallets = wallets
+}
+
+func (widget *CryptoWallets) Render() template.HTML {
+	return widget.render(widget, assets.CryptoWalletsTemplate)
+}
@@ -0,0 +1,73 @@
+//go:build !slim || widget_crypto
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("crypto", func() Widget { return &Crypto{} })
+}
+
+type Crypto struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Coins             []string     `yaml:"coins"`
+	Currency          string       `yaml:"currency"`
+	Sort              string       `yaml:"sort-by"`
+	Markets           feed.Markets `yaml:"-"`
+}
+
+func (widget *Crypto) Initialize() error {
+	widget.withTitle("Crypto").withCacheDuration(time.Hour)
+
+	if len(widget.Coins) == 0 {
+		return errors.New("no coins specified for crypto widget")
+	}
+
+	if err := withDefaultCurrency(&widget.Currency); err != nil {
+		return fmt.Errorf("crypto widget: %v", err)
+	}
+
+	if widget.Currency == "" {
+		widget.Currency = "USD"
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("crypto widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Crypto) Update(ctx context.Context) {
+	markets, err := feed.FetchCryptoMarketsFromCoinGecko(ctx, widget.client, widget.Coins, widget.Currency, widget.Language)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	sortMarkets(markets, widget.Sort)
+	widget.Markets = markets
+}
+
+func (widget *Crypto) sharedData() any {
+	return marketsSharedData(widget.Markets)
+}
+
+// Shares the template with the markets widget
+func (widget *Crypto) WatchlistEditable() bool {
+	return false
+}
+
+func (widget *Crypto) Render() template.HTML {
+	return widget.render(widget, assets.MarketsTemplate)
+}
@@ -0,0 +1,35 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+)
+
+func isValidCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func withDefaultCurrency(code *string) error {
+	if *code == "" {
+		*code = initializationSettings().Currency
+		return nil
+	}
+
+	*code = strings.ToUpper(*code)
+
+	if !isValidCurrencyCode(*code) {
+		return fmt.Errorf("invalid currency '%s', must be a three letter ISO 4217 code such as USD", *code)
+	}
+
+	return nil
+}
@@ -1,7 +1,10 @@
+//go:build !slim || widget_custom_api
+
 package widget
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -12,29 +15,54 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("custom-api", func() Widget { return &CustomApi{} })
+}
+
 type CustomApi struct {
-	widgetBase       `yaml:",inline"`
-	URL              OptionalEnvString            `yaml:"url"`
-	Template         string                       `yaml:"template"`
-	Frameless        bool                         `yaml:"frameless"`
-	Headers          map[string]OptionalEnvString `yaml:"headers"`
-	APIRequest       *http.Request                `yaml:"-"`
-	compiledTemplate *template.Template           `yaml:"-"`
-	CompiledHTML     template.HTML                `yaml:"-"`
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               OptionalEnvString  `yaml:"url"`
+	Source            string             `yaml:"source"`
+	Template          string             `yaml:"template"`
+	Thresholds        namedThresholds    `yaml:"thresholds"`
+	APIRequest        *http.Request      `yaml:"-"`
+	compiledTemplate  *template.Template `yaml:"-"`
+	CompiledHTML      template.HTML      `yaml:"-"`
+	responseBody      string             `yaml:"-"`
 }
 
 func (widget *CustomApi) Initialize() error {
-	widget.withTitle("Custom API").withCacheDuration(1 * time.Hour)
+	widget.withTitle("Custom API")
+
+	// data from another widget is cheap to get and the other
+	// widget decides how often it should actually be fetched
+	if widget.Source != "" {
+		widget.withCacheDuration(1 * time.Minute)
+	} else {
+		widget.withCacheDuration(1 * time.Hour)
+	}
+
+	if widget.URL == "" && widget.Source == "" {
+		return errors.New("URL or source is required for the custom API widget")
+	}
 
-	if widget.URL == "" {
-		return errors.New("URL is required for the custom API widget")
+	if widget.URL != "" && widget.Source != "" {
+		return errors.New("URL and source cannot both be specified for the custom API widget")
 	}
 
 	if widget.Template == "" {
 		return errors.New("template is required for the custom API widget")
 	}
 
-	compiledTemplate, err := template.New("").Funcs(feed.CustomAPITemplateFuncs).Parse(widget.Template)
+	if err := widget.Thresholds.validate(); err != nil {
+		return fmt.Errorf("invalid thresholds in custom API widget: %w", err)
+	}
+
+	compiledTemplate, err := template.New("").
+		Funcs(feed.CustomAPITemplateFuncs).
+		Funcs(template.FuncMap{"threshold": widget.matchThreshold}).
+		Parse(widget.Template)
 
 	if err != nil {
 		return fmt.Errorf("failed parsing custom API widget template: %w", err)
@@ -42,6 +70,10 @@ func (widget *CustomApi) Initialize() error {
 
 	widget.compiledTemplate = compiledTemplate
 
+	if widget.Source != "" {
+		return nil
+	}
+
 	req, err := http.NewRequest(http.MethodGet, widget.URL.String(), nil)
 	if err != nil {
 		return err
@@ -56,8 +88,46 @@ func (widget *CustomApi) Initialize() error {
 	return nil
 }
 
+func (widget *CustomApi) matchThreshold(name string, value any) (*threshold, error) {
+	switch value := value.(type) {
+	case float64:
+		return widget.Thresholds.matchOrErr(name, value)
+	case int64:
+		return widget.Thresholds.matchOrErr(name, float64(value))
+	case int:
+		return widget.Thresholds.matchOrErr(name, float64(value))
+	}
+
+	return nil, fmt.Errorf("threshold value must be a number, got %T", value)
+}
+
 func (widget *CustomApi) Update(ctx context.Context) {
-	compiledHTML, err := feed.FetchAndParseCustomAPI(widget.APIRequest, widget.compiledTemplate)
+	if widget.Source != "" {
+		widget.updateFromSource(ctx)
+		return
+	}
+
+	compiledHTML, body, err := feed.FetchAndParseCustomAPI(ctx, widget.client, widget.APIRequest, widget.compiledTemplate)
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.CompiledHTML = compiledHTML
+	widget.responseBody = body
+}
+
+func (widget *CustomApi) updateFromSource(ctx context.Context) {
+	data, err := widget.Providers.DataBus.data(ctx, widget, widget.Source)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	compiledHTML, err := feed.ParseCustomAPIData(data, widget.compiledTemplate)
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
 	}
@@ -65,6 +135,22 @@ func (widget *CustomApi) Update(ctx context.Context) {
 	widget.CompiledHTML = compiledHTML
 }
 
+func (widget *CustomApi) sharedDataSources() []string {
+	if widget.Source == "" {
+		return nil
+	}
+
+	return []string{widget.Source}
+}
+
+func (widget *CustomApi) sharedData() any {
+	if widget.responseBody == "" {
+		return nil
+	}
+
+	return json.RawMessage(widget.responseBody)
+}
+
 func (widget *CustomApi) Render() template.HTML {
 	return widget.render(widget, assets.CustomAPITemplate)
 }
@@ -0,0 +1,46 @@
+//go:build !slim || widget_custom_html
+
+package widget
+
+import (
+	"errors"
+	"html/template"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("custom-html", func() Widget { return &CustomHTML{} })
+}
+
+// Unlike the html widget, the HTML is shown inside of a regular widget with
+// a header, and using it has to be explicitly allowed since attributes such
+// as onclick and onerror run scripts with access to the dashboard
+type CustomHTML struct {
+	widgetBase   `yaml:",inline"`
+	cachedHTML   template.HTML `yaml:"-"`
+	Body         string        `yaml:"body"`
+	AllowRawHTML bool          `yaml:"allow-raw-html"`
+	HTML         template.HTML `yaml:"-"`
+}
+
+func (widget *CustomHTML) Initialize() error {
+	widget.withTitle("Custom HTML").withError(nil)
+
+	if widget.Body == "" {
+		return errors.New("body is required")
+	}
+
+	if !widget.AllowRawHTML {
+		return errors.New("the body is rendered as is, set allow-raw-html to true if it comes from a source you trust")
+	}
+
+	widget.HTML = template.HTML(widget.Body)
+	widget.cachedHTML = widget.render(widget, assets.CustomHTMLTemplate)
+
+	return nil
+}
+
+func (widget *CustomHTML) Render() template.HTML {
+	return widget.cachedHTML
+}
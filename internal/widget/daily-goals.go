@@ -0,0 +1,104 @@
+//go:build !slim || widget_daily_goals
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("daily-goals", func() Widget { return &DailyGoals{} })
+}
+
+var dailyGoalServiceNames = map[string]string{
+	feed.DailyGoalServiceDuolingo: "Duolingo",
+	feed.DailyGoalServiceAnki:     "Anki",
+	feed.DailyGoalServiceLeetCode: "LeetCode",
+}
+
+type DailyGoals struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	GoalRequests      []struct {
+		Service  string `yaml:"service"`
+		Name     string `yaml:"name"`
+		Username string `yaml:"username"`
+		URL      string `yaml:"url"`
+		Goal     int    `yaml:"goal"`
+	} `yaml:"goals"`
+	Goals    []feed.DailyGoal `yaml:"-"`
+	requests []*feed.DailyGoalRequest
+}
+
+func (widget *DailyGoals) Initialize() error {
+	widget.withTitle("Daily Goals").withCacheDuration(15 * time.Minute)
+
+	if len(widget.GoalRequests) == 0 {
+		return errors.New("no goals specified for daily goals widget")
+	}
+
+	widget.requests = make([]*feed.DailyGoalRequest, len(widget.GoalRequests))
+
+	for i := range widget.GoalRequests {
+		goal := &widget.GoalRequests[i]
+		name, known := dailyGoalServiceNames[goal.Service]
+
+		if !known {
+			return errors.New("service for daily goals must be one of duolingo, anki or leetcode")
+		}
+
+		switch goal.Service {
+		case feed.DailyGoalServiceDuolingo, feed.DailyGoalServiceLeetCode:
+			if goal.Username == "" {
+				return fmt.Errorf("username must be specified for %s in daily goals widget", goal.Service)
+			}
+		case feed.DailyGoalServiceAnki:
+			if goal.URL == "" {
+				goal.URL = "http://localhost:8765"
+			}
+		}
+
+		if goal.Goal < 0 {
+			return errors.New("goal cannot be negative in daily goals widget")
+		}
+
+		if goal.Name == "" {
+			goal.Name = name
+		}
+
+		widget.requests[i] = &feed.DailyGoalRequest{
+			Service:  goal.Service,
+			Name:     goal.Name,
+			Username: goal.Username,
+			URL:      goal.URL,
+			Goal:     goal.Goal,
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("daily goals widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *DailyGoals) Update(ctx context.Context) {
+	goals, err := feed.FetchDailyGoals(ctx, widget.client, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Goals = goals
+}
+
+func (widget *DailyGoals) Render() template.HTML {
+	return widget.render(widget, assets.DailyGoalsTemplate)
+}
@@ -0,0 +1,273 @@
+package widget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// Widgets which have the share-as property make the data they fetch available
+// under that name so that other widgets can use it instead of fetching it again
+type DataBus struct {
+	producers map[string]Widget
+	// the lock which the page of the widget holds while rendering it
+	pageLocks map[Widget]*sync.Mutex
+}
+
+type sharedDataProducer interface {
+	sharedData() any
+}
+
+type sharedDataConsumer interface {
+	sharedDataSources() []string
+}
+
+func NewDataBus() *DataBus {
+	return &DataBus{
+		producers: make(map[string]Widget),
+		pageLocks: make(map[Widget]*sync.Mutex),
+	}
+}
+
+// Registers the widget, along with any widgets nested inside of it, if it has the share-as property.
+// The lock is the one which the page the widget is on holds while updating and rendering it
+func (b *DataBus) Register(widget Widget, pageLock *sync.Mutex) error {
+	if container, ok := widget.(interface{ children() Widgets }); ok {
+		for _, child := range container.children() {
+			if err := b.Register(child, pageLock); err != nil {
+				return err
+			}
+		}
+	}
+
+	b.pageLocks[widget] = pageLock
+
+	name := widget.(interface{ sharedAs() string }).sharedAs()
+
+	if name == "" {
+		return nil
+	}
+
+	if _, ok := widget.(sharedDataProducer); !ok {
+		return fmt.Errorf("%s widget does not support share-as", widget.GetType())
+	}
+
+	if _, exists := b.producers[name]; exists {
+		return fmt.Errorf("more than one widget has share-as set to %s", name)
+	}
+
+	b.producers[name] = widget
+
+	return nil
+}
+
+// Must be called after all widgets have been registered
+func (b *DataBus) Validate(widgets []Widget) error {
+	for _, widget := range widgets {
+		if container, ok := widget.(interface{ children() Widgets }); ok {
+			if err := b.Validate(container.children()); err != nil {
+				return err
+			}
+		}
+
+		consumer, ok := widget.(sharedDataConsumer)
+
+		if !ok {
+			continue
+		}
+
+		for _, source := range consumer.sharedDataSources() {
+			if _, exists := b.producers[source]; !exists {
+				return fmt.Errorf("no widget has share-as set to %s", source)
+			}
+		}
+
+		if err := b.checkForCycles(consumer, make(map[string]bool)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// A producer which is itself a consumer could otherwise end up
+// waiting for a widget that's waiting for it to finish updating
+func (b *DataBus) checkForCycles(consumer sharedDataConsumer, visiting map[string]bool) error {
+	for _, source := range consumer.sharedDataSources() {
+		if visiting[source] {
+			return fmt.Errorf("widgets sharing data as %s depend on each other", source)
+		}
+
+		if next, ok := b.producers[source].(sharedDataConsumer); ok {
+			visiting[source] = true
+
+			if err := b.checkForCycles(next, visiting); err != nil {
+				return err
+			}
+
+			delete(visiting, source)
+		}
+	}
+
+	return nil
+}
+
+// Updates the producer first if its data is outdated, which could happen if it's
+// on a page that hasn't been visited yet, then returns its data encoded as JSON.
+// The producer can't be updated while its page is rendering it, so if it's on another
+// page which is busy, its current data gets used instead. Waiting for that page
+// could deadlock when widgets on both pages use data from each other
+func (b *DataBus) data(ctx context.Context, consumer Widget, name string) ([]byte, error) {
+	producer, exists := b.producers[name]
+
+	if !exists {
+		return nil, fmt.Errorf("no widget has share-as set to %s", name)
+	}
+
+	now := time.Now()
+	pageLock := b.pageLocks[producer]
+
+	if pageLock == b.pageLocks[consumer] {
+		// already held by whatever is updating the consumer
+		UpdateIfRequired(ctx, producer, &now)
+	} else if pageLock.TryLock() {
+		UpdateIfRequired(ctx, producer, &now)
+		pageLock.Unlock()
+	}
+
+	mutex := producer.(interface{ updateMutex() *sync.Mutex }).updateMutex()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	data := producer.(sharedDataProducer).sharedData()
+
+	if data == nil {
+		return nil, fmt.Errorf("widget sharing data as %s has no data available", name)
+	}
+
+	return json.Marshal(data)
+}
+
+// Prevents the same widget from being updated more than once at the same
+// time when it's being updated both by its page and through the data bus
+func UpdateIfRequired(ctx context.Context, widget Widget, now *time.Time) {
+	updateIfRequiredOrOlderThan(ctx, widget, now, 0)
+}
+
+// Same as UpdateIfRequired, but also updates the widget if its data is older than
+// maxAge even though its cache hasn't expired yet, unless it's in its quiet hours.
+// Used to refresh the data of a page when it's looked at again after a while
+func UpdateIfOlderThan(ctx context.Context, widget Widget, now *time.Time, maxAge time.Duration) {
+	updateIfRequiredOrOlderThan(ctx, widget, now, maxAge)
+}
+
+func updateIfRequiredOrOlderThan(ctx context.Context, widget Widget, now *time.Time, maxAge time.Duration) {
+	mutex := widget.(interface{ updateMutex() *sync.Mutex }).updateMutex()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !widget.RequiresUpdate(now) && (maxAge <= 0 || !isOlderThan(widget, *now, maxAge)) {
+		RecordCacheHit(widget)
+		return
+	}
+
+	runUpdate(ctx, widget)
+}
+
+// Updates the widget right away regardless of its cache and quiet hours,
+// used when an update is requested through the admin API
+func ForceUpdate(ctx context.Context, widget Widget) {
+	if base, ok := widget.(interface{ cachesForever() bool }); ok && base.cachesForever() {
+		return
+	}
+
+	mutex := widget.(interface{ updateMutex() *sync.Mutex }).updateMutex()
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	runUpdate(ctx, widget)
+}
+
+// Makes the widget get updated the next time it's needed, unless it's in its quiet hours
+func ExpireCache(widget Widget) {
+	base, ok := widget.(interface {
+		cachesForever() bool
+		updateMutex() *sync.Mutex
+		expire()
+	})
+
+	if !ok || base.cachesForever() {
+		return
+	}
+
+	base.updateMutex().Lock()
+	base.expire()
+	base.updateMutex().Unlock()
+}
+
+// Must be called with the update mutex of the widget held
+func runUpdate(ctx context.Context, widget Widget) {
+	if timeout := widget.(interface{ requestTimeout() time.Duration }).requestTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ctx, span := feed.StartSpan(ctx, "update "+widget.GetType(),
+		"widget.id", widget.GetSlug(),
+		"widget.type", widget.GetType(),
+		"widget.title", widget.GetTitle(),
+	)
+
+	start := time.Now()
+	widget.Update(ctx)
+	widget.(interface{ updateCounter() *atomic.Uint64 }).updateCounter().Add(1)
+
+	var err error
+
+	if tracker, ok := widget.(updateStatsTracker); ok {
+		failed := tracker.lastUpdateFailed()
+		tracker.updateStats().recordUpdate(time.Since(start), failed, time.Now())
+
+		if failed {
+			err = errors.New("update failed")
+		}
+	}
+
+	span.End(err)
+}
+
+func isOlderThan(widget Widget, now time.Time, maxAge time.Duration) bool {
+	base, ok := widget.(interface {
+		cachesForever() bool
+		quietHours() *TimeWindow
+	})
+
+	if !ok || base.cachesForever() || base.quietHours().active(now) {
+		return false
+	}
+
+	stats, _ := GetUpdateStats(widget)
+
+	return !stats.LastUpdateAt.IsZero() && now.Sub(stats.LastUpdateAt) > maxAge
+}
+
+// Returns a number which changes whenever the widget or any widget nested
+// inside of it gets updated, used to know when it has to be rendered again
+func UpdateGeneration(widget Widget) uint64 {
+	generation := widget.(interface{ updateCounter() *atomic.Uint64 }).updateCounter().Load()
+
+	if container, ok := widget.(interface{ children() Widgets }); ok {
+		for _, child := range container.children() {
+			generation += UpdateGeneration(child)
+		}
+	}
+
+	return generation
+}
@@ -0,0 +1,232 @@
+//go:build !slim || widget_ddns
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("ddns", func() Widget { return &DDNS{} })
+}
+
+const (
+	// How often the public address gets checked, regardless of whether anyone has the page open
+	ddnsSyncInterval = 5 * time.Minute
+	ddnsTimeout      = 10 * time.Second
+)
+
+type ddnsRecordConfig struct {
+	Provider string            `yaml:"provider"`
+	Name     string            `yaml:"name"`
+	Token    OptionalEnvString `yaml:"token"`
+}
+
+func (r *ddnsRecordConfig) storageKey(ipv6 bool) string {
+	if ipv6 {
+		return "ddns:" + r.Provider + ":" + r.Name + ":AAAA"
+	}
+
+	return "ddns:" + r.Provider + ":" + r.Name + ":A"
+}
+
+// Kept in the storage so that the records don't get updated again after every restart
+// and so that the widget can show them before the first check is done
+type ddnsRecordState struct {
+	Address   string    `json:"address"`
+	UpdatedAt time.Time `json:"updated-at"`
+	Error     string    `json:"error"`
+}
+
+type ddnsRecordItem struct {
+	Name     string
+	Provider string
+	IPv4     ddnsRecordState
+	// nil when IPv6 isn't enabled
+	IPv6 *ddnsRecordState
+}
+
+type DDNS struct {
+	widgetBase `yaml:",inline"`
+	Records    []ddnsRecordConfig `yaml:"records"`
+	IPv6       bool               `yaml:"ipv6"`
+	Items      []ddnsRecordItem   `yaml:"-"`
+	// keys of the records which were pushed to the provider since Glance started,
+	// only used by the goroutine which keeps them in sync
+	synced map[string]bool `yaml:"-"`
+}
+
+func (widget *DDNS) Initialize() error {
+	widget.withTitle("Dynamic DNS").withCacheDuration(time.Minute)
+
+	if len(widget.Records) == 0 {
+		return fmt.Errorf("no records specified for ddns widget")
+	}
+
+	for i := range widget.Records {
+		record := &widget.Records[i]
+
+		switch record.Provider {
+		case feed.DDNSProviderCloudflare, feed.DDNSProviderDuckDNS, feed.DDNSProviderDeSEC:
+		default:
+			return fmt.Errorf("invalid provider '%s' for record in ddns widget, must be one of cloudflare, duckdns or desec", record.Provider)
+		}
+
+		if record.Name == "" {
+			return fmt.Errorf("name must be specified for records in ddns widget")
+		}
+
+		if record.Token == "" {
+			return fmt.Errorf("token must be specified for record %s in ddns widget", record.Name)
+		}
+	}
+
+	widget.synced = make(map[string]bool)
+
+	return nil
+}
+
+// The records have to follow the address even when nobody is looking at the page,
+// so they're kept in sync in the background for as long as the widget is in use
+func (widget *DDNS) SetProviders(providers *Providers) {
+	widget.widgetBase.SetProviders(providers)
+
+	if providers.Context != nil {
+		go widget.keepInSync(providers.Context)
+	}
+}
+
+func (widget *DDNS) keepInSync(ctx context.Context) {
+	ticker := time.NewTicker(ddnsSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		widget.sync(ctx, false)
+
+		if widget.IPv6 {
+			widget.sync(ctx, true)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (widget *DDNS) sync(ctx context.Context, ipv6 bool) {
+	fetchCtx, cancel := context.WithTimeout(ctx, ddnsTimeout)
+	address, err := feed.FetchPublicIP(fetchCtx, ipv6)
+	cancel()
+
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Failed to find public address for DDNS records", "ipv6", ipv6, "error", err)
+		}
+
+		return
+	}
+
+	for i := range widget.Records {
+		widget.syncRecord(ctx, &widget.Records[i], ipv6, address)
+	}
+}
+
+// Records are only pushed when the address changed, when the last attempt failed or
+// when they haven't been since Glance started, in case they were changed by hand
+func (widget *DDNS) syncRecord(ctx context.Context, record *ddnsRecordConfig, ipv6 bool, address netip.Addr) {
+	key := record.storageKey(ipv6)
+	var state ddnsRecordState
+
+	if err := widget.Providers.Storage.get(key, &state); err != nil {
+		slog.Error("Failed to read DDNS record", "name", record.Name, "error", err)
+	}
+
+	if widget.synced[key] && state.Error == "" && state.Address == address.String() {
+		return
+	}
+
+	updateErr := feed.UpdateDDNSRecord(ctx, &feed.DDNSRecord{
+		Provider: record.Provider,
+		Name:     record.Name,
+		Token:    record.Token.String(),
+	}, address)
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	err := widget.Providers.Storage.update(key, &state, func() error {
+		if updateErr != nil {
+			state.Error = updateErr.Error()
+			return nil
+		}
+
+		if state.Address != address.String() {
+			state.Address = address.String()
+			state.UpdatedAt = time.Now()
+		}
+
+		state.Error = ""
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to store DDNS record", "name", record.Name, "error", err)
+	}
+
+	if state.Error != "" {
+		slog.Error("Failed to update DDNS record", "name", record.Name, "provider", record.Provider, "error", state.Error)
+		return
+	}
+
+	widget.synced[key] = true
+}
+
+func (widget *DDNS) Update(ctx context.Context) {
+	items := make([]ddnsRecordItem, len(widget.Records))
+	var failed int
+
+	for i := range widget.Records {
+		record := &widget.Records[i]
+		item := &items[i]
+		item.Name = record.Name
+		item.Provider = record.Provider
+
+		widget.Providers.Storage.get(record.storageKey(false), &item.IPv4)
+
+		if widget.IPv6 {
+			item.IPv6 = &ddnsRecordState{}
+			widget.Providers.Storage.get(record.storageKey(true), item.IPv6)
+		}
+
+		if item.IPv4.Error != "" || (item.IPv6 != nil && item.IPv6.Error != "") {
+			failed++
+		}
+	}
+
+	var err error
+
+	if failed > 0 {
+		err = fmt.Errorf("%w: could not update %d record(s)", feed.ErrPartialContent, failed)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Items = items
+}
+
+func (widget *DDNS) Render() template.HTML {
+	return widget.render(widget, assets.DDNSTemplate)
+}
@@ -0,0 +1,94 @@
+//go:build !slim || widget_dependency_updates
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("dependency-updates", func() Widget { return &DependencyUpdates{} })
+}
+
+type DependencyUpdates struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Repositories      []string                     `yaml:"repositories"`
+	Token             OptionalEnvString            `yaml:"token"`
+	GiteaToken        OptionalEnvString            `yaml:"gitea-token"`
+	BranchPrefixes    []string                     `yaml:"branch-prefixes"`
+	CollapseAfter     int                          `yaml:"collapse-after"`
+	Groups            []feed.DependencyUpdateGroup `yaml:"-"`
+	Total             int                          `yaml:"-"`
+	RepositoryCount   int                          `yaml:"-"`
+	requests          []*feed.DependencyUpdatesRequest
+}
+
+func (widget *DependencyUpdates) Initialize() error {
+	widget.withTitle("Dependency Updates").withCacheDuration(30 * time.Minute)
+
+	if len(widget.Repositories) == 0 {
+		return errors.New("no repositories specified for dependency-updates widget")
+	}
+
+	if len(widget.BranchPrefixes) == 0 {
+		widget.BranchPrefixes = []string{"renovate/", "dependabot/"}
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("dependency-updates widget: %v", err)
+	}
+
+	widget.requests = make([]*feed.DependencyUpdatesRequest, len(widget.Repositories))
+
+	for i, repository := range widget.Repositories {
+		token := widget.Token.String()
+
+		if strings.Contains(repository, "://") {
+			token = widget.GiteaToken.String()
+		}
+
+		widget.requests[i] = &feed.DependencyUpdatesRequest{
+			Repository:     repository,
+			Token:          token,
+			BranchPrefixes: widget.BranchPrefixes,
+			Client:         widget.client,
+		}
+	}
+
+	return nil
+}
+
+func (widget *DependencyUpdates) Update(ctx context.Context) {
+	updates, err := feed.FetchDependencyUpdates(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	repositories := make(map[string]struct{})
+
+	for i := range updates {
+		repositories[updates[i].Repository] = struct{}{}
+	}
+
+	widget.Groups = feed.GroupDependencyUpdates(updates)
+	widget.Total = len(updates)
+	widget.RepositoryCount = len(repositories)
+}
+
+func (widget *DependencyUpdates) Render() template.HTML {
+	return widget.render(widget, assets.DependencyUpdatesTemplate)
+}
@@ -0,0 +1,149 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+type serviceDiscovery struct {
+	Docker *struct {
+		Host string `yaml:"host"`
+	} `yaml:"docker"`
+	Kubernetes *struct {
+		APIURL        string            `yaml:"api-url"`
+		Token         OptionalEnvString `yaml:"token"`
+		Namespace     string            `yaml:"namespace"`
+		AllowInsecure bool              `yaml:"allow-insecure"`
+		HTTPRoutes    bool              `yaml:"http-routes"`
+	} `yaml:"kubernetes"`
+	Traefik *struct {
+		APIURL        string            `yaml:"api-url"`
+		Username      string            `yaml:"username"`
+		Password      OptionalEnvString `yaml:"password"`
+		Group         string            `yaml:"group"`
+		AllowInsecure bool              `yaml:"allow-insecure"`
+		CheckBackends bool              `yaml:"check-backends"`
+	} `yaml:"traefik"`
+	Caddy *struct {
+		APIURL        string `yaml:"api-url"`
+		Group         string `yaml:"group"`
+		CheckBackends bool   `yaml:"check-backends"`
+	} `yaml:"caddy"`
+}
+
+type serviceDiscoverySource struct {
+	name     string
+	discover func(context.Context) (feed.DiscoveredServices, error)
+}
+
+func (d *serviceDiscovery) sources() []serviceDiscoverySource {
+	sources := make([]serviceDiscoverySource, 0, 4)
+
+	if d.Docker != nil {
+		host := d.Docker.Host
+		sources = append(sources, serviceDiscoverySource{
+			name: "docker",
+			discover: func(ctx context.Context) (feed.DiscoveredServices, error) {
+				return feed.DiscoverServicesFromDockerLabels(ctx, host)
+			},
+		})
+	}
+
+	if d.Kubernetes != nil {
+		request := &feed.KubernetesDiscoveryRequest{
+			APIURL:        d.Kubernetes.APIURL,
+			Token:         d.Kubernetes.Token.String(),
+			Namespace:     d.Kubernetes.Namespace,
+			AllowInsecure: d.Kubernetes.AllowInsecure,
+			HTTPRoutes:    d.Kubernetes.HTTPRoutes,
+		}
+
+		sources = append(sources, serviceDiscoverySource{
+			name: "kubernetes",
+			discover: func(ctx context.Context) (feed.DiscoveredServices, error) {
+				return feed.DiscoverServicesFromKubernetes(ctx, request)
+			},
+		})
+	}
+
+	if d.Traefik != nil {
+		request := &feed.TraefikDiscoveryRequest{
+			APIURL:        d.Traefik.APIURL,
+			Username:      d.Traefik.Username,
+			Password:      d.Traefik.Password.String(),
+			Group:         d.Traefik.Group,
+			AllowInsecure: d.Traefik.AllowInsecure,
+			CheckBackends: d.Traefik.CheckBackends,
+		}
+
+		sources = append(sources, serviceDiscoverySource{
+			name: "traefik",
+			discover: func(ctx context.Context) (feed.DiscoveredServices, error) {
+				return feed.DiscoverServicesFromTraefik(ctx, request)
+			},
+		})
+	}
+
+	if d.Caddy != nil {
+		request := &feed.CaddyDiscoveryRequest{
+			APIURL:        d.Caddy.APIURL,
+			Group:         d.Caddy.Group,
+			CheckBackends: d.Caddy.CheckBackends,
+		}
+
+		sources = append(sources, serviceDiscoverySource{
+			name: "caddy",
+			discover: func(ctx context.Context) (feed.DiscoveredServices, error) {
+				return feed.DiscoverServicesFromCaddy(ctx, request)
+			},
+		})
+	}
+
+	return sources
+}
+
+func (d *serviceDiscovery) validate() error {
+	if len(d.sources()) == 0 {
+		return fmt.Errorf("discovery is enabled but no sources are configured")
+	}
+
+	if d.Traefik != nil && d.Traefik.APIURL == "" {
+		return fmt.Errorf("missing api-url for traefik discovery")
+	}
+
+	return nil
+}
+
+func (d *serviceDiscovery) discover(ctx context.Context) (feed.DiscoveredServices, error) {
+	sources := d.sources()
+	services := make(feed.DiscoveredServices, 0)
+	var failed, partial int
+
+	for i := range sources {
+		discovered, err := sources[i].discover(ctx)
+
+		if errors.Is(err, feed.ErrPartialContent) {
+			partial++
+		} else if err != nil {
+			failed++
+			slog.Error("Failed to discover services", "source", sources[i].name, "error", err)
+			continue
+		}
+
+		services = append(services, discovered...)
+	}
+
+	if failed == len(sources) {
+		return nil, fmt.Errorf("%w: could not discover any services", feed.ErrNoContent)
+	}
+
+	if failed > 0 || partial > 0 {
+		return services, fmt.Errorf("%w: %d discovery source(s) failed", feed.ErrPartialContent, failed+partial)
+	}
+
+	return services, nil
+}
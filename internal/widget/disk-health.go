@@ -0,0 +1,138 @@
+//go:build !slim || widget_disk_health
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("disk-health", func() Widget { return &DiskHealth{} })
+}
+
+type diskHealthSource struct {
+	Type          string            `yaml:"type"`
+	Name          string            `yaml:"name"`
+	URL           string            `yaml:"url"`
+	Token         OptionalEnvString `yaml:"token"`
+	AllowInsecure bool              `yaml:"allow-insecure"`
+	Disks         []diskHealthDisk  `yaml:"-"`
+	Unreachable   bool              `yaml:"-"`
+}
+
+type diskHealthDisk struct {
+	feed.DiskHealth
+	// in the units of the widget
+	Temperature          float64
+	TemperatureThreshold *threshold
+	ReallocatedThreshold *threshold
+}
+
+var diskHealthThresholdNames = []string{"temperature", "reallocated-sectors"}
+
+type DiskHealth struct {
+	widgetBase `yaml:",inline"`
+	Sources    []diskHealthSource `yaml:"sources"`
+	Thresholds namedThresholds    `yaml:"thresholds"`
+	Units      feed.UnitSystem    `yaml:"units"`
+	requests   []*feed.DiskHealthRequest
+}
+
+func (widget *DiskHealth) Initialize() error {
+	widget.withTitle("Disk Health").withCacheDuration(30 * time.Minute)
+
+	if len(widget.Sources) == 0 {
+		widget.Sources = []diskHealthSource{{Type: "local"}}
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("disk-health widget: %v", err)
+	}
+
+	for name := range widget.Thresholds {
+		if !slices.Contains(diskHealthThresholdNames, name) {
+			return fmt.Errorf("invalid thresholds '%s' in disk-health widget, must be either temperature or reallocated-sectors", name)
+		}
+	}
+
+	if err := widget.Thresholds.validate(); err != nil {
+		return fmt.Errorf("invalid thresholds in disk-health widget: %v", err)
+	}
+
+	if _, exists := widget.Thresholds["reallocated-sectors"]; !exists {
+		if widget.Thresholds == nil {
+			widget.Thresholds = make(namedThresholds)
+		}
+
+		none := 0.0
+		widget.Thresholds["reallocated-sectors"] = thresholds{{Above: &none, Color: "negative"}}
+	}
+
+	widget.requests = make([]*feed.DiskHealthRequest, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.Type == "" {
+			source.Type = "local"
+		}
+
+		switch source.Type {
+		case "local":
+		case "remote", "scrutiny":
+			if source.URL == "" {
+				return fmt.Errorf("missing url for %s source in disk-health widget", source.Type)
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in disk-health widget, must be one of local, remote or scrutiny", source.Type)
+		}
+
+		widget.requests[i] = &feed.DiskHealthRequest{
+			URL:           source.URL,
+			Token:         source.Token.String(),
+			AllowInsecure: source.AllowInsecure,
+			Scrutiny:      source.Type == "scrutiny",
+		}
+	}
+
+	return nil
+}
+
+func (widget *DiskHealth) Update(ctx context.Context) {
+	disks, err := feed.FetchDiskHealthForSources(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+		source.Unreachable = disks[i] == nil
+		source.Disks = make([]diskHealthDisk, len(disks[i]))
+
+		for j := range disks[i] {
+			disk := diskHealthDisk{
+				DiskHealth:           disks[i][j],
+				ReallocatedThreshold: widget.Thresholds.Match("reallocated-sectors", float64(disks[i][j].ReallocatedSectors)),
+			}
+
+			if disk.TemperatureC != nil {
+				disk.Temperature = widget.Units.Temperature(*disk.TemperatureC)
+				disk.TemperatureThreshold = widget.Thresholds.Match("temperature", disk.Temperature)
+			}
+
+			source.Disks[j] = disk
+		}
+	}
+}
+
+func (widget *DiskHealth) Render() template.HTML {
+	return widget.render(widget, assets.DiskHealthTemplate)
+}
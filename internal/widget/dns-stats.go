@@ -1,9 +1,13 @@
+//go:build !slim || widget_dns_stats
+
 package widget
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -11,6 +15,20 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("dns-stats", func() Widget { return &DNSStats{} })
+}
+
+type dnsStatsInstance struct {
+	Name        string            `yaml:"name"`
+	URL         OptionalEnvString `yaml:"url"`
+	Token       OptionalEnvString `yaml:"token"`
+	Username    OptionalEnvString `yaml:"username"`
+	Password    OptionalEnvString `yaml:"password"`
+	Stats       *feed.DNSStats    `yaml:"-"`
+	Unreachable bool              `yaml:"-"`
+}
+
 type DNSStats struct {
 	widgetBase `yaml:",inline"`
 
@@ -23,6 +41,9 @@ type DNSStats struct {
 	Token      OptionalEnvString `yaml:"token"`
 	Username   OptionalEnvString `yaml:"username"`
 	Password   OptionalEnvString `yaml:"password"`
+	// several instances of the service, such as a primary Pi-hole and its secondaries,
+	// whose stats get combined
+	Instances []dnsStatsInstance `yaml:"instances"`
 }
 
 func makeDNSTimeLabels(format string) [8]string {
@@ -37,26 +58,55 @@ func makeDNSTimeLabels(format string) [8]string {
 }
 
 func (widget *DNSStats) Initialize() error {
+	if widget.Service != "adguard" && widget.Service != "pihole" {
+		return errors.New("DNS stats service must be either 'adguard' or 'pihole'")
+	}
+
+	if len(widget.Instances) == 0 {
+		widget.Instances = []dnsStatsInstance{{
+			URL:      widget.URL,
+			Token:    widget.Token,
+			Username: widget.Username,
+			Password: widget.Password,
+		}}
+	}
+
+	for i := range widget.Instances {
+		instance := &widget.Instances[i]
+
+		if instance.URL == "" {
+			return fmt.Errorf("url must be specified for instance %d in dns-stats widget", i+1)
+		}
+
+		if instance.Name == "" {
+			instance.Name = instance.URL.String()
+		}
+	}
+
 	widget.
 		withTitle("DNS Stats").
-		withTitleURL(string(widget.URL)).
+		withTitleURL(string(widget.Instances[0].URL)).
 		withCacheDuration(10 * time.Minute)
 
-	if widget.Service != "adguard" && widget.Service != "pihole" {
-		return errors.New("DNS stats service must be either 'adguard' or 'pihole'")
+	return nil
+}
+
+func (widget *DNSStats) fetchInstance(ctx context.Context, instance *dnsStatsInstance) (*feed.DNSStats, error) {
+	if widget.Service == "adguard" {
+		return feed.FetchAdguardStats(ctx, string(instance.URL), string(instance.Username), string(instance.Password))
 	}
 
-	return nil
+	return feed.FetchPiholeStats(ctx, string(instance.URL), string(instance.Token))
 }
 
 func (widget *DNSStats) Update(ctx context.Context) {
 	var stats *feed.DNSStats
 	var err error
 
-	if widget.Service == "adguard" {
-		stats, err = feed.FetchAdguardStats(string(widget.URL), string(widget.Username), string(widget.Password))
+	if len(widget.Instances) == 1 {
+		stats, err = widget.fetchInstance(ctx, &widget.Instances[0])
 	} else {
-		stats, err = feed.FetchPiholeStats(string(widget.URL), string(widget.Token))
+		stats, err = widget.fetchInstances(ctx)
 	}
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
@@ -72,6 +122,37 @@ func (widget *DNSStats) Update(ctx context.Context) {
 	widget.Stats = stats
 }
 
+// Fetches the stats of each of the instances and combines the ones which could be fetched
+func (widget *DNSStats) fetchInstances(ctx context.Context) (*feed.DNSStats, error) {
+	fetched := make([]*feed.DNSStats, 0, len(widget.Instances))
+
+	for i := range widget.Instances {
+		instance := &widget.Instances[i]
+		stats, err := widget.fetchInstance(ctx, instance)
+		instance.Unreachable = err != nil
+
+		if err != nil {
+			slog.Error("Failed to fetch DNS stats", "instance", instance.Name, "error", err)
+			continue
+		}
+
+		instance.Stats = stats
+		fetched = append(fetched, stats)
+	}
+
+	if len(fetched) == 0 {
+		return nil, fmt.Errorf("%w: could not fetch stats of any instance", feed.ErrNoContent)
+	}
+
+	merged := feed.MergeDNSStats(fetched)
+
+	if failed := len(widget.Instances) - len(fetched); failed > 0 {
+		return merged, fmt.Errorf("%w: could not fetch stats of %d instance(s)", feed.ErrPartialContent, failed)
+	}
+
+	return merged, nil
+}
+
 func (widget *DNSStats) Render() template.HTML {
 	return widget.render(widget, assets.DNSStatsTemplate)
 }
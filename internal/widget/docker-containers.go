@@ -0,0 +1,142 @@
+//go:build !slim || widget_docker_containers
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("docker-containers", func() Widget { return &DockerContainers{} })
+}
+
+type dockerContainer struct {
+	feed.DockerContainer
+	Icon        CustomIcon
+	StatusStyle string
+}
+
+type DockerContainers struct {
+	widgetBase `yaml:",inline"`
+	Host       string `yaml:"host"`
+	TLS        *struct {
+		CA   string `yaml:"ca"`
+		Cert string `yaml:"cert"`
+		Key  string `yaml:"key"`
+	} `yaml:"tls"`
+	HideStopped      bool              `yaml:"hide-stopped"`
+	Labels           []string          `yaml:"labels"`
+	Style            string            `yaml:"style"`
+	CollapseAfter    int               `yaml:"collapse-after"`
+	ShowOnlyProblems bool              `yaml:"show-only-problems"`
+	Containers       []dockerContainer `yaml:"-"`
+	HasProblems      bool              `yaml:"-"`
+	request          *feed.DockerContainersRequest
+}
+
+func (widget *DockerContainers) Initialize() error {
+	widget.withTitle("Docker Containers").withCacheDuration(1 * time.Minute)
+
+	if widget.Style != "" && widget.Style != "list" && widget.Style != "compact" {
+		return fmt.Errorf("invalid style '%s' for docker-containers widget, must be either list or compact", widget.Style)
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 7
+	}
+
+	widget.request = &feed.DockerContainersRequest{
+		Host:   widget.Host,
+		All:    !widget.HideStopped,
+		Labels: widget.Labels,
+	}
+
+	if widget.TLS != nil {
+		widget.request.TLS = &feed.DockerTLS{
+			CA:   widget.TLS.CA,
+			Cert: widget.TLS.Cert,
+			Key:  widget.TLS.Key,
+		}
+	}
+
+	return nil
+}
+
+func dockerContainerStatusStyle(container *feed.DockerContainer) string {
+	if container.State != "running" || container.Health == "unhealthy" {
+		return "error"
+	}
+
+	if container.Health == "starting" {
+		return "warning"
+	}
+
+	return "ok"
+}
+
+func (widget *DockerContainers) Update(ctx context.Context) {
+	containers, err := feed.FetchDockerContainers(ctx, widget.request)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Containers = make([]dockerContainer, len(containers))
+	widget.HasProblems = false
+
+	for i := range containers {
+		widget.Containers[i] = dockerContainer{
+			DockerContainer: containers[i],
+			Icon:            newCustomIconFromString(containers[i].Icon),
+			StatusStyle:     dockerContainerStatusStyle(&containers[i]),
+		}
+
+		if widget.Containers[i].StatusStyle != "ok" {
+			widget.HasProblems = true
+		}
+	}
+}
+
+func (widget *DockerContainers) sharedData() any {
+	if widget.Containers == nil {
+		return nil
+	}
+
+	containers := make([]map[string]any, len(widget.Containers))
+
+	for i := range widget.Containers {
+		container := &widget.Containers[i]
+		containers[i] = map[string]any{
+			"name":   container.Name,
+			"image":  container.Image,
+			"state":  container.State,
+			"status": container.Status,
+			"health": container.Health,
+			"ok":     container.StatusStyle == "ok",
+		}
+	}
+
+	return containers
+}
+
+func (widget *DockerContainers) Render() template.HTML {
+	if widget.Style == "compact" {
+		return widget.render(widget, assets.DockerContainersCompactTemplate)
+	}
+
+	return widget.render(widget, assets.DockerContainersTemplate)
+}
+
+func (widget *DockerContainers) isEmpty() bool {
+	return len(widget.Containers) == 0 || (widget.ShowOnlyProblems && !widget.HasProblems)
+}
+
+func (widget *DockerContainers) styleField() (*string, []string) {
+	return &widget.Style, []string{"list", "compact"}
+}
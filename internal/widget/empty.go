@@ -0,0 +1,35 @@
+package widget
+
+// Implemented by widgets which can end up with nothing to show, such as
+// a calendar without any upcoming events or a monitor that only shows problems
+type emptiable interface {
+	isEmpty() bool
+}
+
+// Widgets which failed to update aren't empty so that their error still gets
+// shown, containers are empty when all of the widgets inside of them are
+func IsEmpty(widget Widget) bool {
+	if container, ok := widget.(interface{ children() Widgets }); ok {
+		children := container.children()
+
+		for i := range children {
+			if !IsEmpty(children[i]) {
+				return false
+			}
+		}
+
+		return len(children) > 0
+	}
+
+	e, ok := widget.(emptiable)
+
+	if !ok {
+		return false
+	}
+
+	if base, ok := widget.(interface{ hasFreshContent() bool }); ok && !base.hasFreshContent() {
+		return false
+	}
+
+	return e.isEmpty()
+}
@@ -0,0 +1,137 @@
+//go:build !slim || widget_ev
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("ev", func() Widget { return &EV{} })
+}
+
+type EV struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string            `yaml:"service"`
+	URL                    string            `yaml:"url"`
+	Vehicle                OptionalEnvString `yaml:"vehicle"`
+	Loadpoint              int               `yaml:"loadpoint"`
+	Username               OptionalEnvString `yaml:"username"`
+	Password               OptionalEnvString `yaml:"password"`
+	Region                 string            `yaml:"region"`
+	ClientID               OptionalEnvString `yaml:"client-id"`
+	RefreshToken           OptionalEnvString `yaml:"refresh-token"`
+	Units                  feed.UnitSystem   `yaml:"units"`
+	Status                 *feed.EVStatus    `yaml:"-"`
+	evClient               *feed.EVClient    `yaml:"-"`
+}
+
+func (widget *EV) Initialize() error {
+	widget.withTitle("Vehicle").withCacheDuration(10 * time.Minute)
+
+	switch widget.Service {
+	case feed.EVServiceTesla:
+		if widget.Vehicle == "" || widget.ClientID == "" || widget.RefreshToken == "" {
+			return errors.New("vehicle, client-id and refresh-token must be specified for tesla in ev widget")
+		}
+
+		if widget.Region == "" {
+			widget.Region = "na"
+		}
+
+		if !feed.IsTeslaRegion(widget.Region) {
+			return errors.New("region for tesla in ev widget must be one of na, eu or cn")
+		}
+	case feed.EVServiceOVMS:
+		if widget.Vehicle == "" || widget.Username == "" || widget.Password == "" {
+			return errors.New("vehicle, username and password must be specified for ovms in ev widget")
+		}
+	case feed.EVServiceEVCC:
+		if widget.URL == "" {
+			return errors.New("url must be specified for evcc in ev widget")
+		}
+
+		widget.withTitleURL(widget.URL)
+
+		if widget.Loadpoint == 0 {
+			widget.Loadpoint = 1
+		}
+	case "renault":
+		return errors.New("renault does not offer a public API, use its integration in evcc instead")
+	default:
+		return errors.New("service for ev widget must be one of tesla, ovms or evcc")
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("ev widget: %v", err)
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("ev widget: %v", err)
+	}
+
+	widget.evClient = &feed.EVClient{
+		Service:              widget.Service,
+		URL:                  widget.URL,
+		Vehicle:              widget.Vehicle.String(),
+		Loadpoint:            widget.Loadpoint,
+		Username:             widget.Username.String(),
+		Password:             widget.Password.String(),
+		Region:               widget.Region,
+		ClientID:             widget.ClientID.String(),
+		RefreshToken:         widget.RefreshToken.String(),
+		OnRefreshTokenChange: widget.storeRefreshToken,
+	}
+
+	if widget.Service == feed.EVServiceTesla {
+		widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.evClient.RefreshToken)
+	}
+
+	return nil
+}
+
+func (widget *EV) storageKey() string {
+	return "ev:tesla:" + widget.evClient.ClientID + ":" + widget.evClient.Vehicle
+}
+
+func (widget *EV) Update(ctx context.Context) {
+	status, err := widget.evClient.FetchStatus(ctx, widget.client)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	// keep showing the last known state while a Tesla sleeps
+	if status.Asleep && widget.Status != nil {
+		previous := *widget.Status
+		previous.Asleep = true
+		previous.Charging = false
+		status = &previous
+	}
+
+	widget.Status = status
+}
+
+func (widget *EV) FormatRange(kilometers float64) string {
+	if widget.Units == feed.ImperialUnits {
+		return fmt.Sprintf("%.0f mi", kilometers/1.609344)
+	}
+
+	return fmt.Sprintf("%.0f km", kilometers)
+}
+
+func (widget *EV) Render() template.HTML {
+	return widget.render(widget, assets.EVTemplate)
+}
@@ -0,0 +1,141 @@
+//go:build !slim || widget_events
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("events", func() Widget { return &Events{} })
+}
+
+type Events struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Location          string  `yaml:"location"`
+	Radius            float64 `yaml:"radius"`
+	Days              int     `yaml:"days"`
+	HourFormat        string  `yaml:"hour-format"`
+	Meetup            struct {
+		Groups []string `yaml:"groups"`
+	} `yaml:"meetup"`
+	Bandsintown struct {
+		AppID   OptionalEnvString `yaml:"app-id"`
+		Artists []string          `yaml:"artists"`
+	} `yaml:"bandsintown"`
+	Songkick struct {
+		APIKey  OptionalEnvString `yaml:"api-key"`
+		Artists []string          `yaml:"artists"`
+	} `yaml:"songkick"`
+	CollapseAfter int             `yaml:"collapse-after"`
+	Events        []feed.Event    `yaml:"-"`
+	place         *feed.PlaceJson `yaml:"-"`
+}
+
+func (widget *Events) Initialize() error {
+	widget.withTitle("Events").withCacheDuration(6 * time.Hour)
+
+	if widget.Location == "" {
+		return errors.New("location must be specified for events widget")
+	}
+
+	if len(widget.Meetup.Groups) == 0 && len(widget.Bandsintown.Artists) == 0 && len(widget.Songkick.Artists) == 0 {
+		return errors.New("no meetup groups or artists specified for events widget")
+	}
+
+	if len(widget.Bandsintown.Artists) > 0 && widget.Bandsintown.AppID == "" {
+		return errors.New("app-id must be specified for bandsintown in events widget")
+	}
+
+	if len(widget.Songkick.Artists) > 0 && widget.Songkick.APIKey == "" {
+		return errors.New("api-key must be specified for songkick in events widget")
+	}
+
+	if widget.Radius <= 0 {
+		widget.Radius = 50
+	}
+
+	if widget.Days <= 0 {
+		widget.Days = 30
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if widget.HourFormat == "" {
+		widget.HourFormat = "24h"
+	} else if widget.HourFormat != "12h" && widget.HourFormat != "24h" {
+		return fmt.Errorf("invalid hour format '%s' for events widget, must be either 12h or 24h", widget.HourFormat)
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("events widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Events) Update(ctx context.Context) {
+	if widget.place == nil {
+		place, err := feed.FetchPlaceFromName(ctx, widget.Location, "")
+
+		if err != nil {
+			widget.withError(err).scheduleEarlyUpdate()
+			return
+		}
+
+		widget.place = place
+	}
+
+	events, err := feed.FetchEvents(ctx, &feed.EventsRequest{
+		Place:              widget.place,
+		Radius:             widget.Radius,
+		Until:              time.Now().AddDate(0, 0, widget.Days),
+		MeetupGroups:       widget.Meetup.Groups,
+		BandsintownAppID:   widget.Bandsintown.AppID.String(),
+		BandsintownArtists: widget.Bandsintown.Artists,
+		SongkickAPIKey:     widget.Songkick.APIKey.String(),
+		SongkickArtists:    widget.Songkick.Artists,
+		Client:             widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Events = events
+}
+
+func (widget *Events) TimeLabel(event feed.Event) string {
+	if event.IsAllDay {
+		return event.StartsAt.Format("Mon, Jan 2")
+	}
+
+	if widget.HourFormat == "12h" {
+		return event.StartsAt.Format("Mon, Jan 2, 3:04pm")
+	}
+
+	return event.StartsAt.Format("Mon, Jan 2, 15:04")
+}
+
+func (widget *Events) DistanceLabel(distance float64) string {
+	if distance < 1 {
+		return "<1 km"
+	}
+
+	return fmt.Sprintf("%d km", int(math.Round(distance)))
+}
+
+func (widget *Events) Render() template.HTML {
+	return widget.render(widget, assets.EventsTemplate)
+}
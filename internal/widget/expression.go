@@ -0,0 +1,444 @@
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A small arithmetic expression language used by the computed-metrics widget:
+//
+//	{stocks:#(symbol=="AAPL").price} * 12 + sum({agents:#.disk-used}) / 1024
+//
+// References have the form {source:path} where source is the name that another
+// widget shares its data as and path is a gjson path to a value within that data.
+// A reference to an array can only be used as an argument of an aggregate function.
+
+type expressionNode interface {
+	evaluate(resolve expressionResolver) (expressionValue, error)
+}
+
+type expressionResolver func(source, path string) (expressionValue, error)
+
+type expressionValue struct {
+	number float64
+	list   []float64
+	isList bool
+}
+
+func (v expressionValue) scalar() (float64, error) {
+	if v.isList {
+		return 0, errors.New("a list of values can only be used within sum, avg, min, max or count")
+	}
+
+	return v.number, nil
+}
+
+type numberNode float64
+
+func (n numberNode) evaluate(expressionResolver) (expressionValue, error) {
+	return expressionValue{number: float64(n)}, nil
+}
+
+type referenceNode struct {
+	source string
+	path   string
+}
+
+func (n *referenceNode) evaluate(resolve expressionResolver) (expressionValue, error) {
+	return resolve(n.source, n.path)
+}
+
+type unaryNode struct {
+	operand expressionNode
+}
+
+func (n *unaryNode) evaluate(resolve expressionResolver) (expressionValue, error) {
+	value, err := n.operand.evaluate(resolve)
+
+	if err != nil {
+		return expressionValue{}, err
+	}
+
+	number, err := value.scalar()
+
+	return expressionValue{number: -number}, err
+}
+
+type binaryNode struct {
+	operator    byte
+	left, right expressionNode
+}
+
+func (n *binaryNode) evaluate(resolve expressionResolver) (expressionValue, error) {
+	operands := [2]float64{}
+
+	for i, node := range [2]expressionNode{n.left, n.right} {
+		value, err := node.evaluate(resolve)
+
+		if err != nil {
+			return expressionValue{}, err
+		}
+
+		if operands[i], err = value.scalar(); err != nil {
+			return expressionValue{}, err
+		}
+	}
+
+	left, right := operands[0], operands[1]
+
+	switch n.operator {
+	case '+':
+		return expressionValue{number: left + right}, nil
+	case '-':
+		return expressionValue{number: left - right}, nil
+	case '*':
+		return expressionValue{number: left * right}, nil
+	case '/':
+		if right == 0 {
+			return expressionValue{}, errors.New("division by zero")
+		}
+
+		return expressionValue{number: left / right}, nil
+	case '%':
+		if right == 0 {
+			return expressionValue{}, errors.New("division by zero")
+		}
+
+		return expressionValue{number: math.Mod(left, right)}, nil
+	}
+
+	return expressionValue{}, fmt.Errorf("unknown operator %c", n.operator)
+}
+
+type functionNode struct {
+	name      string
+	arguments []expressionNode
+}
+
+var expressionFunctions = map[string]func([]float64) (float64, error){
+	"sum": func(values []float64) (float64, error) {
+		var sum float64
+
+		for _, v := range values {
+			sum += v
+		}
+
+		return sum, nil
+	},
+	"avg": func(values []float64) (float64, error) {
+		if len(values) == 0 {
+			return 0, errors.New("avg of no values")
+		}
+
+		var sum float64
+
+		for _, v := range values {
+			sum += v
+		}
+
+		return sum / float64(len(values)), nil
+	},
+	"min": func(values []float64) (float64, error) {
+		if len(values) == 0 {
+			return 0, errors.New("min of no values")
+		}
+
+		result := values[0]
+
+		for _, v := range values[1:] {
+			result = math.Min(result, v)
+		}
+
+		return result, nil
+	},
+	"max": func(values []float64) (float64, error) {
+		if len(values) == 0 {
+			return 0, errors.New("max of no values")
+		}
+
+		result := values[0]
+
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+
+		return result, nil
+	},
+	"count": func(values []float64) (float64, error) {
+		return float64(len(values)), nil
+	},
+	"abs": func(values []float64) (float64, error) {
+		if len(values) != 1 {
+			return 0, errors.New("abs takes exactly one value")
+		}
+
+		return math.Abs(values[0]), nil
+	},
+	"round": func(values []float64) (float64, error) {
+		if len(values) != 1 {
+			return 0, errors.New("round takes exactly one value")
+		}
+
+		return math.Round(values[0]), nil
+	},
+}
+
+func (n *functionNode) evaluate(resolve expressionResolver) (expressionValue, error) {
+	values := make([]float64, 0, len(n.arguments))
+
+	for _, argument := range n.arguments {
+		value, err := argument.evaluate(resolve)
+
+		if err != nil {
+			return expressionValue{}, err
+		}
+
+		if value.isList {
+			values = append(values, value.list...)
+		} else {
+			values = append(values, value.number)
+		}
+	}
+
+	result, err := expressionFunctions[n.name](values)
+
+	return expressionValue{number: result}, err
+}
+
+type expressionParser struct {
+	input    string
+	position int
+}
+
+func parseExpression(input string) (expressionNode, error) {
+	parser := &expressionParser{input: input}
+	node, err := parser.parseSum()
+
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipWhitespace()
+
+	if parser.position < len(parser.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", parser.input[parser.position], parser.position+1)
+	}
+
+	return node, nil
+}
+
+func (p *expressionParser) skipWhitespace() {
+	for p.position < len(p.input) && unicode.IsSpace(rune(p.input[p.position])) {
+		p.position++
+	}
+}
+
+func (p *expressionParser) peek() byte {
+	p.skipWhitespace()
+
+	if p.position >= len(p.input) {
+		return 0
+	}
+
+	return p.input[p.position]
+}
+
+func (p *expressionParser) parseSum() (expressionNode, error) {
+	left, err := p.parseProduct()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for operator := p.peek(); operator == '+' || operator == '-'; operator = p.peek() {
+		p.position++
+		right, err := p.parseProduct()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseProduct() (expressionNode, error) {
+	left, err := p.parseUnary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for operator := p.peek(); operator == '*' || operator == '/' || operator == '%'; operator = p.peek() {
+		p.position++
+		right, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if p.peek() == '-' {
+		p.position++
+		operand, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	c := p.peek()
+
+	switch {
+	case c == 0:
+		return nil, errors.New("unexpected end of expression")
+	case c == '(':
+		p.position++
+		node, err := p.parseSum()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", p.position+1)
+		}
+
+		p.position++
+
+		return node, nil
+	case c == '{':
+		return p.parseReference()
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z':
+		return p.parseFunction()
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", c, p.position+1)
+}
+
+func (p *expressionParser) parseNumber() (expressionNode, error) {
+	start := p.position
+
+	for p.position < len(p.input) && (p.input[p.position] == '.' || (p.input[p.position] >= '0' && p.input[p.position] <= '9')) {
+		p.position++
+	}
+
+	number, err := strconv.ParseFloat(p.input[start:p.position], 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", p.input[start:p.position])
+	}
+
+	return numberNode(number), nil
+}
+
+func (p *expressionParser) parseReference() (expressionNode, error) {
+	start := p.position + 1
+	end := strings.IndexByte(p.input[start:], '}')
+
+	if end == -1 {
+		return nil, fmt.Errorf("missing closing brace for reference at position %d", p.position+1)
+	}
+
+	reference := p.input[start : start+end]
+	p.position = start + end + 1
+	source, path, _ := strings.Cut(reference, ":")
+	source = strings.TrimSpace(source)
+
+	if source == "" {
+		return nil, fmt.Errorf("missing source in reference {%s}", reference)
+	}
+
+	return &referenceNode{source: source, path: strings.TrimSpace(path)}, nil
+}
+
+func (p *expressionParser) parseFunction() (expressionNode, error) {
+	start := p.position
+
+	for p.position < len(p.input) && p.input[p.position] >= 'a' && p.input[p.position] <= 'z' {
+		p.position++
+	}
+
+	name := p.input[start:p.position]
+
+	if _, exists := expressionFunctions[name]; !exists {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("expected ( after %s", name)
+	}
+
+	p.position++
+	node := &functionNode{name: name}
+
+	if p.peek() == ')' {
+		p.position++
+		return node, nil
+	}
+
+	for {
+		argument, err := p.parseSum()
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.arguments = append(node.arguments, argument)
+
+		if p.peek() == ',' {
+			p.position++
+			continue
+		}
+
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("expected , or ) at position %d", p.position+1)
+		}
+
+		p.position++
+
+		return node, nil
+	}
+}
+
+// Returns the names of all sources referenced within the expression
+func expressionSources(node expressionNode) []string {
+	switch n := node.(type) {
+	case *referenceNode:
+		return []string{n.source}
+	case *unaryNode:
+		return expressionSources(n.operand)
+	case *binaryNode:
+		return append(expressionSources(n.left), expressionSources(n.right)...)
+	case *functionNode:
+		sources := make([]string, 0, len(n.arguments))
+
+		for _, argument := range n.arguments {
+			sources = append(sources, expressionSources(argument)...)
+		}
+
+		return sources
+	}
+
+	return nil
+}
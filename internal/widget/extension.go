@@ -1,56 +1,91 @@
+//go:build !slim || widget_extension
+
 package widget
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
-	"net/url"
 	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("extension", func() Widget { return &Extension{} })
+}
+
+const defaultExtensionCacheDuration = 30 * time.Minute
+
 type Extension struct {
 	widgetBase          `yaml:",inline"`
-	URL                 string            `yaml:"url"`
-	FallbackContentType string            `yaml:"fallback-content-type"`
-	Parameters          map[string]string `yaml:"parameters"`
-	AllowHtml           bool              `yaml:"allow-potentially-dangerous-html"`
-	Extension           feed.Extension    `yaml:"-"`
-	cachedHTML          template.HTML     `yaml:"-"`
+	URL                 URLField           `yaml:"url"`
+	FallbackContentType string             `yaml:"fallback-content-type"`
+	Parameters          map[string]string  `yaml:"parameters"`
+	AllowHtml           bool               `yaml:"allow-potentially-dangerous-html"`
+	Template            string             `yaml:"template"`
+	CollapseAfter       int                `yaml:"collapse-after"`
+	compiledTemplate    *template.Template `yaml:"-"`
+	Extension           feed.Extension     `yaml:"-"`
+	cachedHTML          template.HTML      `yaml:"-"`
 }
 
 func (widget *Extension) Initialize() error {
-	widget.withTitle("Extension").withCacheDuration(time.Minute * 30)
+	widget.withTitle("Extension").withCacheDuration(defaultExtensionCacheDuration)
 
 	if widget.URL == "" {
 		return errors.New("no extension URL specified")
 	}
 
-	_, err := url.Parse(widget.URL)
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if widget.FallbackContentType != "" {
+		if _, ok := feed.ExtensionStringToType[widget.FallbackContentType]; !ok {
+			return fmt.Errorf("unsupported fallback content type: %s", widget.FallbackContentType)
+		}
+	}
+
+	if widget.Template != "" {
+		var err error
+		widget.compiledTemplate, err = template.New("").Funcs(feed.CustomAPITemplateFuncs).Parse(widget.Template)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return fmt.Errorf("parsing template: %v", err)
+		}
 	}
 
 	return nil
 }
 
 func (widget *Extension) Update(ctx context.Context) {
-	extension, err := feed.FetchExtension(feed.ExtensionRequestOptions{
-		URL:                 widget.URL,
+	extension, err := feed.FetchExtension(ctx, feed.ExtensionRequestOptions{
+		URL:                 widget.URL.String(),
 		FallbackContentType: widget.FallbackContentType,
 		Parameters:          widget.Parameters,
 		AllowHtml:           widget.AllowHtml,
+		Template:            widget.compiledTemplate,
 	})
 
-	widget.canContinueUpdateAfterHandlingErr(err)
+	if widget.canContinueUpdateAfterHandlingErr(err) {
+		widget.Extension = extension
+
+		if extension.Title != "" {
+			widget.Title = extension.Title
+		}
+
+		// the extension can say how long its content stays valid for,
+		// which only applies when the cache isn't set in the config
+		ttl := extension.CacheTTL
 
-	widget.Extension = extension
+		if ttl == 0 {
+			ttl = defaultExtensionCacheDuration
+		}
 
-	if extension.Title != "" {
-		widget.Title = extension.Title
+		widget.withCacheDuration(ttl).scheduleNextUpdate()
 	}
 
 	widget.cachedHTML = widget.render(widget, assets.ExtensionTemplate)
@@ -1,8 +1,11 @@
 package widget
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
+	"math"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -12,8 +15,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-var HSLColorPattern = regexp.MustCompile(`^(?:hsla?\()?(\d{1,3})(?: |,)+(\d{1,3})%?(?: |,)+(\d{1,3})%?\)?$`)
-var EnvFieldPattern = regexp.MustCompile(`(^|.)\$\{([A-Z_]+)\}`)
+var EnvFieldPattern = regexp.MustCompile(`(^|.)\$\{(file:[^}]+|[A-Z_]+)\}`)
 
 const (
 	HSLHueMax        = 360
@@ -21,6 +23,39 @@ const (
 	HSLLightnessMax  = 100
 )
 
+// An error in a value which knows where in the value it happened, so
+// that it can be turned into the line and column of the config file
+type valueError struct {
+	offset  int
+	message string
+}
+
+func (e *valueError) Error() string {
+	return e.message
+}
+
+func errorAt(offset int, format string, args ...any) error {
+	return &valueError{offset: offset, message: fmt.Sprintf(format, args...)}
+}
+
+// Points the error to the position of the problem within the value of the
+// node, or to the start of the value if the error doesn't know where it is
+func nodeError(node *yaml.Node, err error) error {
+	column := node.Column
+
+	// the column of quoted values is the one of the opening quote
+	if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		column++
+	}
+
+	var valueErr *valueError
+	if errors.As(err, &valueErr) {
+		column += valueErr.offset
+	}
+
+	return fmt.Errorf("line %d, column %d: %w", node.Line, column, err)
+}
+
 type HSLColorField struct {
 	Hue        uint16
 	Saturation uint8
@@ -42,81 +77,400 @@ func (c *HSLColorField) UnmarshalYAML(node *yaml.Node) error {
 		return err
 	}
 
-	matches := HSLColorPattern.FindStringSubmatch(value)
+	color, err := parseHSLColor(value)
 
-	if len(matches) != 4 {
-		return fmt.Errorf("invalid HSL color format: %s", value)
+	if err != nil {
+		return nodeError(node, fmt.Errorf("invalid HSL color %q: %w", value, err))
 	}
 
-	hue, err := strconv.ParseUint(matches[1], 10, 16)
+	*c = color
 
-	if err != nil {
-		return err
+	return nil
+}
+
+// Accepts the three values separated by spaces and/or commas, optionally
+// wrapped in hsl() or hsla(), with the saturation and lightness optionally
+// followed by a percent sign, such as 240 13 95 or hsl(240, 13%, 95%)
+func parseHSLColor(value string) (HSLColorField, error) {
+	components := value
+	offset := 0
+
+	if prefix := hslFunctionPrefix(value); prefix != "" {
+		if !strings.HasSuffix(value, ")") {
+			return HSLColorField{}, errorAt(len(value), "missing closing parenthesis")
+		}
+
+		components = value[len(prefix) : len(value)-1]
+		offset = len(prefix)
+	} else if i := strings.IndexAny(value, "()"); i != -1 {
+		return HSLColorField{}, errorAt(i, "unexpected %q", value[i])
 	}
 
-	if hue > HSLHueMax {
-		return fmt.Errorf("HSL hue must be between 0 and %d", HSLHueMax)
+	names := [3]string{"hue", "saturation", "lightness"}
+	maxes := [3]int{HSLHueMax, HSLSaturationMax, HSLLightnessMax}
+	var parsed [3]int
+	i := 0
+
+	for n := range names {
+		start := i
+
+		for i < len(components) && (components[i] == ' ' || components[i] == ',') {
+			i++
+		}
+
+		if n > 0 && i == start && i < len(components) {
+			return HSLColorField{}, errorAt(offset+i, "expected a space or comma before the %s", names[n])
+		}
+
+		if i == len(components) {
+			return HSLColorField{}, errorAt(offset+i, "missing %s", names[n])
+		}
+
+		digitsStart := i
+
+		for i < len(components) && components[i] >= '0' && components[i] <= '9' {
+			i++
+		}
+
+		if i == digitsStart {
+			return HSLColorField{}, errorAt(offset+i, "expected a number for the %s, got %q", names[n], components[i])
+		}
+
+		digits := components[digitsStart:i]
+		// anything this long is out of range regardless of its value and could overflow
+		number, err := strconv.Atoi(digits)
+
+		if len(digits) > 3 || err != nil || number > maxes[n] {
+			return HSLColorField{}, errorAt(offset+digitsStart, "%s must be between 0 and %d, got %s", names[n], maxes[n], digits)
+		}
+
+		parsed[n] = number
+
+		if n > 0 && i < len(components) && components[i] == '%' {
+			i++
+		}
 	}
 
-	saturation, err := strconv.ParseUint(matches[2], 10, 8)
+	for i < len(components) && components[i] == ' ' {
+		i++
+	}
 
-	if err != nil {
-		return err
+	if i < len(components) {
+		return HSLColorField{}, errorAt(offset+i, "unexpected %q after the lightness", components[i:])
 	}
 
-	if saturation > HSLSaturationMax {
-		return fmt.Errorf("HSL saturation must be between 0 and %d", HSLSaturationMax)
+	return HSLColorField{
+		Hue:        uint16(parsed[0]),
+		Saturation: uint8(parsed[1]),
+		Lightness:  uint8(parsed[2]),
+	}, nil
+}
+
+func hslFunctionPrefix(value string) string {
+	for _, prefix := range []string{"hsla(", "hsl("} {
+		if strings.HasPrefix(value, prefix) {
+			return prefix
+		}
 	}
 
-	lightness, err := strconv.ParseUint(matches[3], 10, 8)
+	return ""
+}
+
+// Ordered so that the longer units are matched first, such as ms before m
+var durationUnits = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"ns", time.Nanosecond},
+	{"us", time.Microsecond},
+	{"µs", time.Microsecond},
+	{"ms", time.Millisecond},
+	{"s", time.Second},
+	{"m", time.Minute},
+	{"h", time.Hour},
+	{"d", 24 * time.Hour},
+}
+
+type DurationField time.Duration
+
+func (d *DurationField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	duration, err := parseDuration(value)
 
 	if err != nil {
+		return nodeError(node, fmt.Errorf("invalid duration %q: %w", value, err))
+	}
+
+	*d = DurationField(duration)
+
+	return nil
+}
+
+// For passing durations to scripts in templates
+func (d DurationField) Milliseconds() int64 {
+	return time.Duration(d).Milliseconds()
+}
+
+// One or more numbers each followed by a unit, such as 30m, 1h30m or 1.5d. Accepts
+// everything that time.ParseDuration does except for signs, along with d for days
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errorAt(0, "expected a number followed by one of ms, s, m, h or d")
+	}
+
+	var total time.Duration
+	i := 0
+
+	for i < len(value) {
+		start := i
+
+		for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+			i++
+		}
+
+		integerEnd := i
+
+		if i < len(value) && value[i] == '.' {
+			i++
+
+			for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+				i++
+			}
+		}
+
+		numberEnd := i
+
+		if i == start || value[start:i] == "." {
+			return 0, errorAt(start, "expected a number, got %q", value[start:])
+		}
+
+		var unit time.Duration
+
+		for _, u := range durationUnits {
+			if strings.HasPrefix(value[i:], u.name) {
+				unit = u.duration
+				i += len(u.name)
+				break
+			}
+		}
+
+		if unit == 0 {
+			if i == len(value) {
+				return 0, errorAt(i, "missing unit, expected one of ms, s, m, h or d")
+			}
+
+			return 0, errorAt(i, "unknown unit %q, expected one of ms, s, m, h or d", value[i:])
+		}
+
+		integer, err := strconv.ParseInt("0"+value[start:integerEnd], 10, 64)
+
+		if err != nil || integer > int64(math.MaxInt64/unit) {
+			return 0, errorAt(start, "duration is too long")
+		}
+
+		part := time.Duration(integer) * unit
+
+		if numberEnd-integerEnd > 1 {
+			fraction, _ := strconv.ParseFloat("0"+value[integerEnd:numberEnd], 64)
+			fractionPart := fraction * float64(unit)
+
+			if fractionPart >= float64(math.MaxInt64-part) {
+				return 0, errorAt(start, "duration is too long")
+			}
+
+			part += time.Duration(fractionPart)
+		}
+
+		if part > math.MaxInt64-total {
+			return 0, errorAt(start, "duration is too long")
+		}
+
+		total += part
+	}
+
+	return total, nil
+}
+
+// A percentage between 0 and 100, written with or without the percent sign
+type PercentField float64
+
+func (p *PercentField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
 		return err
 	}
 
-	if lightness > HSLLightnessMax {
-		return fmt.Errorf("HSL lightness must be between 0 and %d", HSLLightnessMax)
+	percent, err := parsePercent(value)
+
+	if err != nil {
+		return nodeError(node, fmt.Errorf("invalid percentage %q: %w", value, err))
 	}
 
-	c.Hue = uint16(hue)
-	c.Saturation = uint8(saturation)
-	c.Lightness = uint8(lightness)
+	*p = PercentField(percent)
 
 	return nil
 }
 
-var DurationPattern = regexp.MustCompile(`^(\d+)(s|m|h|d)$`)
+// Such as 0.25 for 25%
+func (p PercentField) Fraction() float64 {
+	return float64(p) / 100
+}
 
-type DurationField time.Duration
+func parsePercent(value string) (float64, error) {
+	number := strings.TrimSpace(strings.TrimSuffix(value, "%"))
+	percent, err := strconv.ParseFloat(number, 64)
 
-func (d *DurationField) UnmarshalYAML(node *yaml.Node) error {
+	if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+		return 0, errorAt(0, "expected a number such as 75 or 75%%")
+	}
+
+	if percent < 0 || percent > 100 {
+		return 0, errorAt(0, "must be between 0 and 100")
+	}
+
+	return percent, nil
+}
+
+// Ordered so that the longer units are matched first, the ones without an
+// i are powers of 1000 the same as how disk sizes are usually written
+var byteSizeUnits = []struct {
+	name string
+	size int64
+}{
+	{"kib", 1 << 10},
+	{"mib", 1 << 20},
+	{"gib", 1 << 30},
+	{"tib", 1 << 40},
+	{"kb", 1e3},
+	{"mb", 1e6},
+	{"gb", 1e9},
+	{"tb", 1e12},
+	{"b", 1},
+}
+
+// A number of bytes, written as a number optionally followed by a unit such as 512MB or 1.5GiB
+type ByteSizeField int64
+
+func (b *ByteSizeField) UnmarshalYAML(node *yaml.Node) error {
 	var value string
 
 	if err := node.Decode(&value); err != nil {
 		return err
 	}
 
-	matches := DurationPattern.FindStringSubmatch(value)
+	size, err := parseByteSize(value)
 
-	if len(matches) != 3 {
-		return fmt.Errorf("invalid duration format: %s", value)
+	if err != nil {
+		return nodeError(node, fmt.Errorf("invalid size %q: %w", value, err))
 	}
 
-	duration, err := strconv.Atoi(matches[1])
+	*b = ByteSizeField(size)
+
+	return nil
+}
+
+func parseByteSize(value string) (int64, error) {
+	i := 0
+
+	for i < len(value) && (value[i] >= '0' && value[i] <= '9' || value[i] == '.') {
+		i++
+	}
+
+	number, err := strconv.ParseFloat(value[:i], 64)
 
 	if err != nil {
+		return 0, errorAt(0, "expected a number optionally followed by one of B, KB, MB, GB, TB, KiB, MiB, GiB or TiB")
+	}
+
+	unitStart := i
+
+	for i < len(value) && value[i] == ' ' {
+		i++
+	}
+
+	unit := strings.ToLower(value[i:])
+	multiplier := int64(1)
+
+	if unit != "" {
+		multiplier = 0
+
+		for _, u := range byteSizeUnits {
+			if unit == u.name {
+				multiplier = u.size
+				break
+			}
+		}
+
+		if multiplier == 0 {
+			return 0, errorAt(i, "unknown unit %q, expected one of B, KB, MB, GB, TB, KiB, MiB, GiB or TiB", value[i:])
+		}
+	} else if unitStart != len(value) {
+		return 0, errorAt(unitStart, "missing unit after the space")
+	}
+
+	size := number * float64(multiplier)
+
+	if size >= math.MaxInt64 {
+		return 0, errorAt(0, "size is too large")
+	}
+
+	return int64(size), nil
+}
+
+// A URL which can contain environment variables the same as OptionalEnvString,
+// with its scheme limited to http and https
+type URLField string
+
+func (u *URLField) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
 		return err
 	}
 
-	switch matches[2] {
-	case "s":
-		*d = DurationField(time.Duration(duration) * time.Second)
-	case "m":
-		*d = DurationField(time.Duration(duration) * time.Minute)
-	case "h":
-		*d = DurationField(time.Duration(duration) * time.Hour)
-	case "d":
-		*d = DurationField(time.Duration(duration) * 24 * time.Hour)
+	expanded, err := expandEnvString(value)
+
+	if err != nil {
+		return nodeError(node, err)
+	}
+
+	if err := validateURL(expanded); err != nil {
+		// the position is within the expanded value, which can differ from the one in the config
+		return fmt.Errorf("line %d: invalid URL %q: %v", node.Line, value, err)
+	}
+
+	*u = URLField(expanded)
+
+	return nil
+}
+
+func (u URLField) String() string {
+	return string(u)
+}
+
+func validateURL(value string) error {
+	parsed, err := url.Parse(value)
+
+	if err != nil {
+		return errors.Unwrap(err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		if parsed.Scheme == "" {
+			return errors.New("missing scheme, must start with http:// or https://")
+		}
+
+		return fmt.Errorf("unsupported scheme %s, must be http or https", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return errors.New("missing host")
 	}
 
 	return nil
@@ -133,44 +487,60 @@ func (f *OptionalEnvString) UnmarshalYAML(node *yaml.Node) error {
 		return err
 	}
 
-	replaced := EnvFieldPattern.ReplaceAllStringFunc(value, func(whole string) string {
-		if err != nil {
-			return ""
-		}
+	replaced, err := expandEnvString(value)
 
-		groups := EnvFieldPattern.FindStringSubmatch(whole)
+	if err != nil {
+		return nodeError(node, err)
+	}
 
-		if len(groups) != 3 {
-			return whole
-		}
+	*f = OptionalEnvString(replaced)
+
+	return nil
+}
 
-		prefix, key := groups[1], groups[2]
+// Replaces ${NAME} with the value of the environment variable and ${file:path}
+// with the contents of the file, unless they're escaped as \${NAME}
+func expandEnvString(value string) (string, error) {
+	var builder strings.Builder
+	last := 0
+
+	for _, match := range EnvFieldPattern.FindAllStringSubmatchIndex(value, -1) {
+		prefix, key := value[match[2]:match[3]], value[match[4]:match[5]]
+		builder.WriteString(value[last:match[0]])
+		last = match[1]
 
 		if prefix == `\` {
-			if len(whole) >= 2 {
-				return whole[1:]
-			} else {
-				return ""
+			builder.WriteString(value[match[3]:match[1]])
+			continue
+		}
+
+		builder.WriteString(prefix)
+
+		// secrets mounted as files, such as the ones of Docker and Kubernetes,
+		// usually end with a newline which is never part of the value
+		if path, isFile := strings.CutPrefix(key, "file:"); isFile {
+			contents, err := os.ReadFile(path)
+
+			if err != nil {
+				return "", errorAt(match[3], "could not read secret from file: %v", err)
 			}
+
+			builder.WriteString(strings.TrimRight(string(contents), "\r\n"))
+			continue
 		}
 
-		value, found := os.LookupEnv(key)
+		envValue, found := os.LookupEnv(key)
 
 		if !found {
-			err = fmt.Errorf("environment variable %s not found", key)
-			return ""
+			return "", errorAt(match[3], "environment variable %s not found", key)
 		}
 
-		return prefix + value
-	})
-
-	if err != nil {
-		return err
+		builder.WriteString(envValue)
 	}
 
-	*f = OptionalEnvString(replaced)
+	builder.WriteString(value[last:])
 
-	return nil
+	return builder.String(), nil
 }
 
 func (f *OptionalEnvString) String() string {
@@ -191,10 +561,34 @@ func (i *CustomIcon) UnmarshalYAML(node *yaml.Node) error {
 		return err
 	}
 
+	icon, err := parseCustomIcon(value)
+	if err != nil {
+		return nodeError(node, fmt.Errorf("invalid icon %q: %w", value, err))
+	}
+
+	*i = icon
+
+	return nil
+}
+
+// For icons which don't come from a field of their own and can't report errors
+func newCustomIconFromString(value string) CustomIcon {
+	i, _ := parseCustomIcon(value)
+	return i
+}
+
+func parseCustomIcon(value string) (CustomIcon, error) {
+	var i CustomIcon
+
 	prefix, icon, found := strings.Cut(value, ":")
 	if !found {
 		i.URL = value
-		return nil
+		return i, nil
+	}
+
+	if (prefix == "si" || prefix == "di") && strings.TrimSpace(icon) == "" {
+		i.URL = value
+		return i, errorAt(len(prefix)+1, "missing icon name after %s:", prefix)
 	}
 
 	switch prefix {
@@ -221,5 +615,5 @@ func (i *CustomIcon) UnmarshalYAML(node *yaml.Node) error {
 		i.URL = value
 	}
 
-	return nil
+	return i, nil
 }
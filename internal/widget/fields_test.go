@@ -0,0 +1,190 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func FuzzParseHSLColor(f *testing.F) {
+	for _, seed := range []string{
+		"240 13 95",
+		"hsl(240, 13%, 95%)",
+		"hsla(0,0%,0%)",
+		"hsl(400,200,999)",
+		"hsl(10 20 30",
+		"10 20 30)",
+		"99999999999999999999 1 1",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		color, err := parseHSLColor(value)
+
+		if err != nil {
+			if offset := err.(*valueError).offset; offset < 0 || offset > len(value) {
+				t.Fatalf("error offset %d is outside of %q", offset, value)
+			}
+
+			return
+		}
+
+		if color.Hue > HSLHueMax || color.Saturation > HSLSaturationMax || color.Lightness > HSLLightnessMax {
+			t.Fatalf("%q parsed into an out of range color %s", value, color.String())
+		}
+
+		reparsed, err := parseHSLColor(color.String())
+
+		if err != nil || reparsed != color {
+			t.Fatalf("%s did not parse back into the same color: %v", color.String(), err)
+		}
+	})
+}
+
+func FuzzParseDuration(f *testing.F) {
+	for _, seed := range []string{"500ms", "30s", "5m", "1h", "2d", "1h30m", "1.5h", "1d12h", "1µs", "1", "h", "1w", "1h1", ".", "99999999999999999999d", "106751.99d", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		duration, err := parseDuration(value)
+
+		if err != nil {
+			if offset := err.(*valueError).offset; offset < 0 || offset > len(value) {
+				t.Fatalf("error offset %d is outside of %q", offset, value)
+			}
+
+			return
+		}
+
+		if duration < 0 {
+			t.Fatalf("%q parsed into a negative duration %s", value, duration)
+		}
+
+		// anything without days should mean the same as it does to Go
+		if expected, err := time.ParseDuration(value); err == nil && !strings.ContainsAny(value, "d+-") {
+			if difference := duration - expected; difference < -1 || difference > 1 {
+				t.Fatalf("%q parsed into %s rather than %s", value, duration, expected)
+			}
+		}
+	})
+}
+
+func FuzzExpandEnvString(f *testing.F) {
+	for _, seed := range []string{"${GLANCE_FUZZ}", `\${GLANCE_FUZZ}`, "a${GLANCE_FUZZ}b", "${MISSING_GLANCE_VARIABLE}", "${lower}", "${", "$${GLANCE_FUZZ}"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		// reading arbitrary files while fuzzing isn't something we want
+		if strings.Contains(value, "${file:") {
+			t.Skip()
+		}
+
+		t.Setenv("GLANCE_FUZZ", "value")
+
+		expanded, err := expandEnvString(value)
+
+		if err != nil {
+			if offset := err.(*valueError).offset; offset < 0 || offset > len(value) {
+				t.Fatalf("error offset %d is outside of %q", offset, value)
+			}
+
+			return
+		}
+
+		if !strings.Contains(value, "${") && expanded != value {
+			t.Fatalf("%q without any variables was changed to %q", value, expanded)
+		}
+	})
+}
+
+func FuzzParseCustomIcon(f *testing.F) {
+	for _, seed := range []string{"si:github", "di:plex.png", "di:plex.jpeg", "si:", "di: ", "https://example.com/icon.svg", "auto-invert si:github"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		icon, err := parseCustomIcon(value)
+
+		if err == nil && value != "" && icon.URL == "" {
+			t.Fatalf("%q parsed into an icon without a URL", value)
+		}
+	})
+}
+
+func FuzzParsePercent(f *testing.F) {
+	for _, seed := range []string{"75", "75%", "0.5%", "100", "101%", "-1", "NaN", "%", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		percent, err := parsePercent(value)
+
+		if err == nil && (percent < 0 || percent > 100) {
+			t.Fatalf("%q parsed into an out of range percentage %f", value, percent)
+		}
+	})
+}
+
+func FuzzParseByteSize(f *testing.F) {
+	for _, seed := range []string{"512MB", "1.5GiB", "100", "1 kb", "10XB", "1e3MB", "99999999999TB", "", "."} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		size, err := parseByteSize(value)
+
+		if err != nil {
+			if offset := err.(*valueError).offset; offset < 0 || offset > len(value) {
+				t.Fatalf("error offset %d is outside of %q", offset, value)
+			}
+
+			return
+		}
+
+		if size < 0 {
+			t.Fatalf("%q parsed into a negative size %d", value, size)
+		}
+	})
+}
+
+func TestFieldErrorPositions(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		target   any
+		expected string
+	}{
+		{"color: hsl(400, 50, 50)\n", &struct{ Color HSLColorField }{}, "line 1, column 12:"},
+		{"color: \"240 13x 95\"\n", &struct{ Color HSLColorField }{}, "line 1, column 15:"},
+		{"cache: 10w\n", &struct{ Cache DurationField }{}, "line 1, column 10:"},
+		{"icon: \"si:\"\n", &struct{ Icon CustomIcon }{}, "line 1, column 11:"},
+		{"size: 512XB\n", &struct{ Size ByteSizeField }{}, "line 1, column 10:"},
+		{"limit: 120%\n", &struct{ Limit PercentField }{}, "line 1, column 8:"},
+		{"url: ftp://example.com\n", &struct{ URL URLField }{}, "unsupported scheme ftp"},
+	}
+
+	for _, test := range tests {
+		err := yaml.Unmarshal([]byte(test.yaml), test.target)
+
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%q: expected an error containing %q, got %v", test.yaml, test.expected, err)
+		}
+	}
+
+	var sizes struct{ Size ByteSizeField }
+
+	if err := yaml.Unmarshal([]byte("size: 1.5 KiB\n"), &sizes); err != nil || sizes.Size != 1536 {
+		t.Errorf("expected 1.5 KiB to be 1536 bytes, got %d (%v)", sizes.Size, err)
+	}
+
+	var durations struct{ Cache DurationField }
+
+	if err := yaml.Unmarshal([]byte("cache: 1d12h\n"), &durations); err != nil || time.Duration(durations.Cache) != 36*time.Hour {
+		t.Errorf("expected 1d12h to be 36h, got %v (%v)", time.Duration(durations.Cache), err)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build !slim || widget_fitness
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("fitness", func() Widget { return &Fitness{} })
+}
+
+const (
+	metersPerKilometer = 1000
+	metersPerMile      = 1609.344
+)
+
+type Fitness struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string              `yaml:"service"`
+	ClientID               OptionalEnvString   `yaml:"client-id"`
+	ClientSecret           OptionalEnvString   `yaml:"client-secret"`
+	RefreshToken           OptionalEnvString   `yaml:"refresh-token"`
+	Activities             int                 `yaml:"activities"`
+	WeeklyGoal             float64             `yaml:"weekly-goal"`
+	Units                  feed.UnitSystem     `yaml:"units"`
+	Summary                *feed.Fitness       `yaml:"-"`
+	fitness                *feed.FitnessClient `yaml:"-"`
+}
+
+func (widget *Fitness) Initialize() error {
+	widget.withTitle("Fitness").withCacheDuration(30 * time.Minute)
+
+	switch widget.Service {
+	case feed.FitnessServiceStrava:
+		widget.withTitleURL("https://www.strava.com/dashboard")
+	case feed.FitnessServiceFitbit:
+		widget.withTitleURL("https://www.fitbit.com/")
+	case "garmin":
+		return errors.New("garmin connect does not offer an API for personal use, sync its activities to strava instead")
+	default:
+		return errors.New("fitness service must be either 'strava' or 'fitbit'")
+	}
+
+	if widget.ClientID == "" || widget.ClientSecret == "" || widget.RefreshToken == "" {
+		return errors.New("client-id, client-secret and refresh-token must be specified for fitness widget")
+	}
+
+	if widget.Activities < 0 {
+		widget.Activities = 0
+	} else if widget.Activities == 0 {
+		widget.Activities = 5
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("fitness widget: %v", err)
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("fitness widget: %v", err)
+	}
+
+	widget.fitness = &feed.FitnessClient{
+		Service:              widget.Service,
+		ClientID:             widget.ClientID.String(),
+		ClientSecret:         widget.ClientSecret.String(),
+		RefreshToken:         widget.RefreshToken.String(),
+		OnRefreshTokenChange: widget.storeRefreshToken,
+		Client:               widget.client,
+	}
+
+	widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.fitness.RefreshToken)
+
+	return nil
+}
+
+func (widget *Fitness) storageKey() string {
+	return "fitness:" + widget.Service + ":" + widget.fitness.ClientID
+}
+
+func (widget *Fitness) Update(ctx context.Context) {
+	summary, err := widget.fitness.FetchSummary(ctx, widget.Activities)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Summary = summary
+}
+
+func (widget *Fitness) Render() template.HTML {
+	return widget.render(widget, assets.FitnessTemplate)
+}
+
+func (widget *Fitness) FormatDistance(meters float64) string {
+	if widget.Units == feed.ImperialUnits {
+		return fmt.Sprintf("%.1f mi", meters/metersPerMile)
+	}
+
+	return fmt.Sprintf("%.1f km", meters/metersPerKilometer)
+}
+
+// How much of the weekly goal has been covered, can go over 100
+func (widget *Fitness) GoalPercent() float64 {
+	if widget.WeeklyGoal <= 0 || widget.Summary == nil {
+		return 0
+	}
+
+	perUnit := float64(metersPerKilometer)
+
+	if widget.Units == feed.ImperialUnits {
+		perUnit = metersPerMile
+	}
+
+	return widget.Summary.WeekDistance / perUnit / widget.WeeklyGoal * 100
+}
@@ -0,0 +1,221 @@
+//go:build !slim || widget_flights
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("flights", func() Widget { return &Flights{} })
+}
+
+// Flights that depart further away than this don't change often, so
+// they're checked less frequently to save on requests
+const (
+	flightsSoonWindow      = 24 * time.Hour
+	flightsDistantInterval = 6 * time.Hour
+)
+
+type trackedFlight struct {
+	flights   []feed.Flight
+	err       error
+	fetchedAt time.Time
+}
+
+type Flights struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	APIKey            OptionalEnvString `yaml:"api-key"`
+	Tracked           []struct {
+		Number string `yaml:"number"`
+		Date   string `yaml:"date"`
+		date   time.Time
+	} `yaml:"flights"`
+	HideAfter     DurationField `yaml:"hide-after"`
+	CollapseAfter int           `yaml:"collapse-after"`
+	Flights       []feed.Flight `yaml:"-"`
+	cached        map[string]*trackedFlight
+}
+
+func (widget *Flights) Initialize() error {
+	widget.withTitle("Flights").withCacheDuration(10 * time.Minute)
+
+	if widget.APIKey == "" {
+		return errors.New("api-key must be specified for flights widget")
+	}
+
+	for i := range widget.Tracked {
+		flight := &widget.Tracked[i]
+
+		if flight.Number == "" {
+			return errors.New("missing number for flight in flights widget")
+		}
+
+		date, err := time.Parse(time.DateOnly, flight.Date)
+
+		if err != nil {
+			return fmt.Errorf("invalid date '%s' for flight %s in flights widget, must be in the format of YYYY-MM-DD", flight.Date, flight.Number)
+		}
+
+		flight.date = date
+	}
+
+	if widget.HideAfter <= 0 {
+		widget.HideAfter = DurationField(time.Hour)
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 3
+	}
+
+	widget.cached = make(map[string]*trackedFlight, len(widget.Tracked))
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("flights widget: %v", err)
+	}
+
+	return nil
+}
+
+// Whether the flight should be fetched again, which is never once it has landed or
+// been cancelled
+func (t *trackedFlight) isDue(now time.Time) bool {
+	if t.fetchedAt.IsZero() {
+		return true
+	}
+
+	if t.err == nil && len(t.flights) > 0 && !slices.ContainsFunc(t.flights, func(flight feed.Flight) bool {
+		return !flight.IsFinal()
+	}) {
+		return false
+	}
+
+	if len(t.flights) > 0 && t.flights[0].Departure.Time().Sub(now) < flightsSoonWindow {
+		return true
+	}
+
+	return now.Sub(t.fetchedAt) >= flightsDistantInterval
+}
+
+func (widget *Flights) Update(ctx context.Context) {
+	now := time.Now()
+	var flights []feed.Flight
+	failed := 0
+	var lastErr error
+
+	for i := range widget.Tracked {
+		tracked := &widget.Tracked[i]
+		key := strings.ToUpper(tracked.Number) + "@" + tracked.Date
+
+		// the day after has passed everywhere in the world, so the flight has landed
+		if now.Sub(tracked.date) > 3*24*time.Hour {
+			continue
+		}
+
+		cached, exists := widget.cached[key]
+
+		if !exists {
+			cached = &trackedFlight{}
+			widget.cached[key] = cached
+		}
+
+		if cached.isDue(now) {
+			cached.flights, cached.err = feed.FetchAeroDataBoxFlights(ctx, widget.client, widget.APIKey.String(), tracked.Number, tracked.date)
+			cached.fetchedAt = now
+
+			if cached.err != nil {
+				slog.Error("Failed to fetch flight", "flight", tracked.Number, "date", tracked.Date, "error", cached.err)
+			}
+		}
+
+		if cached.err != nil {
+			failed++
+			lastErr = cached.err
+			continue
+		}
+
+		for _, flight := range cached.flights {
+			if flight.IsFinal() && now.Sub(flight.Arrival.Time()) > time.Duration(widget.HideAfter) {
+				continue
+			}
+
+			flights = append(flights, flight)
+		}
+	}
+
+	var err error
+
+	if failed > 0 && failed == len(widget.Tracked) {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, lastErr)
+	} else if failed > 0 {
+		err = fmt.Errorf("%w: could not fetch %d flight(s)", feed.ErrPartialContent, failed)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	slices.SortStableFunc(flights, func(a, b feed.Flight) int {
+		return a.Departure.Time().Compare(b.Departure.Time())
+	})
+
+	widget.Flights = flights
+}
+
+func (widget *Flights) StatusColor(status string) string {
+	switch status {
+	case "Delayed", "Canceled", "CanceledUncertain", "Diverted":
+		return "color-negative"
+	case "Boarding", "GateClosed", "Departed", "EnRoute", "Approaching":
+		return "color-primary"
+	case "Arrived", "Landed":
+		return "color-positive"
+	}
+
+	return "color-subdue"
+}
+
+// Splits statuses such as EnRoute and GateClosed into words
+func (widget *Flights) StatusLabel(status string) string {
+	var label strings.Builder
+
+	for i, r := range status {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			label.WriteByte(' ')
+		}
+
+		label.WriteRune(r)
+	}
+
+	return label.String()
+}
+
+// Empty unless the flight is running late by more than a few minutes
+func (widget *Flights) DelayLabel(stop feed.FlightStop) string {
+	minutes := int(stop.Delay().Round(time.Minute).Minutes())
+
+	if minutes < 5 {
+		return ""
+	}
+
+	if minutes >= 60 {
+		return fmt.Sprintf("+%dh %02dm", minutes/60, minutes%60)
+	}
+
+	return fmt.Sprintf("+%dm", minutes)
+}
+
+func (widget *Flights) Render() template.HTML {
+	return widget.render(widget, assets.FlightsTemplate)
+}
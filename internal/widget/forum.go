@@ -0,0 +1,132 @@
+//go:build !slim || widget_forum || widget_hacker_news || widget_lobsters
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("forum", func() Widget { return &Forum{} })
+	register("hacker-news", func() Widget { return &Forum{Source: "hacker-news"} })
+	register("lobsters", func() Widget { return &Forum{Source: "lobsters"} })
+}
+
+// The hacker-news and lobsters widget types are forum widgets with their source preset
+type Forum struct {
+	widgetBase          `yaml:",inline"`
+	httpClientOptions   `yaml:",inline"`
+	Source              string           `yaml:"source"`
+	Posts               feed.ForumPosts  `yaml:"-"`
+	Limit               int              `yaml:"limit"`
+	CollapseAfter       int              `yaml:"collapse-after"`
+	SortBy              string           `yaml:"sort-by"`
+	ExtraSortBy         string           `yaml:"extra-sort-by"`
+	CommentsUrlTemplate string           `yaml:"comments-url-template"`
+	InstanceURL         string           `yaml:"instance-url"`
+	CustomURL           string           `yaml:"custom-url"`
+	Tags                []string         `yaml:"tags"`
+	ShowThumbnails      bool             `yaml:"-"`
+	source              feed.ForumSource `yaml:"-"`
+}
+
+func (widget *Forum) Initialize() error {
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("forum widget: %v", err)
+	}
+
+	switch widget.Source {
+	case "hacker-news":
+		widget.
+			withTitle("Hacker News").
+			withTitleURL("https://news.ycombinator.com/").
+			withCacheDuration(30 * time.Minute)
+
+		if !slices.Contains(feed.HackerNewsListings, widget.SortBy) {
+			widget.SortBy = "top"
+		}
+
+		widget.source = &feed.HackerNewsSource{
+			Listing:             widget.SortBy,
+			CommentsUrlTemplate: widget.CommentsUrlTemplate,
+			Client:              widget.client,
+		}
+	case "lobsters":
+		widget.withTitle("Lobsters").withCacheDuration(time.Hour)
+
+		if widget.InstanceURL == "" {
+			widget.withTitleURL("https://lobste.rs")
+		} else {
+			widget.withTitleURL(widget.InstanceURL)
+		}
+
+		if widget.SortBy != "hot" && widget.SortBy != "new" {
+			widget.SortBy = "hot"
+		}
+
+		widget.source = &feed.LobstersSource{
+			CustomURL:   widget.CustomURL,
+			InstanceURL: widget.InstanceURL,
+			SortBy:      widget.SortBy,
+			Tags:        widget.Tags,
+			Client:      widget.client,
+		}
+	case "":
+		return errors.New("source must be specified for forum widget")
+	default:
+		return fmt.Errorf("unknown source '%s' for forum widget, must be either hacker-news or lobsters", widget.Source)
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 15
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *Forum) Update(ctx context.Context) {
+	// more posts than necessary are requested so that
+	// sorting by engagement has something to choose from
+	posts, err := widget.source.FetchPosts(ctx, max(widget.Limit, 40))
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	if widget.ExtraSortBy == "engagement" {
+		posts.CalculateEngagement()
+		posts.SortByEngagement()
+	}
+
+	if widget.Limit < len(posts) {
+		posts = posts[:widget.Limit]
+	}
+
+	widget.Posts = posts
+}
+
+func (widget *Forum) Render() template.HTML {
+	return widget.render(widget, assets.ForumPostsTemplate)
+}
+
+func (widget *Forum) resourceHints(hints *ResourceHints) {
+	if !widget.ShowThumbnails {
+		return
+	}
+
+	for i := 0; i < len(widget.Posts) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Posts[i].ThumbnailUrl)
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !slim || widget_frigate
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("frigate", func() Widget { return &Frigate{} })
+}
+
+type Frigate struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               URLField `yaml:"url"`
+	// where the clips are linked to, for when Frigate is reached through a different address from the browser
+	PublicURL     URLField            `yaml:"public-url"`
+	Cameras       []string            `yaml:"cameras"`
+	Labels        []string            `yaml:"labels"`
+	Limit         int                 `yaml:"limit"`
+	CollapseAfter int                 `yaml:"collapse-after"`
+	Events        []feed.FrigateEvent `yaml:"-"`
+	eventsMu      sync.RWMutex
+	// the events whose thumbnails can be requested through the widget
+	eventIDs map[string]bool
+}
+
+func (widget *Frigate) Initialize() error {
+	widget.withTitle("Frigate").withCacheDuration(time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for frigate widget")
+	}
+
+	if widget.PublicURL == "" {
+		widget.PublicURL = widget.URL
+	}
+
+	widget.withTitleURL(string(widget.PublicURL))
+
+	if widget.Limit <= 0 {
+		widget.Limit = 10
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("frigate widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Frigate) Update(ctx context.Context) {
+	events, err := feed.FetchFrigateEvents(ctx, widget.client, string(widget.URL), widget.Cameras, widget.Labels, widget.Limit)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	eventIDs := make(map[string]bool, len(events))
+
+	for i := range events {
+		eventIDs[events[i].ID] = true
+	}
+
+	widget.eventsMu.Lock()
+	widget.eventIDs = eventIDs
+	widget.eventsMu.Unlock()
+
+	widget.Events = events
+}
+
+func (widget *Frigate) ClipURL(eventID string) string {
+	return strings.TrimRight(string(widget.PublicURL), "/") + "/api/events/" + eventID + "/clip.mp4"
+}
+
+func (widget *Frigate) Render() template.HTML {
+	return widget.render(widget, assets.FrigateTemplate)
+}
+
+// GET /thumbnail/{id} responds with the thumbnail of the event, only for the events that
+// the widget is showing so that it can't be used to get at anything else from Frigate
+func (widget *Frigate) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	id, found := strings.CutPrefix(r.PathValue("path"), "thumbnail/")
+
+	if !found || !feed.IsFrigateEventID(id) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	widget.eventsMu.RLock()
+	shown := widget.eventIDs[id]
+	widget.eventsMu.RUnlock()
+
+	if !shown {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	contentType, image, err := feed.FetchFrigateThumbnail(r.Context(), widget.client, string(widget.URL), id)
+
+	if err != nil {
+		slog.Error("Failed to fetch Frigate thumbnail", "widget", widget.GetSlug(), "event", id, "error", err)
+		http.Error(w, "could not get the thumbnail", http.StatusBadGateway)
+		return
+	}
+
+	// thumbnails don't change once the event has been created
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(image)
+}
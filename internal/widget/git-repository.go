@@ -0,0 +1,96 @@
+//go:build !slim || widget_git_repository
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("git-repository", func() Widget { return &GitRepository{} })
+}
+
+type GitRepository struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Repository        string                      `yaml:"repository"`
+	Path              string                      `yaml:"path"`
+	Token             OptionalEnvString           `yaml:"token"`
+	CommitsLimit      int                         `yaml:"commits-limit"`
+	BranchesLimit     int                         `yaml:"branches-limit"`
+	PullRequestsLimit int                         `yaml:"pull-requests-limit"`
+	IssuesLimit       int                         `yaml:"issues-limit"`
+	Activity          *feed.GitRepositoryActivity `yaml:"-"`
+}
+
+func (widget *GitRepository) Initialize() error {
+	widget.withTitle("Repository").withCacheDuration(30 * time.Minute)
+
+	if (widget.Repository == "") == (widget.Path == "") {
+		return errors.New("one of repository or path must be specified for git-repository widget")
+	}
+
+	if widget.Repository != "" && !strings.HasPrefix(widget.Repository, "http://") && !strings.HasPrefix(widget.Repository, "https://") {
+		return fmt.Errorf("repository %s must be the full URL of the repository", widget.Repository)
+	}
+
+	if widget.Path != "" {
+		if info, err := os.Stat(widget.Path); err != nil || !info.IsDir() {
+			return fmt.Errorf("path '%s' of git-repository widget is not a directory", widget.Path)
+		}
+	}
+
+	if widget.CommitsLimit == 0 || widget.CommitsLimit < -1 {
+		widget.CommitsLimit = 5
+	}
+
+	if widget.BranchesLimit == 0 || widget.BranchesLimit < -1 {
+		widget.BranchesLimit = 3
+	}
+
+	if widget.PullRequestsLimit == 0 || widget.PullRequestsLimit < -1 {
+		widget.PullRequestsLimit = 3
+	}
+
+	if widget.IssuesLimit == 0 || widget.IssuesLimit < -1 {
+		widget.IssuesLimit = 3
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("git-repository widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *GitRepository) Update(ctx context.Context) {
+	activity, err := feed.FetchGitRepositoryActivity(ctx, &feed.GitRepositoryRequest{
+		URL:               widget.Repository,
+		Token:             widget.Token.String(),
+		Path:              widget.Path,
+		CommitsLimit:      widget.CommitsLimit,
+		BranchesLimit:     widget.BranchesLimit,
+		PullRequestsLimit: widget.PullRequestsLimit,
+		IssuesLimit:       widget.IssuesLimit,
+		Client:            widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Activity = activity
+}
+
+func (widget *GitRepository) Render() template.HTML {
+	return widget.render(widget, assets.GitRepositoryTemplate)
+}
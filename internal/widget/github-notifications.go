@@ -0,0 +1,70 @@
+//go:build !slim || widget_github_notifications
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("github-notifications", func() Widget { return &GithubNotifications{} })
+}
+
+type GithubNotifications struct {
+	widgetBase    `yaml:",inline"`
+	Token         OptionalEnvString              `yaml:"token"`
+	Filter        []string                       `yaml:"filter"`
+	Limit         int                            `yaml:"limit"`
+	CollapseAfter int                            `yaml:"collapse-after"`
+	Groups        []feed.GithubNotificationGroup `yaml:"-"`
+}
+
+func (widget *GithubNotifications) Initialize() error {
+	widget.
+		withTitle("GitHub").
+		withTitleURL("https://github.com/notifications").
+		withCacheDuration(5 * time.Minute)
+
+	if widget.Token == "" {
+		return errors.New("token must be specified for github-notifications widget")
+	}
+
+	for i := range widget.Filter {
+		widget.Filter[i] = strings.ToLower(strings.TrimSpace(widget.Filter[i]))
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 30
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 4
+	}
+
+	return nil
+}
+
+func (widget *GithubNotifications) Update(ctx context.Context) {
+	groups, err := feed.FetchGithubNotifications(ctx, string(widget.Token), widget.Filter, widget.Limit)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Groups = groups
+}
+
+func (widget *GithubNotifications) Render() template.HTML {
+	return widget.render(widget, assets.GithubNotificationsTemplate)
+}
+
+func (widget *GithubNotifications) isEmpty() bool {
+	return len(widget.Groups) == 0
+}
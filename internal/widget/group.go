@@ -1,3 +1,5 @@
+//go:build !slim || widget_group
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/assets"
 )
 
+func init() {
+	register("group", func() Widget { return &Group{} })
+}
+
 type Group struct {
 	widgetBase          `yaml:",inline"`
 	containerWidgetBase `yaml:",inline"`
@@ -27,7 +33,7 @@ func (widget *Group) Initialize() error {
 			return errors.New("split columns inside of groups are not supported")
 		}
 
-		if err := widget.Widgets[i].Initialize(); err != nil {
+		if err := Initialize(widget.Widgets[i]); err != nil {
 			return err
 		}
 	}
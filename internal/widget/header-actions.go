@@ -0,0 +1,40 @@
+package widget
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Small links shown on the right side of the header of a widget,
+// such as to the app that the data comes from or its settings
+type headerAction struct {
+	Title   string     `yaml:"title"`
+	URL     string     `yaml:"url"`
+	Icon    CustomIcon `yaml:"icon"`
+	SameTab bool       `yaml:"same-tab"`
+}
+
+type headerActions []headerAction
+
+func (a *headerActions) UnmarshalYAML(node *yaml.Node) error {
+	var actions []headerAction
+
+	if err := node.Decode(&actions); err != nil {
+		return err
+	}
+
+	for i := range actions {
+		if actions[i].URL == "" {
+			return fmt.Errorf("header action %d has no url", i+1)
+		}
+
+		if actions[i].Title == "" && actions[i].Icon.URL == "" {
+			return fmt.Errorf("header action %d needs either a title or an icon", i+1)
+		}
+	}
+
+	*a = actions
+
+	return nil
+}
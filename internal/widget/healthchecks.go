@@ -0,0 +1,113 @@
+//go:build !slim || widget_healthchecks
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("healthchecks", func() Widget { return &Healthchecks{} })
+}
+
+type Healthchecks struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               string             `yaml:"url"`
+	APIKey            OptionalEnvString  `yaml:"api-key"`
+	Tags              []string           `yaml:"tags"`
+	ShowAll           bool               `yaml:"show-all"`
+	CollapseAfter     int                `yaml:"collapse-after"`
+	Checks            []feed.Healthcheck `yaml:"-"`
+	Total             int                `yaml:"-"`
+	Failing           int                `yaml:"-"`
+}
+
+func (widget *Healthchecks) Initialize() error {
+	widget.withTitle("Healthchecks").withCacheDuration(time.Minute)
+
+	if widget.APIKey == "" {
+		return errors.New("api-key must be specified for healthchecks widget")
+	}
+
+	if widget.URL == "" {
+		widget.URL = "https://healthchecks.io"
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("healthchecks widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Healthchecks) Update(ctx context.Context) {
+	checks, err := feed.FetchHealthchecks(ctx, widget.client, widget.URL, widget.APIKey.String(), widget.Tags)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Total = len(checks)
+	widget.Failing = 0
+	shown := make([]feed.Healthcheck, 0, len(checks))
+
+	for i := range checks {
+		if checks[i].IsFailing() {
+			widget.Failing++
+		}
+
+		// the checks that are running are shown too, since they're also the ones
+		// most likely to be late, just not yet
+		if widget.ShowAll || checks[i].IsFailing() || checks[i].Status == feed.HealthcheckStatusStarted {
+			shown = append(shown, checks[i])
+		}
+	}
+
+	widget.Checks = shown
+}
+
+func (widget *Healthchecks) StatusColor(status string) string {
+	switch status {
+	case feed.HealthcheckStatusDown:
+		return "color-negative"
+	case feed.HealthcheckStatusGrace, feed.HealthcheckStatusStarted:
+		return "color-primary"
+	case feed.HealthcheckStatusUp:
+		return "color-positive"
+	}
+
+	return "color-subdue"
+}
+
+func (widget *Healthchecks) StatusLabel(status string) string {
+	switch status {
+	case feed.HealthcheckStatusGrace:
+		return "Late"
+	case feed.HealthcheckStatusStarted:
+		return "Running"
+	case feed.HealthcheckStatusDown:
+		return "Down"
+	case feed.HealthcheckStatusUp:
+		return "Up"
+	case feed.HealthcheckStatusPaused:
+		return "Paused"
+	}
+
+	return "New"
+}
+
+func (widget *Healthchecks) Render() template.HTML {
+	return widget.render(widget, assets.HealthchecksTemplate)
+}
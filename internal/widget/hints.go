@@ -0,0 +1,89 @@
+package widget
+
+import (
+	"net/url"
+	"slices"
+	"sync"
+)
+
+const (
+	// each preconnect keeps a connection open, so only a handful are worth it
+	maxPreconnectOrigins = 6
+	maxPrefetchedImages  = 12
+	// the rest are usually collapsed or further down the page
+	prefetchedImagesPerWidget = 3
+)
+
+// Hints for the browser about what the content of a page is going to need, which
+// let it connect to other origins and download images while it's still waiting
+// for the content to load. Derived from the configs and the data of the widgets
+type ResourceHints struct {
+	// origins such as https://i.ytimg.com
+	Preconnect []string
+	Prefetch   []string
+}
+
+// Implemented by widgets which show images from other origins
+type resourceHinter interface {
+	resourceHints(hints *ResourceHints)
+}
+
+// Returns an empty string for relative URLs, which don't need a hint
+func urlOrigin(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+func (h *ResourceHints) addOrigin(rawURL string) {
+	origin := urlOrigin(rawURL)
+
+	if origin == "" || len(h.Preconnect) >= maxPreconnectOrigins || slices.Contains(h.Preconnect, origin) {
+		return
+	}
+
+	h.Preconnect = append(h.Preconnect, origin)
+}
+
+func (h *ResourceHints) addImage(rawURL string) {
+	if urlOrigin(rawURL) == "" || len(h.Prefetch) >= maxPrefetchedImages || slices.Contains(h.Prefetch, rawURL) {
+		return
+	}
+
+	h.Prefetch = append(h.Prefetch, rawURL)
+	h.addOrigin(rawURL)
+}
+
+// Widgets which are being updated get skipped rather than waited for
+// since their data is about to change and the hints are only an optimization
+func CollectResourceHints(widgets Widgets) ResourceHints {
+	var hints ResourceHints
+
+	WalkWidgets(widgets, func(widget Widget) {
+		mutex := widget.(interface{ updateMutex() *sync.Mutex }).updateMutex()
+
+		if !mutex.TryLock() {
+			return
+		}
+
+		defer mutex.Unlock()
+
+		if hinter, ok := widget.(resourceHinter); ok {
+			hinter.resourceHints(&hints)
+		}
+
+		widget.(interface{ headerActionHints(*ResourceHints) }).headerActionHints(&hints)
+	})
+
+	return hints
+}
+
+func (w *widgetBase) headerActionHints(hints *ResourceHints) {
+	for i := range w.HeaderActions {
+		hints.addOrigin(w.HeaderActions[i].Icon.URL)
+	}
+}
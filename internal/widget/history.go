@@ -0,0 +1,325 @@
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Values are averaged into buckets so that a week of history takes up
+// the same amount of space regardless of how often a widget updates
+const historyBucketDuration = 5 * time.Minute
+const historyRetention = 7 * 24 * time.Hour
+const historyBucketsPerSeries = int(historyRetention / historyBucketDuration)
+const historyFileName = "history.json"
+
+// Long-term series are averaged over an hour instead, which lets them cover a
+// month for things that are summarised rather than charted, such as uptime
+const longTermHistoryBucketDuration = time.Hour
+const longTermHistoryRetention = 31 * 24 * time.Hour
+const longTermHistoryBucketsPerSeries = int(longTermHistoryRetention / longTermHistoryBucketDuration)
+
+type historyPoint struct {
+	bucket int64
+	value  float64
+	count  int
+}
+
+// A fixed size ring buffer of points ordered from oldest to newest
+type historySeries struct {
+	points []historyPoint
+	start  int
+	length int
+}
+
+func newHistorySeries(buckets int) *historySeries {
+	return &historySeries{points: make([]historyPoint, buckets)}
+}
+
+func (s *historySeries) at(i int) *historyPoint {
+	return &s.points[(s.start+i)%len(s.points)]
+}
+
+func (s *historySeries) add(bucket int64, value float64) {
+	if s.length > 0 {
+		last := s.at(s.length - 1)
+
+		if last.bucket == bucket {
+			last.value = (last.value*float64(last.count) + value) / float64(last.count+1)
+			last.count++
+			return
+		}
+
+		// the clock went backwards, the value can't be placed so it gets dropped
+		if last.bucket > bucket {
+			return
+		}
+	}
+
+	if s.length < len(s.points) {
+		s.length++
+	} else {
+		s.start = (s.start + 1) % len(s.points)
+	}
+
+	*s.at(s.length - 1) = historyPoint{bucket: bucket, value: value, count: 1}
+}
+
+func (s *historySeries) lastBucket() int64 {
+	if s.length == 0 {
+		return 0
+	}
+
+	return s.at(s.length - 1).bucket
+}
+
+// Stores the history of numeric values produced by widgets, such as response times
+// or CPU usage, so that they can be charted over a longer period of time. If a path
+// is given, the history gets saved to disk and survives restarts.
+type HistoryStore struct {
+	mu             sync.Mutex
+	path           string
+	series         map[string]*historySeries
+	longTermSeries map[string]*historySeries
+	dirty          bool
+}
+
+func NewHistoryStore(directory string) (*HistoryStore, error) {
+	store := &HistoryStore{
+		series:         make(map[string]*historySeries),
+		longTermSeries: make(map[string]*historySeries),
+	}
+
+	if directory == "" {
+		return store, nil
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %v", err)
+	}
+
+	store.path = filepath.Join(directory, historyFileName)
+
+	if err := store.load(); err != nil {
+		return nil, fmt.Errorf("loading history from %s: %v", store.path, err)
+	}
+
+	return store, nil
+}
+
+type historyFile struct {
+	Series         map[string][][2]float64 `json:"series"`
+	LongTermSeries map[string][][2]float64 `json:"long-term-series,omitempty"`
+}
+
+func (s *HistoryStore) load() error {
+	contents, err := os.ReadFile(s.path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var file historyFile
+
+	if err := json.Unmarshal(contents, &file); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	loadHistorySeries(s.series, file.Series, historyBucketsPerSeries, historyBucket(now.Add(-historyRetention)))
+	loadHistorySeries(
+		s.longTermSeries, file.LongTermSeries,
+		longTermHistoryBucketsPerSeries, longTermHistoryBucket(now.Add(-longTermHistoryRetention)),
+	)
+
+	return nil
+}
+
+func loadHistorySeries(series map[string]*historySeries, saved map[string][][2]float64, buckets int, oldestBucket int64) {
+	for key, points := range saved {
+		loaded := newHistorySeries(buckets)
+
+		for _, point := range points {
+			if bucket := int64(point[0]); bucket > oldestBucket {
+				loaded.add(bucket, point[1])
+			}
+		}
+
+		if loaded.length > 0 {
+			series[key] = loaded
+		}
+	}
+}
+
+// Removes the series that have nothing newer than the oldest bucket and returns the points of the rest
+func saveHistorySeries(series map[string]*historySeries, oldestBucket int64) map[string][][2]float64 {
+	saved := make(map[string][][2]float64, len(series))
+
+	for key, current := range series {
+		if current.lastBucket() <= oldestBucket {
+			delete(series, key)
+			continue
+		}
+
+		points := make([][2]float64, current.length)
+
+		for i := range current.length {
+			point := current.at(i)
+			points[i] = [2]float64{float64(point.bucket), point.value}
+		}
+
+		saved[key] = points
+	}
+
+	return saved
+}
+
+// Writes the history to disk if anything has changed since it was last saved
+func (s *HistoryStore) Save() error {
+	s.mu.Lock()
+
+	if s.path == "" || !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	file := historyFile{
+		Series:         saveHistorySeries(s.series, historyBucket(now.Add(-historyRetention))),
+		LongTermSeries: saveHistorySeries(s.longTermSeries, longTermHistoryBucket(now.Add(-longTermHistoryRetention))),
+	}
+
+	s.dirty = false
+	s.mu.Unlock()
+
+	contents, err := json.Marshal(file)
+
+	if err != nil {
+		return err
+	}
+
+	// written to a temporary file first so that a crash
+	// while saving doesn't leave behind a corrupted file
+	temporaryPath := s.path + ".tmp"
+
+	if err := os.WriteFile(temporaryPath, contents, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(temporaryPath, s.path)
+}
+
+func (s *HistoryStore) SaveEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := s.Save(); err != nil {
+			slog.Error("Failed to save history", "path", s.path, "error", err)
+		}
+	}
+}
+
+func historyBucket(t time.Time) int64 {
+	return t.Unix() / int64(historyBucketDuration.Seconds())
+}
+
+func longTermHistoryBucket(t time.Time) int64 {
+	return t.Unix() / int64(longTermHistoryBucketDuration.Seconds())
+}
+
+func (s *HistoryStore) Record(key string, value float64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(s.series, historyBucketsPerSeries, key, historyBucket(at), value)
+}
+
+// Same as Record, except that the value is averaged over an hour and kept for a month
+func (s *HistoryStore) RecordLongTerm(key string, value float64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(s.longTermSeries, longTermHistoryBucketsPerSeries, key, longTermHistoryBucket(at), value)
+}
+
+func (s *HistoryStore) record(series map[string]*historySeries, buckets int, key string, bucket int64, value float64) {
+	current, exists := series[key]
+
+	if !exists {
+		current = newHistorySeries(buckets)
+		series[key] = current
+	}
+
+	current.add(bucket, value)
+	s.dirty = true
+}
+
+// Returns the values recorded since the given time, ordered from oldest to newest
+func (s *HistoryStore) Values(key string, since time.Time) []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return seriesValuesSince(s.series[key], historyBucket(since))
+}
+
+// Returns the hourly averages recorded through RecordLongTerm since the given time
+func (s *HistoryStore) LongTermValues(key string, since time.Time) []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return seriesValuesSince(s.longTermSeries[key], longTermHistoryBucket(since))
+}
+
+func seriesValuesSince(series *historySeries, sinceBucket int64) []float64 {
+	if series == nil {
+		return nil
+	}
+
+	values := make([]float64, 0, series.length)
+
+	for i := range series.length {
+		if point := series.at(i); point.bucket >= sinceBucket {
+			values = append(values, point.value)
+		}
+	}
+
+	return values
+}
+
+func validateChartPeriod(period DurationField) error {
+	if time.Duration(period) > historyRetention {
+		return errors.New("chart-period cannot be longer than 7d")
+	}
+
+	if period > 0 && time.Duration(period) < 2*historyBucketDuration {
+		return errors.New("chart-period must be at least 10m")
+	}
+
+	return nil
+}
+
+func formatChartPeriod(period time.Duration) string {
+	plural := func(value int, unit string) string {
+		if value == 1 {
+			return unit
+		}
+
+		return fmt.Sprintf("%d %ss", value, unit)
+	}
+
+	switch {
+	case period%(24*time.Hour) == 0:
+		return plural(int(period/(24*time.Hour)), "day")
+	case period%time.Hour == 0:
+		return plural(int(period/time.Hour), "hour")
+	}
+
+	return plural(int(period/time.Minute), "minute")
+}
@@ -1,9 +1,15 @@
+//go:build !slim || widget_html
+
 package widget
 
 import (
 	"html/template"
 )
 
+func init() {
+	register("html", func() Widget { return &HTML{} })
+}
+
 type HTML struct {
 	widgetBase `yaml:",inline"`
 	Source     template.HTML `yaml:"source"`
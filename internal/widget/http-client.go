@@ -0,0 +1,101 @@
+package widget
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// Options for widgets that fetch their data from user specified URLs,
+// anything left empty falls back to the options set in the server config
+type httpClientOptions struct {
+	ProxyURL      OptionalEnvString            `yaml:"proxy-url"`
+	Timeout       DurationField                `yaml:"timeout"`
+	AllowInsecure bool                         `yaml:"allow-insecure"`
+	CAFile        string                       `yaml:"ca-file"`
+	ClientCert    string                       `yaml:"client-cert"`
+	ClientKey     string                       `yaml:"client-key"`
+	IPPreference  string                       `yaml:"ip-preference"`
+	DNSResolver   string                       `yaml:"dns-resolver"`
+	Headers       map[string]OptionalEnvString `yaml:"headers"`
+	Language      string                       `yaml:"language"`
+	Cookies       map[string]OptionalEnvString `yaml:"cookies"`
+	Login         *httpLoginOptions            `yaml:"login"`
+	client        feed.RequestDoer             `yaml:"-"`
+}
+
+type httpLoginOptions struct {
+	URL     OptionalEnvString            `yaml:"url"`
+	Method  string                       `yaml:"method"`
+	Headers map[string]OptionalEnvString `yaml:"headers"`
+	Form    map[string]OptionalEnvString `yaml:"form"`
+	Body    OptionalEnvString            `yaml:"body"`
+}
+
+func envStringMap(values map[string]OptionalEnvString) map[string]string {
+	result := make(map[string]string, len(values))
+
+	for key, value := range values {
+		result[key] = value.String()
+	}
+
+	return result
+}
+
+func (o *httpClientOptions) initializeClient() error {
+	var login *feed.LoginOptions
+
+	if o.Login != nil {
+		if o.Login.URL == "" {
+			return errors.New("login url must be specified")
+		}
+
+		login = &feed.LoginOptions{
+			URL:     o.Login.URL.String(),
+			Method:  strings.ToUpper(o.Login.Method),
+			Headers: envStringMap(o.Login.Headers),
+			Form:    envStringMap(o.Login.Form),
+			Body:    o.Login.Body.String(),
+		}
+	}
+
+	headers := envStringMap(o.Headers)
+
+	if o.Language != "" && !hasHeader(headers, "Accept-Language") {
+		headers["Accept-Language"] = o.Language
+	}
+
+	client, err := initializationSettings().Clients.NewClient(feed.ClientOptions{
+		ProxyURL:       o.ProxyURL.String(),
+		Timeout:        time.Duration(o.Timeout),
+		AllowInsecure:  o.AllowInsecure,
+		CAFile:         o.CAFile,
+		ClientCertFile: o.ClientCert,
+		ClientKeyFile:  o.ClientKey,
+		IPPreference:   o.IPPreference,
+		DNSResolver:    o.DNSResolver,
+		Headers:        headers,
+		Cookies:        envStringMap(o.Cookies),
+		Login:          login,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	o.client = client
+
+	return nil
+}
+
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+
+	return false
+}
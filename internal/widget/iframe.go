@@ -1,3 +1,5 @@
+//go:build !slim || widget_iframe
+
 package widget
 
 import (
@@ -5,15 +7,72 @@ import (
 	"fmt"
 	"html/template"
 	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/glanceapp/glance/internal/assets"
+
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	register("iframe", func() Widget { return &IFrame{} })
+}
+
 type IFrame struct {
 	widgetBase `yaml:",inline"`
 	cachedHTML template.HTML `yaml:"-"`
 	Source     string        `yaml:"source"`
 	Height     int           `yaml:"height"`
+	Sandbox    iframeSandbox `yaml:"sandbox"`
+}
+
+var iframeSandboxTokens = []string{
+	"allow-downloads",
+	"allow-forms",
+	"allow-modals",
+	"allow-orientation-lock",
+	"allow-pointer-lock",
+	"allow-popups",
+	"allow-popups-to-escape-sandbox",
+	"allow-presentation",
+	"allow-same-origin",
+	"allow-scripts",
+	"allow-storage-access-by-user-activation",
+	"allow-top-navigation",
+	"allow-top-navigation-by-user-activation",
+	"allow-top-navigation-to-custom-protocols",
+}
+
+// Either true for all of the restrictions or a list of the
+// restrictions to lift, such as allow-scripts and allow-forms
+type iframeSandbox struct {
+	Enabled bool
+	Tokens  []string
+}
+
+func (s *iframeSandbox) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Enabled)
+	}
+
+	if err := node.Decode(&s.Tokens); err != nil {
+		return err
+	}
+
+	for _, token := range s.Tokens {
+		if !slices.Contains(iframeSandboxTokens, token) {
+			return fmt.Errorf("line %d: unknown sandbox value '%s'", node.Line, token)
+		}
+	}
+
+	s.Enabled = true
+
+	return nil
+}
+
+func (s *iframeSandbox) Attribute() string {
+	return strings.Join(s.Tokens, " ")
 }
 
 func (widget *IFrame) Initialize() error {
@@ -29,7 +88,7 @@ func (widget *IFrame) Initialize() error {
 		return fmt.Errorf("invalid source for iframe: %v", err)
 	}
 
-	if widget.Height == 50 {
+	if widget.Height == 0 {
 		widget.Height = 300
 	} else if widget.Height < 50 {
 		widget.Height = 50
@@ -0,0 +1,207 @@
+//go:build !slim || widget_latency
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("latency", func() Widget { return &Latency{} })
+}
+
+type latencyTarget struct {
+	Name string `yaml:"name"`
+	Host string `yaml:"host"`
+}
+
+type latencySource struct {
+	Type          string            `yaml:"type"`
+	Name          string            `yaml:"name"`
+	URL           string            `yaml:"url"`
+	Token         OptionalEnvString `yaml:"token"`
+	AllowInsecure bool              `yaml:"allow-insecure"`
+	Unreachable   bool              `yaml:"-"`
+}
+
+func (s *latencySource) historyKey(host string) string {
+	if s.URL == "" {
+		return "latency:local:" + host
+	}
+
+	return "latency:" + s.URL + ":" + host
+}
+
+type latencyCell struct {
+	// nil when the source couldn't be reached
+	Result    *feed.LatencyResult
+	Threshold *threshold
+	Chart     string
+}
+
+func (c *latencyCell) Milliseconds() float64 {
+	return float64(c.Result.Latency.Microseconds()) / 1000
+}
+
+type latencyRow struct {
+	Name  string
+	Host  string
+	Cells []latencyCell
+}
+
+type Latency struct {
+	widgetBase  `yaml:",inline"`
+	Targets     []latencyTarget `yaml:"targets"`
+	Sources     []latencySource `yaml:"sources"`
+	Traceroute  bool            `yaml:"traceroute"`
+	Thresholds  thresholds      `yaml:"thresholds"`
+	ChartPeriod DurationField   `yaml:"chart-period"`
+	ChartTitle  string          `yaml:"-"`
+	Rows        []latencyRow    `yaml:"-"`
+	requests    []*feed.LatencyRequest
+}
+
+func (widget *Latency) Initialize() error {
+	widget.withTitle("Latency").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Targets) == 0 {
+		return errors.New("no targets specified for latency widget")
+	}
+
+	if len(widget.Targets) > feed.MaxLatencyTargets {
+		return fmt.Errorf("latency widget can have at most %d targets", feed.MaxLatencyTargets)
+	}
+
+	if len(widget.Sources) == 0 {
+		widget.Sources = []latencySource{{Type: "local"}}
+	}
+
+	if widget.ChartPeriod == 0 {
+		widget.ChartPeriod = DurationField(24 * time.Hour)
+	}
+
+	if err := validateChartPeriod(widget.ChartPeriod); err != nil {
+		return fmt.Errorf("latency widget: %v", err)
+	}
+
+	widget.ChartTitle = "Average latency over the last " + formatChartPeriod(time.Duration(widget.ChartPeriod))
+
+	if err := widget.Thresholds.validate(); err != nil {
+		return fmt.Errorf("invalid thresholds in latency widget: %v", err)
+	}
+
+	hosts := make([]string, len(widget.Targets))
+
+	for i := range widget.Targets {
+		target := &widget.Targets[i]
+
+		if err := feed.ValidateLatencyTarget(target.Host); err != nil {
+			return fmt.Errorf("target %d in latency widget: %v", i+1, err)
+		}
+
+		if target.Name == "" {
+			target.Name = target.Host
+		}
+
+		hosts[i] = target.Host
+	}
+
+	widget.requests = make([]*feed.LatencyRequest, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.Type == "" {
+			source.Type = "local"
+		}
+
+		switch source.Type {
+		case "local":
+			source.URL = ""
+
+			if source.Name == "" {
+				source.Name = "Local"
+			}
+		case "remote":
+			if source.URL == "" {
+				return fmt.Errorf("missing url for remote source in latency widget")
+			}
+
+			if source.Name == "" {
+				source.Name = source.URL
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in latency widget, must be one of local or remote", source.Type)
+		}
+
+		widget.requests[i] = &feed.LatencyRequest{
+			URL:           source.URL,
+			Token:         source.Token.String(),
+			AllowInsecure: source.AllowInsecure,
+			Targets:       hosts,
+			Traceroute:    widget.Traceroute,
+		}
+	}
+
+	return nil
+}
+
+func (widget *Latency) Update(ctx context.Context) {
+	results, err := feed.FetchLatenciesForSources(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-time.Duration(widget.ChartPeriod))
+	history := widget.Providers.History
+	rows := make([]latencyRow, len(widget.Targets))
+
+	for i := range widget.Sources {
+		widget.Sources[i].Unreachable = results[i] == nil
+	}
+
+	for t := range widget.Targets {
+		target := &widget.Targets[t]
+		row := &rows[t]
+		row.Name = target.Name
+		row.Host = target.Host
+		row.Cells = make([]latencyCell, len(widget.Sources))
+
+		for s := range widget.Sources {
+			cell := &row.Cells[s]
+			cell.Threshold = noThreshold
+
+			// remote instances answer in the order of the targets they were given
+			if t >= len(results[s]) {
+				continue
+			}
+
+			cell.Result = &results[s][t]
+			key := widget.Sources[s].historyKey(target.Host)
+
+			if cell.Result.Error == "" {
+				cell.Threshold = widget.Thresholds.Match(cell.Milliseconds())
+				history.Record(key, cell.Milliseconds(), now)
+			}
+
+			if values := history.Values(key, since); len(values) >= 2 {
+				cell.Chart = feed.SvgPolylineCoordsFromYValues(100, 30, values)
+			}
+		}
+	}
+
+	widget.Rows = rows
+}
+
+func (widget *Latency) Render() template.HTML {
+	return widget.render(widget, assets.LatencyTemplate)
+}
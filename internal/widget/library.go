@@ -0,0 +1,144 @@
+//go:build !slim || widget_library
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("library", func() Widget { return &Library{} })
+}
+
+// How far back to look for overdue items, libraries drop returned items from the
+// calendar so anything still in it hasn't been returned yet
+const libraryOverdueLookback = 60 * 24 * time.Hour
+
+type libraryLoan struct {
+	Title string
+	Card  string
+	Due   time.Time
+	// negative when overdue
+	DaysLeft int
+}
+
+type Library struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Cards             []struct {
+		Name string            `yaml:"name"`
+		URL  OptionalEnvString `yaml:"url"`
+	} `yaml:"cards"`
+	DueSoon       int           `yaml:"due-soon"`
+	Days          int           `yaml:"days"`
+	CollapseAfter int           `yaml:"collapse-after"`
+	Loans         []libraryLoan `yaml:"-"`
+}
+
+func (widget *Library) Initialize() error {
+	widget.withTitle("Library").withCacheDuration(3 * time.Hour)
+
+	if len(widget.Cards) == 0 {
+		return errors.New("no cards specified for library widget")
+	}
+
+	for i := range widget.Cards {
+		if widget.Cards[i].URL == "" {
+			return errors.New("missing url for card in library widget")
+		}
+	}
+
+	if widget.DueSoon <= 0 {
+		widget.DueSoon = 3
+	}
+
+	if widget.Days <= 0 {
+		widget.Days = 90
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("library widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Library) Update(ctx context.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	requests := make([]*feed.CalendarEventsRequest, len(widget.Cards))
+
+	for i := range widget.Cards {
+		requests[i] = &feed.CalendarEventsRequest{
+			URL:      widget.Cards[i].URL.String(),
+			Name:     widget.Cards[i].Name,
+			From:     today.Add(-libraryOverdueLookback),
+			To:       today.AddDate(0, 0, widget.Days),
+			Location: time.Local,
+			Client:   widget.client,
+		}
+	}
+
+	events, err := feed.FetchCalendarEvents(ctx, requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	loans := make([]libraryLoan, 0, len(events))
+
+	for i := range events {
+		event := &events[i]
+		due := event.StartsAt
+
+		// all day events are dates without a timezone and should not be shifted
+		if !event.IsAllDay {
+			due = due.In(time.Local)
+		}
+
+		dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.Local)
+
+		loans = append(loans, libraryLoan{
+			Title:    event.Title,
+			Card:     event.Calendar,
+			Due:      dueDay,
+			DaysLeft: int(dueDay.Sub(today).Round(24*time.Hour) / (24 * time.Hour)),
+		})
+	}
+
+	widget.Loans = loans
+}
+
+func (widget *Library) IsDueSoon(loan libraryLoan) bool {
+	return loan.DaysLeft >= 0 && loan.DaysLeft <= widget.DueSoon
+}
+
+func (l libraryLoan) DueLabel() string {
+	switch {
+	case l.DaysLeft < -1:
+		return fmt.Sprintf("%d days overdue", -l.DaysLeft)
+	case l.DaysLeft == -1:
+		return "1 day overdue"
+	case l.DaysLeft == 0:
+		return "Due today"
+	case l.DaysLeft == 1:
+		return "Due tomorrow"
+	}
+
+	return fmt.Sprintf("Due in %d days", l.DaysLeft)
+}
+
+func (widget *Library) Render() template.HTML {
+	return widget.render(widget, assets.LibraryTemplate)
+}
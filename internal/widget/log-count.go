@@ -0,0 +1,184 @@
+//go:build !slim || widget_log_count
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("log-count", func() Widget { return &LogCount{} })
+}
+
+type logCountQuery struct {
+	Name       string     `yaml:"name"`
+	Query      string     `yaml:"query"`
+	Index      string     `yaml:"index"`
+	Thresholds thresholds `yaml:"thresholds"`
+	Count      int        `yaml:"-"`
+	Chart      string     `yaml:"-"`
+	Threshold  *threshold `yaml:"-"`
+	Failed     bool       `yaml:"-"`
+}
+
+type LogCount struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Provider          string            `yaml:"provider"`
+	URL               string            `yaml:"url"`
+	Username          string            `yaml:"username"`
+	Password          OptionalEnvString `yaml:"password"`
+	APIKey            OptionalEnvString `yaml:"api-key"`
+	Tenant            string            `yaml:"tenant"`
+	Index             string            `yaml:"index"`
+	TimestampField    string            `yaml:"timestamp-field"`
+	Period            DurationField     `yaml:"period"`
+	ChartPeriod       DurationField     `yaml:"chart-period"`
+	Thresholds        thresholds        `yaml:"thresholds"`
+	Queries           []logCountQuery   `yaml:"queries"`
+	PeriodLabel       string            `yaml:"-"`
+	ChartTitle        string            `yaml:"-"`
+	requests          []*feed.LogCountRequest
+}
+
+func (widget *LogCount) Initialize() error {
+	widget.withTitle("Log Count").withCacheDuration(5 * time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for log-count widget")
+	}
+
+	switch widget.Provider {
+	case "":
+		widget.Provider = feed.LogCountProviderLoki
+	case feed.LogCountProviderLoki, feed.LogCountProviderElasticsearch:
+	default:
+		return fmt.Errorf("invalid provider '%s' for log-count widget, must be either loki or elasticsearch", widget.Provider)
+	}
+
+	if widget.Period == 0 {
+		widget.Period = DurationField(time.Hour)
+	} else if time.Duration(widget.Period) < time.Minute {
+		return errors.New("period of log-count widget must be at least 1m")
+	}
+
+	if widget.ChartPeriod == 0 {
+		widget.ChartPeriod = widget.Period * 24
+	}
+
+	if widget.ChartPeriod%widget.Period != 0 {
+		return errors.New("chart-period of log-count widget must be a multiple of period")
+	}
+
+	if buckets := widget.ChartPeriod / widget.Period; buckets > 100 {
+		return errors.New("chart-period of log-count widget can be at most 100 times period")
+	}
+
+	if widget.TimestampField == "" {
+		widget.TimestampField = "@timestamp"
+	}
+
+	if len(widget.Queries) == 0 {
+		return errors.New("no queries specified for log-count widget")
+	}
+
+	if err := widget.Thresholds.validate(); err != nil {
+		return fmt.Errorf("invalid thresholds in log-count widget: %v", err)
+	}
+
+	widget.requests = make([]*feed.LogCountRequest, len(widget.Queries))
+
+	for i := range widget.Queries {
+		query := &widget.Queries[i]
+
+		if query.Query == "" {
+			return fmt.Errorf("query %d of log-count widget must have a query", i+1)
+		}
+
+		if query.Name == "" {
+			query.Name = query.Query
+		}
+
+		if query.Index == "" {
+			query.Index = widget.Index
+		}
+
+		if widget.Provider == feed.LogCountProviderElasticsearch && query.Index == "" {
+			return fmt.Errorf("index must be specified for query %d of log-count widget", i+1)
+		}
+
+		if query.Thresholds == nil {
+			query.Thresholds = widget.Thresholds
+		} else if err := query.Thresholds.validate(); err != nil {
+			return fmt.Errorf("invalid thresholds for query %d in log-count widget: %v", i+1, err)
+		}
+
+		widget.requests[i] = &feed.LogCountRequest{
+			Provider:       widget.Provider,
+			URL:            widget.URL,
+			Username:       widget.Username,
+			Password:       widget.Password.String(),
+			APIKey:         widget.APIKey.String(),
+			Tenant:         widget.Tenant,
+			Query:          query.Query,
+			Index:          query.Index,
+			TimestampField: widget.TimestampField,
+			Period:         time.Duration(widget.Period),
+			ChartPeriod:    time.Duration(widget.ChartPeriod),
+		}
+	}
+
+	widget.PeriodLabel = "in the last " + formatChartPeriod(time.Duration(widget.Period))
+	widget.ChartTitle = "Count of each " + formatChartPeriod(time.Duration(widget.Period)) +
+		" over the last " + formatChartPeriod(time.Duration(widget.ChartPeriod))
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("log-count widget: %v", err)
+	}
+
+	for i := range widget.requests {
+		widget.requests[i].Client = widget.client
+	}
+
+	return nil
+}
+
+func (widget *LogCount) Update(ctx context.Context) {
+	counts, err := feed.FetchLogCounts(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range widget.Queries {
+		query := &widget.Queries[i]
+		query.Failed = counts[i] == nil
+
+		if query.Failed {
+			continue
+		}
+
+		query.Count = counts[i].Count
+		query.Threshold = query.Thresholds.Match(float64(counts[i].Count))
+		values := make([]float64, len(counts[i].Counts))
+
+		for j, count := range counts[i].Counts {
+			values[j] = float64(count)
+		}
+
+		// starting from zero so that a flat line means there's nothing rather than a steady amount
+		query.Chart = feed.SvgPolylineCoordsFromYValuesInRange(100, 30, values, 0, max(slices.Max(values), 1))
+	}
+}
+
+func (widget *LogCount) Render() template.HTML {
+	return widget.render(widget, assets.LogCountTemplate)
+}
@@ -0,0 +1,137 @@
+//go:build !slim || widget_log_tail
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("log-tail", func() Widget { return &LogTail{} })
+}
+
+type LogTail struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Path              string            `yaml:"path"`
+	URL               string            `yaml:"url"`
+	Query             string            `yaml:"query"`
+	Username          string            `yaml:"username"`
+	Password          OptionalEnvString `yaml:"password"`
+	Tenant            string            `yaml:"tenant"`
+	Since             DurationField     `yaml:"since"`
+	Patterns          []string          `yaml:"patterns"`
+	Limit             int               `yaml:"limit"`
+	MaxLineLength     int               `yaml:"max-line-length"`
+	Wrap              bool              `yaml:"wrap"`
+	Lines             []feed.LogLine    `yaml:"-"`
+	patterns          []*regexp.Regexp
+}
+
+func (widget *LogTail) Initialize() error {
+	widget.withTitle("Logs").withCacheDuration(time.Minute)
+
+	if (widget.Path == "") == (widget.URL == "") {
+		return errors.New("one of path or url must be specified for log-tail widget")
+	}
+
+	if widget.URL != "" && widget.Query == "" {
+		return errors.New("query must be specified for log-tail widget when using loki")
+	}
+
+	for _, pattern := range widget.Patterns {
+		compiled, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s' in log-tail widget: %v", pattern, err)
+		}
+
+		widget.patterns = append(widget.patterns, compiled)
+	}
+
+	if widget.Since <= 0 {
+		widget.Since = DurationField(time.Hour)
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 20
+	} else if widget.Limit > 200 {
+		return errors.New("limit for log-tail widget must be at most 200")
+	}
+
+	if widget.MaxLineLength <= 0 {
+		widget.MaxLineLength = 300
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("log-tail widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *LogTail) Update(ctx context.Context) {
+	lines, err := feed.FetchLogTail(ctx, &feed.LogTailRequest{
+		Path:          widget.Path,
+		URL:           widget.URL,
+		Query:         widget.Query,
+		Username:      widget.Username,
+		Password:      widget.Password.String(),
+		Tenant:        widget.Tenant,
+		Since:         time.Duration(widget.Since),
+		Patterns:      widget.patterns,
+		Limit:         widget.Limit,
+		MaxLineLength: widget.MaxLineLength,
+		Client:        widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Lines = lines
+}
+
+func (widget *LogTail) LevelColor(level string) string {
+	switch level {
+	case feed.LogLevelError:
+		return "color-negative"
+	case feed.LogLevelWarning:
+		return "color-primary"
+	case feed.LogLevelDebug:
+		return "color-subdue"
+	}
+
+	return ""
+}
+
+// The text of the line with the parts that matched a pattern highlighted
+func (widget *LogTail) Highlight(line feed.LogLine) template.HTML {
+	var html strings.Builder
+	last := 0
+
+	for _, match := range line.Matches {
+		html.WriteString(template.HTMLEscapeString(line.Text[last:match[0]]))
+		html.WriteString(`<span class="log-tail-match">`)
+		html.WriteString(template.HTMLEscapeString(line.Text[match[0]:match[1]]))
+		html.WriteString(`</span>`)
+		last = match[1]
+	}
+
+	html.WriteString(template.HTMLEscapeString(line.Text[last:]))
+
+	return template.HTML(html.String())
+}
+
+func (widget *LogTail) Render() template.HTML {
+	return widget.render(widget, assets.LogTailTemplate)
+}
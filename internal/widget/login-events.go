@@ -0,0 +1,202 @@
+//go:build !slim || widget_login_events
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("login-events", func() Widget { return &LoginEvents{} })
+}
+
+// How many of the latest events of each kind are requested from Authentik
+const authentikLoginEventsLimit = 100
+
+type loginEventsSource struct {
+	Type           string                  `yaml:"type"`
+	Name           string                  `yaml:"name"`
+	URL            URLField                `yaml:"url"`
+	Token          OptionalEnvString       `yaml:"token"`
+	ClientID       OptionalEnvString       `yaml:"client-id"`
+	ClientSecret   OptionalEnvString       `yaml:"client-secret"`
+	OrganizationID string                  `yaml:"organization-id"`
+	vaultwarden    *feed.VaultwardenClient `yaml:"-"`
+}
+
+type loginEventsItem struct {
+	feed.LoginEvent
+	Source string
+}
+
+type loginEventsIP struct {
+	IP    string
+	Count int
+}
+
+type LoginEvents struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Sources           []loginEventsSource `yaml:"sources"`
+	Period            DurationField       `yaml:"period"`
+	Limit             int                 `yaml:"limit"`
+	PeriodLabel       string              `yaml:"-"`
+	Events            []loginEventsItem   `yaml:"-"`
+	Failed            int                 `yaml:"-"`
+	Successful        int                 `yaml:"-"`
+	// the addresses with the most failed logins, which are usually the ones trying to guess passwords
+	TopFailedIPs []loginEventsIP `yaml:"-"`
+}
+
+func (widget *LoginEvents) Initialize() error {
+	widget.withTitle("Logins").withCacheDuration(5 * time.Minute)
+
+	if len(widget.Sources) == 0 {
+		return errors.New("no sources specified for login-events widget")
+	}
+
+	if widget.Period == 0 {
+		widget.Period = DurationField(24 * time.Hour)
+	}
+
+	widget.PeriodLabel = formatChartPeriod(time.Duration(widget.Period))
+
+	if widget.Limit <= 0 {
+		widget.Limit = 10
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("login-events widget: %v", err)
+	}
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.URL == "" {
+			return fmt.Errorf("url must be specified for source %d in login-events widget", i+1)
+		}
+
+		switch source.Type {
+		case "authentik":
+			if source.Token == "" {
+				return fmt.Errorf("token must be specified for authentik source in login-events widget")
+			}
+
+			if source.Name == "" {
+				source.Name = "Authentik"
+			}
+		case "vaultwarden":
+			if source.ClientID == "" || source.ClientSecret == "" || source.OrganizationID == "" {
+				return fmt.Errorf("client-id, client-secret and organization-id must be specified for vaultwarden source in login-events widget")
+			}
+
+			if source.Name == "" {
+				source.Name = "Vaultwarden"
+			}
+
+			source.vaultwarden = &feed.VaultwardenClient{
+				URL:            string(source.URL),
+				ClientID:       source.ClientID.String(),
+				ClientSecret:   source.ClientSecret.String(),
+				OrganizationID: source.OrganizationID,
+				Client:         widget.client,
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in login-events widget, must be either authentik or vaultwarden", source.Type)
+		}
+	}
+
+	return nil
+}
+
+func (widget *LoginEvents) Update(ctx context.Context) {
+	since := time.Now().Add(-time.Duration(widget.Period))
+	events := make([]loginEventsItem, 0)
+	var failed int
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+		var sourceEvents []feed.LoginEvent
+		var err error
+
+		if source.vaultwarden != nil {
+			sourceEvents, err = source.vaultwarden.FetchLoginEvents(ctx, since)
+		} else {
+			sourceEvents, err = feed.FetchAuthentikLoginEvents(ctx, widget.client, string(source.URL), source.Token.String(), since, authentikLoginEventsLimit)
+		}
+
+		if err != nil {
+			failed++
+			slog.Error("Failed to fetch login events", "source", source.Name, "error", err)
+			continue
+		}
+
+		for j := range sourceEvents {
+			events = append(events, loginEventsItem{LoginEvent: sourceEvents[j], Source: source.Name})
+		}
+	}
+
+	var err error
+
+	if failed == len(widget.Sources) {
+		err = fmt.Errorf("%w: could not fetch login events from any source", feed.ErrNoContent)
+	} else if failed > 0 {
+		err = fmt.Errorf("%w: could not fetch login events from %d source(s)", feed.ErrPartialContent, failed)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	slices.SortFunc(events, func(a, b loginEventsItem) int {
+		return b.Time.Compare(a.Time)
+	})
+
+	widget.Failed = 0
+	widget.Successful = 0
+	failedByIP := make(map[string]int)
+
+	for i := range events {
+		if !events[i].Failed {
+			widget.Successful++
+			continue
+		}
+
+		widget.Failed++
+
+		if events[i].IP != "" {
+			failedByIP[events[i].IP]++
+		}
+	}
+
+	widget.TopFailedIPs = widget.TopFailedIPs[:0]
+
+	for ip, count := range failedByIP {
+		widget.TopFailedIPs = append(widget.TopFailedIPs, loginEventsIP{IP: ip, Count: count})
+	}
+
+	slices.SortFunc(widget.TopFailedIPs, func(a, b loginEventsIP) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+
+		return strings.Compare(a.IP, b.IP)
+	})
+
+	widget.TopFailedIPs = widget.TopFailedIPs[:min(3, len(widget.TopFailedIPs))]
+	widget.Events = events[:min(widget.Limit, len(events))]
+}
+
+func (widget *LoginEvents) Render() template.HTML {
+	return widget.render(widget, assets.LoginEventsTemplate)
+}
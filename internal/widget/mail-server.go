@@ -0,0 +1,84 @@
+//go:build !slim || widget_mail_server
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("mail-server", func() Widget { return &MailServer{} })
+}
+
+type MailServer struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Service           string                 `yaml:"service"`
+	URL               URLField               `yaml:"url"`
+	APIKey            OptionalEnvString      `yaml:"api-key"`
+	Domains           []string               `yaml:"domains"`
+	DKIMSelector      string                 `yaml:"dkim-selector"`
+	Stats             *feed.MailServerStats  `yaml:"-"`
+	server            *feed.MailServerClient `yaml:"-"`
+}
+
+func (widget *MailServer) Initialize() error {
+	widget.withTitle("Mail Server").withCacheDuration(5 * time.Minute)
+
+	switch widget.Service {
+	case feed.MailServerServiceMailcow, feed.MailServerServiceStalwart:
+		if widget.URL == "" {
+			return fmt.Errorf("url must be specified for %s in mail-server widget", widget.Service)
+		}
+
+		if widget.APIKey == "" {
+			return fmt.Errorf("api-key must be specified for %s in mail-server widget", widget.Service)
+		}
+
+		widget.withTitleURL(string(widget.URL))
+	case "":
+		if len(widget.Domains) == 0 {
+			return errors.New("domains must be specified for mail-server widget when there's no service")
+		}
+	default:
+		return errors.New("mail server service must be either 'mailcow' or 'stalwart'")
+	}
+
+	if widget.DKIMSelector == "" {
+		widget.DKIMSelector = "dkim"
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("mail-server widget: %v", err)
+	}
+
+	widget.server = &feed.MailServerClient{
+		Service: widget.Service,
+		URL:     string(widget.URL),
+		APIKey:  widget.APIKey.String(),
+		Client:  widget.client,
+	}
+
+	return nil
+}
+
+func (widget *MailServer) Update(ctx context.Context) {
+	stats, err := widget.server.FetchStats(ctx, widget.Domains, widget.DKIMSelector)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Stats = stats
+}
+
+func (widget *MailServer) Render() template.HTML {
+	return widget.render(widget, assets.MailServerTemplate)
+}
@@ -0,0 +1,36 @@
+package widget
+
+import "github.com/glanceapp/glance/internal/feed"
+
+// Used by both the markets and the crypto widgets, either of which can be left out of slim builds
+
+func sortMarkets(markets feed.Markets, sortBy string) {
+	if sortBy == "absolute-change" {
+		markets.SortByAbsChange()
+	}
+
+	if sortBy == "change" {
+		markets.SortByChange()
+	}
+}
+
+func marketsSharedData(markets feed.Markets) any {
+	if markets == nil {
+		return nil
+	}
+
+	data := make([]map[string]any, len(markets))
+
+	for i := range markets {
+		market := &markets[i]
+		data[i] = map[string]any{
+			"symbol":         market.Symbol,
+			"name":           market.Name,
+			"currency":       market.Currency,
+			"price":          market.Price,
+			"percent-change": market.PercentChange,
+		}
+	}
+
+	return data
+}
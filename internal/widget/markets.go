@@ -1,20 +1,86 @@
+//go:build !slim || widget_markets
+
 package widget
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("markets", func() Widget { return &Markets{} })
+	register("stocks", func() Widget { return &Markets{} })
+}
+
 type Markets struct {
-	widgetBase     `yaml:",inline"`
-	StocksRequests []feed.MarketRequest `yaml:"stocks"`
-	MarketRequests []feed.MarketRequest `yaml:"markets"`
-	Sort           string               `yaml:"sort-by"`
-	Markets        feed.Markets         `yaml:"-"`
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	StocksRequests    []feed.MarketRequest `yaml:"stocks"`
+	MarketRequests    []feed.MarketRequest `yaml:"markets"`
+	Sort              string               `yaml:"sort-by"`
+	Currency          string               `yaml:"currency"`
+	Editable          bool                 `yaml:"editable"`
+	SymbolsFrom       string               `yaml:"symbols-from"`
+	ShowEvents        bool                 `yaml:"show-events"`
+	Markets           feed.Markets         `yaml:"-"`
+}
+
+const (
+	marketsMaxWatchlistSymbols = 100
+	marketsMaxRequestSize      = 16 << 10
+)
+
+// Covers stocks, indices (^GSPC), futures (ES=F), currency pairs (EURUSD=X) and crypto (BTC-USD)
+var marketSymbolPattern = regexp.MustCompile(`^[A-Z0-9.^=-]{1,20}$`)
+
+var errMarketsVersionConflict = errors.New("the watchlist was changed somewhere else")
+
+type marketsWatchlistItem struct {
+	Symbol string `json:"symbol"`
+}
+
+// Only stored once the watchlist gets changed from the dashboard, until
+// then and after it gets reset the symbols from the config are used
+type marketsWatchlist struct {
+	Version int                    `json:"version"`
+	Symbols []marketsWatchlistItem `json:"symbols"`
+}
+
+func (w *marketsWatchlist) validate() error {
+	if len(w.Symbols) > marketsMaxWatchlistSymbols {
+		return fmt.Errorf("cannot have more than %d symbols", marketsMaxWatchlistSymbols)
+	}
+
+	seen := make(map[string]bool, len(w.Symbols))
+
+	for i := range w.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(w.Symbols[i].Symbol))
+
+		if !marketSymbolPattern.MatchString(symbol) {
+			return fmt.Errorf("invalid symbol '%s'", w.Symbols[i].Symbol)
+		}
+
+		if seen[symbol] {
+			return fmt.Errorf("%s is already in the watchlist", symbol)
+		}
+
+		seen[symbol] = true
+		w.Symbols[i].Symbol = symbol
+	}
+
+	return nil
 }
 
 func (widget *Markets) Initialize() error {
@@ -24,27 +90,278 @@ func (widget *Markets) Initialize() error {
 		widget.MarketRequests = widget.StocksRequests
 	}
 
+	if err := withDefaultCurrency(&widget.Currency); err != nil {
+		return fmt.Errorf("markets widget: %v", err)
+	}
+
+	// the symbols would change under the ones added and removed from the dashboard
+	if widget.Editable && widget.SymbolsFrom != "" {
+		return errors.New("markets widget: editable cannot be used together with symbols-from")
+	}
+
+	for i := range widget.MarketRequests {
+		if widget.MarketRequests[i].CurrencyCode == "" {
+			widget.MarketRequests[i].CurrencyCode = widget.Currency
+		} else if !isValidCurrencyCode(strings.ToUpper(widget.MarketRequests[i].CurrencyCode)) {
+			return fmt.Errorf("markets widget: invalid currency '%s' for %s", widget.MarketRequests[i].CurrencyCode, widget.MarketRequests[i].Symbol)
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("markets widget: %v", err)
+	}
+
 	return nil
 }
 
+// Widgets keep their watchlist separately from each other, even if they
+// have the same symbols, since it's where the symbols come from that differs
+func (widget *Markets) storageKey() string {
+	return "markets:" + widget.GetSlug()
+}
+
+// The symbols that are also in the config keep their properties from it
+func (widget *Markets) requests() []feed.MarketRequest {
+	if !widget.Editable {
+		return widget.MarketRequests
+	}
+
+	watchlist := widget.storedWatchlist()
+
+	if watchlist.Version == 0 {
+		return widget.MarketRequests
+	}
+
+	requests := make([]feed.MarketRequest, 0, len(watchlist.Symbols))
+
+	for _, item := range watchlist.Symbols {
+		index := slices.IndexFunc(widget.MarketRequests, func(r feed.MarketRequest) bool {
+			return strings.EqualFold(r.Symbol, item.Symbol)
+		})
+
+		if index != -1 {
+			requests = append(requests, widget.MarketRequests[index])
+		} else {
+			requests = append(requests, feed.MarketRequest{Symbol: item.Symbol, CurrencyCode: widget.Currency})
+		}
+	}
+
+	return requests
+}
+
+// Falls back to the symbols from the config if the watchlist hasn't been changed
+func (widget *Markets) storedWatchlist() marketsWatchlist {
+	var watchlist marketsWatchlist
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &watchlist); err != nil {
+		slog.Error("Failed to read markets watchlist", "widget", widget.GetSlug(), "error", err)
+	}
+
+	if watchlist.Version == 0 {
+		return watchlistFromRequests(widget.MarketRequests)
+	}
+
+	return watchlist
+}
+
+func watchlistFromRequests(requests []feed.MarketRequest) marketsWatchlist {
+	watchlist := marketsWatchlist{Symbols: make([]marketsWatchlistItem, len(requests))}
+
+	for i := range requests {
+		watchlist.Symbols[i].Symbol = requests[i].Symbol
+	}
+
+	return watchlist
+}
+
+// Symbols from the watchlist that are also in the config keep their properties from
+// it, and the rest get added after the ones from the config for as long as there's room
+func (widget *Markets) withWatchlist(requests, watchlist []feed.MarketRequest) []feed.MarketRequest {
+	merged := slices.Clone(requests)
+
+	for _, request := range watchlist {
+		if len(merged) >= marketsMaxWatchlistSymbols {
+			break
+		}
+
+		if !marketSymbolPattern.MatchString(request.Symbol) {
+			continue
+		}
+
+		if slices.ContainsFunc(merged, func(r feed.MarketRequest) bool {
+			return strings.EqualFold(r.Symbol, request.Symbol)
+		}) {
+			continue
+		}
+
+		if request.CurrencyCode == "" || !isValidCurrencyCode(strings.ToUpper(request.CurrencyCode)) {
+			request.CurrencyCode = widget.Currency
+		}
+
+		merged = append(merged, request)
+	}
+
+	return merged
+}
+
 func (widget *Markets) Update(ctx context.Context) {
-	markets, err := feed.FetchMarketsDataFromYahoo(widget.MarketRequests)
+	requests := widget.requests()
+	var watchlistErr error
 
-	if !widget.canContinueUpdateAfterHandlingErr(err) {
+	if widget.SymbolsFrom != "" {
+		watchlist, err := feed.FetchWatchlist(ctx, widget.client, widget.SymbolsFrom)
+
+		if err != nil && len(requests) == 0 {
+			widget.canContinueUpdateAfterHandlingErr(err)
+			return
+		}
+
+		if err != nil {
+			// the symbols from the config can still be shown
+			watchlistErr = fmt.Errorf("%w: %v", feed.ErrPartialContent, err)
+		} else {
+			requests = widget.withWatchlist(requests, watchlist)
+		}
+	}
+
+	if len(requests) == 0 {
+		widget.Markets = nil
+		widget.withError(nil).scheduleNextUpdate()
 		return
 	}
 
-	if widget.Sort == "absolute-change" {
-		markets.SortByAbsChange()
+	markets, err := feed.FetchMarketsDataFromYahoo(ctx, widget.client, requests)
+
+	if err == nil {
+		err = watchlistErr
 	}
 
-	if widget.Sort == "change" {
-		markets.SortByChange()
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
 	}
 
+	// the events are only a hint, so the prices are still shown without them
+	if widget.ShowEvents {
+		if err := feed.AddMarketEventsFromYahoo(ctx, widget.client, markets); err != nil {
+			slog.Warn("Failed to fetch market events", "widget", widget.GetSlug(), "error", err)
+		}
+	}
+
+	sortMarkets(markets, widget.Sort)
 	widget.Markets = markets
 }
 
+func (widget *Markets) sharedData() any {
+	return marketsSharedData(widget.Markets)
+}
+
+func (widget *Markets) WatchlistEditable() bool {
+	return widget.Editable
+}
+
 func (widget *Markets) Render() template.HTML {
 	return widget.render(widget, assets.MarketsTemplate)
 }
+
+// GET /watchlist returns the symbols, POST /watchlist adds one to them, PUT /watchlist
+// replaces all of them as long as the version in the request matches the current one
+// and DELETE /watchlist goes back to the symbols from the config
+func (widget *Markets) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if !widget.Editable || r.PathValue("path") != "watchlist" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var watchlist marketsWatchlist
+	var invalid, err error
+
+	// the symbols from the config become the stored ones on the first change,
+	// which is called while the storage is locked so it can't be read from again
+	load := func() {
+		if watchlist.Version == 0 {
+			watchlist = watchlistFromRequests(widget.MarketRequests)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		watchlist = widget.storedWatchlist()
+	case http.MethodPost:
+		var item marketsWatchlistItem
+
+		if err := decodeJSONRequest(w, r, &item, marketsMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = widget.Providers.Storage.update(widget.storageKey(), &watchlist, func() error {
+			load()
+			watchlist.Symbols = append(watchlist.Symbols, item)
+
+			if invalid = watchlist.validate(); invalid != nil {
+				return invalid
+			}
+
+			watchlist.Version++
+			return nil
+		})
+	case http.MethodPut:
+		var replacement marketsWatchlist
+
+		if err := decodeJSONRequest(w, r, &replacement, marketsMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := replacement.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = widget.Providers.Storage.update(widget.storageKey(), &watchlist, func() error {
+			if replacement.Version != watchlist.Version {
+				load()
+				return errMarketsVersionConflict
+			}
+
+			watchlist = replacement
+			watchlist.Version++
+			return nil
+		})
+	case http.MethodDelete:
+		err = widget.Providers.Storage.delete(widget.storageKey())
+		watchlist = watchlistFromRequests(widget.MarketRequests)
+	default:
+		w.Header().Set("Allow", "GET, POST, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if invalid != nil {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if errors.Is(err, errMarketsVersionConflict) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(&watchlist)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to update markets watchlist", "widget", widget.GetSlug(), "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the new symbols get fetched the next time the widget gets rendered
+	if r.Method != http.MethodGet {
+		ExpireCache(widget)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&watchlist)
+}
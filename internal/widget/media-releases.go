@@ -0,0 +1,205 @@
+//go:build !slim || widget_media_releases
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("media-releases", func() Widget { return &MediaReleases{} })
+}
+
+var mediaReleasesTitles = map[string]string{
+	"now-playing": "In Theaters",
+	"upcoming":    "Upcoming Movies",
+	"trending":    "Trending",
+	"calendar":    "Upcoming",
+}
+
+type MediaReleases struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string              `yaml:"service"`
+	List                   string              `yaml:"list"`
+	APIKey                 OptionalEnvString   `yaml:"api-key"`
+	Region                 string              `yaml:"region"`
+	ClientID               OptionalEnvString   `yaml:"client-id"`
+	ClientSecret           OptionalEnvString   `yaml:"client-secret"`
+	RefreshToken           OptionalEnvString   `yaml:"refresh-token"`
+	Days                   int                 `yaml:"days"`
+	Limit                  int                 `yaml:"limit"`
+	CollapseAfter          int                 `yaml:"collapse-after"`
+	Releases               []feed.MediaRelease `yaml:"-"`
+	trakt                  *feed.TraktClient
+	postersMu              sync.RWMutex
+	// the posters which can be requested through the widget
+	posters map[string]bool
+}
+
+func (widget *MediaReleases) Initialize() error {
+	widget.withCacheDuration(time.Hour)
+
+	switch widget.Service {
+	case feed.MediaServiceTMDB:
+		if widget.APIKey == "" {
+			return errors.New("api-key must be specified for tmdb in media releases widget")
+		}
+
+		if widget.List == "" {
+			widget.List = "now-playing"
+		}
+
+		switch widget.List {
+		case "now-playing", "upcoming", "trending":
+		default:
+			return errors.New("list for tmdb must be one of now-playing, upcoming or trending")
+		}
+
+		widget.withTitleURL("https://www.themoviedb.org/")
+	case feed.MediaServiceTrakt:
+		if widget.ClientID == "" {
+			return errors.New("client-id must be specified for trakt in media releases widget")
+		}
+
+		if widget.List == "" {
+			widget.List = "calendar"
+		}
+
+		switch widget.List {
+		case "calendar":
+			if widget.ClientSecret == "" || widget.RefreshToken == "" {
+				return errors.New("client-secret and refresh-token must be specified for the trakt calendar")
+			}
+		case "trending":
+		default:
+			return errors.New("list for trakt must be either calendar or trending")
+		}
+
+		widget.withTitleURL("https://trakt.tv/")
+		widget.trakt = &feed.TraktClient{
+			ClientID:             widget.ClientID.String(),
+			ClientSecret:         widget.ClientSecret.String(),
+			RefreshToken:         widget.RefreshToken.String(),
+			TMDBAPIKey:           widget.APIKey.String(),
+			OnRefreshTokenChange: widget.storeRefreshToken,
+		}
+
+		if widget.RefreshToken != "" {
+			widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.trakt.RefreshToken)
+		}
+	default:
+		return errors.New("service for media releases widget must be either tmdb or trakt")
+	}
+
+	widget.withTitle(mediaReleasesTitles[widget.List])
+
+	if widget.Days <= 0 {
+		widget.Days = 14
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 20
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("media releases widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *MediaReleases) storageKey() string {
+	return "media-releases:trakt:" + widget.ClientID.String()
+}
+
+func (widget *MediaReleases) Update(ctx context.Context) {
+	var releases []feed.MediaRelease
+	var err error
+
+	if widget.Service == feed.MediaServiceTMDB {
+		releases, err = feed.FetchTMDBReleases(ctx, widget.client, widget.APIKey.String(), widget.List, widget.Region, widget.Limit)
+	} else {
+		releases, err = widget.trakt.FetchReleases(ctx, widget.client, widget.List, widget.Days, widget.Limit)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	posters := make(map[string]bool, len(releases))
+
+	for i := range releases {
+		if feed.IsTMDBPoster(releases[i].Poster) {
+			posters[releases[i].Poster] = true
+		} else {
+			releases[i].Poster = ""
+		}
+	}
+
+	widget.postersMu.Lock()
+	widget.posters = posters
+	widget.postersMu.Unlock()
+
+	widget.Releases = releases
+}
+
+func (widget *MediaReleases) Render() template.HTML {
+	return widget.render(widget, assets.MediaReleasesTemplate)
+}
+
+// GET /poster/{path} responds with the poster from TMDB, so that browsers don't have to reach
+// it themselves. Only for the posters that the widget is showing
+func (widget *MediaReleases) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	name, found := strings.CutPrefix(r.PathValue("path"), "poster/")
+	path := "/" + name
+
+	if !found || !feed.IsTMDBPoster(path) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	widget.postersMu.RLock()
+	shown := widget.posters[path]
+	widget.postersMu.RUnlock()
+
+	if !shown {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	contentType, image, err := feed.FetchTMDBPoster(r.Context(), widget.client, path)
+
+	if err != nil {
+		slog.Error("Failed to fetch poster", "widget", widget.GetSlug(), "poster", path, "error", err)
+		http.Error(w, "could not get the poster", http.StatusBadGateway)
+		return
+	}
+
+	// the path changes whenever the image does
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=604800")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(image)
+}
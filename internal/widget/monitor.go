@@ -1,8 +1,13 @@
+//go:build !slim || widget_monitor
+
 package widget
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"html/template"
+	"math"
 	"slices"
 	"strconv"
 	"time"
@@ -11,6 +16,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("monitor", func() Widget { return &Monitor{} })
+}
+
 func statusCodeToText(status int, altStatusCodes []int) string {
 	if status == 200 || slices.Contains(altStatusCodes, status) {
 		return "OK"
@@ -34,67 +43,427 @@ func statusCodeToText(status int, altStatusCodes []int) string {
 	return strconv.Itoa(status)
 }
 
-func statusCodeToStyle(status int, altStatusCodes []int) string {
-	if status == 200 || slices.Contains(altStatusCodes, status) {
-		return "ok"
+func isSiteStatusOk(status *feed.SiteStatus, altStatusCodes []int) bool {
+	return !status.TimedOut && status.Error == nil && (status.Code == 200 || slices.Contains(altStatusCodes, status.Code))
+}
+
+// Status codes below 400 other than 200, such as redirects, aren't ok but the site is still up
+func isSiteStatusFailing(status *feed.SiteStatus, altStatusCodes []int) bool {
+	return !slices.Contains(altStatusCodes, status.Code) && (status.Code >= 400 || status.TimedOut || status.Error != nil)
+}
+
+const monitorHistoryLength = 24
+
+type monitorCheck struct {
+	Code         int
+	ResponseTime time.Duration
+	Ok           bool
+	Failed       bool
+}
+
+type monitorSiteHistory struct {
+	checks              []monitorCheck
+	consecutiveFailures int
+}
+
+func (h *monitorSiteHistory) record(check monitorCheck) {
+	h.checks = append(h.checks, check)
+
+	if len(h.checks) > monitorHistoryLength {
+		h.checks = h.checks[len(h.checks)-monitorHistoryLength:]
 	}
 
-	return "error"
+	if check.Failed {
+		h.consecutiveFailures++
+	} else {
+		h.consecutiveFailures = 0
+	}
+}
+
+// The periods shown by the uptime style, both of which fit within the long-term history
+var monitorUptimePeriods = []time.Duration{7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+type monitorUptime struct {
+	Period   string
+	Percent  string
+	Downtime time.Duration
+	HasData  bool
+	// whether the uptime is at or above the uptime target of the widget
+	MeetsTarget bool
+}
+
+type monitorAlertAfter struct {
+	ResponseTime DurationField `yaml:"response-time"`
+	Failures     int           `yaml:"failures"`
+}
+
+type monitorSite struct {
+	*feed.SiteStatusRequest `yaml:",inline"`
+	Status                  *feed.SiteStatus  `yaml:"-"`
+	Title                   string            `yaml:"title"`
+	Icon                    CustomIcon        `yaml:"icon"`
+	SameTab                 bool              `yaml:"same-tab"`
+	StatusText              string            `yaml:"-"`
+	StatusStyle             string            `yaml:"-"`
+	AltStatusCodes          []int             `yaml:"alt-status-codes"`
+	AlertAfter              monitorAlertAfter `yaml:"alert-after"`
+	Thresholds              thresholds        `yaml:"thresholds"`
+	ResponseTimeThreshold   *threshold        `yaml:"-"`
+	IsSlow                  bool              `yaml:"-"`
+	ResponseTimeChart       string            `yaml:"-"`
+	ChartTitle              string            `yaml:"-"`
+	FailedChecks            int               `yaml:"-"`
+	Uptime                  []monitorUptime   `yaml:"-"`
 }
 
 type Monitor struct {
-	widgetBase `yaml:",inline"`
-	Sites      []struct {
-		*feed.SiteStatusRequest `yaml:",inline"`
-		Status                  *feed.SiteStatus `yaml:"-"`
-		Title                   string           `yaml:"title"`
-		Icon                    CustomIcon       `yaml:"icon"`
-		SameTab                 bool             `yaml:"same-tab"`
-		StatusText              string           `yaml:"-"`
-		StatusStyle             string           `yaml:"-"`
-		AltStatusCodes          []int            `yaml:"alt-status-codes"`
-	} `yaml:"sites"`
-	ShowFailingOnly bool `yaml:"show-failing-only"`
-	HasFailing      bool `yaml:"-"`
+	widgetBase       `yaml:",inline"`
+	Sites            []monitorSite     `yaml:"sites"`
+	Discovery        *serviceDiscovery `yaml:"discovery"`
+	ShowOnlyProblems bool              `yaml:"show-only-problems"`
+	ShowFailingOnly  bool              `yaml:"show-failing-only"`
+	ChartPeriod      DurationField     `yaml:"chart-period"`
+	Style            string            `yaml:"style"`
+	UptimeTarget     float64           `yaml:"uptime-target"`
+	UptimePeriods    []string          `yaml:"-"`
+	HasFailing       bool              `yaml:"-"`
+	staticSites      []monitorSite     `yaml:"-"`
+	// when the sites were first checked since Glance started
+	firstCheckedAt time.Time
+	// keyed by URL rather than stored in the site itself since
+	// discovered sites get recreated every time the widget updates
+	history map[string]*monitorSiteHistory
 }
 
 func (widget *Monitor) Initialize() error {
 	widget.withTitle("Monitor").withCacheDuration(5 * time.Minute)
+	widget.history = make(map[string]*monitorSiteHistory)
+
+	// show-failing-only is the name this option had before other widgets got it
+	widget.ShowOnlyProblems = widget.ShowOnlyProblems || widget.ShowFailingOnly
+
+	if err := validateChartPeriod(widget.ChartPeriod); err != nil {
+		return fmt.Errorf("monitor widget: %v", err)
+	}
+
+	if widget.Style != "" && widget.Style != "uptime" {
+		return fmt.Errorf("invalid style '%s' for monitor widget, must be uptime", widget.Style)
+	}
+
+	if widget.UptimeTarget == 0 {
+		widget.UptimeTarget = 99.9
+	} else if widget.UptimeTarget < 0 || widget.UptimeTarget > 100 {
+		return errors.New("uptime-target of monitor widget must be between 0 and 100")
+	}
+
+	widget.UptimePeriods = make([]string, len(monitorUptimePeriods))
+
+	for i, period := range monitorUptimePeriods {
+		widget.UptimePeriods[i] = formatChartPeriod(period)
+	}
+
+	for i := range widget.Sites {
+		if err := widget.Sites[i].Thresholds.validate(); err != nil {
+			return fmt.Errorf("invalid thresholds for site %d in monitor widget: %v", i+1, err)
+		}
+	}
+
+	if widget.Discovery != nil {
+		if err := widget.Discovery.validate(); err != nil {
+			return err
+		}
+
+		widget.staticSites = widget.Sites
+	}
 
 	return nil
 }
 
+func (widget *Monitor) discoverSites(ctx context.Context) error {
+	services, err := widget.Discovery.discover(ctx)
+
+	if err != nil && !errors.Is(err, feed.ErrPartialContent) {
+		return err
+	}
+
+	sites := make([]monitorSite, len(widget.staticSites), len(widget.staticSites)+len(services))
+	copy(sites, widget.staticSites)
+
+	for i := range services {
+		sites = append(sites, monitorSite{
+			SiteStatusRequest: &feed.SiteStatusRequest{
+				URL:      services[i].URL,
+				CheckURL: services[i].CheckURL,
+			},
+			Title: services[i].Name,
+			Icon:  newCustomIconFromString(services[i].Icon),
+		})
+	}
+
+	widget.Sites = sites
+
+	return err
+}
+
 func (widget *Monitor) Update(ctx context.Context) {
+	if widget.Discovery != nil {
+		if err := widget.discoverSites(ctx); err != nil && !errors.Is(err, feed.ErrPartialContent) {
+			widget.withError(err).scheduleEarlyUpdate()
+			return
+		}
+	}
+
 	requests := make([]*feed.SiteStatusRequest, len(widget.Sites))
 
 	for i := range widget.Sites {
 		requests[i] = widget.Sites[i].SiteStatusRequest
 	}
 
-	statuses, err := feed.FetchStatusForSites(requests)
+	statuses, err := feed.FetchStatusForSites(ctx, requests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
 	}
 
 	widget.HasFailing = false
+	now := time.Now()
+
+	if widget.firstCheckedAt.IsZero() {
+		widget.firstCheckedAt = now
+	}
 
 	for i := range widget.Sites {
 		site := &widget.Sites[i]
 		status := &statuses[i]
 		site.Status = status
 
-		if !slices.Contains(site.AltStatusCodes, status.Code) && (status.Code >= 400 || status.TimedOut || status.Error != nil) {
+		if !status.TimedOut {
+			site.StatusText = statusCodeToText(status.Code, site.AltStatusCodes)
+		}
+
+		check := monitorCheck{
+			Code:         status.Code,
+			ResponseTime: status.ResponseTime,
+			Ok:           isSiteStatusOk(status, site.AltStatusCodes),
+			Failed:       isSiteStatusFailing(status, site.AltStatusCodes),
+		}
+
+		history := widget.siteHistory(site)
+		history.record(check)
+
+		if !check.Failed {
+			widget.Providers.History.Record(site.historyKey(), float64(check.ResponseTime.Milliseconds()), now)
+		}
+
+		// recorded regardless of the style so that there's already
+		// a history when switching to the uptime style
+		up := 0.0
+
+		if !check.Failed {
+			up = 1
+		}
+
+		widget.Providers.History.RecordLongTerm(site.uptimeKey(), up, now)
+
+		site.updateFromHistory(history)
+
+		if widget.Style == "uptime" {
+			widget.updateUptime(site, now)
+		} else {
+			widget.updateChart(site, history, now)
+		}
+
+		if site.StatusStyle == "error" {
 			widget.HasFailing = true
 		}
+	}
 
-		if !status.TimedOut {
-			site.StatusText = statusCodeToText(status.Code, site.AltStatusCodes)
-			site.StatusStyle = statusCodeToStyle(status.Code, site.AltStatusCodes)
+	widget.pruneHistory()
+}
+
+func monitorHistoryKey(site *monitorSite) string {
+	return site.URL + " " + site.CheckURL
+}
+
+func (widget *Monitor) siteHistory(site *monitorSite) *monitorSiteHistory {
+	key := monitorHistoryKey(site)
+	history, exists := widget.history[key]
+
+	if !exists {
+		history = &monitorSiteHistory{}
+		widget.history[key] = history
+	}
+
+	return history
+}
+
+// Discovered sites can go away or change their URL, whose history would otherwise be kept forever
+func (widget *Monitor) pruneHistory() {
+	current := make(map[string]bool, len(widget.Sites))
+
+	for i := range widget.Sites {
+		current[monitorHistoryKey(&widget.Sites[i])] = true
+	}
+
+	for key := range widget.history {
+		if !current[key] {
+			delete(widget.history, key)
+		}
+	}
+}
+
+func (site *monitorSite) updateFromHistory(history *monitorSiteHistory) {
+	latest := history.checks[len(history.checks)-1]
+	site.ResponseTimeThreshold = site.Thresholds.Match(float64(latest.ResponseTime.Milliseconds()))
+	site.IsSlow = !latest.Failed && site.AlertAfter.ResponseTime > 0 && latest.ResponseTime > time.Duration(site.AlertAfter.ResponseTime)
+
+	switch {
+	case latest.Failed && history.consecutiveFailures >= max(1, site.AlertAfter.Failures):
+		site.StatusStyle = "error"
+	case latest.Failed, !latest.Ok:
+		site.StatusStyle = "warning"
+	case site.IsSlow:
+		site.StatusStyle = "warning"
+	default:
+		site.StatusStyle = "ok"
+	}
+
+	site.FailedChecks = 0
+
+	for i := range history.checks {
+		if history.checks[i].Failed {
+			site.FailedChecks++
+		}
+	}
+}
+
+// Charts the recent checks unless a chart period is specified, in which case
+// the stored history of successful checks within that period is used
+func (widget *Monitor) updateChart(site *monitorSite, history *monitorSiteHistory, now time.Time) {
+	var responseTimes []float64
+
+	if widget.ChartPeriod > 0 {
+		responseTimes = widget.Providers.History.Values(site.historyKey(), now.Add(-time.Duration(widget.ChartPeriod)))
+		site.ChartTitle = fmt.Sprintf("Average response time over the last %s", formatChartPeriod(time.Duration(widget.ChartPeriod)))
+	} else {
+		responseTimes = make([]float64, len(history.checks))
+
+		for i := range history.checks {
+			responseTimes[i] = float64(history.checks[i].ResponseTime.Milliseconds())
+		}
+
+		site.ChartTitle = fmt.Sprintf("Response time of the last %d checks", len(history.checks))
+	}
+
+	if site.FailedChecks > 0 {
+		site.ChartTitle += fmt.Sprintf(", %d of the last %d checks failed", site.FailedChecks, len(history.checks))
+	}
+
+	if len(responseTimes) < 2 {
+		site.ResponseTimeChart = ""
+		return
+	}
+
+	site.ResponseTimeChart = feed.SvgPolylineCoordsFromYValues(100, 30, responseTimes)
+}
+
+// The share of successful checks in each hour gives the downtime of that hour. The last
+// hour is the current one, since a check was just recorded, so only the part of it that
+// has been checked counts, where each check stands for the time until the next one
+func (widget *Monitor) updateUptime(site *monitorSite, now time.Time) {
+	site.Uptime = make([]monitorUptime, len(monitorUptimePeriods))
+	elapsed := min(now.Sub(now.Truncate(time.Hour)), now.Sub(widget.firstCheckedAt)+widget.cacheDuration)
+	elapsed = max(elapsed, time.Minute)
+
+	for i, period := range monitorUptimePeriods {
+		uptime := &site.Uptime[i]
+		uptime.Period = widget.UptimePeriods[i]
+		values := widget.Providers.History.LongTermValues(site.uptimeKey(), now.Add(-period))
+
+		if len(values) == 0 {
+			continue
+		}
+
+		var downtime float64
+
+		for j, value := range values {
+			if j == len(values)-1 {
+				downtime += (1 - value) * float64(elapsed)
+			} else {
+				downtime += (1 - value) * float64(time.Hour)
+			}
+		}
+
+		covered := float64(len(values)-1)*float64(time.Hour) + float64(elapsed)
+		// rounded down so that anything short of no downtime doesn't show up as 100%
+		percent := math.Floor((1-downtime/covered)*100*100) / 100
+
+		uptime.HasData = true
+		uptime.Downtime = time.Duration(downtime)
+		uptime.MeetsTarget = percent >= widget.UptimeTarget
+		uptime.Percent = strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+	}
+}
+
+func (site *monitorSite) checkedURL() string {
+	if site.CheckURL != "" {
+		return site.CheckURL
+	}
+
+	return site.URL
+}
+
+func (site *monitorSite) historyKey() string {
+	return "monitor:" + site.checkedURL()
+}
+
+func (site *monitorSite) uptimeKey() string {
+	return "monitor-uptime:" + site.checkedURL()
+}
+
+func (widget *Monitor) sharedData() any {
+	sites := make([]map[string]any, 0, len(widget.Sites))
+
+	for i := range widget.Sites {
+		site := &widget.Sites[i]
+
+		if site.Status == nil {
+			return nil
 		}
+
+		sites = append(sites, map[string]any{
+			"title":            site.Title,
+			"url":              site.URL,
+			"status-code":      site.Status.Code,
+			"response-time-ms": site.Status.ResponseTime.Milliseconds(),
+			"timed-out":        site.Status.TimedOut,
+			"error":            site.Status.Error != nil,
+			"ok":               isSiteStatusOk(site.Status, site.AltStatusCodes),
+			"status":           site.StatusStyle,
+			"failed-checks":    site.FailedChecks,
+		})
+	}
+
+	return map[string]any{
+		"has-failing": widget.HasFailing,
+		"sites":       sites,
 	}
 }
 
 func (widget *Monitor) Render() template.HTML {
+	if widget.Style == "uptime" {
+		return widget.render(widget, assets.MonitorUptimeTemplate)
+	}
+
 	return widget.render(widget, assets.MonitorTemplate)
 }
+
+func (widget *Monitor) isEmpty() bool {
+	return len(widget.Sites) == 0 || (widget.ShowOnlyProblems && !widget.HasFailing)
+}
+
+func (widget *Monitor) resourceHints(hints *ResourceHints) {
+	for i := range widget.Sites {
+		hints.addOrigin(widget.Sites[i].Icon.URL)
+	}
+}
@@ -0,0 +1,138 @@
+//go:build !slim || widget_music_releases
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("music-releases", func() Widget { return &MusicReleases{} })
+}
+
+type musicReleasesWeek struct {
+	Start    time.Time
+	Label    string
+	Releases []feed.MusicRelease
+}
+
+type MusicReleases struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string              `yaml:"service"`
+	Artists                []string            `yaml:"artists"`
+	ClientID               OptionalEnvString   `yaml:"client-id"`
+	ClientSecret           OptionalEnvString   `yaml:"client-secret"`
+	RefreshToken           OptionalEnvString   `yaml:"refresh-token"`
+	Weeks                  int                 `yaml:"weeks"`
+	CollapseAfter          int                 `yaml:"collapse-after"`
+	Groups                 []musicReleasesWeek `yaml:"-"`
+	spotify                *feed.SpotifyClient
+}
+
+func (widget *MusicReleases) Initialize() error {
+	widget.withTitle("New Releases").withCacheDuration(6 * time.Hour)
+
+	switch widget.Service {
+	case feed.MusicServiceMusicBrainz:
+		if len(widget.Artists) == 0 {
+			return errors.New("artists must be specified for musicbrainz in music releases widget")
+		}
+	case feed.MusicServiceSpotify:
+		if widget.ClientID == "" || widget.ClientSecret == "" || widget.RefreshToken == "" {
+			return errors.New("client-id, client-secret and refresh-token must be specified for spotify in music releases widget")
+		}
+
+		widget.spotify = &feed.SpotifyClient{
+			ClientID:             widget.ClientID.String(),
+			ClientSecret:         widget.ClientSecret.String(),
+			RefreshToken:         widget.RefreshToken.String(),
+			OnRefreshTokenChange: widget.storeRefreshToken,
+		}
+
+		widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.spotify.RefreshToken)
+	default:
+		return errors.New("service for music releases widget must be either musicbrainz or spotify")
+	}
+
+	if widget.Weeks <= 0 {
+		widget.Weeks = 4
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 2
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("music releases widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *MusicReleases) storageKey() string {
+	return "music-releases:spotify:" + widget.ClientID.String()
+}
+
+func (widget *MusicReleases) Update(ctx context.Context) {
+	thisWeek := startOfMusicReleasesWeek(time.Now())
+	since := thisWeek.AddDate(0, 0, -7*(widget.Weeks-1))
+
+	var releases []feed.MusicRelease
+	var err error
+
+	if widget.Service == feed.MusicServiceMusicBrainz {
+		releases, err = feed.FetchMusicBrainzReleases(ctx, widget.client, widget.Artists, since)
+	} else {
+		releases, err = widget.spotify.FetchReleases(ctx, widget.client, since)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Groups = groupMusicReleasesByWeek(releases, thisWeek)
+}
+
+// Weeks start on Monday. The release dates don't have a timezone, so they're
+// kept in UTC to not shift them to another day
+func startOfMusicReleasesWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// Expects the releases to be sorted from newest to oldest
+func groupMusicReleasesByWeek(releases []feed.MusicRelease, thisWeek time.Time) []musicReleasesWeek {
+	var groups []musicReleasesWeek
+
+	for i := range releases {
+		week := startOfMusicReleasesWeek(releases[i].Date)
+
+		if len(groups) == 0 || !groups[len(groups)-1].Start.Equal(week) {
+			label := "Week of " + week.Format("January 2")
+
+			if week.Equal(thisWeek) {
+				label = "This week"
+			} else if week.Equal(thisWeek.AddDate(0, 0, -7)) {
+				label = "Last week"
+			}
+
+			groups = append(groups, musicReleasesWeek{Start: week, Label: label})
+		}
+
+		groups[len(groups)-1].Releases = append(groups[len(groups)-1].Releases, releases[i])
+	}
+
+	return groups
+}
+
+func (widget *MusicReleases) Render() template.HTML {
+	return widget.render(widget, assets.MusicReleasesTemplate)
+}
@@ -0,0 +1,69 @@
+//go:build !slim || widget_nextcloud
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("nextcloud", func() Widget { return &Nextcloud{} })
+}
+
+type Nextcloud struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               URLField                  `yaml:"url"`
+	Token             OptionalEnvString         `yaml:"token"`
+	Username          OptionalEnvString         `yaml:"username"`
+	Password          OptionalEnvString         `yaml:"password"`
+	Info              *feed.NextcloudServerInfo `yaml:"-"`
+}
+
+func (widget *Nextcloud) Initialize() error {
+	widget.withTitle("Nextcloud").withCacheDuration(10 * time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for nextcloud widget")
+	}
+
+	widget.withTitleURL(string(widget.URL))
+
+	if widget.Token == "" && (widget.Username == "" || widget.Password == "") {
+		return errors.New("either token or username and password must be specified for nextcloud widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("nextcloud widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Nextcloud) Update(ctx context.Context) {
+	info, err := feed.FetchNextcloudServerInfo(
+		ctx,
+		widget.client,
+		string(widget.URL),
+		widget.Token.String(),
+		widget.Username.String(),
+		widget.Password.String(),
+	)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Info = info
+}
+
+func (widget *Nextcloud) Render() template.HTML {
+	return widget.render(widget, assets.NextcloudTemplate)
+}
@@ -0,0 +1,40 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+const ntfyTimeout = 10 * time.Second
+
+// Where widgets which send notifications, such as when a timer ends, send them to
+type ntfyOptions struct {
+	URL   string            `yaml:"url"`
+	Topic string            `yaml:"topic"`
+	Token OptionalEnvString `yaml:"token"`
+}
+
+func (o *ntfyOptions) validate() error {
+	if o.Topic == "" {
+		return errors.New("ntfy topic must be specified")
+	}
+
+	return nil
+}
+
+// Sends the notification without waiting for it, the error only gets logged since
+// nobody is around to see it. The log attributes tell which widget it came from
+func (o *ntfyOptions) send(notification *feed.NtfyNotification, logAttrs ...any) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ntfyTimeout)
+		defer cancel()
+
+		if err := feed.SendNtfyNotification(ctx, nil, o.URL, o.Topic, o.Token.String(), notification); err != nil {
+			slog.Error("Failed to send notification", append(logAttrs, "title", notification.Title, "error", err)...)
+		}
+	}()
+}
@@ -0,0 +1,218 @@
+//go:build !slim || widget_on_this_day
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("on-this-day", func() Widget { return &OnThisDay{} })
+}
+
+type onThisDayYear struct {
+	YearsAgo int
+	Date     time.Time
+	Photos   []feed.Memory
+	Entries  []feed.Memory
+}
+
+type OnThisDay struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Sources           []struct {
+		Type      string            `yaml:"type"`
+		Name      string            `yaml:"name"`
+		Path      string            `yaml:"path"`
+		URL       OptionalEnvString `yaml:"url"`
+		APIKey    OptionalEnvString `yaml:"api-key"`
+		Author    string            `yaml:"author"`
+		CommitURL string            `yaml:"commit-url"`
+	} `yaml:"sources"`
+	Years         int             `yaml:"years"`
+	PhotosPerDay  int             `yaml:"photos"`
+	CollapseAfter int             `yaml:"collapse-after"`
+	Groups        []onThisDayYear `yaml:"-"`
+	sources       []feed.MemorySource
+	photosMu      sync.RWMutex
+	// the photos which can be requested through the widget, by the index of their source
+	photos map[string]int
+}
+
+func (widget *OnThisDay) Initialize() error {
+	widget.withTitle("On This Day").withCacheDuration(time.Hour)
+
+	if len(widget.Sources) == 0 {
+		return errors.New("no sources specified for on-this-day widget")
+	}
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		switch source.Type {
+		case feed.MemorySourceJournal, feed.MemorySourceGit:
+			if source.Path == "" {
+				return fmt.Errorf("path must be specified for %s source in on-this-day widget", source.Type)
+			}
+
+			if info, err := os.Stat(source.Path); err != nil || !info.IsDir() {
+				return fmt.Errorf("path '%s' of %s source in on-this-day widget is not a directory", source.Path, source.Type)
+			}
+		case feed.MemorySourceImmich:
+			if source.URL == "" || source.APIKey == "" {
+				return errors.New("url and api-key must be specified for immich source in on-this-day widget")
+			}
+		default:
+			return errors.New("type of source in on-this-day widget must be one of journal, immich or git")
+		}
+
+		widget.sources = append(widget.sources, feed.MemorySource{
+			Type:      source.Type,
+			Name:      source.Name,
+			Path:      source.Path,
+			URL:       source.URL.String(),
+			APIKey:    source.APIKey.String(),
+			Author:    source.Author,
+			CommitURL: source.CommitURL,
+		})
+	}
+
+	if widget.Years <= 0 {
+		widget.Years = 10
+	} else if widget.Years > 100 {
+		return errors.New("years for on-this-day widget must be at most 100")
+	}
+
+	if widget.PhotosPerDay <= 0 {
+		widget.PhotosPerDay = 6
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 3
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("on-this-day widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *OnThisDay) Update(ctx context.Context) {
+	now := time.Now()
+	days := make([]time.Time, 0, widget.Years)
+
+	for i := 1; i <= widget.Years; i++ {
+		day := time.Date(now.Year()-i, now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+		// February 29 only exists in leap years
+		if day.Day() == now.Day() {
+			days = append(days, day)
+		}
+	}
+
+	memories, err := feed.FetchMemories(ctx, &feed.MemoriesRequest{
+		Sources:      widget.sources,
+		Days:         days,
+		PhotosPerDay: widget.PhotosPerDay,
+		Client:       widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	groups := make([]onThisDayYear, len(days))
+	groupByYear := make(map[int]*onThisDayYear, len(days))
+	photos := make(map[string]int)
+
+	for i := range days {
+		groups[i] = onThisDayYear{YearsAgo: now.Year() - days[i].Year(), Date: days[i]}
+		groupByYear[days[i].Year()] = &groups[i]
+	}
+
+	for i := range memories {
+		memory := &memories[i]
+		group := groupByYear[memory.Date.Year()]
+
+		if memory.PhotoID != "" {
+			group.Photos = append(group.Photos, *memory)
+			photos[memory.PhotoID] = memory.SourceIndex
+		} else {
+			group.Entries = append(group.Entries, *memory)
+		}
+	}
+
+	// only the years that have something to show, from the most recent one
+	nonEmpty := groups[:0]
+
+	for i := range groups {
+		if len(groups[i].Photos) > 0 || len(groups[i].Entries) > 0 {
+			nonEmpty = append(nonEmpty, groups[i])
+		}
+	}
+
+	widget.photosMu.Lock()
+	widget.photos = photos
+	widget.photosMu.Unlock()
+
+	widget.Groups = nonEmpty
+}
+
+// GET /photo/{id} responds with the thumbnail of a photo from Immich, which needs the API
+// key, so that browsers don't need it. Only for the photos that the widget is showing
+func (widget *OnThisDay) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	id, found := strings.CutPrefix(r.PathValue("path"), "photo/")
+
+	widget.photosMu.RLock()
+	index, shown := widget.photos[id]
+	widget.photosMu.RUnlock()
+
+	if !found || !shown {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, image, err := feed.FetchImmichThumbnail(r.Context(), widget.client, &widget.sources[index], id)
+
+	if err != nil {
+		slog.Error("Failed to fetch photo", "widget", widget.GetSlug(), "photo", id, "error", err)
+		http.Error(w, "could not get the photo", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(image)
+}
+
+func (widget *OnThisDay) YearsAgoLabel(years int) string {
+	if years == 1 {
+		return "1 year ago"
+	}
+
+	return fmt.Sprintf("%d years ago", years)
+}
+
+func (widget *OnThisDay) Render() template.HTML {
+	return widget.render(widget, assets.OnThisDayTemplate)
+}
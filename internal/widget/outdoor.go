@@ -0,0 +1,188 @@
+//go:build !slim || widget_outdoor
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("outdoor", func() Widget { return &Outdoor{} })
+}
+
+const (
+	outdoorLevelGood     = "good"
+	outdoorLevelModerate = "moderate"
+	outdoorLevelBad      = "bad"
+)
+
+// Values from moderate up to bad are moderate, anything above is bad
+type outdoorThreshold struct {
+	Moderate float64 `yaml:"moderate"`
+	Bad      float64 `yaml:"bad"`
+}
+
+func (t *outdoorThreshold) level(value float64) string {
+	switch {
+	case value >= t.Bad:
+		return outdoorLevelBad
+	case value >= t.Moderate:
+		return outdoorLevelModerate
+	}
+
+	return outdoorLevelGood
+}
+
+type Outdoor struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Location          string   `yaml:"location"`
+	AirQualityIndex   string   `yaml:"air-quality-index"`
+	PollenTypes       []string `yaml:"pollen"`
+	Thresholds        struct {
+		UVIndex    *outdoorThreshold `yaml:"uv-index"`
+		Pollen     *outdoorThreshold `yaml:"pollen"`
+		AirQuality *outdoorThreshold `yaml:"air-quality"`
+	} `yaml:"thresholds"`
+	Conditions *feed.OutdoorConditions `yaml:"-"`
+	place      *feed.PlaceJson         `yaml:"-"`
+}
+
+func (widget *Outdoor) Initialize() error {
+	widget.withTitle("Outdoor").withCacheDuration(time.Hour)
+
+	if widget.Location == "" {
+		return errors.New("location must be specified for outdoor widget")
+	}
+
+	switch widget.AirQualityIndex {
+	case "":
+		widget.AirQualityIndex = feed.AirQualityIndexEuropean
+	case feed.AirQualityIndexEuropean, feed.AirQualityIndexUS:
+	default:
+		return fmt.Errorf("invalid air-quality-index '%s' for outdoor widget, must be either european or us", widget.AirQualityIndex)
+	}
+
+	if len(widget.PollenTypes) == 0 {
+		widget.PollenTypes = feed.PollenTypes
+	}
+
+	for _, pollenType := range widget.PollenTypes {
+		if !slices.Contains(feed.PollenTypes, pollenType) {
+			return fmt.Errorf("invalid pollen type '%s' for outdoor widget, must be one of %s", pollenType, strings.Join(feed.PollenTypes, ", "))
+		}
+	}
+
+	if widget.Thresholds.UVIndex == nil {
+		widget.Thresholds.UVIndex = &outdoorThreshold{Moderate: 3, Bad: 6}
+	}
+
+	if widget.Thresholds.Pollen == nil {
+		widget.Thresholds.Pollen = &outdoorThreshold{Moderate: 20, Bad: 100}
+	}
+
+	// the bands in which each of the indexes goes from fair to moderate and from moderate to poor
+	if widget.Thresholds.AirQuality == nil {
+		if widget.AirQualityIndex == feed.AirQualityIndexUS {
+			widget.Thresholds.AirQuality = &outdoorThreshold{Moderate: 51, Bad: 101}
+		} else {
+			widget.Thresholds.AirQuality = &outdoorThreshold{Moderate: 40, Bad: 60}
+		}
+	}
+
+	for name, t := range map[string]*outdoorThreshold{
+		"uv-index":    widget.Thresholds.UVIndex,
+		"pollen":      widget.Thresholds.Pollen,
+		"air-quality": widget.Thresholds.AirQuality,
+	} {
+		if t.Moderate <= 0 || t.Bad <= t.Moderate {
+			return fmt.Errorf("invalid %s thresholds for outdoor widget, moderate must be above 0 and below bad", name)
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("outdoor widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Outdoor) Update(ctx context.Context) {
+	if widget.place == nil {
+		place, err := feed.FetchPlaceFromName(ctx, widget.Location, "")
+
+		if err != nil {
+			widget.withError(err).scheduleEarlyUpdate()
+			return
+		}
+
+		widget.place = place
+	}
+
+	conditions, err := feed.FetchOutdoorConditions(ctx, widget.client, widget.place, widget.AirQualityIndex, widget.PollenTypes)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Conditions = conditions
+}
+
+func (widget *Outdoor) UVIndexLevel() string {
+	return widget.Thresholds.UVIndex.level(widget.Conditions.UVIndex)
+}
+
+func (widget *Outdoor) PollenLevel() string {
+	if pollen := widget.Conditions.WorstPollen(); pollen != nil {
+		return widget.Thresholds.Pollen.level(pollen.Value)
+	}
+
+	return ""
+}
+
+func (widget *Outdoor) AirQualityLevel() string {
+	if widget.Conditions.AirQuality != nil {
+		return widget.Thresholds.AirQuality.level(*widget.Conditions.AirQuality)
+	}
+
+	return ""
+}
+
+// The worst of the levels, where the ones that aren't known for the location don't count
+func (widget *Outdoor) Level() string {
+	levels := []string{widget.UVIndexLevel(), widget.PollenLevel(), widget.AirQualityLevel()}
+
+	for _, level := range []string{outdoorLevelBad, outdoorLevelModerate} {
+		if slices.Contains(levels, level) {
+			return level
+		}
+	}
+
+	return outdoorLevelGood
+}
+
+func (widget *Outdoor) LevelColor(level string) string {
+	switch level {
+	case outdoorLevelGood:
+		return "color-positive"
+	case outdoorLevelModerate:
+		return "color-highlight"
+	case outdoorLevelBad:
+		return "color-negative"
+	}
+
+	return "color-subdue"
+}
+
+func (widget *Outdoor) Render() template.HTML {
+	return widget.render(widget, assets.OutdoorTemplate)
+}
@@ -0,0 +1,341 @@
+//go:build !slim || widget_pomodoro
+
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("pomodoro", func() Widget { return &Pomodoro{} })
+}
+
+const (
+	pomodoroPhaseWork       = "work"
+	pomodoroPhaseShortBreak = "short-break"
+	pomodoroPhaseLongBreak  = "long-break"
+)
+
+var errPomodoroInvalidAction = errors.New("the timer cannot do that right now")
+
+// The timer is running while EndsAt is set and paused while Remaining is,
+// otherwise the current phase hasn't been started yet
+type pomodoroState struct {
+	Phase     string        `json:"phase"`
+	EndsAt    time.Time     `json:"ends-at"`
+	Remaining time.Duration `json:"remaining"`
+	// work intervals completed since the last long break
+	Completed int `json:"completed"`
+}
+
+func (s *pomodoroState) IsRunning() bool {
+	return !s.EndsAt.IsZero()
+}
+
+func (s *pomodoroState) IsPaused() bool {
+	return s.EndsAt.IsZero() && s.Remaining > 0
+}
+
+// The phase is empty before the timer has been used, which counts as work
+func (s *pomodoroState) isBreak() bool {
+	return s.Phase == pomodoroPhaseShortBreak || s.Phase == pomodoroPhaseLongBreak
+}
+
+type Pomodoro struct {
+	widgetBase     `yaml:",inline"`
+	WorkDuration   DurationField `yaml:"work-duration"`
+	ShortBreak     DurationField `yaml:"short-break"`
+	LongBreak      DurationField `yaml:"long-break"`
+	LongBreakAfter int           `yaml:"long-break-after"`
+	StorageKey     string        `yaml:"storage-key"`
+	Ntfy           *ntfyOptions  `yaml:"ntfy"`
+	State          pomodoroState `yaml:"-"`
+	Remaining      time.Duration `yaml:"-"`
+	timerMu        sync.Mutex    `yaml:"-"`
+	timer          *time.Timer   `yaml:"-"`
+}
+
+func (widget *Pomodoro) Initialize() error {
+	widget.withTitle("Pomodoro").withError(nil)
+
+	if widget.WorkDuration == 0 {
+		widget.WorkDuration = DurationField(25 * time.Minute)
+	}
+
+	if widget.ShortBreak == 0 {
+		widget.ShortBreak = DurationField(5 * time.Minute)
+	}
+
+	if widget.LongBreak == 0 {
+		widget.LongBreak = DurationField(15 * time.Minute)
+	}
+
+	if widget.LongBreakAfter <= 0 {
+		widget.LongBreakAfter = 4
+	}
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	if widget.Ntfy != nil {
+		if err := widget.Ntfy.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The timer may have been running when glance was stopped, in which
+// case the notification still has to be sent once the interval ends
+func (widget *Pomodoro) SetProviders(providers *Providers) {
+	widget.widgetBase.SetProviders(providers)
+
+	var state pomodoroState
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &state); err != nil {
+		slog.Error("Failed to read pomodoro state", "key", widget.StorageKey, "error", err)
+		return
+	}
+
+	widget.schedule(state.EndsAt)
+}
+
+// Widgets with the same storage key share the same timer
+func (widget *Pomodoro) storageKey() string {
+	return "pomodoro:" + widget.StorageKey
+}
+
+func (widget *Pomodoro) duration(phase string) time.Duration {
+	switch phase {
+	case pomodoroPhaseShortBreak:
+		return time.Duration(widget.ShortBreak)
+	case pomodoroPhaseLongBreak:
+		return time.Duration(widget.LongBreak)
+	}
+
+	return time.Duration(widget.WorkDuration)
+}
+
+func (widget *Pomodoro) PhaseLabel() string {
+	switch widget.State.Phase {
+	case pomodoroPhaseShortBreak:
+		return "Short break"
+	case pomodoroPhaseLongBreak:
+		return "Long break"
+	}
+
+	return "Focus"
+}
+
+// As minutes and seconds, the same way the countdown in the browser shows it
+func (widget *Pomodoro) RemainingClock() string {
+	seconds := int(widget.Remaining.Round(time.Second).Seconds())
+
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// Which of the work intervals before the next long break have been completed
+func (widget *Pomodoro) Cycle() []bool {
+	cycle := make([]bool, widget.LongBreakAfter)
+
+	for i := range min(widget.State.Completed, len(cycle)) {
+		cycle[i] = true
+	}
+
+	return cycle
+}
+
+// Moves on to the phase after the current one without starting it. Skipped
+// work intervals don't count towards the long break
+func (widget *Pomodoro) advance(state *pomodoroState, completed bool) {
+	switch state.Phase {
+	case pomodoroPhaseShortBreak:
+		state.Phase = pomodoroPhaseWork
+	case pomodoroPhaseLongBreak:
+		state.Phase = pomodoroPhaseWork
+		state.Completed = 0
+	default:
+		if completed {
+			state.Completed++
+		}
+
+		if state.Completed >= widget.LongBreakAfter {
+			state.Phase = pomodoroPhaseLongBreak
+		} else {
+			state.Phase = pomodoroPhaseShortBreak
+		}
+	}
+
+	state.EndsAt = time.Time{}
+	state.Remaining = 0
+}
+
+func (widget *Pomodoro) schedule(endsAt time.Time) {
+	widget.timerMu.Lock()
+	defer widget.timerMu.Unlock()
+
+	if widget.timer != nil {
+		widget.timer.Stop()
+		widget.timer = nil
+	}
+
+	if endsAt.IsZero() {
+		return
+	}
+
+	widget.timer = time.AfterFunc(time.Until(endsAt), func() {
+		if _, err := widget.finish(endsAt); err != nil {
+			slog.Error("Failed to finish pomodoro interval", "key", widget.StorageKey, "error", err)
+		}
+	})
+}
+
+// Moves on to the next phase if the interval which ends at endsAt is still the one
+// running. Both the timer and rendering the widget can get here first, and so can
+// other widgets with the same storage key, but only one of them sends the notification
+func (widget *Pomodoro) finish(endsAt time.Time) (pomodoroState, error) {
+	var state pomodoroState
+	var finished, wasBreak bool
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+		if !state.EndsAt.Equal(endsAt) {
+			return nil
+		}
+
+		finished = true
+		wasBreak = state.isBreak()
+		widget.advance(&state, true)
+		return nil
+	})
+
+	if err != nil || !finished || widget.Ntfy == nil {
+		return state, err
+	}
+
+	notification := &feed.NtfyNotification{Title: "Focus interval is over", Message: "Time for a break", Tags: "tomato"}
+
+	if wasBreak {
+		notification = &feed.NtfyNotification{Title: "Break is over", Message: "Time to focus", Tags: "hourglass"}
+	}
+
+	widget.Ntfy.send(notification, "widget", "pomodoro", "key", widget.StorageKey)
+
+	return state, nil
+}
+
+func (widget *Pomodoro) Render() template.HTML {
+	widget.State = pomodoroState{}
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &widget.State); err != nil {
+		slog.Error("Failed to read pomodoro state", "key", widget.StorageKey, "error", err)
+	}
+
+	now := time.Now()
+
+	if widget.State.IsRunning() && !now.Before(widget.State.EndsAt) {
+		state, err := widget.finish(widget.State.EndsAt)
+
+		if err != nil {
+			slog.Error("Failed to finish pomodoro interval", "key", widget.StorageKey, "error", err)
+		} else {
+			widget.State = state
+		}
+	}
+
+	switch {
+	case widget.State.IsRunning():
+		widget.Remaining = max(widget.State.EndsAt.Sub(now), 0)
+	case widget.State.IsPaused():
+		widget.Remaining = widget.State.Remaining
+	default:
+		widget.Remaining = widget.duration(widget.State.Phase)
+	}
+
+	return widget.render(widget, assets.PomodoroTemplate)
+}
+
+// POST /start starts or resumes the current phase, POST /pause pauses it,
+// POST /skip moves on to the next phase and POST /reset goes back to the first one
+func (widget *Pomodoro) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("path")
+
+	if action != "start" && action != "pause" && action != "skip" && action != "reset" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var state pomodoroState
+	now := time.Now()
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+		// the interval may have ended without anything having noticed yet
+		if state.IsRunning() && !now.Before(state.EndsAt) && action != "reset" {
+			return errPomodoroInvalidAction
+		}
+
+		switch action {
+		case "start":
+			if state.IsRunning() {
+				return errPomodoroInvalidAction
+			}
+
+			remaining := state.Remaining
+
+			if remaining <= 0 {
+				remaining = widget.duration(state.Phase)
+			}
+
+			state.EndsAt = now.Add(remaining)
+			state.Remaining = 0
+		case "pause":
+			if !state.IsRunning() {
+				return errPomodoroInvalidAction
+			}
+
+			state.Remaining = state.EndsAt.Sub(now)
+			state.EndsAt = time.Time{}
+		case "skip":
+			widget.advance(&state, false)
+		case "reset":
+			state = pomodoroState{}
+		}
+
+		return nil
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if errors.Is(err, errPomodoroInvalidAction) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to update pomodoro state", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	widget.schedule(state.EndsAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&state)
+}
@@ -0,0 +1,126 @@
+//go:build !slim || widget_printer_supplies
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("printer-supplies", func() Widget { return &PrinterSupplies{} })
+}
+
+type printerSuppliesPrinter struct {
+	Name      string                `yaml:"name"`
+	IPP       OptionalEnvString     `yaml:"ipp"`
+	SNMP      OptionalEnvString     `yaml:"snmp"`
+	Community OptionalEnvString     `yaml:"community"`
+	Status    *feed.PrinterSupplies `yaml:"-"`
+	Error     string                `yaml:"-"`
+}
+
+type PrinterSupplies struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Printers          []printerSuppliesPrinter `yaml:"printers"`
+	LowLevel          int                      `yaml:"low-level"`
+	requests          []feed.PrinterSuppliesRequest
+}
+
+func (widget *PrinterSupplies) Initialize() error {
+	widget.withTitle("Printers").withCacheDuration(10 * time.Minute)
+
+	if len(widget.Printers) == 0 {
+		return errors.New("no printers specified for printer-supplies widget")
+	}
+
+	if widget.LowLevel <= 0 {
+		widget.LowLevel = 15
+	}
+
+	widget.requests = make([]feed.PrinterSuppliesRequest, len(widget.Printers))
+
+	for i := range widget.Printers {
+		printer := &widget.Printers[i]
+
+		if (printer.IPP == "") == (printer.SNMP == "") {
+			return fmt.Errorf("printer %d in printer-supplies widget needs either ipp or snmp", i+1)
+		}
+
+		if printer.IPP != "" {
+			parsed, err := url.Parse(printer.IPP.String())
+
+			if err != nil || parsed.Host == "" || (parsed.Scheme != "ipp" && parsed.Scheme != "ipps" && parsed.Scheme != "http" && parsed.Scheme != "https") {
+				return fmt.Errorf("invalid ipp address for printer %d in printer-supplies widget, must be like ipp://printer.local/ipp/print", i+1)
+			}
+		}
+
+		if printer.Community == "" {
+			printer.Community = "public"
+		}
+
+		widget.requests[i] = feed.PrinterSuppliesRequest{
+			URL:       printer.IPP.String(),
+			SNMPHost:  printer.SNMP.String(),
+			Community: printer.Community.String(),
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("printer-supplies widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *PrinterSupplies) Update(ctx context.Context) {
+	statuses, errs, err := feed.FetchPrinterSupplies(ctx, widget.client, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	var failed int
+
+	for i := range widget.Printers {
+		printer := &widget.Printers[i]
+
+		if errs[i] != nil {
+			failed++
+			printer.Error = errs[i].Error()
+			continue
+		}
+
+		printer.Error = ""
+		printer.Status = statuses[i]
+	}
+
+	if failed == len(widget.Printers) {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not reach any printers", feed.ErrNoContent))
+		return
+	}
+
+	if failed > 0 {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not reach %d printer(s)", feed.ErrPartialContent, failed))
+		return
+	}
+
+	widget.canContinueUpdateAfterHandlingErr(nil)
+}
+
+// Either below the level set for the widget or the one at which the printer itself considers it low
+func (widget *PrinterSupplies) IsLow(supply feed.PrinterSupply) bool {
+	return supply.Level >= 0 && (supply.Level < widget.LowLevel || supply.Level <= supply.LowLevel)
+}
+
+func (widget *PrinterSupplies) Render() template.HTML {
+	return widget.render(widget, assets.PrinterSuppliesTemplate)
+}
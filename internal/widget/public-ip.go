@@ -0,0 +1,265 @@
+//go:build !slim || widget_public_ip
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("public-ip", func() Widget { return &PublicIP{} })
+}
+
+const (
+	// How often the address gets checked for alerts, regardless of whether anyone has the page open
+	publicIPWatchInterval = 5 * time.Minute
+	// For how long after it changed the address is shown as having changed recently
+	publicIPRecentChange = 24 * time.Hour
+	publicIPStorageKey   = "public-ip"
+	publicIPTimeout      = 10 * time.Second
+)
+
+// Kept in the storage so that changes which happen while Glance isn't running get noticed
+// too. It's shared by all public-ip widgets since they all see the same addresses
+type publicIPState struct {
+	IPv4         string    `json:"ipv4"`
+	IPv6         string    `json:"ipv6"`
+	PreviousIPv4 string    `json:"previous-ipv4"`
+	PreviousIPv6 string    `json:"previous-ipv6"`
+	ChangedAt    time.Time `json:"changed-at"`
+	// when the addresses were first seen, for as long as they haven't changed
+	Since time.Time `json:"since"`
+}
+
+// What gets POSTed to the webhook-url when an address changes
+type publicIPChangePayload struct {
+	Family   string `json:"family"`
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+type PublicIP struct {
+	widgetBase `yaml:",inline"`
+	IPv6       bool          `yaml:"ipv6"`
+	Blocklists []string      `yaml:"blocklists"`
+	Ntfy       *ntfyOptions  `yaml:"ntfy"`
+	WebhookURL string        `yaml:"webhook-url"`
+	State      publicIPState `yaml:"-"`
+	Location   string        `yaml:"-"`
+	ListedOn   []string      `yaml:"-"`
+	// whether the blocklists could be checked, since not being listed on them means nothing otherwise
+	BlocklistsChecked bool `yaml:"-"`
+	ChangedRecently   bool `yaml:"-"`
+}
+
+func (widget *PublicIP) Initialize() error {
+	widget.withTitle("Public IP").withCacheDuration(10 * time.Minute)
+
+	if widget.Ntfy != nil {
+		if err := widget.Ntfy.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (widget *PublicIP) alertsEnabled() bool {
+	return widget.Ntfy != nil || widget.WebhookURL != ""
+}
+
+// Dynamic addresses change whenever the ISP feels like it, so they're watched in
+// the background for as long as the widget is in use when alerts are enabled
+func (widget *PublicIP) SetProviders(providers *Providers) {
+	widget.widgetBase.SetProviders(providers)
+
+	if widget.alertsEnabled() && providers.Context != nil {
+		go widget.watch(providers.Context)
+	}
+}
+
+func (widget *PublicIP) fetch(ctx context.Context) (ipv4, ipv6 netip.Addr, err error) {
+	var errs []error
+
+	if ipv4, err = feed.FetchPublicIP(ctx, false); err != nil {
+		errs = append(errs, fmt.Errorf("ipv4: %v", err))
+	}
+
+	if widget.IPv6 {
+		if ipv6, err = feed.FetchPublicIP(ctx, true); err != nil {
+			errs = append(errs, fmt.Errorf("ipv6: %v", err))
+		}
+	}
+
+	return ipv4, ipv6, errors.Join(errs...)
+}
+
+func (widget *PublicIP) Update(ctx context.Context) {
+	ipv4, ipv6, err := widget.fetch(ctx)
+
+	if !ipv4.IsValid() && !ipv6.IsValid() {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	} else if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrPartialContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	state := widget.record(ipv4, ipv6)
+	widget.State = state
+	widget.ChangedRecently = !state.ChangedAt.IsZero() && time.Since(state.ChangedAt) < publicIPRecentChange
+	widget.Location = ""
+
+	if widget.Providers.GeoIP != nil {
+		widget.Location = widget.lookupLocation(preferredAddress(ipv4, ipv6))
+	}
+
+	widget.ListedOn = nil
+	widget.BlocklistsChecked = false
+
+	if len(widget.Blocklists) > 0 && ipv4.IsValid() {
+		listedOn, err := feed.CheckDNSBlocklists(ctx, ipv4, widget.Blocklists)
+
+		if err != nil {
+			slog.Error("Failed to check blocklists", "address", ipv4, "error", err)
+		}
+
+		widget.ListedOn = listedOn
+		widget.BlocklistsChecked = err == nil || len(listedOn) > 0
+	}
+}
+
+func preferredAddress(ipv4, ipv6 netip.Addr) netip.Addr {
+	if ipv4.IsValid() {
+		return ipv4
+	}
+
+	return ipv6
+}
+
+func (widget *PublicIP) lookupLocation(address netip.Addr) string {
+	place, err := widget.Providers.GeoIP.LookupPlace(address)
+
+	if err != nil {
+		slog.Error("Failed to look up location of public IP", "address", address, "error", err)
+		return ""
+	}
+
+	if place != nil {
+		if place.Name != "" {
+			return place.Name + ", " + place.Country
+		}
+
+		return place.Country
+	}
+
+	// country databases don't have the coordinates needed for a place
+	country, _ := widget.Providers.GeoIP.LookupCountry(address)
+
+	return country
+}
+
+func (widget *PublicIP) watch(ctx context.Context) {
+	ticker := time.NewTicker(publicIPWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, publicIPTimeout)
+		ipv4, ipv6, _ := widget.fetch(fetchCtx)
+		cancel()
+
+		widget.record(ipv4, ipv6)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stores the addresses which could be found and sends the alerts for the ones that changed.
+// Whichever of the update and the watcher notices the change first is the one that alerts
+func (widget *PublicIP) record(ipv4, ipv6 netip.Addr) publicIPState {
+	var state publicIPState
+	var changes []publicIPChangePayload
+
+	err := widget.Providers.Storage.update(publicIPStorageKey, &state, func() error {
+		now := time.Now()
+		changes = nil
+
+		if state.Since.IsZero() {
+			state.Since = now
+		}
+
+		if ipv4.IsValid() && ipv4.String() != state.IPv4 {
+			if state.IPv4 != "" {
+				changes = append(changes, publicIPChangePayload{Family: "ipv4", Previous: state.IPv4, Current: ipv4.String()})
+				state.PreviousIPv4 = state.IPv4
+				state.ChangedAt = now
+				state.Since = now
+			}
+
+			state.IPv4 = ipv4.String()
+		}
+
+		if ipv6.IsValid() && ipv6.String() != state.IPv6 {
+			if state.IPv6 != "" {
+				changes = append(changes, publicIPChangePayload{Family: "ipv6", Previous: state.IPv6, Current: ipv6.String()})
+				state.PreviousIPv6 = state.IPv6
+				state.ChangedAt = now
+				state.Since = now
+			}
+
+			state.IPv6 = ipv6.String()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to store public IP", "error", err)
+	}
+
+	for i := range changes {
+		widget.alert(&changes[i])
+	}
+
+	return state
+}
+
+func (widget *PublicIP) alert(change *publicIPChangePayload) {
+	if widget.Ntfy != nil {
+		widget.Ntfy.send(&feed.NtfyNotification{
+			Title:   "Public IP changed",
+			Message: fmt.Sprintf("Changed from %s to %s", change.Previous, change.Current),
+			Tags:    "globe_with_meridians",
+		}, "widget", "public-ip")
+	}
+
+	if widget.WebhookURL != "" {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), publicIPTimeout)
+			defer cancel()
+
+			if err := feed.SendWebhook(ctx, widget.WebhookURL, change); err != nil {
+				slog.Error("Failed to send public IP webhook", "url", widget.WebhookURL, "error", err)
+			}
+		}()
+	}
+}
+
+func (widget *PublicIP) Render() template.HTML {
+	return widget.render(widget, assets.PublicIPTemplate)
+}
@@ -0,0 +1,196 @@
+//go:build !slim || widget_quick_log
+
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("quick-log", func() Widget { return &QuickLog{} })
+}
+
+const (
+	// older entries get removed once there are more than this many
+	quickLogMaxEntries     = 500
+	quickLogMaxRequestSize = 4 << 10
+)
+
+var errQuickLogEmpty = errors.New("there is nothing to undo")
+
+type quickLogEntry struct {
+	Action string    `json:"action"`
+	Time   time.Time `json:"time"`
+}
+
+type quickLogState struct {
+	Entries []quickLogEntry `json:"entries"`
+}
+
+type quickLogAction struct {
+	Name string
+	// zero if it has never been logged
+	Last time.Time
+}
+
+type QuickLog struct {
+	widgetBase  `yaml:",inline"`
+	StorageKey  string           `yaml:"storage-key"`
+	ActionNames []string         `yaml:"actions"`
+	Recent      int              `yaml:"recent"`
+	Actions     []quickLogAction `yaml:"-"`
+	Entries     []quickLogEntry  `yaml:"-"`
+}
+
+func (widget *QuickLog) Initialize() error {
+	widget.withTitle("Log").withError(nil)
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	if len(widget.ActionNames) == 0 {
+		return errors.New("at least one action must be specified for quick-log widget")
+	}
+
+	for i := range widget.ActionNames {
+		widget.ActionNames[i] = strings.TrimSpace(widget.ActionNames[i])
+
+		if widget.ActionNames[i] == "" {
+			return errors.New("actions cannot be empty")
+		}
+
+		if slices.Contains(widget.ActionNames[:i], widget.ActionNames[i]) {
+			return fmt.Errorf("action %s is specified more than once", widget.ActionNames[i])
+		}
+	}
+
+	if widget.Recent < 0 {
+		widget.Recent = 0
+	} else if widget.Recent == 0 {
+		widget.Recent = 5
+	}
+
+	return nil
+}
+
+// Widgets with the same storage key show the same log
+func (widget *QuickLog) storageKey() string {
+	return "quick-log:" + widget.StorageKey
+}
+
+func (widget *QuickLog) Render() template.HTML {
+	var state quickLogState
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &state); err != nil {
+		slog.Error("Failed to read quick log", "key", widget.StorageKey, "error", err)
+	}
+
+	widget.Actions = make([]quickLogAction, len(widget.ActionNames))
+
+	for i, name := range widget.ActionNames {
+		widget.Actions[i].Name = name
+
+		// entries are kept in the order they were logged
+		for j := len(state.Entries) - 1; j >= 0; j-- {
+			if state.Entries[j].Action == name {
+				widget.Actions[i].Last = state.Entries[j].Time
+				break
+			}
+		}
+	}
+
+	widget.Entries = make([]quickLogEntry, 0, widget.Recent)
+
+	for i := len(state.Entries) - 1; i >= 0 && len(widget.Entries) < widget.Recent; i-- {
+		widget.Entries = append(widget.Entries, state.Entries[i])
+	}
+
+	return widget.render(widget, assets.QuickLogTemplate)
+}
+
+type quickLogRequest struct {
+	Action string `json:"action"`
+}
+
+// POST /log adds an entry for the action in the body, at the current time, and
+// POST /undo removes the most recent entry, in case a button was pressed by mistake
+func (widget *QuickLog) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+
+	if path != "log" && path != "undo" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request quickLogRequest
+
+	if path == "log" {
+		if err := decodeJSONRequest(w, r, &request, quickLogMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !slices.Contains(widget.ActionNames, request.Action) {
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var state quickLogState
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+		if path == "undo" {
+			if len(state.Entries) == 0 {
+				return errQuickLogEmpty
+			}
+
+			state.Entries = state.Entries[:len(state.Entries)-1]
+			return nil
+		}
+
+		state.Entries = append(state.Entries, quickLogEntry{Action: request.Action, Time: time.Now().Truncate(time.Second)})
+
+		if len(state.Entries) > quickLogMaxEntries {
+			state.Entries = state.Entries[len(state.Entries)-quickLogMaxEntries:]
+		}
+
+		return nil
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if errors.Is(err, errQuickLogEmpty) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to update quick log", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if state.Entries == nil {
+		state.Entries = []quickLogEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&state)
+}
@@ -0,0 +1,78 @@
+//go:build !slim || widget_reading
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("reading", func() Widget { return &Reading{} })
+}
+
+type Reading struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Source            string      `yaml:"source"`
+	UserID            string      `yaml:"user-id"`
+	Export            string      `yaml:"export"`
+	CollapseAfter     int         `yaml:"collapse-after"`
+	Books             []feed.Book `yaml:"-"`
+}
+
+func (widget *Reading) Initialize() error {
+	widget.withTitle("Currently Reading").withCacheDuration(3 * time.Hour)
+
+	switch widget.Source {
+	case feed.ReadingSourceGoodreads:
+		if widget.UserID == "" {
+			return errors.New("user-id must be specified for goodreads in reading widget")
+		}
+
+		widget.withTitleURL("https://www.goodreads.com/review/list/" + url.PathEscape(widget.UserID) + "?shelf=currently-reading")
+	case feed.ReadingSourceStoryGraph:
+		if widget.Export == "" {
+			return errors.New("export must be specified for storygraph in reading widget")
+		}
+	default:
+		return errors.New("source for reading widget must be either goodreads or storygraph")
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 3
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("reading widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Reading) Update(ctx context.Context) {
+	location := widget.UserID
+
+	if widget.Source == feed.ReadingSourceStoryGraph {
+		location = widget.Export
+	}
+
+	books, err := feed.FetchCurrentlyReading(ctx, widget.client, widget.Source, location)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Books = books
+}
+
+func (widget *Reading) Render() template.HTML {
+	return widget.render(widget, assets.ReadingTemplate)
+}
@@ -1,8 +1,11 @@
+//go:build !slim || widget_reddit
+
 package widget
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
 	"strings"
 	"time"
@@ -11,10 +14,16 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("reddit", func() Widget { return &Reddit{} })
+}
+
 type Reddit struct {
 	widgetBase          `yaml:",inline"`
+	httpClientOptions   `yaml:",inline"`
 	Posts               feed.ForumPosts `yaml:"-"`
 	Subreddit           string          `yaml:"subreddit"`
+	Subreddits          []string        `yaml:"subreddits"`
 	Style               string          `yaml:"style"`
 	ShowThumbnails      bool            `yaml:"show-thumbnails"`
 	ShowFlairs          bool            `yaml:"show-flairs"`
@@ -24,15 +33,41 @@ type Reddit struct {
 	ExtraSortBy         string          `yaml:"extra-sort-by"`
 	CommentsUrlTemplate string          `yaml:"comments-url-template"`
 	Limit               int             `yaml:"limit"`
+	LimitPerSubreddit   int             `yaml:"limit-per-subreddit"`
 	CollapseAfter       int             `yaml:"collapse-after"`
 	RequestUrlTemplate  string          `yaml:"request-url-template"`
+	merged              bool            `yaml:"-"`
 }
 
 func (widget *Reddit) Initialize() error {
-	if widget.Subreddit == "" {
+	// subreddits joined with a + are left up to Reddit unless the
+	// subreddits are asked to be fetched separately and merged
+	widget.merged = len(widget.Subreddits) > 0 || widget.LimitPerSubreddit > 0
+
+	if widget.merged {
+		if widget.Subreddit != "" {
+			widget.Subreddits = append(strings.Split(widget.Subreddit, "+"), widget.Subreddits...)
+		}
+	} else if widget.Subreddit != "" {
+		widget.Subreddits = []string{widget.Subreddit}
+	}
+
+	if len(widget.Subreddits) == 0 {
 		return errors.New("no subreddit specified")
 	}
 
+	for i := range widget.Subreddits {
+		widget.Subreddits[i] = strings.TrimSpace(widget.Subreddits[i])
+
+		if widget.Subreddits[i] == "" {
+			return errors.New("subreddit names cannot be empty")
+		}
+	}
+
+	if widget.ExtraSortBy == "" && widget.merged && len(widget.Subreddits) > 1 {
+		widget.ExtraSortBy = "engagement"
+	}
+
 	if widget.Limit <= 0 {
 		widget.Limit = 15
 	}
@@ -55,9 +90,20 @@ func (widget *Reddit) Initialize() error {
 		}
 	}
 
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("reddit widget: %v", err)
+	}
+
+	joined := strings.Join(widget.Subreddits, "+")
+
+	if len(widget.Subreddits) == 1 {
+		widget.withTitle("/r/" + joined)
+	} else {
+		widget.withTitle("Reddit")
+	}
+
 	widget.
-		withTitle("/r/" + widget.Subreddit).
-		withTitleURL("https://www.reddit.com/r/" + widget.Subreddit + "/").
+		withTitleURL("https://www.reddit.com/r/" + joined + "/").
 		withCacheDuration(30 * time.Minute)
 
 	return nil
@@ -80,28 +126,43 @@ func isValidRedditTopPeriod(period string) bool {
 }
 
 func (widget *Reddit) Update(ctx context.Context) {
-	// TODO: refactor, use a struct to pass all of these
-	posts, err := feed.FetchSubredditPosts(
-		widget.Subreddit,
-		widget.SortBy,
-		widget.TopPeriod,
-		widget.Search,
-		widget.CommentsUrlTemplate,
-		widget.RequestUrlTemplate,
-		widget.ShowFlairs,
-	)
+	requests := make([]feed.SubredditPostsRequest, len(widget.Subreddits))
+
+	for i := range widget.Subreddits {
+		requests[i] = feed.SubredditPostsRequest{
+			Subreddit:           widget.Subreddits[i],
+			SortBy:              widget.SortBy,
+			TopPeriod:           widget.TopPeriod,
+			Search:              widget.Search,
+			CommentsUrlTemplate: widget.CommentsUrlTemplate,
+			RequestUrlTemplate:  widget.RequestUrlTemplate,
+			ShowFlairs:          widget.ShowFlairs,
+			Limit:               widget.LimitPerSubreddit,
+			Client:              widget.client,
+		}
+	}
+
+	posts, err := feed.FetchPostsFromSubreddits(ctx, requests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
 	}
 
-	if len(posts) > widget.Limit {
+	// the posts of a single subreddit are sorted after being limited,
+	// while merged posts are limited once sorted across all of them
+	if !widget.merged && len(posts) > widget.Limit {
 		posts = posts[:widget.Limit]
 	}
 
 	if widget.ExtraSortBy == "engagement" {
 		posts.CalculateEngagement()
 		posts.SortByEngagement()
+	} else if widget.ExtraSortBy == "new" {
+		posts.SortByNewest()
+	}
+
+	if len(posts) > widget.Limit {
+		posts = posts[:widget.Limit]
 	}
 
 	widget.Posts = posts
@@ -119,3 +180,17 @@ func (widget *Reddit) Render() template.HTML {
 	return widget.render(widget, assets.ForumPostsTemplate)
 
 }
+
+func (widget *Reddit) styleField() (*string, []string) {
+	return &widget.Style, []string{"vertical-list", "horizontal-cards", "vertical-cards"}
+}
+
+func (widget *Reddit) resourceHints(hints *ResourceHints) {
+	if widget.Style != "horizontal-cards" && widget.Style != "vertical-cards" && !widget.ShowThumbnails {
+		return
+	}
+
+	for i := 0; i < len(widget.Posts) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Posts[i].ThumbnailUrl)
+	}
+}
@@ -0,0 +1,74 @@
+package widget
+
+import "log/slog"
+
+// Services which use OAuth can hand out a new refresh token along with each access token,
+// after which the previous one stops working. The newest one is kept in the storage so that
+// it survives restarts, along with the one from the config at the time, so that the one in
+// the config gets used again once it's changed, e.g. because the app was authorized again
+type storedRefreshToken struct {
+	Configured string `json:"configured"`
+	Latest     string `json:"latest"`
+}
+
+func loadRefreshToken(storage *Storage, key string, configured string) string {
+	var stored storedRefreshToken
+
+	if err := storage.get(key, &stored); err != nil {
+		slog.Error("Failed to read refresh token", "key", key, "error", err)
+		return configured
+	}
+
+	if stored.Latest != "" && stored.Configured == configured {
+		return stored.Latest
+	}
+
+	return configured
+}
+
+func saveRefreshToken(storage *Storage, key string, configured string, latest string) {
+	var stored storedRefreshToken
+
+	err := storage.update(key, &stored, func() error {
+		stored.Configured = configured
+		stored.Latest = latest
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to store refresh token", "key", key, "error", err)
+	}
+}
+
+// Used in place of widgetBase by widgets whose client gets new refresh tokens,
+// which get stored through storeRefreshToken when given to the client as its
+// OnRefreshTokenChange and used again after a restart
+type refreshTokenWidgetBase struct {
+	widgetBase      `yaml:",inline"`
+	refreshTokenKey string `yaml:"-"`
+	// the refresh token from the config
+	configuredRefreshToken string `yaml:"-"`
+	// the refresh token which the client of the widget uses
+	clientRefreshToken *string `yaml:"-"`
+}
+
+// Must be called from Initialize, the key tells apart the accounts the tokens belong to
+func (w *refreshTokenWidgetBase) keepRefreshToken(key string, configured string, clientRefreshToken *string) {
+	w.refreshTokenKey = key
+	w.configuredRefreshToken = configured
+	w.clientRefreshToken = clientRefreshToken
+}
+
+// Uses the refresh token stored from a previous run, unless the one in the config has
+// been changed since then
+func (w *refreshTokenWidgetBase) SetProviders(providers *Providers) {
+	w.widgetBase.SetProviders(providers)
+
+	if w.clientRefreshToken != nil {
+		*w.clientRefreshToken = loadRefreshToken(providers.Storage, w.refreshTokenKey, w.configuredRefreshToken)
+	}
+}
+
+func (w *refreshTokenWidgetBase) storeRefreshToken(token string) {
+	saveRefreshToken(w.Providers.Storage, w.refreshTokenKey, w.configuredRefreshToken, token)
+}
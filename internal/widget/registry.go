@@ -0,0 +1,186 @@
+//go:build !slim || widget_registry
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("registry", func() Widget { return &Registry{} })
+}
+
+const (
+	// older pulls get dropped once there are this many
+	registryMaxWebhookPulls = 100
+	registryMaxWebhookSize  = 1 << 20
+)
+
+type Registry struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Provider          string            `yaml:"provider"`
+	URL               string            `yaml:"url"`
+	Username          string            `yaml:"username"`
+	Password          OptionalEnvString `yaml:"password"`
+	StoragePath       string            `yaml:"storage-path"`
+	// how full the storage can get, in percent, before it's shown as running out
+	StorageWarning int `yaml:"storage-warning"`
+	// when set, the pulls are received through /api/webhooks/{token}
+	// from the notifications of the registry
+	WebhookToken      OptionalEnvString   `yaml:"webhook-token"`
+	PullsLimit        int                 `yaml:"pulls-limit"`
+	RepositoriesLimit int                 `yaml:"repositories-limit"`
+	Stats             *feed.RegistryStats `yaml:"-"`
+	Pulls             []feed.RegistryPull `yaml:"-"`
+}
+
+func (widget *Registry) Initialize() error {
+	widget.withTitle("Registry").withCacheDuration(30 * time.Minute)
+
+	if widget.URL == "" {
+		return fmt.Errorf("url must be specified for registry widget")
+	}
+
+	switch widget.Provider {
+	case "":
+		widget.Provider = feed.RegistryProviderDistribution
+	case feed.RegistryProviderDistribution, feed.RegistryProviderHarbor:
+	default:
+		return fmt.Errorf("invalid provider '%s' for registry widget, must be either registry or harbor", widget.Provider)
+	}
+
+	if widget.WebhookToken != "" && widget.Provider != feed.RegistryProviderDistribution {
+		return fmt.Errorf("webhook-token can only be used with the registry provider in registry widget")
+	}
+
+	if widget.StoragePath != "" {
+		if info, err := os.Stat(widget.StoragePath); err != nil || !info.IsDir() {
+			return fmt.Errorf("storage-path '%s' of registry widget is not a directory", widget.StoragePath)
+		}
+	}
+
+	if widget.StorageWarning <= 0 || widget.StorageWarning > 100 {
+		widget.StorageWarning = 85
+	}
+
+	if widget.PullsLimit == 0 || widget.PullsLimit < -1 {
+		widget.PullsLimit = 5
+	}
+
+	if widget.RepositoriesLimit == 0 || widget.RepositoriesLimit < -1 {
+		widget.RepositoriesLimit = 5
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("registry widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Registry) Update(ctx context.Context) {
+	stats, err := feed.FetchRegistryStats(ctx, &feed.RegistryRequest{
+		Provider:          widget.Provider,
+		URL:               widget.URL,
+		Username:          widget.Username,
+		Password:          widget.Password.String(),
+		StoragePath:       widget.StoragePath,
+		PullsLimit:        widget.PullsLimit,
+		RepositoriesLimit: widget.RepositoriesLimit,
+		Client:            widget.client,
+	})
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Stats = stats
+}
+
+func (widget *Registry) IsStorageLow() bool {
+	return widget.Stats != nil && widget.Stats.StorageUsedPercent() >= float64(widget.StorageWarning)
+}
+
+// Widgets with the same webhook token show the same pulls
+func (widget *Registry) storageKey() string {
+	return "registry:" + widget.WebhookToken.String()
+}
+
+func (widget *Registry) Render() template.HTML {
+	widget.Pulls = nil
+
+	switch {
+	case widget.PullsLimit < 0:
+	case widget.WebhookToken != "":
+		if err := widget.Providers.Storage.get(widget.storageKey(), &widget.Pulls); err != nil {
+			slog.Error("Failed to read pulls received through webhook", "error", err)
+		}
+	case widget.Stats != nil:
+		widget.Pulls = widget.Stats.Pulls
+	}
+
+	if widget.PullsLimit > 0 && len(widget.Pulls) > widget.PullsLimit {
+		widget.Pulls = widget.Pulls[:widget.PullsLimit]
+	}
+
+	return widget.render(widget, assets.RegistryTemplate)
+}
+
+func (widget *Registry) webhookToken() string {
+	return widget.WebhookToken.String()
+}
+
+// Receives the notifications of the registry, which can be set up through the
+// notifications.endpoints of its configuration
+func (widget *Registry) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, registryMaxWebhookSize))
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	received := feed.ParseRegistryNotifications(body)
+
+	// the registry sends notifications for everything, such as pushes and
+	// layers being pulled, which aren't errors but have nothing to store
+	if len(received) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var pulls []feed.RegistryPull
+
+	err = widget.Providers.Storage.update(widget.storageKey(), &pulls, func() error {
+		for i := range received {
+			pulls = append([]feed.RegistryPull{received[i]}, pulls...)
+		}
+
+		if len(pulls) > registryMaxWebhookPulls {
+			pulls = pulls[:registryMaxWebhookPulls]
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("Failed to store pulls received through webhook", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// lets pages with live updates know that the widget has to be rendered again
+	widget.updates.Add(1)
+	w.WriteHeader(http.StatusNoContent)
+}
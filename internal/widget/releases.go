@@ -1,21 +1,55 @@
+//go:build !slim || widget_releases
+
 package widget
 
 import (
 	"context"
 	"errors"
+	"fmt"
 	"html/template"
 	"strings"
 	"time"
 
 	"github.com/glanceapp/glance/internal/assets"
 	"github.com/glanceapp/glance/internal/feed"
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	register("releases", func() Widget { return &Releases{} })
+}
+
+// Either just the repository or a mapping which also has a token that only applies to it
+type releaseRepository struct {
+	Repository string            `yaml:"repository"`
+	Token      OptionalEnvString `yaml:"token"`
+}
+
+func (r *releaseRepository) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&r.Repository)
+	}
+
+	// a separate type so that decoding it doesn't end up calling this again
+	var repository struct {
+		Repository string            `yaml:"repository"`
+		Token      OptionalEnvString `yaml:"token"`
+	}
+
+	if err := node.Decode(&repository); err != nil {
+		return err
+	}
+
+	*r = releaseRepository(repository)
+
+	return nil
+}
+
 type Releases struct {
 	widgetBase      `yaml:",inline"`
 	Releases        feed.AppReleases       `yaml:"-"`
 	releaseRequests []*feed.ReleaseRequest `yaml:"-"`
-	Repositories    []string               `yaml:"repositories"`
+	Repositories    []releaseRepository    `yaml:"repositories"`
 	Token           OptionalEnvString      `yaml:"token"`
 	GitLabToken     OptionalEnvString      `yaml:"gitlab-token"`
 	Limit           int                    `yaml:"limit"`
@@ -34,44 +68,45 @@ func (widget *Releases) Initialize() error {
 		widget.CollapseAfter = 5
 	}
 
-	var tokenAsString = widget.Token.String()
-	var gitLabTokenAsString = widget.GitLabToken.String()
-
 	for _, repository := range widget.Repositories {
-		parts := strings.SplitN(repository, ":", 2)
-		var request *feed.ReleaseRequest
-		if len(parts) == 1 {
-			request = &feed.ReleaseRequest{
-				Source:     feed.ReleaseSourceGithub,
-				Repository: repository,
-			}
+		source, name, found := strings.Cut(repository.Repository, ":")
+
+		// repositories without a source are on GitHub
+		if !found {
+			source, name = string(feed.ReleaseSourceGithub), repository.Repository
+		}
+
+		if name == "" {
+			return errors.New("repository must not be empty")
+		}
+
+		request := &feed.ReleaseRequest{
+			Source:     feed.ReleaseSource(source),
+			Repository: name,
+		}
+
+		token := repository.Token.String()
 
-			if widget.Token != "" {
-				request.Token = &tokenAsString
+		switch request.Source {
+		case feed.ReleaseSourceGithub:
+			if token == "" {
+				token = widget.Token.String()
 			}
-		} else if len(parts) == 2 {
-			if parts[0] == string(feed.ReleaseSourceGitlab) {
-				request = &feed.ReleaseRequest{
-					Source:     feed.ReleaseSourceGitlab,
-					Repository: parts[1],
-				}
-
-				if widget.GitLabToken != "" {
-					request.Token = &gitLabTokenAsString
-				}
-			} else if parts[0] == string(feed.ReleaseSourceDockerHub) {
-				request = &feed.ReleaseRequest{
-					Source:     feed.ReleaseSourceDockerHub,
-					Repository: parts[1],
-				}
-			} else if parts[0] == string(feed.ReleaseSourceCodeberg) {
-				request = &feed.ReleaseRequest{
-					Source:     feed.ReleaseSourceCodeberg,
-					Repository: parts[1],
-				}
-			} else {
-				return errors.New("invalid repository source " + parts[0])
+		case feed.ReleaseSourceGitlab:
+			if token == "" {
+				token = widget.GitLabToken.String()
 			}
+		case feed.ReleaseSourceGitea:
+			if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+				return fmt.Errorf("gitea repository %s must be the full URL of the repository", name)
+			}
+		case feed.ReleaseSourceCodeberg, feed.ReleaseSourceDockerHub:
+		default:
+			return errors.New("invalid repository source " + source)
+		}
+
+		if token != "" {
+			request.Token = &token
 		}
 
 		widget.releaseRequests = append(widget.releaseRequests, request)
@@ -81,7 +116,7 @@ func (widget *Releases) Initialize() error {
 }
 
 func (widget *Releases) Update(ctx context.Context) {
-	releases, err := feed.FetchLatestReleases(widget.releaseRequests)
+	releases, err := feed.FetchLatestReleases(ctx, widget.releaseRequests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -101,3 +136,7 @@ func (widget *Releases) Update(ctx context.Context) {
 func (widget *Releases) Render() template.HTML {
 	return widget.render(widget, assets.ReleasesTemplate)
 }
+
+func (widget *Releases) isEmpty() bool {
+	return len(widget.Releases) == 0
+}
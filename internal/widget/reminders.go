@@ -0,0 +1,216 @@
+//go:build !slim || widget_reminders
+
+package widget
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("reminders", func() Widget { return &Reminders{} })
+}
+
+const remindersMaxRequestSize = 4 << 10
+
+// When each reminder was last done, as a date. The one before it is kept
+// so that checking a reminder off by mistake can be undone on the same day
+type reminderState struct {
+	Last     string `json:"last"`
+	Previous string `json:"previous,omitempty"`
+}
+
+type reminder struct {
+	Name      string    `yaml:"name"`
+	Every     int       `yaml:"every"`
+	StartDate time.Time `yaml:"start-date"`
+	DoneToday bool      `yaml:"-"`
+	// negative when overdue, relative to today
+	DueIn int `yaml:"-"`
+}
+
+type Reminders struct {
+	widgetBase `yaml:",inline"`
+	StorageKey string     `yaml:"storage-key"`
+	Reminders  []reminder `yaml:"reminders"`
+}
+
+func (widget *Reminders) Initialize() error {
+	widget.withTitle("Reminders").withError(nil)
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	if len(widget.Reminders) == 0 {
+		return errors.New("no reminders specified for reminders widget")
+	}
+
+	for i := range widget.Reminders {
+		r := &widget.Reminders[i]
+		r.Name = strings.TrimSpace(r.Name)
+
+		if r.Name == "" {
+			return errors.New("reminders must have a name")
+		}
+
+		if widget.reminder(r.Name) != r {
+			return fmt.Errorf("reminder %s is specified more than once", r.Name)
+		}
+
+		if r.Every < 0 {
+			return fmt.Errorf("every cannot be negative for reminder %s", r.Name)
+		}
+
+		if r.Every == 0 {
+			r.Every = 1
+		}
+	}
+
+	return nil
+}
+
+// Widgets with the same storage key share when reminders were last done
+func (widget *Reminders) storageKey() string {
+	return "reminders:" + widget.StorageKey
+}
+
+func (widget *Reminders) reminder(name string) *reminder {
+	for i := range widget.Reminders {
+		if widget.Reminders[i].Name == name {
+			return &widget.Reminders[i]
+		}
+	}
+
+	return nil
+}
+
+// Today's date in the timezone of the server, as UTC so that every day is 24 hours long
+func remindersToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Reminders which have never been done are due on their start date, or right away without one
+func (r *reminder) nextDue(state reminderState, today time.Time) time.Time {
+	if last, err := time.Parse(time.DateOnly, state.Last); err == nil {
+		return last.AddDate(0, 0, r.Every)
+	}
+
+	if r.StartDate.IsZero() {
+		return today
+	}
+
+	return time.Date(r.StartDate.Year(), r.StartDate.Month(), r.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (r reminder) DueLabel() string {
+	switch {
+	case r.DueIn < -1:
+		return fmt.Sprintf("Overdue by %d days", -r.DueIn)
+	case r.DueIn == -1:
+		return "Overdue by a day"
+	case r.DueIn == 0:
+		return "Due today"
+	case r.DueIn == 1:
+		return "Due tomorrow"
+	}
+
+	return fmt.Sprintf("Due in %d days", r.DueIn)
+}
+
+func (widget *Reminders) Render() template.HTML {
+	states := make(map[string]reminderState)
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &states); err != nil {
+		slog.Error("Failed to read reminders", "key", widget.StorageKey, "error", err)
+	}
+
+	today := remindersToday()
+	todayString := today.Format(time.DateOnly)
+
+	for i := range widget.Reminders {
+		r := &widget.Reminders[i]
+		state := states[r.Name]
+		r.DoneToday = state.Last == todayString
+		r.DueIn = int(r.nextDue(state, today).Sub(today).Hours() / 24)
+	}
+
+	return widget.render(widget, assets.RemindersTemplate)
+}
+
+type remindersRequest struct {
+	Reminder string `json:"reminder"`
+	Done     bool   `json:"done"`
+}
+
+// POST /done marks the reminder in the body as done today, which moves its next due date
+// forward, or undoes that if it was done earlier today
+func (widget *Reminders) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("path") != "done" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request remindersRequest
+
+	if err := decodeJSONRequest(w, r, &request, remindersMaxRequestSize); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if widget.reminder(request.Reminder) == nil {
+		http.Error(w, "unknown reminder", http.StatusBadRequest)
+		return
+	}
+
+	today := remindersToday().Format(time.DateOnly)
+	states := make(map[string]reminderState)
+
+	err := widget.Providers.Storage.update(widget.storageKey(), &states, func() error {
+		// reminders which have been removed from the config since
+		for name := range states {
+			if widget.reminder(name) == nil {
+				delete(states, name)
+			}
+		}
+
+		state := states[request.Reminder]
+
+		switch {
+		case request.Done && state.Last != today:
+			states[request.Reminder] = reminderState{Last: today, Previous: state.Last}
+		case !request.Done && state.Last == today:
+			if state.Previous == "" {
+				delete(states, request.Reminder)
+			} else {
+				states[request.Reminder] = reminderState{Last: state.Previous}
+			}
+		}
+
+		return nil
+	})
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err != nil {
+		slog.Error("Failed to update reminders", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
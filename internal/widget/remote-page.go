@@ -0,0 +1,78 @@
+//go:build !slim || widget_remote_page
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("remote-page", func() Widget { return &RemotePage{} })
+}
+
+type RemotePage struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               string            `yaml:"url"`
+	Token             OptionalEnvString `yaml:"token"`
+	Page              string            `yaml:"page"`
+	Widgets           []string          `yaml:"widgets"`
+	RemoteWidgets     []template.HTML   `yaml:"-"`
+}
+
+func (widget *RemotePage) Initialize() error {
+	widget.withTitle("Remote Page").withCacheDuration(5 * time.Minute).SetHideHeader(true)
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for remote-page widget")
+	}
+
+	if widget.Token == "" {
+		return errors.New("token must be specified for remote-page widget")
+	}
+
+	if widget.Page == "" {
+		return errors.New("page must be specified for remote-page widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("remote-page widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *RemotePage) Update(ctx context.Context) {
+	page, err := feed.FetchRemotePage(ctx, widget.client, widget.URL, widget.Token.String(), widget.Page)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	remoteWidgets := make([]template.HTML, 0, len(page.Widgets))
+
+	for i := range page.Widgets {
+		remote := &page.Widgets[i]
+
+		if len(widget.Widgets) > 0 && !slices.Contains(widget.Widgets, remote.Slug) {
+			continue
+		}
+
+		// already sanitized when fetched
+		remoteWidgets = append(remoteWidgets, template.HTML(remote.HTML))
+	}
+
+	widget.RemoteWidgets = remoteWidgets
+}
+
+func (widget *RemotePage) Render() template.HTML {
+	return widget.render(widget, assets.RemotePageTemplate)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_repository
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("repository", func() Widget { return &Repository{} })
+}
+
 type Repository struct {
 	widgetBase          `yaml:",inline"`
 	RequestedRepository string            `yaml:"repository"`
@@ -39,6 +45,7 @@ func (widget *Repository) Initialize() error {
 
 func (widget *Repository) Update(ctx context.Context) {
 	details, err := feed.FetchRepositoryDetailsFromGithub(
+		ctx,
 		widget.RequestedRepository,
 		string(widget.Token),
 		widget.PullRequestsLimit,
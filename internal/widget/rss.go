@@ -1,7 +1,10 @@
+//go:build !slim || widget_rss
+
 package widget
 
 import (
 	"context"
+	"fmt"
 	"html/template"
 	"time"
 
@@ -9,17 +12,22 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("rss", func() Widget { return &RSS{} })
+}
+
 type RSS struct {
-	widgetBase       `yaml:",inline"`
-	FeedRequests     []feed.RSSFeedRequest `yaml:"feeds"`
-	Style            string                `yaml:"style"`
-	ThumbnailHeight  float64               `yaml:"thumbnail-height"`
-	CardHeight       float64               `yaml:"card-height"`
-	Items            feed.RSSFeedItems     `yaml:"-"`
-	Limit            int                   `yaml:"limit"`
-	CollapseAfter    int                   `yaml:"collapse-after"`
-	SingleLineTitles bool                  `yaml:"single-line-titles"`
-	NoItemsMessage   string                `yaml:"-"`
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	FeedRequests      []feed.RSSFeedRequest `yaml:"feeds"`
+	Style             string                `yaml:"style"`
+	ThumbnailHeight   float64               `yaml:"thumbnail-height"`
+	CardHeight        float64               `yaml:"card-height"`
+	Items             feed.RSSFeedItems     `yaml:"-"`
+	Limit             int                   `yaml:"limit"`
+	CollapseAfter     int                   `yaml:"collapse-after"`
+	SingleLineTitles  bool                  `yaml:"single-line-titles"`
+	NoItemsMessage    string                `yaml:"-"`
 }
 
 func (widget *RSS) Initialize() error {
@@ -47,13 +55,25 @@ func (widget *RSS) Initialize() error {
 		}
 	}
 
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("rss widget: %v", err)
+	}
+
+	for i := range widget.FeedRequests {
+		widget.FeedRequests[i].Client = widget.client
+
+		if err := widget.FeedRequests[i].CompileExcludeKeywords(); err != nil {
+			return fmt.Errorf("feed %d in rss widget: %v", i+1, err)
+		}
+	}
+
 	widget.NoItemsMessage = "No items were returned from the feeds."
 
 	return nil
 }
 
 func (widget *RSS) Update(ctx context.Context) {
-	items, err := feed.GetItemsFromRSSFeeds(widget.FeedRequests)
+	items, err := feed.GetItemsFromRSSFeeds(ctx, widget.FeedRequests)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -81,3 +101,21 @@ func (widget *RSS) Render() template.HTML {
 
 	return widget.render(widget, assets.RSSListTemplate)
 }
+
+func (widget *RSS) isEmpty() bool {
+	return len(widget.Items) == 0
+}
+
+func (widget *RSS) styleField() (*string, []string) {
+	return &widget.Style, []string{"vertical-list", "detailed-list", "horizontal-cards", "horizontal-cards-2"}
+}
+
+func (widget *RSS) resourceHints(hints *ResourceHints) {
+	if widget.Style != "detailed-list" && widget.Style != "horizontal-cards" && widget.Style != "horizontal-cards-2" {
+		return
+	}
+
+	for i := 0; i < len(widget.Items) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Items[i].ImageURL)
+	}
+}
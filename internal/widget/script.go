@@ -0,0 +1,122 @@
+//go:build !slim || widget_script
+
+package widget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("script", func() Widget { return &Script{} })
+}
+
+const defaultScriptTimeout = 30 * time.Second
+
+// Runs a Lua script which fetches and transforms data and shows what it returns through a
+// template the same way as the custom API widget, which lets widgets be written without
+// having to change Glance itself
+type Script struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Script            string                       `yaml:"script"`
+	ScriptFile        string                       `yaml:"script-file"`
+	Parameters        map[string]OptionalEnvString `yaml:"parameters"`
+	Template          string                       `yaml:"template"`
+	request           *feed.ScriptRequest          `yaml:"-"`
+	compiledTemplate  *template.Template           `yaml:"-"`
+	CompiledHTML      template.HTML                `yaml:"-"`
+	output            []byte                       `yaml:"-"`
+}
+
+func (widget *Script) Initialize() error {
+	widget.withTitle("Script").withCacheDuration(1 * time.Hour)
+
+	if (widget.Script == "") == (widget.ScriptFile == "") {
+		return errors.New("either script or script-file is required")
+	}
+
+	if widget.Template == "" {
+		return errors.New("template is required")
+	}
+
+	// a script which loops forever would otherwise keep the update going forever
+	if widget.RequestTimeout == 0 {
+		widget.RequestTimeout = DurationField(defaultScriptTimeout)
+	}
+
+	name, source := "script", widget.Script
+
+	if widget.ScriptFile != "" {
+		contents, err := os.ReadFile(widget.ScriptFile)
+
+		if err != nil {
+			return fmt.Errorf("reading script-file: %v", err)
+		}
+
+		name, source = filepath.Base(widget.ScriptFile), string(contents)
+	}
+
+	script, err := feed.CompileScript(name, source)
+
+	if err != nil {
+		return fmt.Errorf("compiling script: %v", err)
+	}
+
+	compiledTemplate, err := template.New("").Funcs(feed.CustomAPITemplateFuncs).Parse(widget.Template)
+
+	if err != nil {
+		return fmt.Errorf("parsing template: %v", err)
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("script widget: %v", err)
+	}
+
+	widget.compiledTemplate = compiledTemplate
+	widget.request = &feed.ScriptRequest{
+		Script:     script,
+		Parameters: envStringMap(widget.Parameters),
+		Client:     widget.client,
+	}
+
+	return nil
+}
+
+func (widget *Script) Update(ctx context.Context) {
+	output, err := feed.RunScript(ctx, widget.request)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	compiledHTML, err := feed.ParseCustomAPIData(output, widget.compiledTemplate)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.CompiledHTML = compiledHTML
+	widget.output = output
+}
+
+func (widget *Script) sharedData() any {
+	if widget.output == nil {
+		return nil
+	}
+
+	return json.RawMessage(widget.output)
+}
+
+func (widget *Script) Render() template.HTML {
+	return widget.render(widget, assets.CustomAPITemplate)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_search
+
 package widget
 
 import (
@@ -6,19 +8,57 @@ import (
 	"strings"
 
 	"github.com/glanceapp/glance/internal/assets"
+	"gopkg.in/yaml.v3"
 )
 
+func init() {
+	register("search", func() Widget { return &Search{} })
+}
+
 type SearchBang struct {
 	Title    string
 	Shortcut string
 	URL      string
 }
 
+// Bangs can either be a list of bangs with titles or a
+// map of shortcuts to the URL or name of a search engine
+type searchBangs []SearchBang
+
+func (b *searchBangs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		var bangs []SearchBang
+
+		if err := node.Decode(&bangs); err != nil {
+			return err
+		}
+
+		*b = bangs
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var bang SearchBang
+
+		if err := node.Content[i].Decode(&bang.Shortcut); err != nil {
+			return err
+		}
+
+		if err := node.Content[i+1].Decode(&bang.URL); err != nil {
+			return fmt.Errorf("bang %s: %v", bang.Shortcut, err)
+		}
+
+		*b = append(*b, bang)
+	}
+
+	return nil
+}
+
 type Search struct {
 	widgetBase   `yaml:",inline"`
 	cachedHTML   template.HTML `yaml:"-"`
 	SearchEngine string        `yaml:"search-engine"`
-	Bangs        []SearchBang  `yaml:"bangs"`
+	Bangs        searchBangs   `yaml:"bangs"`
 	NewTab       bool          `yaml:"new-tab"`
 	Autofocus    bool          `yaml:"autofocus"`
 }
@@ -32,6 +72,11 @@ func convertSearchUrl(url string) string {
 var searchEngines = map[string]string{
 	"duckduckgo": "https://duckduckgo.com/?q={QUERY}",
 	"google":     "https://www.google.com/search?q={QUERY}",
+	"bing":       "https://www.bing.com/search?q={QUERY}",
+	"brave":      "https://search.brave.com/search?q={QUERY}",
+	"startpage":  "https://www.startpage.com/do/search?q={QUERY}",
+	"kagi":       "https://kagi.com/search?q={QUERY}",
+	"ecosia":     "https://www.ecosia.org/search?q={QUERY}",
 }
 
 func (widget *Search) Initialize() error {
@@ -56,6 +101,10 @@ func (widget *Search) Initialize() error {
 			return fmt.Errorf("Search bang %d has no URL", i+1)
 		}
 
+		if url, ok := searchEngines[widget.Bangs[i].URL]; ok {
+			widget.Bangs[i].URL = url
+		}
+
 		widget.Bangs[i].URL = convertSearchUrl(widget.Bangs[i].URL)
 	}
 
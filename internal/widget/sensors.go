@@ -0,0 +1,194 @@
+//go:build !slim || widget_sensors
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("sensors", func() Widget { return &Sensors{} })
+}
+
+type sensorReading struct {
+	Label      string     `yaml:"label"`
+	ID         string     `yaml:"id"`
+	Path       string     `yaml:"path"`
+	Suffix     string     `yaml:"suffix"`
+	Decimals   *int       `yaml:"decimals"`
+	Thresholds thresholds `yaml:"thresholds"`
+	Value      float64    `yaml:"-"`
+	Threshold  *threshold `yaml:"-"`
+	Error      string     `yaml:"-"`
+	Chart      string     `yaml:"-"`
+}
+
+// Whole numbers are shown without decimals and everything else with one unless specified otherwise
+func (r *sensorReading) DecimalPlaces() int {
+	if r.Decimals != nil {
+		return *r.Decimals
+	}
+
+	if r.Value == math.Trunc(r.Value) {
+		return 0
+	}
+
+	return 1
+}
+
+type sensorRoom struct {
+	Name    string          `yaml:"name"`
+	ESPHome URLField        `yaml:"esphome"`
+	URL     URLField        `yaml:"url"`
+	Sensors []sensorReading `yaml:"sensors"`
+}
+
+func (room *sensorRoom) historyKey(sensor *sensorReading) string {
+	if room.ESPHome != "" {
+		return "sensors:" + string(room.ESPHome) + ":" + sensor.ID
+	}
+
+	return "sensors:" + string(room.URL) + ":" + sensor.Path
+}
+
+type Sensors struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Rooms             []sensorRoom  `yaml:"rooms"`
+	ChartPeriod       DurationField `yaml:"chart-period"`
+	ChartTitle        string        `yaml:"-"`
+}
+
+func (widget *Sensors) Initialize() error {
+	widget.withTitle("Sensors").withCacheDuration(time.Minute)
+
+	if len(widget.Rooms) == 0 {
+		return errors.New("no rooms specified for sensors widget")
+	}
+
+	if widget.ChartPeriod == 0 {
+		widget.ChartPeriod = DurationField(24 * time.Hour)
+	}
+
+	if err := validateChartPeriod(widget.ChartPeriod); err != nil {
+		return fmt.Errorf("sensors widget: %v", err)
+	}
+
+	widget.ChartTitle = "Over the last " + formatChartPeriod(time.Duration(widget.ChartPeriod))
+
+	for i := range widget.Rooms {
+		room := &widget.Rooms[i]
+
+		if (room.ESPHome == "") == (room.URL == "") {
+			return fmt.Errorf("room %d in sensors widget needs either esphome or url", i+1)
+		}
+
+		if len(room.Sensors) == 0 {
+			return fmt.Errorf("no sensors specified for room %d in sensors widget", i+1)
+		}
+
+		for j := range room.Sensors {
+			sensor := &room.Sensors[j]
+
+			if room.ESPHome != "" && sensor.ID == "" {
+				return fmt.Errorf("sensor %d of room %d in sensors widget needs an id", j+1, i+1)
+			}
+
+			if room.URL != "" && sensor.Path == "" {
+				return fmt.Errorf("sensor %d of room %d in sensors widget needs a path", j+1, i+1)
+			}
+
+			if sensor.Label == "" {
+				sensor.Label = sensor.ID + sensor.Path
+			}
+
+			if err := sensor.Thresholds.validate(); err != nil {
+				return fmt.Errorf("invalid thresholds for sensor %d of room %d in sensors widget: %v", j+1, i+1, err)
+			}
+		}
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("sensors widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Sensors) Update(ctx context.Context) {
+	now := time.Now()
+	var total, failed int
+
+	for i := range widget.Rooms {
+		room := &widget.Rooms[i]
+		keys := make([]string, len(room.Sensors))
+
+		for j := range room.Sensors {
+			keys[j] = room.Sensors[j].ID + room.Sensors[j].Path
+		}
+
+		var values []float64
+		var errs []error
+		var err error
+
+		if room.ESPHome != "" {
+			values, errs, err = feed.FetchESPHomeSensorValues(ctx, widget.client, string(room.ESPHome), keys)
+		} else {
+			values, errs, err = feed.FetchJSONSensorValues(ctx, widget.client, string(room.URL), keys)
+		}
+
+		for j := range room.Sensors {
+			sensor := &room.Sensors[j]
+			total++
+
+			if err == nil {
+				err = errs[j]
+			}
+
+			if err != nil {
+				failed++
+				sensor.Error = err.Error()
+				sensor.Threshold = noThreshold
+				err = nil
+				continue
+			}
+
+			sensor.Error = ""
+			sensor.Value = values[j]
+			sensor.Threshold = sensor.Thresholds.Match(sensor.Value)
+
+			key := room.historyKey(sensor)
+			widget.Providers.History.Record(key, sensor.Value, now)
+
+			if history := widget.Providers.History.Values(key, now.Add(-time.Duration(widget.ChartPeriod))); len(history) >= 2 {
+				sensor.Chart = feed.SvgPolylineCoordsFromYValues(100, 30, history)
+			} else {
+				sensor.Chart = ""
+			}
+		}
+	}
+
+	if failed == total {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not read any sensors", feed.ErrNoContent))
+		return
+	}
+
+	if failed > 0 {
+		widget.canContinueUpdateAfterHandlingErr(fmt.Errorf("%w: could not read %d sensor(s)", feed.ErrPartialContent, failed))
+		return
+	}
+
+	widget.canContinueUpdateAfterHandlingErr(nil)
+}
+
+func (widget *Sensors) Render() template.HTML {
+	return widget.render(widget, assets.SensorsTemplate)
+}
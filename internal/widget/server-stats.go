@@ -0,0 +1,220 @@
+//go:build !slim || widget_server_stats
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("server-stats", func() Widget { return &ServerStats{} })
+}
+
+const serverStatsHistoryLength = 30
+
+type serverStatsServer struct {
+	Type           string            `yaml:"type"`
+	Name           string            `yaml:"name"`
+	URL            string            `yaml:"url"`
+	Token          OptionalEnvString `yaml:"token"`
+	AllowInsecure  bool              `yaml:"allow-insecure"`
+	Mountpoints    []string          `yaml:"mountpoints"`
+	HideSwap       bool              `yaml:"hide-swap"`
+	Stats          *feed.SystemStats `yaml:"-"`
+	CPUChart       string            `yaml:"-"`
+	MemoryChart    string            `yaml:"-"`
+	NetworkChart   string            `yaml:"-"`
+	cpuHistory     []float64         `yaml:"-"`
+	memoryHistory  []float64         `yaml:"-"`
+	networkHistory []float64         `yaml:"-"`
+}
+
+func appendToHistory(history []float64, value float64) []float64 {
+	history = append(history, value)
+
+	if len(history) > serverStatsHistoryLength {
+		history = history[len(history)-serverStatsHistoryLength:]
+	}
+
+	return history
+}
+
+func (s *serverStatsServer) historyKey(metric string) string {
+	if s.URL == "" {
+		return "server-stats:local:" + metric
+	}
+
+	return "server-stats:" + s.URL + ":" + metric
+}
+
+func (widget *ServerStats) recordStats(s *serverStatsServer, stats *feed.SystemStats, now time.Time) {
+	s.Stats = stats
+
+	if stats == nil {
+		return
+	}
+
+	network := stats.Network.ReceiveBytesPerSecond + stats.Network.TransmitBytesPerSecond
+	history := widget.Providers.History
+	history.Record(s.historyKey("cpu"), stats.CPU.LoadPercent, now)
+	history.Record(s.historyKey("memory"), stats.Memory.UsedPercent, now)
+	history.Record(s.historyKey("network"), network, now)
+
+	if widget.ChartPeriod > 0 {
+		since := now.Add(-time.Duration(widget.ChartPeriod))
+		s.cpuHistory = history.Values(s.historyKey("cpu"), since)
+		s.memoryHistory = history.Values(s.historyKey("memory"), since)
+		s.networkHistory = history.Values(s.historyKey("network"), since)
+	} else {
+		s.cpuHistory = appendToHistory(s.cpuHistory, stats.CPU.LoadPercent)
+		s.memoryHistory = appendToHistory(s.memoryHistory, stats.Memory.UsedPercent)
+		s.networkHistory = appendToHistory(s.networkHistory, network)
+	}
+
+	s.CPUChart = ""
+	s.MemoryChart = ""
+	s.NetworkChart = ""
+
+	if len(s.cpuHistory) < 2 {
+		return
+	}
+
+	s.CPUChart = feed.SvgPolylineCoordsFromYValuesInRange(100, 30, s.cpuHistory, 0, 100)
+	s.MemoryChart = feed.SvgPolylineCoordsFromYValuesInRange(100, 30, s.memoryHistory, 0, 100)
+	s.NetworkChart = feed.SvgPolylineCoordsFromYValues(100, 30, s.networkHistory)
+}
+
+func (s *serverStatsServer) Uptime() string {
+	if s.Stats == nil {
+		return ""
+	}
+
+	uptime := time.Duration(s.Stats.UptimeSeconds) * time.Second
+
+	if uptime < time.Hour {
+		return fmt.Sprintf("%dm", uptime/time.Minute)
+	}
+
+	if uptime < 24*time.Hour {
+		return fmt.Sprintf("%dh", uptime/time.Hour)
+	}
+
+	return fmt.Sprintf("%dd", uptime/(24*time.Hour))
+}
+
+var serverStatsThresholdNames = []string{"cpu", "memory", "swap", "disk", "temperature"}
+
+type ServerStats struct {
+	widgetBase  `yaml:",inline"`
+	Servers     []serverStatsServer `yaml:"servers"`
+	Thresholds  namedThresholds     `yaml:"thresholds"`
+	ChartPeriod DurationField       `yaml:"chart-period"`
+	Units       feed.UnitSystem     `yaml:"units"`
+	requests    []*feed.SystemStatsRequest
+}
+
+func (widget *ServerStats) Initialize() error {
+	widget.withTitle("Server Stats").withCacheDuration(15 * time.Second)
+
+	if len(widget.Servers) == 0 {
+		widget.Servers = []serverStatsServer{{Type: "local"}}
+	}
+
+	if err := validateChartPeriod(widget.ChartPeriod); err != nil {
+		return fmt.Errorf("server-stats widget: %v", err)
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("server-stats widget: %v", err)
+	}
+
+	for name := range widget.Thresholds {
+		if !slices.Contains(serverStatsThresholdNames, name) {
+			return fmt.Errorf("invalid thresholds '%s' in server-stats widget, must be one of cpu, memory, swap, disk or temperature", name)
+		}
+	}
+
+	if err := widget.Thresholds.validate(); err != nil {
+		return fmt.Errorf("invalid thresholds in server-stats widget: %v", err)
+	}
+
+	if _, exists := widget.Thresholds["disk"]; !exists {
+		if widget.Thresholds == nil {
+			widget.Thresholds = make(namedThresholds)
+		}
+
+		fullDisk := 90.0
+		widget.Thresholds["disk"] = thresholds{{Above: &fullDisk, Color: "negative"}}
+	}
+
+	widget.requests = make([]*feed.SystemStatsRequest, len(widget.Servers))
+
+	for i := range widget.Servers {
+		server := &widget.Servers[i]
+
+		if server.Type == "" {
+			server.Type = "local"
+		}
+
+		switch server.Type {
+		case "local":
+		case "remote":
+			if server.URL == "" {
+				return fmt.Errorf("missing url for remote server in server-stats widget")
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for server in server-stats widget, must be either local or remote", server.Type)
+		}
+
+		widget.requests[i] = &feed.SystemStatsRequest{
+			URL:           server.URL,
+			Token:         server.Token.String(),
+			AllowInsecure: server.AllowInsecure,
+			Mountpoints:   server.Mountpoints,
+		}
+	}
+
+	return nil
+}
+
+func (widget *ServerStats) Update(ctx context.Context) {
+	stats, err := feed.FetchSystemStatsForServers(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	now := time.Now()
+
+	for i := range widget.Servers {
+		widget.recordStats(&widget.Servers[i], stats[i], now)
+	}
+}
+
+func (widget *ServerStats) Render() template.HTML {
+	return widget.render(widget, assets.ServerStatsTemplate)
+}
+
+func (widget *ServerStats) sharedData() any {
+	servers := make([]map[string]any, 0, len(widget.Servers))
+
+	for i := range widget.Servers {
+		server := &widget.Servers[i]
+
+		servers = append(servers, map[string]any{
+			"name":  server.Name,
+			"url":   server.URL,
+			"ok":    server.Stats != nil,
+			"stats": server.Stats,
+		})
+	}
+
+	return servers
+}
@@ -0,0 +1,79 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// Settings from the top level of the config which widgets that don't specify their own fall back to
+type Settings struct {
+	Clients    *feed.GlobalClients
+	Units      feed.UnitSystem
+	Currency   string
+	QuietHours TimeWindow
+}
+
+func NewSettings(clients *feed.GlobalClients, units feed.UnitSystem, currency string, quietHours TimeWindow) (*Settings, error) {
+	if units == "" {
+		units = feed.MetricUnits
+	} else if !units.IsValid() {
+		return nil, fmt.Errorf("invalid units '%s', must be either metric or imperial", units)
+	}
+
+	currency = strings.ToUpper(currency)
+
+	if currency != "" && !isValidCurrencyCode(currency) {
+		return nil, fmt.Errorf("invalid currency '%s', must be a three letter ISO 4217 code such as USD", currency)
+	}
+
+	return &Settings{
+		Clients:    clients,
+		Units:      units,
+		Currency:   currency,
+		QuietHours: quietHours,
+	}, nil
+}
+
+// The settings of the running widgets, replaced as a whole once a reloaded config has been
+// fully initialized, so that an invalid config doesn't leave any of its settings in place
+var appliedSettings atomic.Pointer[Settings]
+
+func init() {
+	appliedSettings.Store(&Settings{Clients: feed.CurrentGlobalClients(), Units: feed.MetricUnits})
+}
+
+func ApplySettings(settings *Settings) {
+	appliedSettings.Store(settings)
+}
+
+// The settings of the config whose widgets are currently being initialized, which
+// aren't the applied ones yet while a reloaded config is still being checked
+var initializing struct {
+	mu       sync.Mutex
+	settings atomic.Pointer[Settings]
+}
+
+// Runs initialize, which is expected to initialize the widgets of a config, with the settings that
+// the widgets fall back to. The widgets of only one config at a time can be getting initialized
+func InitializeWithSettings(settings *Settings, initialize func() error) error {
+	initializing.mu.Lock()
+	defer initializing.mu.Unlock()
+
+	initializing.settings.Store(settings)
+	defer initializing.settings.Store(nil)
+
+	return initialize()
+}
+
+// Only used while initializing widgets, the running ones use the applied settings
+func initializationSettings() *Settings {
+	if settings := initializing.settings.Load(); settings != nil {
+		return settings
+	}
+
+	return appliedSettings.Load()
+}
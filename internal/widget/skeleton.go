@@ -0,0 +1,42 @@
+package widget
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+// Renders the widget unless it's currently being updated or it hasn't been updated yet,
+// in which case there's nothing to show and rendering it would race with the update
+func TryRender(widget Widget, now *time.Time, render func(Widget) template.HTML) (template.HTML, bool) {
+	mutex := widget.(interface{ updateMutex() *sync.Mutex }).updateMutex()
+
+	if !mutex.TryLock() {
+		return "", false
+	}
+
+	defer mutex.Unlock()
+
+	if UpdateGeneration(widget) == 0 && widget.RequiresUpdate(now) {
+		return "", false
+	}
+
+	return render(widget), true
+}
+
+// A placeholder shown in place of the widget until it's ready, only
+// uses the fields from the config so it's safe to render at any time
+func RenderSkeleton(widget Widget) template.HTML {
+	var buffer bytes.Buffer
+
+	if err := assets.WidgetSkeletonTemplate.Execute(&buffer, widget); err != nil {
+		slog.Error("Failed to render widget skeleton", "error", err)
+		return ""
+	}
+
+	return template.HTML(buffer.String())
+}
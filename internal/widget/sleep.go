@@ -0,0 +1,91 @@
+//go:build !slim || widget_sleep
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("sleep", func() Widget { return &Sleep{} })
+}
+
+type Sleep struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string               `yaml:"service"`
+	Token                  OptionalEnvString    `yaml:"token"`
+	ClientID               OptionalEnvString    `yaml:"client-id"`
+	ClientSecret           OptionalEnvString    `yaml:"client-secret"`
+	RefreshToken           OptionalEnvString    `yaml:"refresh-token"`
+	Summary                *feed.Sleep          `yaml:"-"`
+	withings               *feed.WithingsClient `yaml:"-"`
+}
+
+func (widget *Sleep) Initialize() error {
+	widget.withTitle("Sleep").withCacheDuration(time.Hour)
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("sleep widget: %v", err)
+	}
+
+	switch widget.Service {
+	case "oura":
+		if widget.Token == "" {
+			return errors.New("token must be specified for sleep widget when using oura")
+		}
+
+		widget.withTitleURL("https://cloud.ouraring.com/")
+	case "withings":
+		if widget.ClientID == "" || widget.ClientSecret == "" || widget.RefreshToken == "" {
+			return errors.New("client-id, client-secret and refresh-token must be specified for sleep widget when using withings")
+		}
+
+		widget.withTitleURL("https://healthmate.withings.com/")
+		widget.withings = &feed.WithingsClient{
+			ClientID:             widget.ClientID.String(),
+			ClientSecret:         widget.ClientSecret.String(),
+			RefreshToken:         widget.RefreshToken.String(),
+			OnRefreshTokenChange: widget.storeRefreshToken,
+			Client:               widget.client,
+		}
+
+		widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.withings.RefreshToken)
+	default:
+		return errors.New("sleep service must be either 'oura' or 'withings'")
+	}
+
+	return nil
+}
+
+func (widget *Sleep) storageKey() string {
+	return "sleep:withings:" + widget.ClientID.String()
+}
+
+func (widget *Sleep) Update(ctx context.Context) {
+	var sleep *feed.Sleep
+	var err error
+
+	if widget.withings != nil {
+		sleep, err = widget.withings.FetchSleep(ctx)
+	} else {
+		sleep, err = feed.FetchSleepFromOura(ctx, widget.client, widget.Token.String())
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Summary = sleep
+}
+
+func (widget *Sleep) Render() template.HTML {
+	return widget.render(widget, assets.SleepTemplate)
+}
@@ -0,0 +1,160 @@
+//go:build !slim || widget_solar
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("solar", func() Widget { return &Solar{} })
+}
+
+type solarTotals struct {
+	Produced float64
+	Consumed float64
+	Imported float64
+	Exported float64
+}
+
+type Solar struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Service           string            `yaml:"service"`
+	URL               string            `yaml:"url"`
+	SiteID            string            `yaml:"site-id"`
+	APIKey            OptionalEnvString `yaml:"api-key"`
+	Status            *feed.SolarStatus `yaml:"-"`
+	// in watt-hours, since midnight
+	Today       solarTotals `yaml:"-"`
+	Chart       string      `yaml:"-"`
+	ChartPeak   float64     `yaml:"-"`
+	request     *feed.SolarRequest
+	historyBase string
+}
+
+func (widget *Solar) Initialize() error {
+	switch widget.Service {
+	case feed.SolarServiceEVCC, feed.SolarServiceFronius:
+		if widget.URL == "" {
+			return fmt.Errorf("url must be specified for %s in solar widget", widget.Service)
+		}
+
+		widget.withTitleURL(widget.URL).withCacheDuration(time.Minute)
+		widget.historyBase = "solar:" + widget.Service + ":" + widget.URL
+	case feed.SolarServiceSolarEdge:
+		if widget.SiteID == "" || widget.APIKey == "" {
+			return errors.New("site-id and api-key must be specified for solaredge in solar widget")
+		}
+
+		// SolarEdge only allows 300 requests a day and each update makes two
+		widget.withTitleURL("https://monitoring.solaredge.com/").withCacheDuration(15 * time.Minute)
+		widget.historyBase = "solar:solaredge:" + widget.SiteID
+	case "sma":
+		return errors.New("sma inverters don't offer an HTTP API, use their integration in evcc instead")
+	default:
+		return errors.New("service for solar widget must be one of evcc, fronius or solaredge")
+	}
+
+	widget.withTitle("Solar")
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("solar widget: %v", err)
+	}
+
+	widget.request = &feed.SolarRequest{
+		Service: widget.Service,
+		URL:     widget.URL,
+		SiteID:  widget.SiteID,
+		APIKey:  widget.APIKey.String(),
+	}
+
+	return nil
+}
+
+func (widget *Solar) Update(ctx context.Context) {
+	status, err := feed.FetchSolarStatus(ctx, widget.client, widget.request)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	now := time.Now()
+	history := widget.Providers.History
+	history.Record(widget.historyBase+":production", status.Production, now)
+	history.Record(widget.historyBase+":consumption", status.Consumption, now)
+	history.Record(widget.historyBase+":import", max(status.Grid, 0), now)
+	history.Record(widget.historyBase+":export", max(-status.Grid, 0), now)
+
+	year, month, day := now.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	production := history.Values(widget.historyBase+":production", midnight)
+
+	widget.Today = solarTotals{
+		Produced: widget.energySince(production),
+		Consumed: widget.energySince(history.Values(widget.historyBase+":consumption", midnight)),
+		Imported: widget.energySince(history.Values(widget.historyBase+":import", midnight)),
+		Exported: widget.energySince(history.Values(widget.historyBase+":export", midnight)),
+	}
+
+	if status.ProducedToday >= 0 {
+		widget.Today.Produced = status.ProducedToday
+	}
+
+	widget.Chart = ""
+	widget.ChartPeak = 0
+
+	if len(production) >= 2 {
+		widget.ChartPeak = slices.Max(production)
+		widget.Chart = feed.SvgPolylineCoordsFromYValuesInRange(100, 30, production, 0, widget.ChartPeak)
+	}
+
+	widget.Status = status
+}
+
+// Estimates the energy from the average power of each point in the history, which covers
+// either the duration of a bucket or the time between updates, whichever is longer
+func (widget *Solar) energySince(values []float64) float64 {
+	var sum float64
+
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum * max(historyBucketDuration, widget.cacheDuration).Hours()
+}
+
+func (widget *Solar) Render() template.HTML {
+	return widget.render(widget, assets.SolarTemplate)
+}
+
+func (widget *Solar) FormatPower(watts float64) string {
+	watts = math.Abs(watts)
+
+	if watts >= 1000 {
+		return fmt.Sprintf("%.1f kW", watts/1000)
+	}
+
+	return fmt.Sprintf("%.0f W", watts)
+}
+
+func (widget *Solar) FormatEnergy(wattHours float64) string {
+	if wattHours >= 1000 {
+		return fmt.Sprintf("%.1f kWh", wattHours/1000)
+	}
+
+	return fmt.Sprintf("%.0f Wh", wattHours)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_split_column
+
 package widget
 
 import (
@@ -8,6 +10,10 @@ import (
 	"github.com/glanceapp/glance/internal/assets"
 )
 
+func init() {
+	register("split-column", func() Widget { return &SplitColumn{} })
+}
+
 type SplitColumn struct {
 	widgetBase          `yaml:",inline"`
 	containerWidgetBase `yaml:",inline"`
@@ -18,7 +24,7 @@ func (widget *SplitColumn) Initialize() error {
 	widget.withError(nil).withTitle("Split Column").SetHideHeader(true)
 
 	for i := range widget.Widgets {
-		if err := widget.Widgets[i].Initialize(); err != nil {
+		if err := Initialize(widget.Widgets[i]); err != nil {
 			return err
 		}
 	}
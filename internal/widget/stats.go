@@ -0,0 +1,116 @@
+package widget
+
+import (
+	"sync"
+	"time"
+)
+
+// Tracked for every widget and exposed through the metrics and health endpoints
+type UpdateStats struct {
+	Updates             uint64
+	Failures            uint64
+	ConsecutiveFailures int
+	// zero if the last update succeeded
+	FailingSince       time.Time
+	LastSuccessAt      time.Time
+	LastUpdateAt       time.Time
+	LastUpdateDuration time.Duration
+	// the sum of the durations of all updates
+	UpdateDuration time.Duration
+	// the number of times the widget was checked and didn't need updating
+	CacheHits uint64
+	// the number of times the widget was checked and had to be updated
+	CacheMisses uint64
+}
+
+type updateStats struct {
+	mu    sync.Mutex
+	stats UpdateStats
+}
+
+func (s *updateStats) recordCacheHit() {
+	s.mu.Lock()
+	s.stats.CacheHits++
+	s.mu.Unlock()
+}
+
+func (s *updateStats) recordUpdate(duration time.Duration, failed bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Updates++
+	s.stats.CacheMisses++
+	s.stats.LastUpdateAt = now
+	s.stats.LastUpdateDuration = duration
+	s.stats.UpdateDuration += duration
+
+	if !failed {
+		s.stats.ConsecutiveFailures = 0
+		s.stats.FailingSince = time.Time{}
+		s.stats.LastSuccessAt = now
+		return
+	}
+
+	s.stats.Failures++
+	s.stats.ConsecutiveFailures++
+
+	if s.stats.FailingSince.IsZero() {
+		s.stats.FailingSince = now
+	}
+}
+
+type updateStatsTracker interface {
+	updateStats() *updateStats
+	lastUpdateFailed() bool
+}
+
+func (w *widgetBase) updateStats() *updateStats {
+	return &w.stats
+}
+
+func (w *widgetBase) lastUpdateFailed() bool {
+	return w.Error != nil || w.IsStale()
+}
+
+// Returns false for widgets which contain other widgets and for
+// widgets which never get updated, such as the clock widget
+func GetUpdateStats(widget Widget) (UpdateStats, bool) {
+	if _, ok := widget.(interface{ children() Widgets }); ok {
+		return UpdateStats{}, false
+	}
+
+	if base, ok := widget.(interface{ cachesForever() bool }); ok && base.cachesForever() {
+		return UpdateStats{}, false
+	}
+
+	stats := widget.(updateStatsTracker).updateStats()
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	return stats.stats, true
+}
+
+// Records that the cached data of the widget and of any widget nested
+// inside of it was used since none of them required updating
+func RecordCacheHit(widget Widget) {
+	if container, ok := widget.(interface{ children() Widgets }); ok {
+		for _, child := range container.children() {
+			RecordCacheHit(child)
+		}
+
+		return
+	}
+
+	widget.(updateStatsTracker).updateStats().recordCacheHit()
+}
+
+// Calls fn for every widget, including the ones nested inside of other widgets
+func WalkWidgets(widgets Widgets, fn func(Widget)) {
+	for _, widget := range widgets {
+		fn(widget)
+
+		if container, ok := widget.(interface{ children() Widgets }); ok {
+			WalkWidgets(container.children(), fn)
+		}
+	}
+}
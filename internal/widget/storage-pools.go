@@ -0,0 +1,86 @@
+//go:build !slim || widget_storage_pools
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("storage-pools", func() Widget { return &StoragePools{} })
+}
+
+type storagePoolsSource struct {
+	Type          string             `yaml:"type"`
+	Name          string             `yaml:"name"`
+	URL           string             `yaml:"url"`
+	Token         OptionalEnvString  `yaml:"token"`
+	AllowInsecure bool               `yaml:"allow-insecure"`
+	Pools         []feed.StoragePool `yaml:"-"`
+	Unreachable   bool               `yaml:"-"`
+}
+
+type StoragePools struct {
+	widgetBase `yaml:",inline"`
+	Sources    []storagePoolsSource `yaml:"sources"`
+	requests   []*feed.StoragePoolsRequest
+}
+
+func (widget *StoragePools) Initialize() error {
+	widget.withTitle("Storage Pools").withCacheDuration(time.Minute)
+
+	if len(widget.Sources) == 0 {
+		widget.Sources = []storagePoolsSource{{Type: "local"}}
+	}
+
+	widget.requests = make([]*feed.StoragePoolsRequest, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.Type == "" {
+			source.Type = "local"
+		}
+
+		switch source.Type {
+		case "local":
+		case "remote":
+			if source.URL == "" {
+				return fmt.Errorf("missing url for remote source in storage-pools widget")
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in storage-pools widget, must be either local or remote", source.Type)
+		}
+
+		widget.requests[i] = &feed.StoragePoolsRequest{
+			URL:           source.URL,
+			Token:         source.Token.String(),
+			AllowInsecure: source.AllowInsecure,
+		}
+	}
+
+	return nil
+}
+
+func (widget *StoragePools) Update(ctx context.Context) {
+	pools, err := feed.FetchStoragePoolsForSources(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range widget.Sources {
+		widget.Sources[i].Pools = pools[i]
+		widget.Sources[i].Unreachable = pools[i] == nil
+	}
+}
+
+func (widget *StoragePools) Render() template.HTML {
+	return widget.render(widget, assets.StoragePoolsTemplate)
+}
@@ -0,0 +1,175 @@
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const storageFileName = "widgets.json"
+
+// Stores the state of widgets which gets changed from the dashboard, such as the
+// items of to-do lists. Unlike the history, changes are made by hand and are rare,
+// so they get written to disk right away. If no path is given, the state is only
+// kept in memory.
+type Storage struct {
+	mu     sync.Mutex
+	path   string
+	values map[string]json.RawMessage
+}
+
+func NewStorage(directory string) (*Storage, error) {
+	storage := &Storage{
+		values: make(map[string]json.RawMessage),
+	}
+
+	if directory == "" {
+		return storage, nil
+	}
+
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("creating data directory: %v", err)
+	}
+
+	storage.path = filepath.Join(directory, storageFileName)
+	contents, err := os.ReadFile(storage.path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return storage, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("loading widget storage from %s: %v", storage.path, err)
+	}
+
+	if err := json.Unmarshal(contents, &storage.values); err != nil {
+		return nil, fmt.Errorf("loading widget storage from %s: %v", storage.path, err)
+	}
+
+	return storage, nil
+}
+
+// Leaves value untouched if nothing is stored under the key
+func (s *Storage) get(key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.decode(key, value)
+}
+
+// Decodes the value stored under the key into value and stores it again once modify
+// returns without an error. Nothing else can read or change the storage in between,
+// which lets the change depend on the current value, e.g. by checking its version
+func (s *Storage) update(key string, value any, modify func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.decode(key, value); err != nil {
+		return err
+	}
+
+	if err := modify(); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	previous, existed := s.values[key]
+	s.values[key] = encoded
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.values[key] = previous
+		} else {
+			delete(s.values, key)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.values[key]
+
+	if !existed {
+		return nil
+	}
+
+	delete(s.values, key)
+
+	if err := s.save(); err != nil {
+		s.values[key] = previous
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) decode(key string, value any) error {
+	encoded, exists := s.values[key]
+
+	if !exists {
+		return nil
+	}
+
+	return json.Unmarshal(encoded, value)
+}
+
+func (s *Storage) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	contents, err := json.Marshal(s.values)
+
+	if err != nil {
+		return err
+	}
+
+	// written to a temporary file first so that a crash
+	// while saving doesn't leave behind a corrupted file
+	temporaryPath := s.path + ".tmp"
+
+	if err := os.WriteFile(temporaryPath, contents, 0o644); err != nil {
+		return fmt.Errorf("saving widget storage: %v", err)
+	}
+
+	if err := os.Rename(temporaryPath, s.path); err != nil {
+		return fmt.Errorf("saving widget storage: %v", err)
+	}
+
+	return nil
+}
+
+// Used by widgets which get changed from the dashboard. Requiring a JSON content type means
+// browsers won't send the request from other sites without asking first, unlike with form submissions
+func decodeJSONRequest(w http.ResponseWriter, r *http.Request, value any, maxSize int64) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "application/json" {
+		return errors.New("content type must be application/json")
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package widget
+
+import (
+	"html/template"
+	"slices"
+)
+
+// Implemented by widgets which support multiple styles, returns
+// a pointer to the style of the widget along with the valid styles
+type styledWidget interface {
+	styleField() (*string, []string)
+}
+
+// Renders the widget using a different style without changing its config, the
+// widget is rendered as usual if it doesn't support the style. Must not be
+// called concurrently with other renders of the same widget
+func RenderWithStyle(widget Widget, style string) template.HTML {
+	styled, ok := widget.(styledWidget)
+
+	if !ok {
+		return widget.Render()
+	}
+
+	current, styles := styled.styleField()
+
+	if !slices.Contains(styles, style) {
+		return widget.Render()
+	}
+
+	original := *current
+	*current = style
+	defer func() { *current = original }()
+
+	return widget.Render()
+}
@@ -0,0 +1,61 @@
+//go:build !slim || widget_syncthing
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("syncthing", func() Widget { return &Syncthing{} })
+}
+
+type Syncthing struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	URL               URLField          `yaml:"url"`
+	APIKey            OptionalEnvString `yaml:"api-key"`
+	HideDevices       bool              `yaml:"hide-devices"`
+	Status            *feed.Syncthing   `yaml:"-"`
+}
+
+func (widget *Syncthing) Initialize() error {
+	widget.withTitle("Syncthing").withCacheDuration(time.Minute)
+
+	if widget.URL == "" {
+		widget.URL = "http://localhost:8384"
+	}
+
+	widget.withTitleURL(string(widget.URL))
+
+	if widget.APIKey == "" {
+		return errors.New("api-key must be specified for syncthing widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("syncthing widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Syncthing) Update(ctx context.Context) {
+	status, err := feed.FetchSyncthingStatus(ctx, widget.client, string(widget.URL), widget.APIKey.String())
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Status = status
+}
+
+func (widget *Syncthing) Render() template.HTML {
+	return widget.render(widget, assets.SyncthingTemplate)
+}
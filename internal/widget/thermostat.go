@@ -0,0 +1,214 @@
+//go:build !slim || widget_thermostat
+
+package widget
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+const thermostatMaxRequestSize = 1024
+
+func init() {
+	register("thermostat", func() Widget { return &Thermostat{} })
+}
+
+type Thermostat struct {
+	refreshTokenWidgetBase `yaml:",inline"`
+	httpClientOptions      `yaml:",inline"`
+	Service                string                `yaml:"service"`
+	URL                    URLField              `yaml:"url"`
+	Token                  OptionalEnvString     `yaml:"token"`
+	Entities               []string              `yaml:"entities"`
+	ProjectID              OptionalEnvString     `yaml:"project-id"`
+	ClientID               OptionalEnvString     `yaml:"client-id"`
+	ClientSecret           OptionalEnvString     `yaml:"client-secret"`
+	RefreshToken           OptionalEnvString     `yaml:"refresh-token"`
+	AllowActions           bool                  `yaml:"allow-actions"`
+	Zones                  []feed.ThermostatZone `yaml:"-"`
+	zonesMu                sync.Mutex
+	thermostat             *feed.ThermostatClient
+}
+
+func (widget *Thermostat) Initialize() error {
+	widget.withTitle("Thermostat").withCacheDuration(5 * time.Minute)
+
+	switch widget.Service {
+	case feed.ThermostatServiceHomeAssistant:
+		if widget.URL == "" || widget.Token == "" {
+			return errors.New("url and token must be specified for homeassistant in thermostat widget")
+		}
+
+		widget.withTitleURL(string(widget.URL))
+	case feed.ThermostatServiceTado:
+		if widget.RefreshToken == "" {
+			return errors.New("refresh-token must be specified for tado in thermostat widget")
+		}
+
+		widget.withTitleURL("https://app.tado.com/")
+	case feed.ThermostatServiceNest:
+		if widget.ProjectID == "" || widget.ClientID == "" || widget.ClientSecret == "" || widget.RefreshToken == "" {
+			return errors.New("project-id, client-id, client-secret and refresh-token must be specified for nest in thermostat widget")
+		}
+
+		widget.withTitleURL("https://home.nest.com/")
+	default:
+		return errors.New("service for thermostat widget must be one of homeassistant, tado or nest")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("thermostat widget: %v", err)
+	}
+
+	widget.thermostat = &feed.ThermostatClient{
+		Service:      widget.Service,
+		Client:       widget.client,
+		URL:          string(widget.URL),
+		Token:        widget.Token.String(),
+		Entities:     widget.Entities,
+		ProjectID:    widget.ProjectID.String(),
+		ClientID:     widget.ClientID.String(),
+		ClientSecret: widget.ClientSecret.String(),
+		RefreshToken: widget.RefreshToken.String(),
+	}
+
+	// Google's refresh tokens don't change, so there's nothing to keep for Nest
+	if widget.Service == feed.ThermostatServiceTado {
+		widget.thermostat.OnRefreshTokenChange = widget.storeRefreshToken
+		widget.keepRefreshToken(widget.storageKey(), widget.RefreshToken.String(), &widget.thermostat.RefreshToken)
+	}
+
+	return nil
+}
+
+// Tado always uses the same client, so accounts are told apart by the token from the config
+func (widget *Thermostat) storageKey() string {
+	hash := sha256.Sum256([]byte(widget.RefreshToken.String()))
+	return "thermostat:tado:" + hex.EncodeToString(hash[:8])
+}
+
+func (widget *Thermostat) Update(ctx context.Context) {
+	zones, err := widget.thermostat.FetchZones(ctx)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.zonesMu.Lock()
+	widget.Zones = zones
+	widget.zonesMu.Unlock()
+}
+
+func (widget *Thermostat) Render() template.HTML {
+	return widget.render(widget, assets.ThermostatTemplate)
+}
+
+func (widget *Thermostat) CanAdjust(zone feed.ThermostatZone) bool {
+	if !widget.AllowActions || !zone.HasTarget() {
+		return false
+	}
+
+	// Tado needs more than a temperature for air conditioners
+	return widget.Service != feed.ThermostatServiceTado || zone.Mode == "heat"
+}
+
+type thermostatRequest struct {
+	Zone      string `json:"zone"`
+	Direction string `json:"direction"`
+}
+
+// POST /target raises or lowers the target temperature of the zone in the body by
+// one step, only available with allow-actions
+func (widget *Thermostat) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if !widget.AllowActions || r.PathValue("path") != "target" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request thermostatRequest
+
+	if err := decodeJSONRequest(w, r, &request, thermostatMaxRequestSize); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Direction != "up" && request.Direction != "down" {
+		http.Error(w, "direction must be either up or down", http.StatusBadRequest)
+		return
+	}
+
+	var zone *feed.ThermostatZone
+
+	widget.zonesMu.Lock()
+
+	for i := range widget.Zones {
+		if widget.Zones[i].ID == request.Zone {
+			copied := widget.Zones[i]
+			zone = &copied
+			break
+		}
+	}
+
+	widget.zonesMu.Unlock()
+
+	if zone == nil {
+		http.Error(w, "unknown zone", http.StatusBadRequest)
+		return
+	}
+
+	if !widget.CanAdjust(*zone) {
+		http.Error(w, feed.ErrThermostatNotAdjustable.Error(), http.StatusConflict)
+		return
+	}
+
+	target := zone.Target + zone.Step
+
+	if request.Direction == "down" {
+		target = zone.Target - zone.Step
+	}
+
+	// rounded to the step so that repeated changes don't drift
+	target = math.Round(target/zone.Step) * zone.Step
+
+	if zone.Max > zone.Min {
+		target = min(max(target, zone.Min), zone.Max)
+	}
+
+	err := widget.thermostat.SetTarget(r.Context(), zone, target)
+
+	if errors.Is(err, feed.ErrThermostatNotAdjustable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to change target temperature", "widget", widget.GetSlug(), "zone", zone.Name, "error", err)
+		http.Error(w, "could not change the target temperature", http.StatusBadGateway)
+		return
+	}
+
+	ExpireCache(widget)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package widget
+
+import (
+	"fmt"
+	"slices"
+)
+
+var thresholdColors = []string{"positive", "negative", "primary", "highlight", "subdue"}
+
+// A range of values which changes how a value is displayed, the bounds are exclusive
+type threshold struct {
+	Above *float64   `yaml:"above"`
+	Below *float64   `yaml:"below"`
+	Color string     `yaml:"color"`
+	Icon  CustomIcon `yaml:"icon"`
+}
+
+func (t *threshold) matches(value float64) bool {
+	return (t.Above == nil || value > *t.Above) && (t.Below == nil || value < *t.Below)
+}
+
+func (t *threshold) ColorClass() string {
+	if t.Color == "" {
+		return ""
+	}
+
+	return "color-" + t.Color
+}
+
+type thresholds []threshold
+
+func (t thresholds) validate() error {
+	for i := range t {
+		if t[i].Above == nil && t[i].Below == nil {
+			return fmt.Errorf("threshold %d needs above or below", i+1)
+		}
+
+		if t[i].Color == "" && t[i].Icon.URL == "" {
+			return fmt.Errorf("threshold %d needs a color or an icon", i+1)
+		}
+
+		if t[i].Color != "" && !slices.Contains(thresholdColors, t[i].Color) {
+			return fmt.Errorf("invalid color '%s' for threshold %d, must be one of positive, negative, primary, highlight or subdue", t[i].Color, i+1)
+		}
+	}
+
+	return nil
+}
+
+var noThreshold = &threshold{}
+
+// Returns the first threshold that matches the value, never returns nil
+// so that the result can be used in templates without checking it first
+func (t thresholds) Match(value float64) *threshold {
+	for i := range t {
+		if t[i].matches(value) {
+			return &t[i]
+		}
+	}
+
+	return noThreshold
+}
+
+type namedThresholds map[string]thresholds
+
+func (n namedThresholds) validate() error {
+	for name, t := range n {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (n namedThresholds) Match(name string, value float64) *threshold {
+	return n[name].Match(value)
+}
+
+// Used by templates written in the config, where referencing thresholds
+// that don't exist is more likely to be a typo than intentional
+func (n namedThresholds) matchOrErr(name string, value float64) (*threshold, error) {
+	t, exists := n[name]
+
+	if !exists {
+		return nil, fmt.Errorf("no thresholds named %s", name)
+	}
+
+	return t.Match(value), nil
+}
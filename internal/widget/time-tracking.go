@@ -0,0 +1,121 @@
+//go:build !slim || widget_time_tracking
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("time-tracking", func() Widget { return &TimeTracking{} })
+}
+
+const timeTrackingMaxRequestSize = 4 << 10
+
+type TimeTracking struct {
+	widgetBase   `yaml:",inline"`
+	Service      string                   `yaml:"service"`
+	Token        OptionalEnvString        `yaml:"token"`
+	WorkspaceID  OptionalEnvString        `yaml:"workspace-id"`
+	AllowActions bool                     `yaml:"allow-actions"`
+	Summary      *feed.TimeTracking       `yaml:"-"`
+	client       *feed.TimeTrackingClient `yaml:"-"`
+}
+
+func (widget *TimeTracking) Initialize() error {
+	widget.withTitle("Time Tracking").withCacheDuration(5 * time.Minute)
+
+	switch widget.Service {
+	case "toggl":
+		widget.withTitleURL("https://track.toggl.com/timer")
+	case "clockify":
+		widget.withTitleURL("https://app.clockify.me/tracker")
+	default:
+		return errors.New("time tracking service must be either 'toggl' or 'clockify'")
+	}
+
+	if widget.Token == "" {
+		return errors.New("token must be specified for time-tracking widget")
+	}
+
+	widget.client = &feed.TimeTrackingClient{
+		Service:     widget.Service,
+		Token:       widget.Token.String(),
+		WorkspaceID: widget.WorkspaceID.String(),
+	}
+
+	return nil
+}
+
+func (widget *TimeTracking) Update(ctx context.Context) {
+	summary, err := widget.client.FetchSummary(ctx)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Summary = summary
+}
+
+func (widget *TimeTracking) Render() template.HTML {
+	return widget.render(widget, assets.TimeTrackingTemplate)
+}
+
+type timeTrackingStartRequest struct {
+	Description string `json:"description"`
+}
+
+// POST /start starts a timer with the description from the body and POST /stop stops
+// the one that's running, both of which are only available with allow-actions
+func (widget *TimeTracking) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+
+	if !widget.AllowActions || (path != "start" && path != "stop") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+
+	if path == "start" {
+		var request timeTrackingStartRequest
+
+		if err := decodeJSONRequest(w, r, &request, timeTrackingMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = widget.client.StartTimer(r.Context(), strings.TrimSpace(request.Description))
+	} else {
+		err = widget.client.StopTimer(r.Context())
+	}
+
+	if errors.Is(err, feed.ErrNoRunningTimer) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to change timer", "widget", widget.GetSlug(), "action", path, "error", err)
+		http.Error(w, "could not "+path+" the timer", http.StatusBadGateway)
+		return
+	}
+
+	ExpireCache(widget)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,166 @@
+//go:build !slim || widget_timetable
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("timetable", func() Widget { return &Timetable{} })
+}
+
+type timetableDay struct {
+	Label   string
+	Lessons []feed.Lesson
+}
+
+type Timetable struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Source            string            `yaml:"source"`
+	URL               OptionalEnvString `yaml:"url"`
+	Server            string            `yaml:"server"`
+	School            string            `yaml:"school"`
+	Username          OptionalEnvString `yaml:"username"`
+	Password          OptionalEnvString `yaml:"password"`
+	Class             string            `yaml:"class"`
+	Timezone          string            `yaml:"timezone"`
+	HourFormat        string            `yaml:"hour-format"`
+	Days              []timetableDay    `yaml:"-"`
+	location          *time.Location
+}
+
+func (widget *Timetable) Initialize() error {
+	widget.withTitle("Timetable").withCacheDuration(15 * time.Minute)
+
+	switch widget.Source {
+	case "webuntis":
+		if widget.Server == "" || widget.School == "" || widget.Username == "" || widget.Password == "" {
+			return errors.New("server, school, username and password must be specified for webuntis in timetable widget")
+		}
+
+		widget.withTitleURL("https://" + widget.Server + "/WebUntis/")
+	case "ics":
+		if widget.URL == "" {
+			return errors.New("url must be specified for ics in timetable widget")
+		}
+	default:
+		return errors.New("source for timetable widget must be either webuntis or ics")
+	}
+
+	if widget.HourFormat == "" {
+		widget.HourFormat = "24h"
+	} else if widget.HourFormat != "12h" && widget.HourFormat != "24h" {
+		return fmt.Errorf("invalid hour format '%s' for timetable widget, must be either 12h or 24h", widget.HourFormat)
+	}
+
+	widget.location = time.Local
+
+	if widget.Timezone != "" {
+		location, err := time.LoadLocation(widget.Timezone)
+
+		if err != nil {
+			return fmt.Errorf("invalid timezone '%s' for timetable widget: %v", widget.Timezone, err)
+		}
+
+		widget.location = location
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("timetable widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Timetable) Update(ctx context.Context) {
+	now := time.Now().In(widget.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, widget.location)
+	// far enough to get past weekends and short breaks to the next school day
+	until := today.AddDate(0, 0, 7)
+
+	var lessons []feed.Lesson
+	var err error
+
+	if widget.Source == "webuntis" {
+		lessons, err = feed.FetchWebUntisTimetable(ctx, &feed.WebUntisRequest{
+			Server:   widget.Server,
+			School:   widget.School,
+			Username: widget.Username.String(),
+			Password: widget.Password.String(),
+			Class:    widget.Class,
+			From:     today,
+			To:       until,
+			Location: widget.location,
+			Client:   widget.client,
+		})
+	} else {
+		lessons, err = feed.FetchICSTimetable(ctx, widget.client, widget.URL.String(), today, until, widget.location)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Days = groupTimetableDays(lessons, today)
+}
+
+// Today, followed by the next day that has lessons
+func groupTimetableDays(lessons []feed.Lesson, today time.Time) []timetableDay {
+	days := []timetableDay{{Label: "Today"}}
+	tomorrow := today.AddDate(0, 0, 1)
+	var next time.Time
+
+	for _, lesson := range lessons {
+		day := time.Date(lesson.StartsAt.Year(), lesson.StartsAt.Month(), lesson.StartsAt.Day(), 0, 0, 0, 0, today.Location())
+
+		if day.Equal(today) {
+			days[0].Lessons = append(days[0].Lessons, lesson)
+			continue
+		}
+
+		if next.IsZero() {
+			next = day
+			label := day.Format("Monday")
+
+			if day.Equal(tomorrow) {
+				label = "Tomorrow"
+			}
+
+			days = append(days, timetableDay{Label: label})
+		}
+
+		if day.Equal(next) {
+			days[1].Lessons = append(days[1].Lessons, lesson)
+		}
+	}
+
+	return days
+}
+
+func (widget *Timetable) TimeLabel(t time.Time) string {
+	if widget.HourFormat == "12h" {
+		return t.Format("3:04pm")
+	}
+
+	return t.Format("15:04")
+}
+
+// Whether the lesson is going on right now, which is worked out when rendering so
+// that it stays accurate in between updates
+func (widget *Timetable) IsCurrent(lesson feed.Lesson) bool {
+	now := time.Now()
+	return !lesson.IsCancelled && !now.Before(lesson.StartsAt) && now.Before(lesson.EndsAt)
+}
+
+func (widget *Timetable) Render() template.HTML {
+	return widget.render(widget, assets.TimetableTemplate)
+}
@@ -0,0 +1,191 @@
+//go:build !slim || widget_to_do
+
+package widget
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/glanceapp/glance/internal/assets"
+)
+
+func init() {
+	register("to-do", func() Widget { return &ToDo{} })
+}
+
+const (
+	toDoMaxItems       = 200
+	toDoMaxTextLength  = 500
+	toDoMaxNotesLength = 20000
+	// enough for the maximum amount of items and notes along with the JSON around them
+	toDoMaxRequestSize = 256 << 10
+)
+
+var errToDoVersionConflict = errors.New("the list was changed somewhere else")
+
+type toDoItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// The version gets incremented on every change so that changes made
+// from an outdated copy of the list, e.g. in another browser, get rejected
+type toDoState struct {
+	Version int        `json:"version"`
+	Items   []toDoItem `json:"items"`
+	Notes   string     `json:"notes"`
+}
+
+func (s *toDoState) validate() error {
+	if len(s.Items) > toDoMaxItems {
+		return fmt.Errorf("cannot have more than %d items", toDoMaxItems)
+	}
+
+	for i := range s.Items {
+		s.Items[i].Text = strings.TrimSpace(s.Items[i].Text)
+
+		if s.Items[i].Text == "" {
+			return errors.New("items cannot be empty")
+		}
+
+		if utf8.RuneCountInString(s.Items[i].Text) > toDoMaxTextLength {
+			return fmt.Errorf("items cannot be longer than %d characters", toDoMaxTextLength)
+		}
+	}
+
+	if utf8.RuneCountInString(s.Notes) > toDoMaxNotesLength {
+		return fmt.Errorf("notes cannot be longer than %d characters", toDoMaxNotesLength)
+	}
+
+	return nil
+}
+
+type ToDo struct {
+	widgetBase `yaml:",inline"`
+	StorageKey string    `yaml:"storage-key"`
+	ShowNotes  bool      `yaml:"show-notes"`
+	State      toDoState `yaml:"-"`
+}
+
+func (widget *ToDo) Initialize() error {
+	widget.withTitle("To-do").withError(nil)
+
+	if widget.StorageKey == "" {
+		widget.StorageKey = "default"
+	}
+
+	return nil
+}
+
+// Widgets with the same storage key show the same list,
+// so the state isn't kept by the widget itself
+func (widget *ToDo) storageKey() string {
+	return "to-do:" + widget.StorageKey
+}
+
+func (widget *ToDo) Render() template.HTML {
+	widget.State = toDoState{}
+
+	if err := widget.Providers.Storage.get(widget.storageKey(), &widget.State); err != nil {
+		slog.Error("Failed to read to-do list", "key", widget.StorageKey, "error", err)
+	}
+
+	return widget.render(widget, assets.ToDoTemplate)
+}
+
+// GET /items returns the list, POST /items adds an item to it and PUT /items replaces
+// all of it as long as the version in the request matches the current one
+func (widget *ToDo) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("path") != "items" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.Header().Set("Allow", "GET, POST, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var state toDoState
+	var invalid, err error
+
+	switch r.Method {
+	case http.MethodGet:
+		err = widget.Providers.Storage.get(widget.storageKey(), &state)
+	case http.MethodPost:
+		var item toDoItem
+
+		if err := decodeJSONRequest(w, r, &item, toDoMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+			state.Items = append(state.Items, item)
+
+			if invalid = state.validate(); invalid != nil {
+				return invalid
+			}
+
+			state.Version++
+			return nil
+		})
+	case http.MethodPut:
+		var replacement toDoState
+
+		if err := decodeJSONRequest(w, r, &replacement, toDoMaxRequestSize); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := replacement.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = widget.Providers.Storage.update(widget.storageKey(), &state, func() error {
+			if replacement.Version != state.Version {
+				return errToDoVersionConflict
+			}
+
+			state = replacement
+			state.Version++
+			return nil
+		})
+	}
+
+	if invalid != nil {
+		http.Error(w, invalid.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if state.Items == nil {
+		state.Items = []toDoItem{}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if errors.Is(err, errToDoVersionConflict) {
+		// the current list is sent back so that the browser can show it
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(&state)
+		return
+	}
+
+	if err != nil {
+		slog.Error("Failed to update to-do list", "key", widget.StorageKey, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&state)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_twitch_channels
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("twitch-channels", func() Widget { return &TwitchChannels{} })
+}
+
 type TwitchChannels struct {
 	widgetBase      `yaml:",inline"`
 	ChannelsRequest []string             `yaml:"channels"`
@@ -35,7 +41,7 @@ func (widget *TwitchChannels) Initialize() error {
 }
 
 func (widget *TwitchChannels) Update(ctx context.Context) {
-	channels, err := feed.FetchChannelsFromTwitch(widget.ChannelsRequest)
+	channels, err := feed.FetchChannelsFromTwitch(ctx, widget.ChannelsRequest)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -53,3 +59,11 @@ func (widget *TwitchChannels) Update(ctx context.Context) {
 func (widget *TwitchChannels) Render() template.HTML {
 	return widget.render(widget, assets.TwitchChannelsTemplate)
 }
+
+func (widget *TwitchChannels) resourceHints(hints *ResourceHints) {
+	hints.addOrigin("https://static-cdn.jtvnw.net")
+
+	for i := 0; i < len(widget.Channels) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Channels[i].AvatarUrl)
+	}
+}
@@ -0,0 +1,79 @@
+//go:build !slim || widget_twitch_followed_channels
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("twitch-followed-channels", func() Widget { return &TwitchFollowedChannels{} })
+}
+
+type TwitchFollowedChannels struct {
+	widgetBase    `yaml:",inline"`
+	AccessToken   OptionalEnvString       `yaml:"access-token"`
+	RefreshToken  OptionalEnvString       `yaml:"refresh-token"`
+	ClientID      OptionalEnvString       `yaml:"client-id"`
+	ClientSecret  OptionalEnvString       `yaml:"client-secret"`
+	CollapseAfter int                     `yaml:"collapse-after"`
+	Channels      []feed.TwitchChannel    `yaml:"-"`
+	client        *feed.TwitchHelixClient `yaml:"-"`
+}
+
+func (widget *TwitchFollowedChannels) Initialize() error {
+	widget.
+		withTitle("Followed Channels").
+		withTitleURL("https://www.twitch.tv/directory/following").
+		withCacheDuration(time.Minute * 5)
+
+	if widget.AccessToken == "" {
+		return errors.New("access-token must be specified for twitch-followed-channels widget")
+	}
+
+	if widget.RefreshToken != "" && (widget.ClientID == "" || widget.ClientSecret == "") {
+		return errors.New("client-id and client-secret must be specified in order to use a refresh-token in twitch-followed-channels widget")
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	widget.client = &feed.TwitchHelixClient{
+		AccessToken:  widget.AccessToken.String(),
+		RefreshToken: widget.RefreshToken.String(),
+		ClientID:     widget.ClientID.String(),
+		ClientSecret: widget.ClientSecret.String(),
+	}
+
+	return nil
+}
+
+func (widget *TwitchFollowedChannels) Update(ctx context.Context) {
+	channels, err := feed.FetchFollowedLiveChannelsFromTwitch(ctx, widget.client)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	channels.SortByViewers()
+	widget.Channels = channels
+}
+
+func (widget *TwitchFollowedChannels) Render() template.HTML {
+	return widget.render(widget, assets.TwitchChannelsTemplate)
+}
+
+func (widget *TwitchFollowedChannels) resourceHints(hints *ResourceHints) {
+	hints.addOrigin("https://static-cdn.jtvnw.net")
+
+	for i := 0; i < len(widget.Channels) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Channels[i].AvatarUrl)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_twitch_top_games
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("twitch-top-games", func() Widget { return &TwitchGames{} })
+}
+
 type TwitchGames struct {
 	widgetBase    `yaml:",inline"`
 	Categories    []feed.TwitchCategory `yaml:"-"`
@@ -35,7 +41,7 @@ func (widget *TwitchGames) Initialize() error {
 }
 
 func (widget *TwitchGames) Update(ctx context.Context) {
-	categories, err := feed.FetchTopGamesFromTwitch(widget.Exclude, widget.Limit)
+	categories, err := feed.FetchTopGamesFromTwitch(ctx, widget.Exclude, widget.Limit)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -47,3 +53,11 @@ func (widget *TwitchGames) Update(ctx context.Context) {
 func (widget *TwitchGames) Render() template.HTML {
 	return widget.render(widget, assets.TwitchGamesListTemplate)
 }
+
+func (widget *TwitchGames) resourceHints(hints *ResourceHints) {
+	hints.addOrigin("https://static-cdn.jtvnw.net")
+
+	for i := 0; i < len(widget.Categories) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Categories[i].AvatarUrl)
+	}
+}
@@ -0,0 +1,17 @@
+package widget
+
+import (
+	"fmt"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func withDefaultUnits(units *feed.UnitSystem) error {
+	if *units == "" {
+		*units = initializationSettings().Units
+	} else if !units.IsValid() {
+		return fmt.Errorf("invalid units '%s', must be either metric or imperial", *units)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+//go:build !slim || widget_ups
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("ups", func() Widget { return &UPS{} })
+}
+
+// How often the UPS gets checked for notifications, regardless of whether anyone has the page open
+const upsWatchInterval = 30 * time.Second
+
+type UPS struct {
+	widgetBase `yaml:",inline"`
+	Service    string          `yaml:"service"`
+	Address    string          `yaml:"address"`
+	Name       string          `yaml:"ups"`
+	Ntfy       *ntfyOptions    `yaml:"ntfy"`
+	Status     *feed.UPSStatus `yaml:"-"`
+	watchMu    sync.Mutex      `yaml:"-"`
+	watched    *feed.UPSStatus `yaml:"-"`
+}
+
+func (widget *UPS) Initialize() error {
+	widget.withTitle("UPS").withCacheDuration(1 * time.Minute)
+
+	switch widget.Service {
+	case feed.UPSServiceNUT, feed.UPSServiceApcupsd:
+	default:
+		return errors.New("ups service must be either 'nut' or 'apcupsd'")
+	}
+
+	if widget.Address == "" {
+		widget.Address = "localhost"
+	}
+
+	if widget.Ntfy != nil {
+		if err := widget.Ntfy.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Power outages have to be noticed even when nobody is looking at the page,
+// so the UPS is watched in the background for as long as the widget is in use
+func (widget *UPS) SetProviders(providers *Providers) {
+	widget.widgetBase.SetProviders(providers)
+
+	if widget.Ntfy != nil && providers.Context != nil {
+		go widget.watch(providers.Context)
+	}
+}
+
+func (widget *UPS) fetch(ctx context.Context) (*feed.UPSStatus, error) {
+	if widget.Service == feed.UPSServiceNUT {
+		return feed.FetchUPSStatusFromNUT(ctx, widget.Address, widget.Name)
+	}
+
+	return feed.FetchUPSStatusFromApcupsd(ctx, widget.Address)
+}
+
+func (widget *UPS) Update(ctx context.Context) {
+	status, err := widget.fetch(ctx)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Status = status
+}
+
+func (widget *UPS) watch(ctx context.Context) {
+	ticker := time.NewTicker(upsWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		// the widget shows why it can't be reached, there's nothing to notify about
+		if status, err := widget.fetch(ctx); err == nil {
+			widget.notifyOnChange(status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends a notification when the UPS switches to its battery or back, or when the battery gets
+// low. An outage that's already going on when Glance starts gets a notification as well
+func (widget *UPS) notifyOnChange(status *feed.UPSStatus) {
+	widget.watchMu.Lock()
+	previous := widget.watched
+	widget.watched = status
+	widget.watchMu.Unlock()
+
+	if previous == nil {
+		previous = &feed.UPSStatus{}
+	}
+
+	var notification *feed.NtfyNotification
+
+	switch {
+	case status.OnBattery && status.LowBattery && !previous.LowBattery:
+		notification = &feed.NtfyNotification{Title: widget.Title + " battery is low", Tags: "warning"}
+	case status.OnBattery && !previous.OnBattery:
+		notification = &feed.NtfyNotification{Title: widget.Title + " is on battery", Tags: "battery"}
+	case !status.OnBattery && previous.OnBattery:
+		notification = &feed.NtfyNotification{Title: "Power is back", Message: widget.Title + " is running on line power again", Tags: "electric_plug"}
+	default:
+		return
+	}
+
+	if status.OnBattery {
+		notification.Message = upsBatteryMessage(status)
+	}
+
+	widget.Ntfy.send(notification, "widget", "ups", "address", widget.Address)
+}
+
+func upsBatteryMessage(status *feed.UPSStatus) string {
+	var parts []string
+
+	if status.Charge >= 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% charge", status.Charge))
+	}
+
+	if status.Runtime > 0 {
+		parts = append(parts, fmt.Sprintf("about %d minutes left", int(status.Runtime.Minutes())))
+	}
+
+	if len(parts) == 0 {
+		return "Running on battery"
+	}
+
+	return "Running on battery with " + strings.Join(parts, " and ")
+}
+
+func (widget *UPS) Render() template.HTML {
+	return widget.render(widget, assets.UPSTemplate)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_videos
+
 package widget
 
 import (
@@ -9,6 +11,10 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("videos", func() Widget { return &Videos{} })
+}
+
 type Videos struct {
 	widgetBase        `yaml:",inline"`
 	Videos            feed.Videos `yaml:"-"`
@@ -35,7 +41,7 @@ func (widget *Videos) Initialize() error {
 }
 
 func (widget *Videos) Update(ctx context.Context) {
-	videos, err := feed.FetchYoutubeChannelUploads(widget.Channels, widget.VideoUrlTemplate, widget.IncludeShorts)
+	videos, err := feed.FetchYoutubeChannelUploads(ctx, widget.Channels, widget.VideoUrlTemplate, widget.IncludeShorts)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -55,3 +61,16 @@ func (widget *Videos) Render() template.HTML {
 
 	return widget.render(widget, assets.VideosTemplate)
 }
+
+func (widget *Videos) styleField() (*string, []string) {
+	return &widget.Style, []string{"horizontal-cards", "grid-cards"}
+}
+
+func (widget *Videos) resourceHints(hints *ResourceHints) {
+	// known before the widget has any data
+	hints.addOrigin("https://i.ytimg.com")
+
+	for i := 0; i < len(widget.Videos) && i < prefetchedImagesPerWidget; i++ {
+		hints.addImage(widget.Videos[i].ThumbnailUrl)
+	}
+}
@@ -0,0 +1,112 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The layout of the page as reported by the browser when requesting its content
+type Layout string
+
+const (
+	LayoutMobile  Layout = "mobile"
+	LayoutDesktop Layout = "desktop"
+)
+
+func ParseLayout(value string) Layout {
+	if value == string(LayoutMobile) || value == string(LayoutDesktop) {
+		return Layout(value)
+	}
+
+	return ""
+}
+
+type hideOnLayouts []Layout
+
+func (h *hideOnLayouts) UnmarshalYAML(node *yaml.Node) error {
+	var values []string
+
+	if err := node.Decode(&values); err != nil {
+		var value string
+
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		values = []string{value}
+	}
+
+	for _, value := range values {
+		layout := ParseLayout(value)
+
+		if layout == "" {
+			return fmt.Errorf("invalid hide-on value '%s', must be either mobile or desktop", value)
+		}
+
+		*h = append(*h, layout)
+	}
+
+	return nil
+}
+
+// A time window in the format of HH:MM-HH:MM, stored as minutes since midnight.
+// The end can be earlier than the start for windows that span past midnight
+type TimeWindow struct {
+	start int
+	end   int
+	isSet bool
+}
+
+func (t *TimeWindow) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	startValue, endValue, found := strings.Cut(value, "-")
+
+	if !found {
+		return fmt.Errorf("invalid time window '%s', must be in the format of HH:MM-HH:MM", value)
+	}
+
+	start, err := time.Parse("15:04", strings.TrimSpace(startValue))
+
+	if err != nil {
+		return fmt.Errorf("invalid time window '%s', must be in the format of HH:MM-HH:MM", value)
+	}
+
+	end, err := time.Parse("15:04", strings.TrimSpace(endValue))
+
+	if err != nil {
+		return fmt.Errorf("invalid time window '%s', must be in the format of HH:MM-HH:MM", value)
+	}
+
+	t.start = start.Hour()*60 + start.Minute()
+	t.end = end.Hour()*60 + end.Minute()
+	t.isSet = true
+
+	return nil
+}
+
+// Unlike contains, a window that isn't set contains no times at all
+func (t *TimeWindow) active(now time.Time) bool {
+	return t.isSet && t.contains(now)
+}
+
+func (t *TimeWindow) contains(now time.Time) bool {
+	if !t.isSet || t.start == t.end {
+		return true
+	}
+
+	minutes := now.Hour()*60 + now.Minute()
+
+	if t.start < t.end {
+		return minutes >= t.start && minutes < t.end
+	}
+
+	return minutes >= t.start || minutes < t.end
+}
@@ -0,0 +1,113 @@
+package widget
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+// Implemented by widgets which can show the data for the location of the visitor
+// rather than the one in their config, returning nil when they aren't set up to
+type visitorLocated interface {
+	visitorCopies() *visitorCopies
+	copyForPlace(place *feed.PlaceJson) Widget
+}
+
+const maxVisitorCopies = 50
+
+// The copies of a widget for each of the places that its visitors are in, which
+// get updated and cached the same way as the widget they were copied from
+type visitorCopies struct {
+	mu      sync.Mutex
+	byPlace map[string]*visitorCopy
+}
+
+type visitorCopy struct {
+	widget Widget
+	usedAt time.Time
+}
+
+func newVisitorCopies() *visitorCopies {
+	return &visitorCopies{byPlace: make(map[string]*visitorCopy)}
+}
+
+// Returns the copy of the widget for the place of the visitor, which still needs
+// to be updated before being rendered, or the widget itself if it doesn't use it
+func ForVisitor(widget Widget, place *feed.PlaceJson) Widget {
+	located, ok := widget.(visitorLocated)
+
+	if !ok || place == nil {
+		return widget
+	}
+
+	copies := located.visitorCopies()
+
+	if copies == nil {
+		return widget
+	}
+
+	// rounded to roughly 10km so that visitors near each other
+	// share the same copy, and with it the same requests
+	key := fmt.Sprintf("%.1f,%.1f", place.Latitude, place.Longitude)
+
+	copies.mu.Lock()
+	defer copies.mu.Unlock()
+
+	if existing, exists := copies.byPlace[key]; exists {
+		existing.usedAt = time.Now()
+		return existing.widget
+	}
+
+	// the copy that was used the longest time ago makes room for the new one
+	if len(copies.byPlace) >= maxVisitorCopies {
+		var oldest string
+
+		for k, c := range copies.byPlace {
+			if oldest == "" || c.usedAt.Before(copies.byPlace[oldest].usedAt) {
+				oldest = k
+			}
+		}
+
+		delete(copies.byPlace, oldest)
+	}
+
+	copied := located.copyForPlace(place)
+	copies.byPlace[key] = &visitorCopy{widget: copied, usedAt: time.Now()}
+
+	return copied
+}
+
+func UsesVisitorLocation(widget Widget) bool {
+	located, ok := widget.(visitorLocated)
+
+	return ok && located.visitorCopies() != nil
+}
+
+// Copies the properties from the config and the ones set during initialization,
+// leaving out the state from updates so that the copy gets its own
+func (w *widgetBase) copyConfigTo(other *widgetBase) {
+	other.ID = w.ID
+	other.Slug = w.Slug
+	other.Providers = w.Providers
+	other.Type = w.Type
+	other.Title = w.Title
+	other.TitleURL = w.TitleURL
+	other.HeaderActions = w.HeaderActions
+	other.CSSClass = w.CSSClass
+	other.CustomCacheDuration = w.CustomCacheDuration
+	other.HideOn = w.HideOn
+	other.MobileHide = w.MobileHide
+	other.MobileOrder = w.MobileOrder
+	other.HideWhenEmpty = w.HideWhenEmpty
+	other.ShowBetween = w.ShowBetween
+	other.QuietHours = w.QuietHours
+	other.StaleAfter = w.StaleAfter
+	other.RequestTimeout = w.RequestTimeout
+	other.HideHeader = w.HideHeader
+	other.Frameless = w.Frameless
+	other.cacheDuration = w.cacheDuration
+	other.cacheType = w.cacheType
+	other.configLine = w.configLine
+}
@@ -0,0 +1,135 @@
+//go:build !slim || widget_vpn_peers
+
+package widget
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("vpn-peers", func() Widget { return &VPNPeers{} })
+}
+
+type vpnPeersSource struct {
+	Type          string            `yaml:"type"`
+	Name          string            `yaml:"name"`
+	URL           string            `yaml:"url"`
+	Token         OptionalEnvString `yaml:"token"`
+	AllowInsecure bool              `yaml:"allow-insecure"`
+	Socket        string            `yaml:"socket"`
+	APIKey        OptionalEnvString `yaml:"api-key"`
+	Tailnet       string            `yaml:"tailnet"`
+	// the names of WireGuard peers by their public key
+	PeerNames   map[string]string `yaml:"peer-names"`
+	Peers       []feed.VPNPeer    `yaml:"-"`
+	Online      int               `yaml:"-"`
+	Total       int               `yaml:"-"`
+	Unreachable bool              `yaml:"-"`
+}
+
+type VPNPeers struct {
+	widgetBase  `yaml:",inline"`
+	Sources     []vpnPeersSource `yaml:"sources"`
+	HideOffline bool             `yaml:"hide-offline"`
+	requests    []*feed.VPNPeersRequest
+}
+
+func (widget *VPNPeers) Initialize() error {
+	widget.withTitle("VPN Peers").withCacheDuration(time.Minute)
+
+	if len(widget.Sources) == 0 {
+		widget.Sources = []vpnPeersSource{{Type: "local"}}
+	}
+
+	widget.requests = make([]*feed.VPNPeersRequest, len(widget.Sources))
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+
+		if source.Type == "" {
+			source.Type = "local"
+		}
+
+		switch source.Type {
+		case "local", "tailscale":
+		case "remote":
+			if source.URL == "" {
+				return fmt.Errorf("missing url for remote source in vpn-peers widget")
+			}
+		default:
+			return fmt.Errorf("invalid type '%s' for source in vpn-peers widget, must be one of local, remote or tailscale", source.Type)
+		}
+
+		widget.requests[i] = &feed.VPNPeersRequest{
+			URL:             source.URL,
+			Token:           source.Token.String(),
+			AllowInsecure:   source.AllowInsecure,
+			Tailscale:       source.Type == "tailscale",
+			TailscaleSocket: source.Socket,
+			TailscaleAPIKey: source.APIKey.String(),
+			Tailnet:         source.Tailnet,
+		}
+	}
+
+	return nil
+}
+
+func (widget *VPNPeers) Update(ctx context.Context) {
+	peers, err := feed.FetchVPNPeersForSources(ctx, widget.requests)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	for i := range widget.Sources {
+		source := &widget.Sources[i]
+		source.Unreachable = peers[i] == nil
+		source.Online = 0
+		source.Total = len(peers[i])
+		source.Peers = make([]feed.VPNPeer, 0, len(peers[i]))
+
+		for _, peer := range peers[i] {
+			if peer.Name == "" {
+				peer.Name = source.PeerNames[peer.PublicKey]
+			}
+
+			// the start of the key is what's usually enough to tell peers apart
+			if peer.Name == "" && len(peer.PublicKey) > 8 {
+				peer.Name = peer.PublicKey[:8] + "…"
+			}
+
+			if peer.Online {
+				source.Online++
+			} else if widget.HideOffline {
+				continue
+			}
+
+			source.Peers = append(source.Peers, peer)
+		}
+
+		// the connected ones first
+		slices.SortFunc(source.Peers, func(a, b feed.VPNPeer) int {
+			if a.Online != b.Online {
+				if a.Online {
+					return -1
+				}
+
+				return 1
+			}
+
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	}
+}
+
+func (widget *VPNPeers) Render() template.HTML {
+	return widget.render(widget, assets.VPNPeersTemplate)
+}
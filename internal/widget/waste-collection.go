@@ -0,0 +1,179 @@
+//go:build !slim || widget_waste_collection
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("waste-collection", func() Widget { return &WasteCollection{} })
+}
+
+type wasteCollectionBin struct {
+	Name  string
+	Color *HSLColorField
+}
+
+type wasteCollectionDay struct {
+	Date time.Time
+	Bins []wasteCollectionBin
+}
+
+type WasteCollection struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Source            string            `yaml:"source"`
+	URL               OptionalEnvString `yaml:"url"`
+	PlaceID           string            `yaml:"place-id"`
+	ServiceID         string            `yaml:"service-id"`
+	BinTypes          []struct {
+		Name  string         `yaml:"name"`
+		Match []string       `yaml:"match"`
+		Color *HSLColorField `yaml:"color"`
+	} `yaml:"bins"`
+	Limit       int                  `yaml:"limit"`
+	Collections []wasteCollectionDay `yaml:"-"`
+}
+
+func (widget *WasteCollection) Initialize() error {
+	widget.withTitle("Waste Collection").withCacheDuration(6 * time.Hour)
+
+	switch widget.Source {
+	case "ics":
+		if widget.URL == "" {
+			return errors.New("url must be specified for ics in waste collection widget")
+		}
+	case "recollect":
+		if widget.PlaceID == "" || widget.ServiceID == "" {
+			return errors.New("place-id and service-id must be specified for recollect in waste collection widget")
+		}
+	default:
+		return errors.New("source for waste collection widget must be either ics or recollect")
+	}
+
+	for i := range widget.BinTypes {
+		if widget.BinTypes[i].Name == "" {
+			return errors.New("missing name for bin in waste collection widget")
+		}
+
+		if len(widget.BinTypes[i].Match) == 0 {
+			widget.BinTypes[i].Match = []string{widget.BinTypes[i].Name}
+		}
+	}
+
+	if widget.Limit <= 0 {
+		widget.Limit = 4
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("waste collection widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *WasteCollection) Update(ctx context.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	// most places collect each bin at least once a month, so this is plenty
+	until := today.AddDate(0, 0, 60)
+
+	var collections []feed.WasteCollection
+	var err error
+
+	if widget.Source == "ics" {
+		collections, err = feed.FetchICSWasteCollections(ctx, widget.client, widget.URL.String(), today, until, time.Local)
+	} else {
+		collections, err = feed.FetchRecollectCollections(ctx, widget.client, widget.PlaceID, widget.ServiceID, today, until, time.Local)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	days := make([]wasteCollectionDay, 0, widget.Limit)
+
+	for i := range collections {
+		if collections[i].Date.Before(today) {
+			continue
+		}
+
+		if len(days) == widget.Limit {
+			break
+		}
+
+		day := wasteCollectionDay{Date: collections[i].Date}
+
+		for _, bin := range collections[i].Bins {
+			day.Bins = appendWasteCollectionBin(day.Bins, widget.binFor(bin))
+		}
+
+		days = append(days, day)
+	}
+
+	widget.Collections = days
+}
+
+// Sources name the bins differently, such as "Residual waste collection", so they're
+// shown with the name and color of the first configured bin that matches
+func (widget *WasteCollection) binFor(name string) wasteCollectionBin {
+	lowered := strings.ToLower(name)
+
+	for i := range widget.BinTypes {
+		for _, match := range widget.BinTypes[i].Match {
+			if strings.Contains(lowered, strings.ToLower(match)) {
+				return wasteCollectionBin{Name: widget.BinTypes[i].Name, Color: widget.BinTypes[i].Color}
+			}
+		}
+	}
+
+	return wasteCollectionBin{Name: name}
+}
+
+func appendWasteCollectionBin(bins []wasteCollectionBin, bin wasteCollectionBin) []wasteCollectionBin {
+	for i := range bins {
+		if bins[i].Name == bin.Name {
+			return bins
+		}
+	}
+
+	return append(bins, bin)
+}
+
+func (widget *WasteCollection) DayLabel(date time.Time) string {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	switch {
+	case date.Equal(today):
+		return "Today"
+	case date.Equal(today.AddDate(0, 0, 1)):
+		return "Tomorrow"
+	case date.Before(today.AddDate(0, 0, 7)):
+		return date.Format("Monday")
+	}
+
+	return date.Format("Monday, Jan 2")
+}
+
+// The bins have to be put out the evening before, which is worked out when rendering
+// so that it doesn't depend on when the widget was last updated
+func (widget *WasteCollection) PutOutTonight(date time.Time) bool {
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
+
+	return date.Equal(tomorrow)
+}
+
+func (widget *WasteCollection) Render() template.HTML {
+	return widget.render(widget, assets.WasteCollectionTemplate)
+}
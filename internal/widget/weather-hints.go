@@ -0,0 +1,307 @@
+//go:build !slim || widget_weather_hints
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"slices"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register("weather-hints", func() Widget { return &WeatherHints{} })
+}
+
+var weatherConditions = []string{"clear", "partly-cloudy", "cloudy", "fog", "rain", "snow", "thunderstorm"}
+
+// A time of day in the format of HH:MM, stored as minutes since midnight
+type weatherRuleTime struct {
+	minutes int
+	isSet   bool
+}
+
+func (t *weatherRuleTime) UnmarshalYAML(node *yaml.Node) error {
+	var value string
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse("15:04", value)
+
+	if err != nil {
+		return fmt.Errorf("invalid time '%s', must be in the format of HH:MM", value)
+	}
+
+	t.minutes = parsed.Hour()*60 + parsed.Minute()
+	t.isSet = true
+
+	return nil
+}
+
+type weatherRuleRange struct {
+	Above *float64 `yaml:"above"`
+	Below *float64 `yaml:"below"`
+}
+
+func (r *weatherRuleRange) contains(value int) bool {
+	if r == nil {
+		return true
+	}
+
+	if r.Above != nil && float64(value) <= *r.Above {
+		return false
+	}
+
+	if r.Below != nil && float64(value) >= *r.Below {
+		return false
+	}
+
+	return true
+}
+
+type weatherRule struct {
+	Hint                     string            `yaml:"hint"`
+	Icon                     CustomIcon        `yaml:"icon"`
+	Day                      string            `yaml:"day"`
+	After                    weatherRuleTime   `yaml:"after"`
+	Before                   weatherRuleTime   `yaml:"before"`
+	Match                    string            `yaml:"match"`
+	Temperature              *weatherRuleRange `yaml:"temperature"`
+	PrecipitationProbability *weatherRuleRange `yaml:"precipitation-probability"`
+	WindSpeed                *weatherRuleRange `yaml:"wind-speed"`
+	Conditions               []string          `yaml:"conditions"`
+}
+
+func (r *weatherRule) validate() error {
+	if r.Hint == "" {
+		return errors.New("missing hint")
+	}
+
+	if r.Day == "" {
+		r.Day = "today"
+	} else if r.Day != "today" && r.Day != "tomorrow" {
+		return fmt.Errorf("invalid day '%s', must be either today or tomorrow", r.Day)
+	}
+
+	if r.Match == "" {
+		r.Match = "any"
+	} else if r.Match != "any" && r.Match != "all" {
+		return fmt.Errorf("invalid match '%s', must be either any or all", r.Match)
+	}
+
+	if r.After.isSet && r.Before.isSet && r.After.minutes >= r.Before.minutes {
+		return errors.New("after must be earlier than before")
+	}
+
+	for _, condition := range r.Conditions {
+		if !slices.Contains(weatherConditions, condition) {
+			return fmt.Errorf("invalid condition '%s'", condition)
+		}
+	}
+
+	return nil
+}
+
+func (r *weatherRule) isWithinPeriod(hour *feed.WeatherForecastHour, now time.Time) bool {
+	day := now
+
+	if r.Day == "tomorrow" {
+		day = now.AddDate(0, 0, 1)
+	}
+
+	if hour.Time.YearDay() != day.YearDay() || hour.Time.Year() != day.Year() {
+		return false
+	}
+
+	// hours that have already passed are still part of the forecast but shouldn't be considered
+	if r.Day == "today" && hour.Time.Hour() < now.Hour() {
+		return false
+	}
+
+	minutes := hour.Time.Hour()*60 + hour.Time.Minute()
+
+	if r.After.isSet && minutes < r.After.minutes {
+		return false
+	}
+
+	if r.Before.isSet && minutes >= r.Before.minutes {
+		return false
+	}
+
+	return true
+}
+
+func (r *weatherRule) matchesHour(hour *feed.WeatherForecastHour) bool {
+	if len(r.Conditions) > 0 && !slices.Contains(r.Conditions, hour.Icon()) {
+		return false
+	}
+
+	return r.Temperature.contains(hour.Temperature) &&
+		r.PrecipitationProbability.contains(hour.PrecipitationProbability) &&
+		r.WindSpeed.contains(hour.WindSpeed)
+}
+
+// Returns the first hour that matched the rule, or nil if the rule didn't match. When the rule
+// requires all hours to match, the first hour within the period is returned.
+func (r *weatherRule) evaluate(hours []feed.WeatherForecastHour, now time.Time) *feed.WeatherForecastHour {
+	var first *feed.WeatherForecastHour
+
+	for i := range hours {
+		hour := &hours[i]
+
+		if !r.isWithinPeriod(hour, now) {
+			continue
+		}
+
+		if r.matchesHour(hour) {
+			if first == nil {
+				first = hour
+			}
+		} else if r.Match == "all" {
+			return nil
+		}
+	}
+
+	return first
+}
+
+type weatherHint struct {
+	Text       string
+	Icon       CustomIcon
+	Time       time.Time
+	IsTomorrow bool
+	ShowTime   bool
+}
+
+type WeatherHints struct {
+	widgetBase      `yaml:",inline"`
+	Location        string          `yaml:"location"`
+	VisitorLocation bool            `yaml:"visitor-location"`
+	Units           feed.UnitSystem `yaml:"units"`
+	Language        string          `yaml:"language"`
+	HourFormat      string          `yaml:"hour-format"`
+	Rules           []weatherRule   `yaml:"rules"`
+	Place           *feed.PlaceJson `yaml:"-"`
+	Hints           []weatherHint   `yaml:"-"`
+	timeFormat      string          `yaml:"-"`
+	visitors        *visitorCopies  `yaml:"-"`
+}
+
+func (widget *WeatherHints) Initialize() error {
+	widget.withTitle("Weather Hints").withCacheOnTheHour()
+
+	if widget.Location == "" {
+		return errors.New("location must be specified for weather-hints widget")
+	}
+
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("weather-hints widget: %v", err)
+	}
+
+	if widget.HourFormat == "" || widget.HourFormat == "12h" {
+		widget.timeFormat = "3pm"
+	} else if widget.HourFormat == "24h" {
+		widget.timeFormat = "15:04"
+	} else {
+		return fmt.Errorf("invalid hour format '%s' for weather-hints widget, must be either 12h or 24h", widget.HourFormat)
+	}
+
+	if len(widget.Rules) == 0 {
+		return errors.New("no rules specified for weather-hints widget")
+	}
+
+	for i := range widget.Rules {
+		if err := widget.Rules[i].validate(); err != nil {
+			return fmt.Errorf("invalid rule %d in weather-hints widget: %v", i+1, err)
+		}
+	}
+
+	if widget.VisitorLocation {
+		widget.visitors = newVisitorCopies()
+	}
+
+	return nil
+}
+
+func (widget *WeatherHints) Update(ctx context.Context) {
+	if widget.Place == nil {
+		place, err := feed.FetchPlaceFromName(ctx, widget.Location, widget.Language)
+
+		if err != nil {
+			widget.withError(err).scheduleEarlyUpdate()
+			return
+		}
+
+		widget.Place = place
+	}
+
+	hours, err := feed.FetchHourlyForecastForPlace(ctx, widget.Place, widget.Units)
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	now := time.Now()
+
+	if len(hours) > 0 {
+		now = now.In(hours[0].Time.Location())
+	}
+
+	hints := make([]weatherHint, 0, len(widget.Rules))
+
+	for i := range widget.Rules {
+		rule := &widget.Rules[i]
+		hour := rule.evaluate(hours, now)
+
+		if hour == nil {
+			continue
+		}
+
+		hints = append(hints, weatherHint{
+			Text:       rule.Hint,
+			Icon:       rule.Icon,
+			Time:       hour.Time,
+			IsTomorrow: rule.Day == "tomorrow",
+			ShowTime:   rule.Match == "any",
+		})
+	}
+
+	widget.Hints = hints
+}
+
+func (widget *WeatherHints) visitorCopies() *visitorCopies {
+	return widget.visitors
+}
+
+func (widget *WeatherHints) copyForPlace(place *feed.PlaceJson) Widget {
+	copied := &WeatherHints{
+		Location:        place.Name,
+		VisitorLocation: widget.VisitorLocation,
+		Units:           widget.Units,
+		Language:        widget.Language,
+		HourFormat:      widget.HourFormat,
+		Rules:           widget.Rules,
+		Place:           place,
+		timeFormat:      widget.timeFormat,
+	}
+
+	widget.copyConfigTo(&copied.widgetBase)
+
+	return copied
+}
+
+func (widget *WeatherHints) FormatTime(t time.Time) string {
+	return t.Format(widget.timeFormat)
+}
+
+func (widget *WeatherHints) Render() template.HTML {
+	return widget.render(widget, assets.WeatherHintsTemplate)
+}
@@ -1,3 +1,5 @@
+//go:build !slim || widget_weather
+
 package widget
 
 import (
@@ -9,16 +11,27 @@ import (
 	"github.com/glanceapp/glance/internal/feed"
 )
 
+func init() {
+	register("weather", func() Widget { return &Weather{} })
+}
+
 type Weather struct {
-	widgetBase   `yaml:",inline"`
-	Location     string          `yaml:"location"`
-	ShowAreaName bool            `yaml:"show-area-name"`
-	HideLocation bool            `yaml:"hide-location"`
-	HourFormat   string          `yaml:"hour-format"`
-	Units        string          `yaml:"units"`
-	Place        *feed.PlaceJson `yaml:"-"`
-	Weather      *feed.Weather   `yaml:"-"`
-	TimeLabels   [12]string      `yaml:"-"`
+	widgetBase      `yaml:",inline"`
+	Location        string               `yaml:"location"`
+	VisitorLocation bool                 `yaml:"visitor-location"`
+	ShowAreaName    bool                 `yaml:"show-area-name"`
+	HideLocation    bool                 `yaml:"hide-location"`
+	HourFormat      string               `yaml:"hour-format"`
+	Units           feed.UnitSystem      `yaml:"units"`
+	Language        string               `yaml:"language"`
+	ForecastDays    int                  `yaml:"forecast-days"`
+	Provider        string               `yaml:"provider"`
+	APIKey          OptionalEnvString    `yaml:"api-key"`
+	Place           *feed.PlaceJson      `yaml:"-"`
+	Weather         *feed.Weather        `yaml:"-"`
+	TimeLabels      [12]string           `yaml:"-"`
+	provider        feed.WeatherProvider `yaml:"-"`
+	visitors        *visitorCopies       `yaml:"-"`
 }
 
 var timeLabels12h = [12]string{"2am", "4am", "6am", "8am", "10am", "12pm", "2pm", "4pm", "6pm", "8pm", "10pm", "12am"}
@@ -39,10 +52,24 @@ func (widget *Weather) Initialize() error {
 		return fmt.Errorf("invalid hour format '%s' for weather widget, must be either 12h or 24h", widget.HourFormat)
 	}
 
-	if widget.Units == "" {
-		widget.Units = "metric"
-	} else if widget.Units != "metric" && widget.Units != "imperial" {
-		return fmt.Errorf("invalid units '%s' for weather, must be either metric or imperial", widget.Units)
+	if err := withDefaultUnits(&widget.Units); err != nil {
+		return fmt.Errorf("weather widget: %v", err)
+	}
+
+	provider, err := feed.NewWeatherProvider(widget.Provider, widget.APIKey.String())
+
+	if err != nil {
+		return fmt.Errorf("weather widget: %v", err)
+	}
+
+	widget.provider = provider
+
+	if widget.VisitorLocation {
+		widget.visitors = newVisitorCopies()
+	}
+
+	if maxDays := provider.MaxForecastDays(); widget.ForecastDays < 0 || widget.ForecastDays > maxDays {
+		return fmt.Errorf("invalid forecast-days '%d' for weather widget, must be between 0 and %d", widget.ForecastDays, maxDays)
 	}
 
 	return nil
@@ -50,7 +77,7 @@ func (widget *Weather) Initialize() error {
 
 func (widget *Weather) Update(ctx context.Context) {
 	if widget.Place == nil {
-		place, err := feed.FetchPlaceFromName(widget.Location)
+		place, err := feed.FetchPlaceFromName(ctx, widget.Location, widget.Language)
 
 		if err != nil {
 			widget.withError(err).scheduleEarlyUpdate()
@@ -60,7 +87,7 @@ func (widget *Weather) Update(ctx context.Context) {
 		widget.Place = place
 	}
 
-	weather, err := feed.FetchWeatherForPlace(widget.Place, widget.Units)
+	weather, err := widget.provider.FetchWeather(ctx, widget.Place, widget.Units, widget.ForecastDays)
 
 	if !widget.canContinueUpdateAfterHandlingErr(err) {
 		return
@@ -69,6 +96,48 @@ func (widget *Weather) Update(ctx context.Context) {
 	widget.Weather = weather
 }
 
+func (widget *Weather) visitorCopies() *visitorCopies {
+	return widget.visitors
+}
+
+func (widget *Weather) copyForPlace(place *feed.PlaceJson) Widget {
+	copied := &Weather{
+		Location:        place.Name,
+		VisitorLocation: widget.VisitorLocation,
+		ShowAreaName:    widget.ShowAreaName,
+		HideLocation:    widget.HideLocation,
+		HourFormat:      widget.HourFormat,
+		Units:           widget.Units,
+		Language:        widget.Language,
+		ForecastDays:    widget.ForecastDays,
+		Provider:        widget.Provider,
+		APIKey:          widget.APIKey,
+		Place:           place,
+		TimeLabels:      widget.TimeLabels,
+		provider:        widget.provider,
+	}
+
+	widget.copyConfigTo(&copied.widgetBase)
+
+	return copied
+}
+
+func (widget *Weather) sharedData() any {
+	if widget.Weather == nil {
+		return nil
+	}
+
+	return map[string]any{
+		"location":             widget.Location,
+		"units":                widget.Units,
+		"temperature":          widget.Weather.Temperature,
+		"apparent-temperature": widget.Weather.ApparentTemperature,
+		"weather-code":         widget.Weather.WeatherCode,
+		"condition":            feed.WeatherCodeAsString(widget.Weather.WeatherCode),
+		"icon":                 feed.WeatherCodeAsIcon(widget.Weather.WeatherCode),
+	}
+}
+
 func (widget *Weather) Render() template.HTML {
 	return widget.render(widget, assets.WeatherTemplate)
 }
@@ -0,0 +1,22 @@
+package widget
+
+import "net/http"
+
+// Implemented by widgets which can receive data through /api/webhooks/{token}
+type webhookReceiver interface {
+	webhookToken() string
+	handleWebhook(w http.ResponseWriter, r *http.Request)
+}
+
+// Returns an empty string if the widget doesn't receive webhooks
+func WebhookToken(widget Widget) string {
+	if receiver, ok := widget.(webhookReceiver); ok {
+		return receiver.webhookToken()
+	}
+
+	return ""
+}
+
+func HandleWebhook(widget Widget, w http.ResponseWriter, r *http.Request) {
+	widget.(webhookReceiver).handleWebhook(w, r)
+}
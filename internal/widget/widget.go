@@ -9,6 +9,8 @@ import (
 	"log/slog"
 	"math"
 	"net/http"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,62 +21,26 @@ import (
 
 var uniqueID atomic.Uint64
 
+// Filled by the init functions of the files of the widgets, so that builds which leave
+// some of them out through build tags don't know about the ones that were left out
+var constructors = make(map[string]func() Widget)
+
+func register(widgetType string, constructor func() Widget) {
+	if _, exists := constructors[widgetType]; exists {
+		panic("widget type registered twice: " + widgetType)
+	}
+
+	constructors[widgetType] = constructor
+}
+
 func New(widgetType string) (Widget, error) {
-	var widget Widget
-
-	switch widgetType {
-	case "calendar":
-		widget = &Calendar{}
-	case "clock":
-		widget = &Clock{}
-	case "weather":
-		widget = &Weather{}
-	case "bookmarks":
-		widget = &Bookmarks{}
-	case "iframe":
-		widget = &IFrame{}
-	case "html":
-		widget = &HTML{}
-	case "hacker-news":
-		widget = &HackerNews{}
-	case "releases":
-		widget = &Releases{}
-	case "videos":
-		widget = &Videos{}
-	case "markets", "stocks":
-		widget = &Markets{}
-	case "reddit":
-		widget = &Reddit{}
-	case "rss":
-		widget = &RSS{}
-	case "monitor":
-		widget = &Monitor{}
-	case "twitch-top-games":
-		widget = &TwitchGames{}
-	case "twitch-channels":
-		widget = &TwitchChannels{}
-	case "lobsters":
-		widget = &Lobsters{}
-	case "change-detection":
-		widget = &ChangeDetection{}
-	case "repository":
-		widget = &Repository{}
-	case "search":
-		widget = &Search{}
-	case "extension":
-		widget = &Extension{}
-	case "group":
-		widget = &Group{}
-	case "dns-stats":
-		widget = &DNSStats{}
-	case "split-column":
-		widget = &SplitColumn{}
-	case "custom-api":
-		widget = &CustomApi{}
-	default:
+	constructor, exists := constructors[widgetType]
+
+	if !exists {
 		return nil, fmt.Errorf("unknown widget type: %s", widgetType)
 	}
 
+	widget := constructor()
 	widget.SetID(uniqueID.Add(1))
 
 	return widget, nil
@@ -101,19 +67,64 @@ func (w *Widgets) UnmarshalYAML(node *yaml.Node) error {
 		widget, err := New(meta.Type)
 
 		if err != nil {
-			return err
+			return fmt.Errorf("line %d: %v", node.Line, err)
 		}
 
 		if err = node.Decode(widget); err != nil {
 			return err
 		}
 
+		widget.(interface{ setConfigLine(int) }).setConfigLine(node.Line)
+
 		*w = append(*w, widget)
 	}
 
 	return nil
 }
 
+// Points to the widget in the config file that failed to initialize
+type InitializeError struct {
+	Line int
+	Type string
+	Err  error
+}
+
+func (e *InitializeError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s widget: %v", e.Type, e.Err)
+	}
+
+	return fmt.Sprintf("line %d: %s widget: %v", e.Line, e.Type, e.Err)
+}
+
+func (e *InitializeError) Unwrap() error {
+	return e.Err
+}
+
+// Initializes the widget, adding its location in the config file to the error
+// unless it came from a widget nested inside of it which already has it
+func Initialize(widget Widget) error {
+	err := widget.Initialize()
+
+	if err == nil {
+		return nil
+	}
+
+	var initializeErr *InitializeError
+
+	if errors.As(err, &initializeErr) {
+		return err
+	}
+
+	line := 0
+
+	if located, ok := widget.(interface{ getConfigLine() int }); ok {
+		line = located.getConfigLine()
+	}
+
+	return &InitializeError{Line: line, Type: widget.GetType(), Err: err}
+}
+
 type Widget interface {
 	Initialize() error
 	RequiresUpdate(*time.Time) bool
@@ -121,10 +132,14 @@ type Widget interface {
 	Update(context.Context)
 	Render() template.HTML
 	GetType() string
+	GetTitle() string
 	GetID() uint64
 	SetID(uint64)
+	GetSlug() string
+	SetSlug(string)
 	HandleRequest(w http.ResponseWriter, r *http.Request)
 	SetHideHeader(bool)
+	IsVisible(now time.Time, layout Layout) bool
 }
 
 type cacheType int
@@ -135,27 +150,59 @@ const (
 	cacheTypeOnTheHour
 )
 
+// The number of consecutive failed updates after which the error is shown
+// instead of the content from the last successful update
+const defaultStaleAfter = 3
+
 type widgetBase struct {
 	ID                  uint64        `yaml:"-"`
+	Slug                string        `yaml:"id"`
 	Providers           *Providers    `yaml:"-"`
 	Type                string        `yaml:"type"`
 	Title               string        `yaml:"title"`
 	TitleURL            string        `yaml:"title-url"`
+	HeaderActions       headerActions `yaml:"header-actions"`
 	CSSClass            string        `yaml:"css-class"`
 	CustomCacheDuration DurationField `yaml:"cache"`
+	ShareAs             string        `yaml:"share-as"`
+	HideOn              hideOnLayouts `yaml:"hide-on"`
+	MobileHide          bool          `yaml:"mobile-hide"`
+	MobileOrder         int           `yaml:"mobile-order"`
+	HideWhenEmpty       bool          `yaml:"hide-when-empty"`
+	ShowBetween         TimeWindow    `yaml:"show-between"`
+	QuietHours          TimeWindow    `yaml:"quiet-hours"`
+	StaleAfter          int           `yaml:"stale-after"`
+	RequestTimeout      DurationField `yaml:"request-timeout"`
 	ContentAvailable    bool          `yaml:"-"`
+	Empty               bool          `yaml:"-"`
 	Error               error         `yaml:"-"`
 	Notice              error         `yaml:"-"`
+	StaleSince          time.Time     `yaml:"-"`
 	templateBuffer      bytes.Buffer  `yaml:"-"`
 	cacheDuration       time.Duration `yaml:"-"`
 	cacheType           cacheType     `yaml:"-"`
 	nextUpdate          time.Time     `yaml:"-"`
 	updateRetriedTimes  int           `yaml:"-"`
-	HideHeader          bool          `yaml:"-"`
+	configLine          int           `yaml:"-"`
+	failedUpdates       int           `yaml:"-"`
+	lastSuccessfulAt    time.Time     `yaml:"-"`
+	HideHeader          bool          `yaml:"hide-header"`
+	Frameless           bool          `yaml:"frameless"`
+	updateMu            sync.Mutex    `yaml:"-"`
+	updates             atomic.Uint64 `yaml:"-"`
+	stats               updateStats   `yaml:"-"`
 }
 
 type Providers struct {
 	AssetResolver func(string) string
+	DataBus       *DataBus
+	History       *HistoryStore
+	Storage       *Storage
+	// nil when no GeoIP database is configured
+	GeoIP *feed.GeoIPDatabase
+	// cancelled once the widgets get replaced because the config was reloaded,
+	// for widgets that keep doing something in the background
+	Context context.Context
 }
 
 func (w *widgetBase) RequiresUpdate(now *time.Time) bool {
@@ -167,9 +214,33 @@ func (w *widgetBase) RequiresUpdate(now *time.Time) bool {
 		return true
 	}
 
+	// the data from before the quiet hours keeps being shown until they end
+	if w.quietHours().active(*now) {
+		return false
+	}
+
 	return now.After(w.nextUpdate)
 }
 
+func (w *widgetBase) quietHours() *TimeWindow {
+	if w.QuietHours.isSet {
+		return &w.QuietHours
+	}
+
+	return &appliedSettings.Load().QuietHours
+}
+
+// Whether the widget would have been updated by now if it wasn't for quiet hours
+func (w *widgetBase) IsPausedForQuietHours() bool {
+	now := time.Now()
+
+	return w.quietHours().active(now) && !w.nextUpdate.IsZero() && now.After(w.nextUpdate)
+}
+
+func (w *widgetBase) LastSuccessfulUpdate() time.Time {
+	return w.lastSuccessfulAt
+}
+
 func (w *widgetBase) Update(ctx context.Context) {
 
 }
@@ -182,6 +253,38 @@ func (w *widgetBase) SetID(id uint64) {
 	w.ID = id
 }
 
+// Unlike the ID it stays the same between restarts, either set through
+// the id property or made from the page and type of the widget
+func (w *widgetBase) GetSlug() string {
+	return w.Slug
+}
+
+func (w *widgetBase) SetSlug(slug string) {
+	w.Slug = slug
+}
+
+// Hidden widgets are neither updated nor rendered, an empty
+// layout means that the layout of the page isn't known
+func (w *widgetBase) IsVisible(now time.Time, layout Layout) bool {
+	if layout != "" && slices.Contains(w.HideOn, layout) {
+		return false
+	}
+
+	if layout == LayoutMobile && w.MobileHide {
+		return false
+	}
+
+	return w.ShowBetween.contains(now)
+}
+
+func (w *widgetBase) setConfigLine(line int) {
+	w.configLine = line
+}
+
+func (w *widgetBase) getConfigLine() int {
+	return w.configLine
+}
+
 func (w *widgetBase) SetHideHeader(value bool) {
 	w.HideHeader = value
 }
@@ -190,15 +293,48 @@ func (widget *widgetBase) HandleRequest(w http.ResponseWriter, r *http.Request)
 	http.Error(w, "not implemented", http.StatusNotImplemented)
 }
 
+func (w *widgetBase) sharedAs() string {
+	return w.ShareAs
+}
+
+// The deadline of each update, covering all of the requests it makes
+func (w *widgetBase) requestTimeout() time.Duration {
+	return time.Duration(w.RequestTimeout)
+}
+
+func (w *widgetBase) updateMutex() *sync.Mutex {
+	return &w.updateMu
+}
+
+func (w *widgetBase) updateCounter() *atomic.Uint64 {
+	return &w.updates
+}
+
+func (w *widgetBase) cachesForever() bool {
+	return w.cacheType == cacheTypeInfinite
+}
+
 func (w *widgetBase) GetType() string {
 	return w.Type
 }
 
+func (w *widgetBase) GetTitle() string {
+	return w.Title
+}
+
 func (w *widgetBase) SetProviders(providers *Providers) {
 	w.Providers = providers
 }
 
+func (w *widgetBase) hasFreshContent() bool {
+	return w.ContentAvailable && w.Error == nil
+}
+
 func (w *widgetBase) render(data any, t *template.Template) template.HTML {
+	if widget, ok := data.(Widget); ok {
+		w.Empty = IsEmpty(widget)
+	}
+
 	w.templateBuffer.Reset()
 	err := t.Execute(&w.templateBuffer, data)
 
@@ -293,22 +429,55 @@ func (w *widgetBase) canContinueUpdateAfterHandlingErr(err error) bool {
 		w.scheduleEarlyUpdate()
 
 		if !errors.Is(err, feed.ErrPartialContent) {
+			w.failedUpdates++
+
+			// the widget keeps the data from the last successful update since
+			// it doesn't get overwritten when returning early, so that can be
+			// shown for a while rather than the error
+			if w.ContentAvailable && w.Error == nil && w.failedUpdates < w.staleAfter() {
+				w.StaleSince = w.lastSuccessfulAt
+				w.withNotice(err)
+				return false
+			}
+
+			w.ContentAvailable = false
+			w.StaleSince = time.Time{}
 			w.withError(err)
 			w.withNotice(nil)
 			return false
 		}
 
+		w.markUpdateSuccessful()
 		w.withError(nil)
 		w.withNotice(err)
 		return true
 	}
 
+	w.markUpdateSuccessful()
 	w.withNotice(nil)
 	w.withError(nil)
 	w.scheduleNextUpdate()
 	return true
 }
 
+func (w *widgetBase) staleAfter() int {
+	if w.StaleAfter <= 0 {
+		return defaultStaleAfter
+	}
+
+	return w.StaleAfter
+}
+
+func (w *widgetBase) markUpdateSuccessful() {
+	w.failedUpdates = 0
+	w.lastSuccessfulAt = time.Now()
+	w.StaleSince = time.Time{}
+}
+
+func (w *widgetBase) IsStale() bool {
+	return !w.StaleSince.IsZero()
+}
+
 func (w *widgetBase) getNextUpdateTime() time.Time {
 	now := time.Now()
 
@@ -332,6 +501,12 @@ func (w *widgetBase) scheduleNextUpdate() *widgetBase {
 	return w
 }
 
+func (w *widgetBase) expire() {
+	if !w.nextUpdate.IsZero() {
+		w.nextUpdate = time.Now()
+	}
+}
+
 func (w *widgetBase) scheduleEarlyUpdate() *widgetBase {
 	w.updateRetriedTimes++
 
@@ -0,0 +1,158 @@
+//go:build !slim || widget_wikipedia
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("wikipedia", func() Widget { return &Wikipedia{} })
+}
+
+var wikipediaLanguagePattern = regexp.MustCompile(`^[a-z]+$`)
+
+const (
+	// how long the articles of a category or portal are kept before getting them again
+	wikipediaTitlesMaxAge = 24 * time.Hour
+	// random articles can be disambiguation pages, which are skipped
+	wikipediaMaxAttempts = 3
+)
+
+type Wikipedia struct {
+	widgetBase        `yaml:",inline"`
+	httpClientOptions `yaml:",inline"`
+	Category          string                 `yaml:"category"`
+	Portal            string                 `yaml:"portal"`
+	Article           *feed.WikipediaArticle `yaml:"-"`
+	titles            []string
+	titlesFetchedAt   time.Time
+	// the subdomain of the Wikipedia to use, taken from the language of the client options
+	edition string
+}
+
+func (widget *Wikipedia) Initialize() error {
+	widget.withTitle("Wikipedia").withCacheDuration(time.Hour)
+
+	// regional variants such as fr-CA share the same Wikipedia
+	widget.edition, _, _ = strings.Cut(strings.ToLower(widget.Language), "-")
+
+	if widget.edition == "" {
+		widget.edition = "en"
+	} else if !wikipediaLanguagePattern.MatchString(widget.edition) {
+		return fmt.Errorf("invalid language '%s' for wikipedia widget, must be a language code such as en", widget.Language)
+	}
+
+	if widget.Category != "" && widget.Portal != "" {
+		return errors.New("only one of category or portal can be specified for wikipedia widget")
+	}
+
+	if err := widget.initializeClient(); err != nil {
+		return fmt.Errorf("wikipedia widget: %v", err)
+	}
+
+	return nil
+}
+
+func (widget *Wikipedia) fetchTitles(ctx context.Context) error {
+	if widget.titles != nil && time.Since(widget.titlesFetchedAt) < wikipediaTitlesMaxAge {
+		return nil
+	}
+
+	var titles []string
+	var err error
+
+	if widget.Category != "" {
+		titles, err = feed.FetchWikipediaCategoryTitles(ctx, widget.client, widget.edition, widget.Category)
+	} else {
+		titles, err = feed.FetchWikipediaPortalTitles(ctx, widget.client, widget.edition, widget.Portal)
+	}
+
+	if err != nil {
+		// an older list is better than none
+		if widget.titles != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	widget.titles = titles
+	widget.titlesFetchedAt = time.Now()
+
+	return nil
+}
+
+func (widget *Wikipedia) Update(ctx context.Context) {
+	fromList := widget.Category != "" || widget.Portal != ""
+
+	if fromList {
+		if err := widget.fetchTitles(ctx); err != nil && !widget.canContinueUpdateAfterHandlingErr(err) {
+			return
+		}
+	}
+
+	var article *feed.WikipediaArticle
+	var err error
+
+	for range wikipediaMaxAttempts {
+		title := ""
+
+		if fromList {
+			title = widget.titles[rand.IntN(len(widget.titles))]
+
+			// try not to show the same article twice in a row
+			if widget.Article != nil && title == widget.Article.Title && len(widget.titles) > 1 {
+				continue
+			}
+		}
+
+		article, err = feed.FetchWikipediaArticle(ctx, widget.client, widget.edition, title)
+
+		if err == nil {
+			break
+		}
+	}
+
+	if article == nil && err == nil {
+		err = fmt.Errorf("%w: no article found", feed.ErrNoContent)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	widget.Article = article
+}
+
+// POST /next makes the widget show another article the next time it gets rendered
+func (widget *Wikipedia) HandleRequest(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("path") != "next" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ExpireCache(widget)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (widget *Wikipedia) Render() template.HTML {
+	return widget.render(widget, assets.WikipediaTemplate)
+}
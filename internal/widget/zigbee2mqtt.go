@@ -0,0 +1,116 @@
+//go:build !slim || widget_zigbee2mqtt
+
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/glanceapp/glance/internal/assets"
+	"github.com/glanceapp/glance/internal/feed"
+)
+
+func init() {
+	register("zigbee2mqtt", func() Widget { return &Zigbee2MQTT{} })
+}
+
+type zigbee2MQTTDevice struct {
+	feed.Zigbee2MQTTDevice
+	LowBattery    bool
+	PoorLink      bool
+	NotSeenLately bool
+}
+
+func (d *zigbee2MQTTDevice) severity() int {
+	switch {
+	case d.Offline || d.NotSeenLately:
+		return 2
+	case d.LowBattery:
+		return 1
+	}
+
+	return 0
+}
+
+type Zigbee2MQTT struct {
+	widgetBase           `yaml:",inline"`
+	URL                  URLField            `yaml:"url"`
+	Token                OptionalEnvString   `yaml:"token"`
+	AllowInsecure        bool                `yaml:"allow-insecure"`
+	BatteryThreshold     int                 `yaml:"battery-threshold"`
+	LinkQualityThreshold int                 `yaml:"link-quality-threshold"`
+	UnseenAfter          DurationField       `yaml:"unseen-after"`
+	CollapseAfter        int                 `yaml:"collapse-after"`
+	TotalDevices         int                 `yaml:"-"`
+	Devices              []zigbee2MQTTDevice `yaml:"-"`
+}
+
+func (widget *Zigbee2MQTT) Initialize() error {
+	widget.withTitle("Zigbee Devices").withCacheDuration(15 * time.Minute)
+
+	if widget.URL == "" {
+		return errors.New("url must be specified for zigbee2mqtt widget")
+	}
+
+	widget.withTitleURL(string(widget.URL))
+
+	if widget.BatteryThreshold <= 0 {
+		widget.BatteryThreshold = 20
+	}
+
+	if widget.LinkQualityThreshold <= 0 {
+		widget.LinkQualityThreshold = 30
+	}
+
+	if widget.UnseenAfter == 0 {
+		widget.UnseenAfter = DurationField(24 * time.Hour)
+	}
+
+	if widget.CollapseAfter == 0 || widget.CollapseAfter < -1 {
+		widget.CollapseAfter = 5
+	}
+
+	return nil
+}
+
+func (widget *Zigbee2MQTT) Update(ctx context.Context) {
+	devices, err := feed.FetchZigbee2MQTTDevices(ctx, string(widget.URL), widget.Token.String(), widget.AllowInsecure)
+
+	if err != nil {
+		err = fmt.Errorf("%w: %v", feed.ErrNoContent, err)
+	}
+
+	if !widget.canContinueUpdateAfterHandlingErr(err) {
+		return
+	}
+
+	unseenBefore := time.Now().Add(-time.Duration(widget.UnseenAfter))
+	unhealthy := make([]zigbee2MQTTDevice, 0)
+
+	for i := range devices {
+		device := zigbee2MQTTDevice{Zigbee2MQTTDevice: devices[i]}
+		device.LowBattery = device.BatteryLow || (device.Battery >= 0 && device.Battery < widget.BatteryThreshold)
+		device.PoorLink = device.LinkQuality >= 0 && device.LinkQuality < widget.LinkQualityThreshold
+		device.NotSeenLately = !device.LastSeen.IsZero() && device.LastSeen.Before(unseenBefore)
+
+		if device.Offline || device.LowBattery || device.PoorLink || device.NotSeenLately {
+			unhealthy = append(unhealthy, device)
+		}
+	}
+
+	// the ones which are gone or about to be first
+	sort.SliceStable(unhealthy, func(a, b int) bool {
+		return unhealthy[a].severity() > unhealthy[b].severity()
+	})
+
+	widget.TotalDevices = len(devices)
+	widget.Devices = unhealthy
+}
+
+func (widget *Zigbee2MQTT) Render() template.HTML {
+	return widget.render(widget, assets.Zigbee2MQTTTemplate)
+}